@@ -1,15 +1,47 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/checkpoint"
 	"github.com/andrearaponi/bombardino/pkg/config"
+	"github.com/andrearaponi/bombardino/pkg/dashboard"
+	"github.com/andrearaponi/bombardino/pkg/debuglog"
 	"github.com/andrearaponi/bombardino/pkg/engine"
+	"github.com/andrearaponi/bombardino/pkg/history"
+	"github.com/andrearaponi/bombardino/pkg/logging"
+	"github.com/andrearaponi/bombardino/pkg/mock"
 	"github.com/andrearaponi/bombardino/pkg/progress"
+	"github.com/andrearaponi/bombardino/pkg/pushgateway"
+	"github.com/andrearaponi/bombardino/pkg/record"
+	"github.com/andrearaponi/bombardino/pkg/redact"
+	"github.com/andrearaponi/bombardino/pkg/remotedata"
+	"github.com/andrearaponi/bombardino/pkg/replay"
+	"github.com/andrearaponi/bombardino/pkg/reportdiff"
 	"github.com/andrearaponi/bombardino/pkg/reporter"
+	"github.com/andrearaponi/bombardino/pkg/scaffold"
+	"github.com/andrearaponi/bombardino/pkg/secrets"
+	"github.com/andrearaponi/bombardino/pkg/server"
+	"github.com/andrearaponi/bombardino/pkg/stream"
+	"github.com/andrearaponi/bombardino/pkg/upload"
 )
 
 // Build-time variables (set via ldflags)
@@ -20,32 +52,157 @@ var (
 )
 
 func main() {
+	// Subcommands are dispatched before flag parsing
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			os.Exit(runCompareCommand(os.Args[2:]))
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "dashboard":
+			os.Exit(runDashboardCommand(os.Args[2:]))
+		case "history":
+			os.Exit(runHistoryCommand(os.Args[2:]))
+		case "init":
+			os.Exit(runInitCommand(os.Args[2:]))
+		case "mock":
+			os.Exit(runMockCommand(os.Args[2:]))
+		case "record":
+			os.Exit(runRecordCommand(os.Args[2:]))
+		case "serve":
+			os.Exit(runServeCommand(os.Args[2:]))
+		}
+	}
+
 	var (
-		configFile   = flag.String("config", "", "Path to JSON configuration file")
+		configFile   = flag.String("config", "", "Path to JSON configuration file, or - to read it from stdin")
 		workers      = flag.Int("workers", 10, "Number of concurrent workers")
 		verbose      = flag.Bool("verbose", false, "Enable verbose output")
+		quiet        = flag.Bool("quiet", false, "Print only the final summary, without progress bar or per-endpoint details")
+		noColor      = flag.Bool("no-color", false, "Use plain text instead of emoji in the text report (also set by NO_COLOR)")
 		showVersion  = flag.Bool("version", false, "Show version information")
 		outputFormat = flag.String("output", "text", "Output format: text, json, or html")
 		validateOnly = flag.Bool("t", false, "Validate configuration and exit")
+		debugLogFile = flag.String("debug-log", "", "Stream verbose debug logs to a JSONL file")
+		debugLogSize = flag.Int("debug-log-max-size", 0, "Rotate the debug log file after this many MB (0 = no rotation)")
+		debugLogKeep = flag.Int("debug-log-max-backups", 5, "Number of rotated debug log files to keep")
+		sampleRate   = flag.Float64("sample-rate", 1, "Fraction of requests logged in verbose mode (0-1)")
+		samplePerEp  = flag.Int("sample-per-endpoint", 0, "Maximum requests logged per test in verbose mode (0 = unlimited)")
+		logLevel     = flag.String("log-level", "info", "Log level: debug, info, warn, or error (debug when -verbose)")
+		logFormat    = flag.String("log-format", "text", "Log format: text or json")
+		dataCache    = flag.String("data-cache", "", "Directory to cache remote data files in (default: download every run)")
+		dataCacheTTL = flag.Duration("data-cache-ttl", 0, "Re-download cached remote data files older than this (0 = never)")
+		tags         = flag.String("tags", "", "Comma-separated tags; run only tests that have one of them")
+		excludeTags  = flag.String("exclude-tags", "", "Comma-separated tags; skip tests that have one of them")
+		envName      = flag.String("env", "", "Environment from the config's environments section to run against")
+		baseURL      = flag.String("base-url", "", "Override global base_url")
+		iterations   = flag.Int("iterations", 0, "Override global iterations")
+		duration     = flag.Duration("duration", 0, "Override global duration")
+		maxDuration  = flag.Duration("max-duration", 0, "Hard-stop the run after this wall-clock time, e.g. 30m (0 = no limit)")
+		streamEvents = flag.Bool("stream", false, "Stream run events as NDJSON on stdout instead of printing a report")
+		streamEvery  = flag.Duration("stream-interval", time.Second, "Interval between interval_summary events with -stream (0 = none)")
+		summaryEvery = flag.Duration("summary-interval", 10*time.Second, "Interval between summary lines printed when stdout is not a terminal, e.g. in CI logs (0 = none)")
+		runID        = flag.String("run-id", "", "ID of the run, e.g. the CI job ID (default: a generated UUID)")
+		historyDir   = flag.String("history-dir", "", "Store the results of this run in a directory for bombardino history (e.g. "+history.DefaultDir+")")
+		failOn       = flag.String("fail-on", models.FailOnThresholds, "When to exit non-zero: thresholds, errors, assertions, or none")
+		pushURL      = flag.String("pushgateway", "", "Push the metrics of the run to a Prometheus Pushgateway, e.g. http://pushgateway:9091")
+		pushJob      = flag.String("pushgateway-job", pushgateway.DefaultJob, "Job the metrics are pushed under with -pushgateway")
+		reportWindow = flag.String("report-window", "", "Compute the report over the requests started in start:end of the run, e.g. 2m:8m")
+		checkpointTo = flag.String("checkpoint", "", "Save the progress of the run to a file every -checkpoint-interval, for -resume")
+		ckptInterval = flag.Duration("checkpoint-interval", 30*time.Second, "Interval between checkpoints saved with -checkpoint")
+		resumeFrom   = flag.String("resume", "", "Resume the run saved in a checkpoint file, which keeps being updated")
+		seed         = flag.Int64("seed", 0, "Seed of the random choices of the run, to repeat those of a previous one (default: random, shown in the report)")
+		testNames    stringList
+		headers      stringList
+		reportFiles  stringList
+		labels       stringList
 	)
+	flag.Var(&testNames, "test", "Run only the named test and its dependencies (repeatable)")
+	flag.Var(&headers, "header", "Add or override a global header, e.g. \"X-Env: staging\" (repeatable)")
+	flag.Var(&reportFiles, "report-file", "Also write a json, html, or openmetrics report to a file, e.g. json=report.json (repeatable)")
+	flag.Var(&labels, "label", "Label the reports of the run, e.g. team=checkout (repeatable)")
 	flag.Parse()
 
+	if *quiet && *verbose {
+		fmt.Println("❌ Error: -quiet and -verbose cannot be combined")
+		os.Exit(exitConfigError)
+	}
+	if *streamEvents && isFlagSet("output") && *outputFormat != "text" {
+		fmt.Println("❌ Error: -stream writes to stdout and cannot be combined with -output json or html, use -report-file instead")
+		os.Exit(exitConfigError)
+	}
+	if !slices.Contains(models.FailOnPolicies, *failOn) {
+		fmt.Printf("❌ Error: unknown -fail-on policy '%s' (expected %s)\n", *failOn, strings.Join(models.FailOnPolicies, ", "))
+		os.Exit(exitConfigError)
+	}
+
+	// Verbose mode implies debug logging and quiet mode warnings only,
+	// unless a level was given explicitly
+	level := *logLevel
+	if *verbose && !isFlagSet("log-level") {
+		level = "debug"
+	}
+	if *quiet && !isFlagSet("log-level") {
+		level = "warn"
+	}
+	logger, err := logging.New(os.Stderr, level, *logFormat)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	slog.SetDefault(logger)
+	// log.Fatalf and configFatalf report errors, which -quiet must not hide
+	slog.SetLogLoggerLevel(slog.LevelError)
+
 	if *showVersion {
 		printVersion()
 		os.Exit(0)
 	}
 
+	overrides := config.Overrides{
+		BaseURL:    *baseURL,
+		Iterations: *iterations,
+		Duration:   *duration,
+		Headers:    headers,
+	}
+
 	if *validateOnly {
 		if *configFile == "" {
 			fmt.Println("❌ Configuration invalid: -config flag is required")
-			os.Exit(1)
+			os.Exit(exitConfigError)
 		}
-		cfg, err := config.LoadFromFile(*configFile)
+		cfg, _, err := loadConfig(*configFile)
 		if err != nil {
 			fmt.Printf("❌ Configuration invalid: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitConfigError)
+		}
+		if err := config.ApplyEnvironment(cfg, *envName); err != nil {
+			fmt.Printf("❌ Configuration invalid: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if err := config.ApplyOverrides(cfg, overrides); err != nil {
+			fmt.Printf("❌ Configuration invalid: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if err := selectTests(cfg, testNames, *tags, *excludeTags); err != nil {
+			fmt.Printf("❌ Configuration invalid: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if err := config.CheckRequiredVariables(cfg); err != nil {
+			fmt.Printf("❌ Configuration invalid: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if problems := engine.Validate(cfg); len(problems) > 0 {
+			fmt.Printf("❌ Configuration invalid: %d problem(s) found\n", len(problems))
+			for _, problem := range problems {
+				fmt.Printf("  - %v\n", problem)
+			}
+			os.Exit(exitConfigError)
 		}
 		fmt.Printf("✅ Configuration valid: %s (%d tests)\n", cfg.Name, len(cfg.Tests))
+		for _, warning := range engine.Preflight(cfg, *workers) {
+			fmt.Printf("⚠️  Preflight: %s\n", warning)
+		}
 		os.Exit(0)
 	}
 
@@ -56,45 +213,288 @@ func main() {
 		fmt.Println("  bombardino -config=<config.json> [options]")
 		fmt.Println()
 		fmt.Println("Required:")
-		fmt.Println("  -config string    Path to JSON configuration file")
+		fmt.Println("  -config string    Path to JSON configuration file, or - to read it from stdin")
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  compare           Compare two JSON reports and flag regressions")
+		fmt.Println("  config schema     Print the JSON Schema of the configuration format")
+		fmt.Println("  dashboard         Print a Grafana dashboard of the metrics exported with -pushgateway")
+		fmt.Println("  history           Show P95 and error rate trends of runs stored with -history-dir")
+		fmt.Println("  init              Create a starter configuration interactively")
+		fmt.Println("  mock              Serve fake endpoints from a spec, or echo requests")
+		fmt.Println("  record            Proxy traffic to a target and save it as a configuration")
+		fmt.Println("  serve             Start the control API to upload configs and drive runs over HTTP")
 		fmt.Println()
 		fmt.Println("Options:")
 		fmt.Println("  -workers int      Number of concurrent workers (default: 10)")
 		fmt.Println("  -verbose          Enable verbose output (default: false)")
+		fmt.Println("  -quiet            Print only the final summary (no progress bar)")
+		fmt.Println("  -no-color         Plain text instead of emoji in the text report (or NO_COLOR=1)")
 		fmt.Println("  -output string    Output format: text, json, or html (default: text)")
+		fmt.Println("  -stream           Stream run events as NDJSON on stdout instead of a report")
+		fmt.Println("  -stream-interval value Interval between interval_summary events with -stream (default: 1s, 0 = none)")
+		fmt.Println("  -summary-interval value Summary line interval when stdout is not a terminal (default: 10s, 0 = none)")
+		fmt.Println("  -report-file string Also write a report to a file, json=path, html=path, or openmetrics=path (repeatable)")
+		fmt.Println("  -pushgateway string Push the metrics of the run to a Prometheus Pushgateway URL")
+		fmt.Println("  -pushgateway-job string Job the metrics are pushed under (default: bombardino)")
 		fmt.Println("  -t                Validate configuration and exit")
+		fmt.Println("  -debug-log string Stream verbose debug logs to a JSONL file")
+		fmt.Println("  -debug-log-max-size int Rotate the debug log file after this many MB (default: 0, no rotation)")
+		fmt.Println("  -debug-log-max-backups int Number of rotated debug log files to keep (default: 5)")
+		fmt.Println("  -sample-rate float Fraction of requests logged in verbose mode (default: 1)")
+		fmt.Println("  -sample-per-endpoint int Maximum requests logged per test in verbose mode (default: 0, unlimited)")
+		fmt.Println("  -log-level string Log level: debug, info, warn, error (default: info)")
+		fmt.Println("  -log-format string Log format: text or json (default: text)")
+		fmt.Println("  -data-cache string Directory to cache remote data files in")
+		fmt.Println("  -data-cache-ttl value Re-download cached remote data files older than this (default: 0, never)")
+		fmt.Println("  -tags string      Run only tests with one of these comma-separated tags")
+		fmt.Println("  -exclude-tags string Skip tests with one of these comma-separated tags")
+		fmt.Println("  -test string      Run only this test and its dependencies (repeatable)")
+		fmt.Println("  -env string       Environment from the config's environments section")
+		fmt.Println("  -base-url string  Override global base_url")
+		fmt.Println("  -iterations int   Override global iterations")
+		fmt.Println("  -duration value   Override global duration (e.g. 30s, 5m)")
+		fmt.Println("  -max-duration value Hard-stop the run after this wall-clock time (e.g. 30m)")
+		fmt.Println("  -report-window string Compute the report over part of the run, start:end (e.g. 2m:8m)")
+		fmt.Println("  -history-dir string Store the results of the run for bombardino history")
+		fmt.Println("  -fail-on string   When to exit 1: thresholds, errors, assertions, none (default: thresholds)")
+		fmt.Println("  -header string    Add or override a global header, \"Name: value\" (repeatable)")
+		fmt.Println("  -label string     Label the reports of the run, key=value (repeatable)")
+		fmt.Println("  -run-id string    ID of the run, sent in run_id_header (default: a generated UUID)")
+		fmt.Println("  -checkpoint string Save the progress of the run to a file, for -resume")
+		fmt.Println("  -checkpoint-interval value Interval between checkpoints (default: 30s)")
+		fmt.Println("  -resume string    Resume the run saved in a checkpoint file")
+		fmt.Println("  -seed int         Seed of the random choices of the run, to repeat a previous one")
 		fmt.Println("  -version          Show version information")
 		fmt.Println()
 		fmt.Println("Examples:")
 		fmt.Println("  bombardino -config=test.json")
 		fmt.Println("  bombardino -config=test.json -workers=20 -output=json")
+		fmt.Println("  bombardino -config=test.json -report-file=json=report.json -report-file=html=report.html")
+		fmt.Println("  bombardino -config=test.json -pushgateway=http://pushgateway:9091 -pushgateway-job=checkout")
+		fmt.Println("  bombardino -config=test.json -stream -report-file=report.json | jq -c 'select(.event == \"interval_summary\")'")
+		fmt.Println("  bombardino -config=test.json -tags=smoke -exclude-tags=slow")
+		fmt.Println("  bombardino -config=test.json -test=\"Login\"")
+		fmt.Println("  bombardino -config=test.json -base-url=https://staging.example.com -header=\"X-Env: staging\"")
+		fmt.Println("  bombardino -config=test.json -env=staging")
 		fmt.Println("  bombardino -t -config=test.json")
+		fmt.Println("  cat test.json | bombardino -config=-")
+		fmt.Println("  bombardino init -o api-tests.json")
+		fmt.Println("  bombardino mock -port 9090 -spec mock.json")
+		fmt.Println("  bombardino record -listen :8080 -target https://api.example.com -out recorded.json")
+		fmt.Println("  BOMBARDINO_API_TOKEN=secret bombardino serve -listen :8090")
+		fmt.Println("  bombardino compare -max-p95-increase=20 before.json after.json")
+		fmt.Println("  bombardino -config=test.json -history-dir=.bombardino/history")
+		fmt.Println("  bombardino history test.json")
+		fmt.Println("  bombardino config schema > bombardino.schema.json")
 		fmt.Println("  bombardino -version")
-		os.Exit(1)
+		os.Exit(exitConfigError)
+	}
+
+	cfg, configHash, err := loadConfig(*configFile)
+	if err != nil {
+		configFatalf("Failed to load config: %v", err)
+	}
+	if err := config.ApplyEnvironment(cfg, *envName); err != nil {
+		configFatalf("Failed to select environment: %v", err)
+	}
+	if err := config.ApplyOverrides(cfg, overrides); err != nil {
+		configFatalf("Invalid override: %v", err)
+	}
+	if err := selectTests(cfg, testNames, *tags, *excludeTags); err != nil {
+		configFatalf("Failed to select tests: %v", err)
+	}
+	if err := config.CheckRequiredVariables(cfg); err != nil {
+		configFatalf("Invalid configuration: %v", err)
 	}
 
-	cfg, err := config.LoadFromFile(*configFile)
+	var files []reporter.ReportFile
+	for _, value := range reportFiles {
+		file, err := reporter.ParseReportFile(value)
+		if err != nil {
+			configFatalf("Invalid -report-file: %v", err)
+		}
+		files = append(files, file)
+	}
+	if *pushURL != "" {
+		if _, err := pushgateway.GroupURL(*pushURL, *pushJob); err != nil {
+			configFatalf("Invalid -pushgateway: %v", err)
+		}
+	}
+	runLabels, err := parseLabels(labels)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		configFatalf("Invalid -label: %v", err)
+	}
+
+	// Download remote data files (HTTP(S) URLs, S3 URIs) before starting
+	fetcher := remotedata.NewFetcher(*dataCache, *dataCacheTTL)
+	if err := fetcher.ResolveConfig(cfg); err != nil {
+		fetcher.Close()
+		configFatalf("Failed to fetch data files: %v", err)
 	}
 
-	// Only show progress bar for text output
-	var progressBar *progress.ProgressBar
-	if *outputFormat == "text" {
-		progressBar = progress.New(cfg.GetTotalRequests())
+	// A replay sends the requests of its capture, read before the run starts
+	var replayed []replay.Request
+	if cfg.Global.Replay != nil {
+		if replayed, err = replay.Load(cfg.Global.Replay.File); err != nil {
+			configFatalf("Failed to load replay capture: %v", err)
+		}
+	}
+
+	// Only show progress bar for text output on a terminal, so CI logs and
+	// redirected output are not filled with redraws. A capacity search runs
+	// an unknown number of stages, so it gets interval summaries instead.
+	var progressBar progress.Indicator
+	liveOutput := *outputFormat == "text" && !*quiet && !*streamEvents
+	if liveOutput && isTerminal(os.Stdout) && cfg.Global.Capacity == nil {
+		// Runs that last a duration show the time left instead of a guessed request total
+		if replayed != nil {
+			progressBar = progress.New(len(replayed))
+		} else if runDuration := cfg.RunDuration(); runDuration > 0 {
+			progressBar = progress.NewDuration(runDuration)
+		} else {
+			progressBar = progress.New(cfg.GetTotalRequests())
+		}
+		// A line per test makes runs of several tests or phases readable
+		if len(cfg.Tests) > 1 || len(cfg.Scenarios) > 0 {
+			progressBar.ShowTests()
+		}
 	}
 	testEngine := engine.New(*workers, progressBar, *verbose)
+	testEngine.SetLogger(logger)
 
+	// A resumed run keeps saving its progress to the checkpoint it resumed
+	// from, unless -checkpoint names another file
+	checkpointFile := *checkpointTo
+	if *resumeFrom != "" {
+		saved, err := checkpoint.Load(*resumeFrom)
+		if err != nil {
+			configFatalf("Failed to resume: %v", err)
+		}
+		if err := saved.Check(cfg); err != nil {
+			configFatalf("Failed to resume: %v", err)
+		}
+		testEngine.Resume(saved)
+		if checkpointFile == "" {
+			checkpointFile = *resumeFrom
+		}
+	}
+	if checkpointFile != "" {
+		if len(cfg.Scenarios) > 0 || cfg.Global.Loop || cfg.Global.Capacity != nil || cfg.Global.Replay != nil {
+			configFatalf("-checkpoint and -resume cannot be combined with scenarios, loop, capacity, or replay")
+		}
+		if *ckptInterval <= 0 {
+			configFatalf("-checkpoint-interval must be positive")
+		}
+		testEngine.SetCheckpoint(checkpointFile, *ckptInterval)
+	}
+	if *runID != "" {
+		testEngine.SetRunID(*runID)
+	}
+	if replayed != nil {
+		testEngine.SetReplay(replayed)
+	}
+	if isFlagSet("seed") {
+		testEngine.SetSeed(*seed)
+	}
+
+	if *sampleRate <= 0 || *sampleRate > 1 {
+		configFatalf("-sample-rate must be between 0 (exclusive) and 1")
+	}
+	testEngine.SetSampling(*sampleRate, *samplePerEp)
+
+	if *maxDuration < 0 {
+		configFatalf("-max-duration must not be negative")
+	}
+	testEngine.SetMaxDuration(*maxDuration)
+
+	if *reportWindow != "" {
+		window, err := engine.ParseReportWindow(*reportWindow)
+		if err != nil {
+			configFatalf("Invalid -report-window: %v", err)
+		}
+		testEngine.SetReportWindow(window)
+	}
+
+	if *streamEvents {
+		testEngine.SetEventStream(stream.New(os.Stdout, *streamEvery))
+	}
+
+	// Without a progress bar, CI logs get a summary line per interval instead
+	if *summaryEvery < 0 {
+		configFatalf("-summary-interval must not be negative")
+	}
+	if liveOutput && progressBar == nil {
+		testEngine.SetIntervalSummaries(os.Stdout, *summaryEvery)
+	}
+
+	var debugWriter *debuglog.Writer
+	if *debugLogFile != "" {
+		if !*verbose {
+			configFatalf("-debug-log requires -verbose")
+		}
+		writer, err := debuglog.New(*debugLogFile, int64(*debugLogSize)*1024*1024, *debugLogKeep)
+		if err != nil {
+			configFatalf("Failed to open debug log: %v", err)
+		}
+		debugWriter = writer
+		testEngine.SetDebugLogWriter(writer)
+	}
+
+	// Resolve secrets before starting so missing credentials fail fast
+	if len(cfg.Global.Secrets) > 0 {
+		resolved, err := secrets.NewResolver().ResolveAll(cfg.Global.Secrets)
+		if err != nil {
+			configFatalf("Failed to resolve secrets: %v", err)
+		}
+		testEngine.SetSecrets(resolved)
+	}
+
+	// The first Ctrl+C stops the run and still reports the results collected
+	// so far; a second one exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	testEngine.SetContext(ctx)
+
+	// Wait for the target to pass its health check, so a target still
+	// starting up does not turn the run into connection failures
+	if err := testEngine.WaitReady(cfg); err != nil {
+		fetcher.Close()
+		if ctx.Err() != nil {
+			log.Print(err)
+			os.Exit(exitInterrupted)
+		}
+		configFatalf("Target not ready, run aborted: %v", err)
+	}
+
+	slog.Info("run started", "run_id", testEngine.RunID())
+	startedAt := time.Now()
 	results := testEngine.Run(cfg)
+	stop()
+	results.Metadata = runMetadata(cfg, *configFile, configHash, runLabels, startedAt, time.Now())
+
+	if err := fetcher.Close(); err != nil {
+		slog.Warn("failed to remove downloaded data files", "error", err)
+	}
+
+	if debugWriter != nil {
+		if err := debugWriter.Close(); err != nil {
+			slog.Warn("failed to close debug log", "error", err)
+		}
+	}
 
 	// Generate report
 	reporter := reporter.New(*verbose)
-	switch *outputFormat {
-	case "json":
+	reporter.SetQuiet(*quiet)
+	reporter.SetPlain(*noColor || os.Getenv("NO_COLOR") != "")
+	switch {
+	case *streamEvents:
+		// stdout carries the event stream, run_finished holds the summary
+	case *outputFormat == "json":
 		if err := reporter.GenerateJSONReport(results); err != nil {
 			log.Fatalf("Failed to generate JSON report: %v", err)
 		}
-	case "html":
+	case *outputFormat == "html":
 		if err := reporter.GenerateHTMLReport(results); err != nil {
 			log.Fatalf("Failed to generate HTML report: %v", err)
 		}
@@ -102,10 +502,530 @@ func main() {
 		reporter.GenerateReport(results)
 	}
 
-	// Exit with appropriate code based on test results
-	if results.FailedReqs > 0 {
-		os.Exit(1) // Exit with error code if any tests failed
+	for _, file := range files {
+		if err := reporter.WriteReportFile(file, results); err != nil {
+			log.Fatalf("Failed to write %s report: %v", file.Format, err)
+		}
+		slog.Info("report written", "format", file.Format, "path", file.Path)
+	}
+
+	if len(cfg.Global.ReportUpload) > 0 {
+		uploader := upload.New()
+		for _, dest := range cfg.Global.ReportUpload {
+			var buf bytes.Buffer
+			contentType := "application/json"
+			if dest.Format == "html" {
+				contentType = "text/html; charset=utf-8"
+				err = reporter.WriteHTMLReport(&buf, results)
+			} else {
+				err = reporter.WriteJSONReport(&buf, results)
+			}
+			if err != nil {
+				log.Fatalf("Failed to generate %s report: %v", dest.Format, err)
+			}
+			url := upload.Destination(dest.URL, startedAt)
+			if err := uploader.Upload(context.Background(), url, contentType, buf.Bytes()); err != nil {
+				log.Fatalf("Failed to upload %s report to %s: %v", dest.Format, url, err)
+			}
+			slog.Info("report uploaded", "format", dest.Format, "url", url)
+		}
+	}
+
+	if *pushURL != "" {
+		var buf bytes.Buffer
+		if err := reporter.WriteOpenMetricsReport(&buf, results); err != nil {
+			log.Fatalf("Failed to generate metrics: %v", err)
+		}
+		if err := pushgateway.New().Push(context.Background(), *pushURL, *pushJob, buf.Bytes()); err != nil {
+			log.Fatalf("Failed to push metrics to %s: %v", *pushURL, err)
+		}
+		slog.Info("metrics pushed", "pushgateway", *pushURL, "job", *pushJob)
+	}
+
+	if *historyDir != "" {
+		entry := history.Entry{
+			Config:    cfg.Name,
+			Commit:    history.GitCommit(),
+			Timestamp: time.Now(),
+			Report:    reporter.JSONReport(results),
+		}
+		path, err := history.NewStore(*historyDir).Save(entry)
+		if err != nil {
+			slog.Warn("failed to store run history", "error", err)
+		} else {
+			slog.Info("run stored in history", "path", path)
+		}
+	}
+
+	// Exit with appropriate code based on test results and the -fail-on policy
+	if results.Interrupted {
+		os.Exit(exitInterrupted)
+	}
+	if results.FailedUnder(*failOn) {
+		os.Exit(exitFailed)
+	}
+}
+
+// runConfigCommand handles "bombardino config <subcommand>" and returns the exit code
+func runConfigCommand(args []string) int {
+	if len(args) != 1 || args[0] != "schema" {
+		fmt.Println("Usage: bombardino config schema")
+		return exitConfigError
+	}
+
+	schema, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+	fmt.Println(string(schema))
+	return 0
+}
+
+// runDashboardCommand handles "bombardino dashboard": it prints, or writes,
+// a Grafana dashboard of the metrics exported with -pushgateway or
+// -report-file openmetrics=. Returns the exit code.
+func runDashboardCommand(args []string) int {
+	defaults := dashboard.DefaultOptions()
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	title := flags.String("title", defaults.Title, "Title of the dashboard")
+	uid := flags.String("uid", defaults.UID, "UID of the dashboard; importing a dashboard with the same UID replaces it")
+	output := flags.String("o", "", "File to write the dashboard to (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+	if flags.NArg() != 0 {
+		fmt.Println("Usage: bombardino dashboard [-title title] [-uid uid] [-o file]")
+		return exitConfigError
+	}
+
+	data, err := dashboard.Generate(dashboard.Options{Title: *title, UID: *uid})
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitConfigError
+	}
+	if *output == "" {
+		fmt.Println(string(data))
+		return 0
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+	fmt.Printf("✅ Created %s\n", *output)
+	fmt.Println("   Import it in Grafana under Dashboards > New > Import")
+	return 0
+}
+
+// runInitCommand handles "bombardino init": it asks for the basics of a test
+// suite and writes a starter configuration. Returns the exit code.
+func runInitCommand(args []string) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	output := flags.String("o", "bombardino.json", "File to write the configuration to")
+	force := flags.Bool("force", false, "Overwrite the file if it exists")
+	defaults := flags.Bool("y", false, "Accept all defaults without asking")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+
+	if _, err := os.Stat(*output); err == nil && !*force {
+		fmt.Printf("❌ Error: %s already exists (use -force to overwrite)\n", *output)
+		return exitConfigError
 	}
+
+	answers := scaffold.DefaultAnswers()
+	if !*defaults {
+		var err error
+		if answers, err = scaffold.Prompt(os.Stdin, os.Stdout); err != nil {
+			fmt.Printf("\n❌ Error: %v\n", err)
+			return exitFailed
+		}
+	}
+
+	data, err := scaffold.Generate(answers)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+
+	fmt.Printf("✅ Created %s\n", *output)
+	fmt.Printf("   Validate: bombardino -t -config %s\n", *output)
+	fmt.Printf("   Run:      bombardino -config %s\n", *output)
+	return 0
+}
+
+// runMockCommand handles "bombardino mock": it serves the endpoints of a
+// spec file, or echoes requests back without one. Returns the exit code.
+func runMockCommand(args []string) int {
+	flags := flag.NewFlagSet("mock", flag.ContinueOnError)
+	port := flags.Int("port", 9090, "Port to listen on")
+	specFile := flags.String("spec", "", "Path to a JSON mock spec (default: echo every request)")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+
+	var spec *mock.Spec
+	if *specFile != "" {
+		var err error
+		if spec, err = mock.LoadSpec(*specFile); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return exitConfigError
+		}
+		fmt.Printf("🎭 Mock server on http://localhost:%d serving %d routes from %s\n", *port, len(spec.Routes), *specFile)
+	} else {
+		fmt.Printf("🎭 Mock server on http://localhost:%d echoing every request\n", *port)
+	}
+
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), mock.NewServer(spec)); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+	return 0
+}
+
+// runCompareCommand handles "bombardino compare before.json after.json":
+// it exits with 1 when a metric regressed beyond its threshold
+func runCompareCommand(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	thresholds := reportdiff.DefaultThresholds
+	flags.Float64Var(&thresholds.ThroughputDrop, "max-throughput-drop", thresholds.ThroughputDrop, "Requests/sec drop in percent that counts as a regression")
+	flags.Float64Var(&thresholds.P95Increase, "max-p95-increase", thresholds.P95Increase, "P95 increase in percent that counts as a regression")
+	flags.Float64Var(&thresholds.ErrorRateIncrease, "max-error-rate-increase", thresholds.ErrorRateIncrease, "Error rate increase in percentage points that counts as a regression")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: bombardino compare [options] <before.json> <after.json>")
+		return exitConfigError
+	}
+
+	before, err := reportdiff.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitConfigError
+	}
+	after, err := reportdiff.Load(flags.Arg(1))
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitConfigError
+	}
+
+	rows := reportdiff.Compare(before, after, thresholds)
+	if err := reportdiff.Print(os.Stdout, rows); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+
+	regressed := 0
+	for _, row := range rows {
+		if row.Regressed() {
+			regressed++
+		}
+	}
+	fmt.Println()
+	if regressed > 0 {
+		fmt.Printf("❌ %d regression(s) found\n", regressed)
+		return exitFailed
+	}
+	fmt.Println("✅ No regressions")
+	return 0
+}
+
+// runHistoryCommand handles "bombardino history <config>": it prints the
+// trend of the runs stored with -history-dir. Returns the exit code.
+func runHistoryCommand(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	dir := flags.String("dir", history.DefaultDir, "Directory the runs were stored in with -history-dir")
+	limit := flags.Int("limit", 20, "Number of most recent runs to show (0 for all)")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: bombardino history [-dir .bombardino/history] [-limit 20] <config file or name>")
+		return exitConfigError
+	}
+
+	// Runs are keyed by config name, so a config file is resolved to its name
+	name := flags.Arg(0)
+	if _, err := os.Stat(name); err == nil {
+		cfg, err := config.LoadFromFile(name)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return exitConfigError
+		}
+		name = cfg.Name
+	}
+
+	entries, err := history.NewStore(*dir).Load(name, *limit)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitConfigError
+	}
+	if err := history.Render(os.Stdout, entries); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	}
+	return 0
+}
+
+// runServeCommand handles "bombardino serve": it exposes the control API
+// until interrupted, then stops the active run. Returns the exit code.
+func runServeCommand(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", "127.0.0.1:8090", "Address to listen on")
+	workers := flags.Int("workers", 10, "Number of concurrent workers of runs that do not set workers")
+	token := flags.String("token", os.Getenv("BOMBARDINO_API_TOKEN"), "Bearer token required by every request (default: $BOMBARDINO_API_TOKEN)")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+	if *token == "" {
+		if !isLoopback(*listen) {
+			fmt.Printf("❌ Error: -listen %s accepts connections from other hosts; set -token (or $BOMBARDINO_API_TOKEN) or listen on 127.0.0.1\n", *listen)
+			return exitConfigError
+		}
+		slog.Warn("control API has no token, anyone on this host can start runs")
+	}
+
+	api := server.New(*workers, *token, slog.Default())
+	httpServer := &http.Server{Addr: *listen, Handler: api}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() { errs <- httpServer.ListenAndServe() }()
+	fmt.Printf("🛰️  Control API on %s\n", *listen)
+
+	select {
+	case err := <-errs:
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	httpServer.Shutdown(shutdownCtx)
+	api.Shutdown()
+	return 0
+}
+
+// runRecordCommand handles "bombardino record": it proxies traffic to a
+// target until interrupted, then writes the requests seen as a test
+// configuration. Returns the exit code.
+func runRecordCommand(args []string) int {
+	flags := flag.NewFlagSet("record", flag.ContinueOnError)
+	listen := flags.String("listen", ":8080", "Address to listen on")
+	target := flags.String("target", "", "URL of the API to proxy to (required)")
+	output := flags.String("out", "recorded.json", "File to write the configuration to")
+	name := flags.String("name", "Recorded Traffic", "Name of the generated test suite")
+	force := flags.Bool("force", false, "Overwrite the file if it exists")
+	if err := flags.Parse(args); err != nil {
+		return exitConfigError
+	}
+
+	if *target == "" {
+		fmt.Println("Usage: bombardino record -target <url> [-listen :8080] [-out recorded.json]")
+		return exitConfigError
+	}
+	if _, err := os.Stat(*output); err == nil && !*force {
+		fmt.Printf("❌ Error: %s already exists (use -force to overwrite)\n", *output)
+		return exitConfigError
+	}
+
+	recorder, err := record.New(*target)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitConfigError
+	}
+	recorder.OnExchange = func(exchange record.Exchange) {
+		fmt.Printf("● %s %s → %d\n", exchange.Method, exchange.Path, exchange.Status)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: *listen, Handler: recorder}
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+	fmt.Printf("⏺️  Recording %s on %s, press Ctrl+C to stop and write %s\n", *target, *listen, *output)
+
+	select {
+	case err := <-errs:
+		fmt.Printf("❌ Error: %v\n", err)
+		return exitFailed
+	case <-ctx.Done():
+	}
+	server.Shutdown(context.Background())
+
+	data, err := recorder.Config(*name)
+	if err != nil {
+		fmt.Printf("\n❌ Error: %v\n", err)
+		return exitFailed
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Printf("\n❌ Error: %v\n", err)
+		return exitFailed
+	}
+
+	fmt.Printf("\n✅ Recorded %d requests to %s\n", len(recorder.Exchanges()), *output)
+	fmt.Printf("   Validate: bombardino -t -config %s\n", *output)
+	return 0
+}
+
+// Exit codes, so scripts can tell a failed run from a broken setup
+const (
+	exitFailed      = 1   // The run failed under the -fail-on policy
+	exitConfigError = 2   // Invalid flags, configuration, or setup
+	exitInterrupted = 130 // The run was interrupted by SIGINT or SIGTERM
+)
+
+// configFatalf logs a configuration or setup error and exits
+func configFatalf(format string, args ...any) {
+	log.Printf(format, args...)
+	os.Exit(exitConfigError)
+}
+
+// stringList collects the values of a repeatable flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// selectTests narrows the config to the tests chosen with -test, -tags, and -exclude-tags
+func selectTests(cfg *models.Config, names []string, tags, excludeTags string) error {
+	if err := config.SelectByName(cfg, names); err != nil {
+		return err
+	}
+	return config.FilterByTags(cfg, config.ParseList(tags), config.ParseList(excludeTags))
+}
+
+// loadConfig loads the config file at path, or reads the config from stdin
+// when path is "-"
+func loadConfig(path string) (*models.Config, string, error) {
+	if path != "-" {
+		cfg, err := config.LoadFromFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read config: %w", err)
+		}
+		return cfg, hashConfig(data), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config from stdin: %w", err)
+	}
+	cfg, err := config.Parse(data)
+	return cfg, hashConfig(data), err
+}
+
+// hashConfig returns the SHA-256 of a configuration file, to tell runs of
+// different versions of a configuration apart
+func hashConfig(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseLabels parses the key=value labels given with -label
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		key, label, ok := strings.Cut(value, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("'%s' is not key=value", value)
+		}
+		labels[key] = strings.TrimSpace(label)
+	}
+	return labels, nil
+}
+
+// runMetadata describes what produced a run, for its reports
+func runMetadata(cfg *models.Config, configFile, configHash string, labels map[string]string, startedAt, finishedAt time.Time) *models.RunMetadata {
+	hostname, _ := os.Hostname()
+	return &models.RunMetadata{
+		ConfigName: cfg.Name,
+		ConfigFile: configFile,
+		ConfigHash: configHash,
+		Version:    version,
+		Commit:     history.GitCommit(),
+		Hostname:   hostname,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Args:       maskedArgs(os.Args[1:]),
+		Labels:     labels,
+	}
+}
+
+// maskedArgs returns command line arguments with the values of -header
+// masked, as headers often carry credentials
+func maskedArgs(args []string) []string {
+	masked := slices.Clone(args)
+	for i, arg := range masked {
+		name, value, inline := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "header" {
+			continue
+		}
+		if inline {
+			masked[i] = strings.TrimSuffix(arg, value) + maskHeader(value)
+		} else if i+1 < len(masked) {
+			masked[i+1] = maskHeader(masked[i+1])
+		}
+	}
+	return masked
+}
+
+// maskHeader masks the value of a "Name: value" header
+func maskHeader(header string) string {
+	name, _, _ := strings.Cut(header, ":")
+	return strings.TrimSpace(name) + ": " + redact.Mask
+}
+
+// isLoopback reports whether a listen address only accepts connections from
+// this host. An empty host listens on every interface.
+func isLoopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// isFlagSet reports whether a flag was passed explicitly on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
 func printVersion() {
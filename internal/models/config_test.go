@@ -365,3 +365,265 @@ func TestSummary_AllFields(t *testing.T) {
 	assert.Equal(t, statusCodes, summary.StatusCodes)
 	assert.Equal(t, errors, summary.Errors)
 }
+
+func TestEndpointSummary_FailureBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint EndpointSummary
+		passed   bool
+		consumed float64
+	}{
+		{"no failures", EndpointSummary{SuccessfulReqs: 100}, true, 0},
+		{"failures without budget", EndpointSummary{SuccessfulReqs: 99, FailedReqs: 1}, false, 100},
+		{"within budget", EndpointSummary{SuccessfulReqs: 998, FailedReqs: 2, AllowedFailureRate: 0.5}, true, 40},
+		{"over budget", EndpointSummary{SuccessfulReqs: 990, FailedReqs: 10, AllowedFailureRate: 0.5}, false, 200},
+		{"skipped ignored", EndpointSummary{SuccessfulReqs: 199, FailedReqs: 1, SkippedReqs: 800, AllowedFailureRate: 0.5}, true, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.passed, tt.endpoint.Passed())
+			assert.InDelta(t, tt.consumed, tt.endpoint.BudgetConsumed(), 0.001)
+		})
+	}
+}
+
+func TestSummary_Passed(t *testing.T) {
+	summary := &Summary{
+		FailedReqs: 3,
+		EndpointResults: map[string]*EndpointSummary{
+			"flaky":  {SuccessfulReqs: 997, FailedReqs: 3, AllowedFailureRate: 1},
+			"stable": {SuccessfulReqs: 1000},
+		},
+	}
+	assert.True(t, summary.Passed())
+
+	summary.EndpointResults["stable"].FailedReqs = 1
+	assert.False(t, summary.Passed())
+
+	assert.False(t, (&Summary{FailedReqs: 1}).Passed())
+	assert.True(t, (&Summary{}).Passed())
+	assert.False(t, (&Summary{MaxDurationReached: true}).Passed())
+
+	// A capacity search passes once a rate is sustained, whatever failed past it
+	assert.True(t, (&Summary{FailedReqs: 50, Capacity: &CapacitySummary{MaxRPS: 400, BreakingRPS: 800}}).Passed())
+	assert.False(t, (&Summary{Capacity: &CapacitySummary{BreakingRPS: 10}}).Passed())
+}
+
+func TestConfig_ScenarioConfig(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Iterations: 5, ThinkTime: time.Second},
+		Tests: []TestCase{
+			{Name: "browse"},
+			{Name: "search"},
+			{Name: "checkout", Iterations: 2},
+		},
+		Scenarios: []Scenario{{Name: "shoppers"}},
+	}
+
+	sub := config.ScenarioConfig(Scenario{
+		Name:         "buyers",
+		Duration:     time.Minute,
+		ThinkTimeMin: time.Second,
+		ThinkTimeMax: 3 * time.Second,
+		Tests:        []string{"checkout", "browse"},
+	})
+
+	assert.Equal(t, time.Minute, sub.Global.Duration)
+	assert.Equal(t, 0, sub.Global.Iterations)
+	assert.Equal(t, time.Duration(0), sub.Global.ThinkTime)
+	assert.Equal(t, 3*time.Second, sub.Global.ThinkTimeMax)
+	assert.Empty(t, sub.Scenarios)
+	assert.Len(t, sub.Tests, 2)
+	assert.Equal(t, "checkout", sub.Tests[0].Name)
+	assert.Equal(t, 2, sub.Tests[0].Iterations)
+
+	// The original config is untouched
+	assert.Equal(t, 5, config.Global.Iterations)
+	assert.Len(t, config.Tests, 3)
+
+	// Without overrides the scenario inherits everything
+	all := config.ScenarioConfig(Scenario{Name: "all"})
+	assert.Equal(t, 5, all.Global.Iterations)
+	assert.Equal(t, time.Second, all.Global.ThinkTime)
+	assert.Len(t, all.Tests, 3)
+}
+
+func TestConfig_GetTotalRequests_Scenarios(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Iterations: 5},
+		Tests:  []TestCase{{Name: "a"}, {Name: "b"}},
+		Scenarios: []Scenario{
+			{Name: "first", Iterations: 10, Tests: []string{"a"}},
+			{Name: "second"},
+		},
+	}
+
+	// 10 for the first scenario, 5 + 5 for the second
+	assert.Equal(t, 20, config.GetTotalRequests())
+}
+
+func TestConfig_IsHybrid(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Iterations: 100, Duration: time.Minute},
+		Tests: []TestCase{
+			{Name: "no rule"},
+			{Name: "first", StopOn: StopOnFirst},
+			{Name: "both", StopOn: StopOnBoth, Iterations: 7},
+		},
+	}
+
+	assert.False(t, config.IsHybrid(config.Tests[0]))
+	assert.True(t, config.IsHybrid(config.Tests[1]))
+	assert.True(t, config.IsHybrid(config.Tests[2]))
+
+	config.Global.StopOn = StopOnFirst
+	assert.True(t, config.IsHybrid(config.Tests[0]))
+	assert.Equal(t, StopOnBoth, config.StopOn(config.Tests[2]))
+
+	config.Global.Duration = 0
+	assert.False(t, config.IsHybrid(config.Tests[1]))
+}
+
+func TestConfig_RunDuration(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Duration: time.Minute},
+		Tests:  []TestCase{{Name: "a"}, {Name: "b", Duration: 90 * time.Second}},
+	}
+	assert.Equal(t, 90*time.Second, config.RunDuration())
+
+	// A test of a number of iterations ends the run on a count
+	config.Tests = append(config.Tests, TestCase{Name: "c", Iterations: 10})
+	assert.Zero(t, config.RunDuration())
+	assert.Zero(t, (&Config{Global: GlobalConfig{Iterations: 10}, Tests: []TestCase{{Name: "a"}}}).RunDuration())
+
+	hybrid := &Config{
+		Global: GlobalConfig{Duration: time.Minute, Iterations: 100, StopOn: StopOnFirst},
+		Tests:  []TestCase{{Name: "a"}},
+	}
+	assert.Zero(t, hybrid.RunDuration())
+
+	scenarios := &Config{
+		Global: GlobalConfig{Iterations: 10},
+		Tests:  []TestCase{{Name: "a"}, {Name: "b"}},
+		Scenarios: []Scenario{
+			{Name: "browse", Duration: 5 * time.Minute, Tests: []string{"a"}},
+			{Name: "buy", Duration: 2 * time.Minute, Tests: []string{"b"}},
+		},
+	}
+	assert.Equal(t, 5*time.Minute, scenarios.RunDuration())
+	scenarios.Scenarios[1].Duration = 0
+	assert.Zero(t, scenarios.RunDuration())
+}
+
+func TestConfig_TestPacing(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Pacing: time.Second},
+		Tests:  []TestCase{{Name: "global"}, {Name: "own", Pacing: 250 * time.Millisecond}},
+	}
+
+	assert.Equal(t, time.Second, config.TestPacing(config.Tests[0]))
+	assert.Equal(t, 250*time.Millisecond, config.TestPacing(config.Tests[1]))
+
+	sub := config.ScenarioConfig(Scenario{Name: "s", Pacing: 2 * time.Second})
+	assert.Equal(t, 2*time.Second, sub.TestPacing(sub.Tests[0]))
+	assert.Equal(t, 250*time.Millisecond, sub.TestPacing(sub.Tests[1]))
+}
+
+func TestConfig_GetTotalRequests_Hybrid(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Iterations: 10},
+		Tests: []TestCase{
+			{Name: "capped", Duration: time.Minute, Iterations: 50, StopOn: StopOnFirst},
+			{Name: "plain"},
+		},
+	}
+
+	assert.Equal(t, 60, config.GetTotalRequests())
+}
+
+func TestConfig_GetTotalRequests_Loop(t *testing.T) {
+	config := &Config{
+		Global: GlobalConfig{Iterations: 10, Loop: true},
+		Tests:  []TestCase{{Name: "login"}, {Name: "profile", Iterations: 3}},
+	}
+
+	// Every loop runs each test once; test iterations are ignored
+	assert.Equal(t, 20, config.GetTotalRequests())
+
+	sub := config.ScenarioConfig(Scenario{Name: "s"})
+	assert.True(t, sub.Global.Loop)
+}
+
+func TestTransferStats(t *testing.T) {
+	var transfer TransferStats
+	transfer.Add(TestResult{StatusCode: 200, RequestSize: 100, ResponseSize: 1000})
+	transfer.Add(TestResult{StatusCode: 201, RequestSize: -1, ResponseSize: 3000})
+	transfer.Add(TestResult{StatusCode: 200, ResponseSize: 1000, Cached: true})
+	transfer.Add(TestResult{Error: "connection refused"})
+
+	assert.Equal(t, 2, transfer.Requests)
+	assert.Equal(t, int64(100), transfer.BytesSent)
+	assert.Equal(t, int64(4000), transfer.BytesReceived)
+	assert.Equal(t, 50.0, transfer.AvgRequestSize())
+	assert.Equal(t, 2000.0, transfer.AvgResponseSize())
+
+	sent, received := transfer.Throughput(2 * time.Millisecond)
+	assert.InDelta(t, 0.05, sent, 1e-9)
+	assert.InDelta(t, 2, received, 1e-9)
+
+	sent, received = TransferStats{}.Throughput(0)
+	assert.Zero(t, sent)
+	assert.Zero(t, received)
+	assert.Zero(t, TransferStats{}.AvgResponseSize())
+
+	transfer.Add(TestResult{StatusCode: 200, ResponseSize: 250, ContentEncoding: "gzip", DecodedSize: 1000, DecodeTime: 2 * time.Millisecond})
+	transfer.Add(TestResult{StatusCode: 200, ResponseSize: 250, ContentEncoding: "deflate", DecodedSize: 1000, DecodeTime: 4 * time.Millisecond})
+	assert.Equal(t, 2, transfer.Compressed)
+	assert.Equal(t, int64(500), transfer.BytesCompressed)
+	assert.Equal(t, int64(2000), transfer.BytesDecoded)
+	assert.Equal(t, 75.0, transfer.CompressionSavings())
+	assert.Equal(t, 3*time.Millisecond, transfer.AvgDecodeTime())
+	assert.Zero(t, TransferStats{}.CompressionSavings())
+}
+
+func TestSummary_FailedUnder(t *testing.T) {
+	withinBudget := &Summary{
+		FailedReqs: 1,
+		EndpointResults: map[string]*EndpointSummary{
+			"flaky": {SuccessfulReqs: 99, FailedReqs: 1, AllowedFailureRate: 5},
+		},
+	}
+	overBudget := &Summary{
+		FailedReqs: 1,
+		EndpointResults: map[string]*EndpointSummary{
+			"strict": {SuccessfulReqs: 99, FailedReqs: 1},
+		},
+	}
+	failedAssertions := &Summary{AssertionsFailed: 2}
+	stopped := &Summary{MaxDurationReached: true}
+
+	tests := []struct {
+		policy  string
+		summary *Summary
+		failed  bool
+	}{
+		{FailOnThresholds, withinBudget, false},
+		{FailOnThresholds, overBudget, true},
+		{FailOnThresholds, stopped, true},
+		{"", overBudget, true},
+		{FailOnErrors, withinBudget, true},
+		{FailOnErrors, failedAssertions, false},
+		{FailOnErrors, stopped, true},
+		{FailOnAssertions, overBudget, false},
+		{FailOnAssertions, failedAssertions, true},
+		{FailOnAssertions, stopped, false},
+		{FailOnNone, overBudget, false},
+		{FailOnNone, failedAssertions, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.failed, tt.summary.FailedUnder(tt.policy), "policy %q", tt.policy)
+	}
+	assert.False(t, (&Summary{Interrupted: true}).Passed())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,8 @@ type Context struct {
 	ResponseTime time.Duration
 	Body         []byte
 	Headers      http.Header
+	SSE          *models.SSEResult // Events of a Server-Sent Events stream, nil for other tests
+	RowCount     *int              // Rows returned or affected by a query, nil for other tests
 }
 
 // NewContext creates a new assertion context
@@ -35,17 +38,20 @@ type Result struct {
 	Passed      bool
 	ActualValue interface{}
 	Message     string
+	ContextPath string // Path of the JSON body section around a failure (see Snippet), empty when there is none
 }
 
 // Evaluator evaluates assertions against response data
 type Evaluator struct {
 	verbose bool
+	now     func() time.Time // Clock of before, after, and within
 }
 
 // New creates a new assertion evaluator
 func New(verbose bool) *Evaluator {
 	return &Evaluator{
 		verbose: verbose,
+		now:     time.Now,
 	}
 }
 
@@ -68,6 +74,8 @@ func (e *Evaluator) Evaluate(assertion models.Assertion, ctx *Context) Result {
 	switch assertion.Type {
 	case "json_path":
 		return e.evaluateJSONPath(assertion, ctx)
+	case "xpath":
+		return e.evaluateXPath(assertion, ctx)
 	case "response_time":
 		return e.evaluateResponseTime(assertion, ctx)
 	case "status":
@@ -76,6 +84,10 @@ func (e *Evaluator) Evaluate(assertion models.Assertion, ctx *Context) Result {
 		return e.evaluateHeader(assertion, ctx)
 	case "body_size":
 		return e.evaluateBodySize(assertion, ctx)
+	case "sse_events", "sse_first_event":
+		return e.evaluateSSE(assertion, ctx)
+	case "row_count":
+		return e.evaluateRowCount(assertion, ctx)
 	default:
 		result.Message = fmt.Sprintf("unknown assertion type: %s", assertion.Type)
 		return result
@@ -100,6 +112,10 @@ func (e *Evaluator) evaluateJSONPath(assertion models.Assertion, ctx *Context) R
 		return result
 	}
 
+	if modifier, path := splitModifier(assertion.Target); modifier != "" {
+		return e.evaluateJSONArray(assertion, modifier, path, ctx)
+	}
+
 	// Handle exists/not_exists operators
 	if assertion.Operator == "exists" || assertion.Operator == "not_exists" {
 		exists := gjson.GetBytes(ctx.Body, assertion.Target).Exists()
@@ -109,11 +125,13 @@ func (e *Evaluator) evaluateJSONPath(assertion models.Assertion, ctx *Context) R
 			result.Passed = exists
 			if !exists {
 				result.Message = fmt.Sprintf("path '%s' not found in response", assertion.Target)
+				result.ContextPath = existingAncestor(ctx.Body, assertion.Target)
 			}
 		} else {
 			result.Passed = !exists
 			if exists {
 				result.Message = fmt.Sprintf("path '%s' exists but should not", assertion.Target)
+				result.ContextPath = parentPath(assertion.Target)
 			}
 		}
 		return result
@@ -123,29 +141,175 @@ func (e *Evaluator) evaluateJSONPath(assertion models.Assertion, ctx *Context) R
 	value := gjson.GetBytes(ctx.Body, assertion.Target)
 	if !value.Exists() {
 		result.Message = fmt.Sprintf("path '%s' not found in response", assertion.Target)
+		result.ContextPath = existingAncestor(ctx.Body, assertion.Target)
+		return result
+	}
+
+	actualValue := jsonValue(value)
+	result.ActualValue = actualValue
+
+	// Compare values
+	passed, err := e.compareValue(assertion, actualValue)
+	if err != nil {
+		result.Message = err.Error()
+		result.ContextPath = parentPath(assertion.Target)
+		return result
+	}
+
+	result.Passed = passed
+	if !passed {
+		result.Message = fmt.Sprintf("assertion failed: %s %s %v, got %v",
+			assertion.Target, assertion.Operator, assertion.Value, actualValue)
+		result.ContextPath = parentPath(assertion.Target)
+	}
+
+	return result
+}
+
+// evaluateJSONArray evaluates a json_path assertion with an each:, any:, or
+// none: modifier against every item of the array at the path
+func (e *Evaluator) evaluateJSONArray(assertion models.Assertion, modifier, path string, ctx *Context) Result {
+	result := Result{
+		Assertion: assertion,
+		Passed:    false,
+	}
+
+	value := gjson.GetBytes(ctx.Body, path)
+	if !value.Exists() {
+		result.Message = fmt.Sprintf("path '%s' not found in response", path)
+		result.ContextPath = existingAncestor(ctx.Body, arrayPath(path))
 		return result
 	}
+	if !value.IsArray() {
+		result.Message = fmt.Sprintf("path '%s' is not an array", path)
+		result.ContextPath = parentPath(path)
+		return result
+	}
+
+	// each and none fail on the first item that matches wrongly, any passes
+	// on the first item that matches
+	for i, item := range value.Array() {
+		actualValue := jsonValue(item)
+		passed, err := e.compareValue(assertion, actualValue)
+		if err != nil {
+			result.ActualValue = actualValue
+			result.Message = fmt.Sprintf("item %d: %v", i, err)
+			result.ContextPath = itemPath(path, i)
+			return result
+		}
+		switch {
+		case modifier == "any" && passed:
+			result.ActualValue = actualValue
+			result.Passed = true
+			return result
+		case modifier == "each" && !passed, modifier == "none" && passed:
+			result.ActualValue = actualValue
+			result.Message = fmt.Sprintf("assertion failed: item %d of %s: %s %s %v, got %v",
+				i, path, modifier, assertion.Operator, assertion.Value, actualValue)
+			result.ContextPath = itemPath(path, i)
+			return result
+		}
+	}
+
+	result.ActualValue = value.Raw
+	result.Passed = modifier != "any"
+	if !result.Passed {
+		result.Message = fmt.Sprintf("assertion failed: no item of %s %s %v, got %s",
+			path, assertion.Operator, assertion.Value, value.Raw)
+		result.ContextPath = arrayPath(path)
+	}
+	return result
+}
+
+// JSONPath returns the path a json_path target reads, without its each:,
+// any:, or none: modifier
+func JSONPath(target string) string {
+	_, path := splitModifier(target)
+	return path
+}
+
+// splitModifier splits the each:, any:, or none: modifier of a json_path
+// target from its path, returning an empty modifier when there is none
+func splitModifier(target string) (string, string) {
+	for _, modifier := range arrayModifiers {
+		if path, ok := strings.CutPrefix(target, modifier+":"); ok {
+			return modifier, path
+		}
+	}
+	return "", target
+}
 
-	// Extract the actual value
-	var actualValue interface{}
+// jsonValue returns a JSON value as a string, float64, bool, or nil, or its
+// raw text for objects and arrays
+func jsonValue(value gjson.Result) interface{} {
 	switch value.Type {
 	case gjson.String:
-		actualValue = value.String()
+		return value.String()
 	case gjson.Number:
-		actualValue = value.Float()
+		return value.Float()
 	case gjson.True:
-		actualValue = true
+		return true
 	case gjson.False:
-		actualValue = false
+		return false
 	case gjson.Null:
-		actualValue = nil
+		return nil
 	default:
-		actualValue = value.Raw
+		return value.Raw
+	}
+}
+
+// evaluateXPath evaluates an XPath assertion against an XML body, comparing
+// the first selected value
+func (e *Evaluator) evaluateXPath(assertion models.Assertion, ctx *Context) Result {
+	result := Result{
+		Assertion: assertion,
+		Passed:    false,
+	}
+
+	if len(ctx.Body) == 0 {
+		result.Message = "empty response body"
+		return result
+	}
+
+	xpath, err := ParseXPath(assertion.Target)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	values, err := xpath.Select(ctx.Body)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	if assertion.Operator == "exists" || assertion.Operator == "not_exists" {
+		exists := len(values) > 0
+		result.ActualValue = exists
+		result.Passed = exists == (assertion.Operator == "exists")
+		if !result.Passed && exists {
+			result.Message = fmt.Sprintf("xpath '%s' exists but should not", assertion.Target)
+		} else if !result.Passed {
+			result.Message = fmt.Sprintf("xpath '%s' not found in response", assertion.Target)
+		}
+		return result
+	}
+
+	if len(values) == 0 {
+		result.Message = fmt.Sprintf("xpath '%s' not found in response", assertion.Target)
+		return result
+	}
+
+	// XML has no types: the text is compared as a number when the expected
+	// value is one
+	var actualValue interface{} = values[0]
+	if _, numeric := assertion.Value.(float64); numeric {
+		if number, err := strconv.ParseFloat(values[0], 64); err == nil {
+			actualValue = number
+		}
 	}
 	result.ActualValue = actualValue
 
-	// Compare values
-	passed, err := e.compare(assertion.Operator, actualValue, assertion.Value)
+	passed, err := e.compareValue(assertion, actualValue)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -267,7 +431,7 @@ func (e *Evaluator) evaluateHeader(assertion models.Assertion, ctx *Context) Res
 	}
 
 	// Compare values
-	passed, err := e.compare(assertion.Operator, headerValue, assertion.Value)
+	passed, err := e.compareValue(assertion, headerValue)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -311,6 +475,97 @@ func (e *Evaluator) evaluateBodySize(assertion models.Assertion, ctx *Context) R
 	return result
 }
 
+// evaluateRowCount evaluates the rows returned or affected by a query
+func (e *Evaluator) evaluateRowCount(assertion models.Assertion, ctx *Context) Result {
+	result := Result{
+		Assertion: assertion,
+		Passed:    false,
+	}
+
+	if ctx.RowCount == nil {
+		result.Message = "row_count assertion needs a test with sql"
+		return result
+	}
+	result.ActualValue = *ctx.RowCount
+
+	expected, ok := assertion.Value.(float64)
+	if !ok {
+		result.Message = fmt.Sprintf("invalid row count value: %v", assertion.Value)
+		return result
+	}
+
+	passed, err := e.compare(assertion.Operator, float64(*ctx.RowCount), expected)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Passed = passed
+	if !passed {
+		result.Message = fmt.Sprintf("row count assertion failed: %d %s %v",
+			*ctx.RowCount, assertion.Operator, int(expected))
+	}
+
+	return result
+}
+
+// evaluateSSE evaluates the event count or time to the first event of a
+// Server-Sent Events stream
+func (e *Evaluator) evaluateSSE(assertion models.Assertion, ctx *Context) Result {
+	result := Result{
+		Assertion: assertion,
+		Passed:    false,
+	}
+
+	if ctx.SSE == nil {
+		result.Message = fmt.Sprintf("%s assertion needs a test with sse", assertion.Type)
+		return result
+	}
+
+	if assertion.Type == "sse_events" {
+		result.ActualValue = ctx.SSE.Events
+		expected, ok := assertion.Value.(float64)
+		if !ok {
+			result.Message = fmt.Sprintf("invalid event count value: %v", assertion.Value)
+			return result
+		}
+		passed, err := e.compare(assertion.Operator, float64(ctx.SSE.Events), expected)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		result.Passed = passed
+		if !passed {
+			result.Message = fmt.Sprintf("sse events assertion failed: %d %s %v",
+				ctx.SSE.Events, assertion.Operator, int(expected))
+		}
+		return result
+	}
+
+	result.ActualValue = ctx.SSE.FirstEvent
+	if ctx.SSE.Events == 0 {
+		result.Message = "no event received"
+		return result
+	}
+	valueStr, _ := assertion.Value.(string)
+	expected, err := time.ParseDuration(valueStr)
+	if err != nil {
+		result.Message = fmt.Sprintf("invalid duration value: %v (expected string like '100ms')", assertion.Value)
+		return result
+	}
+	passed, err := e.compareDurations(assertion.Operator, ctx.SSE.FirstEvent, expected)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Passed = passed
+	if !passed {
+		result.Message = fmt.Sprintf("sse first event assertion failed: %v %s %v",
+			ctx.SSE.FirstEvent, assertion.Operator, expected)
+	}
+	return result
+}
+
 // compare compares two values using the specified operator
 func (e *Evaluator) compare(operator string, actual, expected interface{}) (bool, error) {
 	switch operator {
@@ -339,6 +594,30 @@ func (e *Evaluator) compare(operator string, actual, expected interface{}) (bool
 	}
 }
 
+// compareValue compares an actual value with the assertion's value, reading
+// numeric strings on either side as numbers for numeric operators when the
+// assertion coerces
+func (e *Evaluator) compareValue(assertion models.Assertion, actual interface{}) (bool, error) {
+	if containsString(timeOperators, assertion.Operator) {
+		return e.compareTimes(assertion, actual)
+	}
+	if !assertion.Coerce || !containsString(numericOperators, assertion.Operator) {
+		return e.compare(assertion.Operator, actual, assertion.Value)
+	}
+	return e.compare(assertion.Operator, coerceNumber(actual), coerceNumber(assertion.Value))
+}
+
+// coerceNumber returns a string holding a number as a float64, and any other
+// value as it is
+func coerceNumber(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		if number, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return number
+		}
+	}
+	return v
+}
+
 // compareDurations compares two durations using the specified operator
 func (e *Evaluator) compareDurations(operator string, actual, expected time.Duration) (bool, error) {
 	switch operator {
@@ -470,3 +749,99 @@ func toFloat64(v interface{}) (float64, bool) {
 		return 0, false
 	}
 }
+
+// Types lists the supported assertion types
+var Types = []string{"json_path", "xpath", "header", "response_time", "status", "body_size", "sse_events", "sse_first_event", "row_count"}
+
+var (
+	arrayModifiers      = []string{"each", "any", "none"}
+	comparisonOperators = []string{"eq", "neq", "gt", "gte", "lt", "lte", "contains", "starts_with", "ends_with", "matches"}
+	numericOperators    = []string{"eq", "neq", "gt", "gte", "lt", "lte"}
+	presenceOperators   = []string{"exists", "not_exists"}
+)
+
+// Operators lists every operator accepted by at least one assertion type
+func Operators() []string {
+	return append(append(append([]string{}, comparisonOperators...), timeOperators...), presenceOperators...)
+}
+
+// Validate checks an assertion's type, operator, target, and value so that
+// configuration mistakes are reported at load time instead of on every request
+func Validate(assertion models.Assertion) error {
+	var operators []string
+	switch assertion.Type {
+	case "json_path", "xpath", "header":
+		if assertion.Target == "" {
+			return fmt.Errorf("target is required for %s assertions", assertion.Type)
+		}
+		if assertion.Type == "xpath" {
+			if _, err := ParseXPath(assertion.Target); err != nil {
+				return err
+			}
+		}
+		operators = Operators()
+	case "response_time", "status", "body_size", "sse_events", "sse_first_event", "row_count":
+		operators = numericOperators
+	case "":
+		return fmt.Errorf("type is required")
+	default:
+		return fmt.Errorf("unknown assertion type '%s' (expected %s)", assertion.Type, strings.Join(Types, ", "))
+	}
+
+	if assertion.Coerce && assertion.Type != "json_path" && assertion.Type != "xpath" && assertion.Type != "header" {
+		return fmt.Errorf("coerce applies only to json_path, xpath, and header assertions")
+	}
+
+	if modifier, path := splitModifier(assertion.Target); modifier != "" && assertion.Type == "json_path" {
+		if path == "" {
+			return fmt.Errorf("%s: needs the path of an array", modifier)
+		}
+		operators = append(append([]string{}, comparisonOperators...), timeOperators...)
+	}
+
+	if !containsString(operators, assertion.Operator) {
+		return fmt.Errorf("unknown operator '%s' for %s assertion (expected %s)", assertion.Operator, assertion.Type, strings.Join(operators, ", "))
+	}
+	if assertion.Layout != "" && !containsString(timeOperators, assertion.Operator) {
+		return fmt.Errorf("layout applies only to the before, after, and within operators")
+	}
+
+	switch {
+	case assertion.Operator == "exists" || assertion.Operator == "not_exists":
+	case assertion.Type == "response_time" || assertion.Type == "sse_first_event":
+		value, ok := assertion.Value.(string)
+		if !ok {
+			return fmt.Errorf("%s value must be a duration string like '100ms'", assertion.Type)
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s value: %w", assertion.Type, err)
+		}
+	case assertion.Type == "status" || assertion.Type == "body_size" || assertion.Type == "sse_events" || assertion.Type == "row_count":
+		if _, ok := assertion.Value.(float64); !ok {
+			return fmt.Errorf("%s value must be a number", assertion.Type)
+		}
+	case containsString(timeOperators, assertion.Operator):
+		if err := validateTimeValue(assertion); err != nil {
+			return err
+		}
+	case assertion.Operator == "matches":
+		pattern, ok := assertion.Value.(string)
+		if !ok {
+			return fmt.Errorf("matches value must be a regular expression string")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
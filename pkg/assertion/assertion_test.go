@@ -211,6 +211,79 @@ func TestJSONPathAssertion_ArrayAccess(t *testing.T) {
 	}
 }
 
+func TestJSONPathAssertion_ArrayModifiers(t *testing.T) {
+	ctx := NewContext(200, 100*time.Millisecond, []byte(`{
+		"users": [
+			{"id": 1, "status": "active"},
+			{"id": 2, "status": "active"},
+			{"id": 3, "status": "banned"}
+		],
+		"tags": ["a", "b"],
+		"empty": [],
+		"name": "users"
+	}`), nil)
+	e := New(false)
+
+	tests := []struct {
+		target   string
+		operator string
+		value    interface{}
+		wantPass bool
+		wantMsg  string
+	}{
+		{"each:users.#.id", "gt", float64(0), true, ""},
+		{"each:users.#.status", "eq", "active", false, "item 2 of users.#.status: each eq active, got banned"},
+		{"any:users.#.status", "eq", "banned", true, ""},
+		{"any:tags", "eq", "c", false, `no item of tags eq c, got ["a", "b"]`},
+		{"none:users.#.status", "eq", "deleted", true, ""},
+		{"none:users.#.status", "eq", "banned", false, "item 2 of users.#.status: none eq banned, got banned"},
+		{"each:empty", "eq", "x", true, ""},
+		{"any:empty", "eq", "x", false, "no item of empty"},
+		{"each:name", "eq", "users", false, "path 'name' is not an array"},
+		{"each:missing", "eq", "x", false, "path 'missing' not found"},
+		{"each:tags", "gt", float64(1), false, "item 0: cannot compare non-numeric values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.operator, func(t *testing.T) {
+			result := e.Evaluate(models.Assertion{Type: "json_path", Target: tt.target, Operator: tt.operator, Value: tt.value}, ctx)
+			assert.Equal(t, tt.wantPass, result.Passed, "Message: %s", result.Message)
+			if tt.wantMsg != "" {
+				assert.Contains(t, result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestJSONPathAssertion_Coerce(t *testing.T) {
+	ctx := NewContext(200, 100*time.Millisecond, []byte(`{"amount": "12.50", "currency": "EUR", "code": "007"}`), nil)
+	e := New(false)
+
+	tests := []struct {
+		name      string
+		assertion models.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{"string without coerce", models.Assertion{Type: "json_path", Target: "amount", Operator: "gt", Value: 10.0}, false, "cannot compare non-numeric values"},
+		{"gt with coerce", models.Assertion{Type: "json_path", Target: "amount", Operator: "gt", Value: 10.0, Coerce: true}, true, ""},
+		{"eq with coerce", models.Assertion{Type: "json_path", Target: "amount", Operator: "eq", Value: 12.5, Coerce: true}, true, ""},
+		{"string value with coerce", models.Assertion{Type: "json_path", Target: "amount", Operator: "lte", Value: "12.5", Coerce: true}, true, ""},
+		{"non-numeric with coerce", models.Assertion{Type: "json_path", Target: "currency", Operator: "gt", Value: 1.0, Coerce: true}, false, "cannot compare non-numeric values"},
+		{"string operator keeps text", models.Assertion{Type: "json_path", Target: "code", Operator: "starts_with", Value: "00", Coerce: true}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := e.Evaluate(tt.assertion, ctx)
+			assert.Equal(t, tt.wantPass, result.Passed, "Message: %s", result.Message)
+			if tt.wantMsg != "" {
+				assert.Contains(t, result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
 func TestJSONPathAssertion_NonExistentPath(t *testing.T) {
 	ctx := NewContext(200, 100*time.Millisecond, []byte(`{"id": 1}`), nil)
 	e := New(false)
@@ -914,3 +987,88 @@ func TestResultContainsActualValue(t *testing.T) {
 	assert.Contains(t, result.Message, "42")
 	assert.Contains(t, result.Message, "99")
 }
+
+// =============================================================================
+// Validation Tests
+// =============================================================================
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion models.Assertion
+		wantErr   string
+	}{
+		{"valid json_path", models.Assertion{Type: "json_path", Target: "id", Operator: "eq", Value: 1.0}, ""},
+		{"valid exists", models.Assertion{Type: "header", Target: "ETag", Operator: "exists"}, ""},
+		{"valid response_time", models.Assertion{Type: "response_time", Operator: "lt", Value: "500ms"}, ""},
+		{"valid status", models.Assertion{Type: "status", Operator: "eq", Value: 200.0}, ""},
+		{"valid matches", models.Assertion{Type: "json_path", Target: "email", Operator: "matches", Value: "^.+@.+$"}, ""},
+		{"missing type", models.Assertion{Operator: "eq"}, "type is required"},
+		{"unknown type", models.Assertion{Type: "cookie", Operator: "eq"}, "unknown assertion type 'cookie'"},
+		{"missing target", models.Assertion{Type: "json_path", Operator: "eq", Value: 1.0}, "target is required"},
+		{"unknown operator", models.Assertion{Type: "json_path", Target: "id", Operator: "equals"}, "unknown operator 'equals'"},
+		{"string operator on status", models.Assertion{Type: "status", Operator: "contains", Value: 200.0}, "unknown operator 'contains' for status"},
+		{"numeric response_time", models.Assertion{Type: "response_time", Operator: "lt", Value: 500.0}, "duration string"},
+		{"bad response_time", models.Assertion{Type: "response_time", Operator: "lt", Value: "fast"}, "invalid response_time value"},
+		{"string status", models.Assertion{Type: "status", Operator: "eq", Value: "200"}, "status value must be a number"},
+		{"bad regex", models.Assertion{Type: "header", Target: "X", Operator: "matches", Value: "(["}, "invalid regular expression"},
+		{"valid sse_events", models.Assertion{Type: "sse_events", Operator: "gte", Value: 10.0}, ""},
+		{"string row_count", models.Assertion{Type: "row_count", Operator: "gte", Value: "1"}, "row_count value must be a number"},
+		{"string sse_first_event", models.Assertion{Type: "sse_first_event", Operator: "lt", Value: 1.0}, "sse_first_event value must be a duration string"},
+		{"valid xpath", models.Assertion{Type: "xpath", Target: "//User[@id='42']/Name", Operator: "eq", Value: "Mario"}, ""},
+		{"missing xpath target", models.Assertion{Type: "xpath", Operator: "exists"}, "target is required"},
+		{"bad xpath", models.Assertion{Type: "xpath", Target: "//User[last()]", Operator: "exists"}, "unsupported predicate"},
+		{"coerce on header", models.Assertion{Type: "header", Target: "X-Total", Operator: "gt", Value: 1.0, Coerce: true}, ""},
+		{"coerce on status", models.Assertion{Type: "status", Operator: "eq", Value: 200.0, Coerce: true}, "coerce applies only to json_path, xpath, and header"},
+		{"valid each", models.Assertion{Type: "json_path", Target: "each:items.#.status", Operator: "eq", Value: "active"}, ""},
+		{"exists with any", models.Assertion{Type: "json_path", Target: "any:items", Operator: "exists"}, "unknown operator 'exists'"},
+		{"modifier without path", models.Assertion{Type: "json_path", Target: "none:", Operator: "eq", Value: 1.0}, "none: needs the path of an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.assertion)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSSEAssertions(t *testing.T) {
+	evaluator := New(false)
+	ctx := NewContext(200, 80*time.Millisecond, []byte(`{"seq": 5}`), nil)
+	ctx.SSE = &models.SSEResult{Events: 5, FirstEvent: 80 * time.Millisecond}
+
+	assert.True(t, evaluator.Evaluate(models.Assertion{Type: "sse_events", Operator: "gte", Value: 5.0}, ctx).Passed)
+	assert.False(t, evaluator.Evaluate(models.Assertion{Type: "sse_events", Operator: "gt", Value: 5.0}, ctx).Passed)
+	assert.True(t, evaluator.Evaluate(models.Assertion{Type: "sse_first_event", Operator: "lt", Value: "100ms"}, ctx).Passed)
+
+	ctx.SSE = &models.SSEResult{}
+	result := evaluator.Evaluate(models.Assertion{Type: "sse_first_event", Operator: "lt", Value: "100ms"}, ctx)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "no event received", result.Message)
+
+	result = evaluator.Evaluate(models.Assertion{Type: "sse_events", Operator: "gt", Value: 0.0}, NewContext(200, 0, nil, nil))
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "needs a test with sse")
+}
+
+func TestRowCountAssertion(t *testing.T) {
+	evaluator := New(false)
+	rows := 3
+	ctx := NewContext(0, 0, []byte(`[]`), nil)
+	ctx.RowCount = &rows
+
+	assert.True(t, evaluator.Evaluate(models.Assertion{Type: "row_count", Operator: "eq", Value: 3.0}, ctx).Passed)
+	result := evaluator.Evaluate(models.Assertion{Type: "row_count", Operator: "lt", Value: 2.0}, ctx)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "row count assertion failed: 3 lt 2", result.Message)
+
+	result = evaluator.Evaluate(models.Assertion{Type: "row_count", Operator: "gt", Value: 0.0}, NewContext(200, 0, nil, nil))
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "needs a test with sql")
+}
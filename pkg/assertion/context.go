@@ -0,0 +1,121 @@
+package assertion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// rootPath is the gjson path of the whole body
+const rootPath = "@this"
+
+const (
+	snippetLines = 20   // Lines of a body snippet before it is cut
+	snippetBytes = 2048 // Bytes of a body snippet before it is cut
+)
+
+// Snippet returns the section of a JSON body at a gjson path, indented and
+// cut to a few lines, labeled with its path so that a failed json_path
+// assertion shows the values around the one it checked
+func Snippet(body []byte, path string) string {
+	value := gjson.GetBytes(body, path)
+	if !value.Exists() {
+		return ""
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(value.Raw), "", "  "); err != nil {
+		return ""
+	}
+	text := indented.String()
+	lines := strings.Split(text, "\n")
+	if len(lines) > snippetLines {
+		text = strings.Join(lines[:snippetLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-snippetLines)
+	}
+	if len(text) > snippetBytes {
+		text = text[:snippetBytes] + "... (truncated)"
+	}
+
+	label := path
+	if path == rootPath {
+		label = "body"
+	}
+	return label + " = " + text
+}
+
+// parentPath returns the path of the object or array holding the value at a
+// gjson path, the root for a top-level field
+func parentPath(path string) string {
+	parts := splitPath(path)
+	if len(parts) <= 1 {
+		return rootPath
+	}
+	return strings.Join(parts[:len(parts)-1], ".")
+}
+
+// existingAncestor returns the path of the closest parent of a missing path
+// that is in the body
+func existingAncestor(body []byte, path string) string {
+	for path != rootPath {
+		path = parentPath(path)
+		if path == rootPath || gjson.GetBytes(body, path).Exists() {
+			return path
+		}
+	}
+	return rootPath
+}
+
+// itemPath returns the path of an item of the array an each:, any:, or none:
+// path selects from: items.#.status is read from item i of items
+func itemPath(path string, i int) string {
+	parts := splitPath(path)
+	for j, part := range parts {
+		if part == "#" {
+			return strings.Join(append(parts[:j:j], strconv.Itoa(i)), ".")
+		}
+	}
+	return path
+}
+
+// arrayPath returns the path of the array an each:, any:, or none: path
+// selects from
+func arrayPath(path string) string {
+	parts := splitPath(path)
+	for j, part := range parts {
+		if part == "#" {
+			if j == 0 {
+				return rootPath
+			}
+			return strings.Join(parts[:j], ".")
+		}
+	}
+	return path
+}
+
+// splitPath splits a gjson path at its dots, leaving escaped dots and the
+// dots of queries and multipaths such as #(name=="a.b") or [a.b,c] alone
+func splitPath(path string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == '.' && depth == 0:
+			parts = append(parts, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, path[start:])
+}
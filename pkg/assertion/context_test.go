@@ -0,0 +1,74 @@
+package assertion
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippet(t *testing.T) {
+	body := []byte(`{"order":{"id":7,"total":"0.00","lines":[{"sku":"A"}]}}`)
+
+	assert.Equal(t, "order = {\n  \"id\": 7,\n  \"total\": \"0.00\",\n  \"lines\": [\n    {\n      \"sku\": \"A\"\n    }\n  ]\n}", Snippet(body, "order"))
+	assert.Equal(t, "order.lines.0 = {\n  \"sku\": \"A\"\n}", Snippet(body, "order.lines.0"))
+	assert.True(t, strings.HasPrefix(Snippet(body, rootPath), "body = {\n  \"order\": {"))
+	assert.Empty(t, Snippet(body, "missing"))
+	assert.Empty(t, Snippet([]byte("not json"), rootPath))
+
+	var items []string
+	for i := 0; i < 30; i++ {
+		items = append(items, fmt.Sprint(i))
+	}
+	long := Snippet([]byte("["+strings.Join(items, ",")+"]"), rootPath)
+	assert.Len(t, strings.Split(long, "\n"), snippetLines+1)
+	assert.True(t, strings.HasSuffix(long, "... (12 more lines)"))
+}
+
+func TestPaths(t *testing.T) {
+	assert.Equal(t, rootPath, parentPath("total"))
+	assert.Equal(t, "order", parentPath("order.total"))
+	assert.Equal(t, `items.#(name=="a.b")`, parentPath(`items.#(name=="a.b").price`))
+	assert.Equal(t, `my\.key`, parentPath(`my\.key.value`))
+
+	assert.Equal(t, "users.2", itemPath("users.#.status", 2))
+	assert.Equal(t, "tags", itemPath("tags", 1))
+	assert.Equal(t, "users", arrayPath("users.#.status"))
+	assert.Equal(t, rootPath, arrayPath("#.id"))
+
+	body := []byte(`{"order":{"lines":[]}}`)
+	assert.Equal(t, "order", existingAncestor(body, "order.customer.name"))
+	assert.Equal(t, rootPath, existingAncestor(body, "customer.name"))
+}
+
+func TestJSONPathAssertion_ContextPath(t *testing.T) {
+	ctx := NewContext(200, 100*time.Millisecond, []byte(`{
+		"order": {"id": 7, "total": 0},
+		"users": [{"status": "active"}, {"status": "banned"}]
+	}`), nil)
+	e := New(false)
+
+	tests := []struct {
+		target   string
+		operator string
+		value    interface{}
+		want     string
+	}{
+		{"order.total", "gt", 0.0, "order"},
+		{"order.id", "eq", 7.0, ""},
+		{"order.customer.name", "exists", nil, "order"},
+		{"order.customer.name", "eq", "Mario", "order"},
+		{"order.id", "not_exists", nil, "order"},
+		{"each:users.#.status", "eq", "active", "users.1"},
+		{"any:users.#.status", "eq", "deleted", "users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.operator, func(t *testing.T) {
+			result := e.Evaluate(models.Assertion{Type: "json_path", Target: tt.target, Operator: tt.operator, Value: tt.value}, ctx)
+			assert.Equal(t, tt.want, result.ContextPath)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package assertion
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// timeOperators compare timestamps: before and after with a timestamp or a
+// time relative to now (now, now+1h, now-30m), within with a duration from now
+var timeOperators = []string{"before", "after", "within"}
+
+// namedLayouts are the layout names accepted besides Go reference layouts
+var namedLayouts = map[string]string{
+	"rfc3339":  time.RFC3339Nano,
+	"rfc1123":  time.RFC1123,
+	"rfc1123z": time.RFC1123Z,
+	"date":     time.DateOnly,
+	"datetime": time.DateTime,
+}
+
+// defaultLayouts are tried in order for timestamps without a layout
+var defaultLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	time.DateTime,
+	time.DateOnly,
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// compareTimes compares a timestamp with the value of a before, after, or
+// within assertion
+func (e *Evaluator) compareTimes(assertion models.Assertion, actual interface{}) (bool, error) {
+	timestamp, err := parseTimestamp(actual, assertion.Layout)
+	if err != nil {
+		return false, err
+	}
+	now := e.now()
+
+	if assertion.Operator == "within" {
+		window, err := parseWindow(assertion.Value)
+		if err != nil {
+			return false, err
+		}
+		return time.Duration(math.Abs(float64(timestamp.Sub(now)))) <= window, nil
+	}
+
+	reference, err := parseReference(assertion.Value, assertion.Layout, now)
+	if err != nil {
+		return false, err
+	}
+	if assertion.Operator == "before" {
+		return timestamp.Before(reference), nil
+	}
+	return timestamp.After(reference), nil
+}
+
+// parseTimestamp reads a timestamp with a layout: a Go reference layout, one
+// of the named layouts, or unix / unix_ms for epoch numbers. Without a
+// layout the default layouts are tried, and numbers are epoch seconds.
+func parseTimestamp(value interface{}, layout string) (time.Time, error) {
+	if number, ok := toFloat64(value); ok {
+		if layout == "unix_ms" {
+			return time.UnixMilli(int64(number)), nil
+		}
+		if layout == "" || layout == "unix" {
+			return epoch(number), nil
+		}
+		return time.Time{}, fmt.Errorf("cannot read %v as a timestamp with layout %s", value, layout)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot read %v as a timestamp", value)
+	}
+	text = strings.TrimSpace(text)
+
+	switch layout {
+	case "":
+		for _, candidate := range defaultLayouts {
+			if timestamp, err := time.Parse(candidate, text); err == nil {
+				return timestamp, nil
+			}
+		}
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return epoch(number), nil
+		}
+		return time.Time{}, fmt.Errorf("cannot read %q as a timestamp (set layout for other formats)", text)
+	case "unix", "unix_ms":
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot read %q as a %s timestamp", text, layout)
+		}
+		return parseTimestamp(number, layout)
+	}
+
+	if named, ok := namedLayouts[layout]; ok {
+		layout = named
+	}
+	timestamp, err := time.Parse(layout, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot read %q as a timestamp: %v", text, err)
+	}
+	return timestamp, nil
+}
+
+// parseReference reads the value of a before or after assertion: now, a time
+// relative to now such as now+1h or now-30m, or a timestamp
+func parseReference(value interface{}, layout string, now time.Time) (time.Time, error) {
+	text, ok := value.(string)
+	if !ok || !strings.HasPrefix(strings.TrimSpace(text), "now") {
+		timestamp, err := parseTimestamp(value, layout)
+		if err != nil && layout != "" {
+			// Timestamps written in the config need not follow the layout of the response
+			timestamp, err = parseTimestamp(value, "")
+		}
+		return timestamp, err
+	}
+
+	offset := strings.ReplaceAll(strings.TrimPrefix(strings.TrimSpace(text), "now"), " ", "")
+	if offset == "" {
+		return now, nil
+	}
+	if offset[0] != '+' && offset[0] != '-' {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected now, now+1h, now-30m, or a timestamp)", text)
+	}
+	duration, err := time.ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %v", text, err)
+	}
+	return now.Add(duration), nil
+}
+
+// parseWindow reads the duration of a within assertion
+func parseWindow(value interface{}) (time.Duration, error) {
+	text, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("within value must be a duration string like '5m'")
+	}
+	window, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid within value: %v", err)
+	}
+	if window < 0 {
+		return 0, fmt.Errorf("within value must not be negative")
+	}
+	return window, nil
+}
+
+// epoch returns the time of a number of seconds since the Unix epoch
+func epoch(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second)))
+}
+
+// validateTimeValue checks the value of a before, after, or within assertion
+func validateTimeValue(assertion models.Assertion) error {
+	if assertion.Operator == "within" {
+		_, err := parseWindow(assertion.Value)
+		return err
+	}
+	_, err := parseReference(assertion.Value, assertion.Layout, time.Now())
+	return err
+}
@@ -0,0 +1,85 @@
+package assertion
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeOperators(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	headers := http.Header{}
+	headers.Set("Expires", "Sun, 01 Mar 2026 14:00:00 GMT")
+	ctx := NewContext(200, 100*time.Millisecond, []byte(`{
+		"expires_at": "2026-03-01T14:00:00Z",
+		"created": "01/03/2026 11:58",
+		"issued_at": 1772366340,
+		"issued_ms": "1772366340000",
+		"name": "token"
+	}`), headers)
+	e := New(false)
+	e.now = func() time.Time { return now }
+
+	tests := []struct {
+		name      string
+		assertion models.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{"more than 1h in the future", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "now+1h"}, true, ""},
+		{"not 3h in the future", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "now+3h"}, false, "assertion failed: expires_at after now+3h"},
+		{"before a timestamp", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "before", Value: "2026-03-02"}, true, ""},
+		{"within", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "within", Value: "2h"}, true, ""},
+		{"not within", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "within", Value: "30m"}, false, ""},
+		{"custom layout", models.Assertion{Type: "json_path", Target: "created", Operator: "within", Value: "5m", Layout: "02/01/2006 15:04"}, true, ""},
+		{"unix seconds", models.Assertion{Type: "json_path", Target: "issued_at", Operator: "before", Value: "now"}, true, ""},
+		{"unix milliseconds", models.Assertion{Type: "json_path", Target: "issued_ms", Operator: "within", Value: "1m", Layout: "unix_ms"}, true, ""},
+		{"http date header", models.Assertion{Type: "header", Target: "Expires", Operator: "after", Value: "now+1h"}, true, ""},
+		{"each item", models.Assertion{Type: "json_path", Target: "each:[expires_at,issued_at]", Operator: "after", Value: "now-1h"}, true, ""},
+		{"not a timestamp", models.Assertion{Type: "json_path", Target: "name", Operator: "after", Value: "now"}, false, `cannot read "token" as a timestamp`},
+		{"wrong layout", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "now", Layout: "rfc1123"}, false, "cannot read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := e.Evaluate(tt.assertion, ctx)
+			assert.Equal(t, tt.wantPass, result.Passed, "Message: %s", result.Message)
+			if tt.wantMsg != "" {
+				assert.Contains(t, result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestValidate_TimeOperators(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion models.Assertion
+		wantErr   string
+	}{
+		{"relative", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "now+1h"}, ""},
+		{"timestamp", models.Assertion{Type: "header", Target: "Date", Operator: "before", Value: "2030-01-01T00:00:00Z"}, ""},
+		{"within", models.Assertion{Type: "xpath", Target: "//Expires", Operator: "within", Value: "10m", Layout: "rfc3339"}, ""},
+		{"bad offset", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "now+soon"}, "invalid time \"now+soon\""},
+		{"bad timestamp", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "after", Value: "tomorrow"}, "cannot read \"tomorrow\" as a timestamp"},
+		{"numeric within", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "within", Value: 60.0}, "within value must be a duration string"},
+		{"layout without time operator", models.Assertion{Type: "json_path", Target: "expires_at", Operator: "eq", Value: "x", Layout: "unix"}, "layout applies only"},
+		{"time operator on status", models.Assertion{Type: "status", Operator: "after", Value: "now"}, "unknown operator 'after' for status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.assertion)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
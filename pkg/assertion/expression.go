@@ -0,0 +1,615 @@
+package assertion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+)
+
+// Expression is a parsed success condition (success_when), combining the
+// status code, response time, headers, and body of a response, e.g.
+//
+//	status in [200, 202] && json(body).state != 'error' && latency < 500ms
+type Expression struct {
+	source string
+	root   node
+}
+
+// ParseExpression parses a success condition
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source of the expression
+func (x *Expression) String() string {
+	return x.source
+}
+
+// Eval reports whether a response meets the condition
+func (x *Expression) Eval(ctx *Context) (bool, error) {
+	value, err := x.root.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	return condition(value)
+}
+
+// ReadsBody reports whether the condition looks at the response body, which
+// a test that does not read it cannot provide
+func (x *Expression) ReadsBody() bool {
+	return readsBody(x.root)
+}
+
+func readsBody(n node) bool {
+	switch n := n.(type) {
+	case fieldNode:
+		return n != "status" && n != "latency"
+	case jsonNode:
+		return true
+	case listNode:
+		for _, item := range n {
+			if readsBody(item) {
+				return true
+			}
+		}
+	case notNode:
+		return readsBody(n.operand)
+	case logicalNode:
+		return readsBody(n.left) || readsBody(n.right)
+	case compareNode:
+		return readsBody(n.left) || readsBody(n.right)
+	}
+	return false
+}
+
+// node is an operand or operation of an expression
+type node interface {
+	eval(ctx *Context) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(*Context) (interface{}, error) { return n.value, nil }
+
+type listNode []node
+
+func (n listNode) eval(ctx *Context) (interface{}, error) {
+	values := make([]interface{}, 0, len(n))
+	for _, item := range n {
+		value, err := item.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// fieldNode is a property of the response: status, latency, or body
+type fieldNode string
+
+func (n fieldNode) eval(ctx *Context) (interface{}, error) {
+	switch n {
+	case "status":
+		return float64(ctx.StatusCode), nil
+	case "latency":
+		return ctx.ResponseTime, nil
+	default:
+		return string(ctx.Body), nil
+	}
+}
+
+// headerNode is a response header, nil when missing
+type headerNode string
+
+func (n headerNode) eval(ctx *Context) (interface{}, error) {
+	if values := ctx.Headers.Values(string(n)); len(values) > 0 {
+		return strings.Join(values, ", "), nil
+	}
+	return nil, nil
+}
+
+// jsonNode is a value of the JSON body at a gjson path, nil when missing or
+// when the body is not JSON
+type jsonNode string
+
+func (n jsonNode) eval(ctx *Context) (interface{}, error) {
+	if !gjson.ValidBytes(ctx.Body) {
+		return nil, nil
+	}
+	value := gjson.ParseBytes(ctx.Body)
+	if n != "" {
+		value = value.Get(string(n))
+	}
+	switch value.Type {
+	case gjson.String:
+		return value.String(), nil
+	case gjson.Number:
+		return value.Float(), nil
+	case gjson.True:
+		return true, nil
+	case gjson.False:
+		return false, nil
+	case gjson.JSON:
+		return value.Raw, nil
+	default:
+		return nil, nil
+	}
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(ctx *Context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := condition(value)
+	return !ok, err
+}
+
+// logicalNode is a && or || between conditions, evaluated left to right and
+// short-circuited
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicalNode) eval(ctx *Context) (interface{}, error) {
+	value, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	left, err := condition(value)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&") != left {
+		return left, nil
+	}
+	if value, err = n.right.eval(ctx); err != nil {
+		return nil, err
+	}
+	return condition(value)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	pattern     *regexp.Regexp // right operand of matches
+}
+
+func (n compareNode) eval(ctx *Context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.pattern != nil {
+		return left != nil && n.pattern.MatchString(fmt.Sprintf("%v", left)), nil
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "in", "not in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s needs a list, got %v", n.op, right)
+		}
+		found := false
+		for _, item := range list {
+			if equalValues(left, item) {
+				found = true
+				break
+			}
+		}
+		return found == (n.op == "in"), nil
+	case "==":
+		return equalValues(left, right), nil
+	case "!=":
+		return !equalValues(left, right), nil
+	case "contains":
+		if left == nil {
+			return false, nil
+		}
+		return strings.Contains(fmt.Sprintf("%v", left), fmt.Sprintf("%v", right)), nil
+	}
+
+	l, r, ok := orderedValues(left, right)
+	if !ok {
+		return nil, fmt.Errorf("cannot compare %v %s %v", left, n.op, right)
+	}
+	switch n.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
+
+// condition returns the outcome of a condition, which must be a boolean
+func condition(value interface{}) (bool, error) {
+	ok, isBool := value.(bool)
+	if !isBool {
+		return false, fmt.Errorf("expected a condition, got %v", value)
+	}
+	return ok, nil
+}
+
+// equalValues compares numbers, latencies, booleans, strings, and null
+func equalValues(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if l, r, ok := orderedValues(a, b); ok {
+		return l == r
+	}
+	switch a := a.(type) {
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	}
+	return false
+}
+
+// orderedValues returns two numbers, or two latencies, as float64. A number
+// compared with a latency is read as milliseconds.
+func orderedValues(a, b interface{}) (float64, float64, bool) {
+	l, ok1 := orderedValue(a)
+	r, ok2 := orderedValue(b)
+	return l, r, ok1 && ok2
+}
+
+func orderedValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case time.Duration:
+		return float64(val) / float64(time.Millisecond), true
+	}
+	return 0, false
+}
+
+// Tokens of an expression
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokDuration
+	tokString
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits an expression into tokens
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != c; j++ {
+				// Only the quote and the backslash are escaped, so
+				// regular expressions keep their \d and \s
+				if runes[j] == '\\' && j+1 < len(runes) && (runes[j+1] == c || runes[j+1] == '\\') {
+					j++
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{tokString, sb.String(), i})
+			i = j + 1
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			kind := tokNumber
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				kind = tokDuration
+				j++
+			}
+			tokens = append(tokens, token{kind, string(runes[i:j]), i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[i:j]), i})
+			i = j
+		default:
+			text := string(c)
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					text = pair
+				}
+			}
+			if !strings.Contains("()[],.!<>", text) && len(text) == 1 {
+				return nil, fmt.Errorf("unexpected %q at position %d", text, i)
+			}
+			tokens = append(tokens, token{tokPunct, text, i})
+			i += len([]rune(text))
+		}
+	}
+	return append(tokens, token{tokEOF, "end of expression", len(runes)}), nil
+}
+
+// exprParser is a recursive descent parser over the tokens of an expression:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains" | "matches" | "in" | "not in" ) operand ]
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token when it is the punctuation or keyword text
+func (p *exprParser) accept(text string) bool {
+	if tok := p.peek(); (tok.kind == tokPunct || tok.kind == tokIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left = logicalNode{op: "||", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var right node
+		if right, err = p.parseUnary(); err == nil {
+			left = logicalNode{op: "&&", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">", "contains", "matches", "in"} {
+		if p.accept(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" && p.accept("not") {
+		if err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		op = "not in"
+	}
+	if op == "" {
+		return left, nil
+	}
+
+	pos := p.peek().pos
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	compare := compareNode{op: op, left: left, right: right}
+	if op == "matches" {
+		literal, _ := right.(literalNode)
+		pattern, ok := literal.value.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches needs a string pattern at position %d", pos)
+		}
+		if compare.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %w", pos, err)
+		}
+	}
+	return compare, nil
+}
+
+func (p *exprParser) parseOperand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return literalNode{n}, nil
+	case tokDuration:
+		d, err := time.ParseDuration(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q at position %d", tok.text, tok.pos)
+		}
+		return literalNode{d}, nil
+	case tokString:
+		return literalNode{tok.text}, nil
+	case tokIdent:
+		return p.parseIdent(tok)
+	case tokPunct:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			return p.parseList()
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *exprParser) parseIdent(tok token) (node, error) {
+	switch tok.text {
+	case "true":
+		return literalNode{true}, nil
+	case "false":
+		return literalNode{false}, nil
+	case "null":
+		return literalNode{nil}, nil
+	case "status", "latency", "body":
+		return fieldNode(tok.text), nil
+	case "header":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		name := p.next()
+		if name.kind != tokString {
+			return nil, fmt.Errorf("header needs a header name string at position %d", name.pos)
+		}
+		return headerNode(name.text), p.expect(")")
+	case "json":
+		for _, text := range []string{"(", "body", ")"} {
+			if err := p.expect(text); err != nil {
+				return nil, err
+			}
+		}
+		return p.parsePath()
+	}
+	return nil, fmt.Errorf("unknown name %q at position %d (expected status, latency, body, header(...), or json(body))", tok.text, tok.pos)
+}
+
+// parsePath parses the .field, ["field"], and [index] selectors after
+// json(body) into a gjson path
+func (p *exprParser) parsePath() (node, error) {
+	var path []string
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, fmt.Errorf("expected a field name at position %d, got %q", tok.pos, tok.text)
+			}
+			path = append(path, tok.text)
+		case p.accept("["):
+			tok := p.next()
+			if tok.kind != tokString && tok.kind != tokNumber {
+				return nil, fmt.Errorf("expected a field name or index at position %d, got %q", tok.pos, tok.text)
+			}
+			path = append(path, escapePath(tok.text))
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return jsonNode(strings.Join(path, ".")), nil
+		}
+	}
+}
+
+func (p *exprParser) parseList() (node, error) {
+	var list listNode
+	if p.accept("]") {
+		return list, nil
+	}
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+		if p.accept("]") {
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// escapePath escapes the characters gjson gives a meaning to in a field name
+func escapePath(name string) string {
+	var sb strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune(`.*?|#@!\`, c) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package assertion
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	ctx := NewContext(200, 120*time.Millisecond, []byte(`{"state":"done","count":3,"ok":true,"error":null,"items":[{"id":"a"}],"my.key":1}`), headers)
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{`status == 200`, true},
+		{`status in [200, 202]`, true},
+		{`status not in [200, 202]`, false},
+		{`status >= 400`, false},
+		{`latency < 500ms`, true},
+		{`latency > 100`, true},
+		{`json(body).state != 'error'`, true},
+		{`json(body).state == "done" && json(body).count > 2`, true},
+		{`json(body).ok`, true},
+		{`!json(body).ok`, false},
+		{`json(body).error == null`, true},
+		{`json(body).missing == null`, true},
+		{`json(body).items[0].id == 'a'`, true},
+		{`json(body)["my.key"] == 1`, true},
+		{`body contains 'done'`, true},
+		{`body matches '"count":\d+'`, true},
+		{`header('content-type') contains 'json'`, true},
+		{`header('X-Missing') == null`, true},
+		{`status == 500 || json(body).state == 'done'`, true},
+		{`(status == 500 || status == 200) && !(latency > 1s)`, true},
+		{`status == '200'`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expression, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+			got, err := expression.Eval(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpression_NotJSON(t *testing.T) {
+	expression, err := ParseExpression(`status == 200 && json(body).state != 'error'`)
+	require.NoError(t, err)
+
+	ok, err := expression.Eval(NewContext(200, time.Millisecond, []byte("<html>"), nil))
+	require.NoError(t, err)
+	assert.True(t, ok, "a body that is not JSON has no fields")
+}
+
+func TestExpression_ReadsBody(t *testing.T) {
+	tests := map[string]bool{
+		`status in [200, 202] && latency < 500ms`:             false,
+		`header('Content-Type') contains 'json'`:              false,
+		`status == 200 && json(body).state != 'error'`:        true,
+		`!(body contains 'error')`:                            true,
+		`status == 200 || header('X-Cache') == json(body).id`: true,
+	}
+	for source, want := range tests {
+		expression, err := ParseExpression(source)
+		require.NoError(t, err)
+		assert.Equal(t, want, expression.ReadsBody(), source)
+	}
+}
+
+func TestExpression_EvalErrors(t *testing.T) {
+	ctx := NewContext(200, time.Millisecond, []byte(`{"state":"done"}`), nil)
+
+	for _, source := range []string{`status`, `json(body).state > 1`, `status in 200`} {
+		expression, err := ParseExpression(source)
+		require.NoError(t, err)
+		_, err = expression.Eval(ctx)
+		assert.Error(t, err, source)
+	}
+
+	// The right side of || is not evaluated when the left one holds
+	expression, err := ParseExpression(`status == 200 || json(body).state > 1`)
+	require.NoError(t, err)
+	ok, err := expression.Eval(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{`status ==`, "unexpected \"end of expression\""},
+		{`status = 200`, "unexpected \"=\" at position 7"},
+		{`state == 'done'`, "unknown name \"state\""},
+		{`json(body).state == 'done`, "unterminated string"},
+		{`json(response).state`, "expected \"body\""},
+		{`header(Accept)`, "header needs a header name string"},
+		{`status in [200, 202`, "expected \",\""},
+		{`latency < 5xs`, "invalid duration \"5xs\""},
+		{`body matches '(['`, "invalid regular expression"},
+		{`body matches status`, "matches needs a string pattern"},
+		{`status == 200 status`, "unexpected \"status\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseExpression(tt.expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
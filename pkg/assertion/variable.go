@@ -0,0 +1,138 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// variableCheckPattern matches a check of an extracted variable (assert_variables),
+// e.g. ${order_total} gt 0 or ${token} matches "^ey"
+var variableCheckPattern = regexp.MustCompile(`^\s*(\$\{[^}]+\})\s+(\S+)(?:\s+(.*?))?\s*$`)
+
+// VariableCheck is a parsed check of an extracted variable
+type VariableCheck struct {
+	Reference string      // ${variable} reference, with its default and transforms
+	Name      string      // Variable the reference reads
+	Operator  string      // Assertion operator, e.g. gt or matches
+	Value     interface{} // Expected value, nil for exists and not_exists
+}
+
+// ParseVariableCheck parses a check of assert_variables. The expected value
+// is a number, true, false, null, or a string in single or double quotes;
+// other text is compared as it is.
+func ParseVariableCheck(source string) (VariableCheck, error) {
+	groups := variableCheckPattern.FindStringSubmatch(source)
+	if groups == nil {
+		return VariableCheck{}, fmt.Errorf("expected '${variable} operator value', got %q", source)
+	}
+	check := VariableCheck{Reference: groups[1], Operator: groups[2]}
+	check.Name = strings.TrimSpace(groups[1][len("${"):])
+	if end := strings.IndexAny(check.Name, ":| }"); end >= 0 {
+		check.Name = check.Name[:end]
+	}
+
+	if check.Operator == "exists" || check.Operator == "not_exists" {
+		if groups[3] != "" {
+			return VariableCheck{}, fmt.Errorf("%s takes no value", check.Operator)
+		}
+		return check, nil
+	}
+	if !containsString(comparisonOperators, check.Operator) {
+		return VariableCheck{}, fmt.Errorf("unknown operator '%s' (expected %s)", check.Operator, strings.Join(append(append([]string{}, comparisonOperators...), presenceOperators...), ", "))
+	}
+	if groups[3] == "" {
+		return VariableCheck{}, fmt.Errorf("%s needs a value", check.Operator)
+	}
+
+	value, err := parseCheckValue(groups[3])
+	if err != nil {
+		return VariableCheck{}, err
+	}
+	check.Value = value
+	if check.Operator == "matches" {
+		if _, err := regexp.Compile(fmt.Sprintf("%v", value)); err != nil {
+			return VariableCheck{}, fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+	return check, nil
+}
+
+// parseCheckValue reads the expected value of a variable check
+func parseCheckValue(text string) (interface{}, error) {
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number, nil
+	}
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return text[1 : len(text)-1], nil
+	}
+	if text[0] == '"' {
+		var value string
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("invalid string %s", text)
+		}
+		return value, nil
+	}
+	return text, nil
+}
+
+// Assertion returns the check as an assertion of type variable, for the
+// assertion outcomes of a result
+func (c VariableCheck) Assertion() models.Assertion {
+	return models.Assertion{Type: "variable", Target: c.Reference, Operator: c.Operator, Value: c.Value}
+}
+
+// EvaluateVariable checks the value of a variable, found reporting whether
+// it is set
+func (e *Evaluator) EvaluateVariable(check VariableCheck, value interface{}, found bool) Result {
+	result := Result{
+		Assertion:   check.Assertion(),
+		ActualValue: value,
+		Passed:      false,
+	}
+
+	switch check.Operator {
+	case "exists":
+		result.Passed = found
+		if !found {
+			result.Message = fmt.Sprintf("variable %s is not set", check.Reference)
+		}
+		return result
+	case "not_exists":
+		result.Passed = !found
+		if found {
+			result.Message = fmt.Sprintf("variable %s is set but should not be", check.Reference)
+		}
+		return result
+	}
+
+	if !found {
+		result.Message = fmt.Sprintf("variable %s is not set", check.Reference)
+		return result
+	}
+
+	passed, err := e.compare(check.Operator, value, check.Value)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Passed = passed
+	if !passed {
+		result.Message = fmt.Sprintf("variable assertion failed: %s %s %v, got %v",
+			check.Reference, check.Operator, check.Value, value)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package assertion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariableCheck(t *testing.T) {
+	tests := []struct {
+		source string
+		want   VariableCheck
+	}{
+		{`${order_total} gt 0`, VariableCheck{Reference: "${order_total}", Name: "order_total", Operator: "gt", Value: 0.0}},
+		{`${token} matches "^ey"`, VariableCheck{Reference: "${token}", Name: "token", Operator: "matches", Value: "^ey"}},
+		{`${status} eq 'shipped'`, VariableCheck{Reference: "${status}", Name: "status", Operator: "eq", Value: "shipped"}},
+		{`${name | upper} starts_with MAR`, VariableCheck{Reference: "${name | upper}", Name: "name", Operator: "starts_with", Value: "MAR"}},
+		{`${paid} eq true`, VariableCheck{Reference: "${paid}", Name: "paid", Operator: "eq", Value: true}},
+		{`${order_id} exists`, VariableCheck{Reference: "${order_id}", Name: "order_id", Operator: "exists"}},
+		{`${region:-eu} eq eu`, VariableCheck{Reference: "${region:-eu}", Name: "region", Operator: "eq", Value: "eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			check, err := ParseVariableCheck(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, check)
+		})
+	}
+}
+
+func TestParseVariableCheck_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`order_total gt 0`, "expected '${variable} operator value'"},
+		{`${order_total} bigger 0`, "unknown operator 'bigger'"},
+		{`${order_total} gt`, "gt needs a value"},
+		{`${order_id} exists 1`, "exists takes no value"},
+		{`${token} matches "("`, "invalid regular expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := ParseVariableCheck(tt.source)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestEvaluator_EvaluateVariable(t *testing.T) {
+	evaluator := New(false)
+	total, _ := ParseVariableCheck(`${order_total} gt 0`)
+	exists, _ := ParseVariableCheck(`${order_id} exists`)
+
+	result := evaluator.EvaluateVariable(total, 12.5, true)
+	assert.True(t, result.Passed)
+	assert.Equal(t, "variable", result.Assertion.Type)
+
+	result = evaluator.EvaluateVariable(total, 0.0, true)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "variable assertion failed: ${order_total} gt 0, got 0", result.Message)
+
+	result = evaluator.EvaluateVariable(total, nil, false)
+	assert.False(t, result.Passed)
+	assert.Equal(t, "variable ${order_total} is not set", result.Message)
+
+	assert.False(t, evaluator.EvaluateVariable(exists, nil, false).Passed)
+	assert.True(t, evaluator.EvaluateVariable(exists, "42", true).Passed)
+}
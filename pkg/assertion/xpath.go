@@ -0,0 +1,306 @@
+package assertion
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XPath is a parsed XPath expression of the subset used by xpath assertions:
+// absolute and relative location paths with / and //, element names (their
+// namespace prefix is ignored) or *, the predicates [n], [@attr='v'] and
+// [name='v'], and a last step selecting an attribute (@name) or text().
+type XPath struct {
+	steps []xpathStep
+	attr  string // last step @name
+	text  bool   // last step text()
+}
+
+type xpathStep struct {
+	descendant bool // reached through //
+	name       string
+	predicates []xpathPredicate
+}
+
+// xpathPredicate filters the nodes of a step: by 1-based position, or by an
+// attribute or child element value
+type xpathPredicate struct {
+	index int
+	attr  string
+	child string
+	value string
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	name     string // local name
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder // character data directly inside the element
+}
+
+// ParseXPath parses an XPath expression
+func ParseXPath(expr string) (*XPath, error) {
+	path := strings.TrimSpace(expr)
+	if path == "" {
+		return nil, fmt.Errorf("empty xpath")
+	}
+	x := &XPath{}
+	descendant := false
+	if strings.HasPrefix(path, "//") {
+		descendant, path = true, path[2:]
+	} else {
+		path = strings.TrimPrefix(path, "/")
+	}
+
+	for path != "" {
+		raw, rest, sep := splitXPathStep(path)
+		if raw == "" {
+			return nil, fmt.Errorf("invalid xpath '%s': empty step", expr)
+		}
+		last := rest == ""
+		switch {
+		case last && strings.HasPrefix(raw, "@"):
+			x.attr = localName(raw[1:])
+		case last && raw == "text()":
+			x.text = true
+		default:
+			step, err := parseXPathStep(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid xpath '%s': %w", expr, err)
+			}
+			step.descendant = descendant
+			x.steps = append(x.steps, step)
+		}
+		descendant, path = sep == "//", rest
+		if sep != "" && rest == "" {
+			return nil, fmt.Errorf("invalid xpath '%s': trailing /", expr)
+		}
+	}
+	if len(x.steps) == 0 {
+		return nil, fmt.Errorf("invalid xpath '%s': no element step", expr)
+	}
+	return x, nil
+}
+
+// splitXPathStep returns the first step of path, the rest after its
+// separator, and the separator (/ or //), ignoring slashes in predicates
+func splitXPathStep(path string) (string, string, string) {
+	depth := 0
+	var quote rune
+	for i, c := range path {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			if strings.HasPrefix(path[i:], "//") {
+				return path[:i], path[i+2:], "//"
+			}
+			return path[:i], path[i+1:], "/"
+		}
+	}
+	return path, "", ""
+}
+
+func parseXPathStep(raw string) (xpathStep, error) {
+	name, predicates, _ := strings.Cut(raw, "[")
+	step := xpathStep{name: localName(name)}
+	if step.name == "" || strings.ContainsAny(step.name, "@()=") {
+		return step, fmt.Errorf("invalid step '%s'", raw)
+	}
+	if predicates == "" {
+		return step, nil
+	}
+	for _, rawPredicate := range strings.Split(strings.TrimSuffix("["+predicates, "]"), "][") {
+		predicate, err := parseXPathPredicate(strings.TrimPrefix(rawPredicate, "["))
+		if err != nil {
+			return step, err
+		}
+		step.predicates = append(step.predicates, predicate)
+	}
+	return step, nil
+}
+
+func parseXPathPredicate(raw string) (xpathPredicate, error) {
+	raw = strings.TrimSpace(raw)
+	if index, err := strconv.Atoi(raw); err == nil {
+		if index < 1 {
+			return xpathPredicate{}, fmt.Errorf("position must be at least 1, got %d", index)
+		}
+		return xpathPredicate{index: index}, nil
+	}
+	left, right, ok := strings.Cut(raw, "=")
+	right = strings.TrimSpace(right)
+	if !ok || len(right) < 2 || (right[0] != '\'' && right[0] != '"') || right[len(right)-1] != right[0] {
+		return xpathPredicate{}, fmt.Errorf("unsupported predicate '[%s]' (expected [n], [@attr='value'], or [name='value'])", raw)
+	}
+	predicate := xpathPredicate{value: right[1 : len(right)-1]}
+	left = strings.TrimSpace(left)
+	if strings.HasPrefix(left, "@") {
+		predicate.attr = localName(left[1:])
+	} else {
+		predicate.child = localName(left)
+	}
+	return predicate, nil
+}
+
+// localName drops the namespace prefix of a name
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return strings.TrimSpace(name[i+1:])
+	}
+	return strings.TrimSpace(name)
+}
+
+// Select returns the values the expression selects in an XML document: the
+// attribute values, the direct text, or the text content of the elements
+func (x *XPath) Select(document []byte) ([]string, error) {
+	root, err := parseXML(document)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*xmlNode{root}
+	for _, step := range x.steps {
+		nodes = step.apply(nodes)
+	}
+
+	var values []string
+	for _, node := range nodes {
+		switch {
+		case x.attr != "":
+			if value, ok := node.attr(x.attr); ok {
+				values = append(values, value)
+			}
+		case x.text:
+			values = append(values, strings.TrimSpace(node.text.String()))
+		default:
+			values = append(values, strings.TrimSpace(node.content()))
+		}
+	}
+	return values, nil
+}
+
+// apply selects the matching children, or descendants, of nodes
+func (s xpathStep) apply(nodes []*xmlNode) []*xmlNode {
+	var selected []*xmlNode
+	for _, node := range nodes {
+		var candidates []*xmlNode
+		if s.descendant {
+			node.walk(func(n *xmlNode) {
+				if n != node && s.matches(n) {
+					candidates = append(candidates, n)
+				}
+			})
+		} else {
+			for _, child := range node.children {
+				if s.matches(child) {
+					candidates = append(candidates, child)
+				}
+			}
+		}
+		for _, predicate := range s.predicates {
+			candidates = predicate.filter(candidates)
+		}
+		selected = append(selected, candidates...)
+	}
+	return selected
+}
+
+func (s xpathStep) matches(node *xmlNode) bool {
+	return s.name == "*" || s.name == node.name
+}
+
+func (p xpathPredicate) filter(nodes []*xmlNode) []*xmlNode {
+	if p.index > 0 {
+		if p.index > len(nodes) {
+			return nil
+		}
+		return nodes[p.index-1 : p.index]
+	}
+	var kept []*xmlNode
+	for _, node := range nodes {
+		if p.attr != "" {
+			if value, ok := node.attr(p.attr); ok && value == p.value {
+				kept = append(kept, node)
+			}
+			continue
+		}
+		for _, child := range node.children {
+			if child.name == p.child && strings.TrimSpace(child.content()) == p.value {
+				kept = append(kept, node)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// content returns the text of the element and its descendants
+func (n *xmlNode) content() string {
+	var sb strings.Builder
+	n.walk(func(node *xmlNode) {
+		sb.WriteString(node.text.String())
+	})
+	return sb.String()
+}
+
+func (n *xmlNode) walk(visit func(*xmlNode)) {
+	visit(n)
+	for _, child := range n.children {
+		child.walk(visit)
+	}
+}
+
+// parseXML parses a document into a tree under a nameless root node
+func parseXML(document []byte) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML in response body: %w", err)
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.text.Write(t)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("invalid XML in response body: no root element")
+	}
+	return root, nil
+}
@@ -0,0 +1,90 @@
+package assertion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const soapResponse = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <m:GetUserResponse xmlns:m="http://example.com/users">
+      <m:User id="42" status="active">
+        <m:Name>Mario</m:Name>
+        <m:Age>35</m:Age>
+      </m:User>
+      <m:User id="43" status="inactive">
+        <m:Name>Luigi</m:Name>
+        <m:Age>33</m:Age>
+      </m:User>
+    </m:GetUserResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXPath_Select(t *testing.T) {
+	tests := []struct {
+		xpath string
+		want  []string
+	}{
+		{"/soap:Envelope/soap:Body/m:GetUserResponse/m:User/m:Name", []string{"Mario", "Luigi"}},
+		{"//User[1]/Name", []string{"Mario"}},
+		{"//User[2]/@id", []string{"43"}},
+		{"//User[@status='inactive']/Name/text()", []string{"Luigi"}},
+		{"//User[Name='Mario']/Age", []string{"35"}},
+		{"//GetUserResponse/*[@id='42']/Name", []string{"Mario"}},
+		{"Envelope/Body//Age", []string{"35", "33"}},
+		{"//Fault", nil},
+		{"//User[3]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.xpath, func(t *testing.T) {
+			xpath, err := ParseXPath(tt.xpath)
+			require.NoError(t, err)
+			values, err := xpath.Select([]byte(soapResponse))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func TestParseXPath_Errors(t *testing.T) {
+	for _, xpath := range []string{"", "//User/", "//User[0]", "//User[contains(Name, 'M')]", "/@id", "//User/@id/Name"} {
+		_, err := ParseXPath(xpath)
+		assert.Error(t, err, xpath)
+	}
+}
+
+func TestXPathAssertion(t *testing.T) {
+	evaluator := New(false)
+	ctx := NewContext(200, 50*time.Millisecond, []byte(soapResponse), nil)
+
+	tests := []struct {
+		name      string
+		assertion models.Assertion
+		passed    bool
+	}{
+		{"eq string", models.Assertion{Type: "xpath", Target: "//User[1]/Name", Operator: "eq", Value: "Mario"}, true},
+		{"gt number", models.Assertion{Type: "xpath", Target: "//User[1]/Age", Operator: "gt", Value: 30.0}, true},
+		{"eq number", models.Assertion{Type: "xpath", Target: "//User[2]/@id", Operator: "eq", Value: 43.0}, true},
+		{"exists", models.Assertion{Type: "xpath", Target: "//User", Operator: "exists"}, true},
+		{"not_exists", models.Assertion{Type: "xpath", Target: "//Fault", Operator: "not_exists"}, true},
+		{"missing", models.Assertion{Type: "xpath", Target: "//Fault/faultstring", Operator: "eq", Value: "x"}, false},
+		{"mismatch", models.Assertion{Type: "xpath", Target: "//User[1]/Name", Operator: "eq", Value: "Luigi"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluator.Evaluate(tt.assertion, ctx)
+			assert.Equal(t, tt.passed, result.Passed, result.Message)
+		})
+	}
+
+	result := evaluator.Evaluate(models.Assertion{Type: "xpath", Target: "//Name", Operator: "exists"}, NewContext(200, 0, []byte(`{"name":"Mario"}`), nil))
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Message, "invalid XML")
+}
@@ -0,0 +1,58 @@
+package awssig
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsFromEnv(t *testing.T) {
+	env := map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "SECRET", "AWS_SESSION_TOKEN": "TOKEN"}
+	creds, err := CredentialsFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, creds)
+
+	_, err = CredentialsFromEnv(func(string) string { return "" })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AWS_ACCESS_KEY_ID")
+}
+
+func TestRegionFromEnv(t *testing.T) {
+	env := map[string]string{"AWS_DEFAULT_REGION": "us-east-1"}
+	getenv := func(key string) string { return env[key] }
+
+	region, err := RegionFromEnv("eu-west-1", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	region, err = RegionFromEnv("", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", region)
+
+	_, err = RegionFromEnv("", func(string) string { return "" })
+	require.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	creds := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}
+
+	req, err := http.NewRequest(http.MethodGet, "https://bucket.s3.eu-west-1.amazonaws.com/users.csv", nil)
+	require.NoError(t, err)
+	Sign(req, nil, creds, "eu-west-1", "s3", now)
+
+	assert.Equal(t, "20240102T030405Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "TOKEN", req.Header.Get("X-Amz-Security-Token"))
+	// SHA-256 of the empty payload
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Contains(t, req.Header.Get("Authorization"), "Credential=AKID/20240102/eu-west-1/s3/aws4_request")
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token")
+
+	// Signing is deterministic
+	again, _ := http.NewRequest(http.MethodGet, req.URL.String(), nil)
+	Sign(again, nil, creds, "eu-west-1", "s3", now)
+	assert.Equal(t, req.Header.Get("Authorization"), again.Header.Get("Authorization"))
+}
@@ -0,0 +1,120 @@
+// Package checkpoint saves the progress of a run to a file, so that a run
+// cut short by a crash or a restart can be resumed where it stopped.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Checkpoint is the progress of a run at the time it was saved
+type Checkpoint struct {
+	Config    string                 // Name of the config the run was started with
+	RunID     string                 // ID of the run, kept by the resumed one
+	Elapsed   time.Duration          // Time the run has been running, over every resume
+	Completed map[string]int         // Finished iterations per test, counted from the first one until the first still unfinished
+	Finished  map[string][]int       // Iterations finished past those, by 1-based position, in order
+	Variables map[string]interface{} // Variable store, without secrets
+	SavedAt   time.Time
+}
+
+// file is the JSON layout of a checkpoint, with a readable elapsed time
+type file struct {
+	Config    string                 `json:"config"`
+	RunID     string                 `json:"run_id"`
+	Elapsed   string                 `json:"elapsed"`
+	Completed map[string]int         `json:"completed"`
+	Finished  map[string][]int       `json:"finished,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	SavedAt   time.Time              `json:"saved_at"`
+}
+
+// Save writes a checkpoint to path, replacing it atomically so that a crash
+// while saving leaves the previous checkpoint intact
+func Save(path string, cp *Checkpoint) error {
+	data, err := json.MarshalIndent(file{
+		Config:    cp.Config,
+		RunID:     cp.RunID,
+		Elapsed:   cp.Elapsed.Round(time.Millisecond).String(),
+		Completed: cp.Completed,
+		Finished:  cp.Finished,
+		Variables: cp.Variables,
+		SavedAt:   cp.SavedAt,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads the checkpoint saved at path
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	elapsed, err := time.ParseDuration(f.Elapsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: elapsed: %w", err)
+	}
+	if f.Completed == nil {
+		f.Completed = make(map[string]int)
+	}
+	return &Checkpoint{
+		Config:    f.Config,
+		RunID:     f.RunID,
+		Elapsed:   elapsed,
+		Completed: f.Completed,
+		Finished:  f.Finished,
+		Variables: f.Variables,
+		SavedAt:   f.SavedAt,
+	}, nil
+}
+
+// Check reports whether a checkpoint was saved by a run of config, so that
+// resuming it skips the iterations of the same tests
+func (cp *Checkpoint) Check(config *models.Config) error {
+	if cp.Config != config.Name {
+		return fmt.Errorf("checkpoint was saved by config %q, not %q", cp.Config, config.Name)
+	}
+	tests := make(map[string]bool, len(config.Tests))
+	for _, test := range config.Tests {
+		tests[test.Name] = true
+	}
+	for test := range cp.Completed {
+		if !tests[test] {
+			return fmt.Errorf("checkpoint has iterations of test %q, which is not in the config", test)
+		}
+	}
+	for test := range cp.Finished {
+		if !tests[test] {
+			return fmt.Errorf("checkpoint has iterations of test %q, which is not in the config", test)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	saved := &Checkpoint{
+		Config:    "API",
+		RunID:     "run-1",
+		Elapsed:   90 * time.Second,
+		Completed: map[string]int{"login": 40, "browse": 12},
+		Finished:  map[string][]int{"browse": {14, 15}},
+		Variables: map[string]interface{}{"token": "abc"},
+		SavedAt:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, Save(path, saved))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"elapsed": "1m30s"`)
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, loaded)
+
+	// Saving again replaces the file without leaving temporary files behind
+	saved.Completed["login"] = 50
+	require.NoError(t, Save(path, saved))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read checkpoint")
+
+	path := filepath.Join(dir, "checkpoint.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"config": "API", "elapsed": "soon"}`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "failed to parse checkpoint: elapsed")
+}
+
+func TestCheckpoint_Check(t *testing.T) {
+	config := &models.Config{Name: "API", Tests: []models.TestCase{{Name: "login"}, {Name: "browse"}}}
+
+	assert.NoError(t, (&Checkpoint{Config: "API", Completed: map[string]int{"login": 3}}).Check(config))
+	assert.EqualError(t, (&Checkpoint{Config: "Other"}).Check(config), `checkpoint was saved by config "Other", not "API"`)
+	assert.EqualError(t, (&Checkpoint{Config: "API", Completed: map[string]int{"checkout": 1}}).Check(config),
+		`checkpoint has iterations of test "checkout", which is not in the config`)
+	assert.EqualError(t, (&Checkpoint{Config: "API", Finished: map[string][]int{"checkout": {2}}}).Check(config),
+		`checkpoint has iterations of test "checkout", which is not in the config`)
+}
@@ -12,7 +12,18 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-// Evaluator performs response comparisons
+// Comparison modes for full body comparisons
+const (
+	ModeFull       = "full"       // Every field and element must match
+	ModePartial    = "partial"    // Only fields present in the primary response are compared
+	ModeStructural = "structural" // Fields and types must match, values may differ
+)
+
+// Modes lists the supported comparison modes
+var Modes = []string{ModeFull, ModePartial, ModeStructural}
+
+// Evaluator performs response comparisons. Its settings are not safe to
+// change while comparisons run, so use one evaluator per configuration.
 type Evaluator struct {
 	verbose      bool
 	ignoreFields map[string]bool
@@ -24,7 +35,7 @@ func New(verbose bool) *Evaluator {
 	return &Evaluator{
 		verbose:      verbose,
 		ignoreFields: make(map[string]bool),
-		mode:         "full",
+		mode:         ModeFull,
 	}
 }
 
@@ -39,7 +50,7 @@ func (e *Evaluator) SetIgnoreFields(fields []string) {
 // SetMode sets the comparison mode
 func (e *Evaluator) SetMode(mode string) {
 	if mode == "" {
-		mode = "full"
+		mode = ModeFull
 	}
 	e.mode = mode
 }
@@ -549,7 +560,7 @@ func (e *Evaluator) compareValues(primary, compare interface{}, path string) []F
 			if path != "" {
 				newPath = path + "." + key
 			}
-			if _, ok := pVal[key]; !ok && !e.isIgnored(newPath) {
+			if _, ok := pVal[key]; !ok && e.mode != ModePartial && !e.isIgnored(newPath) {
 				diffs = append(diffs, FieldDiff{
 					Path:         newPath,
 					DiffType:     DiffExtra,
@@ -561,7 +572,7 @@ func (e *Evaluator) compareValues(primary, compare interface{}, path string) []F
 
 	case []interface{}:
 		cVal := compare.([]interface{})
-		if e.mode == "structural" {
+		if e.mode == ModeStructural {
 			// Only compare structure, not individual array elements
 			if len(pVal) > 0 && len(cVal) > 0 {
 				diffs = append(diffs, e.compareValues(pVal[0], cVal[0], path+"[0]")...)
@@ -575,6 +586,9 @@ func (e *Evaluator) compareValues(primary, compare interface{}, path string) []F
 			for i := 0; i < maxLen; i++ {
 				elemPath := fmt.Sprintf("%s[%d]", path, i)
 				if i >= len(pVal) {
+					if e.mode == ModePartial {
+						break
+					}
 					diffs = append(diffs, FieldDiff{
 						Path:         elemPath,
 						DiffType:     DiffExtra,
@@ -595,7 +609,7 @@ func (e *Evaluator) compareValues(primary, compare interface{}, path string) []F
 		}
 
 	default:
-		if !reflect.DeepEqual(primary, compare) {
+		if e.mode != ModeStructural && !reflect.DeepEqual(primary, compare) {
 			diffs = append(diffs, FieldDiff{
 				Path:         path,
 				DiffType:     DiffValueMismatch,
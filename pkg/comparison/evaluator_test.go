@@ -196,6 +196,35 @@ func TestIgnoreFields_NestedPath(t *testing.T) {
 	assert.True(t, result.Success)
 }
 
+func TestModes(t *testing.T) {
+	primary := []byte(`{"id": 1, "name": "test", "items": [{"sku": "a"}]}`)
+	tests := []struct {
+		name    string
+		compare string
+		full    bool
+		partial bool
+		struc   bool
+	}{
+		{"identical", `{"id": 1, "name": "test", "items": [{"sku": "a"}]}`, true, true, true},
+		{"extra field", `{"id": 1, "name": "test", "items": [{"sku": "a"}], "version": 2}`, false, true, false},
+		{"extra element", `{"id": 1, "name": "test", "items": [{"sku": "a"}, {"sku": "b"}]}`, false, true, true},
+		{"different value", `{"id": 2, "name": "other", "items": [{"sku": "b"}]}`, false, false, true},
+		{"missing field", `{"id": 1, "items": [{"sku": "a"}]}`, false, false, false},
+		{"different type", `{"id": "1", "name": "test", "items": [{"sku": "a"}]}`, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for mode, want := range map[string]bool{ModeFull: tt.full, ModePartial: tt.partial, ModeStructural: tt.struc} {
+				e := New(false)
+				e.SetMode(mode)
+				ctx := NewContext(200, 0, primary, nil, 200, 0, []byte(tt.compare), nil)
+				assert.Equal(t, want, e.Compare(ctx, nil).Success, "mode %s", mode)
+			}
+		})
+	}
+}
+
 func TestResponseTimeTolerance_WithinTolerance(t *testing.T) {
 	e := New(false)
 	ctx := NewContext(
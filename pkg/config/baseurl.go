@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// baseURLs is a base_url: a single URL, or a list of URLs (or {url, weight}
+// objects) the requests are spread over
+type baseURLs []models.Target
+
+func (b *baseURLs) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*b = nil
+		if single != "" {
+			*b = baseURLs{{URL: single}}
+		}
+		return nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("base_url must be a URL or a list of URLs")
+	}
+	targets := make(baseURLs, 0, len(items))
+	for i, item := range items {
+		var target models.Target
+		if err := json.Unmarshal(item, &target.URL); err != nil {
+			if err := json.Unmarshal(item, &target); err != nil {
+				return fmt.Errorf("base_url %d must be a URL or an object with url and weight", i)
+			}
+		}
+		targets = append(targets, target)
+	}
+	*b = targets
+	return nil
+}
+
+// split returns the first base URL, which stands for the list where a single
+// one is needed, and the targets when there are several
+func (b baseURLs) split() (string, []models.Target, error) {
+	if b != nil && len(b) == 0 {
+		return "", nil, fmt.Errorf("is an empty list")
+	}
+	for i, target := range b {
+		if target.URL == "" {
+			return "", nil, fmt.Errorf("%d: url is required", i)
+		}
+		if target.Weight < 0 {
+			return "", nil, fmt.Errorf("%d: weight must not be negative", i)
+		}
+	}
+	if len(b) == 0 {
+		return "", nil, nil
+	}
+	if len(b) == 1 {
+		return b[0].URL, nil, nil
+	}
+	return b[0].URL, []models.Target(b), nil
+}
+
+// baseURLSchema describes a base_url, which encoding/json decodes through
+// UnmarshalJSON rather than from the shape of its type
+func baseURLSchema(defs map[string]interface{}) map[string]interface{} {
+	target := typeSchema(reflect.TypeOf(models.Target{}), defs)
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items": map[string]interface{}{
+					"oneOf": []interface{}{map[string]interface{}{"type": "string"}, target},
+				},
+			},
+		},
+	}
+}
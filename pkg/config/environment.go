@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// ApplyEnvironment merges the named environment over the global settings.
+// An empty name keeps the global settings, which then must define base_url,
+// unless a replay sends its requests to their captured URLs.
+func ApplyEnvironment(config *models.Config, name string) error {
+	if name == "" {
+		if config.Global.BaseURL == "" && config.Global.Replay == nil {
+			return fmt.Errorf("no base_url configured; select an environment with -env (available: %s)", environmentNames(config))
+		}
+		return nil
+	}
+
+	env, ok := config.Environments[name]
+	if !ok {
+		if len(config.Environments) == 0 {
+			return fmt.Errorf("unknown environment '%s' (config defines no environments)", name)
+		}
+		return fmt.Errorf("unknown environment '%s' (available: %s)", name, environmentNames(config))
+	}
+
+	if env.BaseURL != "" {
+		config.Global.BaseURL = env.BaseURL
+		config.Global.Targets = env.Targets
+	}
+	if len(env.Headers) > 0 {
+		headers := make(models.Headers, len(config.Global.Headers)+len(env.Headers))
+		for key, value := range config.Global.Headers {
+			headers[key] = value
+		}
+		for key, value := range env.Headers {
+			headers[key] = value
+		}
+		config.Global.Headers = headers
+	}
+	if len(env.Variables) > 0 {
+		variables := make(map[string]interface{}, len(config.Global.Variables)+len(env.Variables))
+		for key, value := range config.Global.Variables {
+			variables[key] = value
+		}
+		for key, value := range env.Variables {
+			variables[key] = value
+		}
+		config.Global.Variables = variables
+	}
+
+	return nil
+}
+
+func environmentNames(config *models.Config) string {
+	names := make([]string, 0, len(config.Environments))
+	for name := range config.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func environmentConfig() *models.Config {
+	return &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:   "http://localhost:8080",
+			Targets:   []models.Target{{URL: "http://localhost:8080"}, {URL: "http://localhost:8081"}},
+			Headers:   models.Headers{"Accept": "application/json", "X-Env": "dev"},
+			Variables: map[string]interface{}{"tenant": "acme", "user": "dev"},
+		},
+		Environments: map[string]models.Environment{
+			"staging": {
+				BaseURL:   "https://staging.example.com",
+				Headers:   models.Headers{"X-Env": "staging"},
+				Variables: map[string]interface{}{"user": "qa"},
+			},
+			"prod": {BaseURL: "https://api.example.com"},
+		},
+	}
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	config := environmentConfig()
+	require.NoError(t, ApplyEnvironment(config, "staging"))
+
+	assert.Equal(t, "https://staging.example.com", config.Global.BaseURL)
+	assert.Nil(t, config.Global.Targets)
+	assert.Equal(t, models.Headers{"Accept": "application/json", "X-Env": "staging"}, config.Global.Headers)
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "user": "qa"}, config.Global.Variables)
+}
+
+func TestApplyEnvironment_NoSelection(t *testing.T) {
+	config := environmentConfig()
+	require.NoError(t, ApplyEnvironment(config, ""))
+	assert.Equal(t, "http://localhost:8080", config.Global.BaseURL)
+
+	config.Global.BaseURL = ""
+	err := ApplyEnvironment(config, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: prod, staging")
+}
+
+func TestApplyEnvironment_Unknown(t *testing.T) {
+	err := ApplyEnvironment(environmentConfig(), "qa")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown environment 'qa' (available: prod, staging)")
+
+	err = ApplyEnvironment(&models.Config{}, "qa")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "defines no environments")
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// FilterByTags keeps the tests that have at least one of the include tags
+// (all tests when include is empty) and none of the exclude tags. Tests that a
+// kept test depends on are always kept so chained flows still work.
+func FilterByTags(config *models.Config, include, exclude []string) error {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	return selectTests(config, func(test models.TestCase) bool {
+		if hasAnyTag(test, exclude) {
+			return false
+		}
+		return len(include) == 0 || hasAnyTag(test, include)
+	})
+}
+
+// SelectByName keeps only the named tests and the tests they depend on
+func SelectByName(config *models.Config, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	for _, test := range config.Tests {
+		delete(wanted, test.Name)
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return fmt.Errorf("unknown test '%s'", name)
+		}
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	return selectTests(config, func(test models.TestCase) bool {
+		return selected[test.Name]
+	})
+}
+
+// ParseList splits a comma-separated flag value, dropping empty entries
+func ParseList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func hasAnyTag(test models.TestCase, tags []string) bool {
+	for _, tag := range tags {
+		for _, own := range test.Tags {
+			if strings.EqualFold(own, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectTests keeps the tests for which keep returns true plus their
+// depends_on ancestors, preserving config order. Scenarios lose the tests
+// that were dropped, and scenarios left without tests are removed.
+func selectTests(config *models.Config, keep func(models.TestCase) bool) error {
+	byName := make(map[string]models.TestCase, len(config.Tests))
+	for _, test := range config.Tests {
+		byName[test.Name] = test
+	}
+
+	selected := make(map[string]bool)
+	var include func(name string)
+	include = func(name string) {
+		if selected[name] {
+			return
+		}
+		test, ok := byName[name]
+		if !ok {
+			return
+		}
+		selected[name] = true
+		for _, dep := range test.DependsOn {
+			include(dep)
+		}
+	}
+	for _, test := range config.Tests {
+		if keep(test) {
+			include(test.Name)
+		}
+	}
+
+	if len(selected) == 0 {
+		return fmt.Errorf("no tests match the selection")
+	}
+
+	var tests []models.TestCase
+	for _, test := range config.Tests {
+		if selected[test.Name] {
+			tests = append(tests, test)
+		}
+	}
+	config.Tests = tests
+
+	var scenarios []models.Scenario
+	for _, scenario := range config.Scenarios {
+		if len(scenario.Tests) == 0 {
+			scenarios = append(scenarios, scenario)
+			continue
+		}
+		var names []string
+		for _, name := range scenario.Tests {
+			if selected[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			scenario.Tests = names
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	config.Scenarios = scenarios
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taggedConfig() *models.Config {
+	return &models.Config{
+		Tests: []models.TestCase{
+			{Name: "login", Tags: []string{"auth"}},
+			{Name: "health", Tags: []string{"smoke"}},
+			{Name: "checkout", Tags: []string{"smoke", "slow"}, DependsOn: []string{"login"}},
+			{Name: "report", Tags: []string{"slow"}},
+			{Name: "untagged"},
+		},
+		Scenarios: []models.Scenario{
+			{Name: "buyers", Tests: []string{"login", "checkout"}},
+			{Name: "reporting", Tests: []string{"report"}},
+		},
+	}
+}
+
+func testNames(config *models.Config) []string {
+	var names []string
+	for _, test := range config.Tests {
+		names = append(names, test.Name)
+	}
+	return names
+}
+
+func TestFilterByTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{"no filters", nil, nil, []string{"login", "health", "checkout", "report", "untagged"}},
+		{"include keeps dependencies", []string{"smoke"}, nil, []string{"login", "health", "checkout"}},
+		{"include is case insensitive", []string{"SLOW"}, nil, []string{"login", "checkout", "report"}},
+		{"exclude", nil, []string{"slow"}, []string{"login", "health", "untagged"}},
+		{"include and exclude", []string{"smoke"}, []string{"slow"}, []string{"health"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := taggedConfig()
+			require.NoError(t, FilterByTags(config, tt.include, tt.exclude))
+			assert.Equal(t, tt.want, testNames(config))
+		})
+	}
+}
+
+func TestFilterByTags_PrunesScenarios(t *testing.T) {
+	config := taggedConfig()
+	require.NoError(t, FilterByTags(config, []string{"auth"}, nil))
+
+	require.Len(t, config.Scenarios, 1)
+	assert.Equal(t, "buyers", config.Scenarios[0].Name)
+	assert.Equal(t, []string{"login"}, config.Scenarios[0].Tests)
+}
+
+func TestFilterByTags_NoMatch(t *testing.T) {
+	err := FilterByTags(taggedConfig(), []string{"nightly"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no tests match")
+}
+
+func TestParseList(t *testing.T) {
+	assert.Equal(t, []string{"smoke", "regression"}, ParseList(" smoke, ,regression "))
+	assert.Nil(t, ParseList(""))
+}
+
+func TestSelectByName(t *testing.T) {
+	config := taggedConfig()
+	require.NoError(t, SelectByName(config, []string{"checkout", "health"}))
+
+	assert.Equal(t, []string{"login", "health", "checkout"}, testNames(config))
+	require.Len(t, config.Scenarios, 1)
+	assert.Equal(t, []string{"login", "checkout"}, config.Scenarios[0].Tests)
+}
+
+func TestSelectByName_UnknownTest(t *testing.T) {
+	err := SelectByName(taggedConfig(), []string{"health", "Logn"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown test 'Logn'")
+}
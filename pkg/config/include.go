@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadRaw reads a config file and merges the files listed in its include
+// section. Include paths are relative to the including file; included files
+// are merged in order and the including file wins on conflicting settings.
+func loadRaw(filename string, stack []string) (*rawConfig, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = filename
+	}
+	for _, seen := range stack {
+		if seen == absPath {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, absPath), " -> "))
+		}
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return decodeRaw(data, filepath.Dir(filename), append(stack, absPath))
+}
+
+// decodeRaw parses config data and merges its includes, which are relative
+// to dir; stack holds the files being included, to detect cycles
+func decodeRaw(data []byte, dir string, stack []string) (*rawConfig, error) {
+	var raw rawConfig
+	if err := decodeStrict(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if len(raw.Include) == 0 {
+		return &raw, nil
+	}
+
+	merged := &rawConfig{}
+	for _, include := range raw.Include {
+		path := include
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		included, err := loadRaw(path, stack)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		if err := mergeRaw(merged, included); err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+	}
+	if err := mergeRaw(merged, &raw); err != nil {
+		return nil, err
+	}
+	merged.Include = nil
+
+	return merged, nil
+}
+
+// mergeRaw merges src into dst. Settings set in src replace those in dst,
+// maps are merged key by key, and tests and scenarios are appended.
+func mergeRaw(dst, src *rawConfig) error {
+	if src.Name != "" {
+		dst.Name = src.Name
+	}
+	if src.Description != "" {
+		dst.Description = src.Description
+	}
+
+	mergeRawGlobal(&dst.Global, &src.Global)
+
+	names := make(map[string]bool, len(dst.Tests))
+	for _, test := range dst.Tests {
+		names[test.Name] = true
+	}
+	for _, test := range src.Tests {
+		if names[test.Name] {
+			return fmt.Errorf("duplicate test name '%s'", test.Name)
+		}
+		names[test.Name] = true
+		dst.Tests = append(dst.Tests, test)
+	}
+
+	dst.Scenarios = append(dst.Scenarios, src.Scenarios...)
+
+	for name, template := range src.Templates {
+		if dst.Templates == nil {
+			dst.Templates = make(map[string]rawTemplate)
+		}
+		dst.Templates[name] = template
+	}
+
+	for name, env := range src.Environments {
+		if dst.Environments == nil {
+			dst.Environments = make(map[string]rawEnvironment)
+		}
+		dst.Environments[name] = env
+	}
+
+	return nil
+}
+
+func mergeRawGlobal(dst, src *rawGlobalConfig) {
+	mergeString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	if src.BaseURL != nil {
+		dst.BaseURL = src.BaseURL
+	}
+	mergeString(&dst.Timeout, src.Timeout)
+	mergeString(&dst.ConnectTimeout, src.ConnectTimeout)
+	mergeString(&dst.TLSHandshakeTimeout, src.TLSHandshakeTimeout)
+	mergeString(&dst.ResponseHeaderTimeout, src.ResponseHeaderTimeout)
+	mergeString(&dst.IdleConnTimeout, src.IdleConnTimeout)
+	mergeString(&dst.Delay, src.Delay)
+	mergeString(&dst.Duration, src.Duration)
+	mergeString(&dst.ThinkTime, src.ThinkTime)
+	mergeString(&dst.ThinkTimeMin, src.ThinkTimeMin)
+	mergeString(&dst.ThinkTimeMax, src.ThinkTimeMax)
+	mergeString(&dst.ThinkTimeDistribution, src.ThinkTimeDistribution)
+	mergeString(&dst.ThinkTimeStddev, src.ThinkTimeStddev)
+	mergeString(&dst.Pacing, src.Pacing)
+	mergeString(&dst.StopOn, src.StopOn)
+
+	if src.Iterations != 0 {
+		dst.Iterations = src.Iterations
+	}
+	if src.MaxConnectionsPerHost != 0 {
+		dst.MaxConnectionsPerHost = src.MaxConnectionsPerHost
+	}
+	if src.MaxInFlightPerHost != 0 {
+		dst.MaxInFlightPerHost = src.MaxInFlightPerHost
+	}
+	if src.MaxCapturedBodyBytes != 0 {
+		dst.MaxCapturedBodyBytes = src.MaxCapturedBodyBytes
+	}
+	if src.InsecureSkipVerify {
+		dst.InsecureSkipVerify = true
+	}
+	if src.DisableKeepAlive {
+		dst.DisableKeepAlive = true
+	}
+	mergeString(&dst.AcceptEncoding, src.AcceptEncoding)
+	if src.DisableCompression {
+		dst.DisableCompression = true
+	}
+	mergeString(&dst.RequestIDHeader, src.RequestIDHeader)
+	mergeString(&dst.RunIDHeader, src.RunIDHeader)
+	if src.Loop {
+		dst.Loop = true
+	}
+	if src.StrictVariables {
+		dst.StrictVariables = true
+	}
+	if src.Redact != nil {
+		dst.Redact = src.Redact
+	}
+	if src.Inject != nil {
+		dst.Inject = src.Inject
+	}
+	if src.Throttle != nil {
+		dst.Throttle = src.Throttle
+	}
+	if src.WarmPool != nil {
+		dst.WarmPool = src.WarmPool
+	}
+	if src.Readiness != nil {
+		dst.Readiness = src.Readiness
+	}
+	if src.SteadyState != nil {
+		dst.SteadyState = src.SteadyState
+	}
+	if src.ResultsSink != nil {
+		dst.ResultsSink = src.ResultsSink
+	}
+	if src.Autoscale != nil {
+		dst.Autoscale = src.Autoscale
+	}
+	if src.Capacity != nil {
+		dst.Capacity = src.Capacity
+	}
+	if src.Replay != nil {
+		dst.Replay = src.Replay
+	}
+	dst.RequiredVariables = append(dst.RequiredVariables, src.RequiredVariables...)
+	dst.ReportUpload = append(dst.ReportUpload, src.ReportUpload...)
+	dst.SLOs = append(dst.SLOs, src.SLOs...)
+
+	for key, value := range src.Headers {
+		if dst.Headers == nil {
+			dst.Headers = make(map[string]string)
+		}
+		dst.Headers[key] = value
+	}
+	for key, value := range src.Variables {
+		if dst.Variables == nil {
+			dst.Variables = make(map[string]interface{})
+		}
+		dst.Variables[key] = value
+	}
+	for key, value := range src.Secrets {
+		if dst.Secrets == nil {
+			dst.Secrets = make(map[string]rawSecret)
+		}
+		dst.Secrets[key] = value
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadFromFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "shared/auth_flow.json", `{
+		"global": {
+			"headers": {"Accept": "application/json", "X-Client": "shared"},
+			"variables": {"username": "shared-user", "password": "secret"}
+		},
+		"tests": [
+			{"name": "Login", "method": "POST", "path": "/login", "expected_status": [200]}
+		]
+	}`)
+	writeConfigFile(t, dir, "shared/common_tests.json", `{
+		"global": {"timeout": "5s"},
+		"tests": [
+			{"name": "Health", "method": "GET", "path": "/health", "expected_status": [200]}
+		]
+	}`)
+	mainFile := writeConfigFile(t, dir, "main.json", `{
+		"include": ["shared/auth_flow.json", "shared/common_tests.json"],
+		"name": "Composed",
+		"global": {
+			"base_url": "https://api.example.com",
+			"iterations": 1,
+			"headers": {"X-Client": "main"},
+			"variables": {"username": "main-user"}
+		},
+		"tests": [
+			{"name": "Profile", "method": "GET", "path": "/me", "expected_status": [200], "depends_on": ["Login"]}
+		]
+	}`)
+
+	config, err := LoadFromFile(mainFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Composed", config.Name)
+	assert.Equal(t, []string{"Login", "Health", "Profile"}, testNames(config))
+	assert.Equal(t, "main", config.Global.Headers["X-Client"])
+	assert.Equal(t, "application/json", config.Global.Headers["Accept"])
+	assert.Equal(t, "main-user", config.Global.Variables["username"])
+	assert.Equal(t, "secret", config.Global.Variables["password"])
+	assert.Equal(t, "5s", config.Global.Timeout.String())
+}
+
+func TestLoadFromFile_IncludeStopOn(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "shared/limits.json", `{
+		"global": {"duration": "30s", "stop_on": "both"}
+	}`)
+	mainFile := writeConfigFile(t, dir, "main.json", `{
+		"include": ["shared/limits.json"],
+		"name": "Stop On",
+		"global": {"base_url": "https://api.example.com", "iterations": 100},
+		"tests": [{"name": "Health", "method": "GET", "path": "/health", "expected_status": [200]}]
+	}`)
+
+	config, err := LoadFromFile(mainFile)
+	require.NoError(t, err)
+	assert.Equal(t, "both", config.Global.StopOn)
+}
+
+func TestLoadFromFile_NestedInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "fragments/base.json", `{
+		"tests": [{"name": "Health", "method": "GET", "path": "/health", "expected_status": [200]}]
+	}`)
+	writeConfigFile(t, dir, "fragments/smoke.json", `{
+		"include": ["base.json"],
+		"tests": [{"name": "Home", "method": "GET", "path": "/", "expected_status": [200]}]
+	}`)
+	mainFile := writeConfigFile(t, dir, "main.json", `{
+		"include": ["fragments/smoke.json"],
+		"name": "Nested",
+		"global": {"base_url": "https://api.example.com", "iterations": 1},
+		"tests": []
+	}`)
+
+	config, err := LoadFromFile(mainFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Health", "Home"}, testNames(config))
+}
+
+func TestLoadFromFile_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing file",
+			files:   map[string]string{"main.json": `{"include": ["missing.json"]}`},
+			wantErr: "include missing.json: failed to read config file",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.json": `{"include": ["a.json"]}`,
+				"a.json":    `{"include": ["main.json"]}`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "duplicate test",
+			files: map[string]string{
+				"main.json": `{"include": ["a.json"], "tests": [{"name": "Login"}]}`,
+				"a.json":    `{"tests": [{"name": "Login"}]}`,
+			},
+			wantErr: "duplicate test name 'Login'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeConfigFile(t, dir, name, content)
+			}
+			_, err := LoadFromFile(filepath.Join(dir, "main.json"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Overrides holds command-line values that replace global settings at runtime
+type Overrides struct {
+	BaseURL    string
+	Iterations int
+	Duration   time.Duration
+	Headers    []string // "Name: value"
+}
+
+// ApplyOverrides replaces global settings with the non-zero override values.
+// Test-level settings still take precedence over the overridden globals.
+func ApplyOverrides(config *models.Config, overrides Overrides) error {
+	if overrides.Iterations < 0 {
+		return fmt.Errorf("iterations override must not be negative")
+	}
+	if overrides.Duration < 0 {
+		return fmt.Errorf("duration override must not be negative")
+	}
+
+	if overrides.BaseURL != "" {
+		config.Global.BaseURL = overrides.BaseURL
+		config.Global.Targets = nil
+	}
+	if overrides.Iterations > 0 {
+		config.Global.Iterations = overrides.Iterations
+	}
+	if overrides.Duration > 0 {
+		config.Global.Duration = overrides.Duration
+	}
+
+	for _, header := range overrides.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("invalid header override '%s' (expected \"Name: value\")", header)
+		}
+		if config.Global.Headers == nil {
+			config.Global.Headers = make(models.Headers)
+		}
+		config.Global.Headers[name] = strings.TrimSpace(value)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    "https://api.example.com",
+			Targets:    []models.Target{{URL: "https://api.example.com"}, {URL: "https://api2.example.com"}},
+			Iterations: 100,
+			Headers:    map[string]string{"X-Env": "prod", "Accept": "application/json"},
+		},
+	}
+
+	err := ApplyOverrides(config, Overrides{
+		BaseURL:    "https://staging.example.com",
+		Iterations: 5,
+		Duration:   30 * time.Second,
+		Headers:    []string{"X-Env: staging", "Authorization: Bearer a:b"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://staging.example.com", config.Global.BaseURL)
+	assert.Nil(t, config.Global.Targets)
+	assert.Equal(t, 5, config.Global.Iterations)
+	assert.Equal(t, 30*time.Second, config.Global.Duration)
+	assert.Equal(t, models.Headers{
+		"X-Env":         "staging",
+		"Accept":        "application/json",
+		"Authorization": "Bearer a:b",
+	}, config.Global.Headers)
+}
+
+func TestApplyOverrides_ZeroValuesKeepConfig(t *testing.T) {
+	config := &models.Config{Global: models.GlobalConfig{BaseURL: "https://api.example.com", Iterations: 10}}
+
+	require.NoError(t, ApplyOverrides(config, Overrides{}))
+	assert.Equal(t, "https://api.example.com", config.Global.BaseURL)
+	assert.Equal(t, 10, config.Global.Iterations)
+	assert.Nil(t, config.Global.Headers)
+}
+
+func TestApplyOverrides_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides Overrides
+		wantErr   string
+	}{
+		{"header without colon", Overrides{Headers: []string{"X-Env staging"}}, "invalid header override"},
+		{"header without name", Overrides{Headers: []string{": staging"}}, "invalid header override"},
+		{"negative iterations", Overrides{Iterations: -1}, "iterations override"},
+		{"negative duration", Overrides{Duration: -time.Second}, "duration override"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplyOverrides(&models.Config{}, tt.overrides)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
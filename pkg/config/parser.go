@@ -1,26 +1,46 @@
 package config
 
 import (
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
-	"os"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/assertion"
+	"github.com/andrearaponi/bombardino/pkg/comparison"
+	"github.com/andrearaponi/bombardino/pkg/variables"
 )
 
 func LoadFromFile(filename string) (*models.Config, error) {
-	data, err := os.ReadFile(filename)
+	rawConfig, err := loadRaw(filename, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
+	return build(rawConfig)
+}
+
+// Parse parses and validates config data that does not come from a file,
+// e.g. uploaded through the control API. Include paths are relative to the
+// working directory.
+func Parse(data []byte) (*models.Config, error) {
+	rawConfig, err := decodeRaw(data, ".", nil)
+	if err != nil {
+		return nil, err
+	}
+	return build(rawConfig)
+}
 
-	var rawConfig rawConfig
-	if err := json.Unmarshal(data, &rawConfig); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+func build(rawConfig *rawConfig) (*models.Config, error) {
+	if err := applyTemplates(rawConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	config, err := parseConfig(&rawConfig)
+	config, err := parseConfig(rawConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
@@ -33,53 +53,232 @@ func LoadFromFile(filename string) (*models.Config, error) {
 }
 
 type rawConfig struct {
+	Schema      string          `json:"$schema,omitempty"` // Editor hint, ignored
+	Include     []string        `json:"include,omitempty"`
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
 	Global      rawGlobalConfig `json:"global"`
 	Tests       []rawTestCase   `json:"tests"`
+	Scenarios   []rawScenario   `json:"scenarios,omitempty"`
+
+	Templates    map[string]rawTemplate    `json:"templates,omitempty"`
+	Environments map[string]rawEnvironment `json:"environments,omitempty"`
+}
+
+type rawEnvironment struct {
+	BaseURL   baseURLs               `json:"base_url,omitempty"`
+	Headers   map[string]string      `json:"headers,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type rawScenario struct {
+	Name                  string   `json:"name"`
+	Workers               int      `json:"workers,omitempty"`
+	Iterations            int      `json:"iterations,omitempty"`
+	Duration              string   `json:"duration,omitempty"`
+	ThinkTime             string   `json:"think_time,omitempty"`
+	ThinkTimeMin          string   `json:"think_time_min,omitempty"`
+	ThinkTimeMax          string   `json:"think_time_max,omitempty"`
+	ThinkTimeDistribution string   `json:"think_time_distribution,omitempty"`
+	ThinkTimeStddev       string   `json:"think_time_stddev,omitempty"`
+	Pacing                string   `json:"pacing,omitempty"`
+	Loop                  bool     `json:"loop,omitempty"`
+	Tests                 []string `json:"tests,omitempty"`
 }
 
 type rawGlobalConfig struct {
-	BaseURL            string                 `json:"base_url"`
-	Timeout            string                 `json:"timeout"`
-	Delay              string                 `json:"delay"`
-	Iterations         int                    `json:"iterations,omitempty"`
-	Duration           string                 `json:"duration,omitempty"`
-	Headers            map[string]string      `json:"headers,omitempty"`
-	InsecureSkipVerify bool                   `json:"insecure_skip_verify,omitempty"`
-	Variables          map[string]interface{} `json:"variables,omitempty"`
-	ThinkTime          string                 `json:"think_time,omitempty"`
-	ThinkTimeMin       string                 `json:"think_time_min,omitempty"`
-	ThinkTimeMax       string                 `json:"think_time_max,omitempty"`
+	BaseURL               baseURLs               `json:"base_url"`
+	Timeout               string                 `json:"timeout"`
+	ConnectTimeout        string                 `json:"connect_timeout,omitempty"`
+	TLSHandshakeTimeout   string                 `json:"tls_handshake_timeout,omitempty"`
+	ResponseHeaderTimeout string                 `json:"response_header_timeout,omitempty"`
+	IdleConnTimeout       string                 `json:"idle_conn_timeout,omitempty"`
+	Delay                 string                 `json:"delay"`
+	Iterations            int                    `json:"iterations,omitempty"`
+	Duration              string                 `json:"duration,omitempty"`
+	Headers               map[string]string      `json:"headers,omitempty"`
+	InsecureSkipVerify    bool                   `json:"insecure_skip_verify,omitempty"`
+	Variables             map[string]interface{} `json:"variables,omitempty"`
+	ThinkTime             string                 `json:"think_time,omitempty"`
+	ThinkTimeMin          string                 `json:"think_time_min,omitempty"`
+	ThinkTimeMax          string                 `json:"think_time_max,omitempty"`
+	ThinkTimeDistribution string                 `json:"think_time_distribution,omitempty"`
+	ThinkTimeStddev       string                 `json:"think_time_stddev,omitempty"`
+	Secrets               map[string]rawSecret   `json:"secrets,omitempty"`
+	Redact                *rawRedactConfig       `json:"redact,omitempty"`
+	StopOn                string                 `json:"stop_on,omitempty"`
+	Pacing                string                 `json:"pacing,omitempty"`
+	Loop                  bool                   `json:"loop,omitempty"`
+	RequiredVariables     []string               `json:"required_variables,omitempty"`
+	StrictVariables       bool                   `json:"strict_variables,omitempty"`
+	Inject                *rawInjectConfig       `json:"inject,omitempty"`
+	Throttle              *rawThrottleConfig     `json:"throttle,omitempty"`
+	ReportUpload          []models.ReportUpload  `json:"report_upload,omitempty"`
+	WarmPool              *models.WarmPool       `json:"warm_pool,omitempty"`
+	Readiness             *rawReadinessConfig    `json:"readiness,omitempty"`
+	SteadyState           *rawSteadyStateConfig  `json:"steady_state,omitempty"`
+	DisableKeepAlive      bool                   `json:"disable_keep_alive,omitempty"`
+	AcceptEncoding        string                 `json:"accept_encoding,omitempty"`
+	DisableCompression    bool                   `json:"disable_compression,omitempty"`
+	RequestIDHeader       string                 `json:"request_id_header,omitempty"`
+	RunIDHeader           string                 `json:"run_id_header,omitempty"`
+	MaxConnectionsPerHost int                    `json:"max_connections_per_host,omitempty"`
+	MaxInFlightPerHost    int                    `json:"max_in_flight_per_host,omitempty"`
+	MaxCapturedBodyBytes  int                    `json:"max_captured_body_bytes,omitempty"`
+	SLOs                  []rawSLO               `json:"slos,omitempty"`
+	ResultsSink           *rawResultsSink        `json:"results_sink,omitempty"`
+	Autoscale             *rawAutoscale          `json:"autoscale,omitempty"`
+	Capacity              *rawCapacity           `json:"capacity,omitempty"`
+	Replay                *rawReplay             `json:"replay,omitempty"`
+}
+
+type rawReadinessConfig struct {
+	URL            string `json:"url"`
+	ExpectedStatus []int  `json:"expected_status,omitempty"`
+	Timeout        string `json:"timeout,omitempty"`
+	Interval       string `json:"interval,omitempty"`
+}
+
+type rawSteadyStateConfig struct {
+	Window        string   `json:"window,omitempty"`
+	Tolerance     *float64 `json:"tolerance,omitempty"`
+	StableWindows *int     `json:"stable_windows,omitempty"`
+}
+
+type rawResultsSink struct {
+	Type          string `json:"type"`
+	URL           string `json:"url,omitempty"`
+	Table         string `json:"table"`
+	BatchSize     int    `json:"batch_size,omitempty"`
+	FlushInterval string `json:"flush_interval,omitempty"`
+}
+
+type rawAutoscale struct {
+	TargetRPS  float64 `json:"target_rps,omitempty"`
+	MaxLatency string  `json:"max_latency,omitempty"`
+	MinWorkers int     `json:"min_workers,omitempty"`
+	MaxWorkers int     `json:"max_workers,omitempty"`
+	Interval   string  `json:"interval,omitempty"`
+}
+
+type rawCapacity struct {
+	StartRPS      float64  `json:"start_rps,omitempty"`
+	MaxRPS        float64  `json:"max_rps"`
+	StageDuration string   `json:"stage_duration,omitempty"`
+	MaxErrorRate  *float64 `json:"max_error_rate,omitempty"`
+	MaxP95        string   `json:"max_p95,omitempty"`
+	Precision     float64  `json:"precision,omitempty"`
+}
+
+type rawReplay struct {
+	File  string  `json:"file"`
+	Speed float64 `json:"speed,omitempty"`
+}
+
+type rawSLO struct {
+	Name        string   `json:"name"`
+	Test        string   `json:"test,omitempty"`
+	Latency     string   `json:"latency,omitempty"`
+	Objective   float64  `json:"objective"`
+	Window      string   `json:"window,omitempty"`
+	MaxBurnRate *float64 `json:"max_burn_rate,omitempty"`
+	Abort       bool     `json:"abort,omitempty"`
+}
+
+type rawThrottleConfig struct {
+	MaxRetries *int   `json:"max_retries,omitempty"`
+	MaxWait    string `json:"max_wait,omitempty"`
+}
+
+type rawSSEConfig struct {
+	Window    string `json:"window,omitempty"`
+	MinEvents *int   `json:"min_events,omitempty"`
+	MaxEvents int    `json:"max_events,omitempty"`
+}
+
+type rawInjectConfig struct {
+	Latency  string  `json:"latency,omitempty"`
+	Jitter   string  `json:"jitter,omitempty"`
+	DropRate float64 `json:"drop_rate,omitempty"`
+}
+
+type rawRedactConfig struct {
+	Headers   []string `json:"headers,omitempty"`
+	Variables []string `json:"variables,omitempty"`
+	JSONPaths []string `json:"json_paths,omitempty"`
+}
+
+type rawSecret struct {
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+	Field    string `json:"field,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Region   string `json:"region,omitempty"`
 }
 
 type rawTestCase struct {
-	Name               string                   `json:"name"`
-	Method             string                   `json:"method"`
-	Path               string                   `json:"path"`
-	Headers            map[string]string        `json:"headers,omitempty"`
-	Body               interface{}              `json:"body,omitempty"`
-	ExpectedStatus     []int                    `json:"expected_status"`
-	Timeout            string                   `json:"timeout,omitempty"`
-	Delay              string                   `json:"delay,omitempty"`
-	Iterations         int                      `json:"iterations,omitempty"`
-	Duration           string                   `json:"duration,omitempty"`
-	Assertions         []rawAssertion           `json:"assertions,omitempty"`
-	InsecureSkipVerify *bool                    `json:"insecure_skip_verify,omitempty"`
-	Extract            []rawExtraction          `json:"extract,omitempty"`
-	DependsOn          []string                 `json:"depends_on,omitempty"`
-	ThinkTime          string                   `json:"think_time,omitempty"`
-	ThinkTimeMin       string                   `json:"think_time_min,omitempty"`
-	ThinkTimeMax       string                   `json:"think_time_max,omitempty"`
-	Data               []map[string]interface{} `json:"data,omitempty"`
-	DataFile           string                   `json:"data_file,omitempty"`
-	CompareWith        *rawCompareConfig        `json:"compare_with,omitempty"`
+	Name                  string                           `json:"name"`
+	Description           string                           `json:"description,omitempty"`
+	Template              string                           `json:"template,omitempty"`
+	Method                string                           `json:"method"`
+	Path                  string                           `json:"path"`
+	PathParams            map[string]string                `json:"path_params,omitempty"`
+	Query                 map[string]string                `json:"query,omitempty"`
+	Headers               map[string]string                `json:"headers,omitempty"`
+	RotateHeaders         map[string]models.HeaderRotation `json:"rotate_headers,omitempty"`
+	Body                  interface{}                      `json:"body,omitempty"`
+	SOAP                  *models.SOAPRequest              `json:"soap,omitempty"`
+	SSE                   *rawSSEConfig                    `json:"sse,omitempty"`
+	Socket                *models.SocketConfig             `json:"socket,omitempty"`
+	Messaging             *models.MessagingConfig          `json:"messaging,omitempty"`
+	SQL                   *models.SQLConfig                `json:"sql,omitempty"`
+	ExpectedStatus        []int                            `json:"expected_status"`
+	SuccessWhen           string                           `json:"success_when,omitempty"`
+	Timeout               string                           `json:"timeout,omitempty"`
+	ConnectTimeout        string                           `json:"connect_timeout,omitempty"`
+	TLSHandshakeTimeout   string                           `json:"tls_handshake_timeout,omitempty"`
+	ResponseHeaderTimeout string                           `json:"response_header_timeout,omitempty"`
+	Delay                 string                           `json:"delay,omitempty"`
+	Iterations            int                              `json:"iterations,omitempty"`
+	Duration              string                           `json:"duration,omitempty"`
+	Assertions            []rawAssertion                   `json:"assertions,omitempty"`
+	AssertVariables       []string                         `json:"assert_variables,omitempty"`
+	InsecureSkipVerify    *bool                            `json:"insecure_skip_verify,omitempty"`
+	DisableKeepAlive      *bool                            `json:"disable_keep_alive,omitempty"`
+	AcceptEncoding        string                           `json:"accept_encoding,omitempty"`
+	DisableCompression    *bool                            `json:"disable_compression,omitempty"`
+	RequestIDHeader       string                           `json:"request_id_header,omitempty"`
+	Extract               []rawExtraction                  `json:"extract,omitempty"`
+	DependsOn             []string                         `json:"depends_on,omitempty"`
+	DependsOnMode         string                           `json:"depends_on_mode,omitempty"`
+	ThinkTime             string                           `json:"think_time,omitempty"`
+	ThinkTimeMin          string                           `json:"think_time_min,omitempty"`
+	ThinkTimeMax          string                           `json:"think_time_max,omitempty"`
+	ThinkTimeDistribution string                           `json:"think_time_distribution,omitempty"`
+	ThinkTimeStddev       string                           `json:"think_time_stddev,omitempty"`
+	Data                  []map[string]interface{}         `json:"data,omitempty"`
+	DataFile              string                           `json:"data_file,omitempty"`
+	DataSheet             string                           `json:"data_sheet,omitempty"`
+	DataStrategy          string                           `json:"data_strategy,omitempty"`
+	CompareWith           *rawCompareConfig                `json:"compare_with,omitempty"`
+	AllowedFailureRate    float64                          `json:"allowed_failure_rate,omitempty"`
+	Tags                  []string                         `json:"tags,omitempty"`
+	StopOn                string                           `json:"stop_on,omitempty"`
+	Pacing                string                           `json:"pacing,omitempty"`
+	Cache                 bool                             `json:"cache,omitempty"`
+	ReadBody              *bool                            `json:"read_body,omitempty"`
+	Inject                *rawInjectConfig                 `json:"inject,omitempty"`
+	Throttle              *rawThrottleConfig               `json:"throttle,omitempty"`
 }
 
 type rawExtraction struct {
-	Name   string `json:"name"`
-	Source string `json:"source"`
-	Path   string `json:"path"`
+	Name             string `json:"name"`
+	Source           string `json:"source"`
+	Path             string `json:"path"`
+	ExtractOnFailure bool   `json:"extract_on_failure,omitempty"`
+	Transform        string `json:"transform,omitempty"`
+	Regex            string `json:"regex,omitempty"`
+	Index            *int   `json:"index,omitempty"`
 }
 
 type rawAssertion struct {
@@ -87,6 +286,8 @@ type rawAssertion struct {
 	Target   string      `json:"target"`
 	Operator string      `json:"operator"`
 	Value    interface{} `json:"value"`
+	Coerce   bool        `json:"coerce,omitempty"`
+	Layout   string      `json:"layout,omitempty"`
 }
 
 type rawCompareConfig struct {
@@ -156,34 +357,182 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 		}
 	}
 
+	var globalThinkTimeStddev time.Duration
+	if raw.Global.ThinkTimeStddev != "" {
+		globalThinkTimeStddev, err = time.ParseDuration(raw.Global.ThinkTimeStddev)
+		if err != nil {
+			return nil, fmt.Errorf("invalid global think_time_stddev: %w", err)
+		}
+	}
+
+	var globalPacing time.Duration
+	if raw.Global.Pacing != "" {
+		globalPacing, err = time.ParseDuration(raw.Global.Pacing)
+		if err != nil {
+			return nil, fmt.Errorf("invalid global pacing: %w", err)
+		}
+	}
+
+	baseURL, targets, err := raw.Global.BaseURL.split()
+	if err != nil {
+		return nil, fmt.Errorf("invalid global base_url %w", err)
+	}
+
 	config := &models.Config{
 		Name:        raw.Name,
 		Description: raw.Description,
 		Global: models.GlobalConfig{
-			BaseURL:            raw.Global.BaseURL,
-			Timeout:            globalTimeout,
-			Delay:              globalDelay,
-			Iterations:         raw.Global.Iterations,
-			Duration:           globalDuration,
-			Headers:            raw.Global.Headers,
-			InsecureSkipVerify: raw.Global.InsecureSkipVerify,
-			Variables:          raw.Global.Variables,
-			ThinkTime:          globalThinkTime,
-			ThinkTimeMin:       globalThinkTimeMin,
-			ThinkTimeMax:       globalThinkTimeMax,
+			BaseURL:               baseURL,
+			Targets:               targets,
+			Timeout:               globalTimeout,
+			Delay:                 globalDelay,
+			Iterations:            raw.Global.Iterations,
+			Duration:              globalDuration,
+			Headers:               raw.Global.Headers,
+			InsecureSkipVerify:    raw.Global.InsecureSkipVerify,
+			Variables:             raw.Global.Variables,
+			ThinkTime:             globalThinkTime,
+			ThinkTimeMin:          globalThinkTimeMin,
+			ThinkTimeMax:          globalThinkTimeMax,
+			ThinkTimeDistribution: raw.Global.ThinkTimeDistribution,
+			ThinkTimeStddev:       globalThinkTimeStddev,
+			StopOn:                raw.Global.StopOn,
+			Pacing:                globalPacing,
+			Loop:                  raw.Global.Loop,
+			RequiredVariables:     raw.Global.RequiredVariables,
+			StrictVariables:       raw.Global.StrictVariables,
 		},
 	}
 
+	if len(raw.Global.Secrets) > 0 {
+		config.Global.Secrets = make(map[string]models.SecretRef, len(raw.Global.Secrets))
+		for name, rawSecret := range raw.Global.Secrets {
+			config.Global.Secrets[name] = models.SecretRef{
+				Provider: rawSecret.Provider,
+				Key:      rawSecret.Key,
+				Field:    rawSecret.Field,
+				Address:  rawSecret.Address,
+				Region:   rawSecret.Region,
+			}
+		}
+	}
+
+	if len(raw.Environments) > 0 {
+		config.Environments = make(map[string]models.Environment, len(raw.Environments))
+		for name, rawEnv := range raw.Environments {
+			baseURL, targets, err := rawEnv.BaseURL.split()
+			if err != nil {
+				return nil, fmt.Errorf("invalid environment %s base_url %w", name, err)
+			}
+			config.Environments[name] = models.Environment{
+				BaseURL:   baseURL,
+				Targets:   targets,
+				Headers:   rawEnv.Headers,
+				Variables: rawEnv.Variables,
+			}
+		}
+	}
+
+	if config.Global.Inject, err = parseInject(raw.Global.Inject); err != nil {
+		return nil, fmt.Errorf("invalid global inject %w", err)
+	}
+
+	if config.Global.Throttle, err = parseThrottle(raw.Global.Throttle); err != nil {
+		return nil, fmt.Errorf("invalid global throttle %w", err)
+	}
+
+	if config.Global.Readiness, err = parseReadiness(raw.Global.Readiness); err != nil {
+		return nil, fmt.Errorf("invalid global readiness %w", err)
+	}
+
+	if config.Global.SteadyState, err = parseSteadyState(raw.Global.SteadyState); err != nil {
+		return nil, fmt.Errorf("invalid global steady_state %w", err)
+	}
+
+	for i, rawSLO := range raw.Global.SLOs {
+		slo, err := parseSLO(rawSLO)
+		if err != nil {
+			return nil, fmt.Errorf("invalid global slos %d %w", i, err)
+		}
+		config.Global.SLOs = append(config.Global.SLOs, slo)
+	}
+
+	if config.Global.ResultsSink, err = parseResultsSink(raw.Global.ResultsSink); err != nil {
+		return nil, fmt.Errorf("invalid global results_sink %w", err)
+	}
+
+	if config.Global.Autoscale, err = parseAutoscale(raw.Global.Autoscale); err != nil {
+		return nil, fmt.Errorf("invalid global autoscale %w", err)
+	}
+
+	if config.Global.Capacity, err = parseCapacity(raw.Global.Capacity); err != nil {
+		return nil, fmt.Errorf("invalid global capacity %w", err)
+	}
+
+	config.Global.Replay = parseReplay(raw.Global.Replay)
+
+	if name, err := parseDurations(
+		durationField{"connect_timeout", raw.Global.ConnectTimeout, &config.Global.ConnectTimeout},
+		durationField{"tls_handshake_timeout", raw.Global.TLSHandshakeTimeout, &config.Global.TLSHandshakeTimeout},
+		durationField{"response_header_timeout", raw.Global.ResponseHeaderTimeout, &config.Global.ResponseHeaderTimeout},
+		durationField{"idle_conn_timeout", raw.Global.IdleConnTimeout, &config.Global.IdleConnTimeout},
+	); err != nil {
+		return nil, fmt.Errorf("invalid global %s: %w", name, err)
+	}
+
+	config.Global.WarmPool = raw.Global.WarmPool
+	config.Global.DisableKeepAlive = raw.Global.DisableKeepAlive
+	config.Global.AcceptEncoding = raw.Global.AcceptEncoding
+	config.Global.DisableCompression = raw.Global.DisableCompression
+	config.Global.RequestIDHeader = raw.Global.RequestIDHeader
+	config.Global.RunIDHeader = raw.Global.RunIDHeader
+	config.Global.MaxConnectionsPerHost = raw.Global.MaxConnectionsPerHost
+	config.Global.MaxInFlightPerHost = raw.Global.MaxInFlightPerHost
+	config.Global.MaxCapturedBodyBytes = raw.Global.MaxCapturedBodyBytes
+	config.Global.ReportUpload = raw.Global.ReportUpload
+	for i := range config.Global.ReportUpload {
+		upload := &config.Global.ReportUpload[i]
+		if upload.Format == "" {
+			upload.Format = reportFormat(upload.URL)
+		}
+	}
+
+	if raw.Global.Redact != nil {
+		config.Global.Redact = &models.RedactConfig{
+			Headers:   raw.Global.Redact.Headers,
+			Variables: raw.Global.Redact.Variables,
+			JSONPaths: raw.Global.Redact.JSONPaths,
+		}
+	}
+
 	for i, rawTest := range raw.Tests {
 		test := models.TestCase{
 			Name:               rawTest.Name,
+			Description:        rawTest.Description,
 			Method:             rawTest.Method,
 			Path:               rawTest.Path,
+			PathParams:         rawTest.PathParams,
+			Query:              rawTest.Query,
 			Headers:            rawTest.Headers,
+			RotateHeaders:      rawTest.RotateHeaders,
 			Body:               rawTest.Body,
+			SOAP:               rawTest.SOAP,
+			Messaging:          rawTest.Messaging,
+			SQL:                rawTest.SQL,
 			ExpectedStatus:     rawTest.ExpectedStatus,
+			SuccessWhen:        rawTest.SuccessWhen,
+			AssertVariables:    rawTest.AssertVariables,
 			Iterations:         rawTest.Iterations,
 			InsecureSkipVerify: rawTest.InsecureSkipVerify,
+			DisableKeepAlive:   rawTest.DisableKeepAlive,
+			AcceptEncoding:     rawTest.AcceptEncoding,
+			DisableCompression: rawTest.DisableCompression,
+			RequestIDHeader:    rawTest.RequestIDHeader,
+			AllowedFailureRate: rawTest.AllowedFailureRate,
+			Tags:               rawTest.Tags,
+			StopOn:             rawTest.StopOn,
+			Cache:              rawTest.Cache,
+			ReadBody:           rawTest.ReadBody,
 		}
 
 		if rawTest.Timeout != "" {
@@ -194,6 +543,14 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 			test.Timeout = timeout
 		}
 
+		if name, err := parseDurations(
+			durationField{"connect_timeout", rawTest.ConnectTimeout, &test.ConnectTimeout},
+			durationField{"tls_handshake_timeout", rawTest.TLSHandshakeTimeout, &test.TLSHandshakeTimeout},
+			durationField{"response_header_timeout", rawTest.ResponseHeaderTimeout, &test.ResponseHeaderTimeout},
+		); err != nil {
+			return nil, fmt.Errorf("invalid %s for test %d: %w", name, i, err)
+		}
+
 		if rawTest.Delay != "" {
 			delay, err := time.ParseDuration(rawTest.Delay)
 			if err != nil {
@@ -216,6 +573,8 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 				Target:   rawAssertion.Target,
 				Operator: rawAssertion.Operator,
 				Value:    rawAssertion.Value,
+				Coerce:   rawAssertion.Coerce,
+				Layout:   rawAssertion.Layout,
 			}
 			test.Assertions = append(test.Assertions, assertion)
 		}
@@ -223,15 +582,20 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 		// Parse extraction rules
 		for _, rawExtract := range rawTest.Extract {
 			extraction := models.ExtractionRule{
-				Name:   rawExtract.Name,
-				Source: rawExtract.Source,
-				Path:   rawExtract.Path,
+				Name:             rawExtract.Name,
+				Source:           rawExtract.Source,
+				Path:             rawExtract.Path,
+				ExtractOnFailure: rawExtract.ExtractOnFailure,
+				Transform:        rawExtract.Transform,
+				Regex:            rawExtract.Regex,
+				Index:            rawExtract.Index,
 			}
 			test.Extract = append(test.Extract, extraction)
 		}
 
 		// Copy dependencies
 		test.DependsOn = rawTest.DependsOn
+		test.DependsOnMode = rawTest.DependsOnMode
 
 		// Parse think time settings
 		if rawTest.ThinkTime != "" {
@@ -258,9 +622,28 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 			test.ThinkTimeMax = thinkTimeMax
 		}
 
+		test.ThinkTimeDistribution = rawTest.ThinkTimeDistribution
+		if rawTest.ThinkTimeStddev != "" {
+			thinkTimeStddev, err := time.ParseDuration(rawTest.ThinkTimeStddev)
+			if err != nil {
+				return nil, fmt.Errorf("invalid think_time_stddev for test %d: %w", i, err)
+			}
+			test.ThinkTimeStddev = thinkTimeStddev
+		}
+
+		if rawTest.Pacing != "" {
+			pacing, err := time.ParseDuration(rawTest.Pacing)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pacing for test %d: %w", i, err)
+			}
+			test.Pacing = pacing
+		}
+
 		// Copy data-driven test data
 		test.Data = rawTest.Data
 		test.DataFile = rawTest.DataFile
+		test.DataSheet = rawTest.DataSheet
+		test.DataStrategy = rawTest.DataStrategy
 
 		// Parse compare_with configuration
 		if rawTest.CompareWith != nil {
@@ -292,69 +675,1275 @@ func parseConfig(raw *rawConfig) (*models.Config, error) {
 			test.CompareWith = compareConfig
 		}
 
+		if test.Inject, err = parseInject(rawTest.Inject); err != nil {
+			return nil, fmt.Errorf("invalid inject for test %d: %w", i, err)
+		}
+
+		if test.Throttle, err = parseThrottle(rawTest.Throttle); err != nil {
+			return nil, fmt.Errorf("invalid throttle for test %d: %w", i, err)
+		}
+
+		if test.SSE, err = parseSSE(rawTest.SSE); err != nil {
+			return nil, fmt.Errorf("invalid sse for test %d: %w", i, err)
+		}
+
+		if rawTest.Socket != nil {
+			socket := *rawTest.Socket
+			if socket.Protocol == "" {
+				socket.Protocol = models.SocketTCP
+			}
+			test.Socket = &socket
+		}
+
 		config.Tests = append(config.Tests, test)
 	}
 
+	for i, rawScenario := range raw.Scenarios {
+		scenario := models.Scenario{
+			Name:                  rawScenario.Name,
+			Workers:               rawScenario.Workers,
+			Iterations:            rawScenario.Iterations,
+			ThinkTimeDistribution: rawScenario.ThinkTimeDistribution,
+			Loop:                  rawScenario.Loop,
+			Tests:                 rawScenario.Tests,
+		}
+
+		durations := []struct {
+			field string
+			value string
+			dest  *time.Duration
+		}{
+			{"duration", rawScenario.Duration, &scenario.Duration},
+			{"think_time", rawScenario.ThinkTime, &scenario.ThinkTime},
+			{"think_time_min", rawScenario.ThinkTimeMin, &scenario.ThinkTimeMin},
+			{"think_time_max", rawScenario.ThinkTimeMax, &scenario.ThinkTimeMax},
+			{"think_time_stddev", rawScenario.ThinkTimeStddev, &scenario.ThinkTimeStddev},
+			{"pacing", rawScenario.Pacing, &scenario.Pacing},
+		}
+		for _, d := range durations {
+			if d.value == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for scenario %d: %w", d.field, i, err)
+			}
+			*d.dest = parsed
+		}
+
+		config.Scenarios = append(config.Scenarios, scenario)
+	}
+
 	return config, nil
 }
 
-func validateConfig(config *models.Config) error {
-	if config.Name == "" {
-		return fmt.Errorf("config name is required")
+// durationField is an optional duration field and where its parsed value goes
+type durationField struct {
+	name string
+	raw  string
+	dst  *time.Duration
+}
+
+// parseDurations parses optional duration fields, returning the name of the
+// first invalid one with its error
+func parseDurations(fields ...durationField) (string, error) {
+	for _, field := range fields {
+		if field.raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(field.raw)
+		if err != nil {
+			return field.name, err
+		}
+		*field.dst = value
 	}
+	return "", nil
+}
 
-	if config.Global.BaseURL == "" {
-		return fmt.Errorf("global base_url is required")
+// validateTimeouts checks that phase timeouts are not negative
+func validateTimeouts(timeouts ...time.Duration) error {
+	for _, timeout := range timeouts {
+		if timeout < 0 {
+			return fmt.Errorf("connect, TLS handshake, response header, and idle connection timeouts must not be negative")
+		}
 	}
+	return nil
+}
 
-	// Validate that either duration or iterations is specified at global level
-	if config.Global.Duration <= 0 && config.Global.Iterations <= 0 {
-		return fmt.Errorf("either global duration or global iterations must be greater than 0")
+// parseThrottle converts a raw throttle block with its defaults, returning
+// nil when it is not set
+func parseThrottle(raw *rawThrottleConfig) (*models.ThrottleConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	throttle := &models.ThrottleConfig{MaxRetries: 3, MaxWait: 30 * time.Second}
+	if raw.MaxRetries != nil {
+		throttle.MaxRetries = *raw.MaxRetries
+	}
+	if raw.MaxWait != "" {
+		maxWait, err := time.ParseDuration(raw.MaxWait)
+		if err != nil {
+			return nil, fmt.Errorf("max_wait: %w", err)
+		}
+		throttle.MaxWait = maxWait
 	}
+	return throttle, nil
+}
 
-	// Warn if both are specified (duration takes precedence)
-	if config.Global.Duration > 0 && config.Global.Iterations > 0 {
-		fmt.Printf("Warning: Both global duration and iterations specified. Duration will take precedence.\n")
+// parseReadiness converts a raw readiness block with its defaults, returning
+// nil when it is not set
+func parseReadiness(raw *rawReadinessConfig) (*models.ReadinessConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	readiness := &models.ReadinessConfig{
+		URL:            raw.URL,
+		ExpectedStatus: raw.ExpectedStatus,
+		Timeout:        60 * time.Second,
+		Interval:       time.Second,
 	}
+	if len(readiness.ExpectedStatus) == 0 {
+		readiness.ExpectedStatus = []int{200}
+	}
+	if name, err := parseDurations(
+		durationField{"timeout", raw.Timeout, &readiness.Timeout},
+		durationField{"interval", raw.Interval, &readiness.Interval},
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return readiness, nil
+}
 
-	if len(config.Tests) == 0 {
-		return fmt.Errorf("at least one test case is required")
+// parseSteadyState converts a raw steady_state block with its defaults,
+// returning nil when it is not set
+func parseSteadyState(raw *rawSteadyStateConfig) (*models.SteadyStateConfig, error) {
+	if raw == nil {
+		return nil, nil
 	}
+	steady := &models.SteadyStateConfig{Window: 10 * time.Second, Tolerance: 10, StableWindows: 3}
+	if raw.Tolerance != nil {
+		steady.Tolerance = *raw.Tolerance
+	}
+	if raw.StableWindows != nil {
+		steady.StableWindows = *raw.StableWindows
+	}
+	if name, err := parseDurations(
+		durationField{"window", raw.Window, &steady.Window},
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return steady, nil
+}
 
-	for i, test := range config.Tests {
-		if test.Name == "" {
-			return fmt.Errorf("test %d: name is required", i)
+// parseSLO converts a raw slos entry with its defaults
+func parseSLO(raw rawSLO) (models.SLO, error) {
+	slo := models.SLO{
+		Name:        raw.Name,
+		Test:        raw.Test,
+		Objective:   raw.Objective,
+		Window:      time.Minute,
+		MaxBurnRate: 1,
+		Abort:       raw.Abort,
+	}
+	if raw.MaxBurnRate != nil {
+		slo.MaxBurnRate = *raw.MaxBurnRate
+	}
+	if name, err := parseDurations(
+		durationField{"latency", raw.Latency, &slo.Latency},
+		durationField{"window", raw.Window, &slo.Window},
+	); err != nil {
+		return models.SLO{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return slo, nil
+}
+
+// parseResultsSink converts a raw results_sink block with its defaults,
+// returning nil when it is not set
+func parseResultsSink(raw *rawResultsSink) (*models.ResultsSink, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	sink := &models.ResultsSink{
+		Type:          raw.Type,
+		URL:           raw.URL,
+		Table:         raw.Table,
+		BatchSize:     1000,
+		FlushInterval: 5 * time.Second,
+	}
+	if raw.BatchSize != 0 {
+		sink.BatchSize = raw.BatchSize
+	}
+	if name, err := parseDurations(
+		durationField{"flush_interval", raw.FlushInterval, &sink.FlushInterval},
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return sink, nil
+}
+
+// parseAutoscale converts a raw autoscale block with its defaults,
+// returning nil when it is not set
+func parseAutoscale(raw *rawAutoscale) (*models.AutoscaleConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	autoscale := &models.AutoscaleConfig{
+		TargetRPS:  raw.TargetRPS,
+		MinWorkers: 1,
+		MaxWorkers: 1000,
+		Interval:   5 * time.Second,
+	}
+	if raw.MinWorkers != 0 {
+		autoscale.MinWorkers = raw.MinWorkers
+	}
+	if raw.MaxWorkers != 0 {
+		autoscale.MaxWorkers = raw.MaxWorkers
+	}
+	if name, err := parseDurations(
+		durationField{"max_latency", raw.MaxLatency, &autoscale.MaxLatency},
+		durationField{"interval", raw.Interval, &autoscale.Interval},
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return autoscale, nil
+}
+
+// parseCapacity converts a raw capacity block with its defaults, returning
+// nil when it is not set
+func parseCapacity(raw *rawCapacity) (*models.CapacityConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	capacity := &models.CapacityConfig{
+		StartRPS:      10,
+		MaxRPS:        raw.MaxRPS,
+		StageDuration: 30 * time.Second,
+		MaxErrorRate:  1,
+		Precision:     5,
+	}
+	if raw.StartRPS != 0 {
+		capacity.StartRPS = raw.StartRPS
+	}
+	if raw.MaxErrorRate != nil {
+		capacity.MaxErrorRate = *raw.MaxErrorRate
+	}
+	if raw.Precision != 0 {
+		capacity.Precision = raw.Precision
+	}
+	if name, err := parseDurations(
+		durationField{"stage_duration", raw.StageDuration, &capacity.StageDuration},
+		durationField{"max_p95", raw.MaxP95, &capacity.MaxP95},
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return capacity, nil
+}
+
+// parseReplay converts a raw replay block with its defaults, returning nil
+// when it is not set
+func parseReplay(raw *rawReplay) *models.ReplayConfig {
+	if raw == nil {
+		return nil
+	}
+	replay := &models.ReplayConfig{File: raw.File, Speed: 1}
+	if raw.Speed != 0 {
+		replay.Speed = raw.Speed
+	}
+	return replay
+}
+
+// parseSSE converts a raw sse block with its defaults, returning nil when it
+// is not set
+func parseSSE(raw *rawSSEConfig) (*models.SSEConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	sse := &models.SSEConfig{Window: 10 * time.Second, MinEvents: 1, MaxEvents: raw.MaxEvents}
+	if raw.MinEvents != nil {
+		sse.MinEvents = *raw.MinEvents
+	}
+	if raw.Window != "" {
+		window, err := time.ParseDuration(raw.Window)
+		if err != nil {
+			return nil, fmt.Errorf("window: %w", err)
+		}
+		sse.Window = window
+	}
+	return sse, nil
+}
+
+// parseInject converts a raw inject block, returning nil when it is not set
+func parseInject(raw *rawInjectConfig) (*models.InjectConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	inject := &models.InjectConfig{DropRate: raw.DropRate}
+	var err error
+	if raw.Latency != "" {
+		if inject.Latency, err = time.ParseDuration(raw.Latency); err != nil {
+			return nil, fmt.Errorf("latency: %w", err)
+		}
+	}
+	if raw.Jitter != "" {
+		if inject.Jitter, err = time.ParseDuration(raw.Jitter); err != nil {
+			return nil, fmt.Errorf("jitter: %w", err)
+		}
+	}
+	return inject, nil
+}
+
+// reportFormat infers the format of a report from its file extension,
+// returning an empty string when it cannot tell
+func reportFormat(url string) string {
+	switch strings.ToLower(path.Ext(url)) {
+	case ".json":
+		return "json"
+	case ".html", ".htm":
+		return "html"
+	}
+	return ""
+}
+
+// validateReportUpload checks the destination and format of a report upload
+func validateReportUpload(upload models.ReportUpload) error {
+	scheme, rest, ok := strings.Cut(upload.URL, "://")
+	if !ok || (scheme != "s3" && scheme != "gs" && scheme != "az") {
+		return fmt.Errorf("url must start with s3://, gs:// or az://")
+	}
+	if parts := strings.SplitN(rest, "/", 2); len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("url must name a bucket or account and an object")
+	} else if scheme == "az" && !strings.Contains(parts[1], "/") {
+		return fmt.Errorf("az:// url must name an account, a container and a blob")
+	}
+	switch upload.Format {
+	case "json", "html":
+	case "":
+		return fmt.Errorf("cannot tell the report format of %q, set format to json or html", upload.URL)
+	default:
+		return fmt.Errorf("unsupported format '%s' (expected json or html)", upload.Format)
+	}
+	return nil
+}
+
+func environmentsSetBaseURL(environments map[string]models.Environment) bool {
+	if len(environments) == 0 {
+		return false
+	}
+	for _, env := range environments {
+		if env.BaseURL == "" {
+			return false
 		}
+	}
+	return true
+}
+
+// isHTTPTest reports whether a test sends HTTP requests, rather than socket
+// exchanges, broker messages, or database queries
+func isHTTPTest(test models.TestCase) bool {
+	return test.Socket == nil && test.Messaging == nil && test.SQL == nil
+}
 
-		if test.Method == "" {
-			return fmt.Errorf("test %d: method is required", i)
+// hasHTTPTests reports whether any test sends HTTP requests to the base_url
+func hasHTTPTests(tests []models.TestCase) bool {
+	for _, test := range tests {
+		if isHTTPTest(test) {
+			return true
 		}
+	}
+	return len(tests) == 0
+}
+
+func validateStopOn(stopOn string) error {
+	switch stopOn {
+	case "", models.StopOnFirst, models.StopOnBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown stop_on '%s' (expected first or both)", stopOn)
+}
 
-		if test.Path == "" {
-			return fmt.Errorf("test %d: path is required", i)
+// validateThinkTime checks that a think time distribution has the settings it
+// samples from
+func validateThinkTime(distribution string, mean, stddev, min, max time.Duration) error {
+	if max > 0 && min > max {
+		return fmt.Errorf("think_time_min must not be greater than think_time_max")
+	}
+	switch distribution {
+	case "":
+		if stddev > 0 {
+			return fmt.Errorf("think_time_stddev requires think_time_distribution")
+		}
+	case models.ThinkTimeUniform:
+		if max <= 0 && (mean <= 0 || stddev <= 0) {
+			return fmt.Errorf("uniform think time requires think_time_min and think_time_max, or think_time and think_time_stddev")
+		}
+	case models.ThinkTimeNormal:
+		if mean <= 0 || stddev <= 0 {
+			return fmt.Errorf("normal think time requires think_time (the mean) and think_time_stddev")
+		}
+	case models.ThinkTimeExponential:
+		if mean <= 0 {
+			return fmt.Errorf("exponential think time requires think_time (the mean)")
 		}
+	default:
+		return fmt.Errorf("unknown think_time_distribution '%s' (expected uniform, normal, or exponential)", distribution)
+	}
+	return nil
+}
+
+func validateSOAP(soap *models.SOAPRequest, body interface{}) error {
+	if soap == nil {
+		return nil
+	}
+	if body != nil {
+		return fmt.Errorf("soap cannot be combined with body")
+	}
+	if soap.Version != "" && soap.Version != models.SOAP11 && soap.Version != models.SOAP12 {
+		return fmt.Errorf("soap version must be %s or %s", models.SOAP11, models.SOAP12)
+	}
+	if soap.Envelope != "" && (soap.Header != "" || soap.Body != "") {
+		return fmt.Errorf("soap envelope cannot be combined with header and body")
+	}
+	if soap.Envelope == "" && soap.Body == "" {
+		return fmt.Errorf("soap body or envelope is required")
+	}
+	return nil
+}
 
-		if len(test.ExpectedStatus) == 0 {
-			return fmt.Errorf("test %d: at least one expected status is required", i)
+// validatePathParams checks that path_params and the {name} placeholders of
+// the test's paths match, so a typo does not send a literal placeholder
+func validatePathParams(test models.TestCase) error {
+	if len(test.PathParams) == 0 {
+		return nil
+	}
+	placeholders := variables.PathPlaceholders(test.Path)
+	if test.CompareWith != nil {
+		placeholders = append(placeholders, variables.PathPlaceholders(test.CompareWith.Path)...)
+	}
+	used := make(map[string]bool, len(placeholders))
+	for _, name := range placeholders {
+		if _, ok := test.PathParams[name]; !ok {
+			return fmt.Errorf("path placeholder {%s} has no path_params value", name)
 		}
+		used[name] = true
+	}
 
-		// Validate compare_with configuration
-		if test.CompareWith != nil {
-			if test.CompareWith.Endpoint == "" {
-				return fmt.Errorf("test %d: compare_with.endpoint is required when compare_with is specified", i)
-			}
+	names := make([]string, 0, len(test.PathParams))
+	for name := range test.PathParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[name] {
+			return fmt.Errorf("path_params '%s' is not used in path", name)
+		}
+	}
+	return nil
+}
 
-			for j, assertion := range test.CompareWith.Assertions {
-				if assertion.Type == "" {
-					return fmt.Errorf("test %d: compare_with.assertions[%d].type is required", i, j)
-				}
-				// Target is required for all types except structure_match and status_match
-				if assertion.Target == "" && assertion.Type != "structure_match" && assertion.Type != "status_match" {
-					return fmt.Errorf("test %d: compare_with.assertions[%d].target is required for type %s", i, j, assertion.Type)
-				}
-			}
+func validateRotateHeaders(test models.TestCase) error {
+	for name, rotation := range test.RotateHeaders {
+		if name == "" {
+			return fmt.Errorf("rotate_headers header name cannot be empty")
+		}
+		switch {
+		case len(rotation.Values) > 0 && rotation.Column != "":
+			return fmt.Errorf("rotate_headers '%s' cannot combine values and column", name)
+		case len(rotation.Values) == 0 && rotation.Column == "":
+			return fmt.Errorf("rotate_headers '%s' requires values or column", name)
+		case rotation.Column != "" && len(test.Data) == 0 && test.DataFile == "":
+			return fmt.Errorf("rotate_headers '%s' column requires data or data_file", name)
+		}
+		switch rotation.Strategy {
+		case "", models.DataStrategyCircular, models.DataStrategyRandom:
+		default:
+			return fmt.Errorf("rotate_headers '%s': unknown strategy '%s' (expected circular or random)", name, rotation.Strategy)
 		}
 	}
+	return nil
+}
 
+// validateHeaderName checks that an optional setting holds an HTTP header
+// name (a token without separators)
+func validateHeaderName(setting, name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, c := range name {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", c) {
+			return fmt.Errorf("%s '%s' is not a valid header name", setting, name)
+		}
+	}
 	return nil
 }
+
+func validateSSE(test models.TestCase) error {
+	sse := test.SSE
+	if sse == nil {
+		return nil
+	}
+	if sse.Window <= 0 {
+		return fmt.Errorf("sse window must be positive")
+	}
+	if sse.MinEvents < 0 || sse.MaxEvents < 0 {
+		return fmt.Errorf("sse min_events and max_events must not be negative")
+	}
+	if sse.MaxEvents > 0 && sse.MinEvents > sse.MaxEvents {
+		return fmt.Errorf("sse min_events must not exceed max_events")
+	}
+	if test.Cache {
+		return fmt.Errorf("sse cannot be combined with cache")
+	}
+	return nil
+}
+
+// validateReadBody checks that a test whose response body is not read has
+// nothing that looks at it
+func validateReadBody(test models.TestCase) error {
+	if test.ReadBody == nil || *test.ReadBody {
+		return nil
+	}
+	if !isHTTPTest(test) {
+		return fmt.Errorf("read_body only applies to HTTP tests")
+	}
+	for _, a := range test.Assertions {
+		switch a.Type {
+		case "json_path", "xpath", "body_size":
+			return fmt.Errorf("read_body false cannot be combined with %s assertions", a.Type)
+		}
+	}
+	for _, rule := range test.Extract {
+		if rule.Source == "body" {
+			return fmt.Errorf("read_body false cannot be combined with extraction from the body")
+		}
+	}
+	if test.SuccessWhen != "" {
+		if expression, err := assertion.ParseExpression(test.SuccessWhen); err == nil && expression.ReadsBody() {
+			return fmt.Errorf("read_body false cannot be combined with a success_when reading the body")
+		}
+	}
+	switch {
+	case test.SSE != nil:
+		return fmt.Errorf("read_body false cannot be combined with sse")
+	case test.CompareWith != nil:
+		return fmt.Errorf("read_body false cannot be combined with compare_with")
+	case test.Cache:
+		return fmt.Errorf("read_body false cannot be combined with cache")
+	}
+	return nil
+}
+
+func validateSocket(test models.TestCase) error {
+	socket := test.Socket
+	if socket.Protocol != models.SocketTCP && socket.Protocol != models.SocketUDP {
+		return fmt.Errorf("socket protocol must be %s or %s", models.SocketTCP, models.SocketUDP)
+	}
+	if socket.Address == "" {
+		return fmt.Errorf("socket address is required")
+	}
+	if socket.Payload != "" && socket.PayloadHex != "" {
+		return fmt.Errorf("socket payload cannot be combined with payload_hex")
+	}
+	if _, err := hex.DecodeString(socket.PayloadHex); err != nil {
+		return fmt.Errorf("invalid socket payload_hex: %w", err)
+	}
+	if socket.ReadBytes < 0 {
+		return fmt.Errorf("socket read_bytes must not be negative")
+	}
+	if socket.ReadBytes > 0 && socket.Delimiter != "" {
+		return fmt.Errorf("socket read_bytes cannot be combined with delimiter")
+	}
+	if test.Body != nil || test.SOAP != nil || test.SSE != nil || test.Messaging != nil || test.SQL != nil || test.Cache || test.CompareWith != nil {
+		return fmt.Errorf("socket cannot be combined with body, soap, sse, messaging, sql, cache, or compare_with")
+	}
+	return nil
+}
+
+func validateMessaging(test models.TestCase) error {
+	messaging := test.Messaging
+	if messaging.URL == "" {
+		return fmt.Errorf("messaging url is required")
+	}
+	// The URL is only parsed at runtime, as variables may hold its credentials
+	scheme, _, _ := strings.Cut(messaging.URL, "://")
+	switch scheme {
+	case "kafka":
+		if messaging.Topic == "" {
+			return fmt.Errorf("messaging topic is required for kafka")
+		}
+		if messaging.Exchange != "" || messaging.RoutingKey != "" {
+			return fmt.Errorf("messaging exchange and routing_key are only used with amqp")
+		}
+	case "amqp", "amqps":
+		if messaging.Exchange == "" && messaging.RoutingKey == "" {
+			return fmt.Errorf("messaging exchange or routing_key is required for amqp")
+		}
+		if messaging.Topic != "" || messaging.Key != "" {
+			return fmt.Errorf("messaging topic and key are only used with kafka")
+		}
+	default:
+		return fmt.Errorf("messaging url must start with kafka://, amqp://, or amqps://")
+	}
+	if messaging.Rate < 0 {
+		return fmt.Errorf("messaging rate must not be negative")
+	}
+	if test.Body != nil || test.SOAP != nil || test.SSE != nil || test.Socket != nil || test.SQL != nil || test.Cache || test.CompareWith != nil {
+		return fmt.Errorf("messaging cannot be combined with body, soap, sse, socket, sql, cache, or compare_with")
+	}
+	return nil
+}
+
+func validateSQL(test models.TestCase) error {
+	sql := test.SQL
+	if sql.DSN == "" {
+		return fmt.Errorf("sql dsn is required")
+	}
+	// Like messaging URLs, the DSN is only parsed at runtime
+	scheme, _, _ := strings.Cut(sql.DSN, "://")
+	switch scheme {
+	case "postgres", "postgresql", "mysql":
+	default:
+		return fmt.Errorf("sql dsn must start with postgres://, postgresql://, or mysql://")
+	}
+	if strings.TrimSpace(sql.Query) == "" {
+		return fmt.Errorf("sql query is required")
+	}
+	for i, param := range sql.Params {
+		switch param.(type) {
+		case nil, string, float64, bool:
+		default:
+			return fmt.Errorf("sql params[%d] must be a string, number, boolean, or null", i)
+		}
+	}
+	if test.Body != nil || test.SOAP != nil || test.SSE != nil || test.Socket != nil || test.Messaging != nil || test.Cache || test.CompareWith != nil {
+		return fmt.Errorf("sql cannot be combined with body, soap, sse, socket, messaging, cache, or compare_with")
+	}
+	return nil
+}
+
+func validateReadiness(readiness *models.ReadinessConfig) error {
+	if readiness == nil {
+		return nil
+	}
+	if readiness.URL == "" {
+		return fmt.Errorf("readiness url is required")
+	}
+	if readiness.Timeout <= 0 || readiness.Interval <= 0 {
+		return fmt.Errorf("readiness timeout and interval must be positive")
+	}
+	for _, status := range readiness.ExpectedStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("readiness expected_status %d is not a valid HTTP status", status)
+		}
+	}
+	return nil
+}
+
+func validateSteadyState(steady *models.SteadyStateConfig) error {
+	if steady == nil {
+		return nil
+	}
+	if steady.Window <= 0 {
+		return fmt.Errorf("steady_state window must be positive")
+	}
+	if steady.Tolerance <= 0 {
+		return fmt.Errorf("steady_state tolerance must be positive")
+	}
+	if steady.StableWindows < 1 {
+		return fmt.Errorf("steady_state stable_windows must be at least 1")
+	}
+	return nil
+}
+
+// validateAutoscale checks the goal and bounds of autoscale, and that the
+// run is one whose workers can come and go: stopping a worker drops its job,
+// which only runs limited by duration can afford
+func validateAutoscale(config *models.Config) error {
+	autoscale := config.Global.Autoscale
+	if autoscale == nil {
+		return nil
+	}
+	if autoscale.TargetRPS < 0 {
+		return fmt.Errorf("autoscale target_rps must not be negative")
+	}
+	if autoscale.MaxLatency < 0 {
+		return fmt.Errorf("autoscale max_latency must not be negative")
+	}
+	if autoscale.TargetRPS == 0 && autoscale.MaxLatency == 0 {
+		return fmt.Errorf("autoscale requires target_rps, max_latency, or both")
+	}
+	if autoscale.MinWorkers < 1 {
+		return fmt.Errorf("autoscale min_workers must be at least 1")
+	}
+	if autoscale.MaxWorkers < autoscale.MinWorkers {
+		return fmt.Errorf("autoscale max_workers must not be less than min_workers")
+	}
+	if autoscale.Interval <= 0 {
+		return fmt.Errorf("autoscale interval must be positive")
+	}
+	if len(config.Scenarios) > 0 {
+		return fmt.Errorf("autoscale cannot be combined with scenarios")
+	}
+	if config.Global.Loop {
+		return fmt.Errorf("autoscale cannot be combined with loop")
+	}
+	for _, test := range config.Tests {
+		if len(test.DependsOn) > 0 {
+			return fmt.Errorf("autoscale cannot be combined with depends_on (test '%s')", test.Name)
+		}
+	}
+	if config.RunDuration() == 0 {
+		return fmt.Errorf("autoscale requires every test to run for a duration, without iterations")
+	}
+	return nil
+}
+
+// validateCapacity checks the rates, stages, and thresholds of a capacity
+// search, and that the tests can run in stages: every stage runs them all
+// for stage_duration on the same workers
+func validateCapacity(config *models.Config) error {
+	capacity := config.Global.Capacity
+	if capacity == nil {
+		return nil
+	}
+	if capacity.MaxRPS <= 0 {
+		return fmt.Errorf("capacity max_rps must be positive")
+	}
+	if capacity.StartRPS <= 0 || capacity.StartRPS > capacity.MaxRPS {
+		return fmt.Errorf("capacity start_rps must be positive and at most max_rps")
+	}
+	if capacity.StageDuration <= 0 {
+		return fmt.Errorf("capacity stage_duration must be positive")
+	}
+	if capacity.MaxErrorRate < 0 || capacity.MaxErrorRate > 100 {
+		return fmt.Errorf("capacity max_error_rate must be between 0 and 100")
+	}
+	if capacity.MaxP95 < 0 {
+		return fmt.Errorf("capacity max_p95 must not be negative")
+	}
+	if capacity.Precision <= 0 || capacity.Precision >= 100 {
+		return fmt.Errorf("capacity precision must be between 0 and 100")
+	}
+	if len(config.Scenarios) > 0 {
+		return fmt.Errorf("capacity cannot be combined with scenarios")
+	}
+	if config.Global.Loop {
+		return fmt.Errorf("capacity cannot be combined with loop")
+	}
+	if config.Global.Autoscale != nil {
+		return fmt.Errorf("capacity cannot be combined with autoscale")
+	}
+	for _, test := range config.Tests {
+		if len(test.DependsOn) > 0 {
+			return fmt.Errorf("capacity cannot be combined with depends_on (test '%s')", test.Name)
+		}
+	}
+	return nil
+}
+
+// validateReplay checks the capture and speed of a replay, and that it is
+// the only way the run sends its requests
+func validateReplay(config *models.Config) error {
+	replay := config.Global.Replay
+	if replay == nil {
+		return nil
+	}
+	if replay.File == "" {
+		return fmt.Errorf("replay file is required")
+	}
+	if replay.Speed <= 0 {
+		return fmt.Errorf("replay speed must be positive")
+	}
+	if len(config.Scenarios) > 0 {
+		return fmt.Errorf("replay cannot be combined with scenarios")
+	}
+	if config.Global.Loop {
+		return fmt.Errorf("replay cannot be combined with loop")
+	}
+	if config.Global.Autoscale != nil {
+		return fmt.Errorf("replay cannot be combined with autoscale")
+	}
+	if config.Global.Capacity != nil {
+		return fmt.Errorf("replay cannot be combined with capacity")
+	}
+	for _, test := range config.Tests {
+		if len(test.DependsOn) > 0 {
+			return fmt.Errorf("replay cannot be combined with depends_on (test '%s')", test.Name)
+		}
+	}
+	return nil
+}
+
+// validateResultsSink checks the database, endpoint, table, and batching of
+// a results sink
+func validateResultsSink(sink *models.ResultsSink) error {
+	if sink == nil {
+		return nil
+	}
+	var parts []string
+	switch sink.Type {
+	case models.SinkClickHouse:
+		if sink.URL == "" {
+			return fmt.Errorf("results_sink url is required for clickhouse")
+		}
+		if parts = strings.Split(sink.Table, "."); len(parts) > 2 || !sinkIdentifiers(parts, false) {
+			return fmt.Errorf("results_sink table '%s' must be table or database.table", sink.Table)
+		}
+	case models.SinkBigQuery:
+		if parts = strings.Split(sink.Table, "."); len(parts) != 3 || !sinkIdentifiers(parts[:1], true) || !sinkIdentifiers(parts[1:], false) {
+			return fmt.Errorf("results_sink table '%s' must be project.dataset.table", sink.Table)
+		}
+	case "":
+		return fmt.Errorf("results_sink type is required (clickhouse or bigquery)")
+	default:
+		return fmt.Errorf("results_sink type '%s' is not supported (expected clickhouse or bigquery)", sink.Type)
+	}
+	if sink.URL != "" && !strings.HasPrefix(sink.URL, "http://") && !strings.HasPrefix(sink.URL, "https://") {
+		return fmt.Errorf("results_sink url must start with http:// or https://")
+	}
+	if sink.BatchSize < 1 {
+		return fmt.Errorf("results_sink batch_size must be at least 1")
+	}
+	if sink.FlushInterval <= 0 {
+		return fmt.Errorf("results_sink flush_interval must be positive")
+	}
+	return nil
+}
+
+// sinkIdentifiers reports whether names are valid database, dataset, or
+// table names (letters, digits, and underscores), also allowing dashes as
+// in Google Cloud project IDs
+func sinkIdentifiers(names []string, dashes bool) bool {
+	for _, name := range names {
+		if name == "" {
+			return false
+		}
+		for _, c := range name {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || dashes && c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateSLOs checks slos names, objectives, windows, and test references
+func validateSLOs(slos []models.SLO, tests []models.TestCase) error {
+	testNames := make(map[string]bool, len(tests))
+	for _, test := range tests {
+		testNames[test.Name] = true
+	}
+
+	seen := make(map[string]bool, len(slos))
+	for i, slo := range slos {
+		if slo.Name == "" {
+			return fmt.Errorf("slos %d: name is required", i)
+		}
+		if seen[slo.Name] {
+			return fmt.Errorf("slos %d: duplicate name '%s'", i, slo.Name)
+		}
+		seen[slo.Name] = true
+
+		if slo.Objective <= 0 || slo.Objective >= 100 {
+			return fmt.Errorf("slo %s: objective must be between 0 and 100 (exclusive)", slo.Name)
+		}
+		if slo.Window <= 0 {
+			return fmt.Errorf("slo %s: window must be positive", slo.Name)
+		}
+		if slo.Latency < 0 {
+			return fmt.Errorf("slo %s: latency must not be negative", slo.Name)
+		}
+		if slo.MaxBurnRate <= 0 {
+			return fmt.Errorf("slo %s: max_burn_rate must be positive", slo.Name)
+		}
+		if slo.Test != "" && !testNames[slo.Test] {
+			return fmt.Errorf("slo %s: unknown test '%s'", slo.Name, slo.Test)
+		}
+	}
+	return nil
+}
+
+func validateThrottle(throttle *models.ThrottleConfig) error {
+	if throttle == nil {
+		return nil
+	}
+	if throttle.MaxRetries < 0 || throttle.MaxWait < 0 {
+		return fmt.Errorf("throttle max_retries and max_wait must not be negative")
+	}
+	return nil
+}
+
+func validateInject(inject *models.InjectConfig) error {
+	if inject == nil {
+		return nil
+	}
+	if inject.Latency < 0 || inject.Jitter < 0 {
+		return fmt.Errorf("inject latency and jitter must not be negative")
+	}
+	if inject.DropRate < 0 || inject.DropRate > 1 {
+		return fmt.Errorf("inject drop_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+func validateConfig(config *models.Config) error {
+	if config.Name == "" {
+		return fmt.Errorf("config name is required")
+	}
+
+	// base_url may come from the environments instead, as long as each one
+	// sets it, and is not needed when no test sends HTTP requests
+	// A replay sends its requests to their captured URLs without base_url
+	if config.Global.BaseURL == "" && !environmentsSetBaseURL(config.Environments) && hasHTTPTests(config.Tests) && config.Global.Replay == nil {
+		return fmt.Errorf("global base_url is required")
+	}
+
+	// Validate that either duration or iterations is specified at global level;
+	// a capacity search runs its own stages, and a replay the captured requests
+	if config.Global.Duration <= 0 && config.Global.Iterations <= 0 && config.Global.Capacity == nil && config.Global.Replay == nil {
+		return fmt.Errorf("either global duration or global iterations must be greater than 0")
+	}
+
+	if err := validateStopOn(config.Global.StopOn); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	global := config.Global
+	if err := validateThinkTime(global.ThinkTimeDistribution, global.ThinkTime, global.ThinkTimeStddev, global.ThinkTimeMin, global.ThinkTimeMax); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateInject(global.Inject); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateThrottle(global.Throttle); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateReadiness(global.Readiness); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateSteadyState(global.SteadyState); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateTimeouts(global.ConnectTimeout, global.TLSHandshakeTimeout, global.ResponseHeaderTimeout, global.IdleConnTimeout); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if global.AcceptEncoding != "" && global.DisableCompression {
+		return fmt.Errorf("global accept_encoding cannot be combined with disable_compression")
+	}
+
+	if err := validateHeaderName("request_id_header", global.RequestIDHeader); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+	if err := validateHeaderName("run_id_header", global.RunIDHeader); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if global.WarmPool != nil && global.WarmPool.Connections < 0 {
+		return fmt.Errorf("global warm_pool connections must not be negative")
+	}
+
+	if global.MaxConnectionsPerHost < 0 {
+		return fmt.Errorf("global max_connections_per_host must not be negative")
+	}
+	if global.MaxInFlightPerHost < 0 {
+		return fmt.Errorf("global max_in_flight_per_host must not be negative")
+	}
+	if global.MaxCapturedBodyBytes < 0 {
+		return fmt.Errorf("global max_captured_body_bytes must not be negative")
+	}
+
+	if err := validateSLOs(global.SLOs, config.Tests); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateResultsSink(global.ResultsSink); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateAutoscale(config); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateCapacity(config); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	if err := validateReplay(config); err != nil {
+		return fmt.Errorf("global %w", err)
+	}
+
+	for i, upload := range global.ReportUpload {
+		if err := validateReportUpload(upload); err != nil {
+			return fmt.Errorf("report_upload %d: %w", i, err)
+		}
+	}
+
+	// Warn if both are specified without a stop rule (duration takes precedence)
+	if config.Global.Duration > 0 && config.Global.Iterations > 0 && config.Global.StopOn == "" {
+		slog.Warn("both global duration and iterations specified, duration takes precedence")
+	}
+
+	if config.Global.Loop && len(config.Scenarios) == 0 && !hasDependencies(config.Tests) {
+		return fmt.Errorf("global loop requires tests with depends_on")
+	}
+
+	for name, secret := range config.Global.Secrets {
+		switch secret.Provider {
+		case "env", "vault", "aws":
+		default:
+			return fmt.Errorf("secret %s: unknown provider '%s' (expected env, vault, or aws)", name, secret.Provider)
+		}
+		if secret.Key == "" {
+			return fmt.Errorf("secret %s: key is required", name)
+		}
+	}
+
+	// The tests of a replay only check the captured requests of the same name
+	if len(config.Tests) == 0 && config.Global.Replay == nil {
+		return fmt.Errorf("at least one test case is required")
+	}
+
+	for i, test := range config.Tests {
+		if test.Name == "" {
+			return fmt.Errorf("test %d: name is required", i)
+		}
+
+		// Socket, messaging, and sql tests have no HTTP method, path, or status
+		if test.Socket != nil {
+			if err := validateSocket(test); err != nil {
+				return fmt.Errorf("test %d: %w", i, err)
+			}
+		} else if test.Messaging != nil {
+			if err := validateMessaging(test); err != nil {
+				return fmt.Errorf("test %d: %w", i, err)
+			}
+		} else if test.SQL != nil {
+			if err := validateSQL(test); err != nil {
+				return fmt.Errorf("test %d: %w", i, err)
+			}
+		} else if config.Global.Replay == nil {
+			// The tests of a replay check requests whose method and path are captured
+			if test.Method == "" {
+				return fmt.Errorf("test %d: method is required", i)
+			}
+
+			if test.Path == "" {
+				return fmt.Errorf("test %d: path is required", i)
+			}
+		}
+
+		if err := validatePathParams(test); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateRotateHeaders(test); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if test.AcceptEncoding != "" && test.DisableCompression != nil && *test.DisableCompression {
+			return fmt.Errorf("test %d: accept_encoding cannot be combined with disable_compression", i)
+		}
+
+		if err := validateHeaderName("request_id_header", test.RequestIDHeader); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		for name := range test.Query {
+			if name == "" {
+				return fmt.Errorf("test %d: query parameter name cannot be empty", i)
+			}
+		}
+
+		if err := validateSOAP(test.SOAP, test.Body); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateSSE(test); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateReadBody(test); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if test.SuccessWhen != "" {
+			if _, err := assertion.ParseExpression(test.SuccessWhen); err != nil {
+				return fmt.Errorf("test %d: invalid success_when: %w", i, err)
+			}
+		} else if len(test.ExpectedStatus) == 0 && isHTTPTest(test) {
+			return fmt.Errorf("test %d: at least one expected status is required without success_when", i)
+		}
+
+		if test.AllowedFailureRate < 0 || test.AllowedFailureRate > 100 {
+			return fmt.Errorf("test %d: allowed_failure_rate must be between 0 and 100", i)
+		}
+
+		if err := validateStopOn(test.StopOn); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+		if test.StopOn != "" && (config.TestDuration(test) <= 0 || config.TestIterations(test) <= 0) {
+			return fmt.Errorf("test %d: stop_on requires both duration and iterations (on the test or in global)", i)
+		}
+
+		if err := validateThinkTime(test.ThinkTimeDistribution, test.ThinkTime, test.ThinkTimeStddev, test.ThinkTimeMin, test.ThinkTimeMax); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateInject(test.Inject); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateThrottle(test.Throttle); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		if err := validateTimeouts(test.ConnectTimeout, test.TLSHandshakeTimeout, test.ResponseHeaderTimeout); err != nil {
+			return fmt.Errorf("test %d: %w", i, err)
+		}
+
+		switch test.DependsOnMode {
+		case "", models.DependsOnSuccess, models.DependsOnCompletion, models.DependsOnAny:
+		default:
+			return fmt.Errorf("test %d: unknown depends_on_mode '%s' (expected success, completion, or any)", i, test.DependsOnMode)
+		}
+		if test.DependsOnMode != "" && len(test.DependsOn) == 0 {
+			return fmt.Errorf("test %d: depends_on_mode requires depends_on", i)
+		}
+
+		switch test.DataStrategy {
+		case "", models.DataStrategySequential, models.DataStrategyRandom, models.DataStrategyUnique, models.DataStrategyCircular:
+		default:
+			return fmt.Errorf("test %d: unknown data_strategy '%s' (expected sequential, random, unique, or circular)", i, test.DataStrategy)
+		}
+
+		for j, a := range test.Assertions {
+			if err := assertion.Validate(a); err != nil {
+				return fmt.Errorf("test %d: assertions[%d]: %w", i, j, err)
+			}
+		}
+
+		for j, source := range test.AssertVariables {
+			if _, err := assertion.ParseVariableCheck(source); err != nil {
+				return fmt.Errorf("test %d: assert_variables[%d]: %w", i, j, err)
+			}
+		}
+
+		for j, rule := range test.Extract {
+			if rule.Name == "" {
+				return fmt.Errorf("test %d: extract[%d]: name is required", i, j)
+			}
+			switch rule.Source {
+			case "body", "header":
+				if rule.Path == "" {
+					return fmt.Errorf("test %d: extract[%d]: path is required for source %s", i, j, rule.Source)
+				}
+			case "status":
+			default:
+				return fmt.Errorf("test %d: extract[%d]: unknown source '%s' (expected body, header, or status)", i, j, rule.Source)
+			}
+			if rule.Source != "header" && (rule.Regex != "" || rule.Index != nil) {
+				return fmt.Errorf("test %d: extract[%d]: regex and index apply only to source header", i, j)
+			}
+			if rule.Regex != "" {
+				if _, err := regexp.Compile(rule.Regex); err != nil {
+					return fmt.Errorf("test %d: extract[%d]: invalid regex: %w", i, j, err)
+				}
+			}
+			if _, err := variables.ParsePipeline(rule.Transform); err != nil {
+				return fmt.Errorf("test %d: extract[%d]: %w", i, j, err)
+			}
+		}
+
+		// Validate compare_with configuration
+		if test.CompareWith != nil {
+			if test.CompareWith.Endpoint == "" {
+				return fmt.Errorf("test %d: compare_with.endpoint is required when compare_with is specified", i)
+			}
+
+			switch test.CompareWith.Mode {
+			case "", comparison.ModeFull, comparison.ModePartial, comparison.ModeStructural:
+			default:
+				return fmt.Errorf("test %d: unknown compare_with.mode '%s' (expected full, partial, or structural)", i, test.CompareWith.Mode)
+			}
+
+			for j, assertion := range test.CompareWith.Assertions {
+				if assertion.Type == "" {
+					return fmt.Errorf("test %d: compare_with.assertions[%d].type is required", i, j)
+				}
+				// Target is required for all types except structure_match and status_match
+				if assertion.Target == "" && assertion.Type != "structure_match" && assertion.Type != "status_match" {
+					return fmt.Errorf("test %d: compare_with.assertions[%d].target is required for type %s", i, j, assertion.Type)
+				}
+			}
+		}
+	}
+
+	return validateScenarios(config)
+}
+
+// validateScenarios checks scenario names, test references, and that
+// dependencies stay within a scenario
+func validateScenarios(config *models.Config) error {
+	testByName := make(map[string]models.TestCase, len(config.Tests))
+	for _, test := range config.Tests {
+		testByName[test.Name] = test
+	}
+
+	seen := make(map[string]bool, len(config.Scenarios))
+	for i, scenario := range config.Scenarios {
+		if scenario.Name == "" {
+			return fmt.Errorf("scenario %d: name is required", i)
+		}
+		if seen[scenario.Name] {
+			return fmt.Errorf("scenario %d: duplicate name '%s'", i, scenario.Name)
+		}
+		seen[scenario.Name] = true
+
+		if scenario.Workers < 0 || scenario.Iterations < 0 || scenario.Duration < 0 {
+			return fmt.Errorf("scenario %s: workers, iterations, and duration must not be negative", scenario.Name)
+		}
+		if err := validateThinkTime(scenario.ThinkTimeDistribution, scenario.ThinkTime, scenario.ThinkTimeStddev, scenario.ThinkTimeMin, scenario.ThinkTimeMax); err != nil {
+			return fmt.Errorf("scenario %s: %w", scenario.Name, err)
+		}
+
+		included := make(map[string]bool, len(scenario.Tests))
+		for _, name := range scenario.Tests {
+			if _, ok := testByName[name]; !ok {
+				return fmt.Errorf("scenario %s: unknown test '%s'", scenario.Name, name)
+			}
+			included[name] = true
+		}
+		if scenario.Loop || config.Global.Loop {
+			if !hasDependencies(config.ScenarioConfig(scenario).Tests) {
+				return fmt.Errorf("scenario %s: loop requires tests with depends_on", scenario.Name)
+			}
+		}
+		if len(scenario.Tests) == 0 {
+			continue
+		}
+		for _, name := range scenario.Tests {
+			for _, dep := range testByName[name].DependsOn {
+				if !included[dep] {
+					return fmt.Errorf("scenario %s: test '%s' depends on '%s', which is not part of the scenario", scenario.Name, name, dep)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasDependencies reports whether any of the tests depends on another, which
+// is what loop mode repeats
+func hasDependencies(tests []models.TestCase) bool {
+	for _, test := range tests {
+		if len(test.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
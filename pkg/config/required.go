@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// CheckRequiredVariables verifies that every variable listed in
+// global.required_variables is set, either as a non-empty variable (after the
+// environment is applied) or as a secret. Call it before the run so missing
+// variables fail fast instead of being sent as ${name} placeholders.
+func CheckRequiredVariables(config *models.Config) error {
+	var missing []string
+	seen := make(map[string]bool, len(config.Global.RequiredVariables))
+	for _, name := range config.Global.RequiredVariables {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if _, ok := config.Global.Secrets[name]; ok {
+			continue
+		}
+		if value, ok := config.Global.Variables[name]; ok && value != nil && value != "" {
+			continue
+		}
+		missing = append(missing, name)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required variables: %s (set them in global variables, the selected environment, or secrets)", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRequiredVariables(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			Variables:         map[string]interface{}{"tenant": "acme", "region": ""},
+			Secrets:           map[string]models.SecretRef{"api_key": {Provider: "env", Key: "API_KEY"}},
+			RequiredVariables: []string{"tenant", "api_key"},
+		},
+	}
+	require.NoError(t, CheckRequiredVariables(config))
+
+	config.Global.RequiredVariables = []string{"tenant", "region", "user", "user"}
+	err := CheckRequiredVariables(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing required variables: region, user (")
+}
+
+func TestCheckRequiredVariables_FromEnvironment(t *testing.T) {
+	config := environmentConfig()
+	config.Global.RequiredVariables = []string{"password"}
+	config.Environments["staging"] = models.Environment{Variables: map[string]interface{}{"password": "s3cret"}}
+
+	assert.Error(t, CheckRequiredVariables(config))
+
+	require.NoError(t, ApplyEnvironment(config, "staging"))
+	assert.NoError(t, CheckRequiredVariables(config))
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/assertion"
+	"github.com/andrearaponi/bombardino/pkg/comparison"
+)
+
+// SchemaID identifies the generated JSON Schema
+const SchemaID = "https://github.com/andrearaponi/bombardino/config.schema.json"
+
+// durationPattern matches Go duration strings such as "500ms", "1m30s", or "0"
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// durationFields are string fields parsed with time.ParseDuration
+var durationFields = map[string]bool{
+	"timeout":                 true,
+	"connect_timeout":         true,
+	"tls_handshake_timeout":   true,
+	"response_header_timeout": true,
+	"idle_conn_timeout":       true,
+	"delay":                   true,
+	"duration":                true,
+	"think_time":              true,
+	"think_time_min":          true,
+	"think_time_max":          true,
+	"think_time_stddev":       true,
+	"pacing":                  true,
+	"latency":                 true,
+	"jitter":                  true,
+	"max_wait":                true,
+	"window":                  true,
+	"interval":                true,
+	"flush_interval":          true,
+	"max_latency":             true,
+	"stage_duration":          true,
+	"max_p95":                 true,
+}
+
+// schemaRequired lists the required properties of each definition. The root
+// has none so that files used with include validate on their own.
+var schemaRequired = map[string][]string{
+	"TestCase":         {"name"},
+	"SocketConfig":     {"address"},
+	"MessagingConfig":  {"url"},
+	"SQLConfig":        {"dsn", "query"},
+	"ReadinessConfig":  {"url"},
+	"Scenario":         {"name"},
+	"Secret":           {"provider", "key"},
+	"Extraction":       {"name", "source"},
+	"Assertion":        {"type", "operator"},
+	"CompareConfig":    {"endpoint"},
+	"CompareAssertion": {"type"},
+	"ReportUpload":     {"url"},
+	"Target":           {"url"},
+	"SLO":              {"name", "objective"},
+	"ResultsSink":      {"type", "table"},
+	"Capacity":         {"max_rps"},
+	"Replay":           {"file"},
+}
+
+var thinkTimeDistributions = []string{models.ThinkTimeUniform, models.ThinkTimeNormal, models.ThinkTimeExponential}
+
+// schemaEnums lists the allowed values of string properties
+var schemaEnums = map[string][]string{
+	"TestCase.data_strategy":               {models.DataStrategySequential, models.DataStrategyRandom, models.DataStrategyUnique, models.DataStrategyCircular},
+	"TestCase.stop_on":                     {models.StopOnFirst, models.StopOnBoth},
+	"TestCase.depends_on_mode":             {models.DependsOnSuccess, models.DependsOnCompletion, models.DependsOnAny},
+	"Template.data_strategy":               {models.DataStrategySequential, models.DataStrategyRandom, models.DataStrategyUnique, models.DataStrategyCircular},
+	"Template.stop_on":                     {models.StopOnFirst, models.StopOnBoth},
+	"Template.depends_on_mode":             {models.DependsOnSuccess, models.DependsOnCompletion, models.DependsOnAny},
+	"GlobalConfig.stop_on":                 {models.StopOnFirst, models.StopOnBoth},
+	"GlobalConfig.think_time_distribution": thinkTimeDistributions,
+	"TestCase.think_time_distribution":     thinkTimeDistributions,
+	"Template.think_time_distribution":     thinkTimeDistributions,
+	"Scenario.think_time_distribution":     thinkTimeDistributions,
+	"Secret.provider":                      {"env", "vault", "aws"},
+	"Extraction.source":                    {"body", "header", "status"},
+	"Assertion.type":                       assertion.Types,
+	"Assertion.operator":                   assertion.Operators(),
+	"CompareConfig.mode":                   comparison.Modes,
+	"CompareAssertion.type":                {"field_match", "field_tolerance", "structure_match", "status_match", "response_time_tolerance"},
+	"ReportUpload.format":                  {"json", "html"},
+	"ResultsSink.type":                     {models.SinkClickHouse, models.SinkBigQuery},
+	"SOAPRequest.version":                  {models.SOAP11, models.SOAP12},
+	"SocketConfig.protocol":                {models.SocketTCP, models.SocketUDP},
+	"HeaderRotation.strategy":              {models.DataStrategyCircular, models.DataStrategyRandom},
+}
+
+// Schema returns a JSON Schema (draft 2020-12) for the config file format,
+// generated from the types the parser decodes into so it never drifts from
+// what LoadFromFile accepts
+func Schema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	root := structSchema(reflect.TypeOf(rawConfig{}), "Config", defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "Bombardino configuration"
+	root["$defs"] = defs
+	return root
+}
+
+func structSchema(typ reflect.Type, name string, defs map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonField := jsonName(field)
+		if jsonField == "" {
+			continue
+		}
+		prop := typeSchema(field.Type, defs)
+		if values, ok := schemaEnums[name+"."+jsonField]; ok {
+			prop["enum"] = values
+		}
+		if durationFields[jsonField] && field.Type.Kind() == reflect.String {
+			prop["pattern"] = durationPattern
+			prop["description"] = "Duration, e.g. 500ms, 30s, or 5m"
+		}
+		properties[jsonField] = prop
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[name]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(typ reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if typ == reflect.TypeOf(baseURLs{}) {
+		return baseURLSchema(defs)
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(typ.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(typ.Elem(), defs)}
+	case reflect.Struct:
+		name := strings.TrimPrefix(typ.Name(), "raw")
+		if _, ok := defs[name]; !ok {
+			defs[name] = nil // placeholder guards against recursive types
+			defs[name] = structSchema(typ, name, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		// interface{} fields (body, values, data rows) accept any JSON value
+		return map[string]interface{}{}
+	}
+}
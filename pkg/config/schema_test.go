@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"$schema", "include", "name", "description", "global", "tests", "scenarios", "environments"} {
+		assert.Contains(t, properties, name)
+	}
+	assert.Equal(t, map[string]interface{}{"$ref": "#/$defs/TestCase"}, properties["tests"].(map[string]interface{})["items"])
+
+	defs := schema["$defs"].(map[string]interface{})
+	testCase := defs["TestCase"].(map[string]interface{})
+	assert.Equal(t, []string{"name"}, testCase["required"])
+
+	testProps := testCase["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}, testProps["expected_status"])
+	assert.Equal(t, map[string]interface{}{}, testProps["body"])
+	assert.Contains(t, testProps["data_strategy"], "enum")
+	assert.Equal(t, durationPattern, testProps["timeout"].(map[string]interface{})["pattern"])
+}
+
+func TestLoadFromFile_SchemaKeyAllowed(t *testing.T) {
+	configContent := `{
+		"$schema": "./bombardino.schema.json",
+		"name": "Schema Hint",
+		"global": {"base_url": "https://api.example.com", "iterations": 1},
+		"tests": [{"name": "t", "method": "GET", "path": "/", "expected_status": [200]}]
+	}`
+
+	_, err := LoadFromFile(createTempFile(t, configContent))
+	require.NoError(t, err)
+}
+
+func TestSchema_RefsResolve(t *testing.T) {
+	data, err := json.Marshal(Schema())
+	require.NoError(t, err)
+
+	defs := Schema()["$defs"].(map[string]interface{})
+	for _, match := range regexp.MustCompile(`"\$ref":"#/\$defs/([A-Za-z]+)"`).FindAllStringSubmatch(string(data), -1) {
+		assert.Contains(t, defs, match[1])
+	}
+	assert.False(t, strings.Contains(string(data), "raw"), "definition names must not leak parser type names")
+}
+
+func TestSchema_DurationPattern(t *testing.T) {
+	pattern := regexp.MustCompile(durationPattern)
+	for _, valid := range []string{"0", "500ms", "30s", "1m30s", "1.5h", "100us"} {
+		assert.True(t, pattern.MatchString(valid), valid)
+	}
+	for _, invalid := range []string{"", "5", "5 minutes", "fast", "-1s"} {
+		assert.False(t, pattern.MatchString(invalid), invalid)
+	}
+}
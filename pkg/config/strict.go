@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// decodeStrict decodes data into v, rejecting fields that v does not define.
+// Errors carry the JSON pointer of the offending field and its line number.
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := checkFields(dec, reflect.TypeOf(v), "", data); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%v (%s)", syntaxErr, position(data, syntaxErr.Offset))
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("unexpected end of JSON input")
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%s: expected %s, got %s (%s)",
+				pointer(typeErr.Field), typeErr.Type, typeErr.Value, position(data, typeErr.Offset))
+		}
+		return err
+	}
+	return nil
+}
+
+// checkFields walks the next JSON value alongside typ and reports the first
+// object key that typ has no field for
+func checkFields(dec *json.Decoder, typ reflect.Type, path string, data []byte) error {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			keyPath := path + "/" + escapePointer(key)
+
+			var fieldType reflect.Type
+			switch {
+			case typ != nil && typ.Kind() == reflect.Struct:
+				field, found := lookupField(typ, key)
+				if !found {
+					// InputOffset is just past the key's closing quote
+					start := dec.InputOffset() - int64(len(key)) - 2
+					msg := fmt.Sprintf("unknown field %q at %s (%s)", key, keyPath, position(data, max(start, 0)))
+					if suggestion := closestField(typ, key); suggestion != "" {
+						msg += fmt.Sprintf("; did you mean %q?", suggestion)
+					}
+					return errors.New(msg)
+				}
+				fieldType = field
+			case typ != nil && typ.Kind() == reflect.Map:
+				fieldType = typ.Elem()
+			}
+
+			if err := checkFields(dec, fieldType, keyPath, data); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		for i := 0; dec.More(); i++ {
+			if err := checkFields(dec, elemType, fmt.Sprintf("%s/%d", path, i), data); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// lookupField finds the struct field for a JSON key, matching names
+// case-insensitively like encoding/json does
+func lookupField(typ reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if name := jsonName(field); name != "" && strings.EqualFold(name, key) {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// closestField suggests a known field within two edits of key
+func closestField(typ reflect.Type, key string) string {
+	best, bestDistance := "", 3
+	for i := 0; i < typ.NumField(); i++ {
+		name := jsonName(typ.Field(i))
+		if name == "" {
+			continue
+		}
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// position converts a byte offset into a "line N, column M" description
+func position(data []byte, offset int64) string {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Sprintf("line %d, column %d", line, column)
+}
+
+// pointer turns an encoding/json field path ("tests.0.iterations") into a
+// JSON pointer ("/tests/0/iterations")
+func pointer(field string) string {
+	if field == "" {
+		return "/"
+	}
+	return "/" + strings.ReplaceAll(field, ".", "/")
+}
+
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromFile_StrictDecoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "unknown global field",
+			content: `{
+  "name": "Strict",
+  "global": {
+    "base_url": "https://api.example.com",
+    "iternations": 10
+  },
+  "tests": []
+}`,
+			wantErr: `unknown field "iternations" at /global/iternations (line 5, column 5); did you mean "iterations"?`,
+		},
+		{
+			name: "unknown test field",
+			content: `{
+  "name": "Strict",
+  "global": {"base_url": "https://api.example.com", "iterations": 1},
+  "tests": [
+    {"name": "a", "method": "GET", "path": "/", "expected_status": [200]},
+    {"name": "b", "method": "GET", "path": "/", "expected_status": [200], "dependson": ["a"]}
+  ]
+}`,
+			wantErr: `unknown field "dependson" at /tests/1/dependson (line 6, column 75); did you mean "depends_on"?`,
+		},
+		{
+			name: "unknown nested field",
+			content: `{
+  "name": "Strict",
+  "global": {"base_url": "https://api.example.com", "iterations": 1},
+  "tests": [{"name": "a", "method": "GET", "path": "/", "expected_status": [200],
+    "extract": [{"name": "id", "source": "body", "path": "id", "selector": "x"}]}]
+}`,
+			wantErr: `unknown field "selector" at /tests/0/extract/0/selector (line 5`,
+		},
+		{
+			name: "wrong type",
+			content: `{
+  "name": "Strict",
+  "global": {"base_url": "https://api.example.com", "iterations": "ten"},
+  "tests": []
+}`,
+			wantErr: "/global/iterations: expected int, got string (line 3",
+		},
+		{
+			name: "syntax error",
+			content: `{
+  "name": "Strict",
+  "global": {"base_url": "https://api.example.com",,}
+}`,
+			wantErr: "(line 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromFile(createTempFile(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadFromFile_StrictDecodingFreeFormFields(t *testing.T) {
+	configContent := `{
+		"name": "Free Form",
+		"global": {"base_url": "https://api.example.com", "iterations": 1, "variables": {"any_name": {"nested": true}}},
+		"tests": [{
+			"name": "t", "method": "POST", "path": "/", "expected_status": [200],
+			"headers": {"X-Anything": "ok"},
+			"body": {"arbitrary": {"keys": [1, 2]}},
+			"data": [{"whatever": 1}]
+		}]
+	}`
+
+	_, err := LoadFromFile(createTempFile(t, configContent))
+	require.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rawTemplate holds the settings a test inherits from a named template. It
+// has the fields of a test, none of them required.
+type rawTemplate rawTestCase
+
+// applyTemplates fills the settings each test leaves unset from its template.
+// Templates can themselves use a template. Headers, path parameters, and query
+// parameters are merged key by key and the template's assertions run before
+// the test's own; any other setting of the test replaces the template's.
+func applyTemplates(raw *rawConfig) error {
+	resolved := make(map[string]rawTestCase, len(raw.Templates))
+	for i := range raw.Tests {
+		test := &raw.Tests[i]
+		if test.Template == "" {
+			continue
+		}
+		template, err := resolveTemplate(raw.Templates, test.Template, resolved, nil)
+		if err != nil {
+			return fmt.Errorf("test '%s': %w", test.Name, err)
+		}
+		inherit(test, template)
+	}
+	return nil
+}
+
+// resolveTemplate returns a template with the settings of the templates it
+// uses filled in; stack holds the templates being resolved, to detect cycles
+func resolveTemplate(templates map[string]rawTemplate, name string, resolved map[string]rawTestCase, stack []string) (rawTestCase, error) {
+	if template, ok := resolved[name]; ok {
+		return template, nil
+	}
+	for _, seen := range stack {
+		if seen == name {
+			return rawTestCase{}, fmt.Errorf("template cycle: %s", strings.Join(append(stack, name), " -> "))
+		}
+	}
+	raw, ok := templates[name]
+	if !ok {
+		return rawTestCase{}, fmt.Errorf("unknown template '%s'", name)
+	}
+
+	template := rawTestCase(raw)
+	if template.Template != "" {
+		parent, err := resolveTemplate(templates, template.Template, resolved, append(stack, name))
+		if err != nil {
+			return rawTestCase{}, err
+		}
+		inherit(&template, parent)
+	}
+	resolved[name] = template
+	return template, nil
+}
+
+// inherit copies the settings of template that test leaves unset
+func inherit(test *rawTestCase, template rawTestCase) {
+	test.Headers = mergeStrings(template.Headers, test.Headers)
+	test.PathParams = mergeStrings(template.PathParams, test.PathParams)
+	test.Query = mergeStrings(template.Query, test.Query)
+	if len(template.Assertions) > 0 {
+		test.Assertions = append(append([]rawAssertion{}, template.Assertions...), test.Assertions...)
+	}
+
+	dst := reflect.ValueOf(test).Elem()
+	src := reflect.ValueOf(template)
+	for i := 0; i < dst.NumField(); i++ {
+		switch dst.Type().Field(i).Name {
+		case "Name", "Description", "Template", "Headers", "PathParams", "Query", "Assertions":
+			continue
+		}
+		if field := dst.Field(i); field.IsZero() {
+			field.Set(src.Field(i))
+		}
+	}
+}
+
+// mergeStrings returns the keys of base overridden by those of override
+func mergeStrings(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Templates(t *testing.T) {
+	config, err := Parse([]byte(`{
+		"name": "Templates",
+		"global": {"base_url": "https://api.example.com", "iterations": 1, "variables": {"token": "t"}},
+		"templates": {
+			"authenticated": {
+				"headers": {"Authorization": "Bearer ${token}", "Accept": "*/*"},
+				"timeout": "5s",
+				"expected_status": [200],
+				"assertions": [{"type": "response_time", "target": "response", "operator": "lt", "value": "1s"}]
+			},
+			"json_api": {"template": "authenticated", "method": "GET", "headers": {"Accept": "application/json"}}
+		},
+		"tests": [
+			{"name": "Orders", "template": "json_api", "path": "/orders"},
+			{"name": "Create Order", "template": "json_api", "method": "POST", "path": "/orders", "body": {"sku": "A1"},
+			 "expected_status": [201], "headers": {"Idempotency-Key": "k1"},
+			 "assertions": [{"type": "json_path", "target": "id", "operator": "exists"}]},
+			{"name": "Health", "method": "GET", "path": "/health", "expected_status": [200]}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Len(t, config.Tests, 3)
+
+	orders := config.Tests[0]
+	assert.Equal(t, "GET", orders.Method)
+	assert.Equal(t, "/orders", orders.Path)
+	assert.Equal(t, []int{200}, orders.ExpectedStatus)
+	assert.Equal(t, 5*time.Second, orders.Timeout)
+	assert.Equal(t, "application/json", orders.Headers["Accept"], "the nearest template wins")
+	assert.Equal(t, "Bearer ${token}", orders.Headers["Authorization"])
+	require.Len(t, orders.Assertions, 1)
+
+	create := config.Tests[1]
+	assert.Equal(t, "POST", create.Method)
+	assert.Equal(t, []int{201}, create.ExpectedStatus)
+	assert.Equal(t, 5*time.Second, create.Timeout)
+	assert.Len(t, create.Headers, 3)
+	require.Len(t, create.Assertions, 2)
+	assert.Equal(t, "response_time", create.Assertions[0].Type, "template assertions come first")
+	assert.Equal(t, "json_path", create.Assertions[1].Type)
+
+	health := config.Tests[2]
+	assert.Empty(t, health.Headers)
+	assert.Zero(t, health.Timeout)
+}
+
+func TestParse_TemplateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates string
+		wantErr   string
+	}{
+		{"unknown", `{}`, "test 'Orders': unknown template 'json_api'"},
+		{"cycle", `{"json_api": {"template": "base"}, "base": {"template": "json_api"}}`, "test 'Orders': template cycle: json_api -> base -> json_api"},
+		{"unknown field", `{"json_api": {"timeot": "5s"}}`, `unknown field "timeot" at /templates/json_api/timeot`},
+		{"invalid setting", `{"json_api": {"timeout": "soon"}}`, "invalid timeout for test 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{
+				"name": "Templates",
+				"global": {"base_url": "https://api.example.com", "iterations": 1},
+				"templates": ` + tt.templates + `,
+				"tests": [{"name": "Orders", "template": "json_api", "method": "GET", "path": "/orders", "expected_status": [200]}]
+			}`))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadFromFile_IncludedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "templates.json", `{"templates": {"json_api": {"method": "GET", "expected_status": [200]}}}`)
+	main := writeConfigFile(t, dir, "main.json", `{
+		"include": ["templates.json"],
+		"name": "Main",
+		"global": {"base_url": "https://api.example.com", "iterations": 1},
+		"tests": [{"name": "Orders", "template": "json_api", "path": "/orders"}]
+	}`)
+
+	config, err := LoadFromFile(filepath.Join(dir, filepath.Base(main)))
+	require.NoError(t, err)
+	assert.Equal(t, "GET", config.Tests[0].Method)
+	assert.Equal(t, []int{200}, config.Tests[0].ExpectedStatus)
+}
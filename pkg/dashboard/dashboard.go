@@ -0,0 +1,219 @@
+// Package dashboard generates a Grafana dashboard for bombardino dashboard,
+// charting the metrics runs write as OpenMetrics or push to a Prometheus
+// Pushgateway (see the reporter package) across runs.
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Options name the generated dashboard
+type Options struct {
+	Title string // Title shown in Grafana
+	UID   string // Stable ID, so importing again replaces the dashboard
+}
+
+// DefaultOptions are used for the options left empty
+func DefaultOptions() Options {
+	return Options{Title: "Bombardino", UID: "bombardino"}
+}
+
+// datasource makes panels query the Prometheus data source picked in the
+// dashboard's datasource variable
+var datasource = map[string]string{"type": "prometheus", "uid": "${datasource}"}
+
+type panel struct {
+	ID          int                    `json:"id"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	GridPos     gridPos                `json:"gridPos"`
+	Datasource  map[string]string      `json:"datasource,omitempty"`
+	Targets     []target               `json:"targets,omitempty"`
+	FieldConfig map[string]interface{} `json:"fieldConfig,omitempty"`
+	Options     map[string]interface{} `json:"options,omitempty"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type target struct {
+	RefID        string            `json:"refId"`
+	Datasource   map[string]string `json:"datasource"`
+	Expr         string            `json:"expr"`
+	LegendFormat string            `json:"legendFormat,omitempty"`
+}
+
+type variable struct {
+	Name       string            `json:"name"`
+	Label      string            `json:"label"`
+	Type       string            `json:"type"`
+	Query      interface{}       `json:"query"`
+	Datasource map[string]string `json:"datasource,omitempty"`
+	Refresh    int               `json:"refresh,omitempty"` // 2: on time range change
+	Multi      bool              `json:"multi,omitempty"`
+	IncludeAll bool              `json:"includeAll,omitempty"`
+	AllValue   string            `json:"allValue,omitempty"`
+	Current    map[string]string `json:"current,omitempty"`
+}
+
+// Generate returns the dashboard as JSON, ready to import in Grafana
+func Generate(opts Options) ([]byte, error) {
+	defaults := DefaultOptions()
+	if opts.Title == "" {
+		opts.Title = defaults.Title
+	}
+	if opts.UID == "" {
+		opts.UID = defaults.UID
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           opts.UID,
+		"title":         opts.Title,
+		"description":   "Load test runs of bombardino, from the metrics of -pushgateway or -report-file openmetrics=",
+		"tags":          []string{"bombardino", "load-testing"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"time":          map[string]string{"from": "now-7d", "to": "now"},
+		"refresh":       "1m",
+		"templating":    map[string]interface{}{"list": variables()},
+		"panels":        panels(),
+	}
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return data, nil
+}
+
+// variables pick the data source, and the jobs and tests to chart. The job
+// is the one of -pushgateway-job, which Prometheus adds to every metric.
+func variables() []variable {
+	return []variable{
+		{
+			Name:    "datasource",
+			Label:   "Data source",
+			Type:    "datasource",
+			Query:   "prometheus",
+			Current: map[string]string{},
+		},
+		{
+			Name:       "job",
+			Label:      "Job",
+			Type:       "query",
+			Query:      map[string]string{"query": "label_values(bombardino_run_info, job)", "refId": "job"},
+			Datasource: datasource,
+			Refresh:    2,
+			Multi:      true,
+			IncludeAll: true,
+			AllValue:   ".*",
+		},
+		{
+			Name:       "test",
+			Label:      "Test",
+			Type:       "query",
+			Query:      map[string]string{"query": `label_values(bombardino_test_requests{job=~"$job"}, test)`, "refId": "test"},
+			Datasource: datasource,
+			Refresh:    2,
+			Multi:      true,
+			IncludeAll: true,
+			AllValue:   ".*",
+		},
+	}
+}
+
+func panels() []panel {
+	var list []panel
+	add := func(p panel) {
+		p.ID = len(list) + 1
+		list = append(list, p)
+	}
+
+	add(stat("Result of the last run", `bombardino_run_passed{job=~"$job"}`, "none", 0, 0, map[string]interface{}{
+		"mappings": []interface{}{map[string]interface{}{
+			"type": "value",
+			"options": map[string]interface{}{
+				"0": map[string]string{"text": "FAILED", "color": "red"},
+				"1": map[string]string{"text": "PASSED", "color": "green"},
+			},
+		}},
+	}))
+	add(stat("Requests/sec", `bombardino_requests_per_second{job=~"$job"}`, "reqps", 6, 0, nil))
+	add(stat("P95 response time", `bombardino_response_time_seconds{job=~"$job", percentile="95"}`, "s", 12, 0, nil))
+	add(stat("Error rate",
+		`sum by (job) (bombardino_requests{job=~"$job", result="failed"}) / sum by (job) (bombardino_requests{job=~"$job", result=~"success|failed"})`,
+		"percentunit", 18, 0, nil))
+
+	add(timeseries("Response time", "s", 0, 4,
+		target{Expr: `bombardino_response_time_seconds{job=~"$job"}`, LegendFormat: "{{job}} P{{percentile}}"},
+		target{Expr: `bombardino_response_time_avg_seconds{job=~"$job"}`, LegendFormat: "{{job}} avg"},
+	))
+	add(timeseries("Throughput", "reqps", 12, 4,
+		target{Expr: `bombardino_requests_per_second{job=~"$job"}`, LegendFormat: "{{job}}"},
+	))
+	add(timeseries("Requests by result", "short", 0, 12,
+		target{Expr: `bombardino_requests{job=~"$job"}`, LegendFormat: "{{job}} {{result}}"},
+	))
+	add(timeseries("Responses by status code", "short", 12, 12,
+		target{Expr: `bombardino_responses{job=~"$job"}`, LegendFormat: "{{job}} {{code}}"},
+	))
+	add(timeseries("P95 response time by test", "s", 0, 20,
+		target{Expr: `bombardino_test_response_time_seconds{job=~"$job", test=~"$test", percentile="95"}`, LegendFormat: "{{job}} {{test}}"},
+	))
+	add(timeseries("Failed requests by test", "short", 12, 20,
+		target{Expr: `bombardino_test_requests{job=~"$job", test=~"$test", result="failed"}`, LegendFormat: "{{job}} {{test}}"},
+	))
+	return list
+}
+
+// stat is a 6x4 panel showing the last value of expr
+func stat(title, expr, unit string, x, y int, extra map[string]interface{}) panel {
+	defaults := map[string]interface{}{"unit": unit}
+	for key, value := range extra {
+		defaults[key] = value
+	}
+	return panel{
+		Type:        "stat",
+		Title:       title,
+		GridPos:     gridPos{H: 4, W: 6, X: x, Y: y},
+		Datasource:  datasource,
+		Targets:     []target{{RefID: "A", Datasource: datasource, Expr: expr, LegendFormat: "{{job}}"}},
+		FieldConfig: map[string]interface{}{"defaults": defaults, "overrides": []interface{}{}},
+		Options: map[string]interface{}{
+			"reduceOptions": map[string]interface{}{"calcs": []string{"lastNotNull"}, "fields": "", "values": false},
+			"colorMode":     "value",
+			"graphMode":     "area",
+		},
+	}
+}
+
+// timeseries is a 12x8 panel charting targets over time. A Pushgateway
+// keeps the values of the last run of a job, so each run shows up as a step.
+func timeseries(title, unit string, x, y int, targets ...target) panel {
+	for i := range targets {
+		targets[i].RefID = string(rune('A' + i))
+		targets[i].Datasource = datasource
+	}
+	return panel{
+		Type:       "timeseries",
+		Title:      title,
+		GridPos:    gridPos{H: 8, W: 12, X: x, Y: y},
+		Datasource: datasource,
+		Targets:    targets,
+		FieldConfig: map[string]interface{}{
+			"defaults": map[string]interface{}{
+				"unit":   unit,
+				"custom": map[string]interface{}{"lineInterpolation": "stepAfter", "fillOpacity": 10},
+			},
+			"overrides": []interface{}{},
+		},
+		Options: map[string]interface{}{
+			"legend":  map[string]interface{}{"displayMode": "table", "placement": "bottom", "calcs": []string{"lastNotNull", "max"}},
+			"tooltip": map[string]interface{}{"mode": "multi"},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	data, err := Generate(Options{Title: "Checkout load tests"})
+	require.NoError(t, err)
+
+	var dashboard struct {
+		UID        string `json:"uid"`
+		Title      string `json:"title"`
+		Templating struct {
+			List []struct {
+				Name  string          `json:"name"`
+				Query json.RawMessage `json:"query"`
+			} `json:"list"`
+		} `json:"templating"`
+		Panels []struct {
+			ID      int    `json:"id"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(data, &dashboard))
+	assert.Equal(t, "bombardino", dashboard.UID)
+	assert.Equal(t, "Checkout load tests", dashboard.Title)
+	require.Len(t, dashboard.Templating.List, 3)
+	assert.Equal(t, "datasource", dashboard.Templating.List[0].Name)
+
+	ids := make(map[int]bool)
+	for _, panel := range dashboard.Panels {
+		assert.False(t, ids[panel.ID], "duplicate panel id %d", panel.ID)
+		ids[panel.ID] = true
+		assert.NotEmpty(t, panel.Targets, panel.Title)
+	}
+}
+
+// Every metric the dashboard queries must be one the reporter writes
+func TestGenerate_MetricNames(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:    2,
+		SuccessfulReqs:   1,
+		FailedReqs:       1,
+		TotalTime:        time.Second,
+		StatusCodes:      map[int]int{200: 1, 500: 1},
+		TotalAssertions:  1,
+		AssertionsPassed: 1,
+		EndpointResults:  map[string]*models.EndpointSummary{"Login": {SuccessfulReqs: 1, FailedReqs: 1}},
+		Metadata:         &models.RunMetadata{ConfigName: "API Tests", FinishedAt: time.Now()},
+	}
+	var metrics bytes.Buffer
+	require.NoError(t, reporter.New(false).WriteOpenMetricsReport(&metrics, summary))
+	written := make(map[string]bool)
+	for _, match := range regexp.MustCompile(`(?m)^# TYPE (\S+)`).FindAllStringSubmatch(metrics.String(), -1) {
+		written[match[1]] = true
+	}
+
+	data, err := Generate(DefaultOptions())
+	require.NoError(t, err)
+	queried := regexp.MustCompile(`bombardino_[a-z_]+`).FindAllString(string(data), -1)
+	require.NotEmpty(t, queried)
+	for _, name := range queried {
+		assert.True(t, written[name], "dashboard queries %s, which is not written", name)
+	}
+}
@@ -0,0 +1,116 @@
+package debuglog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Writer streams debug log entries to a JSONL file with optional size-based rotation
+type Writer struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// New opens (or creates) the log file. When maxSize is greater than zero the file is
+// rotated once it would grow beyond maxSize bytes, keeping at most maxBackups old files
+// named path.1 (newest) to path.N (oldest).
+func New(path string, maxSize int64, maxBackups int) (*Writer, error) {
+	w := &Writer{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends a single entry as one JSON line
+func (w *Writer) Write(log models.DebugLog) error {
+	line, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal debug log: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("debug log writer is closed")
+	}
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(line)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write debug log: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the underlying file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens the current log file in append mode
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat debug log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts existing backups and starts a fresh log file
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close debug log file: %w", err)
+	}
+	w.file = nil
+
+	if w.maxBackups > 0 {
+		// Drop the oldest backup, then shift path.N-1 -> path.N ... path -> path.1
+		os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxBackups))
+		for i := w.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+		}
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate debug log file: %w", err)
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return fmt.Errorf("failed to truncate debug log file: %w", err)
+	}
+
+	return w.open()
+}
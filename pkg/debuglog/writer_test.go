@@ -0,0 +1,76 @@
+package debuglog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countLines(t *testing.T, path string) int {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var log models.DebugLog
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &log))
+		lines++
+	}
+	return lines
+}
+
+func TestWriter_WritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	w, err := New(path, 0, 0)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.Write(models.DebugLog{Type: "request", TestName: "t"}))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, 3, countLines(t, path))
+}
+
+func TestWriter_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	w, err := New(path, 200, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, w.Write(models.DebugLog{Type: "response", TestName: "rotation-test", Body: "0123456789"}))
+	}
+	require.NoError(t, w.Close())
+
+	assert.FileExists(t, path)
+	assert.FileExists(t, path+".1")
+	assert.FileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(200))
+}
+
+func TestWriter_WriteAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	w, err := New(path, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Error(t, w.Write(models.DebugLog{}))
+	assert.NoError(t, w.Close())
+}
+
+func TestNew_InvalidPath(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing", "debug.jsonl"), 0, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// autoscaleTolerance is the share of target_rps an interval must reach to
+// meet the goal, so that noise around the target still counts
+const autoscaleTolerance = 0.95
+
+// autoscaler grows and shrinks the worker pool every interval from the
+// throughput and P95 of the requests finished in it
+type autoscaler struct {
+	config        models.AutoscaleConfig
+	mu            sync.Mutex
+	start         time.Time
+	intervalStart time.Time
+	times         []time.Duration
+	steps         []models.AutoscaleStep
+	needed        int // Workers of the last interval that met the goal
+	peak          int
+}
+
+// newAutoscaler returns the autoscaler of config, or nil without autoscale
+func newAutoscaler(config *models.AutoscaleConfig) *autoscaler {
+	if config == nil {
+		return nil
+	}
+	return &autoscaler{config: *config}
+}
+
+// add counts a finished request of the current interval; skipped requests
+// are left out
+func (a *autoscaler) add(result models.TestResult) {
+	if a == nil || result.Skipped {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.times = append(a.times, result.ResponseTime)
+}
+
+// runAutoscaled runs workers on jobs, starting from the given number, and
+// resizes the pool every interval until the jobs are generated or ctx is
+// done. Stopped workers finish their request in flight.
+func (e *Engine) runAutoscaled(ctx context.Context, workers int, jobs <-chan Job, results chan<- models.TestResult, generated <-chan struct{}) {
+	a := e.autoscale
+	var wg sync.WaitGroup
+	var stops []context.CancelFunc
+	resize := func(n int) {
+		for len(stops) < n {
+			workerCtx, stop := context.WithCancel(ctx)
+			stops = append(stops, stop)
+			wg.Add(1)
+			go e.worker(workerCtx, len(stops), jobs, results, &wg)
+		}
+		for len(stops) > n {
+			stops[len(stops)-1]()
+			stops = stops[:len(stops)-1]
+		}
+	}
+
+	resize(a.clamp(workers))
+	a.begin(time.Now(), len(stops))
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-generated:
+			running = false
+		case now := <-ticker.C:
+			step := a.adjust(now, len(stops))
+			if step.Next != step.Workers {
+				e.log.Debug("autoscale", "workers", step.Workers, "next", step.Next,
+					"rps", math.Round(step.RPS*10)/10, "p95", step.P95, "met", step.Met)
+			}
+			resize(step.Next)
+		}
+	}
+
+	wg.Wait()
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// begin starts the first interval with the initial workers
+func (a *autoscaler) begin(now time.Time, workers int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.start = now
+	a.intervalStart = now
+	a.peak = workers
+}
+
+// adjust ends the interval at now, run by the given workers, and returns it
+// with the workers of the next one
+func (a *autoscaler) adjust(now time.Time, workers int) models.AutoscaleStep {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	step := models.AutoscaleStep{
+		Elapsed: now.Sub(a.start),
+		Workers: workers,
+		P95:     calculatePercentile(a.times, 95),
+	}
+	if elapsed := now.Sub(a.intervalStart).Seconds(); elapsed > 0 {
+		step.RPS = float64(len(a.times)) / elapsed
+	}
+	step.Met = (a.config.TargetRPS == 0 || step.RPS >= a.config.TargetRPS*autoscaleTolerance) &&
+		(a.config.MaxLatency == 0 || step.P95 <= a.config.MaxLatency)
+	step.Next = a.next(workers, step.RPS, step.P95)
+
+	if step.Met {
+		a.needed = workers
+	}
+	a.peak = max(a.peak, step.Next)
+	a.steps = append(a.steps, step)
+	a.intervalStart = now
+	a.times = a.times[:0]
+	return step
+}
+
+// next returns the workers that should move the next interval towards the
+// goal. A P95 over the set point always backs off; otherwise the pool is
+// sized by the throughput a worker has shown, changing at most twofold at
+// once, or grows while only a latency set point is given.
+func (a *autoscaler) next(workers int, rps float64, p95 time.Duration) int {
+	var next int
+	switch {
+	case a.config.MaxLatency > 0 && p95 > a.config.MaxLatency:
+		next = workers - max(1, workers/4)
+	case a.config.TargetRPS > 0 && rps > 0:
+		next = int(math.Ceil(a.config.TargetRPS * float64(workers) / rps))
+		next = min(max(next, workers/2), workers*2)
+	case a.config.TargetRPS > 0:
+		next = workers * 2
+	default:
+		next = workers + max(1, workers/4)
+	}
+	return a.clamp(next)
+}
+
+// clamp bounds workers to min_workers and max_workers
+func (a *autoscaler) clamp(workers int) int {
+	return min(max(workers, a.config.MinWorkers), a.config.MaxWorkers)
+}
+
+// result returns the adjustments made and the concurrency the goal needed
+func (a *autoscaler) result() *models.AutoscaleSummary {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &models.AutoscaleSummary{
+		TargetRPS:   a.config.TargetRPS,
+		MaxLatency:  a.config.MaxLatency,
+		Workers:     a.needed,
+		PeakWorkers: a.peak,
+		Steps:       a.steps,
+	}
+}
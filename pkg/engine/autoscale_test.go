@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoscaler_Next(t *testing.T) {
+	bounds := models.AutoscaleConfig{MinWorkers: 2, MaxWorkers: 50}
+	tests := []struct {
+		name    string
+		goal    models.AutoscaleConfig
+		workers int
+		rps     float64
+		p95     time.Duration
+		want    int
+	}{
+		{"sized by the rate of a worker", models.AutoscaleConfig{TargetRPS: 300}, 10, 200, 0, 15},
+		{"at most doubles", models.AutoscaleConfig{TargetRPS: 1000}, 10, 100, 0, 20},
+		{"at most halves", models.AutoscaleConfig{TargetRPS: 10}, 10, 100, 0, 5},
+		{"doubles without requests", models.AutoscaleConfig{TargetRPS: 100}, 4, 0, 0, 8},
+		{"backs off over the set point", models.AutoscaleConfig{TargetRPS: 1000, MaxLatency: 100 * time.Millisecond}, 20, 100, 150 * time.Millisecond, 15},
+		{"grows under the set point", models.AutoscaleConfig{MaxLatency: 100 * time.Millisecond}, 8, 100, 50 * time.Millisecond, 10},
+		{"stays within max_workers", models.AutoscaleConfig{TargetRPS: 1000}, 40, 100, 0, 50},
+		{"stays within min_workers", models.AutoscaleConfig{MaxLatency: time.Millisecond}, 2, 100, time.Second, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.goal
+			config.MinWorkers, config.MaxWorkers = bounds.MinWorkers, bounds.MaxWorkers
+			a := newAutoscaler(&config)
+			assert.Equal(t, tt.want, a.next(tt.workers, tt.rps, tt.p95))
+		})
+	}
+}
+
+func TestAutoscaler_Adjust(t *testing.T) {
+	a := newAutoscaler(&models.AutoscaleConfig{TargetRPS: 20, MinWorkers: 1, MaxWorkers: 100, Interval: time.Second})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.begin(start, 2)
+
+	for i := 0; i < 10; i++ {
+		a.add(models.TestResult{ResponseTime: 100 * time.Millisecond})
+	}
+	a.add(models.TestResult{Skipped: true})
+	step := a.adjust(start.Add(time.Second), 2)
+	assert.Equal(t, models.AutoscaleStep{Elapsed: time.Second, Workers: 2, RPS: 10, P95: 100 * time.Millisecond, Next: 4}, step)
+
+	for i := 0; i < 20; i++ {
+		a.add(models.TestResult{ResponseTime: 100 * time.Millisecond})
+	}
+	step = a.adjust(start.Add(2*time.Second), 4)
+	assert.True(t, step.Met)
+	assert.Equal(t, 4, step.Next)
+
+	result := a.result()
+	assert.Equal(t, 4, result.Workers)
+	assert.Equal(t, 4, result.PeakWorkers)
+	assert.Len(t, result.Steps, 2)
+
+	// Without autoscale nothing is tracked
+	var none *autoscaler
+	none.add(models.TestResult{})
+	assert.Nil(t, none.result())
+}
+
+func TestEngine_Autoscale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:  server.URL,
+			Timeout:  5 * time.Second,
+			Duration: 800 * time.Millisecond,
+			Autoscale: &models.AutoscaleConfig{
+				TargetRPS:  200,
+				MinWorkers: 1,
+				MaxWorkers: 20,
+				Interval:   100 * time.Millisecond,
+			},
+		},
+		Tests: []models.TestCase{{Name: "ping", Method: "GET", Path: "/ping", ExpectedStatus: []int{200}}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	require.NotNil(t, summary.Autoscale)
+	autoscale := summary.Autoscale
+	require.NotEmpty(t, autoscale.Steps)
+	assert.Equal(t, 1, autoscale.Steps[0].Workers)
+	assert.Greater(t, autoscale.Steps[0].Next, 1, "one worker cannot reach 200 req/s against a 20ms endpoint")
+	assert.Greater(t, autoscale.PeakWorkers, 2)
+	assert.Equal(t, 0, summary.FailedReqs)
+}
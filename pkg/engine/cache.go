@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// responseCache holds the responses of tests with cache enabled, so
+// repeated identical requests (e.g. a config object fetched by every worker)
+// are answered locally instead of hitting the server again
+type responseCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]cachedResponse)}
+}
+
+// get returns a copy of the cached response for key, or nil on a miss
+func (c *responseCache) get(key string) *http.Response {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return &http.Response{
+		StatusCode: entry.statusCode,
+		Header:     entry.header.Clone(),
+		Body:       io.NopCloser(bytes.NewReader(entry.body)),
+	}
+}
+
+// put stores a response for key, keeping the first one stored
+func (c *responseCache) put(key string, statusCode int, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = cachedResponse{
+		statusCode: statusCode,
+		header:     header.Clone(),
+		body:       body,
+	}
+}
+
+// requestCacheKey identifies a request by its method, URL, headers, and
+// body after variable substitution, so requests that differ in any of them
+// (e.g. another token or data row) are cached separately. The request ID
+// header is left out, as it differs on every request.
+func requestCacheKey(req *http.Request, requestIDHeader string) string {
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL.String() + "\n")
+
+	requestIDHeader = http.CanonicalHeaderKey(requestIDHeader)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if name != requestIDHeader {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key.WriteString(name + ": " + strings.Join(req.Header[name], ", ") + "\n")
+	}
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			io.Copy(&key, body)
+		}
+	}
+	return key.String()
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// capacityTolerance is the share of its rate a stage must reach to pass;
+// falling further behind means the target, or the workers, are saturated
+const capacityTolerance = 0.95
+
+// capacitySearch picks the rates of the stages of a capacity search: the
+// rate doubles from start_rps until a stage fails or max_rps passes, then
+// the gap between the highest passing and lowest failing rate is halved
+// until it is within precision
+type capacitySearch struct {
+	config models.CapacityConfig
+	passed float64 // Highest rate that passed (0: none yet)
+	failed float64 // Lowest rate that failed (0: none yet)
+	stages []models.CapacityStage
+}
+
+func newCapacitySearch(config models.CapacityConfig) *capacitySearch {
+	return &capacitySearch{config: config}
+}
+
+// next returns the rate of the next stage, or false once the search is done
+func (s *capacitySearch) next() (float64, bool) {
+	switch {
+	case len(s.stages) == 0:
+		return s.config.StartRPS, true
+	case s.failed == 0:
+		if s.passed >= s.config.MaxRPS {
+			return 0, false
+		}
+		return min(s.passed*2, s.config.MaxRPS), true
+	case s.passed == 0:
+		// Not even start_rps is sustained
+		return 0, false
+	case s.failed-s.passed <= s.failed*s.config.Precision/100:
+		return 0, false
+	default:
+		return (s.passed + s.failed) / 2, true
+	}
+}
+
+// record judges a finished stage against the thresholds and narrows the
+// search with it
+func (s *capacitySearch) record(stage models.CapacityStage) models.CapacityStage {
+	stage.Problem = s.problem(stage)
+	stage.Passed = stage.Problem == ""
+	if stage.Passed {
+		s.passed = max(s.passed, stage.RPS)
+	} else if s.failed == 0 || stage.RPS < s.failed {
+		s.failed = stage.RPS
+	}
+	s.stages = append(s.stages, stage)
+	return stage
+}
+
+// problem returns why a stage did not sustain its rate, or "" if it did
+func (s *capacitySearch) problem(stage models.CapacityStage) string {
+	switch {
+	case stage.ErrorRate > s.config.MaxErrorRate:
+		return fmt.Sprintf("error rate %.2f%% over %.2f%%", stage.ErrorRate, s.config.MaxErrorRate)
+	case s.config.MaxP95 > 0 && stage.P95 > s.config.MaxP95:
+		return fmt.Sprintf("P95 %v over %v", stage.P95.Round(time.Millisecond), s.config.MaxP95)
+	case stage.AchievedRPS < stage.RPS*capacityTolerance:
+		return fmt.Sprintf("reached %.1f of %.1f req/s", stage.AchievedRPS, stage.RPS)
+	}
+	return ""
+}
+
+// result returns the stages run and the outcome of the search
+func (s *capacitySearch) result() *models.CapacitySummary {
+	return &models.CapacitySummary{
+		MaxErrorRate: s.config.MaxErrorRate,
+		MaxP95:       s.config.MaxP95,
+		MaxRPS:       s.passed,
+		BreakingRPS:  s.failed,
+		Stages:       s.stages,
+	}
+}
+
+// runCapacity runs the stages of a capacity search one after the other on
+// the same workers and collects the results of all of them into one summary
+func (e *Engine) runCapacity(config *models.Config) *models.Summary {
+	search := newCapacitySearch(*config.Global.Capacity)
+	stageConfig := capacityStageConfig(config)
+
+	results := make(chan models.TestResult, 1000)
+	go func() {
+		defer close(results)
+		for rate, ok := search.next(); ok; rate, ok = search.next() {
+			stage := e.runStage(stageConfig, rate, results)
+			if e.context().Err() != nil {
+				// A stage cut short says nothing about its rate
+				return
+			}
+			stage = search.record(stage)
+			e.log.Info("capacity stage", "rps", stage.RPS, "achieved_rps", math.Round(stage.AchievedRPS*10)/10,
+				"error_rate", math.Round(stage.ErrorRate*100)/100, "p95", stage.P95, "passed", stage.Passed)
+		}
+	}()
+
+	summary := e.collectResults(results, 0)
+	summary.Capacity = search.result()
+	return summary
+}
+
+// runStage runs the tests for a stage at the given rate, forwarding their
+// results, and returns the throughput, errors, and P95 the stage reached
+func (e *Engine) runStage(config *models.Config, rate float64, results chan<- models.TestResult) models.CapacityStage {
+	stageResults := make(chan models.TestResult, 1000)
+	e.stageRate = newRateLimiter(rate)
+	start := time.Now()
+	go func() {
+		defer close(stageResults)
+		e.runPool(config, e.workers, stageResults)
+	}()
+
+	var times []time.Duration
+	failed := 0
+	for result := range stageResults {
+		results <- result
+		if result.Skipped {
+			continue
+		}
+		times = append(times, result.ResponseTime)
+		// Rate-limited responses count too: the target refused the load
+		if !result.Success {
+			failed++
+		}
+	}
+
+	stage := models.CapacityStage{
+		RPS:      rate,
+		Requests: len(times),
+		P95:      calculatePercentile(times, 95),
+	}
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		stage.AchievedRPS = float64(len(times)) / elapsed
+	}
+	if len(times) > 0 {
+		stage.ErrorRate = float64(failed) / float64(len(times)) * 100
+	}
+	return stage
+}
+
+// capacityStageConfig returns config with every test running for the
+// stage_duration of its capacity search, whatever duration or iterations
+// it sets
+func capacityStageConfig(config *models.Config) *models.Config {
+	stage := *config
+	stage.Global.Duration = config.Global.Capacity.StageDuration
+	stage.Global.Iterations = 0
+	stage.Tests = make([]models.TestCase, len(config.Tests))
+	for i, test := range config.Tests {
+		test.Duration = 0
+		test.Iterations = 0
+		stage.Tests[i] = test
+	}
+	return &stage
+}
+
+// waitStageRate holds a worker until the next slot at the rate of the
+// capacity stage, returning how far behind its slot the request starts, or
+// false if ctx is done first. Without a capacity search it returns at once.
+func (e *Engine) waitStageRate(ctx context.Context) (time.Duration, bool) {
+	if e.stageRate == nil {
+		return 0, true
+	}
+	slot, lag := e.stageRate.reserve(time.Now())
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, false
+	case <-timer.C:
+		return lag, true
+	}
+}
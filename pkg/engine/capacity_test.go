@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacitySearch(t *testing.T) {
+	search := newCapacitySearch(models.CapacityConfig{
+		StartRPS:     100,
+		MaxRPS:       1000,
+		MaxErrorRate: 1,
+		MaxP95:       200 * time.Millisecond,
+		Precision:    10,
+	})
+	// The target sustains 650 req/s: the P95 breaks past it
+	stage := func(rps float64) models.CapacityStage {
+		p95 := 50 * time.Millisecond
+		if rps > 650 {
+			p95 = time.Second
+		}
+		return models.CapacityStage{RPS: rps, AchievedRPS: rps, P95: p95}
+	}
+
+	var rates []float64
+	for rate, ok := search.next(); ok; rate, ok = search.next() {
+		rates = append(rates, rate)
+		search.record(stage(rate))
+	}
+	assert.Equal(t, []float64{100, 200, 400, 800, 600, 700, 650}, rates)
+
+	result := search.result()
+	assert.Equal(t, 650.0, result.MaxRPS)
+	assert.Equal(t, 700.0, result.BreakingRPS)
+	require.Len(t, result.Stages, 7)
+	assert.Equal(t, "P95 1s over 200ms", result.Stages[3].Problem)
+	assert.False(t, result.Stages[3].Passed)
+	assert.True(t, result.Stages[6].Passed)
+}
+
+func TestCapacitySearch_Limits(t *testing.T) {
+	config := models.CapacityConfig{StartRPS: 10, MaxRPS: 30, MaxErrorRate: 1, Precision: 5}
+
+	// Every stage passes up to max_rps
+	search := newCapacitySearch(config)
+	var rates []float64
+	for rate, ok := search.next(); ok; rate, ok = search.next() {
+		rates = append(rates, rate)
+		search.record(models.CapacityStage{RPS: rate, AchievedRPS: rate})
+	}
+	assert.Equal(t, []float64{10, 20, 30}, rates)
+	assert.Equal(t, 30.0, search.result().MaxRPS)
+	assert.Zero(t, search.result().BreakingRPS)
+
+	// start_rps already fails, by errors or by falling behind the rate
+	search = newCapacitySearch(config)
+	stage := search.record(models.CapacityStage{RPS: 10, AchievedRPS: 10, ErrorRate: 5})
+	assert.Equal(t, "error rate 5.00% over 1.00%", stage.Problem)
+	_, ok := search.next()
+	assert.False(t, ok)
+	assert.Zero(t, search.result().MaxRPS)
+
+	stage = newCapacitySearch(config).record(models.CapacityStage{RPS: 10, AchievedRPS: 6})
+	assert.Equal(t, "reached 6.0 of 10.0 req/s", stage.Problem)
+}
+
+func TestEngine_Capacity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL: server.URL,
+			Timeout: 5 * time.Second,
+			Capacity: &models.CapacityConfig{
+				StartRPS:      4,
+				MaxRPS:        64,
+				StageDuration: 300 * time.Millisecond,
+				MaxErrorRate:  1,
+				Precision:     50,
+			},
+		},
+		Tests: []models.TestCase{{Name: "ping", Method: "GET", Path: "/ping", ExpectedStatus: []int{200}}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	require.NotNil(t, summary.Capacity)
+	capacity := summary.Capacity
+	require.NotEmpty(t, capacity.Stages)
+	assert.True(t, capacity.Stages[0].Passed)
+	assert.Greater(t, capacity.BreakingRPS, 0.0, "one worker cannot send 64 req/s to a 50ms endpoint")
+	assert.Less(t, capacity.MaxRPS, capacity.BreakingRPS)
+
+	requests := 0
+	for _, stage := range capacity.Stages {
+		requests += stage.Requests
+	}
+	assert.Equal(t, requests, summary.TotalRequests)
+	assert.True(t, summary.Passed())
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"encoding/json"
+)
+
+// capturedBody returns a body as kept in error messages and body samples:
+// redacted, then cut at max_captured_body_bytes
+func (e *Engine) capturedBody(body []byte) string {
+	return e.capBody(e.redactor.Body(string(body)))
+}
+
+// capBody cuts a body at max_captured_body_bytes (0: unlimited)
+func (e *Engine) capBody(body string) string {
+	if e.maxBodyBytes <= 0 || len(body) <= e.maxBodyBytes {
+		return body
+	}
+	return body[:e.maxBodyBytes] + "... (truncated)"
+}
+
+// logBody returns a body as kept in a debug log entry. Entries are redacted
+// when printed, but a cut JSON body no longer parses for its paths to be
+// masked, so a body over the limit is redacted before it is cut
+func (e *Engine) logBody(body []byte) string {
+	if e.maxBodyBytes <= 0 || len(body) <= e.maxBodyBytes {
+		return string(body)
+	}
+	return e.capturedBody(body)
+}
+
+// comparisonBody returns a body as kept in a comparison result; a body over
+// the limit is left out, as a cut one would no longer be valid JSON
+func (e *Engine) comparisonBody(body []byte) json.RawMessage {
+	if e.maxBodyBytes > 0 && len(body) > e.maxBodyBytes {
+		return nil
+	}
+	return body
+}
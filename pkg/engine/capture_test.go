@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_CapturedBody(t *testing.T) {
+	e := New(1, nil, false)
+	long := []byte(strings.Repeat("a", 20))
+
+	// Without a limit bodies are kept whole
+	assert.Equal(t, string(long), e.capturedBody(long))
+	assert.Equal(t, string(long), e.logBody(long))
+	assert.Equal(t, long, []byte(e.comparisonBody(long)))
+
+	e.maxBodyBytes = 8
+	assert.Equal(t, "aaaaaaaa... (truncated)", e.capturedBody(long))
+	assert.Equal(t, "aaaaaaaa... (truncated)", e.logBody(long))
+	assert.Nil(t, e.comparisonBody(long))
+	assert.Equal(t, "short", e.capturedBody([]byte("short")))
+	assert.Equal(t, `{"a":1}`, string(e.comparisonBody([]byte(`{"a":1}`))))
+
+	// A body over the limit is redacted before it is cut, while it still parses
+	e.redactor = redact.New(models.RedactConfig{JSONPaths: []string{"password"}}, nil)
+	e.maxBodyBytes = 20
+	assert.Equal(t, `{"password":"***","u... (truncated)`, e.logBody([]byte(`{"password":"hunter2","user":"bob"}`)))
+}
+
+func TestEngine_MaxCapturedBodyBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(strings.Repeat("x", 4096)))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:              server.URL,
+			Timeout:              5 * time.Second,
+			Iterations:           2,
+			MaxCapturedBodyBytes: 100,
+		},
+		Tests: []models.TestCase{
+			{Name: "large", Method: "GET", Path: "/", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, true).Run(config)
+
+	capped := strings.Repeat("x", 100) + "... (truncated)"
+	samples := summary.EndpointResults["large"].FailureSamples
+	require.NotEmpty(t, samples)
+	for _, sample := range samples {
+		assert.True(t, strings.HasSuffix(sample.Error, "Response body: "+capped), sample.Error)
+		assert.Equal(t, capped, sample.BodySample)
+	}
+	require.NotEmpty(t, summary.DebugLogs)
+	for _, log := range summary.DebugLogs {
+		if log.Type == "response" {
+			assert.Equal(t, capped, log.Body)
+		}
+	}
+}
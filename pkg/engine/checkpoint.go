@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/checkpoint"
+)
+
+// checkpointer tracks the finished iterations of every test and saves them,
+// with the variable store and the elapsed time, to the checkpoint file every
+// interval while the run goes on. Iterations finish out of order across
+// workers, so it keeps how many finished from the first one on, and which
+// finished past the first one still running.
+type checkpointer struct {
+	mu        sync.Mutex
+	path      string
+	every     time.Duration
+	config    string
+	runID     string
+	start     time.Time
+	elapsed   time.Duration           // Time the run had been running before it was resumed
+	completed map[string]int          // Iterations per test finished from the first one on, over every resume
+	finished  map[string]map[int]bool // Iterations per test finished past those, by job index
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// SetCheckpoint saves the progress of the run to path every interval, and
+// once more when it ends, so that it can be resumed with Resume
+func (e *Engine) SetCheckpoint(path string, every time.Duration) {
+	e.checkpoint = &checkpointer{path: path, every: every}
+}
+
+// Resume continues the run saved in cp: the iterations it finished are not
+// sent again, durations are shortened by the time it ran, and its variables
+// and run ID are restored. Iterations in flight when it was saved run again,
+// with the data rows they had.
+func (e *Engine) Resume(cp *checkpoint.Checkpoint) {
+	e.resumed = cp
+	if cp.RunID != "" {
+		e.runID = cp.RunID
+	}
+}
+
+// restoreCheckpoint restores the variables of a resumed run over those of
+// the config, and starts saving checkpoints
+func (e *Engine) restoreCheckpoint(config *models.Config) {
+	if e.resumed != nil {
+		for name, value := range e.resumed.Variables {
+			if _, secret := e.secrets[name]; !secret {
+				e.varStore.Set(name, value)
+			}
+		}
+	}
+	if e.checkpoint != nil {
+		e.checkpoint.begin(config.Name, e.runID, e.resumed)
+		go e.saveCheckpoints()
+	}
+}
+
+// remaining returns what is left of a duration once the time the run ran
+// before it was resumed is taken off
+func (e *Engine) remaining(d time.Duration) time.Duration {
+	if e.resumed == nil {
+		return d
+	}
+	return d - e.resumed.Elapsed
+}
+
+// resumedSummary returns the progress the run had made when it was resumed
+func (e *Engine) resumedSummary() *models.ResumeSummary {
+	if e.resumed == nil {
+		return nil
+	}
+	resumed := &models.ResumeSummary{Elapsed: e.resumed.Elapsed}
+	for _, done := range e.resumed.Completed {
+		resumed.Iterations += done
+	}
+	for _, finished := range e.resumed.Finished {
+		resumed.Iterations += len(finished)
+	}
+	return resumed
+}
+
+// skipResumed wraps send so that it numbers the jobs of an iteration-based
+// test, for checkpoints, and drops those finished before the run was
+// resumed, still taking their data rows
+func (e *Engine) skipResumed(test string, send func(Job) bool) func(Job) bool {
+	var completed int
+	finished := make(map[int]bool)
+	if e.resumed != nil {
+		completed = e.resumed.Completed[test]
+		for _, index := range e.resumed.Finished[test] {
+			finished[index] = true
+		}
+	}
+
+	index := 0
+	return func(job Job) bool {
+		index++
+		job.JobIndex = index
+		if index <= completed || finished[index] {
+			return true
+		}
+		return send(job)
+	}
+}
+
+// begin starts counting from the progress of a resumed run, if any, and
+// saving every interval
+func (c *checkpointer) begin(config, runID string, resumed *checkpoint.Checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.config = config
+	c.runID = runID
+	c.start = time.Now()
+	c.completed = make(map[string]int)
+	c.finished = make(map[string]map[int]bool)
+	if resumed != nil {
+		c.elapsed = resumed.Elapsed
+		for test, done := range resumed.Completed {
+			c.completed[test] = done
+		}
+		for test, indexes := range resumed.Finished {
+			c.finished[test] = make(map[int]bool, len(indexes))
+			for _, index := range indexes {
+				c.finished[test][index] = true
+			}
+		}
+	}
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+}
+
+// add records a finished iteration of a test. Jobs of timed tests are not
+// numbered, as they are not skipped on resume; they are only counted.
+func (c *checkpointer) add(result models.TestResult) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	test := result.TestName
+	if result.JobIndex == 0 {
+		c.completed[test]++
+		return
+	}
+	finished := c.finished[test]
+	if result.JobIndex != c.completed[test]+1 {
+		if finished == nil {
+			finished = make(map[int]bool)
+			c.finished[test] = finished
+		}
+		finished[result.JobIndex] = true
+		return
+	}
+	c.completed[test]++
+	for finished[c.completed[test]+1] {
+		delete(finished, c.completed[test]+1)
+		c.completed[test]++
+	}
+}
+
+// snapshot returns the progress of the run at now
+func (c *checkpointer) snapshot(now time.Time, variables map[string]interface{}) *checkpoint.Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	completed := make(map[string]int, len(c.completed))
+	for test, done := range c.completed {
+		completed[test] = done
+	}
+	var finished map[string][]int
+	for test, indexes := range c.finished {
+		if len(indexes) == 0 {
+			continue
+		}
+		if finished == nil {
+			finished = make(map[string][]int)
+		}
+		for index := range indexes {
+			finished[test] = append(finished[test], index)
+		}
+		sort.Ints(finished[test])
+	}
+	return &checkpoint.Checkpoint{
+		Config:    c.config,
+		RunID:     c.runID,
+		Elapsed:   c.elapsed + now.Sub(c.start),
+		Completed: completed,
+		Finished:  finished,
+		Variables: variables,
+		SavedAt:   now,
+	}
+}
+
+// saveCheckpoints saves a checkpoint every interval until the run ends
+func (e *Engine) saveCheckpoints() {
+	c := e.checkpoint
+	defer close(c.done)
+	ticker := time.NewTicker(c.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			e.saveCheckpoint(now)
+		}
+	}
+}
+
+// endCheckpoints stops the periodic saves and saves the final progress
+func (e *Engine) endCheckpoints() {
+	if e.checkpoint == nil || e.checkpoint.stop == nil {
+		return
+	}
+	close(e.checkpoint.stop)
+	<-e.checkpoint.done
+	e.saveCheckpoint(time.Now())
+}
+
+// saveCheckpoint writes the progress of the run at now to the checkpoint
+// file; secrets are left out of its variables
+func (e *Engine) saveCheckpoint(now time.Time) {
+	variables := e.varStore.All()
+	for name := range e.secrets {
+		delete(variables, name)
+	}
+	if err := checkpoint.Save(e.checkpoint.path, e.checkpoint.snapshot(now, variables)); err != nil {
+		e.log.Warn("failed to save checkpoint", "error", err)
+	}
+}
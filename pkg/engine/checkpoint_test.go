@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Checkpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token": "abc"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "API",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 5},
+		Tests: []models.TestCase{
+			{Name: "login", Method: "POST", Path: "/login", ExpectedStatus: []int{200}, Iterations: 1,
+				Extract: []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}}},
+			{Name: "browse", Method: "GET", Path: "/items", ExpectedStatus: []int{200}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	e := New(2, nil, false)
+	e.SetCheckpoint(path, time.Hour)
+	e.SetSecrets(map[string]string{"api_key": "hunter2"})
+	summary := e.Run(config)
+	require.Equal(t, 6, summary.TotalRequests)
+
+	// The final checkpoint holds every iteration, the extracted variables,
+	// and no secrets
+	saved, err := checkpoint.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "API", saved.Config)
+	assert.Equal(t, e.RunID(), saved.RunID)
+	assert.Equal(t, map[string]int{"login": 1, "browse": 5}, saved.Completed)
+	assert.Equal(t, "abc", saved.Variables["token"])
+	assert.NotContains(t, saved.Variables, "api_key")
+	assert.Greater(t, saved.Elapsed, time.Duration(0))
+}
+
+func TestEngine_Resume(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "API",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 10},
+		Tests: []models.TestCase{
+			{Name: "login", Method: "POST", Path: "/login", ExpectedStatus: []int{200}, Iterations: 1},
+			{Name: "browse", Method: "GET", Path: "/items", ExpectedStatus: []int{200},
+				Headers: map[string]string{"Authorization": "Bearer ${token}"}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	e := New(2, nil, false)
+	e.Resume(&checkpoint.Checkpoint{
+		Config:    "API",
+		RunID:     "run-1",
+		Elapsed:   time.Minute,
+		Completed: map[string]int{"login": 1, "browse": 4},
+		Variables: map[string]interface{}{"token": "abc"},
+	})
+	e.SetCheckpoint(path, time.Hour)
+	summary := e.Run(config)
+
+	// Only the iterations left run, with the variables of the first run
+	assert.Equal(t, 6, summary.TotalRequests)
+	assert.Equal(t, "run-1", summary.RunID)
+	assert.Equal(t, &models.ResumeSummary{Elapsed: time.Minute, Iterations: 5}, summary.Resumed)
+	require.Len(t, tokens, 6)
+	for _, token := range tokens {
+		assert.Equal(t, "Bearer abc", token)
+	}
+
+	saved, err := checkpoint.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"login": 1, "browse": 10}, saved.Completed)
+	assert.Greater(t, saved.Elapsed, time.Minute)
+}
+
+func TestEngine_ResumeDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "API",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Duration: time.Minute},
+		Tests:  []models.TestCase{{Name: "ping", Method: "GET", Path: "/ping", ExpectedStatus: []int{200}}},
+	}
+
+	// A minute had already run, less 200ms
+	e := New(1, nil, false)
+	e.Resume(&checkpoint.Checkpoint{Config: "API", Elapsed: time.Minute - 200*time.Millisecond})
+	start := time.Now()
+	e.Run(config)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCheckpointer_OutOfOrder(t *testing.T) {
+	c := &checkpointer{}
+	c.begin("API", "run-1", nil)
+
+	// Iteration 2 is still running when 1, 3, and 4 have finished
+	for _, index := range []int{1, 3, 4} {
+		c.add(models.TestResult{TestName: "browse", JobIndex: index})
+	}
+	c.add(models.TestResult{TestName: "ping"})
+	saved := c.snapshot(time.Now(), nil)
+	assert.Equal(t, map[string]int{"browse": 1, "ping": 1}, saved.Completed)
+	assert.Equal(t, map[string][]int{"browse": {3, 4}}, saved.Finished)
+
+	c.add(models.TestResult{TestName: "browse", JobIndex: 2})
+	saved = c.snapshot(time.Now(), nil)
+	assert.Equal(t, 4, saved.Completed["browse"])
+	assert.Empty(t, saved.Finished)
+}
+
+func TestEngine_ResumeOutOfOrder(t *testing.T) {
+	var mu sync.Mutex
+	var users []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		users = append(users, r.URL.Query().Get("user"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "API",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 5},
+		Tests: []models.TestCase{{
+			Name: "login", Method: "GET", Path: "/login?user=${data.user}", ExpectedStatus: []int{200},
+			DataStrategy: models.DataStrategySequential,
+			Data: []map[string]interface{}{
+				{"user": "a"}, {"user": "b"}, {"user": "c"}, {"user": "d"}, {"user": "e"},
+			},
+		}},
+	}
+
+	// Iteration 2 was in flight when 1, 3, and 4 had finished
+	e := New(1, nil, false)
+	e.Resume(&checkpoint.Checkpoint{
+		Config:    "API",
+		Completed: map[string]int{"login": 1},
+		Finished:  map[string][]int{"login": {3, 4}},
+	})
+	summary := e.Run(config)
+
+	assert.Equal(t, []string{"b", "e"}, users, "in-flight iterations run again with their rows")
+	assert.Equal(t, 3, summary.Resumed.Iterations)
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// setAcceptEncoding sets the Accept-Encoding of a request. Without a
+// configured value it asks for gzip when Go's transport would, but sets the
+// header itself so the transport hands the body over as received and its
+// compressed size is known. Event streams are left to the transport.
+func setAcceptEncoding(req *http.Request, config *models.Config, test models.TestCase) {
+	if encoding := config.AcceptEncoding(test); encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+		return
+	}
+	if test.SSE != nil || req.Method == http.MethodHead || req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+}
+
+// decodeBody decodes a body received with a gzip or deflate content
+// encoding, returning the encoding it was decoded from and the time that
+// took. Bodies sent as is, and encodings that cannot be decoded (br, zstd),
+// are returned as received with no encoding.
+func decodeBody(resp *http.Response, body []byte) ([]byte, string, time.Duration, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if resp.Uncompressed || len(body) == 0 {
+		return body, "", 0, nil
+	}
+
+	start := time.Now()
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// Meant to be zlib-wrapped, but some servers send raw deflate
+		if reader, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return body, "", 0, nil
+	}
+	if err != nil {
+		return body, "", 0, fmt.Errorf("failed to decode %s response body: %w", encoding, err)
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return body, "", 0, fmt.Errorf("failed to decode %s response body: %w", encoding, err)
+	}
+	return decoded, encoding, time.Since(start), nil
+}
@@ -0,0 +1,339 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// connPool holds the transports shared by the requests of a run, so
+// connections are reused through keep-alive. With a warm pool it also
+// resolves the target hosts and opens idle connections before the run
+// starts, so the first requests do not pay for DNS lookups and TCP and TLS
+// handshakes; its transports take the pre-opened connections before dialing
+// new ones.
+type connPool struct {
+	maxIdle     int
+	maxConns    int // Connections per host, 0 for no limit (max_connections_per_host)
+	idleTimeout time.Duration
+	warmed      bool
+
+	mu         sync.Mutex
+	addrs      map[string][]string   // host -> resolved IPs
+	next       map[string]int        // host -> next IP to dial, round-robin
+	idle       map[string][]net.Conn // dial key -> pre-opened connections
+	transports map[transportKey]*http.Transport
+}
+
+// transportKey identifies the requests that can share a transport
+type transportKey struct {
+	skipVerify bool
+	keepAlive  bool
+	timeouts   models.TransportTimeouts
+}
+
+// warmTarget is a host requests are sent to, with the TLS verification they use
+type warmTarget struct {
+	scheme     string
+	addr       string // host:port
+	skipVerify bool
+}
+
+// errTLSHandshakeTimeout reports a TLS handshake of a warm pool connection
+// that exceeded tls_handshake_timeout, worded like net/http's own error
+var errTLSHandshakeTimeout = errors.New("TLS handshake timeout")
+
+func newConnPool(maxIdle int, idleTimeout time.Duration, warm bool) *connPool {
+	if idleTimeout <= 0 {
+		idleTimeout = 90 * time.Second
+	}
+	return &connPool{
+		maxIdle:     maxIdle,
+		idleTimeout: idleTimeout,
+		warmed:      warm,
+		addrs:       make(map[string][]string),
+		next:        make(map[string]int),
+		idle:        make(map[string][]net.Conn),
+		transports:  make(map[transportKey]*http.Transport),
+	}
+}
+
+// openConnPool creates the connection pool of a run and, with a warm_pool,
+// resolves the targets of config and opens its idle connections, logging
+// targets that cannot be reached; their requests then fail on their own
+func (e *Engine) openConnPool(config *models.Config) {
+	maxConns := config.Global.MaxConnectionsPerHost
+	warm := config.Global.WarmPool
+	if warm == nil {
+		e.connPool = newConnPool(e.workers, config.Global.IdleConnTimeout, false)
+		e.connPool.maxConns = maxConns
+		return
+	}
+	connections := warm.Connections
+	if connections <= 0 {
+		connections = e.workers
+	}
+	// Connections above the limit per host could never be used
+	if maxConns > 0 && connections > maxConns {
+		connections = maxConns
+	}
+	pool := newConnPool(max(connections, e.workers), config.Global.IdleConnTimeout, true)
+	pool.maxConns = maxConns
+	e.connPool = pool
+
+	// Connections are warmed up with the global timeouts, the connection
+	// itself bounded by the total timeout when connect_timeout is not set
+	timeouts := config.TestTransportTimeouts(models.TestCase{})
+	if timeouts.Connect <= 0 {
+		timeouts.Connect = config.Global.Timeout
+	}
+
+	start := time.Now()
+	targets := e.warmTargets(config)
+	opened := 0
+	for _, target := range targets {
+		n, err := pool.warm(e.context(), target, connections, timeouts)
+		opened += n
+		if err != nil {
+			e.log.Warn("failed to warm up connections", "target", target.addr, "error", err)
+		}
+	}
+	e.log.Info("connections warmed up", "targets", len(targets), "connections", opened, "elapsed", time.Since(start).Round(time.Millisecond))
+}
+
+// warmTargets returns the hosts of the run's base URLs, once for every TLS
+// verification setting its tests use
+func (e *Engine) warmTargets(config *models.Config) []warmTarget {
+	skips := map[bool]bool{config.Global.InsecureSkipVerify: true}
+	for _, test := range config.Tests {
+		if test.InsecureSkipVerify != nil {
+			skips[*test.InsecureSkipVerify] = true
+		}
+	}
+
+	var targets []warmTarget
+	for _, baseURL := range baseURLs(config) {
+		u, err := url.Parse(e.varSubstitutor.Substitute(baseURL))
+		if err != nil || u.Host == "" {
+			e.log.Warn("cannot warm up connections: invalid base_url", "base_url", baseURL)
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		addr := net.JoinHostPort(u.Hostname(), port)
+
+		if u.Scheme != "https" {
+			targets = append(targets, warmTarget{scheme: u.Scheme, addr: addr})
+			continue
+		}
+		for _, skipVerify := range []bool{false, true} {
+			if skips[skipVerify] {
+				targets = append(targets, warmTarget{scheme: u.Scheme, addr: addr, skipVerify: skipVerify})
+			}
+		}
+	}
+	return targets
+}
+
+// warm resolves the host of target and opens n connections to it
+// concurrently, returning how many were opened
+func (p *connPool) warm(ctx context.Context, target warmTarget, n int, timeouts models.TransportTimeouts) (int, error) {
+	host, _, err := net.SplitHostPort(target.addr)
+	if err != nil {
+		return 0, err
+	}
+	if net.ParseIP(host) == nil {
+		p.mu.Lock()
+		_, resolved := p.addrs[host]
+		p.mu.Unlock()
+		if !resolved {
+			ips, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return 0, err
+			}
+			p.mu.Lock()
+			p.addrs[host] = ips
+			p.mu.Unlock()
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if target.scheme == "https" {
+		tlsConfig = warmTLSConfig(target.skipVerify)
+	}
+	key := dialKey(target.addr, tlsConfig)
+
+	var wg sync.WaitGroup
+	var firstErr error
+	opened := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := p.connect(ctx, target.addr, tlsConfig, timeouts)
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			p.idle[key] = append(p.idle[key], conn)
+			opened++
+		}()
+	}
+	wg.Wait()
+	return opened, firstErr
+}
+
+// transport returns the shared transport of the requests with the same
+// TLS verification, keep-alive, and timeouts. Without keep-alive every
+// request opens a new connection, closed once the response is read.
+func (p *connPool) transport(key transportKey) *http.Transport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if transport, ok := p.transports[key]; ok {
+		return transport
+	}
+	dialer := &net.Dialer{Timeout: key.timeouts.Connect, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		DisableKeepAlives:     !key.keepAlive,
+		MaxIdleConnsPerHost:   p.maxIdle,
+		MaxConnsPerHost:       p.maxConns,
+		IdleConnTimeout:       p.idleTimeout,
+		TLSHandshakeTimeout:   key.timeouts.TLSHandshake,
+		ResponseHeaderTimeout: key.timeouts.ResponseHeader,
+		// A custom dialer opts out of HTTP/2 unless forced; verified
+		// connections keep negotiating it
+		ForceAttemptHTTP2: !key.skipVerify,
+	}
+	if p.warmed {
+		tlsConfig := warmTLSConfig(key.skipVerify)
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return p.dial(ctx, addr, nil, key.timeouts)
+		}
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return p.dial(ctx, addr, tlsConfig, key.timeouts)
+		}
+	} else if key.skipVerify {
+		transport.TLSClientConfig = insecureTLSConfig()
+	}
+	p.transports[key] = transport
+	return transport
+}
+
+// dial hands out a pre-opened connection to addr, or opens a new one
+func (p *connPool) dial(ctx context.Context, addr string, tlsConfig *tls.Config, timeouts models.TransportTimeouts) (net.Conn, error) {
+	key := dialKey(addr, tlsConfig)
+	p.mu.Lock()
+	if conns := p.idle[key]; len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		p.idle[key] = conns[:len(conns)-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+	return p.connect(ctx, addr, tlsConfig, timeouts)
+}
+
+// connect dials addr through its pre-resolved IPs and, with tlsConfig,
+// completes the TLS handshake, each bounded by its timeout
+func (p *connPool) connect(ctx context.Context, addr string, tlsConfig *tls.Config, timeouts models.TransportTimeouts) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: timeouts.Connect, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(p.resolve(host), port))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return conn, nil
+	}
+
+	cfg := tlsConfig.Clone()
+	cfg.ServerName = host
+	tlsConn := tls.Client(conn, cfg)
+	handshakeCtx := ctx
+	if timeouts.TLSHandshake > 0 {
+		var cancel context.CancelFunc
+		handshakeCtx, cancel = context.WithTimeout(ctx, timeouts.TLSHandshake)
+		defer cancel()
+	}
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		if ctx.Err() == nil && handshakeCtx.Err() != nil {
+			return nil, errTLSHandshakeTimeout
+		}
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// resolve returns the next pre-resolved IP of host, or host itself when it
+// was not resolved up front
+func (p *connPool) resolve(host string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ips := p.addrs[host]
+	if len(ips) == 0 {
+		return host
+	}
+	ip := ips[p.next[host]%len(ips)]
+	p.next[host]++
+	return ip
+}
+
+// close closes the pre-opened connections that were never used and the
+// idle ones of the shared transports
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, conns := range p.idle {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		delete(p.idle, key)
+	}
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// warmTLSConfig is the TLS configuration of warm pool connections. They
+// speak HTTP/1.1, as a custom TLS dialer opts out of HTTP/2.
+func warmTLSConfig(skipVerify bool) *tls.Config {
+	cfg := &tls.Config{}
+	if skipVerify {
+		cfg = insecureTLSConfig()
+	}
+	cfg.NextProtos = []string{"http/1.1"}
+	return cfg
+}
+
+// dialKey identifies the connections interchangeable for a dial
+func dialKey(addr string, tlsConfig *tls.Config) string {
+	switch {
+	case tlsConfig == nil:
+		return "tcp|" + addr
+	case tlsConfig.InsecureSkipVerify:
+		return "tls-insecure|" + addr
+	default:
+		return "tls|" + addr
+	}
+}
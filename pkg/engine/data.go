@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"io"
+	"sync"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// dataCursor hands out data rows to jobs according to a data strategy.
+// Rows come either from memory or, for data files, from a stream that is
+// read one row at a time.
+type dataCursor struct {
+	mu       sync.Mutex
+	rows     []map[string]interface{}
+	strategy string
+	next     int
+	random   *randomSource
+
+	// Streaming source: open (re)opens the data file, pending holds the row
+	// read ahead when the stream was opened
+	open    func() (dataIterator, error)
+	iter    dataIterator
+	pending map[string]interface{}
+	err     error
+}
+
+// newDataCursor creates a cursor over rows. For the unique strategy the rows
+// are shuffled once so every row is still consumed exactly once.
+func newDataCursor(rows []map[string]interface{}, strategy string, random *randomSource) *dataCursor {
+	if strategy == models.DataStrategyUnique {
+		shuffled := make([]map[string]interface{}, len(rows))
+		copy(shuffled, rows)
+		random.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		rows = shuffled
+	}
+	return &dataCursor{rows: rows, strategy: strategy, random: random}
+}
+
+// newStreamingDataCursor creates a cursor that streams rows from open. The
+// first row is read immediately so empty or unreadable files fail early.
+func newStreamingDataCursor(open func() (dataIterator, error), strategy string) (*dataCursor, error) {
+	iter, err := open()
+	if err != nil {
+		return nil, err
+	}
+	first, err := iter.Next()
+	if err != nil {
+		iter.Close()
+		if err == io.EOF {
+			err = errNoDataRows
+		}
+		return nil, err
+	}
+	return &dataCursor{strategy: strategy, open: open, iter: iter, pending: first}, nil
+}
+
+// Next returns the row for the next request, or false once a consuming
+// strategy (sequential, unique) has used every row
+func (c *dataCursor) Next() (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.open != nil {
+		return c.nextStreamed()
+	}
+
+	if len(c.rows) == 0 {
+		return nil, false
+	}
+
+	switch c.strategy {
+	case models.DataStrategyRandom:
+		return c.rows[c.random.Intn(len(c.rows))], true
+	case models.DataStrategySequential, models.DataStrategyUnique:
+		if c.next >= len(c.rows) {
+			return nil, false
+		}
+	}
+
+	row := c.rows[c.next%len(c.rows)]
+	c.next++
+	return row, true
+}
+
+// nextStreamed reads the next row from the stream, reopening the file at the
+// end when rows are cycled
+func (c *dataCursor) nextStreamed() (map[string]interface{}, bool) {
+	if c.pending != nil {
+		row := c.pending
+		c.pending = nil
+		return row, true
+	}
+	if c.iter == nil {
+		return nil, false
+	}
+
+	row, err := c.iter.Next()
+	if err == io.EOF && c.strategy == models.DataStrategyCircular {
+		c.iter.Close()
+		c.iter, err = c.open()
+		if err == nil {
+			row, err = c.iter.Next()
+		}
+	}
+	if err != nil {
+		if err != io.EOF {
+			c.err = err
+		}
+		c.closeStream()
+		return nil, false
+	}
+	return row, true
+}
+
+// Err returns the error that stopped a streaming cursor, if any
+func (c *dataCursor) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close releases the underlying data file
+func (c *dataCursor) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeStream()
+}
+
+func (c *dataCursor) closeStream() {
+	if c.iter != nil {
+		c.iter.Close()
+		c.iter = nil
+	}
+}
+
+// openDataCursor returns a cursor over the test's data, or nil when the test
+// has no data. Data files are streamed unless the strategy needs random
+// access to every row (random, unique).
+func (e *Engine) openDataCursor(test models.TestCase, strategy string) *dataCursor {
+	if len(test.Data) > 0 {
+		return newDataCursor(test.Data, strategy, e.random)
+	}
+	if test.DataFile == "" {
+		return nil
+	}
+
+	if strategy == models.DataStrategyRandom || strategy == models.DataStrategyUnique {
+		rows := e.getDataRows(test)
+		if len(rows) == 0 {
+			return nil
+		}
+		return newDataCursor(rows, strategy, e.random)
+	}
+
+	cursor, err := newStreamingDataCursor(func() (dataIterator, error) {
+		return openDataFile(test.DataFile, test.DataSheet)
+	}, strategy)
+	if err != nil {
+		// Log error but continue - test will run without data
+		e.log.Warn("failed to load data file", "test", test.Name, "file", test.DataFile, "error", err)
+		return nil
+	}
+	return cursor
+}
+
+// closeDataCursor closes the cursor and reports a data file that could not
+// be read to the end
+func (e *Engine) closeDataCursor(test models.TestCase, cursor *dataCursor) {
+	cursor.Close()
+	if err := cursor.Err(); err != nil {
+		e.log.Warn("failed to read data file", "test", test.Name, "file", test.DataFile, "error", err)
+	}
+}
+
+// dataRowCount returns the number of data rows of a test without loading a
+// data file into memory
+func (e *Engine) dataRowCount(test models.TestCase) int {
+	if len(test.Data) > 0 {
+		return len(test.Data)
+	}
+	if test.DataFile == "" {
+		return 0
+	}
+	count, err := countDataRows(test.DataFile, test.DataSheet)
+	if err != nil {
+		// Reported when the jobs are generated
+		return 0
+	}
+	return count
+}
+
+// iterationJobCount returns how many jobs an iteration-based test produces
+func iterationJobCount(test models.TestCase, dataRows int, iterations int) int {
+	if dataRows == 0 {
+		return iterations
+	}
+	switch test.DataStrategy {
+	case models.DataStrategySequential, models.DataStrategyUnique:
+		if dataRows < iterations {
+			return dataRows
+		}
+		return iterations
+	case models.DataStrategyRandom, models.DataStrategyCircular:
+		return iterations
+	default:
+		// Every row runs for every iteration
+		return dataRows * iterations
+	}
+}
+
+// forEachIterationJob builds the jobs of an iteration-based test and passes
+// them to send, assigning data rows according to the test's data strategy.
+// Generation stops early when send returns false.
+func (e *Engine) forEachIterationJob(config *models.Config, test models.TestCase, fullURL string, iterations int, send func(Job) bool) {
+	send = e.skipResumed(test.Name, send)
+
+	// Without a strategy every row is read once and runs for every iteration
+	strategy := test.DataStrategy
+	if strategy == "" {
+		strategy = models.DataStrategySequential
+	}
+
+	cursor := e.openDataCursor(test, strategy)
+	if cursor == nil {
+		// Regular test without data
+		for i := 0; i < iterations; i++ {
+			if !send(Job{Config: config, TestCase: test, URL: fullURL}) {
+				return
+			}
+		}
+		return
+	}
+	defer e.closeDataCursor(test, cursor)
+
+	if test.DataStrategy == "" {
+		// Data-driven test: run iterations for each data row
+		for {
+			dataRow, ok := cursor.Next()
+			if !ok {
+				return
+			}
+			for i := 0; i < iterations; i++ {
+				if !send(Job{Config: config, TestCase: test, URL: fullURL, DataRow: dataRow}) {
+					return
+				}
+			}
+		}
+	}
+
+	for i := 0; i < iterations; i++ {
+		dataRow, ok := cursor.Next()
+		if !ok {
+			return
+		}
+		if !send(Job{Config: config, TestCase: test, URL: fullURL, DataRow: dataRow}) {
+			return
+		}
+	}
+}
@@ -1,11 +1,19 @@
 package engine
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -419,3 +427,491 @@ func TestEngine_DataDriven_NestedData(t *testing.T) {
 	assert.Equal(t, "Item 1", receivedBodies[0]["name"])
 	assert.Equal(t, "books", receivedBodies[0]["category"])
 }
+
+// =============================================================================
+// Data Strategy Tests
+// =============================================================================
+
+func runDataStrategy(t *testing.T, strategy string, iterations int) []string {
+	t.Helper()
+
+	var paths []string
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Data Strategy Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: iterations,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Login",
+				Method:         "GET",
+				Path:           "/login/${data.user}",
+				ExpectedStatus: []int{200},
+				DataStrategy:   strategy,
+				Data: []map[string]interface{}{
+					{"user": "alice"},
+					{"user": "bob"},
+					{"user": "charlie"},
+				},
+			},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+	assert.Equal(t, len(paths), summary.TotalRequests)
+	return paths
+}
+
+func TestEngine_DataStrategy_Sequential(t *testing.T) {
+	paths := runDataStrategy(t, models.DataStrategySequential, 5)
+
+	// Rows are consumed in order, once each
+	assert.Equal(t, []string{"/login/alice", "/login/bob", "/login/charlie"}, paths)
+}
+
+func TestEngine_DataStrategy_Circular(t *testing.T) {
+	paths := runDataStrategy(t, models.DataStrategyCircular, 5)
+
+	assert.Equal(t, []string{"/login/alice", "/login/bob", "/login/charlie", "/login/alice", "/login/bob"}, paths)
+}
+
+func TestEngine_DataStrategy_Unique(t *testing.T) {
+	paths := runDataStrategy(t, models.DataStrategyUnique, 5)
+
+	// Every row used exactly once, in any order
+	assert.ElementsMatch(t, []string{"/login/alice", "/login/bob", "/login/charlie"}, paths)
+}
+
+func TestEngine_DataStrategy_Random(t *testing.T) {
+	paths := runDataStrategy(t, models.DataStrategyRandom, 10)
+
+	// One request per iteration, each with one of the rows
+	require.Len(t, paths, 10)
+	for _, path := range paths {
+		assert.Contains(t, []string{"/login/alice", "/login/bob", "/login/charlie"}, path)
+	}
+}
+
+func TestEngine_DataStrategy_DurationStopsWhenConsumed(t *testing.T) {
+	var requestCount int
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Data Strategy Duration Test",
+		Global: models.GlobalConfig{
+			BaseURL:  server.URL,
+			Timeout:  5 * time.Second,
+			Duration: 300 * time.Millisecond,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Login",
+				Method:         "GET",
+				Path:           "/login/${data.user}",
+				ExpectedStatus: []int{200},
+				DataStrategy:   models.DataStrategyUnique,
+				Data: []map[string]interface{}{
+					{"user": "alice"},
+					{"user": "bob"},
+				},
+			},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 2, summary.TotalRequests)
+	assert.Equal(t, 2, requestCount)
+}
+
+func TestGenerateTimedJobs_SlowWorkerKeepsRows(t *testing.T) {
+	var data []map[string]interface{}
+	for i := 0; i < 5; i++ {
+		data = append(data, map[string]interface{}{"user": fmt.Sprintf("user-%d", i)})
+	}
+	test := models.TestCase{Name: "Login", DataStrategy: models.DataStrategyUnique, Data: data}
+
+	// An unbuffered channel read by a worker slower than a send attempt
+	jobs := make(chan Job)
+	done := make(chan struct{})
+	var users []interface{}
+	go func() {
+		defer close(done)
+		for job := range jobs {
+			users = append(users, job.DataRow["user"])
+			time.Sleep(30 * time.Millisecond)
+		}
+	}()
+
+	e := New(1, nil, false)
+	e.generateTimedJobs(context.Background(), &models.Config{}, test, "http://localhost", time.Now().Add(5*time.Second), jobs)
+	close(jobs)
+	<-done
+
+	assert.ElementsMatch(t, []interface{}{"user-0", "user-1", "user-2", "user-3", "user-4"}, users)
+}
+
+func TestEngine_DataStrategy_UniqueWithWorkers(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Every part of a request is built from the same row
+		if user := strings.TrimPrefix(r.URL.Path, "/login/"); user == r.Header.Get("X-User") {
+			seen[user]++
+		} else {
+			seen["mismatch"]++
+		}
+	}))
+	defer server.Close()
+
+	var data []map[string]interface{}
+	for i := 0; i < 200; i++ {
+		data = append(data, map[string]interface{}{"user": fmt.Sprintf("user-%d", i)})
+	}
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: len(data)},
+		Tests: []models.TestCase{{
+			Name:           "Login",
+			Method:         "GET",
+			Path:           "/login/${data.user}",
+			Headers:        map[string]string{"X-User": "${data.user}"},
+			ExpectedStatus: []int{200},
+			DataStrategy:   models.DataStrategyUnique,
+			Data:           data,
+		}},
+	}
+
+	e := New(8, nil, false)
+	summary := e.Run(config)
+
+	assert.Equal(t, len(data), summary.SuccessfulReqs)
+	assert.Len(t, seen, len(data))
+	assert.Zero(t, seen["mismatch"])
+	for user, count := range seen {
+		assert.Equal(t, 1, count, user)
+	}
+	_, shared := e.varStore.Get("data.user")
+	assert.False(t, shared, "rows stay in the scope of their request")
+}
+
+func TestIterationJobCount(t *testing.T) {
+	rows := 3
+
+	assert.Equal(t, 4, iterationJobCount(models.TestCase{}, 0, 4))
+	assert.Equal(t, 12, iterationJobCount(models.TestCase{}, rows, 4))
+	assert.Equal(t, 3, iterationJobCount(models.TestCase{DataStrategy: models.DataStrategySequential}, rows, 4))
+	assert.Equal(t, 2, iterationJobCount(models.TestCase{DataStrategy: models.DataStrategyUnique}, rows, 2))
+	assert.Equal(t, 4, iterationJobCount(models.TestCase{DataStrategy: models.DataStrategyCircular}, rows, 4))
+	assert.Equal(t, 4, iterationJobCount(models.TestCase{DataStrategy: models.DataStrategyRandom}, rows, 4))
+}
+
+// =============================================================================
+// Data File Format Tests
+// =============================================================================
+
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	_, err = gz.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+}
+
+// writeXLSX builds a minimal workbook with two sheets: "Users" uses shared
+// strings and numbers, "Admins" uses inline strings
+func writeXLSX(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Users" sheetId="1" r:id="rId1"/>
+    <sheet name="Admins" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>username</t></si>
+  <si><t>age</t></si>
+  <si><t>mario</t></si>
+  <si><r><t>lu</t></r><r><t>igi</t></r></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+    <row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>30</v></c></row>
+    <row r="3"><c r="A3" t="s"><v>3</v></c></row>
+  </sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="inlineStr"><is><t>username</t></is></c><c r="C1" t="inlineStr"><is><t>active</t></is></c></row>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>peach</t></is></c><c r="C2" t="b"><v>1</v></c></row>
+  </sheetData>
+</worksheet>`,
+	}
+
+	zw := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+func TestLoadDataFromFile_GzipCSV(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "users.csv.gz")
+	writeGzip(t, dataFile, "username,age\nalice,25\nbob,30")
+
+	engine := New(1, nil, false)
+	rows, err := engine.loadDataFromFile(dataFile, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]interface{}{
+		{"username": "alice", "age": "25"},
+		{"username": "bob", "age": "30"},
+	}, rows)
+}
+
+func TestLoadDataFromFile_GzipJSON(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "users.json.gz")
+	writeGzip(t, dataFile, `[{"username": "alice", "age": 25}]`)
+
+	engine := New(1, nil, false)
+	rows, err := engine.loadDataFromFile(dataFile, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]interface{}{{"username": "alice", "age": float64(25)}}, rows)
+}
+
+func TestLoadDataFromFile_XLSX(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "users.xlsx")
+	writeXLSX(t, dataFile)
+
+	engine := New(1, nil, false)
+
+	// First sheet by default
+	rows, err := engine.loadDataFromFile(dataFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"username": "mario", "age": "30"},
+		{"username": "luigi"},
+	}, rows)
+
+	// Named sheet, with a gap between columns
+	rows, err = engine.loadDataFromFile(dataFile, "Admins")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"username": "peach", "active": "true"},
+	}, rows)
+
+	_, err = engine.loadDataFromFile(dataFile, "Missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sheet 'Missing' not found")
+}
+
+func TestLoadDataFromFile_UnsupportedFormat(t *testing.T) {
+	engine := New(1, nil, false)
+
+	_, err := engine.loadDataFromFile("users.txt.gz", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported data file format")
+}
+
+// =============================================================================
+// Streaming Data Tests
+// =============================================================================
+
+func TestOpenDataFile_StreamsCSV(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(dataFile, []byte("username,age\nalice,25\nbob,30\n"), 0644))
+
+	it, err := openDataFile(dataFile, "")
+	require.NoError(t, err)
+	defer it.Close()
+
+	row, err := it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "alice", "age": "25"}, row)
+
+	row, err = it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "bob", "age": "30"}, row)
+
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestOpenDataFile_StreamsJSON(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(dataFile, []byte(`[{"id": 1}, {"id": 2}]`), 0644))
+
+	count, err := countDataRows(dataFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = openDataFile(filepath.Join(t.TempDir(), "missing.json"), "")
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(dataFile, []byte(`{"id": 1}`), 0644))
+	_, err = openDataFile(dataFile, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected an array of objects")
+}
+
+func TestEngine_DataDriven_StreamsLargeCSV(t *testing.T) {
+	var requestCount int
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dataFile := filepath.Join(t.TempDir(), "ids.csv.gz")
+	var sb strings.Builder
+	sb.WriteString("id\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	writeGzip(t, dataFile, sb.String())
+
+	config := &models.Config{
+		Name: "Streaming Data Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Get Item",
+				Method:         "GET",
+				Path:           "/items/${data.id}",
+				ExpectedStatus: []int{200},
+				DataFile:       dataFile,
+			},
+		},
+	}
+
+	engine := New(4, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 2000, summary.TotalRequests)
+	assert.Equal(t, 2000, requestCount)
+}
+
+func TestEngine_DataDriven_StreamingCircularReopensFile(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dataFile := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(dataFile, []byte("user\nalice\nbob\n"), 0644))
+
+	config := &models.Config{
+		Name: "Streaming Circular Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 5,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Login",
+				Method:         "GET",
+				Path:           "/login/${data.user}",
+				ExpectedStatus: []int{200},
+				DataFile:       dataFile,
+				DataStrategy:   models.DataStrategyCircular,
+			},
+		},
+	}
+
+	engine := New(1, nil, false)
+	engine.Run(config)
+
+	assert.Equal(t, []string{"/login/alice", "/login/bob", "/login/alice", "/login/bob", "/login/alice"}, paths)
+}
+
+func TestEngine_DataDriven_StreamingMalformedRowWarns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dataFile := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(dataFile, []byte("user,age\nalice,25\nbob\ncharlie,40\n"), 0644))
+
+	config := &models.Config{
+		Name: "Malformed Data Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{Name: "Login", Method: "GET", Path: "/login/${data.user}", ExpectedStatus: []int{200}, DataFile: dataFile},
+		},
+	}
+
+	var buf bytes.Buffer
+	engine := New(1, nil, false)
+	engine.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	summary := engine.Run(config)
+
+	// Rows before the malformed one still run
+	assert.Equal(t, 1, summary.TotalRequests)
+	assert.Contains(t, buf.String(), "failed to read data file")
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errNoDataRows is returned for data files without any data row
+var errNoDataRows = errors.New("data file has no data rows")
+
+// dataIterator yields data rows one at a time so large data files never
+// have to be held in memory. Next returns io.EOF after the last row.
+type dataIterator interface {
+	Next() (map[string]interface{}, error)
+	Close() error
+}
+
+// openDataFile opens a JSON, CSV, or XLSX data file for iteration. JSON and
+// CSV files are streamed and may be gzip-compressed (.json.gz, .csv.gz);
+// XLSX workbooks are read in full.
+func openDataFile(filePath, sheet string) (dataIterator, error) {
+	name := strings.ToLower(filePath)
+	compressed := strings.HasSuffix(name, ".gz")
+	ext := filepath.Ext(strings.TrimSuffix(name, ".gz"))
+
+	if ext == ".xlsx" {
+		if compressed {
+			return nil, fmt.Errorf("compressed xlsx files are not supported")
+		}
+		records, err := readXLSX(filePath, sheet)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := recordsToRows(records)
+		if err != nil {
+			return nil, err
+		}
+		return &sliceIterator{rows: rows}, nil
+	}
+
+	if ext != ".json" && ext != ".csv" {
+		return nil, fmt.Errorf("unsupported data file format: %s", ext)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var reader io.Reader = file
+	closers := []io.Closer{file}
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		reader = gz
+		closers = []io.Closer{gz, file}
+	}
+
+	var it dataIterator
+	if ext == ".json" {
+		it, err = newJSONIterator(reader, closers)
+	} else {
+		it, err = newCSVIterator(reader, closers)
+	}
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+	return it, nil
+}
+
+// loadDataFromFile reads every row of a data file into memory
+func (e *Engine) loadDataFromFile(filePath, sheet string) ([]map[string]interface{}, error) {
+	it, err := openDataFile(filePath, sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var result []map[string]interface{}
+	for {
+		row, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if len(result) == 0 {
+		return nil, errNoDataRows
+	}
+	return result, nil
+}
+
+// countDataRows counts the rows of a data file without keeping them in memory
+func countDataRows(filePath, sheet string) (int, error) {
+	it, err := openDataFile(filePath, sheet)
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	count := 0
+	for {
+		_, err := it.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+}
+
+// csvIterator streams rows of a CSV file (first row is header)
+type csvIterator struct {
+	reader  *csv.Reader
+	headers []string
+	closers []io.Closer
+}
+
+func newCSVIterator(r io.Reader, closers []io.Closer) (*csvIterator, error) {
+	reader := csv.NewReader(r)
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV file must have at least a header and one data row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return &csvIterator{reader: reader, headers: headers, closers: closers}, nil
+}
+
+func (it *csvIterator) Next() (map[string]interface{}, error) {
+	record, err := it.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return recordToRow(it.headers, record), nil
+}
+
+func (it *csvIterator) Close() error {
+	return closeAll(it.closers)
+}
+
+// jsonIterator streams the objects of a top-level JSON array
+type jsonIterator struct {
+	decoder *json.Decoder
+	closers []io.Closer
+}
+
+func newJSONIterator(r io.Reader, closers []io.Closer) (*jsonIterator, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("failed to parse JSON: expected an array of objects")
+	}
+	return &jsonIterator{decoder: decoder, closers: closers}, nil
+}
+
+func (it *jsonIterator) Next() (map[string]interface{}, error) {
+	if !it.decoder.More() {
+		return nil, io.EOF
+	}
+	var row map[string]interface{}
+	if err := it.decoder.Decode(&row); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return row, nil
+}
+
+func (it *jsonIterator) Close() error {
+	return closeAll(it.closers)
+}
+
+// sliceIterator iterates over rows already held in memory
+type sliceIterator struct {
+	rows []map[string]interface{}
+	next int
+}
+
+func (it *sliceIterator) Next() (map[string]interface{}, error) {
+	if it.next >= len(it.rows) {
+		return nil, io.EOF
+	}
+	row := it.rows[it.next]
+	it.next++
+	return row, nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
+
+// recordsToRows converts tabular records (first row is the header) to data rows
+func recordsToRows(records [][]string) ([]map[string]interface{}, error) {
+	if len(records) < 2 {
+		return nil, fmt.Errorf("data file must have at least a header and one data row")
+	}
+
+	// First row is the header
+	headers := records[0]
+	result := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		result = append(result, recordToRow(headers, record))
+	}
+	return result, nil
+}
+
+// recordToRow maps a record to its header names, skipping unnamed columns
+func recordToRow(headers, record []string) map[string]interface{} {
+	row := make(map[string]interface{}, len(headers))
+	for j, header := range headers {
+		if header != "" && j < len(record) {
+			row[header] = record[j]
+		}
+	}
+	return row
+}
+
+func closeAll(closers []io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
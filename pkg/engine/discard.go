@@ -0,0 +1,15 @@
+package engine
+
+import (
+	"io"
+)
+
+// discardBodyLimit bounds the bytes drained from a response body that is
+// not read; the connection of a longer body is closed rather than reused
+const discardBodyLimit = 64 << 20
+
+// discardBody drains a response body through a shared buffer, so the
+// connection can be reused without allocating the body, and returns its size
+func discardBody(body io.Reader) (int64, error) {
+	return io.Copy(io.Discard, io.LimitReader(body, discardBodyLimit))
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscardBody(t *testing.T) {
+	n, err := discardBody(strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// Bodies over the limit are drained up to it
+	n, err = discardBody(io.LimitReader(neverEnding{}, discardBodyLimit+1024))
+	require.NoError(t, err)
+	assert.Equal(t, int64(discardBodyLimit), n)
+}
+
+// neverEnding is a reader of endless zero bytes
+type neverEnding struct{}
+
+func (neverEnding) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestEngine_ReadBodyFalse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "2")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(strings.Repeat("x", 10000)))
+	}))
+	defer server.Close()
+
+	readBody := false
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:            server.URL,
+			Timeout:            5 * time.Second,
+			Iterations:         3,
+			DisableCompression: true,
+		},
+		Tests: []models.TestCase{{
+			Name:           "throughput",
+			Method:         "GET",
+			Path:           "/",
+			ExpectedStatus: []int{200},
+			ReadBody:       &readBody,
+			Assertions:     []models.Assertion{{Type: "header", Target: "X-Version", Operator: "eq", Value: "2"}},
+		}},
+	}
+
+	summary := New(1, nil, true).Run(config)
+
+	endpoint := summary.EndpointResults["throughput"]
+	require.NotNil(t, endpoint)
+	assert.Equal(t, 3, endpoint.TotalRequests)
+	require.NotEmpty(t, endpoint.FailureSamples)
+	for _, sample := range endpoint.FailureSamples {
+		assert.Equal(t, int64(10000), sample.ResponseSize)
+		assert.Empty(t, sample.BodySample)
+		assert.Equal(t, "Unexpected status code: 503 (expected: [200])", sample.Error)
+	}
+}
@@ -4,14 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/http/httptrace"
+	"net/url"
 	"sort"
 	"strings"
 	"sync"
@@ -19,68 +19,350 @@ import (
 
 	"github.com/andrearaponi/bombardino/internal/models"
 	"github.com/andrearaponi/bombardino/pkg/assertion"
+	"github.com/andrearaponi/bombardino/pkg/checkpoint"
 	"github.com/andrearaponi/bombardino/pkg/comparison"
+	"github.com/andrearaponi/bombardino/pkg/debuglog"
 	"github.com/andrearaponi/bombardino/pkg/progress"
+	"github.com/andrearaponi/bombardino/pkg/redact"
+	"github.com/andrearaponi/bombardino/pkg/replay"
+	"github.com/andrearaponi/bombardino/pkg/stream"
 	"github.com/andrearaponi/bombardino/pkg/variables"
 	"github.com/google/uuid"
 )
 
 type Engine struct {
-	workers              int
-	progressBar          *progress.ProgressBar
-	verbose              bool
-	logChan              chan models.DebugLog
-	debugLogs            []models.DebugLog
-	logMutex             sync.Mutex
-	assertionEvaluator   *assertion.Evaluator
-	comparisonEvaluator  *comparison.Evaluator
-	varStore             *variables.Store
-	varExtractor         *variables.Extractor
-	varSubstitutor       *variables.Substitutor
-}
-
-func New(workers int, progressBar *progress.ProgressBar, verbose bool) *Engine {
+	workers            int
+	progressBar        progress.Indicator
+	verbose            bool
+	logChan            chan models.DebugLog
+	logDone            chan struct{}
+	debugLogs          []models.DebugLog
+	logMutex           sync.Mutex
+	assertionEvaluator *assertion.Evaluator
+	varStore           *variables.Store
+	varExtractor       *variables.Extractor
+	varSubstitutor     *variables.Substitutor
+	iterationCounters  *variables.Counters
+	responseCache      *responseCache
+	successConditions  sync.Map // success_when source -> *assertion.Expression
+	variableChecks     sync.Map // assert_variables source -> assertion.VariableCheck
+	secrets            map[string]string
+	redactor           *redact.Redactor
+	steadyState        *models.SteadyStateConfig
+	debugLogWriter     *debuglog.Writer
+	eventStream        *stream.Writer
+	intervals          *intervalLog
+	connPool           *connPool
+	publishers         *publisherPool
+	databases          *databasePool
+	rateLimiters       sync.Map // test name -> *rateLimiter of messaging tests
+	rotators           sync.Map // test name -> []*headerRotator of rotated headers
+	failureBodies      sync.Map // test name -> *atomic.Int64 of failures with a body sample
+	runID              string
+	seed               int64
+	random             *randomSource
+	targets            *targetBalancer      // Spreads requests over the base URLs of a base_url list (nil with one)
+	inFlight           *hostLimiter         // Bounds the requests in flight per host (nil without max_in_flight_per_host)
+	slos               *sloMonitor          // Evaluates the burn rate of the slos (nil without any)
+	sink               *resultsSink         // Writes every result to the results_sink table (nil without one)
+	maxBodyBytes       int                  // Limit of the bytes of a captured body (0: unlimited)
+	autoscale          *autoscaler          // Resizes the worker pool towards the autoscale goal (nil without autoscale)
+	stageRate          *rateLimiter         // Paces the requests of the running capacity stage (nil without capacity)
+	checkpoint         *checkpointer        // Saves the progress of the run (nil without a checkpoint file)
+	reportWindow       *models.ReportWindow // Part of the run the statistics cover (nil: all of it)
+	start              time.Time            // Start of the run, the origin of the report window
+	resumed            *checkpoint.Checkpoint
+	replayed           []replay.Request
+	sampleRate         float64
+	samplePerEndpoint  int
+	sampleCounts       map[string]int
+	sampleMutex        sync.Mutex
+	log                *slog.Logger
+	maxDuration        time.Duration
+	parent             context.Context
+	ctx                context.Context
+	abort              context.CancelCauseFunc // Stops the run early (SLO with abort)
+}
+
+// debugLogMemorySample is the number of debug log entries kept in memory
+// (and attached to the summary) when entries are streamed to a file
+const debugLogMemorySample = 100
+
+func New(workers int, progressBar progress.Indicator, verbose bool) *Engine {
 	varStore := variables.NewStore()
 	e := &Engine{
-		workers:             workers,
-		progressBar:         progressBar,
-		verbose:             verbose,
-		assertionEvaluator:  assertion.New(verbose),
-		comparisonEvaluator: comparison.New(verbose),
-		varStore:            varStore,
-		varExtractor:        variables.NewExtractor(varStore),
-		varSubstitutor:      variables.NewSubstitutor(varStore),
-	}
+		workers:            workers,
+		progressBar:        progressBar,
+		verbose:            verbose,
+		assertionEvaluator: assertion.New(verbose),
+		varStore:           varStore,
+		varExtractor:       variables.NewExtractor(varStore),
+		varSubstitutor:     variables.NewSubstitutor(varStore),
+		iterationCounters:  variables.NewCounters(),
+		responseCache:      newResponseCache(),
+		publishers:         newPublisherPool(),
+		databases:          newDatabasePool(),
+		sampleRate:         1,
+		sampleCounts:       make(map[string]int),
+		log:                slog.Default(),
+		runID:              uuid.NewString(),
+	}
+	e.SetSeed(time.Now().UnixNano())
 	if verbose {
 		e.logChan = make(chan models.DebugLog, 100)
+		e.logDone = make(chan struct{})
 	}
 	return e
 }
 
-func (e *Engine) Run(config *models.Config) *models.Summary {
-	// Load global variables into store
+// SetRunID replaces the generated ID of the run, e.g. with the ID of the CI
+// job that started it
+func (e *Engine) SetRunID(id string) {
+	e.runID = id
+}
+
+// RunID returns the unique ID of the run, sent in run_id_header and
+// included in its reports
+func (e *Engine) RunID() string {
+	return e.runID
+}
+
+// SetLogger sets the structured logger used for debug output and warnings
+func (e *Engine) SetLogger(logger *slog.Logger) {
+	e.log = logger
+}
+
+// SetDebugLogWriter streams verbose debug logs to a file instead of
+// keeping all of them in memory
+func (e *Engine) SetDebugLogWriter(w *debuglog.Writer) {
+	e.debugLogWriter = w
+}
+
+// SetEventStream emits the events of the run, such as every finished
+// request, as they happen
+func (e *Engine) SetEventStream(w *stream.Writer) {
+	e.eventStream = w
+}
+
+// SetSampling limits which requests produce debug log entries in verbose mode.
+// rate is the fraction of requests logged (0 < rate <= 1); perEndpoint, when
+// greater than zero, caps the number of logged requests per test.
+func (e *Engine) SetSampling(rate float64, perEndpoint int) {
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	e.sampleRate = rate
+	e.samplePerEndpoint = perEndpoint
+}
+
+// shouldSample decides whether a request of the given test is logged
+func (e *Engine) shouldSample(testName string) bool {
+	if e.sampleRate < 1 && e.random.Float64() >= e.sampleRate {
+		return false
+	}
+	if e.samplePerEndpoint > 0 {
+		e.sampleMutex.Lock()
+		defer e.sampleMutex.Unlock()
+		if e.sampleCounts[testName] >= e.samplePerEndpoint {
+			return false
+		}
+		e.sampleCounts[testName]++
+	}
+	return true
+}
+
+// SetSecrets registers resolved secrets so they are exposed as variables
+// and masked in debug logs
+func (e *Engine) SetSecrets(secrets map[string]string) {
+	e.secrets = secrets
+}
+
+// SetMaxDuration sets a wall-clock limit after which the run is stopped,
+// whatever its mode; 0 disables the limit
+func (e *Engine) SetMaxDuration(d time.Duration) {
+	e.maxDuration = d
+}
+
+// SetReportWindow restricts the statistics of the run to the requests
+// started within window, e.g. to leave out ramp-up and ramp-down. Live
+// events and slos still cover the whole run.
+func (e *Engine) SetReportWindow(window models.ReportWindow) {
+	e.reportWindow = &window
+}
+
+// SetContext sets a context whose cancellation interrupts the run, e.g. on
+// Ctrl+C; results collected so far are still summarized
+func (e *Engine) SetContext(ctx context.Context) {
+	e.parent = ctx
+}
+
+// context returns the context of the current run, cancelled once the max
+// duration is reached or an SLO with abort raises an alert
+func (e *Engine) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// loadGlobalVariables loads the global variables and secrets into the store
+func (e *Engine) loadGlobalVariables(config *models.Config) {
 	if config.Global.Variables != nil {
 		e.varStore.SetFromMap(config.Global.Variables)
 	}
 
-	// Check if we need DAG-based execution (tests have dependencies)
-	if e.hasDependencies(config) {
-		return e.runWithDAG(config)
+	// Secrets take precedence over plain variables with the same name
+	for name, value := range e.secrets {
+		e.varStore.Set(name, value)
 	}
+}
 
-	jobs := make(chan Job, 1000)
-	results := make(chan models.TestResult, 1000)
+func (e *Engine) Run(config *models.Config) *models.Summary {
+	ctx := context.Background()
+	if e.parent != nil {
+		ctx = e.parent
+	}
+	if e.maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.maxDuration)
+		defer cancel()
+	}
+	ctx, e.abort = context.WithCancelCause(ctx)
+	defer e.abort(nil)
+	e.ctx = ctx
+
+	e.loadGlobalVariables(config)
+	e.redactor = e.newRedactor(config)
+	e.steadyState = config.Global.SteadyState
+	e.targets = newTargetBalancer(config.Global.Targets)
+	e.inFlight = newHostLimiter(config.Global.MaxInFlightPerHost)
+	e.slos = newSLOMonitor(config.Global.SLOs)
+	e.sink = e.openResultsSink(config.Global.ResultsSink)
+	e.maxBodyBytes = config.Global.MaxCapturedBodyBytes
+	e.autoscale = newAutoscaler(config.Global.Autoscale)
 
 	// Start logger goroutine if verbose mode is enabled
 	if e.verbose {
 		go e.logger()
 	}
 
+	for _, warning := range Preflight(config, e.workers) {
+		e.log.Warn("preflight check failed", "problem", warning)
+	}
+
+	// Warm pool connections are opened before the run starts, outside of its results
+	e.openConnPool(config)
+	defer e.connPool.close()
+	defer e.publishers.close()
+	defer e.databases.close()
+
+	e.restoreCheckpoint(config)
+	e.start = time.Now()
+	if e.eventStream != nil {
+		e.eventStream.RunStarted(config.Name, e.runID, e.workers, config.GetTotalRequests())
+	}
+	e.intervals.begin()
+
+	monitor := startGeneratorMonitor()
+	var summary *models.Summary
+	if len(config.Scenarios) > 0 {
+		summary = e.runScenarios(config)
+	} else if e.hasDependencies(config) {
+		// Tests with dependencies run in DAG phases
+		summary = e.runWithDAG(config)
+	} else if config.Global.Capacity != nil {
+		summary = e.runCapacity(config)
+	} else if config.Global.Replay != nil {
+		summary = e.runReplay(config)
+	} else {
+		results := make(chan models.TestResult, 1000)
+		go func() {
+			defer close(results)
+			e.runPool(config, e.workers, results)
+		}()
+		summary = e.collectResults(results, config.GetTotalRequests())
+	}
+
+	summary.Generator = monitor.stop()
+	summary.RunID = e.runID
+	summary.Seed = e.seed
+	summary.ReportWindow = e.reportWindow
+	if summary.ReportWindow != nil && summary.TotalRequests == 0 && summary.ExcludedReqs > 0 {
+		e.log.Warn("no request started within the report window", "excluded", summary.ExcludedReqs)
+	}
+	for _, warning := range summary.Generator.Warnings {
+		e.log.Warn("load generator may be the bottleneck", "problem", warning)
+	}
+
+	applyFailureBudgets(summary, config)
+	summary.SLOAlerts, summary.AbortedBy = e.slos.result()
+	summary.Autoscale = e.autoscale.result()
+	summary.Resumed = e.resumedSummary()
+	if e.parent != nil && e.parent.Err() != nil {
+		summary.Interrupted = true
+		e.log.Warn("run interrupted")
+	} else if ctx.Err() != nil && summary.AbortedBy == "" {
+		summary.MaxDurationReached = true
+		e.log.Warn("run stopped: max duration reached", "max_duration", e.maxDuration)
+	}
+	if e.progressBar != nil {
+		e.progressBar.Finish()
+	}
+	e.intervals.end()
+	e.endCheckpoints()
+	e.sink.close()
+	if e.eventStream != nil {
+		if err := e.eventStream.RunFinished(summary); err != nil {
+			e.log.Warn("failed to stream run events", "error", err)
+		}
+	}
+
+	// Close log channel if verbose mode is enabled
+	if e.verbose {
+		close(e.logChan)
+		// Wait for logger to flush remaining messages
+		<-e.logDone
+
+		// Add debug logs to summary
+		e.logMutex.Lock()
+		summary.DebugLogs = e.debugLogs
+		e.logMutex.Unlock()
+	}
+
+	return summary
+}
+
+// finished reports a result to the progress bar, the event stream, the
+// interval summaries, the slos, the results sink, and the autoscaler as soon
+// as it is produced
+func (e *Engine) finished(result models.TestResult) {
+	if e.progressBar != nil {
+		name := result.TestName
+		if result.Scenario != "" {
+			name = result.Scenario + "/" + name
+		}
+		e.progressBar.IncrementTest(name, result.Phase, !result.Success && !result.Skipped && !result.Throttled)
+	}
+	if e.eventStream != nil {
+		e.eventStream.RequestFinished(result)
+	}
+	e.intervals.add(result)
+	e.observeSLOs(result)
+	e.sink.add(result)
+	e.autoscale.add(result)
+	e.checkpoint.add(result)
+}
+
+// runPool executes the tests of config on a pool of workers and sends their
+// results, returning once every job is done or the test duration has elapsed
+func (e *Engine) runPool(config *models.Config, workers int, results chan<- models.TestResult) {
+	jobs := make(chan Job, 1000)
+
 	// Create context with timeout for duration-based tests
 	var ctx context.Context
 	var cancel context.CancelFunc
 
-	if config.IsDurationBased() || config.HasMixedMode() {
+	if (config.IsDurationBased() || config.HasMixedMode()) && !hasStopOnBoth(config) {
 		// Find the maximum duration among all tests
 		maxDuration := config.Global.Duration
 		for _, test := range config.Tests {
@@ -88,54 +370,113 @@ func (e *Engine) Run(config *models.Config) *models.Summary {
 				maxDuration = test.Duration
 			}
 		}
-		ctx, cancel = context.WithTimeout(context.Background(), maxDuration)
+		ctx, cancel = context.WithTimeout(e.context(), e.remaining(maxDuration))
 	} else {
-		ctx, cancel = context.WithCancel(context.Background())
+		// Iteration runs, and tests that must reach both limits, end once their jobs are done
+		ctx, cancel = context.WithCancel(e.context())
 	}
 	defer cancel()
 
+	if e.autoscale != nil {
+		generated := make(chan struct{})
+		go func() {
+			defer close(generated)
+			defer close(jobs)
+			e.generateJobs(ctx, config, jobs)
+		}()
+		e.runAutoscaled(ctx, workers, jobs, results, generated)
+		return
+	}
+
 	var wg sync.WaitGroup
 
-	for i := 0; i < e.workers; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go e.worker(ctx, jobs, results, &wg)
+		go e.worker(ctx, i+1, jobs, results, &wg)
 	}
 
 	go func() {
 		defer close(jobs)
-		e.generateJobs(config, jobs)
+		e.generateJobs(ctx, config, jobs)
 	}()
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	wg.Wait()
+}
 
-	summary := e.collectResults(results, config.GetTotalRequests())
-	if e.progressBar != nil {
-		e.progressBar.Finish()
+// hasStopOnBoth reports whether any test runs until both its duration and
+// its iterations are reached
+func hasStopOnBoth(config *models.Config) bool {
+	for _, test := range config.Tests {
+		if config.IsHybrid(test) && config.StopOn(test) == models.StopOnBoth {
+			return true
+		}
 	}
+	return false
+}
 
-	// Close log channel if verbose mode is enabled
-	if e.verbose {
-		close(e.logChan)
-		// Give logger time to flush remaining messages
-		time.Sleep(100 * time.Millisecond)
-		
-		// Add debug logs to summary
-		e.logMutex.Lock()
-		summary.DebugLogs = e.debugLogs
-		e.logMutex.Unlock()
+// runScenarios runs every scenario concurrently, each with its own workers
+// and load settings, and collects all results into one summary
+func (e *Engine) runScenarios(config *models.Config) *models.Summary {
+	results := make(chan models.TestResult, 1000)
+
+	var wg sync.WaitGroup
+	for _, scenario := range config.Scenarios {
+		wg.Add(1)
+		go func(scenario models.Scenario) {
+			defer wg.Done()
+
+			sub := config.ScenarioConfig(scenario)
+			workers := scenario.Workers
+			if workers <= 0 {
+				workers = e.workers
+			}
+
+			if e.hasDependencies(sub) {
+				dagResults, err := e.executeDAG(sub, workers)
+				if err != nil {
+					e.log.Error("scenario failed", "scenario", scenario.Name, "error", err)
+					return
+				}
+				for _, result := range dagResults {
+					result.Scenario = scenario.Name
+					results <- result
+				}
+				return
+			}
+
+			scenarioResults := make(chan models.TestResult, 1000)
+			go func() {
+				defer close(scenarioResults)
+				e.runPool(sub, workers, scenarioResults)
+			}()
+			for result := range scenarioResults {
+				result.Scenario = scenario.Name
+				results <- result
+			}
+		}(scenario)
 	}
 
-	return summary
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return e.collectResults(results, config.GetTotalRequests())
 }
 
 type Job struct {
-	Config   *models.Config
-	TestCase models.TestCase
-	URL      string
-	DataRow  map[string]interface{} // Data row for data-driven testing
+	Config    *models.Config
+	TestCase  models.TestCase
+	URL       string
+	DataRow   map[string]interface{} // Data row for data-driven testing
+	VU        int                    // 1-based ID of the worker executing the job
+	Scope     variables.Scope        // Request-local variables (__iteration, __vu, counters)
+	Deadline  time.Time              // Jobs of duration-limited tests are dropped after this time
+	Loop      variables.Scope        // Variables of the current loop in loop mode (extractions, data rows)
+	RequestID string                 // Sent in the request ID header (request_id_header); kept across throttle retries
+	Body      []byte                 // Sent as is instead of the test's body (replayed requests)
+	Due       time.Time              // When a replayed request was due; a later start counts as schedule lag
+	JobIndex  int                    // 1-based position among the jobs of an iteration-based test, for checkpoints (0 for timed jobs)
 }
 
 type TestMode int
@@ -145,18 +486,32 @@ const (
 	DurationMode
 )
 
-func (e *Engine) generateJobs(config *models.Config, jobs chan<- Job) {
+func (e *Engine) generateJobs(ctx context.Context, config *models.Config, jobs chan<- Job) {
 	if config.HasMixedMode() {
-		e.generateMixedModeJobs(config, jobs)
+		e.generateMixedModeJobs(ctx, config, jobs)
 	} else if config.IsDurationBased() {
-		e.generateDurationBasedJobs(config, jobs)
+		e.generateDurationBasedJobs(ctx, config, jobs)
 	} else {
-		e.generateIterationBasedJobs(config, jobs)
+		e.generateIterationBasedJobs(ctx, config, jobs)
+	}
+}
+
+// sendJob queues job, giving up once ctx is done
+func sendJob(ctx context.Context, jobs chan<- Job, job Job) bool {
+	select {
+	case jobs <- job:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
-func (e *Engine) generateIterationBasedJobs(config *models.Config, jobs chan<- Job) {
+func (e *Engine) generateIterationBasedJobs(ctx context.Context, config *models.Config, jobs chan<- Job) {
 	for _, test := range config.Tests {
+		if ctx.Err() != nil {
+			return
+		}
+
 		iterations := test.Iterations
 		if iterations == 0 {
 			iterations = config.Global.Iterations
@@ -166,35 +521,13 @@ func (e *Engine) generateIterationBasedJobs(config *models.Config, jobs chan<- J
 		testPath := strings.TrimPrefix(test.Path, "/")
 		fullURL := baseURL + "/" + testPath
 
-		// Get data rows (from inline data, file, or empty)
-		dataRows := e.getDataRows(test)
-
-		if len(dataRows) > 0 {
-			// Data-driven test: run iterations for each data row
-			for _, dataRow := range dataRows {
-				for i := 0; i < iterations; i++ {
-					jobs <- Job{
-						Config:   config,
-						TestCase: test,
-						URL:      fullURL,
-						DataRow:  dataRow,
-					}
-				}
-			}
-		} else {
-			// Regular test without data
-			for i := 0; i < iterations; i++ {
-				jobs <- Job{
-					Config:   config,
-					TestCase: test,
-					URL:      fullURL,
-				}
-			}
-		}
+		e.forEachIterationJob(config, test, fullURL, iterations, func(job Job) bool {
+			return sendJob(ctx, jobs, job)
+		})
 	}
 }
 
-func (e *Engine) generateDurationBasedJobs(config *models.Config, jobs chan<- Job) {
+func (e *Engine) generateDurationBasedJobs(ctx context.Context, config *models.Config, jobs chan<- Job) {
 	startTime := time.Now()
 
 	// Create separate goroutines for each test to handle individual durations
@@ -211,38 +544,37 @@ func (e *Engine) generateDurationBasedJobs(config *models.Config, jobs chan<- Jo
 				testDuration = config.Global.Duration
 			}
 
-			endTime := startTime.Add(testDuration)
+			endTime := startTime.Add(e.remaining(testDuration))
 
 			baseURL := strings.TrimSuffix(config.Global.BaseURL, "/")
 			testPath := strings.TrimPrefix(testCase.Path, "/")
 			fullURL := baseURL + "/" + testPath
 
 			// Generate jobs as fast as possible - let workers handle delays
-			for time.Now().Before(endTime) {
-				select {
-				case jobs <- Job{
-					Config:   config,
-					TestCase: testCase,
-					URL:      fullURL,
-				}:
-					// Job sent successfully
-				case <-time.After(10 * time.Millisecond):
-					// Prevent busy waiting if channel is full
-				}
-			}
+			e.generateTimedJobs(ctx, config, testCase, fullURL, endTime, jobs)
 		}(test)
 	}
 
 	wg.Wait()
 }
 
-func (e *Engine) generateMixedModeJobs(config *models.Config, jobs chan<- Job) {
+func (e *Engine) generateMixedModeJobs(ctx context.Context, config *models.Config, jobs chan<- Job) {
 	var wg sync.WaitGroup
 
 	for _, test := range config.Tests {
 		wg.Add(1)
 
-		if test.Duration > 0 || (test.Duration == 0 && config.Global.Duration > 0 && test.Iterations == 0) {
+		if config.IsHybrid(test) {
+			go func(testCase models.TestCase) {
+				defer wg.Done()
+
+				baseURL := strings.TrimSuffix(config.Global.BaseURL, "/")
+				testPath := strings.TrimPrefix(testCase.Path, "/")
+				fullURL := baseURL + "/" + testPath
+
+				e.generateHybridJobs(ctx, config, testCase, fullURL, jobs)
+			}(test)
+		} else if test.Duration > 0 || (test.Duration == 0 && config.Global.Duration > 0 && test.Iterations == 0) {
 			// Duration-based test
 			go func(testCase models.TestCase) {
 				defer wg.Done()
@@ -252,24 +584,13 @@ func (e *Engine) generateMixedModeJobs(config *models.Config, jobs chan<- Job) {
 					testDuration = config.Global.Duration
 				}
 
-				endTime := time.Now().Add(testDuration)
+				endTime := time.Now().Add(e.remaining(testDuration))
 
 				baseURL := strings.TrimSuffix(config.Global.BaseURL, "/")
 				testPath := strings.TrimPrefix(testCase.Path, "/")
 				fullURL := baseURL + "/" + testPath
 
-				for time.Now().Before(endTime) {
-					select {
-					case jobs <- Job{
-						Config:   config,
-						TestCase: testCase,
-						URL:      fullURL,
-					}:
-						// Job sent successfully
-					case <-time.After(10 * time.Millisecond):
-						// Prevent busy waiting if channel is full
-					}
-				}
+				e.generateTimedJobs(ctx, config, testCase, fullURL, endTime, jobs)
 			}(test)
 		} else {
 			// Iteration-based test
@@ -285,13 +606,9 @@ func (e *Engine) generateMixedModeJobs(config *models.Config, jobs chan<- Job) {
 				testPath := strings.TrimPrefix(testCase.Path, "/")
 				fullURL := baseURL + "/" + testPath
 
-				for i := 0; i < iterations; i++ {
-					jobs <- Job{
-						Config:   config,
-						TestCase: testCase,
-						URL:      fullURL,
-					}
-				}
+				e.forEachIterationJob(config, testCase, fullURL, iterations, func(job Job) bool {
+					return sendJob(ctx, jobs, job)
+				})
 			}(test)
 		}
 	}
@@ -299,9 +616,77 @@ func (e *Engine) generateMixedModeJobs(config *models.Config, jobs chan<- Job) {
 	wg.Wait()
 }
 
-func (e *Engine) worker(ctx context.Context, jobs <-chan Job, results chan<- models.TestResult, wg *sync.WaitGroup) {
+// generateHybridJobs sends jobs for a test limited by both iterations and
+// duration. With stop_on "first" it stops at whichever limit is reached
+// first; with "both" it keeps going until the iterations have been sent and
+// the duration has elapsed.
+func (e *Engine) generateHybridJobs(ctx context.Context, config *models.Config, test models.TestCase, fullURL string, jobs chan<- Job) {
+	endTime := time.Now().Add(e.remaining(config.TestDuration(test)))
+	stopOn := config.StopOn(test)
+
+	e.forEachIterationJob(config, test, fullURL, config.TestIterations(test), func(job Job) bool {
+		if stopOn == models.StopOnFirst {
+			if !time.Now().Before(endTime) {
+				return false
+			}
+			job.Deadline = endTime
+		}
+		return sendJob(ctx, jobs, job)
+	})
+
+	if stopOn == models.StopOnBoth && time.Now().Before(endTime) {
+		e.generateTimedJobs(ctx, config, test, fullURL, endTime, jobs)
+	}
+}
+
+// generateTimedJobs sends jobs for a duration-based test until endTime,
+// assigning data rows when the test has data
+func (e *Engine) generateTimedJobs(ctx context.Context, config *models.Config, test models.TestCase, fullURL string, endTime time.Time, jobs chan<- Job) {
+	// Without an explicit strategy rows are cycled
+	strategy := test.DataStrategy
+	if strategy == "" {
+		strategy = models.DataStrategyCircular
+	}
+	cursor := e.openDataCursor(test, strategy)
+	if cursor != nil {
+		defer e.closeDataCursor(test, cursor)
+	}
+
+	for time.Now().Before(endTime) && ctx.Err() == nil {
+		job := Job{
+			Config:   config,
+			TestCase: test,
+			URL:      fullURL,
+			Deadline: endTime,
+		}
+		if cursor != nil {
+			dataRow, ok := cursor.Next()
+			if !ok {
+				// Every row has been consumed
+				return
+			}
+			job.DataRow = dataRow
+		}
+
+		// The job waits for a free worker rather than being dropped, so no
+		// row is taken without being sent
+		deadline := time.NewTimer(time.Until(endTime))
+		select {
+		case jobs <- job:
+			deadline.Stop()
+		case <-ctx.Done():
+			deadline.Stop()
+			return
+		case <-deadline.C:
+			return
+		}
+	}
+}
+
+func (e *Engine) worker(ctx context.Context, vu int, jobs <-chan Job, results chan<- models.TestResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	var schedule pacingSchedule
 	for {
 		select {
 		case <-ctx.Done():
@@ -312,6 +697,16 @@ func (e *Engine) worker(ctx context.Context, jobs <-chan Job, results chan<- mod
 				// Jobs channel closed, no more work
 				return
 			}
+			if !job.Deadline.IsZero() && time.Now().After(job.Deadline) {
+				// Queued before its test's duration ran out
+				continue
+			}
+			rateLag, ok := e.waitStageRate(ctx)
+			if !ok {
+				return
+			}
+			iterationStart := time.Now()
+			lag := schedule.lag(iterationStart) + rateLag + replayLag(job, iterationStart)
 
 			// Apply think time before executing the request (simulates user thinking)
 			thinkTime := e.calculateThinkTime(job)
@@ -324,16 +719,15 @@ func (e *Engine) worker(ctx context.Context, jobs <-chan Job, results chan<- mod
 				}
 			}
 
-			// Set data variables for data-driven tests
-			if job.DataRow != nil {
-				e.setDataVariables(job.DataRow)
-			}
-
+			job.VU = vu
 			result := e.executeTest(job)
-			results <- result
-			if e.progressBar != nil {
-				e.progressBar.Increment()
+			if !result.Success && e.context().Err() != nil {
+				// Aborted by the max duration limit, not a failure of the target
+				return
 			}
+			result.ScheduleLag += lag
+			results <- result
+			e.finished(result)
 
 			// Apply delay after processing the job (only for workers)
 			delay := job.TestCase.Delay
@@ -349,42 +743,113 @@ func (e *Engine) worker(ctx context.Context, jobs <-chan Job, results chan<- mod
 					// Delay completed, continue
 				}
 			}
+
+			schedule.next(iterationStart, job.Config.TestPacing(job.TestCase))
+			if !e.waitPacing(ctx, job, iterationStart) {
+				return
+			}
 		}
 	}
 }
 
+// waitPacing sleeps for the rest of the job's pacing interval, measured from
+// the start of its iteration, so that iterations of a worker start at a fixed
+// rate whatever the response time. It returns false if ctx is done first.
+func (e *Engine) waitPacing(ctx context.Context, job Job, iterationStart time.Time) bool {
+	pacing := job.Config.TestPacing(job.TestCase)
+	if pacing <= 0 {
+		return true
+	}
+
+	remaining := pacing - time.Since(iterationStart)
+	if remaining <= 0 {
+		// The iteration took longer than the pacing interval
+		e.log.Debug("iteration exceeded pacing", "test", job.TestCase.Name, "pacing", pacing)
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(remaining):
+		return true
+	}
+}
+
 // calculateThinkTime returns the think time to apply before a request
-// It handles both fixed think time and random range
+// It handles fixed think time, random range, and distributions
 func (e *Engine) calculateThinkTime(job Job) time.Duration {
-	// Check test-level think time first
-	if job.TestCase.ThinkTime > 0 {
-		return job.TestCase.ThinkTime
+	test := job.TestCase
+	global := job.Config.Global
+
+	// Check test-level distribution first
+	if test.ThinkTimeDistribution != "" {
+		return e.sampleThinkTime(test.ThinkTimeDistribution, test.ThinkTime, test.ThinkTimeStddev, test.ThinkTimeMin, test.ThinkTimeMax)
+	}
+
+	// Then test-level fixed think time
+	if test.ThinkTime > 0 {
+		return test.ThinkTime
 	}
 
 	// Check test-level random range
-	if job.TestCase.ThinkTimeMin > 0 && job.TestCase.ThinkTimeMax > 0 {
-		return e.randomDuration(job.TestCase.ThinkTimeMin, job.TestCase.ThinkTimeMax)
+	if test.ThinkTimeMin > 0 && test.ThinkTimeMax > 0 {
+		return e.randomDuration(test.ThinkTimeMin, test.ThinkTimeMax)
 	}
 
 	// Fall back to global settings
-	if job.Config.Global.ThinkTime > 0 {
-		return job.Config.Global.ThinkTime
+	if global.ThinkTimeDistribution != "" {
+		return e.sampleThinkTime(global.ThinkTimeDistribution, global.ThinkTime, global.ThinkTimeStddev, global.ThinkTimeMin, global.ThinkTimeMax)
+	}
+
+	if global.ThinkTime > 0 {
+		return global.ThinkTime
 	}
 
 	// Check global random range
-	if job.Config.Global.ThinkTimeMin > 0 && job.Config.Global.ThinkTimeMax > 0 {
-		return e.randomDuration(job.Config.Global.ThinkTimeMin, job.Config.Global.ThinkTimeMax)
+	if global.ThinkTimeMin > 0 && global.ThinkTimeMax > 0 {
+		return e.randomDuration(global.ThinkTimeMin, global.ThinkTimeMax)
 	}
 
 	return 0
 }
 
+// sampleThinkTime draws a think time from a distribution with the given mean
+// and standard deviation, bounded by min and max when they are set
+func (e *Engine) sampleThinkTime(distribution string, mean, stddev, min, max time.Duration) time.Duration {
+	var sample float64
+	switch distribution {
+	case models.ThinkTimeUniform:
+		if max > 0 {
+			return e.randomDuration(min, max)
+		}
+		// A uniform distribution over mean ± √3·stddev has the given stddev
+		spread := math.Sqrt(3) * float64(stddev)
+		sample = float64(mean) - spread + e.random.Float64()*2*spread
+	case models.ThinkTimeNormal:
+		sample = float64(mean) + e.random.NormFloat64()*float64(stddev)
+	case models.ThinkTimeExponential:
+		sample = e.random.ExpFloat64() * float64(mean)
+	default:
+		return mean
+	}
+
+	thinkTime := time.Duration(sample)
+	if thinkTime < min {
+		thinkTime = min
+	}
+	if max > 0 && thinkTime > max {
+		thinkTime = max
+	}
+	return thinkTime
+}
+
 // randomDuration returns a random duration between min and max
 func (e *Engine) randomDuration(min, max time.Duration) time.Duration {
 	if min >= max {
 		return min
 	}
-	return min + time.Duration(rand.Int63n(int64(max-min)))
+	return min + time.Duration(e.random.Int63n(int64(max-min)))
 }
 
 // getDataRows returns the data rows for a test (from inline data or file)
@@ -396,12 +861,10 @@ func (e *Engine) getDataRows(test models.TestCase) []map[string]interface{} {
 
 	// Check for data file
 	if test.DataFile != "" {
-		data, err := e.loadDataFromFile(test.DataFile)
+		data, err := e.loadDataFromFile(test.DataFile, test.DataSheet)
 		if err != nil {
 			// Log error but continue - test will run without data
-			if e.verbose {
-				fmt.Printf("Warning: Failed to load data file %s: %v\n", test.DataFile, err)
-			}
+			e.log.Warn("failed to load data file", "test", test.Name, "file", test.DataFile, "error", err)
 			return nil
 		}
 		return data
@@ -410,122 +873,84 @@ func (e *Engine) getDataRows(test models.TestCase) []map[string]interface{} {
 	return nil
 }
 
-// loadDataFromFile loads data from a JSON or CSV file
-func (e *Engine) loadDataFromFile(filePath string) ([]map[string]interface{}, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
-
-	switch ext {
-	case ".json":
-		return e.loadJSONData(filePath)
-	case ".csv":
-		return e.loadCSVData(filePath)
-	default:
-		return nil, fmt.Errorf("unsupported data file format: %s", ext)
-	}
-}
-
-// loadJSONData loads an array of objects from a JSON file
-func (e *Engine) loadJSONData(filePath string) ([]map[string]interface{}, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
-
-	var result []map[string]interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
-	}
-
-	return result, nil
-}
-
-// loadCSVData loads data from a CSV file (first row is header)
-func (e *Engine) loadCSVData(filePath string) ([]map[string]interface{}, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	if len(records) < 2 {
-		return nil, fmt.Errorf("CSV file must have at least a header and one data row")
-	}
-
-	// First row is the header
-	headers := records[0]
-	var result []map[string]interface{}
-
-	// Convert each row to a map
-	for i := 1; i < len(records); i++ {
-		row := make(map[string]interface{})
-		for j, header := range headers {
-			if j < len(records[i]) {
-				row[header] = records[i][j]
-			}
-		}
-		result = append(result, row)
+// insecureTLSConfig skips certificate verification and accepts legacy
+// protocol versions and cipher suites, for test environments
+func insecureTLSConfig() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		MaxVersion:         tls.VersionTLS13,
+		CipherSuites: []uint16{
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		},
 	}
-
-	return result, nil
 }
 
-// setDataVariables sets the data row variables in the store with "data." prefix
-func (e *Engine) setDataVariables(dataRow map[string]interface{}) {
-	if dataRow == nil {
-		return
+// executeRequest sends the request of a job once and checks its response
+func (e *Engine) executeRequest(job Job) models.TestResult {
+	start := time.Now()
+	job.Scope = e.newScope(job)
+	if job.TestCase.Socket != nil {
+		return e.executeSocket(job, start)
 	}
-
-	// Set each field with "data." prefix
-	for key, value := range dataRow {
-		e.varStore.Set("data."+key, value)
-
-		// Handle nested maps
-		if nested, ok := value.(map[string]interface{}); ok {
-			e.setNestedDataVariables("data."+key, nested)
-		}
+	if job.TestCase.Messaging != nil {
+		return e.executeMessaging(job)
 	}
-}
-
-// setNestedDataVariables recursively sets nested data variables
-func (e *Engine) setNestedDataVariables(prefix string, data map[string]interface{}) {
-	for key, value := range data {
-		fullKey := prefix + "." + key
-		e.varStore.Set(fullKey, value)
-
-		// Handle nested maps
-		if nested, ok := value.(map[string]interface{}); ok {
-			e.setNestedDataVariables(fullKey, nested)
-		}
+	if job.TestCase.SQL != nil {
+		return e.executeSQL(job, start)
 	}
-}
-
-func (e *Engine) executeTest(job Job) models.TestResult {
-	start := time.Now()
 	
-	// Generate a unique request ID for tracking in verbose mode
-	requestID := ""
-	if e.verbose {
+	// Decide whether this request is logged and generate a unique request ID for tracking
+	logRequest := e.verbose && e.shouldSample(job.TestCase.Name)
+	requestID := job.RequestID
+	if logRequest && requestID == "" {
 		requestID = uuid.New().String()[:8] // Use first 8 chars for readability
 	}
 
 	req, err := e.createRequest(job)
 	if err != nil {
 		return models.TestResult{
-			TestName:  job.TestCase.Name,
-			URL:       job.URL,
-			Method:    job.TestCase.Method,
-			Success:   false,
-			Error:     err.Error(),
-			Timestamp: start,
+			TestName:      job.TestCase.Name,
+			URL:           job.URL,
+			Method:        job.TestCase.Method,
+			Success:       false,
+			Error:         err.Error(),
+			ErrorCategory: ErrorRequest,
+			Timestamp:     start,
 		}
 	}
 
+	// The request holds a slot of its host until its response is read, and
+	// frees it before a comparison request that may need one of the same
+	// host. The wait for a slot is spent in the client, not the server.
+	release := func() {}
+	if e.inFlight != nil {
+		if release, err = e.inFlight.acquire(req.Context(), req.URL.Host); err != nil {
+			return models.TestResult{
+				TestName:      job.TestCase.Name,
+				URL:           job.URL,
+				Method:        job.TestCase.Method,
+				Success:       false,
+				Error:         fmt.Sprintf("waiting for a request slot of %s: %v", req.URL.Host, err),
+				ErrorCategory: ErrorRequest,
+				Timestamp:     start,
+			}
+		}
+		defer release()
+		start = time.Now()
+	}
+
 	timeout := job.TestCase.Timeout
 	if timeout == 0 {
 		timeout = job.Config.Global.Timeout
@@ -536,40 +961,30 @@ func (e *Engine) executeTest(job Job) models.TestResult {
 		skipVerify = *job.TestCase.InsecureSkipVerify
 	}
 
-	var transport *http.Transport
-	if skipVerify {
-		transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-				MinVersion:         tls.VersionTLS10,
-				MaxVersion:         tls.VersionTLS13,
-				CipherSuites: []uint16{
-					tls.TLS_RSA_WITH_AES_128_CBC_SHA,
-					tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-					tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
-					tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
-					tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				},
-			},
-		}
-	} else {
-		transport = &http.Transport{}
-	}
-
 	client := &http.Client{
-		Timeout:   timeout,
-		Transport: transport,
+		Timeout: timeout,
+		Transport: e.connPool.transport(transportKey{
+			skipVerify: skipVerify,
+			keepAlive:  !job.Config.DisableKeepAlive(job.TestCase),
+			timeouts:   job.Config.TestTransportTimeouts(job.TestCase),
+		}),
+	}
+
+	// Event streams are read for their window after the timeout of the
+	// response headers, and aborted through their context when it ends
+	var streamCtx context.Context
+	var endStream context.CancelFunc
+	if sse := job.TestCase.SSE; sse != nil {
+		if client.Timeout > 0 {
+			client.Timeout += sse.Window
+		}
+		streamCtx, endStream = context.WithCancel(req.Context())
+		defer endStream()
+		req = req.WithContext(streamCtx)
 	}
 	
 	// Log request details in verbose mode
-	if e.verbose {
+	if logRequest {
 		log := models.DebugLog{
 			Timestamp: start,
 			RequestID: requestID,
@@ -591,31 +1006,102 @@ func (e *Engine) executeTest(job Job) models.TestResult {
 			// Read and restore body for logging
 			bodyBytes, _ := io.ReadAll(req.Body)
 			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-			log.Body = string(bodyBytes)
+			log.Body = e.logBody(bodyBytes)
 		}
 		
 		e.logChan <- log
 	}
 	
-	resp, err := client.Do(req)
-	if err != nil {
-		return models.TestResult{
-			TestName:     job.TestCase.Name,
-			URL:          job.URL,
-			Method:       job.TestCase.Method,
-			ResponseTime: time.Since(start),
-			Success:      false,
-			Error:        err.Error(),
-			Timestamp:    start,
+	// Serve repeated identical requests of cached tests locally
+	var resp *http.Response
+	cacheKey := ""
+	if job.TestCase.Cache {
+		cacheKey = requestCacheKey(req, job.Config.RequestIDHeader(job.TestCase))
+		resp = e.responseCache.get(cacheKey)
+	}
+	cached := resp != nil
+
+	var timer *requestTimer
+	if !cached {
+		if e.injectFault(job.Config.TestInject(job.TestCase)) {
+			return models.TestResult{
+				TestName:      job.TestCase.Name,
+				URL:           job.URL,
+				Method:        job.TestCase.Method,
+				ResponseTime:  time.Since(start),
+				Success:       false,
+				Error:         "request dropped by fault injection",
+				ErrorCategory: ErrorInjected,
+				Timestamp:     start,
+			}
+		}
+
+		timer = &requestTimer{}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+		resp, err = client.Do(req)
+		if err != nil {
+			return models.TestResult{
+				TestName:      job.TestCase.Name,
+				URL:           job.URL,
+				Method:        job.TestCase.Method,
+				ResponseTime:  time.Since(start),
+				Success:       false,
+				Error:         e.redactor.String(err.Error()),
+				ErrorCategory: classifyError(err),
+				Timestamp:     start,
+				Timing:        timer.finish(time.Now()),
+			}
 		}
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	responseTime := time.Since(start)
+	var body []byte
+	var events *models.SSEResult
+	var responseTime time.Duration
+	var responseSize int64
+	var encoding string
+	var decodeTime time.Duration
+	readBody := job.TestCase.ReadBody == nil || *job.TestCase.ReadBody
+	if sse := job.TestCase.SSE; sse != nil && resp.StatusCode < 300 {
+		// Streams report their time to the first event; the body checked by
+		// assertions is the data of the last event
+		events, responseSize, err = readEvents(streamCtx, endStream, resp.Body, start, sse)
+		responseTime = time.Since(start)
+		if events.Events > 0 {
+			responseTime = events.FirstEvent
+		}
+		body = []byte(events.LastData)
+	} else if !readBody {
+		// Pure throughput tests drain the body without keeping it
+		responseSize, err = discardBody(resp.Body)
+		responseTime = time.Since(start)
+	} else {
+		body, err = io.ReadAll(resp.Body)
+		responseTime = time.Since(start)
+		responseSize = int64(len(body))
+		// Cached bodies are stored decoded
+		if err == nil && !cached {
+			body, encoding, decodeTime, err = decodeBody(resp, body)
+		}
+	}
+	release()
+	if err != nil {
+		return models.TestResult{
+			TestName:      job.TestCase.Name,
+			URL:           job.URL,
+			Method:        job.TestCase.Method,
+			StatusCode:    resp.StatusCode,
+			ResponseTime:  responseTime,
+			Success:       false,
+			Error:         fmt.Sprintf("Failed to read response body: %v", err),
+			ErrorCategory: ErrorRead,
+			ResponseSize:  responseSize,
+			Timestamp:     start,
+		}
+	}
 	
 	// Log response details in verbose mode
-	if e.verbose {
+	if logRequest {
 		log := models.DebugLog{
 			Timestamp:    time.Now(),
 			RequestID:    requestID,
@@ -623,7 +1109,7 @@ func (e *Engine) executeTest(job Job) models.TestResult {
 			TestName:     job.TestCase.Name,
 			StatusCode:   resp.StatusCode,
 			Headers:      make(map[string]string),
-			Body:         string(body),
+			Body:         e.logBody(body),
 			ResponseTime: responseTime,
 		}
 		
@@ -638,6 +1124,16 @@ func (e *Engine) executeTest(job Job) models.TestResult {
 	}
 
 	success := e.isExpectedStatus(resp.StatusCode, job.TestCase.ExpectedStatus)
+	var conditionErr error
+	if job.TestCase.SuccessWhen != "" {
+		success, conditionErr = e.meetsSuccessCondition(job.TestCase.SuccessWhen,
+			assertion.NewContext(resp.StatusCode, responseTime, body, resp.Header))
+	}
+
+	// Only responses with an expected status are cached, so failures are retried
+	if cacheKey != "" && !cached && success {
+		e.responseCache.put(cacheKey, resp.StatusCode, resp.Header, body)
+	}
 
 	result := models.TestResult{
 		TestName:     job.TestCase.Name,
@@ -646,72 +1142,227 @@ func (e *Engine) executeTest(job Job) models.TestResult {
 		StatusCode:   resp.StatusCode,
 		ResponseTime: responseTime,
 		Success:      success,
-		ResponseSize: int64(len(body)),
+		ResponseSize: responseSize,
 		RequestSize:  req.ContentLength,
 		Timestamp:    start,
+		Cached:       cached,
+		SSE:          events,
+	}
+	if encoding != "" {
+		result.ContentEncoding = encoding
+		result.DecodedSize = int64(len(body))
+		result.DecodeTime = decodeTime
+	}
+	if timer != nil {
+		result.Timing = timer.finish(start.Add(responseTime))
+	}
+
+	// Rate-limited responses are retried by executeTest, not checked
+	if !success && job.Config.TestThrottle(job.TestCase) != nil {
+		if throttled, retryAfter := isThrottled(resp, time.Now()); throttled {
+			if e.verbose {
+				result.BodySample = bodySample(e.capturedBody(body))
+			}
+			result.Throttled = true
+			result.RetryAfter = retryAfter
+			result.Error = fmt.Sprintf("throttled: status code %d", resp.StatusCode)
+			result.ErrorCategory = ErrorThrottled
+			return result
+		}
 	}
 
-	if !success {
-		if e.verbose {
+	if !success && job.TestCase.SuccessWhen != "" {
+		result.ErrorCategory = ErrorSuccessWhen
+		if conditionErr != nil {
+			result.Error = fmt.Sprintf("success_when: %v (status code %d)", conditionErr, resp.StatusCode)
+		} else {
+			result.Error = fmt.Sprintf("success_when not met: %s (status code %d)", job.TestCase.SuccessWhen, resp.StatusCode)
+		}
+		if e.verbose && readBody {
+			result.Error += fmt.Sprintf("\nResponse body: %s", e.capturedBody(body))
+		}
+	} else if !success {
+		result.ErrorCategory = ErrorStatus
+		if e.verbose && readBody {
 			// In verbose mode, include more details in the error message
 			result.Error = fmt.Sprintf("Unexpected status code: %d (expected: %v)\nResponse body: %s",
-				resp.StatusCode, job.TestCase.ExpectedStatus, string(body))
+				resp.StatusCode, job.TestCase.ExpectedStatus, e.capturedBody(body))
 		} else {
 			result.Error = fmt.Sprintf("Unexpected status code: %d (expected: %v)",
 				resp.StatusCode, job.TestCase.ExpectedStatus)
 		}
 	}
 
+	if events != nil && success && events.Events < job.TestCase.SSE.MinEvents {
+		result.Success = false
+		result.ErrorCategory = ErrorSSE
+		result.Error = fmt.Sprintf("sse: %d events in %v, expected at least %d",
+			events.Events, job.TestCase.SSE.Window, job.TestCase.SSE.MinEvents)
+	}
+
 	// Extract variables from response if extraction rules are defined
-	if len(job.TestCase.Extract) > 0 && success {
-		if err := e.varExtractor.Extract(job.TestCase.Extract, body, resp.Header, resp.StatusCode); err != nil {
+	if rules := extractionRules(job.TestCase.Extract, success); len(rules) > 0 {
+		if err := e.varExtractor.ExtractScoped(rules, body, resp.Header, resp.StatusCode, job.Loop); err != nil && success {
 			result.Error = fmt.Sprintf("Variable extraction failed: %v", err)
+			result.ErrorCategory = ErrorExtraction
 			result.Success = false
 		}
 	}
+	extracted := result.Success
 
 	// Evaluate assertions if any are defined
+	var contextPaths map[int]string // Assertion index -> body section around its failure
 	if len(job.TestCase.Assertions) > 0 {
 		ctx := assertion.NewContext(resp.StatusCode, responseTime, body, resp.Header)
+		ctx.SSE = events
 		assertionResults := e.assertionEvaluator.EvaluateAll(job.TestCase.Assertions, ctx)
 
-		for _, ar := range assertionResults {
+		for i, ar := range assertionResults {
+			outcome := models.AssertionOutcome{Assertion: job.TestCase.Assertions[i], Passed: ar.Passed}
 			if ar.Passed {
 				result.AssertionsPassed++
 			} else {
 				result.AssertionsFailed++
-				result.AssertionErrors = append(result.AssertionErrors, ar.Message)
+				message := e.assertionMessage(ar)
+				result.AssertionErrors = append(result.AssertionErrors, message)
 				result.Success = false // Assertion failure means test failure
+				outcome.Message = message
+				if ar.ContextPath != "" {
+					if contextPaths == nil {
+						contextPaths = make(map[int]string)
+					}
+					contextPaths[len(result.Assertions)] = ar.ContextPath
+				}
 			}
+			result.Assertions = append(result.Assertions, outcome)
+		}
+		if result.AssertionsFailed > 0 && result.ErrorCategory == "" {
+			result.ErrorCategory = ErrorAssertion
 		}
 	}
 
+	// Extracted variables are only checked once extraction succeeded
+	if extracted {
+		e.assertVariables(job, &result)
+	}
+
 	// Execute tap compare if configured
 	if job.TestCase.CompareWith != nil {
 		compResult := e.executeComparison(job, body, resp.StatusCode, responseTime, resp.Header)
 		result.ComparisonResult = compResult
 
-		if compResult != nil && !compResult.Success {
-			result.Success = false
-			if result.Error == "" {
-				result.Error = "Comparison failed"
-			} else {
-				result.Error += "; Comparison failed"
-			}
+		if compResult != nil && !compResult.Success {
+			result.Success = false
+			if result.ErrorCategory == "" {
+				result.ErrorCategory = ErrorComparison
+			}
+			if result.Error == "" {
+				result.Error = "Comparison failed"
+			} else {
+				result.Error += "; Comparison failed"
+			}
+		}
+	}
+
+	// The body is sampled once extraction ran, so that the values it
+	// extracted into redacted variables are masked in it too. The first
+	// failures keep their body for the report's failure samples.
+	if e.verbose || (!result.Success && e.sampleFailureBody(job.TestCase.Name)) {
+		result.BodySample = bodySample(e.capturedBody(body))
+	}
+
+	// Failed assertions of the requests keeping their body also keep the
+	// section of it they looked at
+	if result.BodySample != "" && len(contextPaths) > 0 {
+		redacted := []byte(e.redactor.Body(string(body)))
+		for i, path := range contextPaths {
+			result.Assertions[i].Context = assertion.Snippet(redacted, path)
+		}
+	}
+
+	return result
+}
+
+// assertionMessage returns the message of a failed assertion with sensitive
+// values masked, leaving out the actual value of json_path assertions that
+// read a redacted path
+func (e *Engine) assertionMessage(ar assertion.Result) string {
+	message := ar.Message
+	if ar.Assertion.Type == "json_path" && e.redactor.Path(assertion.JSONPath(ar.Assertion.Target)) {
+		message = maskActual(message, ar)
+	}
+	return e.redactor.String(message)
+}
+
+// maskActual leaves the actual value out of the message of a failed
+// assertion. Messages without a "got" part, such as comparison errors, are
+// replaced unless no value was read.
+func maskActual(message string, ar assertion.Result) string {
+	if i := strings.LastIndex(message, ", got "); i >= 0 {
+		return message[:i] + ", got " + redact.Mask
+	}
+	if ar.ActualValue == nil {
+		return message
+	}
+	return fmt.Sprintf("assertion failed: %s %s %v, got %s", ar.Assertion.Target, ar.Assertion.Operator, ar.Assertion.Value, redact.Mask)
+}
+
+// setRunID sets the run ID header of a request, unless the configured
+// headers already set it
+func (e *Engine) setRunID(req *http.Request, config *models.Config) {
+	if header := config.Global.RunIDHeader; header != "" && req.Header.Get(header) == "" {
+		req.Header.Set(header, e.runID)
+	}
+}
+
+// extractionRules returns the rules to apply to a response: all of them when
+// the status was expected, otherwise only those marked extract_on_failure
+func extractionRules(rules []models.ExtractionRule, success bool) []models.ExtractionRule {
+	if success {
+		return rules
+	}
+	var onFailure []models.ExtractionRule
+	for _, rule := range rules {
+		if rule.ExtractOnFailure {
+			onFailure = append(onFailure, rule)
 		}
 	}
+	return onFailure
+}
 
-	return result
+// newScope builds the request-local variables for a job: the per-test
+// iteration number, the ID of the worker (virtual user) running it, and the
+// data row of a data-driven test, over the variables of the job's loop in
+// loop mode. Rows live in the scope rather than the shared store, so a row
+// is only ever seen by the request it was taken for.
+func (e *Engine) newScope(job Job) variables.Scope {
+	scope := make(variables.Scope, len(job.Loop)+len(job.DataRow)+2)
+	for name, value := range job.Loop {
+		scope[name] = value
+	}
+	setScopeDataVariables(scope, "data", job.DataRow)
+	scope["__iteration"] = e.iterationCounters.Next(job.TestCase.Name)
+	scope["__vu"] = job.VU
+	return scope
 }
 
 func (e *Engine) createRequest(job Job) (*http.Request, error) {
 	// Substitute variables in URL
-	url := e.varSubstitutor.Substitute(job.URL)
+	rawURL, pathParams := e.withPathParams(job.URL, job.TestCase.PathParams, job.Scope)
+	url, query := e.withQuery(e.varSubstitutor.SubstituteScoped(rawURL, job.Scope), job.TestCase.Query, job.Scope)
+	substituted := []interface{}{url, pathParams, query}
 
 	var body io.Reader
-	if job.TestCase.Body != nil {
+	if soap := job.TestCase.SOAP; soap != nil {
+		envelope := e.varSubstitutor.SubstituteScoped(soapEnvelope(soap), job.Scope)
+		substituted = append(substituted, envelope)
+		body = strings.NewReader(envelope)
+	} else if job.Body != nil {
+		body = bytes.NewReader(job.Body)
+	} else if job.TestCase.Body != nil {
 		// Substitute variables in body
-		substitutedBody := e.varSubstitutor.SubstituteBody(job.TestCase.Body)
+		substitutedBody := e.varSubstitutor.SubstituteBodyScoped(job.TestCase.Body, job.Scope)
+		substituted = append(substituted, substitutedBody)
 		jsonBody, err := json.Marshal(substitutedBody)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal body: %w", err)
@@ -719,19 +1370,47 @@ func (e *Engine) createRequest(job Job) (*http.Request, error) {
 		body = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(job.TestCase.Method, url, body)
+	req, err := http.NewRequestWithContext(e.context(), job.TestCase.Method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Substitute variables in global headers
 	for key, value := range job.Config.Global.Headers {
-		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+		req.Header.Set(key, e.varSubstitutor.SubstituteScoped(value, job.Scope))
 	}
 
 	// Substitute variables in test-specific headers
 	for key, value := range job.TestCase.Headers {
-		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+		req.Header.Set(key, e.varSubstitutor.SubstituteScoped(value, job.Scope))
+	}
+
+	// Rotated headers replace the fixed ones
+	if len(job.TestCase.RotateHeaders) > 0 {
+		for _, rotator := range e.headerRotators(job.TestCase) {
+			req.Header.Set(rotator.name, e.varSubstitutor.SubstituteScoped(rotator.value(), job.Scope))
+		}
+	}
+	setAcceptEncoding(req, job.Config, job.TestCase)
+	if header := job.Config.RequestIDHeader(job.TestCase); header != "" && job.RequestID != "" && req.Header.Get(header) == "" {
+		req.Header.Set(header, job.RequestID)
+	}
+	e.setRunID(req, job.Config)
+
+	if soap := job.TestCase.SOAP; soap != nil {
+		setSOAPHeaders(req.Header, soap, e.varSubstitutor.SubstituteScoped(soap.Action, job.Scope))
+	}
+	if job.TestCase.SSE != nil && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	if job.Config.Global.StrictVariables {
+		for _, values := range req.Header {
+			substituted = append(substituted, strings.Join(values, ", "))
+		}
+		if err := unresolvedVariables(substituted); err != nil {
+			return nil, err
+		}
 	}
 
 	if job.TestCase.Body != nil && req.Header.Get("Content-Type") == "" {
@@ -741,6 +1420,129 @@ func (e *Engine) createRequest(job Job) (*http.Request, error) {
 	return req, nil
 }
 
+// withPathParams fills the {name} placeholders of rawURL with the test's path
+// parameters, substituting variables in them first so that extracted values
+// are percent-encoded too. It also returns the substituted values.
+func (e *Engine) withPathParams(rawURL string, params map[string]string, scope variables.Scope) (string, []string) {
+	if len(params) == 0 {
+		return rawURL, nil
+	}
+	substituted := make(map[string]string, len(params))
+	values := make([]string, 0, len(params))
+	for name, value := range params {
+		value = e.varSubstitutor.SubstituteScoped(value, scope)
+		substituted[name] = value
+		values = append(values, value)
+	}
+	return variables.ExpandPath(rawURL, substituted), values
+}
+
+// withQuery appends query parameters to rawURL, substituting variables in
+// their values and encoding them so any character is sent as is. It also
+// returns the substituted values.
+func (e *Engine) withQuery(rawURL string, query map[string]string, scope variables.Scope) (string, []string) {
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+	params := make(url.Values, len(query))
+	values := make([]string, 0, len(query))
+	for name, value := range query {
+		value = e.varSubstitutor.SubstituteScoped(value, scope)
+		params.Set(name, value)
+		values = append(values, value)
+	}
+
+	fragment := ""
+	if i := strings.Index(rawURL, "#"); i >= 0 {
+		rawURL, fragment = rawURL[:i], rawURL[i:]
+	}
+	separator := "?"
+	if strings.HasSuffix(rawURL, "?") || strings.HasSuffix(rawURL, "&") {
+		separator = ""
+	} else if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + params.Encode() + fragment, values
+}
+
+// unresolvedVariables returns an error naming the ${variable} references
+// left in the substituted URL, body, and headers of a request
+func unresolvedVariables(substituted []interface{}) error {
+	names := uniqueSorted(variables.References(substituted))
+	if len(names) == 0 {
+		return nil
+	}
+	for i, name := range names {
+		names[i] = "${" + name + "}"
+	}
+	return fmt.Errorf("unresolved variable %s", strings.Join(names, ", "))
+}
+
+// meetsSuccessCondition evaluates a test's success_when against a response,
+// parsing each expression once per engine
+func (e *Engine) meetsSuccessCondition(source string, ctx *assertion.Context) (bool, error) {
+	cached, ok := e.successConditions.Load(source)
+	if !ok {
+		expression, err := assertion.ParseExpression(source)
+		if err != nil {
+			return false, err
+		}
+		cached, _ = e.successConditions.LoadOrStore(source, expression)
+	}
+	return cached.(*assertion.Expression).Eval(ctx)
+}
+
+// assertVariables evaluates a test's assert_variables against the variables
+// extracted so far, adding their outcomes to the result's assertions
+func (e *Engine) assertVariables(job Job, result *models.TestResult) {
+	for _, source := range job.TestCase.AssertVariables {
+		var ar assertion.Result
+		check, err := e.variableCheck(source)
+		if err != nil {
+			ar = assertion.Result{
+				Assertion: models.Assertion{Type: "variable", Target: source},
+				Message:   fmt.Sprintf("assert_variables: %v", err),
+			}
+		} else {
+			value, found := e.varSubstitutor.Resolve(check.Reference, job.Scope)
+			ar = e.assertionEvaluator.EvaluateVariable(check, value, found)
+		}
+		outcome := models.AssertionOutcome{Assertion: ar.Assertion, Passed: ar.Passed}
+		if ar.Passed {
+			result.AssertionsPassed++
+		} else {
+			// Values of redacted variables are left out, whatever their transforms
+			message := ar.Message
+			if err == nil && e.redactor.Variable(check.Name) {
+				message = maskActual(message, ar)
+			}
+			message = e.redactor.String(message)
+			result.AssertionsFailed++
+			result.AssertionErrors = append(result.AssertionErrors, message)
+			result.Success = false
+			outcome.Message = message
+		}
+		result.Assertions = append(result.Assertions, outcome)
+	}
+	if result.AssertionsFailed > 0 && result.ErrorCategory == "" {
+		result.ErrorCategory = ErrorAssertion
+	}
+}
+
+// variableCheck returns a parsed check of assert_variables, parsing each
+// check once per engine
+func (e *Engine) variableCheck(source string) (assertion.VariableCheck, error) {
+	cached, ok := e.variableChecks.Load(source)
+	if !ok {
+		check, err := assertion.ParseVariableCheck(source)
+		if err != nil {
+			return assertion.VariableCheck{}, err
+		}
+		cached, _ = e.variableChecks.LoadOrStore(source, check)
+	}
+	return cached.(assertion.VariableCheck), nil
+}
+
 func (e *Engine) isExpectedStatus(statusCode int, expectedStatuses []int) bool {
 	for _, expected := range expectedStatuses {
 		if statusCode == expected {
@@ -759,7 +1561,7 @@ func (e *Engine) executeComparison(job Job, primaryBody []byte, primaryStatus in
 			StatusCode:   primaryStatus,
 			ResponseTime: primaryTime,
 			BodySize:     int64(len(primaryBody)),
-			Body:         primaryBody,
+			Body:         e.comparisonBody(primaryBody),
 		},
 	}
 
@@ -772,12 +1574,15 @@ func (e *Engine) executeComparison(job Job, primaryBody []byte, primaryStatus in
 		path := strings.TrimPrefix(job.TestCase.Path, "/")
 		compareURL += "/" + path
 	}
-	compareURL = e.varSubstitutor.Substitute(compareURL)
+	compareURL, _ = e.withPathParams(compareURL, job.TestCase.PathParams, job.Scope)
+	compareURL, _ = e.withQuery(e.varSubstitutor.SubstituteScoped(compareURL, job.Scope), job.TestCase.Query, job.Scope)
 
 	// Create comparison request
 	var body io.Reader
-	if job.TestCase.Body != nil {
-		substitutedBody := e.varSubstitutor.SubstituteBody(job.TestCase.Body)
+	if soap := job.TestCase.SOAP; soap != nil {
+		body = strings.NewReader(e.varSubstitutor.SubstituteScoped(soapEnvelope(soap), job.Scope))
+	} else if job.TestCase.Body != nil {
+		substitutedBody := e.varSubstitutor.SubstituteBodyScoped(job.TestCase.Body, job.Scope)
 		jsonBody, err := json.Marshal(substitutedBody)
 		if err != nil {
 			result.Error = fmt.Sprintf("failed to marshal body: %v", err)
@@ -794,13 +1599,17 @@ func (e *Engine) executeComparison(job Job, primaryBody []byte, primaryStatus in
 
 	// Set headers: global -> test-specific -> compare-specific
 	for key, value := range job.Config.Global.Headers {
-		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+		req.Header.Set(key, e.varSubstitutor.SubstituteScoped(value, job.Scope))
 	}
 	for key, value := range job.TestCase.Headers {
-		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+		req.Header.Set(key, e.varSubstitutor.SubstituteScoped(value, job.Scope))
 	}
 	for key, value := range compareConfig.Headers {
-		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+		req.Header.Set(key, e.varSubstitutor.SubstituteScoped(value, job.Scope))
+	}
+	e.setRunID(req, job.Config)
+	if soap := job.TestCase.SOAP; soap != nil {
+		setSOAPHeaders(req.Header, soap, e.varSubstitutor.SubstituteScoped(soap.Action, job.Scope))
 	}
 
 	if job.TestCase.Body != nil && req.Header.Get("Content-Type") == "" {
@@ -836,6 +1645,14 @@ func (e *Engine) executeComparison(job Job, primaryBody []byte, primaryStatus in
 		Transport: transport,
 	}
 
+	// Comparison requests count towards the requests in flight of their host
+	release, err := e.inFlight.acquire(e.context(), req.URL.Host)
+	if err != nil {
+		result.Error = fmt.Sprintf("comparison request failed: %v", err)
+		return result
+	}
+	defer release()
+
 	// Execute comparison request
 	compareStart := time.Now()
 	resp, err := client.Do(req)
@@ -852,19 +1669,21 @@ func (e *Engine) executeComparison(job Job, primaryBody []byte, primaryStatus in
 		StatusCode:   resp.StatusCode,
 		ResponseTime: compareTime,
 		BodySize:     int64(len(compareBody)),
-		Body:         compareBody,
+		Body:         e.comparisonBody(compareBody),
 	}
 
-	// Perform comparison
-	e.comparisonEvaluator.SetIgnoreFields(compareConfig.IgnoreFields)
-	e.comparisonEvaluator.SetMode(compareConfig.Mode)
+	// Perform comparison with this test's settings; a shared evaluator would
+	// let concurrent tests overwrite each other's mode and ignored fields
+	evaluator := comparison.New(e.verbose)
+	evaluator.SetIgnoreFields(compareConfig.IgnoreFields)
+	evaluator.SetMode(compareConfig.Mode)
 
 	ctx := comparison.NewContext(
 		primaryStatus, primaryTime, primaryBody, convertHeaders(primaryHeaders),
 		resp.StatusCode, compareTime, compareBody, convertHeaders(resp.Header),
 	)
 
-	compResult := e.comparisonEvaluator.Compare(ctx, compareConfig.Assertions)
+	compResult := evaluator.Compare(ctx, compareConfig.Assertions)
 
 	result.Success = compResult.Success
 
@@ -909,21 +1728,46 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 	summary := &models.Summary{
 		StatusCodes:     make(map[int]int),
 		Errors:          make(map[string]int),
+		ErrorCategories: make(map[string]int),
 		EndpointResults: make(map[string]*models.EndpointSummary),
 	}
 
 	var allResults []models.TestResult
 
 	for result := range results {
+		if !e.inReportWindow(result) {
+			summary.ExcludedReqs++
+			continue
+		}
 		allResults = append(allResults, result)
 
+		if result.Scenario != "" {
+			if summary.ScenarioResults == nil {
+				summary.ScenarioResults = make(map[string]*models.ScenarioSummary)
+			}
+			scenario := summary.ScenarioResults[result.Scenario]
+			if scenario == nil {
+				scenario = &models.ScenarioSummary{Name: result.Scenario}
+				summary.ScenarioResults[result.Scenario] = scenario
+			}
+			scenario.TotalRequests++
+			if result.Success {
+				scenario.SuccessfulReqs++
+			} else if !result.Throttled {
+				scenario.FailedReqs++
+			}
+		}
+
 		summary.TotalRequests++
+		summary.ThrottleRetries += result.ThrottleRetries
 		if result.Success {
 			summary.SuccessfulReqs++
+		} else if result.Throttled {
+			summary.ThrottledReqs++
 		} else {
 			summary.FailedReqs++
 			if result.Error != "" {
-				summary.Errors[result.Error]++
+				summary.Errors[normalizeError(result.Error)]++
 			}
 		}
 
@@ -941,10 +1785,11 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 		key := result.TestName
 		if summary.EndpointResults[key] == nil {
 			summary.EndpointResults[key] = &models.EndpointSummary{
-				Name:        result.TestName,
-				URL:         result.URL,
-				StatusCodes: make(map[int]int),
-				Errors:      []string{},
+				Name:            result.TestName,
+				URL:             result.URL,
+				StatusCodes:     make(map[int]int),
+				Errors:          []string{},
+				ErrorCategories: make(map[string]int),
 			}
 		}
 
@@ -952,13 +1797,26 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 		endpoint.TotalRequests++
 		if result.Success {
 			endpoint.SuccessfulReqs++
+		} else if result.Throttled {
+			endpoint.ThrottledReqs++
 		} else {
 			endpoint.FailedReqs++
 			if result.Error != "" {
-				endpoint.Errors = append(endpoint.Errors, result.Error)
+				endpoint.Errors = addError(endpoint.Errors, normalizeError(result.Error))
 			}
+			category := errorCategory(result)
+			summary.ErrorCategories[category]++
+			endpoint.ErrorCategories[category]++
+			endpoint.FailureSamples = trackFailure(endpoint.FailureSamples, result)
 		}
 		endpoint.StatusCodes[result.StatusCode]++
+		if result.Cached {
+			endpoint.CachedReqs++
+		}
+		summary.Transfer.Add(result)
+		endpoint.Transfer.Add(result)
+		endpoint.Rows.Add(result)
+		summary.SlowestRequests = trackSlowest(summary.SlowestRequests, result)
 
 		// Aggregate assertion results
 		summary.AssertionsPassed += result.AssertionsPassed
@@ -967,6 +1825,7 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 		endpoint.AssertionsPassed += result.AssertionsPassed
 		endpoint.AssertionsFailed += result.AssertionsFailed
 		endpoint.TotalAssertions += result.AssertionsPassed + result.AssertionsFailed
+		endpoint.Assertions = trackAssertions(endpoint.Assertions, result.Assertions)
 
 		// Aggregate comparison results
 		if result.ComparisonResult != nil {
@@ -986,11 +1845,17 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 		var totalResponseTime time.Duration
 		var allTimes []time.Duration
 		endpointTimes := make(map[string][]time.Duration)
+		classTimes := make(map[string]map[string][]time.Duration)
+		streams := make(map[string][]*models.SSEResult)
 
 		for _, result := range allResults {
 			totalResponseTime += result.ResponseTime
 			allTimes = append(allTimes, result.ResponseTime)
 			endpointTimes[result.TestName] = append(endpointTimes[result.TestName], result.ResponseTime)
+			addClassTime(classTimes, result)
+			if result.SSE != nil {
+				streams[result.TestName] = append(streams[result.TestName], result.SSE)
+			}
 		}
 
 		summary.AvgResponseTime = totalResponseTime / time.Duration(len(allResults))
@@ -1000,6 +1865,14 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 			summary.RequestsPerSec = float64(len(allResults)) / summary.TotalTime.Seconds()
 		}
 
+		if len(summary.ScenarioResults) > 0 {
+			calculateScenarioTimes(summary, allResults)
+		}
+		summary.PhaseResults = calculatePhaseSummaries(allResults)
+		summary.TargetResults = e.targets.summaries(allResults)
+		summary.SteadyState = calculateSteadyState(allResults, e.steadyState)
+		summary.ScheduleLag = calculateScheduleLag(allResults)
+
 		// Calculate global percentiles
 		summary.P50ResponseTime = calculatePercentile(allTimes, 50)
 		summary.P95ResponseTime = calculatePercentile(allTimes, 95)
@@ -1016,6 +1889,11 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 				endpoint.P50ResponseTime = calculatePercentile(times, 50)
 				endpoint.P95ResponseTime = calculatePercentile(times, 95)
 				endpoint.P99ResponseTime = calculatePercentile(times, 99)
+				endpoint.LatencyHistogram = latencyHistogram(times)
+				endpoint.StatusClassLatency = classLatencies(classTimes[testName])
+				if len(streams[testName]) > 0 {
+					endpoint.SSE = sseStats(streams[testName])
+				}
 			}
 		}
 	}
@@ -1023,6 +1901,124 @@ func (e *Engine) collectResults(results <-chan models.TestResult, totalRequests
 	return summary
 }
 
+// calculateScenarioTimes fills in the timing figures of each scenario summary.
+// Results of concurrent scenarios arrive interleaved, so the time span is
+// taken from the earliest start to the latest end.
+func calculateScenarioTimes(summary *models.Summary, allResults []models.TestResult) {
+	type span struct {
+		start, end time.Time
+		total      time.Duration
+	}
+	spans := make(map[string]*span)
+
+	for _, result := range allResults {
+		if result.Scenario == "" {
+			continue
+		}
+		end := result.Timestamp.Add(result.ResponseTime)
+		sp := spans[result.Scenario]
+		if sp == nil {
+			sp = &span{start: result.Timestamp, end: end}
+			spans[result.Scenario] = sp
+		}
+		if result.Timestamp.Before(sp.start) {
+			sp.start = result.Timestamp
+		}
+		if end.After(sp.end) {
+			sp.end = end
+		}
+		sp.total += result.ResponseTime
+	}
+
+	for name, sp := range spans {
+		scenario := summary.ScenarioResults[name]
+		scenario.AvgResponseTime = sp.total / time.Duration(scenario.TotalRequests)
+		scenario.TotalTime = sp.end.Sub(sp.start)
+		if scenario.TotalTime > 0 {
+			scenario.RequestsPerSec = float64(scenario.TotalRequests) / scenario.TotalTime.Seconds()
+		}
+	}
+}
+
+// calculatePhaseSummaries groups the results of DAG runs by phase, so that
+// the slow phases of chained flows stand out. Each scenario has its own
+// phases. Results outside DAG execution are ignored.
+func calculatePhaseSummaries(allResults []models.TestResult) []*models.PhaseSummary {
+	type phaseKey struct {
+		scenario string
+		phase    int
+	}
+	phases := make(map[phaseKey]*models.PhaseSummary)
+	seenTests := make(map[phaseKey]map[string]bool)
+	totalTimes := make(map[phaseKey]time.Duration)
+
+	for _, result := range allResults {
+		if result.Phase == 0 {
+			continue
+		}
+		key := phaseKey{result.Scenario, result.Phase}
+		phase := phases[key]
+		if phase == nil {
+			phase = &models.PhaseSummary{Phase: result.Phase, Scenario: result.Scenario}
+			phases[key] = phase
+			seenTests[key] = make(map[string]bool)
+		}
+		if !seenTests[key][result.TestName] {
+			seenTests[key][result.TestName] = true
+			phase.Tests = append(phase.Tests, result.TestName)
+		}
+
+		phase.TotalRequests++
+		if result.Skipped {
+			phase.SkippedReqs++
+			continue
+		}
+		if result.Success {
+			phase.SuccessfulReqs++
+		} else if !result.Throttled {
+			phase.FailedReqs++
+		}
+		totalTimes[key] += result.ResponseTime
+
+		end := result.Timestamp.Add(result.ResponseTime)
+		if phase.StartTime.IsZero() || result.Timestamp.Before(phase.StartTime) {
+			phase.StartTime = result.Timestamp
+		}
+		if end.After(phase.EndTime) {
+			phase.EndTime = end
+		}
+	}
+
+	summaries := make([]*models.PhaseSummary, 0, len(phases))
+	for key, phase := range phases {
+		sort.Strings(phase.Tests)
+		if executed := phase.SuccessfulReqs + phase.FailedReqs; executed > 0 {
+			phase.AvgResponseTime = totalTimes[key] / time.Duration(executed)
+			phase.Duration = phase.EndTime.Sub(phase.StartTime)
+			if phase.Duration > 0 {
+				phase.RequestsPerSec = float64(executed) / phase.Duration.Seconds()
+			}
+		}
+		summaries = append(summaries, phase)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Scenario != summaries[j].Scenario {
+			return summaries[i].Scenario < summaries[j].Scenario
+		}
+		return summaries[i].Phase < summaries[j].Phase
+	})
+	return summaries
+}
+
+// applyFailureBudgets copies each test's allowed failure rate onto its endpoint summary
+func applyFailureBudgets(summary *models.Summary, config *models.Config) {
+	for _, test := range config.Tests {
+		if endpoint, ok := summary.EndpointResults[test.Name]; ok {
+			endpoint.AllowedFailureRate = test.AllowedFailureRate
+		}
+	}
+}
+
 func calculatePercentile(times []time.Duration, percentile float64) time.Duration {
 	if len(times) == 0 {
 		return 0
@@ -1052,20 +2048,118 @@ func calculatePercentile(times []time.Duration, percentile float64) time.Duratio
 	return time.Duration(float64(lower) + weight*float64(upper-lower))
 }
 
+// addClassTime files the response time of a result under its endpoint and
+// status class; requests that got no response are left out
+func addClassTime(classTimes map[string]map[string][]time.Duration, result models.TestResult) {
+	if result.StatusCode == 0 {
+		return
+	}
+	if classTimes[result.TestName] == nil {
+		classTimes[result.TestName] = make(map[string][]time.Duration)
+	}
+	class := fmt.Sprintf("%dxx", result.StatusCode/100)
+	classTimes[result.TestName][class] = append(classTimes[result.TestName][class], result.ResponseTime)
+}
+
+// classLatencies summarizes the response times of each status class
+func classLatencies(classTimes map[string][]time.Duration) map[string]*models.LatencySummary {
+	if len(classTimes) == 0 {
+		return nil
+	}
+	latencies := make(map[string]*models.LatencySummary, len(classTimes))
+	for class, times := range classTimes {
+		var total time.Duration
+		for _, t := range times {
+			total += t
+		}
+		latencies[class] = &models.LatencySummary{
+			Requests:        len(times),
+			AvgResponseTime: total / time.Duration(len(times)),
+			P50ResponseTime: calculatePercentile(times, 50),
+			P95ResponseTime: calculatePercentile(times, 95),
+			P99ResponseTime: calculatePercentile(times, 99),
+		}
+	}
+	return latencies
+}
+
+// latencyBucketBounds are the upper bounds of the latency histogram buckets;
+// a final bucket catches everything slower
+var latencyBucketBounds = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// latencyHistogram counts response times into the latency buckets
+func latencyHistogram(times []time.Duration) []models.LatencyBucket {
+	buckets := make([]models.LatencyBucket, len(latencyBucketBounds)+1)
+	for i, bound := range latencyBucketBounds {
+		buckets[i].UpperBound = bound
+	}
+	for _, t := range times {
+		i := sort.Search(len(latencyBucketBounds), func(i int) bool {
+			return t < latencyBucketBounds[i]
+		})
+		buckets[i].Count++
+	}
+	return buckets
+}
+
 // logger is a goroutine that handles all verbose logging sequentially
 func (e *Engine) logger() {
+	defer close(e.logDone)
 	for log := range e.logChan {
-		if e.progressBar != nil {
-			// Text mode: print formatted output
-			e.printDebugLog(log)
+		log = e.redactor.DebugLog(log)
+		e.printDebugLog(log)
+		if e.debugLogWriter != nil {
+			if err := e.debugLogWriter.Write(log); err != nil {
+				e.log.Warn("failed to write debug log", "error", err)
+			}
 		}
-		// Always store for potential JSON output
+		// Store for potential JSON output (only a sample when streaming to file)
 		e.logMutex.Lock()
-		e.debugLogs = append(e.debugLogs, log)
+		if e.debugLogWriter == nil || len(e.debugLogs) < debugLogMemorySample {
+			e.debugLogs = append(e.debugLogs, log)
+		}
 		e.logMutex.Unlock()
 	}
 }
 
+// newRedactor builds the redactor for debug logs and error messages.
+// Resolved secrets are always masked, in addition to the configured redact list.
+func (e *Engine) newRedactor(config *models.Config) *redact.Redactor {
+	var redactConfig models.RedactConfig
+	if config.Global.Redact != nil {
+		redactConfig = *config.Global.Redact
+	}
+
+	if len(e.secrets) == 0 && len(redactConfig.Headers) == 0 &&
+		len(redactConfig.Variables) == 0 && len(redactConfig.JSONPaths) == 0 {
+		return nil
+	}
+
+	return redact.New(redactConfig, func() []string {
+		var values []string
+		for _, value := range e.secrets {
+			values = append(values, value)
+		}
+		// Variables are looked up on every call so extracted values are covered too
+		for _, name := range redactConfig.Variables {
+			if value := e.varStore.GetString(name); value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	})
+}
+
 // hasDependencies checks if any test has dependencies requiring DAG execution
 func (e *Engine) hasDependencies(config *models.Config) bool {
 	for _, test := range config.Tests {
@@ -1076,14 +2170,61 @@ func (e *Engine) hasDependencies(config *models.Config) bool {
 	return false
 }
 
+// dependencySkipReason returns why a test must be skipped given the tests
+// that failed or were skipped so far, following its depends_on_mode, or ""
+// when the test can run
+func dependencySkipReason(test models.TestCase, failed map[string]bool) string {
+	switch test.DependsOnMode {
+	case models.DependsOnCompletion:
+		return ""
+	case models.DependsOnAny:
+		for _, dep := range test.DependsOn {
+			if !failed[dep] {
+				return ""
+			}
+		}
+		if len(test.DependsOn) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("all dependencies failed (%s)", strings.Join(test.DependsOn, ", "))
+	default:
+		for _, dep := range test.DependsOn {
+			if failed[dep] {
+				return fmt.Sprintf("dependency '%s' failed", dep)
+			}
+		}
+		return ""
+	}
+}
+
 // runWithDAG executes tests using DAG-based ordering for dependencies
 func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
-	// Start logger goroutine if verbose mode is enabled
-	if e.verbose {
-		go e.logger()
+	startTime := time.Now()
+
+	allResults, err := e.executeDAG(config, e.workers)
+	if err != nil {
+		// Return summary with error
+		summary := &models.Summary{
+			StatusCodes:     make(map[int]int),
+			Errors:          make(map[string]int),
+			ErrorCategories: make(map[string]int),
+			EndpointResults: make(map[string]*models.EndpointSummary),
+		}
+		summary.Errors[err.Error()] = 1
+		return summary
 	}
 
-	startTime := time.Now()
+	// Calculate summary from all results
+	return e.calculateSummaryFromResults(allResults, startTime)
+}
+
+// executeDAG runs the tests phase by phase following their dependencies,
+// tests within each phase in parallel on up to workers workers. In loop mode
+// the whole chain is repeated on every worker instead.
+func (e *Engine) executeDAG(config *models.Config, workers int) ([]models.TestResult, error) {
+	if config.Global.Loop {
+		return e.executeLoop(config, workers)
+	}
 
 	// Build DAG from test dependencies
 	var testDeps []variables.TestDependency
@@ -1096,14 +2237,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 
 	plan, err := variables.BuildDAG(testDeps)
 	if err != nil {
-		// Return summary with error
-		summary := &models.Summary{
-			StatusCodes:     make(map[int]int),
-			Errors:          make(map[string]int),
-			EndpointResults: make(map[string]*models.EndpointSummary),
-		}
-		summary.Errors[err.Error()] = 1
-		return summary
+		return nil, err
 	}
 
 	// Create test lookup map
@@ -1116,7 +2250,14 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 	var allResults []models.TestResult
 	failedTests := make(map[string]bool) // Track tests that failed
 
-	for _, phase := range plan.Phases {
+	ctx := e.context()
+	for phaseIndex, phase := range plan.Phases {
+		phaseNumber := phaseIndex + 1
+		if ctx.Err() != nil {
+			// Max duration reached, later phases are not started
+			break
+		}
+
 		var wg sync.WaitGroup
 
 		// Separate tests into executable and skipped
@@ -1125,22 +2266,15 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 
 		for _, testName := range phase {
 			test := testByName[testName]
-			// Check if any dependency has failed
-			var failedDep string
-			for _, dep := range test.DependsOn {
-				if failedTests[dep] {
-					failedDep = dep
-					break
-				}
-			}
+			skipReason := dependencySkipReason(test, failedTests)
 
-			if failedDep != "" {
+			if skipReason != "" {
 				// Skip this test - create skipped result(s)
 				baseURL := strings.TrimSuffix(config.Global.BaseURL, "/")
 				testPath := strings.TrimPrefix(test.Path, "/")
 				fullURL := baseURL + "/" + testPath
 
-				dataRows := e.getDataRows(test)
+				dataRows := e.dataRowCount(test)
 				iterations := config.Global.Iterations
 				if test.Iterations > 0 {
 					iterations = test.Iterations
@@ -1149,10 +2283,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 					iterations = 1
 				}
 
-				numSkipped := iterations
-				if len(dataRows) > 0 {
-					numSkipped = len(dataRows) * iterations
-				}
+				numSkipped := iterationJobCount(test, dataRows, iterations)
 
 				for i := 0; i < numSkipped; i++ {
 					skippedResults = append(skippedResults, models.TestResult{
@@ -1160,8 +2291,9 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 						URL:        fullURL,
 						Method:     test.Method,
 						Skipped:    true,
-						SkipReason: fmt.Sprintf("dependency '%s' failed", failedDep),
+						SkipReason: skipReason,
 						Timestamp:  time.Now(),
+						Phase:      phaseNumber,
 					})
 				}
 				// Mark this test as failed too (so its dependents are also skipped)
@@ -1174,9 +2306,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 		// Add skipped results immediately
 		for _, result := range skippedResults {
 			allResults = append(allResults, result)
-			if e.progressBar != nil {
-				e.progressBar.Increment()
-			}
+			e.finished(result)
 		}
 
 		// If no executable tests, continue to next phase
@@ -1188,7 +2318,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 		totalPhaseJobs := 0
 		for _, testName := range executableTests {
 			test := testByName[testName]
-			dataRows := e.getDataRows(test)
+			dataRows := e.dataRowCount(test)
 			iterations := config.Global.Iterations
 			if test.Iterations > 0 {
 				iterations = test.Iterations
@@ -1196,11 +2326,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 			if iterations <= 0 {
 				iterations = 1
 			}
-			if len(dataRows) > 0 {
-				totalPhaseJobs += len(dataRows) * iterations
-			} else {
-				totalPhaseJobs += iterations
-			}
+			totalPhaseJobs += iterationJobCount(test, dataRows, iterations)
 		}
 
 		// Create channels with proper buffer sizes
@@ -1208,35 +2334,51 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 		phaseJobs := make(chan Job, totalPhaseJobs)
 
 		// Limit workers to min(available workers, total jobs in phase)
-		workers := e.workers
-		if totalPhaseJobs < workers {
-			workers = totalPhaseJobs
+		phaseWorkers := workers
+		if totalPhaseJobs < phaseWorkers {
+			phaseWorkers = totalPhaseJobs
 		}
-		if workers < 1 {
-			workers = 1
+		if phaseWorkers < 1 {
+			phaseWorkers = 1
 		}
 
 		// Start workers for this phase
-		for i := 0; i < workers; i++ {
+		for i := 0; i < phaseWorkers; i++ {
 			wg.Add(1)
-			go func() {
+			go func(vu int) {
 				defer wg.Done()
+				var schedule pacingSchedule
 				for job := range phaseJobs {
+					if ctx.Err() != nil {
+						// Drain the remaining jobs without running them
+						continue
+					}
+					iterationStart := time.Now()
+					lag := schedule.lag(iterationStart)
+
 					// Apply think time before executing the request
 					thinkTime := e.calculateThinkTime(job)
 					if thinkTime > 0 {
-						time.Sleep(thinkTime)
-					}
-
-					// Set data variables for data-driven tests
-					if job.DataRow != nil {
-						e.setDataVariables(job.DataRow)
+						select {
+						case <-ctx.Done():
+							continue
+						case <-time.After(thinkTime):
+						}
 					}
 
+					job.VU = vu
 					result := e.executeTestWithExtraction(job)
+					if !result.Success && ctx.Err() != nil {
+						continue
+					}
+					result.Phase = phaseNumber
+					result.ScheduleLag += lag
 					phaseResults <- result
+
+					schedule.next(iterationStart, job.Config.TestPacing(job.TestCase))
+					e.waitPacing(ctx, job, iterationStart)
 				}
-			}()
+			}(i + 1)
 		}
 
 		// Send jobs for executable tests
@@ -1246,9 +2388,6 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 			testPath := strings.TrimPrefix(test.Path, "/")
 			fullURL := baseURL + "/" + testPath
 
-			// Get data rows for data-driven testing
-			dataRows := e.getDataRows(test)
-
 			// Determine iterations
 			iterations := config.Global.Iterations
 			if test.Iterations > 0 {
@@ -1258,28 +2397,10 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 				iterations = 1
 			}
 
-			if len(dataRows) > 0 {
-				// Data-driven test: run iterations for each data row
-				for _, dataRow := range dataRows {
-					for i := 0; i < iterations; i++ {
-						phaseJobs <- Job{
-							Config:   config,
-							TestCase: test,
-							URL:      fullURL,
-							DataRow:  dataRow,
-						}
-					}
-				}
-			} else {
-				// Regular test without data
-				for i := 0; i < iterations; i++ {
-					phaseJobs <- Job{
-						Config:   config,
-						TestCase: test,
-						URL:      fullURL,
-					}
-				}
-			}
+			e.forEachIterationJob(config, test, fullURL, iterations, func(job Job) bool {
+				phaseJobs <- job
+				return true
+			})
 		}
 		close(phaseJobs)
 
@@ -1290,9 +2411,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 		// Collect results for this phase and track failures
 		for result := range phaseResults {
 			allResults = append(allResults, result)
-			if e.progressBar != nil {
-				e.progressBar.Increment()
-			}
+			e.finished(result)
 			// Mark test as failed if it didn't succeed
 			if !result.Success {
 				failedTests[result.TestName] = true
@@ -1300,24 +2419,7 @@ func (e *Engine) runWithDAG(config *models.Config) *models.Summary {
 		}
 	}
 
-	// Calculate summary from all results
-	summary := e.calculateSummaryFromResults(allResults, startTime)
-
-	if e.progressBar != nil {
-		e.progressBar.Finish()
-	}
-
-	// Close log channel if verbose mode is enabled
-	if e.verbose {
-		close(e.logChan)
-		time.Sleep(100 * time.Millisecond)
-
-		e.logMutex.Lock()
-		summary.DebugLogs = e.debugLogs
-		e.logMutex.Unlock()
-	}
-
-	return summary
+	return allResults, nil
 }
 
 // executeTestWithExtraction executes a test and extracts variables from the response
@@ -1331,8 +2433,10 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 	summary := &models.Summary{
 		StatusCodes:     make(map[int]int),
 		Errors:          make(map[string]int),
+		ErrorCategories: make(map[string]int),
 		EndpointResults: make(map[string]*models.EndpointSummary),
 	}
+	allResults, summary.ExcludedReqs = e.windowResults(allResults)
 
 	for _, result := range allResults {
 		summary.TotalRequests++
@@ -1345,6 +2449,7 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 				URL:             result.URL,
 				StatusCodes:     make(map[int]int),
 				Errors:          []string{},
+				ErrorCategories: make(map[string]int),
 				FirstExecutedAt: result.Timestamp,
 			}
 		}
@@ -1361,25 +2466,41 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 			endpoint.SkippedReqs++
 			if result.SkipReason != "" {
 				summary.Errors[result.SkipReason]++
-				endpoint.Errors = append(endpoint.Errors, result.SkipReason)
+				endpoint.Errors = addError(endpoint.Errors, result.SkipReason)
 			}
 			continue // Don't count skipped in response times or status codes
 		}
 
+		summary.ThrottleRetries += result.ThrottleRetries
 		if result.Success {
 			summary.SuccessfulReqs++
 			endpoint.SuccessfulReqs++
+		} else if result.Throttled {
+			summary.ThrottledReqs++
+			endpoint.ThrottledReqs++
 		} else {
 			summary.FailedReqs++
 			endpoint.FailedReqs++
 			if result.Error != "" {
-				summary.Errors[result.Error]++
-				endpoint.Errors = append(endpoint.Errors, result.Error)
+				message := normalizeError(result.Error)
+				summary.Errors[message]++
+				endpoint.Errors = addError(endpoint.Errors, message)
 			}
+			category := errorCategory(result)
+			summary.ErrorCategories[category]++
+			endpoint.ErrorCategories[category]++
+			endpoint.FailureSamples = trackFailure(endpoint.FailureSamples, result)
 		}
 
 		summary.StatusCodes[result.StatusCode]++
 		endpoint.StatusCodes[result.StatusCode]++
+		if result.Cached {
+			endpoint.CachedReqs++
+		}
+		summary.Transfer.Add(result)
+		endpoint.Transfer.Add(result)
+		endpoint.Rows.Add(result)
+		summary.SlowestRequests = trackSlowest(summary.SlowestRequests, result)
 
 		if summary.MinResponseTime == 0 || result.ResponseTime < summary.MinResponseTime {
 			summary.MinResponseTime = result.ResponseTime
@@ -1396,6 +2517,7 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 		endpoint.AssertionsPassed += result.AssertionsPassed
 		endpoint.AssertionsFailed += result.AssertionsFailed
 		endpoint.TotalAssertions += result.AssertionsPassed + result.AssertionsFailed
+		endpoint.Assertions = trackAssertions(endpoint.Assertions, result.Assertions)
 
 		// Aggregate comparison results
 		if result.ComparisonResult != nil {
@@ -1412,11 +2534,13 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 	}
 
 	// Calculate response time stats (excluding skipped)
-	executedCount := summary.SuccessfulReqs + summary.FailedReqs
+	executedCount := summary.SuccessfulReqs + summary.FailedReqs + summary.ThrottledReqs
 	if executedCount > 0 {
 		var totalResponseTime time.Duration
 		var allTimes []time.Duration
 		endpointTimes := make(map[string][]time.Duration)
+		classTimes := make(map[string]map[string][]time.Duration)
+		streams := make(map[string][]*models.SSEResult)
 
 		for _, result := range allResults {
 			if result.Skipped {
@@ -1425,6 +2549,10 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 			totalResponseTime += result.ResponseTime
 			allTimes = append(allTimes, result.ResponseTime)
 			endpointTimes[result.TestName] = append(endpointTimes[result.TestName], result.ResponseTime)
+			addClassTime(classTimes, result)
+			if result.SSE != nil {
+				streams[result.TestName] = append(streams[result.TestName], result.SSE)
+			}
 		}
 
 		summary.AvgResponseTime = totalResponseTime / time.Duration(executedCount)
@@ -1450,53 +2578,51 @@ func (e *Engine) calculateSummaryFromResults(allResults []models.TestResult, sta
 				endpoint.P50ResponseTime = calculatePercentile(times, 50)
 				endpoint.P95ResponseTime = calculatePercentile(times, 95)
 				endpoint.P99ResponseTime = calculatePercentile(times, 99)
+				endpoint.LatencyHistogram = latencyHistogram(times)
+				endpoint.StatusClassLatency = classLatencies(classTimes[testName])
+				if len(streams[testName]) > 0 {
+					endpoint.SSE = sseStats(streams[testName])
+				}
 			}
 		}
 	}
 
+	summary.PhaseResults = calculatePhaseSummaries(allResults)
+	summary.TargetResults = e.targets.summaries(allResults)
+	summary.SteadyState = calculateSteadyState(allResults, e.steadyState)
+	summary.ScheduleLag = calculateScheduleLag(allResults)
+
 	return summary
 }
 
-// printDebugLog formats and prints debug log for text output
+// printDebugLog emits a debug log entry through the structured logger
 func (e *Engine) printDebugLog(log models.DebugLog) {
+	if !e.log.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+
+	attrs := []any{
+		"request_id", log.RequestID,
+		"test", log.TestName,
+	}
 	if log.Type == "request" {
-		fmt.Printf("\n=== REQUEST DEBUG ===")
-		fmt.Printf("\nRequest ID: %s", log.RequestID)
-		fmt.Printf("\nTimestamp: %s", log.Timestamp.Format(time.RFC3339))
-		fmt.Printf("\nTest: %s", log.TestName)
-		fmt.Printf("\nMethod: %s", log.Method)
-		fmt.Printf("\nURL: %s", log.URL)
-		if len(log.Headers) > 0 {
-			fmt.Printf("\nHeaders:")
-			for key, value := range log.Headers {
-				fmt.Printf("\n  %s: %s", key, value)
-			}
-		}
-		if log.Body != "" {
-			fmt.Printf("\nBody: %s", log.Body)
-		}
-		fmt.Printf("\n===================\n")
-	} else if log.Type == "response" {
-		fmt.Printf("\n=== RESPONSE DEBUG ===")
-		fmt.Printf("\nRequest ID: %s", log.RequestID)
-		fmt.Printf("\nTimestamp: %s", log.Timestamp.Format(time.RFC3339))
-		fmt.Printf("\nTest: %s", log.TestName)
-		fmt.Printf("\nStatus: %d", log.StatusCode)
-		if len(log.Headers) > 0 {
-			fmt.Printf("\nHeaders:")
-			for key, value := range log.Headers {
-				fmt.Printf("\n  %s: %s", key, value)
-			}
-		}
-		if log.Body != "" {
-			fmt.Printf("\nBody (%d bytes):", len(log.Body))
-			if len(log.Body) > 1000 {
-				fmt.Printf("\n%s... (truncated)", log.Body[:1000])
-			} else {
-				fmt.Printf("\n%s", log.Body)
-			}
+		attrs = append(attrs, "method", log.Method, "url", log.URL)
+	} else {
+		attrs = append(attrs, "status", log.StatusCode, "response_time", log.ResponseTime)
+	}
+	if len(log.Headers) > 0 {
+		attrs = append(attrs, "headers", log.Headers)
+	}
+	if log.Body != "" {
+		body := log.Body
+		if len(body) > 1000 {
+			body = body[:1000] + "... (truncated)"
 		}
-		fmt.Printf("\nResponse Time: %v", log.ResponseTime)
-		fmt.Printf("\n===================\n")
+		attrs = append(attrs, "body", body, "body_size", len(log.Body))
 	}
+	if log.Error != "" {
+		attrs = append(attrs, "error", log.Error)
+	}
+
+	e.log.Debug(log.Type, attrs...)
 }
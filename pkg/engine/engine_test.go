@@ -1,14 +1,30 @@
 package engine
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/debuglog"
 	"github.com/andrearaponi/bombardino/pkg/progress"
+	"github.com/andrearaponi/bombardino/pkg/stream"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -388,3 +404,1696 @@ func TestEngine_createRequest_WithoutBody(t *testing.T) {
 	assert.Equal(t, "", req.Header.Get("Content-Type"))
 	assert.Nil(t, req.Body)
 }
+
+func TestEngine_createRequest_WithPathParams(t *testing.T) {
+	engine := New(1, nil, false)
+	engine.varStore.Set("user_id", "John Doe/2")
+
+	job := Job{
+		Config: &models.Config{},
+		TestCase: models.TestCase{
+			Method:     "GET",
+			PathParams: map[string]string{"org": "a&b", "id": "${user_id}"},
+			Query:      map[string]string{"v": "1"},
+		},
+		URL: "https://api.example.com/orgs/{org}/users/{id}",
+	}
+	req, err := engine.createRequest(job)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/orgs/a&b/users/John%20Doe%2F2?v=1", req.URL.String())
+	assert.Equal(t, "/orgs/a&b/users/John Doe/2", req.URL.Path)
+
+	job.Config = &models.Config{Global: models.GlobalConfig{StrictVariables: true}}
+	job.TestCase.PathParams = map[string]string{"org": "acme", "id": "${missing}"}
+	_, err = engine.createRequest(job)
+	assert.EqualError(t, err, "unresolved variable ${missing}")
+}
+
+func TestEngine_createRequest_RotateHeaders(t *testing.T) {
+	engine := New(1, nil, false)
+	engine.varStore.Set("version", "2.0")
+
+	job := Job{
+		Config: &models.Config{},
+		TestCase: models.TestCase{
+			Name:    "Rotated",
+			Method:  "GET",
+			Headers: map[string]string{"User-Agent": "fixed"},
+			RotateHeaders: map[string]models.HeaderRotation{
+				"User-Agent":      {Values: []string{"Mozilla/5.0", "curl/${version}"}},
+				"Accept-Language": {Column: "lang"},
+			},
+			Data: []map[string]interface{}{{"lang": "en"}, {"lang": "it"}, {"lang": ""}},
+		},
+		URL: "https://api.example.com/",
+	}
+
+	var agents, languages []string
+	for i := 0; i < 3; i++ {
+		req, err := engine.createRequest(job)
+		require.NoError(t, err)
+		agents = append(agents, req.Header.Get("User-Agent"))
+		languages = append(languages, req.Header.Get("Accept-Language"))
+	}
+	assert.Equal(t, []string{"Mozilla/5.0", "curl/2.0", "Mozilla/5.0"}, agents)
+	assert.Equal(t, []string{"en", "it", "en"}, languages)
+
+	job.TestCase.Name = "Random"
+	job.TestCase.RotateHeaders = map[string]models.HeaderRotation{"User-Agent": {Values: []string{"a", "b"}, Strategy: models.DataStrategyRandom}}
+	for i := 0; i < 10; i++ {
+		req, err := engine.createRequest(job)
+		require.NoError(t, err)
+		assert.Contains(t, []string{"a", "b"}, req.Header.Get("User-Agent"))
+	}
+}
+
+func TestEngine_createRequest_WithQuery(t *testing.T) {
+	engine := New(1, nil, false)
+	engine.varStore.Set("term", "fish & chips")
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.example.com/search", "https://api.example.com/search?page=2&q=fish+%26+chips"},
+		{"https://api.example.com/search?lang=en", "https://api.example.com/search?lang=en&page=2&q=fish+%26+chips"},
+		{"https://api.example.com/search?", "https://api.example.com/search?page=2&q=fish+%26+chips"},
+		{"https://api.example.com/search#top", "https://api.example.com/search?page=2&q=fish+%26+chips#top"},
+	}
+	for _, tt := range tests {
+		job := Job{
+			Config: &models.Config{},
+			TestCase: models.TestCase{
+				Method: "GET",
+				Query:  map[string]string{"q": "${term}", "page": "2"},
+			},
+			URL: tt.url,
+		}
+		req, err := engine.createRequest(job)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, req.URL.String())
+		assert.Equal(t, "fish & chips", req.URL.Query().Get("q"))
+	}
+
+	job := Job{
+		Config:   &models.Config{Global: models.GlobalConfig{StrictVariables: true}},
+		TestCase: models.TestCase{Method: "GET", Query: map[string]string{"id": "${missing}"}},
+		URL:      "https://api.example.com/items",
+	}
+	_, err := engine.createRequest(job)
+	assert.EqualError(t, err, "unresolved variable ${missing}")
+}
+
+func TestEngine_DebugLogWriter_KeepsSampleInMemory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Debug log",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 60,
+		},
+		Tests: []models.TestCase{
+			{Name: "ping", Method: "GET", Path: "/", ExpectedStatus: []int{200}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	writer, err := debuglog.New(path, 0, 0)
+	require.NoError(t, err)
+
+	engine := New(4, nil, true)
+	engine.SetDebugLogWriter(writer)
+	summary := engine.Run(config)
+	require.NoError(t, writer.Close())
+
+	// 60 requests produce 120 entries (request + response)
+	assert.Len(t, summary.DebugLogs, debugLogMemorySample)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 120, strings.Count(string(data), "\n"))
+}
+
+func TestEngine_Sampling_PerEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Sampling",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 20,
+		},
+		Tests: []models.TestCase{
+			{Name: "first", Method: "GET", Path: "/a", ExpectedStatus: []int{200}},
+			{Name: "second", Method: "GET", Path: "/b", ExpectedStatus: []int{200}},
+		},
+	}
+
+	engine := New(4, nil, true)
+	engine.SetSampling(1, 3)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 40, summary.TotalRequests)
+	perTest := make(map[string]int)
+	for _, log := range summary.DebugLogs {
+		if log.Type == "request" {
+			perTest[log.TestName]++
+		}
+	}
+	assert.Equal(t, map[string]int{"first": 3, "second": 3}, perTest)
+}
+
+func TestEngine_Sampling_Rate(t *testing.T) {
+	engine := New(1, nil, true)
+
+	engine.SetSampling(0.5, 0)
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if engine.shouldSample("t") {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 500, sampled, 100)
+
+	// Out-of-range rates fall back to logging everything
+	engine.SetSampling(0, 0)
+	assert.True(t, engine.shouldSample("t"))
+}
+
+func TestEngine_Logger_DebugEntriesAndWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Logger",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{Name: "missing data", Method: "GET", Path: "/", ExpectedStatus: []int{200}, DataFile: "does-not-exist.csv"},
+		},
+	}
+
+	var buf bytes.Buffer
+	engine := New(1, nil, true)
+	engine.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	engine.Run(config)
+
+	levels := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		levels[entry["level"].(string)] = append(levels[entry["level"].(string)], entry["msg"].(string))
+	}
+
+	assert.Contains(t, levels["WARN"], "failed to load data file")
+	assert.Contains(t, levels["DEBUG"], "request")
+	assert.Contains(t, levels["DEBUG"], "response")
+}
+
+func TestEngine_BuiltinSequenceVariables(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Sequences",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 5,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "create order",
+				Method:         "POST",
+				Path:           "/orders/${counter(order_id)}",
+				ExpectedStatus: []int{200},
+				Body: map[string]interface{}{
+					"order_id":  "${counter(order_id)}",
+					"iteration": "${__iteration}",
+					"vu":        "${__vu}",
+				},
+			},
+		},
+	}
+
+	engine := New(2, nil, false)
+	summary := engine.Run(config)
+	assert.Equal(t, 5, summary.SuccessfulReqs)
+
+	require.Len(t, paths, 5)
+	seenOrders := make(map[string]bool)
+	seenIterations := make(map[float64]bool)
+	for i, path := range paths {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(bodies[i]), &body))
+
+		// The counter is stable within a request and unique across requests
+		assert.Equal(t, fmt.Sprintf("/orders/%v", body["order_id"]), path)
+		assert.False(t, seenOrders[path])
+		seenOrders[path] = true
+
+		iteration := body["iteration"].(float64)
+		assert.False(t, seenIterations[iteration])
+		seenIterations[iteration] = true
+
+		vu := body["vu"].(float64)
+		assert.True(t, vu >= 1 && vu <= 2)
+	}
+	assert.Len(t, seenIterations, 5)
+}
+
+func TestEngine_Scenarios(t *testing.T) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/login" {
+			w.Write([]byte(`{"token": "abc"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Scenarios",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{Name: "browse", Method: "GET", Path: "/products", ExpectedStatus: []int{200}},
+			{
+				Name: "login", Method: "POST", Path: "/login", ExpectedStatus: []int{200},
+				Extract: []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}},
+			},
+			{Name: "checkout", Method: "POST", Path: "/orders/${token}", ExpectedStatus: []int{200}, DependsOn: []string{"login"}},
+		},
+		Scenarios: []models.Scenario{
+			{Name: "browsers", Workers: 4, Iterations: 20, Tests: []string{"browse"}},
+			{Name: "buyers", Workers: 1, Iterations: 3, Tests: []string{"login", "checkout"}},
+		},
+	}
+
+	engine := New(10, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 26, summary.TotalRequests)
+	assert.Equal(t, 26, summary.SuccessfulReqs)
+	assert.Equal(t, 20, counts["/products"])
+	assert.Equal(t, 3, counts["/login"])
+	assert.Equal(t, 3, counts["/orders/abc"])
+
+	require.Len(t, summary.ScenarioResults, 2)
+	assert.Equal(t, 20, summary.ScenarioResults["browsers"].TotalRequests)
+	assert.Equal(t, 6, summary.ScenarioResults["buyers"].TotalRequests)
+	assert.Greater(t, summary.ScenarioResults["browsers"].RequestsPerSec, 0.0)
+}
+
+func TestEngine_Scenarios_DurationAndIterationsRunConcurrently(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Scenarios",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{Name: "soak", Method: "GET", Path: "/soak", ExpectedStatus: []int{200}},
+			{Name: "spike", Method: "GET", Path: "/spike", ExpectedStatus: []int{200}},
+		},
+		Scenarios: []models.Scenario{
+			{Name: "background", Workers: 1, Duration: 200 * time.Millisecond, ThinkTime: 20 * time.Millisecond, Tests: []string{"soak"}},
+			{Name: "burst", Workers: 2, Iterations: 5, Tests: []string{"spike"}},
+		},
+	}
+
+	start := time.Now()
+	summary := New(10, nil, false).Run(config)
+	elapsed := time.Since(start)
+
+	// Both scenarios overlap, so the run takes about as long as the longest one
+	assert.Less(t, elapsed, time.Second)
+	assert.Equal(t, 5, summary.ScenarioResults["burst"].TotalRequests)
+	assert.Greater(t, summary.ScenarioResults["background"].TotalRequests, 1)
+	assert.Less(t, summary.ScenarioResults["background"].TotalRequests, 15)
+}
+
+func TestEngine_StopOn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	run := func(test models.TestCase) (*models.Summary, time.Duration) {
+		test.Name = "hybrid"
+		test.Method = "GET"
+		test.Path = "/"
+		test.ExpectedStatus = []int{200}
+		config := &models.Config{
+			Name:   "Stop On",
+			Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+			Tests:  []models.TestCase{test},
+		}
+		start := time.Now()
+		summary := New(1, nil, false).Run(config)
+		return summary, time.Since(start)
+	}
+
+	t.Run("first stops at the iteration cap", func(t *testing.T) {
+		summary, elapsed := run(models.TestCase{Iterations: 3, Duration: 10 * time.Second, StopOn: models.StopOnFirst})
+		assert.Equal(t, 3, summary.TotalRequests)
+		assert.Less(t, elapsed, 2*time.Second)
+	})
+
+	t.Run("first stops at the duration cap", func(t *testing.T) {
+		summary, elapsed := run(models.TestCase{Iterations: 100000, Duration: 150 * time.Millisecond, StopOn: models.StopOnFirst})
+		assert.Greater(t, summary.TotalRequests, 0)
+		assert.Less(t, summary.TotalRequests, 100)
+		assert.Less(t, elapsed, 2*time.Second)
+	})
+
+	t.Run("both runs past the duration until iterations are done", func(t *testing.T) {
+		summary, _ := run(models.TestCase{Iterations: 20, Duration: time.Millisecond, StopOn: models.StopOnBoth})
+		assert.Equal(t, 20, summary.TotalRequests)
+	})
+
+	t.Run("both runs past the iterations until the duration has elapsed", func(t *testing.T) {
+		summary, elapsed := run(models.TestCase{Iterations: 2, Duration: 150 * time.Millisecond, StopOn: models.StopOnBoth})
+		assert.Greater(t, summary.TotalRequests, 2)
+		assert.GreaterOrEqual(t, elapsed, 150*time.Millisecond)
+	})
+}
+
+func TestEngine_MaxDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "Max Duration",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+		Tests: []models.TestCase{
+			{Name: "slow", Method: "GET", Path: "/", Iterations: 1000, ExpectedStatus: []int{200}},
+		},
+	}
+
+	engine := New(2, nil, false)
+	engine.SetMaxDuration(200 * time.Millisecond)
+
+	start := time.Now()
+	summary := engine.Run(config)
+	elapsed := time.Since(start)
+
+	assert.True(t, summary.MaxDurationReached)
+	assert.False(t, summary.Passed())
+	assert.Less(t, summary.TotalRequests, 1000)
+	assert.Equal(t, 0, summary.FailedReqs)
+	assert.Less(t, elapsed, 2*time.Second)
+}
+
+func TestEngine_Interrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "Interrupted",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+		Tests: []models.TestCase{
+			{Name: "slow", Method: "GET", Path: "/", Iterations: 1000, ExpectedStatus: []int{200}},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	engine := New(2, nil, false)
+	engine.SetContext(ctx)
+	engine.SetMaxDuration(time.Minute)
+
+	start := time.Now()
+	summary := engine.Run(config)
+
+	assert.True(t, summary.Interrupted)
+	assert.False(t, summary.MaxDurationReached)
+	assert.False(t, summary.Passed())
+	assert.Greater(t, summary.TotalRequests, 0)
+	assert.Less(t, summary.TotalRequests, 1000)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestEngine_EventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name:   "Streamed",
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+		Tests: []models.TestCase{
+			{Name: "login", Method: "POST", Path: "/login", Iterations: 1, ExpectedStatus: []int{200}},
+			{Name: "profile", Method: "GET", Path: "/profile", Iterations: 2, ExpectedStatus: []int{200}, DependsOn: []string{"login"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	engine := New(2, nil, false)
+	engine.SetEventStream(stream.New(&buf, 0))
+	summary := engine.Run(config)
+	require.Equal(t, 3, summary.TotalRequests)
+
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event struct {
+			Event string `json:"event"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event.Event)
+	}
+	assert.Equal(t, []string{
+		stream.EventRunStarted, stream.EventRequestFinished, stream.EventRequestFinished,
+		stream.EventRequestFinished, stream.EventRunFinished,
+	}, events)
+}
+
+func TestEngine_WarmPool(t *testing.T) {
+	for _, secure := range []bool{false, true} {
+		var mu sync.Mutex
+		opened := 0
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				mu.Lock()
+				opened++
+				mu.Unlock()
+			}
+		}
+		if secure {
+			server.StartTLS()
+		} else {
+			server.Start()
+		}
+
+		config := &models.Config{
+			Global: models.GlobalConfig{
+				BaseURL:            server.URL,
+				Timeout:            5 * time.Second,
+				InsecureSkipVerify: secure,
+				WarmPool:           &models.WarmPool{Connections: 2},
+			},
+			Tests: []models.TestCase{
+				{Name: "ping", Method: "GET", Path: "/ping", Iterations: 20, ExpectedStatus: []int{200}},
+			},
+		}
+		summary := New(2, nil, false).Run(config)
+		server.Close()
+
+		require.Equal(t, 20, summary.SuccessfulReqs, "secure=%v", secure)
+		// Every request reuses the two connections opened before the run
+		mu.Lock()
+		assert.Equal(t, 2, opened, "secure=%v", secure)
+		mu.Unlock()
+		for _, result := range summary.SlowestRequests {
+			require.NotNil(t, result.Timing)
+			assert.Zero(t, result.Timing.Connect, "secure=%v", secure)
+		}
+	}
+}
+
+func TestEngine_WarmPoolUnreachable(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:  "http://127.0.0.1:1",
+			Timeout:  time.Second,
+			WarmPool: &models.WarmPool{},
+		},
+		Tests: []models.TestCase{
+			{Name: "ping", Method: "GET", Path: "/ping", Iterations: 2, ExpectedStatus: []int{200}},
+		},
+	}
+	summary := New(2, nil, false).Run(config)
+	assert.Equal(t, 2, summary.FailedReqs)
+}
+
+func TestEngine_KeepAlive(t *testing.T) {
+	var mu sync.Mutex
+	opened := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			opened++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	enabled, disabled := false, true
+	tests := []struct {
+		name          string
+		global        bool
+		test          *bool
+		wantPerWorker bool
+	}{
+		{"reused by default", false, nil, true},
+		{"disabled globally", true, nil, false},
+		{"disabled for the test", false, &disabled, false},
+		{"enabled for the test", true, &enabled, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			opened = 0
+			mu.Unlock()
+
+			config := &models.Config{
+				Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, DisableKeepAlive: tt.global},
+				Tests: []models.TestCase{
+					{Name: "ping", Method: "GET", Path: "/ping", Iterations: 10, ExpectedStatus: []int{200}, DisableKeepAlive: tt.test},
+				},
+			}
+			summary := New(2, nil, false).Run(config)
+			require.Equal(t, 10, summary.SuccessfulReqs)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.wantPerWorker {
+				assert.LessOrEqual(t, opened, 2)
+			} else {
+				assert.Equal(t, 10, opened)
+			}
+		})
+	}
+}
+
+func TestEngine_Compression(t *testing.T) {
+	payload := []byte(`{"items": "` + strings.Repeat("bombardino ", 200) + `"}`)
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.Header.Get("Accept-Encoding"))
+		mu.Unlock()
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write(payload)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(payload)
+		gz.Close()
+	}))
+	defer server.Close()
+
+	disabled := true
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+		Tests: []models.TestCase{
+			{Name: "gzip", Method: "GET", Path: "/", Iterations: 2, ExpectedStatus: []int{200},
+				Assertions: []models.Assertion{{Type: "json_path", Target: "items", Operator: "contains", Value: "bombardino"}}},
+			{Name: "identity", Method: "GET", Path: "/", Iterations: 1, ExpectedStatus: []int{200}, DisableCompression: &disabled},
+			{Name: "br", Method: "GET", Path: "/", Iterations: 1, ExpectedStatus: []int{200}, AcceptEncoding: "br"},
+		},
+	}
+	summary := New(1, nil, false).Run(config)
+	require.Equal(t, 4, summary.SuccessfulReqs)
+	assert.ElementsMatch(t, []string{"gzip", "gzip", "identity", "br"}, requested)
+
+	gzipped := summary.EndpointResults["gzip"].Transfer
+	assert.Equal(t, 2, gzipped.Compressed)
+	assert.Equal(t, int64(2*len(payload)), gzipped.BytesDecoded)
+	assert.Less(t, gzipped.BytesCompressed, gzipped.BytesDecoded)
+	assert.Equal(t, gzipped.BytesCompressed, gzipped.BytesReceived)
+	assert.Zero(t, summary.EndpointResults["identity"].Transfer.Compressed)
+	assert.Equal(t, int64(len(payload)), summary.EndpointResults["identity"].Transfer.BytesReceived)
+	assert.Equal(t, 2, summary.Transfer.Compressed)
+}
+
+func TestEngine_RequestIDHeader(t *testing.T) {
+	var mu sync.Mutex
+	ids := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/payments" {
+			ids["key"] = append(ids["key"], r.Header.Get("Idempotency-Key"))
+			if len(ids["key"]) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		} else {
+			ids["id"] = append(ids["id"], r.Header.Get("X-Request-ID"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, RequestIDHeader: "X-Request-ID"},
+		Tests: []models.TestCase{
+			{Name: "Health", Method: "GET", Path: "/health", Iterations: 3, ExpectedStatus: []int{200}},
+			{Name: "Pay", Method: "POST", Path: "/payments", Iterations: 1, ExpectedStatus: []int{200},
+				RequestIDHeader: "Idempotency-Key", Throttle: &models.ThrottleConfig{MaxRetries: 1}},
+		},
+	}
+	summary := New(1, nil, false).Run(config)
+	require.Equal(t, 4, summary.SuccessfulReqs)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, ids["id"], 3)
+	assert.Len(t, uniqueSorted(ids["id"]), 3, "every request gets its own ID")
+	for _, id := range ids["id"] {
+		assert.Len(t, id, 36)
+	}
+	require.Len(t, ids["key"], 2)
+	assert.Equal(t, ids["key"][0], ids["key"][1], "a retry sends the same Idempotency-Key")
+
+	requestIDs := make(map[string]bool)
+	for _, result := range summary.SlowestRequests {
+		requestIDs[result.RequestID] = true
+	}
+	assert.True(t, requestIDs[ids["key"][0]], "results carry the request ID")
+}
+
+func TestEngine_RunIDHeader(t *testing.T) {
+	var mu sync.Mutex
+	var runs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, r.Header.Get("X-Load-Test-Run"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 2, RunIDHeader: "X-Load-Test-Run"},
+		Tests: []models.TestCase{
+			{Name: "Health", Method: "GET", Path: "/health", ExpectedStatus: []int{200}},
+			{Name: "Compared", Method: "GET", Path: "/v1", ExpectedStatus: []int{200}, CompareWith: &models.CompareConfig{Endpoint: server.URL}},
+			{Name: "Own", Method: "GET", Path: "/own", ExpectedStatus: []int{200}, Headers: map[string]string{"X-Load-Test-Run": "custom"}},
+		},
+	}
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+	require.Equal(t, 6, summary.SuccessfulReqs)
+	assert.Len(t, summary.RunID, 36)
+	assert.Equal(t, engine.RunID(), summary.RunID)
+
+	mu.Lock()
+	require.Len(t, runs, 8, "compared requests included")
+	assert.ElementsMatch(t, []string{"custom", summary.RunID}, uniqueSorted(runs))
+	mu.Unlock()
+
+	other := New(1, nil, false)
+	other.SetRunID("ci-job-42")
+	config.Global.RunIDHeader = ""
+	assert.Equal(t, "ci-job-42", other.Run(config).RunID)
+	assert.NotEqual(t, summary.RunID, New(1, nil, false).RunID())
+}
+
+func TestEngine_ResponseCache(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		count := hits[r.URL.Path]
+		mu.Unlock()
+		if r.URL.Path == "/flaky" && count == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Hit", fmt.Sprintf("%d", count))
+		w.Write([]byte(`{"feature": "on"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 5,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Config",
+				Method:         "GET",
+				Path:           "/config",
+				ExpectedStatus: []int{200},
+				Cache:          true,
+				Assertions:     []models.Assertion{{Type: "json_path", Target: "feature", Operator: "eq", Value: "on"}},
+			},
+			{Name: "Flaky", Method: "GET", Path: "/flaky", ExpectedStatus: []int{200}, Cache: true},
+			{Name: "Uncached", Method: "GET", Path: "/uncached", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	assert.Equal(t, 1, hits["/config"])
+	assert.Equal(t, 2, hits["/flaky"], "failed responses are not cached")
+	assert.Equal(t, 5, hits["/uncached"])
+
+	cfg := summary.EndpointResults["Config"]
+	assert.Equal(t, 5, cfg.SuccessfulReqs)
+	assert.Equal(t, 4, cfg.CachedReqs)
+	assert.Equal(t, 5, cfg.AssertionsPassed, "assertions run on cached responses")
+	assert.Equal(t, 3, summary.EndpointResults["Flaky"].CachedReqs)
+	assert.Equal(t, 0, summary.EndpointResults["Uncached"].CachedReqs)
+
+	// A request ID differs on every request, so it is not part of the key
+	config.Global.RequestIDHeader = "X-Request-ID"
+	config.Tests = []models.TestCase{{Name: "Traced", Method: "GET", Path: "/traced", ExpectedStatus: []int{200}, Cache: true}}
+	summary = New(1, nil, false).Run(config)
+
+	assert.Equal(t, 1, hits["/traced"])
+	assert.Equal(t, 4, summary.EndpointResults["Traced"].CachedReqs)
+}
+
+func TestRequestCacheKey(t *testing.T) {
+	newRequest := func(method, url, body string, headers map[string]string) *http.Request {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+
+	key := requestCacheKey(newRequest("POST", "http://api/x", `{"a":1}`, map[string]string{"A": "1", "B": "2"}), "")
+	assert.Equal(t, key, requestCacheKey(newRequest("POST", "http://api/x", `{"a":1}`, map[string]string{"B": "2", "A": "1"}), ""))
+	assert.NotEqual(t, key, requestCacheKey(newRequest("PUT", "http://api/x", `{"a":1}`, map[string]string{"A": "1", "B": "2"}), ""))
+	assert.NotEqual(t, key, requestCacheKey(newRequest("POST", "http://api/y", `{"a":1}`, map[string]string{"A": "1", "B": "2"}), ""))
+	assert.NotEqual(t, key, requestCacheKey(newRequest("POST", "http://api/x", `{"a":2}`, map[string]string{"A": "1", "B": "2"}), ""))
+	assert.NotEqual(t, key, requestCacheKey(newRequest("POST", "http://api/x", `{"a":1}`, map[string]string{"A": "1", "B": "3"}), ""))
+
+	traced := requestCacheKey(newRequest("GET", "http://api/x", "", map[string]string{"X-Request-Id": "a"}), "x-request-id")
+	assert.Equal(t, traced, requestCacheKey(newRequest("GET", "http://api/x", "", map[string]string{"X-Request-Id": "b"}), "x-request-id"))
+}
+
+func TestEngine_TransferStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 4,
+		},
+		Tests: []models.TestCase{
+			{Name: "Upload", Method: "POST", Path: "/upload", ExpectedStatus: []int{200}, Body: map[string]interface{}{"name": "abc"}},
+			{Name: "Download", Method: "GET", Path: "/download", ExpectedStatus: []int{200}, Cache: true},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	upload := summary.EndpointResults["Upload"].Transfer
+	assert.Equal(t, 4, upload.Requests)
+	assert.Equal(t, int64(4*len(`{"name":"abc"}`)), upload.BytesSent)
+	assert.Equal(t, int64(4000), upload.BytesReceived)
+
+	download := summary.EndpointResults["Download"].Transfer
+	assert.Equal(t, 1, download.Requests, "cached responses are not transferred")
+	assert.Equal(t, int64(1000), download.BytesReceived)
+
+	assert.Equal(t, 5, summary.Transfer.Requests)
+	assert.Equal(t, int64(5000), summary.Transfer.BytesReceived)
+	assert.Equal(t, 1000.0, summary.Transfer.AvgResponseSize())
+}
+
+func TestEngine_StatusClassLatency(t *testing.T) {
+	var mu sync.Mutex
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		n := count
+		mu.Unlock()
+		if n%2 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 4,
+		},
+		Tests: []models.TestCase{
+			{Name: "Mixed", Method: "GET", Path: "/mixed", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	latencies := summary.EndpointResults["Mixed"].StatusClassLatency
+	require.Len(t, latencies, 2)
+	assert.Equal(t, 2, latencies["2xx"].Requests)
+	assert.Equal(t, 2, latencies["5xx"].Requests)
+	assert.GreaterOrEqual(t, latencies["2xx"].P50ResponseTime, 30*time.Millisecond)
+	assert.Less(t, latencies["5xx"].P99ResponseTime, latencies["2xx"].P50ResponseTime)
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	histogram := latencyHistogram([]time.Duration{
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		99 * time.Millisecond,
+		100 * time.Millisecond,
+		5 * time.Second,
+		time.Minute,
+	})
+
+	require.Len(t, histogram, len(latencyBucketBounds)+1)
+	counts := make(map[time.Duration]int)
+	total := 0
+	for _, bucket := range histogram {
+		counts[bucket.UpperBound] = bucket.Count
+		total += bucket.Count
+	}
+	assert.Equal(t, 6, total)
+	assert.Equal(t, 1, counts[10*time.Millisecond])
+	assert.Equal(t, 1, counts[25*time.Millisecond])
+	assert.Equal(t, 1, counts[100*time.Millisecond])
+	assert.Equal(t, 1, counts[250*time.Millisecond])
+	assert.Equal(t, time.Duration(0), histogram[len(histogram)-1].UpperBound)
+	assert.Equal(t, 2, histogram[len(histogram)-1].Count)
+}
+
+func TestCalculateSteadyState(t *testing.T) {
+	start := time.Now()
+	var results []models.TestResult
+	// Ten 1s windows: the P95 falls during the first four, then stays flat
+	latencies := []time.Duration{300, 200, 120, 100, 100, 100, 100, 100, 100, 100}
+	for window, latency := range latencies {
+		for i := 0; i < 10; i++ {
+			results = append(results, models.TestResult{
+				Timestamp:    start.Add(time.Duration(window)*time.Second + time.Duration(i)*100*time.Millisecond),
+				ResponseTime: latency * time.Millisecond,
+				Success:      window > 0,
+			})
+		}
+	}
+	results = append(results, models.TestResult{Timestamp: start, Skipped: true})
+
+	assert.Nil(t, calculateSteadyState(results, nil))
+
+	steady := calculateSteadyState(results, &models.SteadyStateConfig{Window: time.Second, Tolerance: 10, StableWindows: 3})
+	require.True(t, steady.Reached)
+	assert.Equal(t, 4*time.Second, steady.Start)
+	assert.Equal(t, 40, steady.Ramp.Requests)
+	assert.Equal(t, 10, steady.Ramp.FailedReqs)
+	assert.Equal(t, 300*time.Millisecond, steady.Ramp.P99ResponseTime)
+	assert.Equal(t, 60, steady.Steady.Requests)
+	assert.Equal(t, 0, steady.Steady.FailedReqs)
+	assert.Equal(t, 100*time.Millisecond, steady.Steady.P95ResponseTime)
+	assert.Equal(t, 6*time.Second, steady.Steady.Duration)
+	assert.InDelta(t, 10, steady.Steady.RequestsPerSec, 0.01)
+
+	notReached := calculateSteadyState(results, &models.SteadyStateConfig{Window: time.Second, Tolerance: 10, StableWindows: 7})
+	assert.False(t, notReached.Reached)
+	assert.Equal(t, 100, notReached.Ramp.Requests)
+	assert.Zero(t, notReached.Steady.Requests)
+}
+
+func TestEngine_InjectFault(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 3,
+			Inject:     &models.InjectConfig{DropRate: 1},
+		},
+		Tests: []models.TestCase{
+			{Name: "Dropped", Method: "GET", Path: "/dropped", ExpectedStatus: []int{200}},
+			{Name: "Slow", Method: "GET", Path: "/slow", ExpectedStatus: []int{200}, Inject: &models.InjectConfig{Latency: 50 * time.Millisecond}},
+		},
+	}
+
+	summary := New(2, nil, false).Run(config)
+
+	dropped := summary.EndpointResults["Dropped"]
+	assert.Equal(t, 0, hits["/dropped"])
+	assert.Equal(t, 3, dropped.FailedReqs)
+	assert.Equal(t, 3, dropped.ErrorCategories[ErrorInjected])
+
+	slow := summary.EndpointResults["Slow"]
+	assert.Equal(t, 3, hits["/slow"], "the test inject replaces the global one")
+	assert.Equal(t, 3, slow.SuccessfulReqs)
+	assert.GreaterOrEqual(t, slow.AvgResponseTime, 50*time.Millisecond)
+}
+
+func TestInjectedLatency(t *testing.T) {
+	e := New(1, nil, false)
+	assert.Equal(t, 200*time.Millisecond, e.injectedLatency(&models.InjectConfig{Latency: 200 * time.Millisecond}))
+
+	for i := 0; i < 100; i++ {
+		delay := e.injectedLatency(&models.InjectConfig{Latency: 200 * time.Millisecond, Jitter: 50 * time.Millisecond})
+		assert.GreaterOrEqual(t, delay, 150*time.Millisecond)
+		assert.LessOrEqual(t, delay, 250*time.Millisecond)
+
+		assert.GreaterOrEqual(t, e.injectedLatency(&models.InjectConfig{Latency: 10 * time.Millisecond, Jitter: time.Second}), time.Duration(0))
+	}
+}
+
+func TestEngine_Comparison_PerTestSettings(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fmt.Sprintf(`{"id": 1, "timestamp": %d}`, time.Now().UnixNano())))
+	}))
+	defer primary.Close()
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fmt.Sprintf(`{"id": 1, "timestamp": %d, "version": 2}`, time.Now().UnixNano())))
+	}))
+	defer candidate.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: primary.URL, Timeout: 5 * time.Second, Iterations: 10},
+		Tests: []models.TestCase{
+			{
+				Name: "Partial", Method: "GET", Path: "/", ExpectedStatus: []int{200},
+				CompareWith: &models.CompareConfig{Endpoint: candidate.URL, Mode: "partial", IgnoreFields: []string{"timestamp"}},
+			},
+			{
+				Name: "Full", Method: "GET", Path: "/", ExpectedStatus: []int{200},
+				CompareWith: &models.CompareConfig{Endpoint: candidate.URL, IgnoreFields: []string{"timestamp"}},
+			},
+		},
+	}
+
+	summary := New(4, nil, false).Run(config)
+
+	assert.Equal(t, 10, summary.EndpointResults["Partial"].ComparisonsPassed)
+	assert.Equal(t, 10, summary.EndpointResults["Full"].ComparisonsFailed, "version only exists in the candidate")
+}
+
+func TestEngine_Throttle(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/recovers":
+			if n <= 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		case "/limited":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:  server.URL,
+			Timeout:  5 * time.Second,
+			Throttle: &models.ThrottleConfig{MaxRetries: 3, MaxWait: time.Second},
+		},
+		Tests: []models.TestCase{
+			{Name: "Recovers", Method: "GET", Path: "/recovers", Iterations: 1, ExpectedStatus: []int{200}},
+			{Name: "Limited", Method: "GET", Path: "/limited", Iterations: 1, ExpectedStatus: []int{200}, Throttle: &models.ThrottleConfig{MaxRetries: 1}},
+			{Name: "Unavailable", Method: "GET", Path: "/unavailable", Iterations: 1, ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	recovers := summary.EndpointResults["Recovers"]
+	assert.Equal(t, 3, hits["/recovers"])
+	assert.Equal(t, 1, recovers.SuccessfulReqs)
+
+	limited := summary.EndpointResults["Limited"]
+	assert.Equal(t, 2, hits["/limited"], "the test throttle replaces the global one")
+	assert.Equal(t, 1, limited.ThrottledReqs)
+	assert.Equal(t, 0, limited.FailedReqs)
+	assert.True(t, limited.Passed())
+
+	unavailable := summary.EndpointResults["Unavailable"]
+	assert.Equal(t, 1, hits["/unavailable"], "a 503 without Retry-After is not throttled")
+	assert.Equal(t, 1, unavailable.FailedReqs)
+
+	assert.Equal(t, 1, summary.ThrottledReqs)
+	assert.Equal(t, 3, summary.ThrottleRetries)
+	assert.Equal(t, 1, summary.FailedReqs)
+}
+
+func TestEngine_ThrottleDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second},
+		Tests: []models.TestCase{
+			{Name: "Limited", Method: "GET", Path: "/limited", Iterations: 2, ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+	assert.Equal(t, 2, summary.FailedReqs)
+	assert.Equal(t, 0, summary.ThrottledReqs)
+}
+
+func TestThrottleWait(t *testing.T) {
+	assert.Equal(t, 5*time.Second, throttleWait(5*time.Second, 0, 30*time.Second))
+	assert.Equal(t, time.Duration(0), throttleWait(0, 2, 30*time.Second))
+	assert.Equal(t, time.Second, throttleWait(-1, 0, 30*time.Second))
+	assert.Equal(t, 4*time.Second, throttleWait(-1, 2, 30*time.Second))
+	assert.Equal(t, 10*time.Second, throttleWait(-1, 5, 10*time.Second))
+	assert.Equal(t, 10*time.Second, throttleWait(time.Minute, 0, 10*time.Second))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", -1},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", -1},
+		{"soon", -1},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
+
+func TestEngine_SuccessWhen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"state":"queued"}`))
+		case "/embedded-error":
+			w.Write([]byte(`{"state":"error","message":"quota exceeded"}`))
+		default:
+			w.Write([]byte(`{"state":"done"}`))
+		}
+	}))
+	defer server.Close()
+
+	condition := `status in [200, 202] && json(body).state != 'error'`
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 2},
+		Tests: []models.TestCase{
+			{Name: "Done", Method: "GET", Path: "/done", SuccessWhen: condition},
+			{Name: "Accepted", Method: "GET", Path: "/accepted", ExpectedStatus: []int{200}, SuccessWhen: condition},
+			{Name: "Embedded error", Method: "GET", Path: "/embedded-error", ExpectedStatus: []int{200}, SuccessWhen: condition},
+			{Name: "Not a condition", Method: "GET", Path: "/done", SuccessWhen: `json(body).state`},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	assert.Equal(t, 2, summary.EndpointResults["Done"].SuccessfulReqs)
+	assert.Equal(t, 2, summary.EndpointResults["Accepted"].SuccessfulReqs, "success_when replaces expected_status")
+
+	embedded := summary.EndpointResults["Embedded error"]
+	assert.Equal(t, 2, embedded.FailedReqs)
+	assert.Equal(t, 2, embedded.ErrorCategories[ErrorSuccessWhen])
+	assert.Contains(t, embedded.Errors[0], "success_when not met")
+
+	invalid := summary.EndpointResults["Not a condition"]
+	assert.Equal(t, 2, invalid.FailedReqs)
+	assert.Contains(t, invalid.Errors[0], "expected a condition")
+}
+
+func TestEngine_AssertVariables(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"total":0,"token":"eyJhbGciOi"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 1},
+		Tests: []models.TestCase{{
+			Name: "Order", Method: "GET", Path: "/order", ExpectedStatus: []int{200},
+			Extract: []models.ExtractionRule{
+				{Name: "order_total", Source: "body", Path: "total"},
+				{Name: "token", Source: "body", Path: "token"},
+			},
+			AssertVariables: []string{`${token} matches "^ey"`, `${order_total} gt 0`},
+		}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	result := summary.EndpointResults["Order"]
+	assert.Equal(t, 1, result.FailedReqs)
+	assert.Equal(t, 1, result.ErrorCategories[ErrorAssertion])
+	require.Len(t, result.Assertions, 2)
+	assert.Equal(t, 1, result.Assertions[0].Passed)
+	assert.Equal(t, 1, result.Assertions[1].Failed)
+	assert.Equal(t, "variable", result.Assertions[1].Assertion.Type)
+}
+
+func TestEngine_AssertVariables_Redacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token":"SUPERSECRETTOKEN123"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Redact:     &models.RedactConfig{Variables: []string{"token"}},
+		},
+		Tests: []models.TestCase{{
+			Name: "Login", Method: "GET", Path: "/login", ExpectedStatus: []int{200},
+			Extract:         []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}},
+			AssertVariables: []string{`${token} matches "^zz"`, `${token | lower} eq x`},
+		}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	result := summary.EndpointResults["Login"]
+	require.Len(t, result.Assertions, 2)
+	assert.Equal(t, []string{"variable assertion failed: ${token} matches ^zz, got ***"}, result.Assertions[0].Messages)
+	require.NotEmpty(t, result.Assertions[1].Messages)
+	assert.NotContains(t, strings.ToLower(result.Assertions[1].Messages[0]), "supersecrettoken123")
+}
+
+func TestEngine_SOAP(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetUserResponse><Name>Tom &amp; Jerry</Name></GetUserResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 1},
+		Tests: []models.TestCase{
+			{
+				Name: "SOAP 1.1", Method: "POST", Path: "/users", ExpectedStatus: []int{200},
+				SOAP: &models.SOAPRequest{
+					Action: "http://example.com/GetUser",
+					Header: `<auth:Token xmlns:auth="http://example.com/auth">secret</auth:Token>`,
+					Body:   `<GetUser xmlns="http://example.com/users"><Name>${name | xmlescape}</Name></GetUser>`,
+				},
+				Assertions: []models.Assertion{{Type: "xpath", Target: "//GetUserResponse/Name", Operator: "eq", Value: "Tom & Jerry"}},
+			},
+			{
+				Name: "SOAP 1.2", Method: "POST", Path: "/users", ExpectedStatus: []int{200}, DependsOn: []string{"SOAP 1.1"},
+				SOAP: &models.SOAPRequest{Version: models.SOAP12, Action: "http://example.com/GetUser", Body: `<GetUser/>`},
+			},
+		},
+	}
+
+	e := New(1, nil, false)
+	e.varStore.Set("name", "Tom & Jerry")
+	summary := e.Run(config)
+
+	require.Equal(t, 2, summary.SuccessfulReqs)
+	require.Len(t, requests, 2)
+
+	assert.Equal(t, "text/xml; charset=utf-8", requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, `"http://example.com/GetUser"`, requests[0].Header.Get("SOAPAction"))
+	assert.Contains(t, bodies[0], `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">`)
+	assert.Contains(t, bodies[0], `<soap:Header><auth:Token xmlns:auth="http://example.com/auth">secret</auth:Token></soap:Header>`)
+	assert.Contains(t, bodies[0], `<Name>Tom &amp; Jerry</Name>`)
+
+	assert.Equal(t, `application/soap+xml; charset=utf-8; action="http://example.com/GetUser"`, requests[1].Header.Get("Content-Type"))
+	assert.Empty(t, requests[1].Header.Get("SOAPAction"))
+	assert.Contains(t, bodies[1], "http://www.w3.org/2003/05/soap-envelope")
+}
+
+func TestEngine_SSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		events := 3
+		if r.URL.Path == "/quiet" {
+			events = 0
+		}
+		for i := 1; i <= 5; i++ {
+			if i <= events {
+				fmt.Fprintf(w, ": keep-alive\nevent: tick\nid: %d\ndata: {\"seq\": %d,\ndata: \"state\": \"ok\"}\n\n", i, i)
+			}
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 2},
+		Tests: []models.TestCase{
+			{
+				Name: "Ticks", Method: "GET", Path: "/ticks", ExpectedStatus: []int{200},
+				SSE: &models.SSEConfig{Window: 2 * time.Second, MinEvents: 1, MaxEvents: 3},
+				Assertions: []models.Assertion{
+					{Type: "sse_events", Operator: "eq", Value: 3.0},
+					{Type: "json_path", Target: "seq", Operator: "eq", Value: 3.0},
+					{Type: "sse_first_event", Operator: "lt", Value: "1s"},
+				},
+			},
+			{
+				Name: "Quiet", Method: "GET", Path: "/quiet", ExpectedStatus: []int{200},
+				SSE: &models.SSEConfig{Window: 200 * time.Millisecond, MinEvents: 1},
+			},
+		},
+	}
+
+	start := time.Now()
+	summary := New(2, nil, false).Run(config)
+	assert.Less(t, time.Since(start), 2*time.Second, "max_events ends the stream before the window")
+
+	ticks := summary.EndpointResults["Ticks"]
+	assert.Equal(t, 2, ticks.SuccessfulReqs)
+	assert.Equal(t, 6, ticks.AssertionsPassed)
+	assert.Equal(t, 2, ticks.SSE.Streams)
+	assert.Equal(t, 6, ticks.SSE.Events)
+	assert.Greater(t, ticks.SSE.AvgInterval, time.Duration(0))
+	assert.GreaterOrEqual(t, ticks.SSE.MaxInterval, ticks.SSE.P95Interval)
+	assert.Less(t, ticks.AvgResponseTime, time.Second, "the response time of a stream is its time to the first event")
+
+	quiet := summary.EndpointResults["Quiet"]
+	assert.Equal(t, 2, quiet.FailedReqs)
+	assert.Equal(t, 2, quiet.ErrorCategories[ErrorSSE])
+	assert.Equal(t, 0, quiet.SSE.Events)
+}
+
+func TestSSEStats(t *testing.T) {
+	stats := sseStats([]*models.SSEResult{
+		{Events: 3, FirstEvent: 10 * time.Millisecond, Intervals: []time.Duration{100 * time.Millisecond, 300 * time.Millisecond}},
+		{Events: 0},
+		{Events: 2, FirstEvent: 30 * time.Millisecond, Intervals: []time.Duration{200 * time.Millisecond}},
+	})
+	assert.Equal(t, models.SSEStats{
+		Streams:       3,
+		Events:        5,
+		AvgFirstEvent: 20 * time.Millisecond,
+		AvgInterval:   200 * time.Millisecond,
+		P95Interval:   calculatePercentile([]time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, 95),
+		MaxInterval:   300 * time.Millisecond,
+	}, stats)
+}
+
+func TestEngine_Socket(t *testing.T) {
+	// TCP server answering each line with its uppercase, and closing the
+	// connection on QUIT without answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || line == "QUIT\n" {
+					return
+				}
+				conn.Write([]byte(strings.ToUpper(line) + "trailing"))
+			}()
+		}
+	}()
+
+	// UDP server echoing datagrams
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer udp.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			udp.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{Timeout: 2 * time.Second, Iterations: 2},
+		Tests: []models.TestCase{
+			{
+				Name:        "tcp",
+				Socket:      &models.SocketConfig{Protocol: models.SocketTCP, Address: listener.Addr().String(), Payload: "ping ${name}\n", Delimiter: "\n"},
+				SuccessWhen: `body contains "PING BOMBARDINO"`,
+				Assertions:  []models.Assertion{{Type: "body_size", Operator: "eq", Value: 16.0}},
+			},
+			{
+				Name:   "udp",
+				Socket: &models.SocketConfig{Protocol: models.SocketUDP, Address: udp.LocalAddr().String(), PayloadHex: "cafe0102", ReadBytes: 4},
+			},
+			{
+				Name:   "closed",
+				Socket: &models.SocketConfig{Protocol: models.SocketTCP, Address: listener.Addr().String(), Payload: "QUIT\n", Delimiter: "\n"},
+			},
+		},
+	}
+
+	e := New(2, nil, false)
+	e.varStore.Set("name", "bombardino")
+	summary := e.Run(config)
+
+	tcp := summary.EndpointResults["tcp"]
+	require.NotNil(t, tcp)
+	assert.Equal(t, 2, tcp.SuccessfulReqs, tcp.Errors)
+	assert.Equal(t, int64(32), tcp.Transfer.BytesReceived)
+	assert.Equal(t, 2, summary.EndpointResults["udp"].SuccessfulReqs)
+	assert.Equal(t, int64(8), summary.EndpointResults["udp"].Transfer.BytesSent)
+
+	closed := summary.EndpointResults["closed"]
+	assert.Equal(t, 2, closed.FailedReqs)
+	assert.Equal(t, 2, closed.ErrorCategories[ErrorRead])
+}
+
+func TestEngine_MessagingUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{Timeout: 2 * time.Second, Iterations: 1},
+		Tests: []models.TestCase{
+			{Name: "orders", Messaging: &models.MessagingConfig{URL: "kafka://" + addr, Topic: "orders", Payload: map[string]interface{}{"id": 1}}},
+			{Name: "jobs", Messaging: &models.MessagingConfig{URL: "amqp://loader:s3cret@" + addr + "/prod", RoutingKey: "jobs", Payload: "run"}},
+		},
+	}
+
+	summary := New(2, nil, false).Run(config)
+
+	orders := summary.EndpointResults["orders"]
+	assert.Equal(t, "kafka://"+addr+"/orders", orders.URL)
+	assert.Equal(t, 1, orders.ErrorCategories[ErrorConnectionRefused])
+
+	jobs := summary.EndpointResults["jobs"]
+	assert.Equal(t, "amqp://"+addr+"/prod", jobs.URL)
+	assert.Equal(t, 1, jobs.ErrorCategories[ErrorConnectionRefused])
+}
+
+func TestEngine_SQLUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{Timeout: 2 * time.Second, Iterations: 1},
+		Tests: []models.TestCase{
+			{Name: "orders", SQL: &models.SQLConfig{DSN: "postgres://app:s3cret@" + addr + "/shop?sslmode=disable", Query: "SELECT * FROM orders WHERE id = $1", Params: []interface{}{"${id}"}}},
+			{Name: "users", SQL: &models.SQLConfig{DSN: "mysql://app:s3cret@" + addr + "/shop", Query: "SELECT 1"}},
+		},
+	}
+
+	summary := New(2, nil, false).Run(config)
+
+	orders := summary.EndpointResults["orders"]
+	assert.Equal(t, "postgres://"+addr+"/shop?sslmode=disable", orders.URL)
+	assert.Equal(t, 1, orders.ErrorCategories[ErrorConnectionRefused])
+	assert.Zero(t, orders.Rows.Queries)
+
+	users := summary.EndpointResults["users"]
+	assert.Equal(t, "mysql://"+addr+"/shop", users.URL)
+	assert.Equal(t, 1, users.ErrorCategories[ErrorConnectionRefused])
+}
+
+func TestEngine_WaitReady(t *testing.T) {
+	var checks atomic.Int32
+	var lastPath atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath.Store(r.URL.Path)
+		assert.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		if checks.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:   server.URL + "/api",
+			Timeout:   time.Second,
+			Headers:   models.Headers{"Authorization": "Bearer ${token}"},
+			Variables: map[string]interface{}{"token": "s3cret"},
+			Readiness: &models.ReadinessConfig{URL: server.URL + "/health", ExpectedStatus: []int{200, 204}, Timeout: 5 * time.Second, Interval: 10 * time.Millisecond},
+		},
+	}
+	require.NoError(t, New(1, nil, false).WaitReady(config))
+	assert.Equal(t, int32(3), checks.Load())
+	assert.Equal(t, "/health", lastPath.Load())
+
+	// A target that never becomes ready fails with the last problem seen
+	config.Global.Readiness = &models.ReadinessConfig{URL: "/health", ExpectedStatus: []int{418}, Timeout: 100 * time.Millisecond, Interval: 20 * time.Millisecond}
+	err := New(1, nil, false).WaitReady(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), server.URL+"/api/health not ready after 100ms")
+	assert.Contains(t, err.Error(), "status 204, expected [418]")
+	assert.Equal(t, "/api/health", lastPath.Load())
+
+	config.Global.Readiness = nil
+	assert.NoError(t, New(1, nil, false).WaitReady(config))
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(4)
+	reserve := func(now time.Time) time.Time {
+		slot, _ := limiter.reserve(now)
+		return slot
+	}
+	now := time.Now()
+	slot, lag := limiter.reserve(now)
+	assert.Equal(t, now, slot)
+	assert.Zero(t, lag, "the first request is never late")
+	assert.Equal(t, now.Add(250*time.Millisecond), reserve(now))
+	assert.Equal(t, now.Add(500*time.Millisecond), reserve(now.Add(100*time.Millisecond)))
+
+	// Idle time is not saved up for bursts, but counts as schedule lag
+	later := now.Add(5 * time.Second)
+	slot, lag = limiter.reserve(later)
+	assert.Equal(t, later, slot)
+	assert.Equal(t, 5*time.Second-750*time.Millisecond, lag)
+	assert.Equal(t, later.Add(250*time.Millisecond), reserve(later))
+}
+
+func TestCalculateScheduleLag(t *testing.T) {
+	assert.Nil(t, calculateScheduleLag([]models.TestResult{{ResponseTime: time.Second}}))
+
+	var results []models.TestResult
+	for i := 0; i < 8; i++ {
+		results = append(results, models.TestResult{ResponseTime: 10 * time.Millisecond})
+	}
+	results = append(results,
+		models.TestResult{ResponseTime: 10 * time.Millisecond, ScheduleLag: 90 * time.Millisecond},
+		models.TestResult{ResponseTime: 10 * time.Millisecond, ScheduleLag: 490 * time.Millisecond},
+		models.TestResult{Skipped: true, ScheduleLag: time.Second},
+	)
+
+	lag := calculateScheduleLag(results)
+	require.NotNil(t, lag)
+	assert.Equal(t, 2, lag.LateRequests)
+	assert.Equal(t, 290*time.Millisecond, lag.AvgLag)
+	assert.Equal(t, 490*time.Millisecond, lag.MaxLag)
+	assert.Equal(t, 10, lag.Corrected.Requests)
+	assert.Equal(t, 68*time.Millisecond, lag.Corrected.AvgResponseTime)
+	assert.Equal(t, 10*time.Millisecond, lag.Corrected.P50ResponseTime)
+	assert.Equal(t, 100*time.Millisecond, lag.Corrected.P95ResponseTime)
+}
+
+func TestPacingSchedule(t *testing.T) {
+	var schedule pacingSchedule
+	start := time.Now()
+	assert.Zero(t, schedule.lag(start))
+
+	schedule.next(start, 100*time.Millisecond)
+	assert.Zero(t, schedule.lag(start.Add(100*time.Millisecond)), "on time")
+	assert.Equal(t, 150*time.Millisecond, schedule.lag(start.Add(250*time.Millisecond)))
+
+	schedule.next(start, 0)
+	assert.Zero(t, schedule.lag(start.Add(time.Hour)), "no pacing, no schedule")
+}
+
+func TestGeneratorMonitor(t *testing.T) {
+	monitor := startGeneratorMonitor()
+	time.Sleep(10 * time.Millisecond)
+	summary := monitor.stop()
+
+	assert.Equal(t, runtime.GOMAXPROCS(0), summary.CPUs)
+	assert.Positive(t, summary.MaxGoroutines)
+	assert.Positive(t, summary.MaxHeapBytes)
+	assert.GreaterOrEqual(t, summary.MaxMemoryBytes, summary.MaxHeapBytes)
+	if runtime.GOOS == "linux" {
+		assert.Positive(t, summary.MaxOpenFiles)
+		assert.Positive(t, summary.OpenFilesLimit)
+	}
+}
+
+func TestGeneratorWarnings(t *testing.T) {
+	healthy := &models.GeneratorSummary{
+		CPUs:               4,
+		MaxCPUPercent:      40,
+		GCPauseTotal:       10 * time.Millisecond,
+		MaxGCPause:         time.Millisecond,
+		MaxOpenFiles:       100,
+		OpenFilesLimit:     1024,
+		MaxEphemeralPorts:  500,
+		EphemeralPortRange: 28232,
+	}
+	assert.Empty(t, generatorWarnings(healthy, time.Minute))
+
+	saturated := &models.GeneratorSummary{
+		CPUs:               4,
+		MaxCPUPercent:      98,
+		GCPauseTotal:       6 * time.Second,
+		MaxOpenFiles:       1000,
+		OpenFilesLimit:     1024,
+		MaxEphemeralPorts:  27000,
+		EphemeralPortRange: 28232,
+	}
+	warnings := generatorWarnings(saturated, time.Minute)
+	require.Len(t, warnings, 4)
+	assert.Contains(t, warnings[0], "CPU usage peaked at 98% of 4 CPUs")
+	assert.Contains(t, warnings[1], "GC pauses took 6s of the 1m0s run")
+	assert.Contains(t, warnings[2], "1000 open files of a limit of 1024")
+	assert.Contains(t, warnings[3], "27000 of 28232 ephemeral ports in use")
+
+	assert.Equal(t, []string{"a GC pause lasted 250ms: response times around it include the pause"},
+		generatorWarnings(&models.GeneratorSummary{MaxGCPause: 250 * time.Millisecond}, time.Minute))
+}
+
+func TestConnectionNeeds(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: "https://api.example.com"},
+		Tests: []models.TestCase{
+			{Name: "Orders", Path: "/orders"},
+			{Name: "Users", Path: "/users", CompareWith: &models.CompareConfig{Endpoint: "https://staging.example.com"}},
+			{Name: "Query", SQL: &models.SQLConfig{DSN: "postgres://app:secret@db:5432/shop"}},
+		},
+	}
+	perTarget, total := connectionNeeds(config, 50)
+	assert.Equal(t, 50, perTarget)
+	assert.Equal(t, 150, total, "every worker may connect to the API, staging, and the database")
+
+	config.Global.WarmPool = &models.WarmPool{Connections: 80}
+	perTarget, total = connectionNeeds(config, 50)
+	assert.Equal(t, 80, perTarget)
+	assert.Equal(t, 180, total)
+
+	config.Global.WarmPool = nil
+	config.Scenarios = []models.Scenario{{Name: "Browse", Workers: 20}, {Name: "Buy"}}
+	perTarget, total = connectionNeeds(config, 50)
+	assert.Equal(t, 70, perTarget)
+	assert.Equal(t, 210, total)
+}
+
+func TestPreflight(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: "https://api.example.com"},
+		Tests:  []models.TestCase{{Name: "Orders", Path: "/orders"}},
+	}
+	assert.Empty(t, Preflight(config, 1))
+
+	if runtime.GOOS != "linux" || ephemeralPortRange() == 0 {
+		t.Skip("limits are only known on Linux")
+	}
+	warnings := Preflight(config, 1_000_000)
+	require.NotEmpty(t, warnings)
+	assert.Contains(t, warnings[len(warnings)-1], "up to 1000000 connections to one target use most of the")
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Error categories used to group failures in the summary
+const (
+	ErrorTimeout               = "timeout"
+	ErrorConnectTimeout        = "connect_timeout"
+	ErrorTLSHandshakeTimeout   = "tls_handshake_timeout"
+	ErrorResponseHeaderTimeout = "response_header_timeout"
+	ErrorConnectionRefused     = "connection_refused"
+	ErrorDNS                   = "dns"
+	ErrorTLS                   = "tls"
+	ErrorRead                  = "read_error"
+	ErrorStatus                = "unexpected_status"
+	ErrorSuccessWhen           = "success_when"
+	ErrorAssertion             = "assertion"
+	ErrorExtraction            = "extraction"
+	ErrorComparison            = "comparison"
+	ErrorRequest               = "request"
+	ErrorInjected              = "injected"
+	ErrorThrottled             = "throttled"
+	ErrorSSE                   = "sse"
+	ErrorPublish               = "publish"
+	ErrorQuery                 = "query"
+	ErrorOther                 = "other"
+)
+
+// classifyError maps a transport error to an error category
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return ErrorTimeout
+		}
+		return ErrorDNS
+	}
+
+	// Phase timeouts are told apart before the total timeout. net/http only
+	// reports its own through their message.
+	if strings.Contains(err.Error(), "TLS handshake timeout") {
+		return ErrorTLSHandshakeTimeout
+	}
+	if strings.Contains(err.Error(), "timeout awaiting response headers") {
+		return ErrorResponseHeaderTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return ErrorConnectTimeout
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorConnectionRefused
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) || strings.Contains(err.Error(), "tls:") {
+		return ErrorTLS
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return ErrorRead
+	}
+	if errors.As(err, &opErr) && opErr.Op == "read" {
+		return ErrorRead
+	}
+
+	return ErrorOther
+}
+
+// errorCategory returns the category of a failed result, defaulting to other
+func errorCategory(result models.TestResult) string {
+	if result.ErrorCategory != "" {
+		return result.ErrorCategory
+	}
+	return ErrorOther
+}
+
+// Parts of error messages that differ from request to request
+var (
+	uuidPattern      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	timestampPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	pathIDPattern    = regexp.MustCompile(`/\d+([/?#"]|$)`)
+	numberPattern    = regexp.MustCompile(`\b\d{5,}\b`)
+	hexPattern       = regexp.MustCompile(`(?i)\b[0-9a-f]{16,}\b`)
+)
+
+// normalizeError reduces an error message to the problem it reports, so
+// failures group in the summary: response bodies appended in verbose mode
+// are dropped, and UUIDs, timestamps, numeric path segments, long numbers
+// (such as ephemeral ports), and long hex strings become placeholders. The
+// raw messages are kept in the failure samples.
+func normalizeError(message string) string {
+	if i := strings.Index(message, "\nResponse body:"); i >= 0 {
+		message = message[:i]
+	}
+	message = uuidPattern.ReplaceAllString(message, "<uuid>")
+	message = timestampPattern.ReplaceAllString(message, "<timestamp>")
+	message = pathIDPattern.ReplaceAllString(message, "/<id>$1")
+	message = numberPattern.ReplaceAllString(message, "<n>")
+	return hexPattern.ReplaceAllString(message, "<hex>")
+}
+
+// addError adds a message to an endpoint's distinct error messages
+func addError(messages []string, message string) []string {
+	for _, existing := range messages {
+		if existing == message {
+			return messages
+		}
+	}
+	return append(messages, message)
+}
@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, ""},
+		{"deadline", context.DeadlineExceeded, ErrorTimeout},
+		{"net timeout", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, ErrorTimeout},
+		{"connect timeout", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, ErrorConnectTimeout},
+		{"tls handshake timeout", errors.New("Get \"https://api\": net/http: TLS handshake timeout"), ErrorTLSHandshakeTimeout},
+		{"warm pool tls handshake timeout", fmt.Errorf("get: %w", errTLSHandshakeTimeout), ErrorTLSHandshakeTimeout},
+		{"response header timeout", errors.New("Get \"http://api\": net/http: timeout awaiting response headers"), ErrorResponseHeaderTimeout},
+		{"dns", &net.DNSError{Err: "no such host", Name: "nope.invalid"}, ErrorDNS},
+		{"dns timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, ErrorTimeout},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ErrorConnectionRefused},
+		{"unknown authority", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), ErrorTLS},
+		{"tls message", errors.New("remote error: tls: handshake failure"), ErrorTLS},
+		{"eof", fmt.Errorf("read: %w", io.EOF), ErrorRead},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, ErrorRead},
+		{"other", errors.New("something odd"), ErrorOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
+
+func TestNormalizeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{"plain", "Unexpected status code: 500 (expected: [200])", "Unexpected status code: 500 (expected: [200])"},
+		{"verbose body", "Unexpected status code: 500 (expected: [200])\nResponse body: {\"trace\": \"abc\"}", "Unexpected status code: 500 (expected: [200])"},
+		{"uuid", "order 5f0c6a1e-43c2-4d7e-9A50-1b0d2c7e8f11 not found", "order <uuid> not found"},
+		{"timestamp", "token expired at 2024-01-02T12:00:00.123Z", "token expired at <timestamp>"},
+		{"path id", `Get "http://api/users/12345/orders/7": context deadline exceeded`, `Get "http://api/users/<id>/orders/<id>": context deadline exceeded`},
+		{"ephemeral port", "read tcp 127.0.0.1:54321->127.0.0.1:8080: read: connection reset by peer", "read tcp 127.0.0.1:<n>->127.0.0.1:8080: read: connection reset by peer"},
+		{"hex", "session 9f86d081884c7d659a2feaa0c55ad015 rejected", "session <hex> rejected"},
+		{"short words", "tls: bad certificate for cafe.example", "tls: bad certificate for cafe.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeError(tt.message))
+		})
+	}
+}
+
+func TestEngine_ErrorsGrouped(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"error": "failed", "request": %d}`, requests.Add(1))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 4,
+		},
+		Tests: []models.TestCase{
+			{Name: "failing", Method: "GET", Path: "/orders", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(2, nil, true).Run(config)
+
+	assert.Equal(t, map[string]int{"Unexpected status code: 500 (expected: [200])": 4}, summary.Errors)
+	endpoint := summary.EndpointResults["failing"]
+	assert.Equal(t, []string{"Unexpected status code: 500 (expected: [200])"}, endpoint.Errors)
+	require.NotEmpty(t, endpoint.FailureSamples)
+	assert.Contains(t, endpoint.FailureSamples[0].Error, `Response body: {"error": "failed"`)
+}
+
+func TestEngine_ErrorCategories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"status": "bad"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Categories",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 2,
+		},
+		Tests: []models.TestCase{
+			{Name: "status", Method: "GET", Path: "/error", ExpectedStatus: []int{200}},
+			{Name: "timeout", Method: "GET", Path: "/slow", ExpectedStatus: []int{200}, Timeout: 50 * time.Millisecond},
+			{
+				Name: "assertion", Method: "GET", Path: "/ok", ExpectedStatus: []int{200},
+				Assertions: []models.Assertion{{Type: "json_path", Target: "status", Operator: "eq", Value: "good"}},
+			},
+		},
+	}
+
+	summary := New(3, nil, false).Run(config)
+
+	assert.Equal(t, map[string]int{ErrorStatus: 2, ErrorTimeout: 2, ErrorAssertion: 2}, summary.ErrorCategories)
+	assert.Equal(t, map[string]int{ErrorTimeout: 2}, summary.EndpointResults["timeout"].ErrorCategories)
+}
+
+func TestEngine_PhaseTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Accepts connections but never answers the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	for _, warm := range []*models.WarmPool{nil, {Connections: 1}} {
+		config := &models.Config{
+			Global: models.GlobalConfig{
+				BaseURL:               server.URL,
+				Timeout:               5 * time.Second,
+				Iterations:            1,
+				ResponseHeaderTimeout: 50 * time.Millisecond,
+				WarmPool:              warm,
+			},
+			Tests: []models.TestCase{{Name: "slow", Method: "GET", Path: "/", ExpectedStatus: []int{200}}},
+		}
+		summary := New(1, nil, false).Run(config)
+		assert.Equal(t, map[string]int{ErrorResponseHeaderTimeout: 1}, summary.ErrorCategories, "warm=%v", warm != nil)
+
+		config = &models.Config{
+			Global: models.GlobalConfig{
+				BaseURL:             "https://" + listener.Addr().String(),
+				Timeout:             5 * time.Second,
+				Iterations:          1,
+				TLSHandshakeTimeout: 50 * time.Millisecond,
+				WarmPool:            warm,
+			},
+			Tests: []models.TestCase{{Name: "handshake", Method: "GET", Path: "/", ExpectedStatus: []int{200}}},
+		}
+		summary = New(1, nil, false).Run(config)
+		assert.Equal(t, map[string]int{ErrorTLSHandshakeTimeout: 1}, summary.ErrorCategories, "warm=%v", warm != nil)
+	}
+}
+
+func TestEngine_FailureBudget(t *testing.T) {
+	var count int32
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		n := count
+		mu.Unlock()
+		// One request in 50 fails
+		if n%50 == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Budget",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 100,
+		},
+		Tests: []models.TestCase{
+			{Name: "flaky", Method: "GET", Path: "/", ExpectedStatus: []int{200}, AllowedFailureRate: 5},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	endpoint := summary.EndpointResults["flaky"]
+	assert.Equal(t, 2, endpoint.FailedReqs)
+	assert.Equal(t, 5.0, endpoint.AllowedFailureRate)
+	assert.True(t, summary.Passed())
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"sync/atomic"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// failureSamplesLimit bounds the failures kept per endpoint as examples
+const failureSamplesLimit = 5
+
+// assertionMessagesLimit bounds the failure messages kept per assertion
+const assertionMessagesLimit = 3
+
+// sampleFailureBody reports whether a failure of test keeps the start of its
+// response body, which only the first failures of each test do
+func (e *Engine) sampleFailureBody(test string) bool {
+	counter, _ := e.failureBodies.LoadOrStore(test, new(atomic.Int64))
+	return counter.(*atomic.Int64).Add(1) <= failureSamplesLimit
+}
+
+// trackFailure adds a failed result, with its error category resolved, to
+// an endpoint's failure samples, keeping the first failureSamplesLimit
+func trackFailure(samples []models.TestResult, result models.TestResult) []models.TestResult {
+	if len(samples) >= failureSamplesLimit {
+		return samples
+	}
+	result.ErrorCategory = errorCategory(result)
+	return append(samples, result)
+}
+
+// trackAssertions adds the assertion outcomes of a result to an endpoint's
+// assertion summaries, matched by position as they come from the same test.
+// Failure messages are normalized like errors, so the examples differ.
+func trackAssertions(summaries []models.AssertionSummary, outcomes []models.AssertionOutcome) []models.AssertionSummary {
+	for i, outcome := range outcomes {
+		if i == len(summaries) {
+			summaries = append(summaries, models.AssertionSummary{Assertion: outcome.Assertion})
+		}
+		summary := &summaries[i]
+		if outcome.Passed {
+			summary.Passed++
+			continue
+		}
+		summary.Failed++
+		if summary.Context == "" {
+			summary.Context = outcome.Context
+		}
+		if len(summary.Messages) < assertionMessagesLimit {
+			summary.Messages = addError(summary.Messages, normalizeError(outcome.Message))
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackFailure(t *testing.T) {
+	var samples []models.TestResult
+	for i := 1; i <= failureSamplesLimit+3; i++ {
+		samples = trackFailure(samples, models.TestResult{TestName: fmt.Sprintf("req-%d", i), Error: "boom"})
+	}
+
+	require.Len(t, samples, failureSamplesLimit)
+	assert.Equal(t, "req-1", samples[0].TestName)
+	assert.Equal(t, "req-5", samples[4].TestName)
+	assert.Equal(t, ErrorOther, samples[0].ErrorCategory)
+}
+
+func TestEngine_FailureSamples(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "database unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:         server.URL,
+			Timeout:         5 * time.Second,
+			Iterations:      8,
+			RequestIDHeader: "X-Request-ID",
+		},
+		Tests: []models.TestCase{
+			{Name: "Fail", Method: "GET", Path: "/fail", ExpectedStatus: []int{200}},
+			{Name: "Pass", Method: "GET", Path: "/pass", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(2, nil, false).Run(config)
+
+	var failing, passing *models.EndpointSummary
+	for _, endpoint := range summary.EndpointResults {
+		switch endpoint.Name {
+		case "Fail":
+			failing = endpoint
+		case "Pass":
+			passing = endpoint
+		}
+	}
+	require.NotNil(t, failing)
+	require.NotNil(t, passing)
+	assert.Empty(t, passing.FailureSamples)
+
+	require.Len(t, failing.FailureSamples, failureSamplesLimit)
+	for _, sample := range failing.FailureSamples {
+		assert.Equal(t, 500, sample.StatusCode)
+		assert.Equal(t, ErrorStatus, sample.ErrorCategory)
+		assert.Contains(t, sample.Error, "Unexpected status code: 500")
+		assert.Equal(t, `{"error": "database unavailable"}`, sample.BodySample)
+		assert.NotEmpty(t, sample.RequestID)
+		assert.False(t, sample.Timestamp.IsZero())
+	}
+}
+
+func TestTrackAssertions(t *testing.T) {
+	status := models.Assertion{Type: "status", Operator: "eq", Value: 200.0}
+	field := models.Assertion{Type: "json_path", Target: "state", Operator: "eq", Value: "done"}
+
+	var summaries []models.AssertionSummary
+	for i := 0; i < 5; i++ {
+		summaries = trackAssertions(summaries, []models.AssertionOutcome{
+			{Assertion: status, Passed: true},
+			{Assertion: field, Message: fmt.Sprintf("expected done, got pending (job 1000%d)", i), Context: fmt.Sprintf("body = {\"job\": %d}", i)},
+		})
+	}
+	summaries = trackAssertions(summaries, []models.AssertionOutcome{
+		{Assertion: status, Passed: true},
+		{Assertion: field, Message: "path 'state' not found in response"},
+	})
+	summaries = trackAssertions(summaries, nil)
+
+	require.Len(t, summaries, 2)
+	assert.Equal(t, models.AssertionSummary{Assertion: status, Passed: 6}, summaries[0])
+	assert.Equal(t, field, summaries[1].Assertion)
+	assert.Equal(t, 0, summaries[1].Passed)
+	assert.Equal(t, 6, summaries[1].Failed)
+	assert.Equal(t, []string{"expected done, got pending (job <n>)", "path 'state' not found in response"}, summaries[1].Messages)
+	assert.Equal(t, `body = {"job": 0}`, summaries[1].Context, "the first context is kept")
+}
+
+func TestEngine_AssertionSummaries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"state": "pending"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 3,
+		},
+		Tests: []models.TestCase{
+			{
+				Name: "Job", Method: "GET", Path: "/job", ExpectedStatus: []int{200},
+				Assertions: []models.Assertion{
+					{Type: "status", Operator: "eq", Value: 200.0},
+					{Type: "json_path", Target: "state", Operator: "eq", Value: "done"},
+				},
+			},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	assertions := summary.EndpointResults["Job"].Assertions
+	require.Len(t, assertions, 2)
+	assert.Equal(t, "status", assertions[0].Assertion.Type)
+	assert.Equal(t, 3, assertions[0].Passed)
+	assert.Equal(t, 0, assertions[0].Failed)
+	assert.Equal(t, "state", assertions[1].Assertion.Target)
+	assert.Equal(t, 0, assertions[1].Passed)
+	assert.Equal(t, 3, assertions[1].Failed)
+	require.Len(t, assertions[1].Messages, 1)
+	assert.Contains(t, assertions[1].Messages[0], "pending")
+}
+
+func TestEngine_AssertionContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"order": {"id": 7, "total": 0, "card": "4111111111111111"}, "meta": {"page": 1}}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Redact:     &models.RedactConfig{JSONPaths: []string{"order.card"}},
+		},
+		Tests: []models.TestCase{{
+			Name: "Order", Method: "GET", Path: "/order", ExpectedStatus: []int{200},
+			Assertions: []models.Assertion{
+				{Type: "json_path", Target: "order.id", Operator: "eq", Value: 7.0},
+				{Type: "json_path", Target: "order.total", Operator: "gt", Value: 0.0},
+			},
+		}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	assertions := summary.EndpointResults["Order"].Assertions
+	require.Len(t, assertions, 2)
+	assert.Empty(t, assertions[0].Context)
+	assert.Contains(t, assertions[1].Context, "order = {\n")
+	assert.Contains(t, assertions[1].Context, `"total": 0`)
+	assert.NotContains(t, assertions[1].Context, "meta", "only the section around the failure is kept")
+	assert.NotContains(t, assertions[1].Context, "4111111111111111", "the body is redacted")
+}
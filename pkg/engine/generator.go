@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// generatorSampleInterval is how often the load generator's health is sampled
+const generatorSampleInterval = time.Second
+
+// Thresholds above which the generator is reported as a likely bottleneck
+const (
+	generatorCPUWarnPercent   = 85
+	generatorGCWarnPercent    = 5 // Share of the run spent in GC pauses
+	generatorMaxGCPauseWarn   = 100 * time.Millisecond
+	generatorResourceWarnRate = 0.8 // Share of the file or ephemeral port limit in use
+)
+
+// generatorMonitor samples the CPU, memory, GC, and socket usage of the load
+// generator while a run is in progress
+type generatorMonitor struct {
+	mu      sync.Mutex
+	summary models.GeneratorSummary
+	start   time.Time
+	cpu     time.Duration // Process CPU time at start
+	gcStart uint32        // Completed GC cycles at start
+	pauses  time.Duration // Total GC pauses at start
+
+	lastSample time.Time
+	lastCPU    time.Duration
+	lastGC     uint32
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// startGeneratorMonitor starts sampling until stop is called
+func startGeneratorMonitor() *generatorMonitor {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	cpu, _ := processCPUTime()
+
+	m := &generatorMonitor{
+		summary: models.GeneratorSummary{
+			CPUs:               runtime.GOMAXPROCS(0),
+			OpenFilesLimit:     openFilesLimit(),
+			EphemeralPortRange: ephemeralPortRange(),
+		},
+		start:      time.Now(),
+		cpu:        cpu,
+		gcStart:    mem.NumGC,
+		pauses:     time.Duration(mem.PauseTotalNs),
+		lastSample: time.Now(),
+		lastCPU:    cpu,
+		lastGC:     mem.NumGC,
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+func (m *generatorMonitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(generatorSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.sample()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// sample records the current usage, keeping the peaks
+func (m *generatorMonitor) sample() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	summary := &m.summary
+
+	if cpu, ok := processCPUTime(); ok {
+		if wall := now.Sub(m.lastSample); wall > 0 {
+			percent := cpuPercent(cpu-m.lastCPU, wall, summary.CPUs)
+			if percent > summary.MaxCPUPercent {
+				summary.MaxCPUPercent = percent
+			}
+		}
+		m.lastCPU = cpu
+	}
+	m.lastSample = now
+
+	summary.MaxHeapBytes = max(summary.MaxHeapBytes, mem.HeapAlloc)
+	summary.MaxMemoryBytes = max(summary.MaxMemoryBytes, mem.Sys)
+	summary.MaxGoroutines = max(summary.MaxGoroutines, runtime.NumGoroutine())
+
+	// PauseNs is a circular buffer of the last 256 GC pauses
+	cycles := mem.NumGC - m.lastGC
+	if cycles > uint32(len(mem.PauseNs)) {
+		cycles = uint32(len(mem.PauseNs))
+	}
+	for i := uint32(0); i < cycles; i++ {
+		pause := time.Duration(mem.PauseNs[(mem.NumGC-i+255)%256])
+		summary.MaxGCPause = max(summary.MaxGCPause, pause)
+	}
+	m.lastGC = mem.NumGC
+	summary.GCCount = int(mem.NumGC - m.gcStart)
+	summary.GCPauseTotal = time.Duration(mem.PauseTotalNs) - m.pauses
+
+	if files, ok := openFiles(); ok {
+		summary.MaxOpenFiles = max(summary.MaxOpenFiles, files)
+	}
+	if ports, ok := ephemeralPortsInUse(); ok {
+		summary.MaxEphemeralPorts = max(summary.MaxEphemeralPorts, ports)
+	}
+}
+
+// stop takes a last sample and returns the summary with its warnings
+func (m *generatorMonitor) stop() *models.GeneratorSummary {
+	close(m.stopCh)
+	<-m.done
+	m.sample()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	summary := m.summary
+	elapsed := time.Since(m.start)
+	if cpu, ok := processCPUTime(); ok && elapsed > 0 {
+		summary.AvgCPUPercent = cpuPercent(cpu-m.cpu, elapsed, summary.CPUs)
+	}
+	summary.Warnings = generatorWarnings(&summary, elapsed)
+	return &summary
+}
+
+// cpuPercent returns CPU time used during wall, in percent of all cpus
+func cpuPercent(cpu, wall time.Duration, cpus int) float64 {
+	if cpus < 1 {
+		cpus = 1
+	}
+	return float64(cpu) / float64(wall) / float64(cpus) * 100
+}
+
+// generatorWarnings explains which limits of the load generator were close,
+// making its results suspect
+func generatorWarnings(summary *models.GeneratorSummary, elapsed time.Duration) []string {
+	var warnings []string
+	if summary.MaxCPUPercent >= generatorCPUWarnPercent {
+		warnings = append(warnings, fmt.Sprintf("CPU usage peaked at %.0f%% of %d CPUs: requests may have queued in the generator; use fewer workers or more machines",
+			summary.MaxCPUPercent, summary.CPUs))
+	}
+	if elapsed > 0 && float64(summary.GCPauseTotal)/float64(elapsed)*100 >= generatorGCWarnPercent {
+		warnings = append(warnings, fmt.Sprintf("GC pauses took %v of the %v run: response times include generator pauses",
+			summary.GCPauseTotal.Round(time.Millisecond), elapsed.Round(time.Second)))
+	} else if summary.MaxGCPause >= generatorMaxGCPauseWarn {
+		warnings = append(warnings, fmt.Sprintf("a GC pause lasted %v: response times around it include the pause",
+			summary.MaxGCPause.Round(time.Millisecond)))
+	}
+	if summary.OpenFilesLimit > 0 && float64(summary.MaxOpenFiles) >= float64(summary.OpenFilesLimit)*generatorResourceWarnRate {
+		warnings = append(warnings, fmt.Sprintf("%d open files of a limit of %d: new connections may fail; raise the limit (ulimit -n)",
+			summary.MaxOpenFiles, summary.OpenFilesLimit))
+	}
+	if summary.EphemeralPortRange > 0 && float64(summary.MaxEphemeralPorts) >= float64(summary.EphemeralPortRange)*generatorResourceWarnRate {
+		warnings = append(warnings, fmt.Sprintf("%d of %d ephemeral ports in use: connections may fail to open; keep connections alive or widen the port range",
+			summary.MaxEphemeralPorts, summary.EphemeralPortRange))
+	}
+	return warnings
+}
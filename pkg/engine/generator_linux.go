@@ -0,0 +1,114 @@
+//go:build linux
+
+package engine
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the user and system CPU time used by the process
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
+
+// openFiles returns the number of file descriptors open by the process
+func openFiles() (int, bool) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	return len(entries), true
+}
+
+// openFilesLimit returns the soft limit of open file descriptors, or 0
+func openFilesLimit() int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0
+	}
+	return int(limit.Cur)
+}
+
+// ephemeralPortRange returns the size of the local port range used for
+// outgoing connections, or 0
+func ephemeralPortRange() int {
+	low, high, ok := ephemeralPorts()
+	if !ok {
+		return 0
+	}
+	return high - low + 1
+}
+
+func ephemeralPorts() (low, high int, ok bool) {
+	data, err := os.ReadFile("/proc/sys/net/ipv4/ip_local_port_range")
+	if err != nil {
+		return 0, 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	low, errLow := strconv.Atoi(fields[0])
+	high, errHigh := strconv.Atoi(fields[1])
+	return low, high, errLow == nil && errHigh == nil && high >= low
+}
+
+// ephemeralPortsInUse counts the TCP sockets of the machine bound to a port
+// of the ephemeral range, including those in TIME_WAIT
+func ephemeralPortsInUse() (int, bool) {
+	low, high, ok := ephemeralPorts()
+	if !ok {
+		return 0, false
+	}
+	count, found := 0, false
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+		found = true
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			// sl local_address rem_address st ...; local_address is IP:PORT in hex
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			_, hexPort, _ := strings.Cut(fields[1], ":")
+			port, err := strconv.ParseInt(hexPort, 16, 32)
+			if err == nil && int(port) >= low && int(port) <= high {
+				count++
+			}
+		}
+		file.Close()
+	}
+	return count, found
+}
+
+// raiseOpenFilesLimit raises the soft limit of open file descriptors towards
+// needed, up to the hard limit, and returns the resulting soft limit
+func raiseOpenFilesLimit(needed int) int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0
+	}
+	if limit.Cur >= uint64(needed) || limit.Cur >= limit.Max {
+		return int(limit.Cur)
+	}
+	raised := limit
+	raised.Cur = min(uint64(needed), limit.Max)
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &raised); err != nil {
+		return int(limit.Cur)
+	}
+	return int(raised.Cur)
+}
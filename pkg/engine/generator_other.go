@@ -0,0 +1,20 @@
+//go:build !linux
+
+package engine
+
+import "time"
+
+// The generator's CPU time, open files, and ephemeral ports are only read
+// on Linux; elsewhere they are reported as 0
+
+func processCPUTime() (time.Duration, bool) { return 0, false }
+
+func openFiles() (int, bool) { return 0, false }
+
+func openFilesLimit() int { return 0 }
+
+func ephemeralPortRange() int { return 0 }
+
+func ephemeralPortsInUse() (int, bool) { return 0, false }
+
+func raiseOpenFilesLimit(needed int) int { return 0 }
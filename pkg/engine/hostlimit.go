@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// hostLimiter bounds the requests awaiting a response from each host
+// (max_in_flight_per_host), so a run mixing several hosts cannot flood
+// one of them with all of its workers
+type hostLimiter struct {
+	limit int
+	slots sync.Map // host -> chan struct{} holding a token per request in flight
+}
+
+// newHostLimiter returns a limiter of limit requests per host, or nil
+// without a limit
+func newHostLimiter(limit int) *hostLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &hostLimiter{limit: limit}
+}
+
+// acquire waits for a free slot of host, returning the function that frees
+// it (and does nothing when called again), or the error of ctx when it ends
+// first. Without a limiter it returns at once.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	slots, _ := l.slots.LoadOrStore(host, make(chan struct{}, l.limit))
+	ch := slots.(chan struct{})
+	select {
+	case ch <- struct{}{}:
+		return sync.OnceFunc(func() { <-ch }), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
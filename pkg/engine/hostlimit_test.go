@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLimiter(t *testing.T) {
+	assert.Nil(t, newHostLimiter(0))
+	release, err := (*hostLimiter)(nil).acquire(context.Background(), "a")
+	require.NoError(t, err)
+	release()
+
+	limiter := newHostLimiter(1)
+	release, err = limiter.acquire(context.Background(), "a")
+	require.NoError(t, err)
+	// Other hosts have their own slots
+	other, err := limiter.acquire(context.Background(), "b")
+	require.NoError(t, err)
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Releasing twice frees one slot only
+	release()
+	release()
+	_, err = limiter.acquire(context.Background(), "a")
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "a")
+	assert.Error(t, err)
+}
+
+func TestEngine_MaxConnectionsPerHost(t *testing.T) {
+	var opened atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, warm := range []*models.WarmPool{nil, {Connections: 6}} {
+		opened.Store(0)
+		config := &models.Config{
+			Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, MaxConnectionsPerHost: 2, WarmPool: warm},
+			Tests: []models.TestCase{
+				{Name: "ping", Method: "GET", Path: "/ping", Iterations: 24, ExpectedStatus: []int{200}},
+			},
+		}
+		summary := New(6, nil, false).Run(config)
+		require.Equal(t, 24, summary.SuccessfulReqs, "warm=%v", warm)
+		assert.LessOrEqual(t, opened.Load(), int64(2), "warm=%v", warm)
+	}
+}
+
+func TestEngine_MaxInFlightPerHost(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, MaxInFlightPerHost: 2},
+		Tests: []models.TestCase{
+			{Name: "ping", Method: "GET", Path: "/ping", Iterations: 24, ExpectedStatus: []int{200}},
+		},
+	}
+	summary := New(6, nil, false).Run(config)
+	require.Equal(t, 24, summary.SuccessfulReqs)
+	mu.Lock()
+	assert.Equal(t, 2, peak)
+	mu.Unlock()
+
+	// A comparison request to the same host does not wait for the slot of
+	// its primary request
+	config.Global.MaxInFlightPerHost = 1
+	config.Tests[0].Iterations = 4
+	config.Tests[0].CompareWith = &models.CompareConfig{Endpoint: server.URL}
+	summary = New(2, nil, false).Run(config)
+	assert.Equal(t, 4, summary.SuccessfulReqs)
+	assert.Equal(t, 4, summary.ComparisonsPassed)
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// injectFault applies client-side fault injection before a request is sent.
+// It reports whether the request is dropped, and otherwise waits for the
+// injected latency so it counts in the response time like a slow network.
+func (e *Engine) injectFault(inject *models.InjectConfig) bool {
+	if inject == nil {
+		return false
+	}
+	if inject.DropRate > 0 && e.random.Float64() < inject.DropRate {
+		return true
+	}
+
+	delay := e.injectedLatency(inject)
+	if delay <= 0 {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-e.context().Done():
+	}
+	return false
+}
+
+// injectedLatency returns latency plus a uniform random offset within
+// ±jitter, never less than zero
+func (e *Engine) injectedLatency(inject *models.InjectConfig) time.Duration {
+	delay := inject.Latency
+	if inject.Jitter > 0 {
+		delay += time.Duration(e.random.Int63n(int64(2*inject.Jitter)+1)) - inject.Jitter
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// intervalLog prints a line every interval with the requests, errors, and
+// P95 of the requests finished in it, so logs of runs without a progress bar
+// show how the run went over time
+type intervalLog struct {
+	mu            sync.Mutex
+	out           io.Writer
+	every         time.Duration
+	start         time.Time
+	intervalStart time.Time
+	times         []time.Duration
+	failed        int
+	totalRequests int
+	totalFailed   int
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// SetIntervalSummaries prints a summary line of the last interval to w
+// every interval while the run goes on
+func (e *Engine) SetIntervalSummaries(w io.Writer, every time.Duration) {
+	if every <= 0 {
+		e.intervals = nil
+		return
+	}
+	e.intervals = &intervalLog{out: w, every: every}
+}
+
+// begin starts the intervals at the start of the run
+func (l *intervalLog) begin() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.start = time.Now()
+	l.intervalStart = l.start
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.tick(l.stop, l.done)
+}
+
+func (l *intervalLog) tick(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			l.print(now)
+			l.mu.Unlock()
+		}
+	}
+}
+
+// add counts a finished request; skipped requests are left out
+func (l *intervalLog) add(result models.TestResult) {
+	if l == nil || result.Skipped {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.times = append(l.times, result.ResponseTime)
+	l.totalRequests++
+	if !result.Success && !result.Throttled {
+		l.failed++
+		l.totalFailed++
+	}
+}
+
+// end stops the intervals, printing the last partial one if it has requests
+func (l *intervalLog) end() {
+	if l == nil || l.stop == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+	l.stop = nil
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.times) > 0 {
+		l.print(time.Now())
+	}
+}
+
+// print writes the line of the interval ending at now and starts the next
+// one; the caller holds the lock
+func (l *intervalLog) print(now time.Time) {
+	var rps float64
+	if elapsed := now.Sub(l.intervalStart).Seconds(); elapsed > 0 {
+		rps = float64(len(l.times)) / elapsed
+	}
+	p95 := calculatePercentile(l.times, 95)
+	if p95 >= time.Millisecond {
+		p95 = p95.Round(time.Millisecond)
+	}
+	fmt.Fprintf(l.out, "[%6v] %d requests, %d errors | %.1f req/s | P95 %v | total %d requests, %d errors\n",
+		now.Sub(l.start).Round(time.Second),
+		len(l.times),
+		l.failed,
+		rps,
+		p95,
+		l.totalRequests,
+		l.totalFailed,
+	)
+	l.intervalStart = now
+	l.times = l.times[:0]
+	l.failed = 0
+}
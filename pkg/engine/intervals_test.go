@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalLog(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := &intervalLog{out: &out, every: 10 * time.Second, start: start, intervalStart: start}
+
+	for i := 1; i <= 20; i++ {
+		l.add(models.TestResult{Success: i != 20, ResponseTime: time.Duration(i) * 10 * time.Millisecond})
+	}
+	l.add(models.TestResult{Skipped: true})
+	l.add(models.TestResult{Throttled: true, ResponseTime: time.Millisecond})
+	l.print(start.Add(10 * time.Second))
+	assert.Equal(t, "[   10s] 21 requests, 1 errors | 2.1 req/s | P95 190ms | total 21 requests, 1 errors\n", out.String())
+
+	// The next interval starts from zero, the totals keep counting
+	out.Reset()
+	l.add(models.TestResult{Success: true, ResponseTime: 400 * time.Microsecond})
+	l.print(start.Add(20 * time.Second))
+	assert.Equal(t, "[   20s] 1 requests, 0 errors | 0.1 req/s | P95 400µs | total 22 requests, 1 errors\n", out.String())
+
+	// Without summaries nothing is tracked
+	var none *intervalLog
+	none.begin()
+	none.add(models.TestResult{})
+	none.end()
+}
+
+func TestEngine_IntervalSummaries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Duration: 350 * time.Millisecond},
+		Tests:  []models.TestCase{{Name: "ping", Method: "GET", Path: "/ping", ExpectedStatus: []int{200}}},
+	}
+	var out bytes.Buffer
+	e := New(2, nil, false)
+	e.SetIntervalSummaries(&out, 100*time.Millisecond)
+	summary := e.Run(config)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.GreaterOrEqual(t, len(lines), 3)
+	assert.Contains(t, lines[0], "requests, 0 errors")
+	assert.True(t, strings.HasSuffix(lines[len(lines)-1], fmt.Sprintf("total %d requests, 0 errors", summary.TotalRequests)))
+
+	e = New(1, nil, false)
+	e.SetIntervalSummaries(&out, 0)
+	assert.Nil(t, e.intervals)
+}
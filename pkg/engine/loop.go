@@ -0,0 +1,225 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/variables"
+)
+
+// loopStep is one test of a looped dependency chain
+type loopStep struct {
+	test   models.TestCase
+	url    string
+	phase  int // 1-based DAG phase of the test
+	cursor *dataCursor
+}
+
+// executeLoop runs the whole dependency chain repeatedly on every worker,
+// as a virtual user would. Each loop runs the tests one after the other in
+// dependency order with its own scope for extracted variables, so loops
+// running concurrently never see each other's values. Loops are started
+// until the global iterations (the number of loops) are done or the global
+// duration has elapsed.
+func (e *Engine) executeLoop(config *models.Config, workers int) ([]models.TestResult, error) {
+	chain, err := e.loopChain(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range chain {
+		if step.cursor != nil {
+			defer e.closeDataCursor(step.test, step.cursor)
+		}
+	}
+
+	var deadline time.Time
+	loops := int64(config.Global.Iterations)
+	if config.Global.Duration > 0 {
+		deadline = time.Now().Add(config.Global.Duration)
+		loops = 0
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu         sync.Mutex
+		allResults []models.TestResult
+		started    int64
+		wg         sync.WaitGroup
+	)
+	ctx := e.context()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(vu int) {
+			defer wg.Done()
+			var schedule pacingSchedule
+			for ctx.Err() == nil {
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				loop := atomic.AddInt64(&started, 1)
+				if loops > 0 && loop > loops {
+					return
+				}
+
+				loopStart := time.Now()
+				lag := schedule.lag(loopStart)
+				results, ok := e.runLoop(ctx, config, chain, int(loop), vu, deadline)
+				if len(results) > 0 {
+					// Only the first request of a late loop was held back
+					results[0].ScheduleLag += lag
+				}
+				schedule.next(loopStart, config.Global.Pacing)
+
+				mu.Lock()
+				allResults = append(allResults, results...)
+				mu.Unlock()
+
+				if !ok || !e.waitPacing(ctx, Job{Config: config}, loopStart) {
+					return
+				}
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	return allResults, nil
+}
+
+// loopChain orders the tests of config for a loop: DAG phases in order and,
+// within a phase, the order of the config file
+func (e *Engine) loopChain(config *models.Config) ([]loopStep, error) {
+	var testDeps []variables.TestDependency
+	position := make(map[string]int, len(config.Tests))
+	testByName := make(map[string]models.TestCase, len(config.Tests))
+	for i, test := range config.Tests {
+		testDeps = append(testDeps, variables.TestDependency{
+			Name:      test.Name,
+			DependsOn: test.DependsOn,
+		})
+		position[test.Name] = i
+		testByName[test.Name] = test
+	}
+
+	plan, err := variables.BuildDAG(testDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimSuffix(config.Global.BaseURL, "/")
+	var chain []loopStep
+	for phaseIndex, phase := range plan.Phases {
+		names := append([]string(nil), phase...)
+		sort.Slice(names, func(i, j int) bool {
+			return position[names[i]] < position[names[j]]
+		})
+		for _, name := range names {
+			test := testByName[name]
+			// Rows are cycled across loops unless the test asks otherwise
+			strategy := test.DataStrategy
+			if strategy == "" {
+				strategy = models.DataStrategyCircular
+			}
+			chain = append(chain, loopStep{
+				test:   test,
+				url:    baseURL + "/" + strings.TrimPrefix(test.Path, "/"),
+				phase:  phaseIndex + 1,
+				cursor: e.openDataCursor(test, strategy),
+			})
+		}
+	}
+	return chain, nil
+}
+
+// runLoop runs one loop of the chain for the given virtual user. Tests are
+// skipped following their depends_on_mode when dependencies failed in this
+// loop. It returns false when the
+// worker must stop: the run or the duration ended, or a data source ran out.
+func (e *Engine) runLoop(ctx context.Context, config *models.Config, chain []loopStep, loop int, vu int, deadline time.Time) ([]models.TestResult, bool) {
+	scope := variables.Scope{"__loop": loop}
+	failed := make(map[string]bool)
+	var results []models.TestResult
+
+	for _, step := range chain {
+		if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+			return results, false
+		}
+		test := step.test
+
+		if skipReason := dependencySkipReason(test, failed); skipReason != "" {
+			failed[test.Name] = true
+			results = append(results, models.TestResult{
+				TestName:   test.Name,
+				URL:        step.url,
+				Method:     test.Method,
+				Skipped:    true,
+				SkipReason: skipReason,
+				Timestamp:  time.Now(),
+				Phase:      step.phase,
+			})
+			e.finished(results[len(results)-1])
+			continue
+		}
+
+		job := Job{Config: config, TestCase: test, URL: step.url, VU: vu, Loop: scope}
+		if step.cursor != nil {
+			row, ok := step.cursor.Next()
+			if !ok {
+				return results, false
+			}
+			setScopeDataVariables(scope, "data", row)
+		}
+
+		if thinkTime := e.calculateThinkTime(job); thinkTime > 0 {
+			select {
+			case <-ctx.Done():
+				return results, false
+			case <-time.After(thinkTime):
+			}
+		}
+
+		result := e.executeTest(job)
+		if !result.Success && e.context().Err() != nil {
+			// Aborted by the max duration limit, not a failure of the target
+			return results, false
+		}
+		result.Phase = step.phase
+		results = append(results, result)
+		e.finished(result)
+		if !result.Success {
+			failed[test.Name] = true
+		}
+
+		delay := test.Delay
+		if delay == 0 {
+			delay = config.Global.Delay
+		}
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return results, false
+			case <-time.After(delay):
+			}
+		}
+	}
+	return results, true
+}
+
+// setScopeDataVariables sets a data row in a scope with the given prefix,
+// flattening nested maps into dotted names (data.user.name)
+func setScopeDataVariables(scope variables.Scope, prefix string, data map[string]interface{}) {
+	for key, value := range data {
+		fullKey := prefix + "." + key
+		scope[fullKey] = value
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			setScopeDataVariables(scope, fullKey, nested)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/messaging"
+)
+
+// executeMessaging publishes the message of a messaging test and checks it.
+// The response time is the publish latency, until the broker acknowledges
+// the message; the wait for the test's rate is not part of it.
+func (e *Engine) executeMessaging(job Job) models.TestResult {
+	config := job.TestCase.Messaging
+	var lag time.Duration
+	if config.Rate > 0 {
+		limiter, _ := e.rateLimiters.LoadOrStore(job.TestCase.Name, newRateLimiter(config.Rate))
+		var slot time.Time
+		slot, lag = limiter.(*rateLimiter).reserve(time.Now())
+		e.wait(time.Until(slot))
+	}
+
+	start := time.Now()
+	brokerURL := e.varSubstitutor.SubstituteScoped(config.URL, job.Scope)
+	result := models.TestResult{
+		TestName:    job.TestCase.Name,
+		URL:         brokerTarget(brokerURL, config.Topic),
+		Method:      "AMQP",
+		Timestamp:   start,
+		ScheduleLag: lag,
+	}
+	if strings.HasPrefix(brokerURL, "kafka:") {
+		result.Method = "KAFKA"
+	}
+
+	msg, err := e.message(job)
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorCategory = ErrorRequest
+		return result
+	}
+	result.RequestSize = int64(len(msg.Value))
+
+	if e.injectFault(job.Config.TestInject(job.TestCase)) {
+		result.ResponseTime = time.Since(start)
+		result.Error = "request dropped by fault injection"
+		result.ErrorCategory = ErrorInjected
+		return result
+	}
+
+	timeout := job.TestCase.Timeout
+	if timeout == 0 {
+		timeout = job.Config.Global.Timeout
+	}
+	ctx := e.context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	publisher, err := e.publishers.get(ctx, brokerURL, job.Config.TestTransportTimeouts(job.TestCase).Connect)
+	if err == nil {
+		if err = publisher.Publish(ctx, msg); err != nil {
+			publisher.Close()
+		} else {
+			e.publishers.put(brokerURL, publisher)
+		}
+	}
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = e.redactor.String(err.Error())
+		var brokerErr *messaging.BrokerError
+		if errors.As(err, &brokerErr) {
+			result.ErrorCategory = ErrorPublish
+		} else {
+			result.ErrorCategory = classifyError(err)
+		}
+		return result
+	}
+
+	e.checkReply(job, &result, nil)
+	return result
+}
+
+// message builds the message of a messaging test, with variables substituted
+func (e *Engine) message(job Job) (messaging.Message, error) {
+	config := job.TestCase.Messaging
+	msg := messaging.Message{
+		Topic: e.varSubstitutor.SubstituteScoped(config.Topic, job.Scope),
+		Key:   e.varSubstitutor.SubstituteScoped(config.Key, job.Scope),
+	}
+	if config.Exchange != "" || config.RoutingKey != "" {
+		msg.Topic = e.varSubstitutor.SubstituteScoped(config.Exchange, job.Scope)
+		msg.Key = e.varSubstitutor.SubstituteScoped(config.RoutingKey, job.Scope)
+	}
+	substituted := []interface{}{msg.Topic, msg.Key}
+
+	switch payload := config.Payload.(type) {
+	case nil:
+	case string:
+		value := e.varSubstitutor.SubstituteScoped(payload, job.Scope)
+		substituted = append(substituted, value)
+		msg.Value = []byte(value)
+	default:
+		value := e.varSubstitutor.SubstituteBodyScoped(payload, job.Scope)
+		substituted = append(substituted, value)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return msg, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		msg.Value = data
+		msg.ContentType = "application/json"
+	}
+
+	if len(config.Headers) > 0 {
+		msg.Headers = make(map[string]string, len(config.Headers))
+		for key, value := range config.Headers {
+			msg.Headers[key] = e.varSubstitutor.SubstituteScoped(value, job.Scope)
+			substituted = append(substituted, msg.Headers[key])
+		}
+	}
+
+	if job.Config.Global.StrictVariables {
+		if err := unresolvedVariables(substituted); err != nil {
+			return msg, err
+		}
+	}
+	return msg, nil
+}
+
+// brokerTarget returns the broker URL of a messaging test without its
+// credentials and, for Kafka, with the topic it publishes to
+func brokerTarget(brokerURL, topic string) string {
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return brokerURL
+	}
+	u.User = nil
+	if u.Scheme == "kafka" {
+		return u.String() + "/" + topic
+	}
+	return u.String()
+}
+
+// publisherPool keeps the idle broker connections of messaging tests, so
+// requests reuse them like HTTP keep-alive connections
+type publisherPool struct {
+	mu   sync.Mutex
+	idle map[string][]messaging.Publisher // broker URL -> idle publishers
+}
+
+func newPublisherPool() *publisherPool {
+	return &publisherPool{idle: make(map[string][]messaging.Publisher)}
+}
+
+// get returns an idle publisher of a broker, or connects a new one
+func (p *publisherPool) get(ctx context.Context, brokerURL string, connectTimeout time.Duration) (messaging.Publisher, error) {
+	p.mu.Lock()
+	if idle := p.idle[brokerURL]; len(idle) > 0 {
+		publisher := idle[len(idle)-1]
+		p.idle[brokerURL] = idle[:len(idle)-1]
+		p.mu.Unlock()
+		return publisher, nil
+	}
+	p.mu.Unlock()
+	return messaging.Dial(ctx, brokerURL, connectTimeout)
+}
+
+// put returns a publisher to the pool after a successful publish
+func (p *publisherPool) put(brokerURL string, publisher messaging.Publisher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle[brokerURL] = append(p.idle[brokerURL], publisher)
+}
+
+// close closes the idle publishers at the end of a run
+func (p *publisherPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for brokerURL, idle := range p.idle {
+		for _, publisher := range idle {
+			publisher.Close()
+		}
+		delete(p.idle, brokerURL)
+	}
+}
+
+// rateLimiter spaces the requests of a test evenly at a target rate,
+// whichever worker sends them
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// reserve returns the time the next request may start, and how far behind
+// its slot the request already is when the senders fell behind the rate
+func (r *rateLimiter) reserve(now time.Time) (time.Time, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lag time.Duration
+	if r.next.Before(now) {
+		if !r.next.IsZero() {
+			lag = now.Sub(r.next)
+		}
+		r.next = now
+	}
+	slot := r.next
+	r.next = slot.Add(r.interval)
+	return slot, lag
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// fdReserve is the number of file descriptors kept for everything but
+// connections: standard streams, data files, reports, and logs
+const fdReserve = 64
+
+// Preflight checks that the machine can hold the connections config needs
+// with workers. A soft limit of open files too low for them is raised up to
+// the hard limit. It returns a warning with guidance for every limit that is
+// still too low; limits are only known on Linux.
+func Preflight(config *models.Config, workers int) []string {
+	perTarget, connections := connectionNeeds(config, workers)
+	var warnings []string
+
+	needed := connections + fdReserve
+	if limit := openFilesLimit(); limit > 0 && limit < needed {
+		if limit = raiseOpenFilesLimit(needed); limit < needed {
+			warnings = append(warnings, fmt.Sprintf(
+				"the run needs about %d open files (%d connections plus %d for files and logs), above the limit of %d: connections will fail with \"too many open files\"; raise it with 'ulimit -n %d' (LimitNOFILE for a systemd service) or use fewer workers",
+				needed, connections, fdReserve, limit, needed))
+		}
+	}
+
+	if ports := ephemeralPortRange(); ports > 0 && float64(perTarget) >= float64(ports)*generatorResourceWarnRate {
+		warnings = append(warnings, fmt.Sprintf(
+			"up to %d connections to one target use most of the %d ephemeral ports: connections may fail to open; widen the range (sysctl net.ipv4.ip_local_port_range) or use fewer workers",
+			perTarget, ports))
+	}
+	return warnings
+}
+
+// connectionNeeds estimates the connections a run keeps open: every worker
+// may hold one to each target, and a warm pool can open more to the base
+// URL's host. It returns the connections to one target and in total.
+func connectionNeeds(config *models.Config, workers int) (perTarget, total int) {
+	concurrency := workers
+	if len(config.Scenarios) > 0 {
+		// Scenarios run concurrently, each with its own workers
+		concurrency = 0
+		for _, scenario := range config.Scenarios {
+			if scenario.Workers > 0 {
+				concurrency += scenario.Workers
+			} else {
+				concurrency += workers
+			}
+		}
+	}
+
+	hosts := make(map[string]bool)
+	for _, test := range config.Tests {
+		switch {
+		case test.Socket != nil:
+			hosts[test.Socket.Address] = true
+		case test.Messaging != nil:
+			hosts[test.Messaging.URL] = true
+		case test.SQL != nil:
+			hosts[dsnTarget(test.SQL.DSN)] = true
+		default:
+			for _, baseURL := range baseURLs(config) {
+				hosts[baseURL] = true
+			}
+		}
+		if test.CompareWith != nil {
+			hosts[test.CompareWith.Endpoint] = true
+		}
+	}
+
+	perTarget = concurrency
+	if warm := config.Global.WarmPool; warm != nil && warm.Connections > perTarget {
+		perTarget = warm.Connections
+	}
+	total = concurrency*len(hosts) + perTarget - concurrency
+	return perTarget, total
+}
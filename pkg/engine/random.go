@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// randomSource is the source of every random choice of a run: think times,
+// random data rows, rotated headers, fault injection, and log sampling. The
+// same seed repeats the same choices, in the order they are asked for.
+type randomSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{r: rand.New(rand.NewSource(seed))}
+}
+
+func (s *randomSource) Float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Float64()
+}
+
+func (s *randomSource) NormFloat64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.NormFloat64()
+}
+
+func (s *randomSource) ExpFloat64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.ExpFloat64()
+}
+
+func (s *randomSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Intn(n)
+}
+
+func (s *randomSource) Int63n(n int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Int63n(n)
+}
+
+func (s *randomSource) Shuffle(n int, swap func(i, j int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.r.Shuffle(n, swap)
+}
+
+// SetSeed seeds the random choices of the run, so that a run with the same
+// seed makes the same ones. With several workers, which worker gets which
+// choice still depends on scheduling; one worker repeats a run exactly.
+func (e *Engine) SetSeed(seed int64) {
+	e.seed = seed
+	e.random = newRandomSource(seed)
+}
+
+// Seed returns the seed of the random choices of the run, reported so that
+// the run can be repeated with -seed
+func (e *Engine) Seed() int64 {
+	return e.seed
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// choices returns a run of random choices of an engine: think times, data
+// rows, rotated headers, and injected latency
+func choices(e *Engine) []interface{} {
+	rows := []map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}}
+	random := newDataCursor(rows, models.DataStrategyRandom, e.random)
+	unique := newDataCursor(rows, models.DataStrategyUnique, e.random)
+	rotator := &headerRotator{name: "X-Key", values: []string{"a", "b", "c"}, random: e.random}
+
+	var got []interface{}
+	for i := 0; i < 4; i++ {
+		row, _ := random.Next()
+		next, _ := unique.Next()
+		got = append(got,
+			e.sampleThinkTime(models.ThinkTimeNormal, 100*time.Millisecond, 30*time.Millisecond, 0, 0),
+			e.randomDuration(10*time.Millisecond, time.Second),
+			row["id"], next["id"], rotator.value(),
+			e.injectedLatency(&models.InjectConfig{Latency: 50 * time.Millisecond, Jitter: 20 * time.Millisecond}))
+	}
+	return got
+}
+
+func TestEngine_Seed(t *testing.T) {
+	first := New(1, nil, false)
+	first.SetSeed(42)
+	again := New(1, nil, false)
+	again.SetSeed(42)
+	other := New(1, nil, false)
+	other.SetSeed(43)
+
+	assert.Equal(t, int64(42), first.Seed())
+	assert.Equal(t, choices(first), choices(again), "the same seed makes the same choices")
+	assert.NotEqual(t, choices(first), choices(other))
+
+	// Without -seed every engine gets its own
+	assert.NotZero(t, New(1, nil, false).Seed())
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// WaitReady polls the readiness health check of config, if any, until the
+// target answers it with an expected status. It returns an error when the
+// target is still not ready at the readiness timeout, so the run can be
+// aborted instead of failing every request, or when the wait is interrupted.
+// With several base URLs, a relative health check must pass on each of them.
+func (e *Engine) WaitReady(config *models.Config) error {
+	readiness := config.Global.Readiness
+	if readiness == nil {
+		return nil
+	}
+	e.loadGlobalVariables(config)
+
+	ctx := context.Background()
+	if e.parent != nil {
+		ctx = e.parent
+	}
+	ctx, cancel := context.WithTimeout(ctx, readiness.Timeout)
+	defer cancel()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: config.Global.InsecureSkipVerify},
+			DisableKeepAlives: true,
+		},
+		Timeout: config.Global.Timeout,
+	}
+	defer client.CloseIdleConnections()
+
+	for _, target := range e.readinessURLs(config) {
+		if err := e.waitReady(ctx, client, config, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitReady polls the health check at target until it passes or ctx ends
+func (e *Engine) waitReady(ctx context.Context, client *http.Client, config *models.Config, target string) error {
+	readiness := config.Global.Readiness
+	start := time.Now()
+	e.log.Info("waiting for target to be ready", "url", target, "timeout", readiness.Timeout)
+	for checks := 1; ; checks++ {
+		problem := e.checkReadiness(ctx, client, config, target)
+		if problem == "" {
+			e.log.Info("target ready", "url", target, "checks", checks, "elapsed", time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+		e.log.Debug("target not ready", "url", target, "check", checks, "problem", problem)
+
+		timer := time.NewTimer(readiness.Interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			if e.parent != nil && e.parent.Err() != nil {
+				return fmt.Errorf("interrupted while waiting for %s to be ready", target)
+			}
+			return fmt.Errorf("%s not ready after %v (%d checks): %s", target, readiness.Timeout, checks, problem)
+		}
+	}
+}
+
+// checkReadiness sends one health check, returning why the target is not
+// ready, or an empty string when it is
+func (e *Engine) checkReadiness(ctx context.Context, client *http.Client, config *models.Config, target string) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err.Error()
+	}
+	for key, value := range config.Global.Headers {
+		req.Header.Set(key, e.varSubstitutor.Substitute(value))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return classifyError(err) + ": " + err.Error()
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	expected := config.Global.Readiness.ExpectedStatus
+	if !slices.Contains(expected, resp.StatusCode) {
+		return fmt.Sprintf("status %d, expected %v", resp.StatusCode, expected)
+	}
+	return ""
+}
+
+// readinessURLs returns the health check URLs, resolving a path against
+// each base URL
+func (e *Engine) readinessURLs(config *models.Config) []string {
+	target := e.varSubstitutor.Substitute(config.Global.Readiness.URL)
+	if strings.Contains(target, "://") {
+		return []string{target}
+	}
+	var urls []string
+	for _, baseURL := range baseURLs(config) {
+		baseURL = strings.TrimSuffix(e.varSubstitutor.Substitute(baseURL), "/")
+		urls = append(urls, baseURL+"/"+strings.TrimPrefix(target, "/"))
+	}
+	return urls
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/replay"
+)
+
+// replaySuccess judges replayed requests with no test of their name and no
+// captured response
+const replaySuccess = "status < 400"
+
+// replayTolerance is how late a replayed request may start and still be on
+// time: timers wake up a little after they are due
+const replayTolerance = time.Millisecond
+
+// replayDroppedHeaders are captured headers that are not sent again: the
+// transport sets them for the replayed request
+var replayDroppedHeaders = []string{"Content-Length", "Accept-Encoding", "Host"}
+
+// SetReplay sets the requests a config with replay sends, read from its
+// capture beforehand; without them the capture is read when the run starts
+func (e *Engine) SetReplay(requests []replay.Request) {
+	e.replayed = requests
+}
+
+// runReplay sends the captured requests again at the pace they were first
+// sent, sped up by the speed of the replay, and collects their results.
+// Requests are due at their time whether or not earlier ones are answered;
+// those no worker is free for start late and count as schedule lag.
+func (e *Engine) runReplay(config *models.Config) *models.Summary {
+	requests := e.replayed
+	if requests == nil {
+		var err error
+		if requests, err = replay.Load(config.Global.Replay.File); err != nil {
+			e.log.Error("failed to load replay capture", "file", config.Global.Replay.File, "error", err)
+			// The run fails rather than passing without sending a request
+			summary := &models.Summary{
+				StatusCodes:     make(map[int]int),
+				Errors:          make(map[string]int),
+				ErrorCategories: make(map[string]int),
+				EndpointResults: make(map[string]*models.EndpointSummary),
+				FailedReqs:      1,
+				Replay:          &models.ReplaySummary{File: config.Global.Replay.File, Speed: config.Global.Replay.Speed},
+			}
+			summary.Errors[fmt.Sprintf("failed to load replay capture: %v", err)] = 1
+			return summary
+		}
+	}
+	replayed := replayConfig(config)
+
+	// With a duration, the replay stops once it is over
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if config.Global.Duration > 0 {
+		ctx, cancel = context.WithTimeout(e.context(), config.Global.Duration)
+	} else {
+		ctx, cancel = context.WithCancel(e.context())
+	}
+	results := make(chan models.TestResult, 1000)
+	go func() {
+		defer cancel()
+		defer close(results)
+		jobs := make(chan Job, 1000)
+		var wg sync.WaitGroup
+		for i := 0; i < e.workers; i++ {
+			wg.Add(1)
+			go e.worker(ctx, i+1, jobs, results, &wg)
+		}
+		go func() {
+			defer close(jobs)
+			e.generateReplayJobs(ctx, replayed, requests, jobs)
+		}()
+		wg.Wait()
+	}()
+
+	summary := e.collectResults(results, len(requests))
+	summary.Replay = &models.ReplaySummary{
+		File:     config.Global.Replay.File,
+		Speed:    config.Global.Replay.Speed,
+		Requests: len(requests),
+	}
+	if len(requests) > 0 {
+		summary.Replay.Span = requests[len(requests)-1].Offset
+	}
+	return summary
+}
+
+// generateReplayJobs sends the job of every captured request when it is due
+func (e *Engine) generateReplayJobs(ctx context.Context, config *models.Config, requests []replay.Request, jobs chan<- Job) {
+	tests := make(map[string]models.TestCase, len(config.Tests))
+	for _, test := range config.Tests {
+		tests[test.Name] = test
+	}
+
+	start := time.Now()
+	for _, request := range requests {
+		due := start.Add(time.Duration(float64(request.Offset) / config.Global.Replay.Speed))
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		if !sendJob(ctx, jobs, e.replayJob(config, tests, request, due)) {
+			return
+		}
+	}
+}
+
+// replayJob returns the job sending a captured request again. It is checked
+// by the test of the config with the name of the one that sent it, or else
+// expected to get the status it got in the capture.
+func (e *Engine) replayJob(config *models.Config, tests map[string]models.TestCase, request replay.Request, due time.Time) Job {
+	target := replayURL(request.URL, config.Global.BaseURL)
+	test, ok := tests[request.Test]
+	if !ok {
+		test = models.TestCase{Name: request.Test}
+		if test.Name == "" {
+			test.Name = request.Method + " " + replayPath(request.URL)
+		}
+		if request.Status != 0 {
+			test.ExpectedStatus = []int{request.Status}
+		} else {
+			test.SuccessWhen = replaySuccess
+		}
+	}
+
+	// The capture says what is sent; the test only how it is checked
+	headers := replayHeaders(request.Headers, config, test)
+	test.Method = request.Method
+	test.Path = replayPath(request.URL)
+	test.PathParams, test.Query, test.RotateHeaders = nil, nil, nil
+	test.Body, test.SOAP = nil, nil
+	test.Headers = headers
+
+	job := Job{Config: config, TestCase: test, URL: target, Due: due}
+	if request.Body != "" {
+		job.Body = []byte(request.Body)
+	}
+	return job
+}
+
+// replayHeaders returns the captured headers to send again. Global headers
+// and those of the test take their place, so that values masked in the
+// capture, such as credentials, can be given again; run and request IDs are
+// left for the replay to set.
+func replayHeaders(captured map[string]string, config *models.Config, test models.TestCase) map[string]string {
+	dropped := make(map[string]bool)
+	for _, name := range replayDroppedHeaders {
+		dropped[name] = true
+	}
+	for name := range config.Global.Headers {
+		dropped[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range []string{config.Global.RunIDHeader, config.RequestIDHeader(test)} {
+		if name != "" {
+			dropped[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	headers := make(map[string]string, len(captured)+len(test.Headers))
+	for name, value := range captured {
+		if !dropped[http.CanonicalHeaderKey(name)] {
+			headers[name] = value
+		}
+	}
+	for name, value := range test.Headers {
+		for existing := range headers {
+			if strings.EqualFold(existing, name) {
+				delete(headers, existing)
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// replayLag returns how far behind its due time a replayed job starts at
+// start, or 0 for other jobs
+func replayLag(job Job, start time.Time) time.Duration {
+	if job.Due.IsZero() {
+		return 0
+	}
+	if lag := start.Sub(job.Due); lag > replayTolerance {
+		return lag
+	}
+	return 0
+}
+
+// replayURL returns the URL a captured request is sent to again: the
+// captured one, with the scheme and host of base_url when it is set
+func replayURL(captured, baseURL string) string {
+	if baseURL == "" {
+		return captured
+	}
+	u, err := url.Parse(captured)
+	if err != nil {
+		return captured
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return captured
+	}
+	u.Scheme, u.Host, u.User = base.Scheme, base.Host, base.User
+	return u.String()
+}
+
+// replayPath returns the path of a captured URL, naming its requests in the
+// results
+func replayPath(captured string) string {
+	u, err := url.Parse(captured)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// replayConfig returns config with the pauses between requests taken out, as
+// a replay keeps those of the capture
+func replayConfig(config *models.Config) *models.Config {
+	replayed := *config
+	replayed.Global.ThinkTime, replayed.Global.ThinkTimeMin, replayed.Global.ThinkTimeMax = 0, 0, 0
+	replayed.Global.ThinkTimeDistribution = ""
+	replayed.Global.Delay, replayed.Global.Pacing = 0, 0
+	replayed.Tests = make([]models.TestCase, len(config.Tests))
+	for i, test := range config.Tests {
+		test.ThinkTime, test.ThinkTimeMin, test.ThinkTimeMax = 0, 0, 0
+		test.ThinkTimeDistribution = ""
+		test.Delay, test.Pacing = 0, 0
+		replayed.Tests[i] = test
+	}
+	return &replayed
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Replay(t *testing.T) {
+	type received struct {
+		at     time.Time
+		method string
+		uri    string
+		body   string
+		token  string
+		tenant string
+	}
+	var mu sync.Mutex
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{time.Now(), r.Method, r.RequestURI, string(body), r.Header.Get("Authorization"), r.Header.Get("X-Tenant")})
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:   server.URL,
+			Timeout:   5 * time.Second,
+			ThinkTime: time.Second,
+			Headers:   map[string]string{"Authorization": "Bearer fresh"},
+			Replay:    &models.ReplayConfig{File: "capture.jsonl", Speed: 2},
+		},
+		Tests: []models.TestCase{{Name: "create", ExpectedStatus: []int{200}}},
+	}
+
+	e := New(2, nil, false)
+	e.SetReplay([]replay.Request{
+		{Test: "list", Method: "GET", URL: "https://api.example.com/orders?page=2", Headers: map[string]string{"X-Tenant": "acme", "Authorization": "***"}, Status: 200},
+		{Offset: 400 * time.Millisecond, Test: "create", Method: "POST", URL: "https://api.example.com/orders", Body: `{"sku":"A1"}`, Status: 201},
+		{Offset: 800 * time.Millisecond, Test: "list", Method: "GET", URL: "https://api.example.com/orders", Status: 200},
+	})
+	start := time.Now()
+	summary := e.Run(config)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, "/orders?page=2", requests[0].uri, "sent to base_url with the captured path and query")
+	assert.Equal(t, "acme", requests[0].tenant)
+	assert.Equal(t, "Bearer fresh", requests[0].token, "global headers replace captured ones")
+	assert.Equal(t, "POST", requests[1].method)
+	assert.Equal(t, `{"sku":"A1"}`, requests[1].body)
+
+	// Twice as fast: 200ms and 400ms apart from the start, without think time
+	assert.InDelta(t, 200*time.Millisecond, requests[1].at.Sub(start), float64(150*time.Millisecond))
+	assert.InDelta(t, 400*time.Millisecond, requests[2].at.Sub(start), float64(150*time.Millisecond))
+
+	// The test of the config checks its requests, the others get their captured status
+	assert.Equal(t, 3, summary.TotalRequests)
+	assert.Equal(t, 1, summary.FailedReqs)
+	require.NotNil(t, summary.Replay)
+	assert.Equal(t, &models.ReplaySummary{File: "capture.jsonl", Speed: 2, Requests: 3, Span: 800 * time.Millisecond}, summary.Replay)
+}
+
+func TestEngine_ReplayMissingCapture(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL: "http://localhost",
+			Timeout: 5 * time.Second,
+			Replay:  &models.ReplayConfig{File: "missing.jsonl", Speed: 1},
+		},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	assert.False(t, summary.Passed(), "a replay without its capture fails")
+	assert.Equal(t, 0, summary.TotalRequests)
+	require.Len(t, summary.Errors, 1)
+	for message := range summary.Errors {
+		assert.Contains(t, message, "failed to load replay capture")
+	}
+}
+
+func TestReplayLag(t *testing.T) {
+	due := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	job := Job{Due: due}
+	assert.Equal(t, time.Duration(0), replayLag(job, due.Add(-time.Millisecond)))
+	assert.Equal(t, time.Duration(0), replayLag(job, due.Add(500*time.Microsecond)), "timer wake-ups are on time")
+	assert.Equal(t, 50*time.Millisecond, replayLag(job, due.Add(50*time.Millisecond)))
+	assert.Equal(t, time.Duration(0), replayLag(Job{}, due))
+}
+
+func TestReplayURL(t *testing.T) {
+	assert.Equal(t, "https://api.example.com/v1/orders?page=2", replayURL("https://api.example.com/v1/orders?page=2", ""))
+	assert.Equal(t, "http://localhost:8080/v1/orders?page=2", replayURL("https://api.example.com/v1/orders?page=2", "http://localhost:8080/v1"))
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// headerRotator hands out the values of a rotated header, one per request
+type headerRotator struct {
+	name   string
+	values []string
+	random *randomSource // Picks the values at random (nil: in turn)
+	next   atomic.Uint64
+}
+
+// value returns the header value for the next request
+func (r *headerRotator) value() string {
+	if r.random != nil {
+		return r.values[r.random.Intn(len(r.values))]
+	}
+	return r.values[(r.next.Add(1)-1)%uint64(len(r.values))]
+}
+
+// headerRotators returns the rotators of a test's rotated headers, building
+// them on first use. Values taken from a data column are read once.
+func (e *Engine) headerRotators(test models.TestCase) []*headerRotator {
+	if cached, ok := e.rotators.Load(test.Name); ok {
+		return cached.([]*headerRotator)
+	}
+
+	names := make([]string, 0, len(test.RotateHeaders))
+	for name := range test.RotateHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rotators []*headerRotator
+	for _, name := range names {
+		rotation := test.RotateHeaders[name]
+		values := rotation.Values
+		if rotation.Column != "" {
+			values = columnValues(e.getDataRows(test), rotation.Column)
+		}
+		if len(values) == 0 {
+			e.log.Warn("rotated header has no values", "test", test.Name, "header", name, "column", rotation.Column)
+			continue
+		}
+		rotator := &headerRotator{name: name, values: values}
+		if rotation.Strategy == models.DataStrategyRandom {
+			rotator.random = e.random
+		}
+		rotators = append(rotators, rotator)
+	}
+
+	cached, _ := e.rotators.LoadOrStore(test.Name, rotators)
+	return cached.([]*headerRotator)
+}
+
+// columnValues returns the non-empty values of column in rows
+func columnValues(rows []map[string]interface{}, column string) []string {
+	var values []string
+	for _, row := range rows {
+		if value, ok := row[column]; ok && value != nil {
+			if s := fmt.Sprint(value); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// pacingSchedule tracks when the next iteration of a worker is due under
+// pacing, so that iterations held back by a slow previous one are known
+type pacingSchedule struct {
+	due time.Time
+}
+
+// lag returns how long after its due time an iteration starting at start
+// began, or 0 without pacing
+func (s *pacingSchedule) lag(start time.Time) time.Duration {
+	if s.due.IsZero() || !start.After(s.due) {
+		return 0
+	}
+	return start.Sub(s.due)
+}
+
+// next schedules the iteration after one that started at start
+func (s *pacingSchedule) next(start time.Time, pacing time.Duration) {
+	if pacing <= 0 {
+		s.due = time.Time{}
+		return
+	}
+	s.due = start.Add(pacing)
+}
+
+// calculateScheduleLag summarizes the requests sent behind their target
+// rate. A request held back by a slow previous one would have been sent
+// earlier by a real client, so its wait counts as latency in the corrected
+// statistics (coordinated omission). It returns nil when no request was late.
+func calculateScheduleLag(allResults []models.TestResult) *models.ScheduleLagSummary {
+	summary := &models.ScheduleLagSummary{}
+	var totalLag, totalCorrected time.Duration
+	var corrected []time.Duration
+	for _, result := range allResults {
+		if result.Skipped {
+			continue
+		}
+		if result.ScheduleLag > 0 {
+			summary.LateRequests++
+			totalLag += result.ScheduleLag
+			if result.ScheduleLag > summary.MaxLag {
+				summary.MaxLag = result.ScheduleLag
+			}
+		}
+		latency := result.ResponseTime + result.ScheduleLag
+		corrected = append(corrected, latency)
+		totalCorrected += latency
+	}
+	if summary.LateRequests == 0 {
+		return nil
+	}
+
+	summary.AvgLag = totalLag / time.Duration(summary.LateRequests)
+	summary.Corrected = models.LatencySummary{
+		Requests:        len(corrected),
+		AvgResponseTime: totalCorrected / time.Duration(len(corrected)),
+		P50ResponseTime: calculatePercentile(corrected, 50),
+		P95ResponseTime: calculatePercentile(corrected, 95),
+		P99ResponseTime: calculatePercentile(corrected, 99),
+	}
+	return summary
+}
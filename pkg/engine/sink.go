@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/sink"
+)
+
+// sinkQueuedBatches is the number of batches that can wait for the sink: a
+// sink that cannot keep up drops the results past them instead of slowing
+// down the run
+const sinkQueuedBatches = 10
+
+// sinkInsertTimeout bounds the insert of one batch
+const sinkInsertTimeout = 30 * time.Second
+
+// resultsSink writes the results of the run to a results_sink table in
+// batches, from a goroutine of its own
+type resultsSink struct {
+	cfg      *models.ResultsSink
+	inserter sink.Inserter
+	runID    string
+	rows     chan sink.Row
+	done     chan struct{}
+	log      *slog.Logger
+	written  int
+	dropped  atomic.Int64
+	err      error // First failed insert
+}
+
+// openResultsSink starts writing results to the sink of cfg, returning nil
+// without one
+func (e *Engine) openResultsSink(cfg *models.ResultsSink) *resultsSink {
+	if cfg == nil {
+		return nil
+	}
+	inserter, err := sink.New(*cfg)
+	if err != nil {
+		e.log.Warn("results sink disabled", "error", err)
+		return nil
+	}
+	s := &resultsSink{
+		cfg:      cfg,
+		inserter: inserter,
+		runID:    e.runID,
+		rows:     make(chan sink.Row, cfg.BatchSize*sinkQueuedBatches),
+		done:     make(chan struct{}),
+		log:      e.log,
+	}
+	go s.run()
+	return s
+}
+
+// add queues a result, dropping it when the queue is full
+func (s *resultsSink) add(result models.TestResult) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.rows <- sink.NewRow(s.runID, result):
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run inserts the queued rows once a batch is full or its flush interval
+// elapsed, until the queue is closed
+func (s *resultsSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]sink.Row, 0, s.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sinkInsertTimeout)
+		err := s.inserter.Insert(ctx, batch)
+		cancel()
+		if err != nil {
+			s.log.Warn("failed to write results to sink", "table", s.cfg.Table, "rows", len(batch), "error", err)
+			s.dropped.Add(int64(len(batch)))
+			if s.err == nil {
+				s.err = err
+			}
+		} else {
+			s.written += len(batch)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case row, ok := <-s.rows:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, row)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// close inserts the rows still queued and waits for the sink to finish
+func (s *resultsSink) close() {
+	if s == nil {
+		return
+	}
+	close(s.rows)
+	<-s.done
+	if dropped := s.dropped.Load(); dropped > 0 {
+		s.log.Warn("results sink dropped results", "table", s.cfg.Table, "written", s.written, "dropped", dropped, "error", s.err)
+		return
+	}
+	s.log.Info("results written to sink", "table", s.cfg.Table, "rows", s.written)
+}
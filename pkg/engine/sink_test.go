@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/sink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ResultsSink(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	var mu sync.Mutex
+	var batches [][]sink.Row
+	clickhouse := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []sink.Row
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row sink.Row
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			batch = append(batch, row)
+		}
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer clickhouse.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL: api.URL,
+			Timeout: 5 * time.Second,
+			ResultsSink: &models.ResultsSink{
+				Type:          models.SinkClickHouse,
+				URL:           clickhouse.URL,
+				Table:         "results",
+				BatchSize:     10,
+				FlushInterval: time.Minute,
+			},
+		},
+		Tests: []models.TestCase{
+			{Name: "ping", Method: "GET", Path: "/ping", Iterations: 25, ExpectedStatus: []int{200}},
+		},
+	}
+	e := New(2, nil, false)
+	e.SetRunID("nightly-42")
+	summary := e.Run(config)
+	require.Equal(t, 25, summary.TotalRequests)
+
+	// Two full batches, and the rest flushed when the run ended
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 10)
+	assert.Len(t, batches[2], 5)
+	row := batches[2][4]
+	assert.Equal(t, "nightly-42", row.RunID)
+	assert.Equal(t, "ping", row.Test)
+	assert.Equal(t, 200, row.StatusCode)
+	assert.True(t, row.Success)
+}
+
+func TestResultsSink_FailedInsert(t *testing.T) {
+	clickhouse := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "table does not exist", http.StatusNotFound)
+	}))
+	defer clickhouse.Close()
+
+	e := New(1, nil, false)
+	s := e.openResultsSink(&models.ResultsSink{Type: models.SinkClickHouse, URL: clickhouse.URL, Table: "results", BatchSize: 2, FlushInterval: time.Minute})
+	require.NotNil(t, s)
+	for i := 0; i < 3; i++ {
+		s.add(models.TestResult{TestName: "ping", Success: true})
+	}
+	s.close()
+
+	// A failed insert loses its batch but not the run
+	assert.Equal(t, 0, s.written)
+	assert.Equal(t, int64(3), s.dropped.Load())
+	assert.ErrorContains(t, s.err, "unexpected status code: 404")
+
+	assert.Nil(t, e.openResultsSink(nil))
+	(*resultsSink)(nil).add(models.TestResult{})
+	(*resultsSink)(nil).close()
+}
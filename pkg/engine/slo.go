@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// sloBuckets is the number of buckets a window is split into: requests
+// leave the window a bucket at a time
+const sloBuckets = 60
+
+// sloMinRequests is the number of requests a window needs before its burn
+// rate is evaluated, so the first few failures cannot raise an alert alone
+const sloMinRequests = 20
+
+// errSLOAbort is the cause of a run stopped by an SLO with abort
+var errSLOAbort = errors.New("slo burn rate exceeded")
+
+// sloMonitor evaluates the slos of a run on every result, over a sliding
+// window per SLO
+type sloMonitor struct {
+	mu        sync.Mutex
+	windows   []*sloWindow
+	alerts    []models.SLOAlert
+	abortedBy string
+}
+
+// sloWindow counts the requests of an SLO in its window
+type sloWindow struct {
+	slo      models.SLO
+	width    time.Duration // Width of a bucket
+	buckets  []sloBucket   // Oldest first
+	requests int
+	bad      int
+	alert    int // Index of the open alert in alerts, -1 without one
+}
+
+type sloBucket struct {
+	slot     int64
+	requests int
+	bad      int
+}
+
+// sloEvent is a change of state of an SLO: an alert raised or cleared
+type sloEvent struct {
+	alert     models.SLOAlert
+	recovered bool
+	abort     bool // The alert stops the run
+}
+
+// newSLOMonitor returns a monitor of slos, or nil without any
+func newSLOMonitor(slos []models.SLO) *sloMonitor {
+	if len(slos) == 0 {
+		return nil
+	}
+	m := &sloMonitor{}
+	for _, slo := range slos {
+		width := max(slo.Window/sloBuckets, time.Millisecond)
+		m.windows = append(m.windows, &sloWindow{slo: slo, width: width, alert: -1})
+	}
+	return m
+}
+
+// observe adds a result finished at now to the windows of its SLOs,
+// returning the alerts raised and cleared by it
+func (m *sloMonitor) observe(result models.TestResult, now time.Time) []sloEvent {
+	if m == nil || result.Skipped || result.Throttled {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []sloEvent
+	for _, w := range m.windows {
+		if w.slo.Test != "" && w.slo.Test != result.TestName {
+			continue
+		}
+		bad := !result.Success || (w.slo.Latency > 0 && result.ResponseTime > w.slo.Latency)
+		w.add(now, bad)
+		burnRate := w.burnRate()
+
+		if w.alert >= 0 {
+			alert := &m.alerts[w.alert]
+			if burnRate > w.slo.MaxBurnRate {
+				alert.PeakBurnRate = max(alert.PeakBurnRate, burnRate)
+				continue
+			}
+			alert.RecoveredAt = now
+			events = append(events, sloEvent{alert: *alert, recovered: true})
+			w.alert = -1
+			continue
+		}
+
+		if w.requests < sloMinRequests || burnRate <= w.slo.MaxBurnRate {
+			continue
+		}
+		alert := models.SLOAlert{
+			SLO:          w.slo.Name,
+			Time:         now,
+			BurnRate:     burnRate,
+			PeakBurnRate: burnRate,
+			BadRequests:  w.bad,
+			Requests:     w.requests,
+		}
+		w.alert = len(m.alerts)
+		m.alerts = append(m.alerts, alert)
+		abort := w.slo.Abort && m.abortedBy == ""
+		if abort {
+			m.abortedBy = w.slo.Name
+		}
+		events = append(events, sloEvent{alert: alert, abort: abort})
+	}
+	return events
+}
+
+// add counts a request at now, dropping the buckets that left the window
+func (w *sloWindow) add(now time.Time, bad bool) {
+	slot := now.UnixNano() / int64(w.width)
+	if n := len(w.buckets); n == 0 || w.buckets[n-1].slot < slot {
+		w.buckets = append(w.buckets, sloBucket{slot: slot})
+	}
+	// Results can finish slightly out of order; they count in the newest bucket
+	last := &w.buckets[len(w.buckets)-1]
+	last.requests++
+	w.requests++
+	if bad {
+		last.bad++
+		w.bad++
+	}
+
+	for len(w.buckets) > 0 && w.buckets[0].slot <= slot-sloBuckets {
+		w.requests -= w.buckets[0].requests
+		w.bad -= w.buckets[0].bad
+		w.buckets = w.buckets[1:]
+	}
+}
+
+// burnRate returns how fast the window spends the error budget of its SLO:
+// 1 spends it exactly as fast as the objective allows
+func (w *sloWindow) burnRate() float64 {
+	if w.requests == 0 {
+		return 0
+	}
+	// The share of bad requests over the share the objective allows
+	return float64(w.bad) * 100 / (float64(w.requests) * (100 - w.slo.Objective))
+}
+
+// result returns the alerts raised during the run and the SLO that stopped
+// it, if any
+func (m *sloMonitor) result() ([]models.SLOAlert, string) {
+	if m == nil {
+		return nil, ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.SLOAlert(nil), m.alerts...), m.abortedBy
+}
+
+// observeSLOs evaluates the slos on a finished result, warning and streaming
+// the alerts it raises or clears and stopping the run for those with abort
+func (e *Engine) observeSLOs(result models.TestResult) {
+	for _, event := range e.slos.observe(result, time.Now()) {
+		alert := event.alert
+		if event.recovered {
+			e.log.Info("SLO burn rate back under its limit", "slo", alert.SLO, "peak_burn_rate", alert.PeakBurnRate)
+			if e.eventStream != nil {
+				e.eventStream.SLORecovered(alert)
+			}
+			continue
+		}
+		e.log.Warn("SLO burn rate exceeded", "slo", alert.SLO, "burn_rate", alert.BurnRate, "bad_requests", alert.BadRequests, "requests", alert.Requests)
+		if e.eventStream != nil {
+			e.eventStream.SLOAlert(alert, event.abort)
+		}
+		if event.abort {
+			e.log.Warn("run stopped by SLO", "slo", alert.SLO)
+			e.abort(errSLOAbort)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLOMonitor(t *testing.T) {
+	assert.Nil(t, newSLOMonitor(nil))
+	assert.Nil(t, (*sloMonitor)(nil).observe(models.TestResult{}, time.Now()))
+
+	monitor := newSLOMonitor([]models.SLO{
+		{Name: "latency", Test: "search", Latency: 100 * time.Millisecond, Objective: 90, Window: time.Minute, MaxBurnRate: 2},
+	})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fast := models.TestResult{TestName: "search", Success: true, ResponseTime: 10 * time.Millisecond}
+	slow := models.TestResult{TestName: "search", Success: true, ResponseTime: 300 * time.Millisecond}
+
+	// Other tests, skipped, and throttled requests do not count
+	for i := 0; i < 30; i++ {
+		assert.Empty(t, monitor.observe(models.TestResult{TestName: "login"}, start))
+		assert.Empty(t, monitor.observe(models.TestResult{TestName: "search", Skipped: true}, start))
+		assert.Empty(t, monitor.observe(models.TestResult{TestName: "search", Throttled: true}, start))
+	}
+
+	// Too few requests to raise an alert, even if all of them are bad
+	for i := 0; i < sloMinRequests-1; i++ {
+		assert.Empty(t, monitor.observe(slow, start))
+	}
+	// The 20th bad request raises it: 100% bad against a 10% budget
+	events := monitor.observe(slow, start)
+	require.Len(t, events, 1)
+	assert.False(t, events[0].recovered)
+	assert.False(t, events[0].abort)
+	assert.Equal(t, 10.0, events[0].alert.BurnRate)
+	assert.Equal(t, 20, events[0].alert.BadRequests)
+	assert.Equal(t, 20, events[0].alert.Requests)
+
+	// A minute later the bad requests left the window
+	later := start.Add(time.Minute + time.Second)
+	events = monitor.observe(fast, later)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].recovered)
+	assert.Equal(t, later, events[0].alert.RecoveredAt)
+
+	alerts, abortedBy := monitor.result()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 10.0, alerts[0].PeakBurnRate)
+	assert.Equal(t, later, alerts[0].RecoveredAt)
+	assert.Empty(t, abortedBy)
+
+	// 1 bad in 10 spends the budget exactly as fast as allowed
+	window := &sloWindow{slo: models.SLO{Objective: 90}, width: time.Second}
+	for i := 0; i < 10; i++ {
+		window.add(start, i == 0)
+	}
+	assert.InDelta(t, 1.0, window.burnRate(), 1e-9)
+}
+
+func TestEngine_SLOAbort(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL: server.URL,
+			Timeout: 5 * time.Second,
+			SLOs: []models.SLO{
+				{Name: "watch", Objective: 99, Window: time.Minute, MaxBurnRate: 1},
+				{Name: "errors", Objective: 99, Window: time.Minute, MaxBurnRate: 1, Abort: true},
+			},
+		},
+		Tests: []models.TestCase{
+			{Name: "ping", Method: "GET", Path: "/ping", Iterations: 5000, ExpectedStatus: []int{200}},
+		},
+	}
+	summary := New(2, nil, false).Run(config)
+
+	assert.Equal(t, "errors", summary.AbortedBy)
+	assert.False(t, summary.MaxDurationReached)
+	assert.False(t, summary.Passed())
+	assert.True(t, summary.FailedUnder(models.FailOnErrors))
+	assert.Less(t, hits.Load(), int64(5000))
+	require.Len(t, summary.SLOAlerts, 2)
+	assert.Equal(t, "watch", summary.SLOAlerts[0].SLO)
+	assert.Equal(t, "errors", summary.SLOAlerts[1].SLO)
+	assert.Equal(t, 100.0, summary.SLOAlerts[1].BurnRate)
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Envelope namespaces of the SOAP versions
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// soapEnvelope returns the envelope of a SOAP call, before variable
+// substitution
+func soapEnvelope(soap *models.SOAPRequest) string {
+	if soap.Envelope != "" {
+		return soap.Envelope
+	}
+	namespace := soap11Namespace
+	if soap.Version == models.SOAP12 {
+		namespace = soap12Namespace
+	}
+
+	var envelope strings.Builder
+	envelope.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	envelope.WriteString(`<soap:Envelope xmlns:soap="` + namespace + `">`)
+	if soap.Header != "" {
+		envelope.WriteString("<soap:Header>" + soap.Header + "</soap:Header>")
+	}
+	envelope.WriteString("<soap:Body>" + soap.Body + "</soap:Body>")
+	envelope.WriteString("</soap:Envelope>")
+	return envelope.String()
+}
+
+// setSOAPHeaders sets the Content-Type and action of a SOAP call, unless
+// the test sets them itself. SOAP 1.1 sends the action in the SOAPAction
+// header, SOAP 1.2 as the action parameter of the Content-Type.
+func setSOAPHeaders(header http.Header, soap *models.SOAPRequest, action string) {
+	if soap.Version == models.SOAP12 {
+		if header.Get("Content-Type") == "" {
+			contentType := "application/soap+xml; charset=utf-8"
+			if action != "" {
+				contentType += `; action="` + action + `"`
+			}
+			header.Set("Content-Type", contentType)
+		}
+		return
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/xml; charset=utf-8")
+	}
+	if header.Get("SOAPAction") == "" {
+		header.Set("SOAPAction", `"`+action+`"`)
+	}
+}
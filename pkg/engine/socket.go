@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/assertion"
+)
+
+// maxSocketRead is the size of the buffer replies are read into
+const maxSocketRead = 64 * 1024
+
+// executeSocket sends the payload of a socket test, waits for its reply
+// when the test asks for one, and checks it. The response time is the
+// round trip from the dial to the end of the reply.
+func (e *Engine) executeSocket(job Job, start time.Time) models.TestResult {
+	socket := job.TestCase.Socket
+	address := e.varSubstitutor.SubstituteScoped(socket.Address, job.Scope)
+	result := models.TestResult{
+		TestName:  job.TestCase.Name,
+		URL:       socket.Protocol + "://" + address,
+		Method:    strings.ToUpper(socket.Protocol),
+		Timestamp: start,
+	}
+
+	payload, err := e.socketPayload(job, address)
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorCategory = ErrorRequest
+		return result
+	}
+	result.RequestSize = int64(len(payload))
+
+	if e.injectFault(job.Config.TestInject(job.TestCase)) {
+		result.ResponseTime = time.Since(start)
+		result.Error = "request dropped by fault injection"
+		result.ErrorCategory = ErrorInjected
+		return result
+	}
+
+	timeout := job.TestCase.Timeout
+	if timeout == 0 {
+		timeout = job.Config.Global.Timeout
+	}
+	ctx := e.context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	dialer := net.Dialer{Timeout: job.Config.TestTransportTimeouts(job.TestCase).Connect}
+	conn, err := dialer.DialContext(ctx, socket.Protocol, address)
+	if err != nil {
+		result.ResponseTime = time.Since(start)
+		result.Error = e.redactor.String(err.Error())
+		result.ErrorCategory = classifyError(err)
+		return result
+	}
+	defer conn.Close()
+
+	// The timeout covers the whole exchange, and stopping the run aborts it
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			result.ResponseTime = time.Since(start)
+			result.Error = e.redactor.String(err.Error())
+			result.ErrorCategory = classifyError(err)
+			return result
+		}
+	}
+
+	reply, err := readReply(conn, socket)
+	result.ResponseTime = time.Since(start)
+	result.ResponseSize = int64(len(reply))
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to read reply: %v", err)
+		result.ErrorCategory = classifyError(err)
+		return result
+	}
+	if e.verbose {
+		result.BodySample = bodySample(e.capturedBody(reply))
+	}
+
+	e.checkReply(job, &result, reply)
+	return result
+}
+
+// checkReply decides the success of a request without an HTTP response:
+// it succeeds unless its reply misses success_when or an assertion, or a
+// variable cannot be extracted from it
+func (e *Engine) checkReply(job Job, result *models.TestResult, reply []byte) {
+	result.Success = true
+	if job.TestCase.SuccessWhen != "" {
+		met, conditionErr := e.meetsSuccessCondition(job.TestCase.SuccessWhen, replyContext(result, reply))
+		if !met {
+			result.Success = false
+			result.ErrorCategory = ErrorSuccessWhen
+			if conditionErr != nil {
+				result.Error = fmt.Sprintf("success_when: %v", conditionErr)
+			} else {
+				result.Error = fmt.Sprintf("success_when not met: %s", job.TestCase.SuccessWhen)
+			}
+		}
+	}
+
+	if rules := extractionRules(job.TestCase.Extract, result.Success); len(rules) > 0 {
+		if err := e.varExtractor.ExtractScoped(rules, reply, nil, 0, job.Loop); err != nil && result.Success {
+			result.Error = fmt.Sprintf("Variable extraction failed: %v", err)
+			result.ErrorCategory = ErrorExtraction
+			result.Success = false
+		}
+	}
+	extracted := result.Success
+
+	if len(job.TestCase.Assertions) > 0 {
+		ctx := replyContext(result, reply)
+		for i, ar := range e.assertionEvaluator.EvaluateAll(job.TestCase.Assertions, ctx) {
+			outcome := models.AssertionOutcome{Assertion: job.TestCase.Assertions[i], Passed: ar.Passed}
+			if ar.Passed {
+				result.AssertionsPassed++
+			} else {
+				result.AssertionsFailed++
+				result.AssertionErrors = append(result.AssertionErrors, ar.Message)
+				result.Success = false
+				outcome.Message = ar.Message
+			}
+			result.Assertions = append(result.Assertions, outcome)
+		}
+		if result.AssertionsFailed > 0 && result.ErrorCategory == "" {
+			result.ErrorCategory = ErrorAssertion
+		}
+	}
+
+	if extracted {
+		e.assertVariables(job, result)
+	}
+}
+
+// replyContext returns the assertion context of a request without an HTTP
+// response
+func replyContext(result *models.TestResult, reply []byte) *assertion.Context {
+	ctx := assertion.NewContext(0, result.ResponseTime, reply, nil)
+	ctx.RowCount = result.RowCount
+	return ctx
+}
+
+// socketPayload returns the bytes a socket test sends: its hex-decoded
+// payload_hex, or its payload with variables substituted
+func (e *Engine) socketPayload(job Job, address string) ([]byte, error) {
+	socket := job.TestCase.Socket
+	if socket.PayloadHex != "" {
+		payload, err := hex.DecodeString(socket.PayloadHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload_hex: %w", err)
+		}
+		return payload, nil
+	}
+
+	payload := e.varSubstitutor.SubstituteScoped(socket.Payload, job.Scope)
+	if job.Config.Global.StrictVariables {
+		if err := unresolvedVariables([]interface{}{address, payload}); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(payload), nil
+}
+
+// readReply reads the reply of a socket test: read_bytes bytes, or up to and
+// including the delimiter. Without either, the test does not wait for a
+// reply. UDP replies are read a datagram at a time.
+func readReply(conn net.Conn, socket *models.SocketConfig) ([]byte, error) {
+	if socket.ReadBytes == 0 && socket.Delimiter == "" {
+		return nil, nil
+	}
+
+	var reply []byte
+	buf := make([]byte, maxSocketRead)
+	for {
+		n, err := conn.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if socket.ReadBytes > 0 && len(reply) >= socket.ReadBytes {
+			return reply[:socket.ReadBytes], nil
+		}
+		if socket.Delimiter != "" {
+			if i := bytes.Index(reply, []byte(socket.Delimiter)); i >= 0 {
+				return reply[:i+len(socket.Delimiter)], nil
+			}
+		}
+		if err != nil {
+			return reply, fmt.Errorf("after %d bytes: %w", len(reply), err)
+		}
+	}
+}
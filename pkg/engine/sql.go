@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/sqlclient"
+)
+
+// executeSQL runs the query of a sql test and checks its rows. The response
+// time is the query latency, plus the connection when no idle one is left.
+func (e *Engine) executeSQL(job Job, start time.Time) models.TestResult {
+	config := job.TestCase.SQL
+	dsn := e.varSubstitutor.SubstituteScoped(config.DSN, job.Scope)
+	result := models.TestResult{
+		TestName:  job.TestCase.Name,
+		URL:       dsnTarget(dsn),
+		Method:    "POSTGRES",
+		Timestamp: start,
+	}
+	if strings.HasPrefix(dsn, "mysql:") {
+		result.Method = "MYSQL"
+	}
+
+	query := e.varSubstitutor.SubstituteScoped(config.Query, job.Scope)
+	params := make([]interface{}, len(config.Params))
+	substituted := []interface{}{dsn, query}
+	for i, param := range config.Params {
+		if text, ok := param.(string); ok {
+			param = e.varSubstitutor.SubstituteScoped(text, job.Scope)
+			substituted = append(substituted, param)
+		}
+		params[i] = param
+	}
+	if job.Config.Global.StrictVariables {
+		if err := unresolvedVariables(substituted); err != nil {
+			result.Error = err.Error()
+			result.ErrorCategory = ErrorRequest
+			return result
+		}
+	}
+	result.RequestSize = int64(len(query))
+
+	if e.injectFault(job.Config.TestInject(job.TestCase)) {
+		result.ResponseTime = time.Since(start)
+		result.Error = "request dropped by fault injection"
+		result.ErrorCategory = ErrorInjected
+		return result
+	}
+
+	timeout := job.TestCase.Timeout
+	if timeout == 0 {
+		timeout = job.Config.Global.Timeout
+	}
+	ctx := e.context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var rows *sqlclient.Result
+	conn, err := e.databases.get(ctx, dsn, job.Config.TestTransportTimeouts(job.TestCase).Connect)
+	if err == nil {
+		rows, err = conn.Query(ctx, query, params)
+		// A query rejected by the database leaves its connection usable
+		var serverErr *sqlclient.ServerError
+		if err == nil || errors.As(err, &serverErr) {
+			e.databases.put(dsn, conn)
+		} else {
+			conn.Close()
+		}
+	}
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = e.redactor.String(err.Error())
+		var serverErr *sqlclient.ServerError
+		if errors.As(err, &serverErr) {
+			result.ErrorCategory = ErrorQuery
+		} else {
+			result.ErrorCategory = classifyError(err)
+		}
+		return result
+	}
+
+	body := rows.JSON()
+	result.ResponseSize = int64(len(body))
+	result.RowCount = &rows.RowCount
+	if e.verbose {
+		result.BodySample = bodySample(e.capturedBody(body))
+	}
+
+	e.checkReply(job, &result, body)
+	return result
+}
+
+// dsnTarget returns the DSN of a sql test without its credentials
+func dsnTarget(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		scheme, _, _ := strings.Cut(dsn, "://")
+		return scheme + "://"
+	}
+	u.User = nil
+	return u.String()
+}
+
+// databasePool keeps the idle database connections of sql tests, so queries
+// reuse them like HTTP keep-alive connections
+type databasePool struct {
+	mu   sync.Mutex
+	idle map[string][]sqlclient.Conn // DSN -> idle connections
+}
+
+func newDatabasePool() *databasePool {
+	return &databasePool{idle: make(map[string][]sqlclient.Conn)}
+}
+
+// get returns an idle connection to a database, or opens a new one
+func (p *databasePool) get(ctx context.Context, dsn string, connectTimeout time.Duration) (sqlclient.Conn, error) {
+	p.mu.Lock()
+	if idle := p.idle[dsn]; len(idle) > 0 {
+		conn := idle[len(idle)-1]
+		p.idle[dsn] = idle[:len(idle)-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+	return sqlclient.Open(ctx, dsn, connectTimeout)
+}
+
+// put returns a connection to the pool after a query
+func (p *databasePool) put(dsn string, conn sqlclient.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle[dsn] = append(p.idle[dsn], conn)
+}
+
+// close closes the idle connections at the end of a run
+func (p *databasePool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for dsn, idle := range p.idle {
+		for _, conn := range idle {
+			conn.Close()
+		}
+		delete(p.idle, dsn)
+	}
+}
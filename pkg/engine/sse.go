@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// maxSSELine is the longest line of an event stream that can be read
+const maxSSELine = 1 << 20
+
+// readEvents reads the events of a Server-Sent Events stream until its
+// window ends, max_events are read, or the server closes it. The window
+// starts when the response headers arrive; cancel aborts the request when
+// it ends. It returns the events, the bytes read, and a read error other
+// than the end of the window.
+func readEvents(ctx context.Context, cancel context.CancelFunc, body io.Reader, start time.Time, sse *models.SSEConfig) (*models.SSEResult, int64, error) {
+	timer := time.AfterFunc(sse.Window, cancel)
+	defer timer.Stop()
+
+	result := &models.SSEResult{}
+	var read int64
+	var data []string
+	hasData := false
+	var last time.Time
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELine)
+	for scanner.Scan() {
+		line := scanner.Text()
+		read += int64(len(line)) + 1
+
+		if line != "" {
+			// Comments start with a colon; other fields than data do not
+			// change the measurements
+			field, value, _ := strings.Cut(line, ":")
+			if field == "data" {
+				data = append(data, strings.TrimPrefix(value, " "))
+				hasData = true
+			}
+			continue
+		}
+
+		// A blank line dispatches the event, if it has data
+		if !hasData {
+			continue
+		}
+		now := time.Now()
+		if result.Events == 0 {
+			result.FirstEvent = now.Sub(start)
+		} else {
+			result.Intervals = append(result.Intervals, now.Sub(last))
+		}
+		last = now
+		result.Events++
+		result.LastData = strings.Join(data, "\n")
+		data, hasData = nil, false
+
+		if sse.MaxEvents > 0 && result.Events >= sse.MaxEvents {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return result, read, err
+	}
+	return result, read, nil
+}
+
+// sseStats aggregates the streams of an endpoint
+func sseStats(streams []*models.SSEResult) models.SSEStats {
+	var stats models.SSEStats
+	var firstEvents time.Duration
+	withEvents := 0
+	var intervals []time.Duration
+	for _, stream := range streams {
+		stats.Streams++
+		stats.Events += stream.Events
+		if stream.Events > 0 {
+			withEvents++
+			firstEvents += stream.FirstEvent
+		}
+		intervals = append(intervals, stream.Intervals...)
+	}
+	if withEvents > 0 {
+		stats.AvgFirstEvent = firstEvents / time.Duration(withEvents)
+	}
+	if len(intervals) > 0 {
+		var total time.Duration
+		for _, interval := range intervals {
+			total += interval
+			stats.MaxInterval = max(stats.MaxInterval, interval)
+		}
+		stats.AvgInterval = total / time.Duration(len(intervals))
+		stats.P95Interval = calculatePercentile(intervals, 95)
+	}
+	return stats
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// calculateSteadyState splits the executed requests into ramp and steady
+// state. The run is cut into windows of config.Window, and the steady state
+// starts at the first of config.StableWindows consecutive windows whose P95
+// is within config.Tolerance percent of the previous window's. The first
+// window has no previous one, so it always belongs to the ramp.
+func calculateSteadyState(allResults []models.TestResult, config *models.SteadyStateConfig) *models.SteadyStateSummary {
+	if config == nil {
+		return nil
+	}
+
+	var executed []models.TestResult
+	var runStart time.Time
+	for _, result := range allResults {
+		if result.Skipped {
+			continue
+		}
+		executed = append(executed, result)
+		if runStart.IsZero() || result.Timestamp.Before(runStart) {
+			runStart = result.Timestamp
+		}
+	}
+	summary := &models.SteadyStateSummary{}
+	if len(executed) == 0 {
+		return summary
+	}
+
+	window := func(result models.TestResult) int {
+		return int(result.Timestamp.Sub(runStart) / config.Window)
+	}
+	var windowTimes [][]time.Duration
+	for _, result := range executed {
+		i := window(result)
+		for len(windowTimes) <= i {
+			windowTimes = append(windowTimes, nil)
+		}
+		windowTimes[i] = append(windowTimes[i], result.ResponseTime)
+	}
+
+	steadyWindow := -1
+	stable := 0
+	previous := time.Duration(-1) // P95 of the previous window, -1 when it had no requests
+	for i, times := range windowTimes {
+		if len(times) == 0 {
+			stable, previous = 0, -1
+			continue
+		}
+		p95 := calculatePercentile(times, 95)
+		if previous >= 0 && withinTolerance(p95, previous, config.Tolerance) {
+			stable++
+		} else {
+			stable = 0
+		}
+		previous = p95
+		if stable == config.StableWindows {
+			steadyWindow = i - stable + 1
+			break
+		}
+	}
+
+	var ramp, steady []models.TestResult
+	for _, result := range executed {
+		if steadyWindow >= 0 && window(result) >= steadyWindow {
+			steady = append(steady, result)
+		} else {
+			ramp = append(ramp, result)
+		}
+	}
+	summary.Ramp = periodSummary(ramp)
+	if steadyWindow >= 0 {
+		summary.Reached = true
+		summary.Start = time.Duration(steadyWindow) * config.Window
+		summary.Steady = periodSummary(steady)
+	}
+	return summary
+}
+
+// withinTolerance reports whether p95 differs from previous by at most
+// tolerance percent of previous
+func withinTolerance(p95, previous time.Duration, tolerance float64) bool {
+	if previous == 0 {
+		return p95 == 0
+	}
+	change := math.Abs(float64(p95-previous)) / float64(previous) * 100
+	return change <= tolerance
+}
+
+// periodSummary aggregates the executed requests of a period of the run
+func periodSummary(results []models.TestResult) models.PeriodSummary {
+	var period models.PeriodSummary
+	if len(results) == 0 {
+		return period
+	}
+
+	times := make([]time.Duration, 0, len(results))
+	var total time.Duration
+	var start, end time.Time
+	for _, result := range results {
+		times = append(times, result.ResponseTime)
+		total += result.ResponseTime
+		if !result.Success && !result.Throttled {
+			period.FailedReqs++
+		}
+		if start.IsZero() || result.Timestamp.Before(start) {
+			start = result.Timestamp
+		}
+		if finish := result.Timestamp.Add(result.ResponseTime); finish.After(end) {
+			end = finish
+		}
+	}
+
+	period.Requests = len(results)
+	period.AvgResponseTime = total / time.Duration(len(results))
+	period.P50ResponseTime = calculatePercentile(times, 50)
+	period.P95ResponseTime = calculatePercentile(times, 95)
+	period.P99ResponseTime = calculatePercentile(times, 99)
+	period.Duration = end.Sub(start)
+	if period.Duration > 0 {
+		period.RequestsPerSec = float64(len(results)) / period.Duration.Seconds()
+	}
+	return period
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// targetBalancer spreads the requests over the base URLs of a base_url list
+// in proportion to their weights. It uses smooth weighted round-robin, which
+// interleaves the targets instead of sending bursts to the heaviest one.
+type targetBalancer struct {
+	mu      sync.Mutex
+	targets []models.Target
+	current []int
+	total   int
+}
+
+// newTargetBalancer returns a balancer over targets, or nil with fewer than
+// two of them
+func newTargetBalancer(targets []models.Target) *targetBalancer {
+	if len(targets) < 2 {
+		return nil
+	}
+	b := &targetBalancer{targets: targets, current: make([]int, len(targets))}
+	for _, target := range targets {
+		b.total += targetWeight(target)
+	}
+	return b
+}
+
+// targetWeight returns the weight of a target, 1 when unset
+func targetWeight(target models.Target) int {
+	if target.Weight <= 0 {
+		return 1
+	}
+	return target.Weight
+}
+
+// next returns the base URL of the next request, or "" without a balancer
+func (b *targetBalancer) next() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	best := 0
+	for i, target := range b.targets {
+		b.current[i] += targetWeight(target)
+		if b.current[i] > b.current[best] {
+			best = i
+		}
+	}
+	b.current[best] -= b.total
+	return b.targets[best].URL
+}
+
+// baseURLs returns the base URLs of a run: the targets of a base_url list,
+// or the single base_url
+func baseURLs(config *models.Config) []string {
+	if len(config.Global.Targets) == 0 {
+		return []string{config.Global.BaseURL}
+	}
+	urls := make([]string, len(config.Global.Targets))
+	for i, target := range config.Global.Targets {
+		urls[i] = target.URL
+	}
+	return urls
+}
+
+// retarget points a job URL built from the global base_url at target
+func retarget(url, baseURL, target string) string {
+	rest, ok := strings.CutPrefix(url, strings.TrimSuffix(baseURL, "/"))
+	if !ok {
+		return url
+	}
+	return strings.TrimSuffix(target, "/") + rest
+}
+
+// summaries aggregates the results per target, in the order of base_url.
+// It returns nil without a balancer.
+func (b *targetBalancer) summaries(allResults []models.TestResult) []*models.TargetSummary {
+	if b == nil {
+		return nil
+	}
+	summaries := make([]*models.TargetSummary, len(b.targets))
+	byURL := make(map[string]*models.TargetSummary, len(b.targets))
+	times := make(map[string][]time.Duration, len(b.targets))
+	for i, target := range b.targets {
+		summaries[i] = &models.TargetSummary{URL: target.URL, Weight: targetWeight(target), StatusCodes: make(map[int]int)}
+		byURL[target.URL] = summaries[i]
+	}
+
+	for _, result := range allResults {
+		summary := byURL[result.Target]
+		if summary == nil || result.Skipped {
+			continue
+		}
+		summary.TotalRequests++
+		if result.Success {
+			summary.SuccessfulReqs++
+		} else if !result.Throttled {
+			summary.FailedReqs++
+		}
+		if result.StatusCode > 0 {
+			summary.StatusCodes[result.StatusCode]++
+		}
+		times[result.Target] = append(times[result.Target], result.ResponseTime)
+	}
+
+	for _, summary := range summaries {
+		if len(times[summary.URL]) == 0 {
+			continue
+		}
+		var total time.Duration
+		for _, t := range times[summary.URL] {
+			total += t
+		}
+		summary.AvgResponseTime = total / time.Duration(len(times[summary.URL]))
+		summary.P95ResponseTime = calculatePercentile(times[summary.URL], 95)
+	}
+	return summaries
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetBalancer(t *testing.T) {
+	assert.Nil(t, newTargetBalancer(nil))
+	assert.Nil(t, newTargetBalancer([]models.Target{{URL: "http://a"}}))
+	assert.Equal(t, "", (*targetBalancer)(nil).next())
+
+	b := newTargetBalancer([]models.Target{{URL: "http://a"}, {URL: "http://b", Weight: 3}})
+	var picks []string
+	for i := 0; i < 8; i++ {
+		picks = append(picks, b.next())
+	}
+	assert.Equal(t, []string{"http://b", "http://a", "http://b", "http://b", "http://b", "http://a", "http://b", "http://b"}, picks)
+}
+
+func TestRetarget(t *testing.T) {
+	assert.Equal(t, "http://b:8080/v1/users?id=1", retarget("http://a/v1/users?id=1", "http://a/", "http://b:8080/"))
+	assert.Equal(t, "http://b/api/health", retarget("http://a/api/health", "http://a/api", "http://b/api"))
+	assert.Equal(t, "http://other/health", retarget("http://other/health", "http://a", "http://b"))
+}
+
+func TestEngine_Targets(t *testing.T) {
+	var hitsA, hitsB atomic.Int64
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			hitsA.Add(1)
+		}
+	}))
+	defer serverA.Close()
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			hitsB.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer serverB.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    serverA.URL,
+			Targets:    []models.Target{{URL: serverA.URL}, {URL: serverB.URL + "/", Weight: 3}},
+			Timeout:    5 * time.Second,
+			Iterations: 8,
+			Readiness:  &models.ReadinessConfig{URL: "/ready", ExpectedStatus: []int{200}, Timeout: 5 * time.Second, Interval: 10 * time.Millisecond},
+		},
+		Tests: []models.TestCase{{Name: "Health", Method: "GET", Path: "/health", ExpectedStatus: []int{200}}},
+	}
+	engine := New(1, nil, false)
+	require.NoError(t, engine.WaitReady(config))
+	summary := engine.Run(config)
+
+	assert.Equal(t, int64(2), hitsA.Load())
+	assert.Equal(t, int64(6), hitsB.Load())
+	require.Len(t, summary.TargetResults, 2)
+	a, b := summary.TargetResults[0], summary.TargetResults[1]
+	assert.Equal(t, serverA.URL, a.URL)
+	assert.Equal(t, 1, a.Weight)
+	assert.Equal(t, 2, a.TotalRequests)
+	assert.Equal(t, 2, a.SuccessfulReqs)
+	assert.Equal(t, map[int]int{200: 2}, a.StatusCodes)
+	assert.Equal(t, serverB.URL+"/", b.URL)
+	assert.Equal(t, 3, b.Weight)
+	assert.Equal(t, 6, b.FailedReqs)
+	assert.Equal(t, map[int]int{500: 6}, b.StatusCodes)
+	assert.Greater(t, b.AvgResponseTime, time.Duration(0))
+
+	config.Global.Targets = nil
+	assert.Nil(t, New(1, nil, false).Run(config).TargetResults)
+}
@@ -296,3 +296,176 @@ func TestEngine_ThinkTime_DAGMode(t *testing.T) {
 	assert.True(t, totalTime >= 80*time.Millisecond,
 		"Total time should include think time, got %v", totalTime)
 }
+
+func TestEngine_CalculateThinkTime_Distributions(t *testing.T) {
+	engine := New(1, nil, false)
+	const samples = 20000
+
+	meanOf := func(job Job) (mean, min, max time.Duration) {
+		var total time.Duration
+		min = time.Hour
+		for i := 0; i < samples; i++ {
+			d := engine.calculateThinkTime(job)
+			total += d
+			if d < min {
+				min = d
+			}
+			if d > max {
+				max = d
+			}
+		}
+		return total / samples, min, max
+	}
+
+	tests := []struct {
+		name     string
+		test     models.TestCase
+		wantMean time.Duration
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{
+			name:     "normal",
+			test:     models.TestCase{ThinkTimeDistribution: models.ThinkTimeNormal, ThinkTime: time.Second, ThinkTimeStddev: 100 * time.Millisecond},
+			wantMean: time.Second,
+		},
+		{
+			name:     "normal bounded",
+			test:     models.TestCase{ThinkTimeDistribution: models.ThinkTimeNormal, ThinkTime: time.Second, ThinkTimeStddev: time.Second, ThinkTimeMin: 500 * time.Millisecond, ThinkTimeMax: 1500 * time.Millisecond},
+			wantMean: time.Second,
+			wantMin:  500 * time.Millisecond,
+			wantMax:  1500 * time.Millisecond,
+		},
+		{
+			name:     "exponential",
+			test:     models.TestCase{ThinkTimeDistribution: models.ThinkTimeExponential, ThinkTime: time.Second},
+			wantMean: time.Second,
+		},
+		{
+			name:     "uniform from mean and stddev",
+			test:     models.TestCase{ThinkTimeDistribution: models.ThinkTimeUniform, ThinkTime: time.Second, ThinkTimeStddev: 100 * time.Millisecond},
+			wantMean: time.Second,
+			wantMin:  826 * time.Millisecond,
+			wantMax:  1174 * time.Millisecond,
+		},
+		{
+			name:     "uniform from range",
+			test:     models.TestCase{ThinkTimeDistribution: models.ThinkTimeUniform, ThinkTimeMin: 200 * time.Millisecond, ThinkTimeMax: 400 * time.Millisecond},
+			wantMean: 300 * time.Millisecond,
+			wantMin:  200 * time.Millisecond,
+			wantMax:  400 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := Job{Config: &models.Config{}, TestCase: tt.test}
+			mean, min, max := meanOf(job)
+
+			assert.InDelta(t, float64(tt.wantMean), float64(mean), float64(tt.wantMean)*0.05)
+			assert.GreaterOrEqual(t, min, tt.wantMin)
+			if tt.wantMax > 0 {
+				assert.LessOrEqual(t, max, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestEngine_CalculateThinkTime_TestOverridesGlobalDistribution(t *testing.T) {
+	engine := New(1, nil, false)
+	config := &models.Config{
+		Global: models.GlobalConfig{ThinkTimeDistribution: models.ThinkTimeExponential, ThinkTime: time.Second},
+	}
+
+	fixed := Job{Config: config, TestCase: models.TestCase{ThinkTime: 50 * time.Millisecond}}
+	assert.Equal(t, 50*time.Millisecond, engine.calculateThinkTime(fixed))
+
+	inherited := Job{Config: config, TestCase: models.TestCase{}}
+	var total time.Duration
+	for i := 0; i < 10000; i++ {
+		total += engine.calculateThinkTime(inherited)
+	}
+	assert.InDelta(t, float64(time.Second), float64(total/10000), float64(50*time.Millisecond))
+}
+
+// =============================================================================
+// Pacing Tests
+// =============================================================================
+
+func TestEngine_Pacing_ConstantIterationStarts(t *testing.T) {
+	var requestTimes []time.Time
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestTimes = append(requestTimes, time.Now())
+		slow := len(requestTimes)%2 == 0
+		mu.Unlock()
+		// Alternate response times; pacing must absorb the difference
+		if slow {
+			time.Sleep(60 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Pacing Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 4,
+			Pacing:     100 * time.Millisecond,
+		},
+		Tests: []models.TestCase{
+			{Name: "Test", Method: "GET", Path: "/test", ExpectedStatus: []int{200}},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 4, summary.SuccessfulReqs)
+	require.Len(t, requestTimes, 4)
+
+	for i := 1; i < len(requestTimes); i++ {
+		gap := requestTimes[i].Sub(requestTimes[i-1])
+		assert.True(t, gap >= 90*time.Millisecond && gap < 150*time.Millisecond,
+			"Iterations should start ~100ms apart whatever the response time, got %v", gap)
+	}
+}
+
+func TestEngine_Pacing_SlowResponseStartsNextIterationImmediately(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Pacing Exceeded Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 3,
+		},
+		Tests: []models.TestCase{
+			{Name: "Test", Method: "GET", Path: "/test", ExpectedStatus: []int{200}, Pacing: 10 * time.Millisecond},
+		},
+	}
+
+	engine := New(1, nil, false)
+	startTime := time.Now()
+	summary := engine.Run(config)
+	totalTime := time.Since(startTime)
+
+	assert.Equal(t, 3, summary.SuccessfulReqs)
+	// No pacing wait is added on top of responses slower than the interval
+	assert.Less(t, totalTime, 300*time.Millisecond)
+
+	// The iterations held back by the slow responses were sent ~40ms late
+	require.NotNil(t, summary.ScheduleLag)
+	assert.Equal(t, 2, summary.ScheduleLag.LateRequests)
+	assert.GreaterOrEqual(t, summary.ScheduleLag.AvgLag, 30*time.Millisecond)
+	assert.Greater(t, summary.ScheduleLag.Corrected.AvgResponseTime, summary.AvgResponseTime)
+}
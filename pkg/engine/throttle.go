@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/google/uuid"
+)
+
+// throttleBackoff is the first wait before retrying a rate-limited response
+// without Retry-After; it doubles with every retry
+const throttleBackoff = time.Second
+
+// executeTest executes a job and, with throttle handling, retries it while
+// its responses are rate limited. The result reports the last attempt.
+func (e *Engine) executeTest(job Job) models.TestResult {
+	// Retries send the same request ID, as an Idempotency-Key must be
+	if job.Config.RequestIDHeader(job.TestCase) != "" && sendsHTTP(job.TestCase) {
+		job.RequestID = uuid.New().String()
+	}
+	// With several base URLs, retries go to the target of the first attempt
+	target := ""
+	if sendsHTTP(job.TestCase) {
+		if target = e.targets.next(); target != "" {
+			job.URL = retarget(job.URL, job.Config.Global.BaseURL, target)
+		}
+	}
+	result := e.executeRequest(job)
+	result.RequestID = job.RequestID
+	result.Target = target
+	result.JobIndex = job.JobIndex
+	throttle := job.Config.TestThrottle(job.TestCase)
+	if throttle == nil {
+		return result
+	}
+
+	for retries := 0; result.Throttled && retries < throttle.MaxRetries; retries++ {
+		if !e.wait(throttleWait(result.RetryAfter, retries, throttle.MaxWait)) {
+			break
+		}
+		result = e.executeRequest(job)
+		result.RequestID = job.RequestID
+		result.Target = target
+		result.JobIndex = job.JobIndex
+		result.ThrottleRetries = retries + 1
+	}
+	return result
+}
+
+// sendsHTTP reports whether a test sends HTTP requests, rather than socket,
+// messaging, or SQL ones
+func sendsHTTP(test models.TestCase) bool {
+	return test.Socket == nil && test.Messaging == nil && test.SQL == nil
+}
+
+// throttleWait returns the wait before a retry: the server's Retry-After,
+// or else an exponential backoff, capped at maxWait
+func throttleWait(retryAfter time.Duration, retries int, maxWait time.Duration) time.Duration {
+	wait := retryAfter
+	if wait < 0 {
+		wait = throttleBackoff << retries
+	}
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+	return wait
+}
+
+// isThrottled reports whether a response is rate limited: a 429, or a 503
+// with Retry-After. It also returns the wait asked by Retry-After, negative
+// when the header is missing or invalid.
+func isThrottled(resp *http.Response, now time.Time) (bool, time.Duration) {
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, retryAfter
+	case http.StatusServiceUnavailable:
+		return retryAfter >= 0, retryAfter
+	}
+	return false, retryAfter
+}
+
+// parseRetryAfter parses a Retry-After header, either delay seconds or an
+// HTTP date, returning -1 when it is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return -1
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return -1
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return -1
+}
+
+// wait sleeps for d unless the run ends first, reporting whether it waited
+// the whole time
+func (e *Engine) wait(d time.Duration) bool {
+	if d <= 0 {
+		return e.context().Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-e.context().Done():
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// slowestRequestsLimit bounds the slowest requests kept in the summary
+const slowestRequestsLimit = 20
+
+// bodySampleLimit bounds the response body kept for the slowest requests
+const bodySampleLimit = 512
+
+// requestTimer records the phases of a request through httptrace. Dial
+// callbacks may run on other goroutines, hence the mutex.
+type requestTimer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	timing       models.RequestTiming
+}
+
+func (t *requestTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.since(&t.timing.DNS, &t.dnsStart)
+		},
+		ConnectStart: func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone: func(string, string, error) {
+			t.since(&t.timing.Connect, &t.connectStart)
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
+			t.since(&t.timing.TLS, &t.tlsStart)
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+func (t *requestTimer) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *requestTimer) since(phase *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !start.IsZero() {
+		*phase = time.Since(*start)
+	}
+}
+
+// finish returns the timing of a request that completed at end. Wait is the
+// time from sending the request to the first response byte, Transfer the
+// time from there to the end of the body.
+func (t *requestTimer) finish(end time.Time) *models.RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timing := t.timing
+	if !t.wroteRequest.IsZero() && !t.firstByte.IsZero() {
+		timing.Wait = t.firstByte.Sub(t.wroteRequest)
+		timing.Transfer = end.Sub(t.firstByte)
+	} else if !t.wroteRequest.IsZero() {
+		timing.Wait = end.Sub(t.wroteRequest)
+	}
+	return &timing
+}
+
+// bodySample returns the start of a response body for the slowest requests
+func bodySample(body string) string {
+	if len(body) <= bodySampleLimit {
+		return body
+	}
+	return body[:bodySampleLimit] + "... (truncated)"
+}
+
+// trackSlowest adds a result to the slowest requests, kept sorted from the
+// slowest down and bounded by slowestRequestsLimit
+func trackSlowest(slowest []models.TestResult, result models.TestResult) []models.TestResult {
+	if result.Skipped {
+		return slowest
+	}
+	if len(slowest) == slowestRequestsLimit && result.ResponseTime <= slowest[len(slowest)-1].ResponseTime {
+		return slowest
+	}
+	i := sort.Search(len(slowest), func(i int) bool {
+		return slowest[i].ResponseTime < result.ResponseTime
+	})
+	if len(slowest) < slowestRequestsLimit {
+		slowest = append(slowest, models.TestResult{})
+	}
+	copy(slowest[i+1:], slowest[i:])
+	slowest[i] = result
+	return slowest
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackSlowest(t *testing.T) {
+	var slowest []models.TestResult
+	for i := 1; i <= slowestRequestsLimit+10; i++ {
+		slowest = trackSlowest(slowest, models.TestResult{
+			TestName:     fmt.Sprintf("req-%d", i),
+			ResponseTime: time.Duration(i%17) * time.Millisecond,
+		})
+	}
+	slowest = trackSlowest(slowest, models.TestResult{TestName: "skipped", ResponseTime: time.Hour, Skipped: true})
+
+	require.Len(t, slowest, slowestRequestsLimit)
+	assert.Equal(t, 16*time.Millisecond, slowest[0].ResponseTime)
+	for i := 1; i < len(slowest); i++ {
+		assert.GreaterOrEqual(t, slowest[i-1].ResponseTime, slowest[i].ResponseTime)
+	}
+	for _, result := range slowest {
+		assert.NotEqual(t, "skipped", result.TestName)
+	}
+}
+
+func TestBodySample(t *testing.T) {
+	assert.Equal(t, "short", bodySample("short"))
+	long := bodySample(strings.Repeat("a", 2*bodySampleLimit))
+	assert.Equal(t, strings.Repeat("a", bodySampleLimit)+"... (truncated)", long)
+}
+
+func TestEngine_SlowestRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 3,
+			// Every request connects, so the connect phase is measured
+			DisableKeepAlive: true,
+		},
+		Tests: []models.TestCase{
+			{Name: "Fast", Method: "GET", Path: "/fast", ExpectedStatus: []int{200}},
+			{Name: "Slow", Method: "GET", Path: "/slow", ExpectedStatus: []int{200}},
+		},
+	}
+
+	summary := New(1, nil, true).Run(config)
+
+	require.Len(t, summary.SlowestRequests, 6)
+	slowest := summary.SlowestRequests[0]
+	assert.Equal(t, "Slow", slowest.TestName)
+	assert.Equal(t, 200, slowest.StatusCode)
+	assert.Equal(t, `{"path": "/slow"}`, slowest.BodySample)
+	require.NotNil(t, slowest.Timing)
+	assert.GreaterOrEqual(t, slowest.Timing.Wait, 50*time.Millisecond)
+	assert.Greater(t, slowest.Timing.Connect, time.Duration(0))
+	assert.Equal(t, "Fast", summary.SlowestRequests[5].TestName)
+}
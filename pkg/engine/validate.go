@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/remotedata"
+	"github.com/andrearaponi/bombardino/pkg/variables"
+)
+
+// builtinVariables are set by the engine for every request
+var builtinVariables = []string{"__iteration", "__vu"}
+
+// Validate performs the checks that need more than the config file itself:
+// test dependencies must form a DAG, local data files must exist and parse,
+// and every ${variable} reference must be resolvable from global variables,
+// secrets, data columns, built-ins, or extractions of the tests it depends
+// on, and use known transforms. All problems are returned together.
+func Validate(config *models.Config) []error {
+	var problems []error
+
+	var testDeps []variables.TestDependency
+	for _, test := range config.Tests {
+		testDeps = append(testDeps, variables.TestDependency{Name: test.Name, DependsOn: test.DependsOn})
+	}
+	if _, err := variables.BuildDAG(testDeps); err != nil {
+		problems = append(problems, fmt.Errorf("dependencies: %w", err))
+	}
+
+	defined := make(map[string]bool)
+	for name := range config.Global.Variables {
+		defined[name] = true
+	}
+	for name := range config.Global.Secrets {
+		defined[name] = true
+	}
+	for _, name := range builtinVariables {
+		defined[name] = true
+	}
+	// __loop is only set in loop mode
+	loop := config.Global.Loop
+	for _, scenario := range config.Scenarios {
+		loop = loop || scenario.Loop
+	}
+	if loop {
+		defined["__loop"] = true
+	}
+
+	extractedBy := make(map[string][]string)
+	testByName := make(map[string]models.TestCase, len(config.Tests))
+	for _, test := range config.Tests {
+		testByName[test.Name] = test
+		for _, rule := range test.Extract {
+			extractedBy[rule.Name] = append(extractedBy[rule.Name], test.Name)
+		}
+	}
+
+	// Global settings are sent by every test, so any extraction satisfies them
+	globalValues := []interface{}{map[string]string(config.Global.Headers)}
+	for _, baseURL := range baseURLs(config) {
+		globalValues = append(globalValues, baseURL)
+	}
+	globalRefs := variables.References(globalValues)
+	for _, name := range uniqueSorted(globalRefs) {
+		if !defined[name] && len(extractedBy[name]) == 0 {
+			problems = append(problems, fmt.Errorf("global: ${%s} is not defined", name))
+		}
+	}
+
+	for _, name := range uniqueSorted(variables.UnknownTransforms(globalValues)) {
+		problems = append(problems, fmt.Errorf("global: unknown transform '%s'", name))
+	}
+
+	for _, test := range config.Tests {
+		for _, name := range uniqueSorted(variables.UnknownTransforms(testValues(test))) {
+			problems = append(problems, fmt.Errorf("test '%s': unknown transform '%s'", test.Name, name))
+		}
+
+		columns, unknownColumns, err := dataColumns(test)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("test '%s': data file %s: %w", test.Name, test.DataFile, err))
+		}
+
+		ancestors := make(map[string]bool)
+		collectAncestors(test, testByName, ancestors)
+
+		for _, name := range uniqueSorted(testReferences(test)) {
+			if defined[name] || columns[name] {
+				continue
+			}
+			if unknownColumns && strings.HasPrefix(name, "data.") {
+				continue
+			}
+			producers := extractedBy[name]
+			if len(producers) == 0 {
+				problems = append(problems, fmt.Errorf("test '%s': ${%s} is not defined", test.Name, name))
+				continue
+			}
+			satisfied := false
+			for _, producer := range producers {
+				if ancestors[producer] {
+					satisfied = true
+					break
+				}
+			}
+			if !satisfied {
+				problems = append(problems, fmt.Errorf("test '%s': ${%s} is extracted by '%s', which it does not depend on",
+					test.Name, name, strings.Join(producers, "', '")))
+			}
+		}
+	}
+
+	return problems
+}
+
+// testReferences lists the variables referenced by a test's own request and
+// comparison settings
+func testReferences(test models.TestCase) []string {
+	return variables.References(testValues(test))
+}
+
+// testValues returns the parts of a test's own request and comparison
+// settings that variables are substituted in
+func testValues(test models.TestCase) []interface{} {
+	values := []interface{}{test.Path, test.PathParams, test.Query, map[string]string(test.Headers), test.Body}
+	if test.CompareWith != nil {
+		values = append(values, test.CompareWith.Endpoint, test.CompareWith.Path, test.CompareWith.Headers)
+	}
+	return values
+}
+
+// dataColumns returns the data.* variables a test's data rows provide. When
+// the columns cannot be known in advance (remote files, unreadable files),
+// unknown reports true so data.* references are not flagged.
+func dataColumns(test models.TestCase) (columns map[string]bool, unknown bool, err error) {
+	columns = make(map[string]bool)
+	for _, row := range test.Data {
+		addDataColumns(columns, "data", row)
+	}
+
+	if test.DataFile == "" {
+		return columns, false, nil
+	}
+	if remotedata.IsRemote(test.DataFile) {
+		return columns, true, nil
+	}
+
+	it, err := openDataFile(test.DataFile, test.DataSheet)
+	if err != nil {
+		return columns, true, err
+	}
+	defer it.Close()
+
+	row, err := it.Next()
+	if err == io.EOF {
+		return columns, true, errNoDataRows
+	}
+	if err != nil {
+		return columns, true, err
+	}
+	addDataColumns(columns, "data", row)
+	return columns, false, nil
+}
+
+func addDataColumns(columns map[string]bool, prefix string, row map[string]interface{}) {
+	for key, value := range row {
+		name := prefix + "." + key
+		columns[name] = true
+		if nested, ok := value.(map[string]interface{}); ok {
+			addDataColumns(columns, name, nested)
+		}
+	}
+}
+
+func collectAncestors(test models.TestCase, testByName map[string]models.TestCase, ancestors map[string]bool) {
+	for _, dep := range test.DependsOn {
+		if ancestors[dep] {
+			continue
+		}
+		ancestors[dep] = true
+		if parent, ok := testByName[dep]; ok {
+			collectAncestors(parent, testByName, ancestors)
+		}
+	}
+}
+
+func uniqueSorted(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var result []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
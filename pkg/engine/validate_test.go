@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func problemMessages(problems []error) []string {
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+	}
+	return messages
+}
+
+func TestValidate_Valid(t *testing.T) {
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "users.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("username,password\nalice,secret\n"), 0644))
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:   "https://api.example.com/${version}",
+			Headers:   models.Headers{"Authorization": "Bearer ${token}"},
+			Variables: map[string]interface{}{"version": "v1"},
+			Secrets:   map[string]models.SecretRef{"api_key": {Provider: "env", Key: "API_KEY"}},
+		},
+		Tests: []models.TestCase{
+			{
+				Name:     "Login",
+				Path:     "/login?key=${api_key}",
+				Body:     map[string]interface{}{"user": "${data.username}", "pass": "${data.password}"},
+				DataFile: csvFile,
+				Extract:  []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}},
+			},
+			{
+				Name:      "Order",
+				Path:      "/orders/${__iteration}",
+				Body:      map[string]interface{}{"sku": "${data.item.sku}", "ref": "${counter(orders)}"},
+				Data:      []map[string]interface{}{{"item": map[string]interface{}{"sku": "A1"}}},
+				DependsOn: []string{"Login"},
+			},
+			{
+				Name:      "Receipt",
+				Headers:   models.Headers{"X-Token": "${token}"},
+				DependsOn: []string{"Order"},
+			},
+		},
+	}
+
+	assert.Empty(t, Validate(config))
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			Headers: models.Headers{"X-Tenant": "${tenant}"},
+		},
+		Tests: []models.TestCase{
+			{Name: "Login", Extract: []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}}},
+			{Name: "Profile", Path: "/me?t=${token}&u=${user_id}"},
+			{Name: "Import", DataFile: filepath.Join(t.TempDir(), "missing.csv"), Body: "${data.name}"},
+			{Name: "Search", Path: "/search?q=${data.query}"},
+			{Name: "A", DependsOn: []string{"B"}},
+			{Name: "B", DependsOn: []string{"A"}},
+		},
+	}
+
+	messages := problemMessages(Validate(config))
+
+	require.Len(t, messages, 6)
+	assert.Contains(t, messages[0], "dependencies: cyclic dependency")
+	assert.Equal(t, "global: ${tenant} is not defined", messages[1])
+	assert.Equal(t, "test 'Profile': ${token} is extracted by 'Login', which it does not depend on", messages[2])
+	assert.Equal(t, "test 'Profile': ${user_id} is not defined", messages[3])
+	assert.Contains(t, messages[4], "test 'Import': data file")
+	assert.Equal(t, "test 'Search': ${data.query} is not defined", messages[5])
+}
+
+func TestValidate_UnknownDependency(t *testing.T) {
+	config := &models.Config{
+		Tests: []models.TestCase{{Name: "Profile", DependsOn: []string{"Login"}}},
+	}
+
+	messages := problemMessages(Validate(config))
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "Login")
+}
+
+func TestValidate_RemoteDataFileSkipped(t *testing.T) {
+	config := &models.Config{
+		Tests: []models.TestCase{{Name: "Import", DataFile: "https://example.com/users.csv", Body: "${data.name}"}},
+	}
+
+	assert.Empty(t, Validate(config))
+}
+
+func TestValidate_LoopVariable(t *testing.T) {
+	config := &models.Config{
+		Tests: []models.TestCase{{Name: "Order", Path: "/orders/${__loop}"}},
+	}
+
+	messages := problemMessages(Validate(config))
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "${__loop} is not defined")
+
+	config.Global.Loop = true
+	assert.Empty(t, Validate(config))
+}
+
+func TestValidate_UnknownTransform(t *testing.T) {
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			Variables: map[string]interface{}{"token": "abc"},
+			Headers:   models.Headers{"Authorization": "Basic ${token | base46}"},
+		},
+		Tests: []models.TestCase{{Name: "Search", Path: "/search?q=${token | urlencode | shout}"}},
+	}
+
+	messages := problemMessages(Validate(config))
+	assert.Equal(t, []string{
+		"global: unknown transform 'base46'",
+		"test 'Search': unknown transform 'shout'",
+	}, messages)
+}
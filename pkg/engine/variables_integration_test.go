@@ -2,6 +2,7 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -256,6 +257,57 @@ func TestEngine_VariableExtraction_FromHeader(t *testing.T) {
 	assert.Equal(t, "req-abc-123", receivedRequestID)
 }
 
+func TestEngine_VariableExtraction_OnFailure(t *testing.T) {
+	var receivedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error_id": "err-77", "order_id": 5}`))
+			return
+		}
+		receivedPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Extract On Failure Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Create Order",
+				Method:         "POST",
+				Path:           "/orders",
+				ExpectedStatus: []int{201},
+				Extract: []models.ExtractionRule{
+					{Name: "error_id", Source: "body", Path: "error_id", ExtractOnFailure: true},
+					{Name: "order_id", Source: "body", Path: "order_id"},
+				},
+			},
+			{
+				Name:           "Report Error",
+				Method:         "GET",
+				Path:           "/errors/${error_id}",
+				ExpectedStatus: []int{200},
+				DependsOn:      []string{"Create Order"},
+				DependsOnMode:  models.DependsOnCompletion,
+			},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 1, summary.FailedReqs)
+	assert.Equal(t, "/errors/err-77", receivedPath)
+	_, ok := engine.varStore.Get("order_id")
+	assert.False(t, ok, "rules without extract_on_failure only run on expected statuses")
+}
+
 // =============================================================================
 // DAG Execution Tests
 // =============================================================================
@@ -420,6 +472,244 @@ func TestEngine_DAG_NoDependencies_AllParallel(t *testing.T) {
 	assert.Contains(t, requestPaths, "/c")
 }
 
+func TestEngine_DAG_PhaseSummaries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/profile" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if r.URL.Path == "/settings" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "DAG Phase Summary Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 2,
+		},
+		Tests: []models.TestCase{
+			{Name: "Login", Method: "GET", Path: "/login", ExpectedStatus: []int{200}},
+			{Name: "Get Profile", Method: "GET", Path: "/profile", ExpectedStatus: []int{200}, DependsOn: []string{"Login"}},
+			{Name: "Get Settings", Method: "GET", Path: "/settings", ExpectedStatus: []int{200}, DependsOn: []string{"Login"}},
+			{Name: "Save Settings", Method: "PUT", Path: "/settings", ExpectedStatus: []int{200}, DependsOn: []string{"Get Settings"}},
+		},
+	}
+
+	engine := New(2, nil, false)
+	summary := engine.Run(config)
+
+	require.Len(t, summary.PhaseResults, 3)
+
+	login := summary.PhaseResults[0]
+	assert.Equal(t, 1, login.Phase)
+	assert.Equal(t, []string{"Login"}, login.Tests)
+	assert.Equal(t, 2, login.SuccessfulReqs)
+
+	reads := summary.PhaseResults[1]
+	assert.Equal(t, 2, reads.Phase)
+	assert.Equal(t, []string{"Get Profile", "Get Settings"}, reads.Tests)
+	assert.Equal(t, 4, reads.TotalRequests)
+	assert.Equal(t, 2, reads.FailedReqs)
+	assert.False(t, reads.StartTime.Before(login.EndTime))
+	assert.GreaterOrEqual(t, reads.Duration, 50*time.Millisecond)
+	assert.Greater(t, reads.RequestsPerSec, 0.0)
+
+	skipped := summary.PhaseResults[2]
+	assert.Equal(t, []string{"Save Settings"}, skipped.Tests)
+	assert.Equal(t, 2, skipped.SkippedReqs)
+	assert.True(t, skipped.StartTime.IsZero())
+}
+
+func TestEngine_DAG_DependsOnMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Depends On Mode Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+		},
+		Tests: []models.TestCase{
+			{Name: "Create", Method: "POST", Path: "/ok", ExpectedStatus: []int{200}},
+			{Name: "Update", Method: "PUT", Path: "/broken", ExpectedStatus: []int{200}, DependsOn: []string{"Create"}},
+			{Name: "Mirror", Method: "PUT", Path: "/broken", ExpectedStatus: []int{200}, DependsOn: []string{"Create"}},
+			{Name: "Verify", Method: "GET", Path: "/ok", ExpectedStatus: []int{200}, DependsOn: []string{"Update"}},
+			{Name: "Cleanup", Method: "DELETE", Path: "/ok", ExpectedStatus: []int{200}, DependsOn: []string{"Update"}, DependsOnMode: models.DependsOnCompletion},
+			{Name: "Either", Method: "GET", Path: "/ok", ExpectedStatus: []int{200}, DependsOn: []string{"Update", "Create"}, DependsOnMode: models.DependsOnAny},
+			{Name: "Neither", Method: "GET", Path: "/ok", ExpectedStatus: []int{200}, DependsOn: []string{"Update", "Mirror"}, DependsOnMode: models.DependsOnAny},
+		},
+	}
+
+	engine := New(2, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 1, summary.EndpointResults["Verify"].SkippedReqs)
+	assert.Equal(t, 1, summary.EndpointResults["Cleanup"].SuccessfulReqs)
+	assert.Equal(t, 1, summary.EndpointResults["Either"].SuccessfulReqs)
+	assert.Equal(t, 1, summary.EndpointResults["Neither"].SkippedReqs)
+	assert.Contains(t, summary.Errors, "all dependencies failed (Update, Mirror)")
+}
+
+// =============================================================================
+// Loop Mode Tests
+// =============================================================================
+
+func TestEngine_Loop_IsolatesExtractionsPerLoop(t *testing.T) {
+	var mu sync.Mutex
+	issued := 0
+	used := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			mu.Lock()
+			issued++
+			token := fmt.Sprintf("token-%d", issued)
+			mu.Unlock()
+			// Give other loops time to log in before this one continues
+			time.Sleep(5 * time.Millisecond)
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+		case "/profile":
+			mu.Lock()
+			used[r.Header.Get("Authorization")]++
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Loop Isolation Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 20,
+			Loop:       true,
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Login",
+				Method:         "POST",
+				Path:           "/login",
+				ExpectedStatus: []int{200},
+				Extract:        []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}},
+			},
+			{
+				Name:           "Profile",
+				Method:         "GET",
+				Path:           "/profile",
+				Headers:        models.Headers{"Authorization": "${token}"},
+				ExpectedStatus: []int{200},
+				DependsOn:      []string{"Login"},
+			},
+		},
+	}
+
+	engine := New(4, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 40, summary.TotalRequests)
+	assert.Equal(t, 40, summary.SuccessfulReqs)
+	assert.Equal(t, 20, summary.EndpointResults["Login"].TotalRequests)
+	assert.Equal(t, 20, summary.EndpointResults["Profile"].TotalRequests)
+
+	// Every loop uses the token its own login returned, exactly once
+	require.Len(t, used, 20)
+	for token, count := range used {
+		assert.Equal(t, 1, count, token)
+	}
+
+	// Extracted values stay in their loop
+	_, ok := engine.varStore.Get("token")
+	assert.False(t, ok)
+}
+
+func TestEngine_Loop_SkipsDependentsOfFailedStep(t *testing.T) {
+	var mu sync.Mutex
+	logins := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			mu.Lock()
+			logins++
+			fail := logins%2 == 0
+			mu.Unlock()
+			if fail {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Loop Skip Test",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 4,
+			Loop:       true,
+		},
+		Tests: []models.TestCase{
+			{Name: "Login", Method: "POST", Path: "/login", ExpectedStatus: []int{200}},
+			{Name: "Profile", Method: "GET", Path: "/profile", ExpectedStatus: []int{200}, DependsOn: []string{"Login"}},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+
+	profile := summary.EndpointResults["Profile"]
+	assert.Equal(t, 2, profile.SuccessfulReqs)
+	assert.Equal(t, 2, profile.SkippedReqs)
+	assert.Equal(t, 2, summary.EndpointResults["Login"].FailedReqs)
+}
+
+func TestEngine_Loop_DurationBased(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Loop Duration Test",
+		Global: models.GlobalConfig{
+			BaseURL:  server.URL,
+			Timeout:  5 * time.Second,
+			Duration: 200 * time.Millisecond,
+			Loop:     true,
+		},
+		Tests: []models.TestCase{
+			{Name: "Login", Method: "POST", Path: "/login", ExpectedStatus: []int{200}},
+			{Name: "Profile", Method: "GET", Path: "/profile", ExpectedStatus: []int{200}, DependsOn: []string{"Login"}},
+		},
+	}
+
+	start := time.Now()
+	engine := New(2, nil, false)
+	summary := engine.Run(config)
+	elapsed := time.Since(start)
+
+	assert.Less(t, elapsed, time.Second)
+	assert.Greater(t, summary.EndpointResults["Login"].TotalRequests, 2)
+	assert.Zero(t, summary.FailedReqs)
+}
+
 // =============================================================================
 // Complex Flow Tests
 // =============================================================================
@@ -611,3 +901,203 @@ func TestEngine_MissingVariable_StaysAsIs(t *testing.T) {
 	// Missing variable should stay as-is
 	assert.Equal(t, "/users/${missing_var}", receivedPath)
 }
+
+func TestEngine_StrictVariables_FailsUnresolved(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Strict Variables Test",
+		Global: models.GlobalConfig{
+			BaseURL:         server.URL,
+			Timeout:         5 * time.Second,
+			Iterations:      1,
+			StrictVariables: true,
+			Variables:       map[string]interface{}{"user_id": 7},
+		},
+		Tests: []models.TestCase{
+			{
+				Name:           "Unresolved",
+				Method:         "POST",
+				Path:           "/users/${user_id}",
+				Headers:        models.Headers{"Authorization": "Bearer ${token}"},
+				Body:           map[string]interface{}{"org": "${org_id}", "region": "${region:-eu}"},
+				ExpectedStatus: []int{200},
+			},
+			{
+				Name:           "Resolved",
+				Method:         "GET",
+				Path:           "/users/${user_id}?region=${region:-eu}",
+				ExpectedStatus: []int{200},
+			},
+		},
+	}
+
+	engine := New(1, nil, false)
+	summary := engine.Run(config)
+
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 1, summary.SuccessfulReqs)
+	unresolved := summary.EndpointResults["Unresolved"]
+	require.Equal(t, 1, unresolved.FailedReqs)
+	assert.Equal(t, []string{"unresolved variable ${org_id}, ${token}"}, unresolved.Errors)
+	assert.Equal(t, 1, unresolved.ErrorCategories[ErrorRequest])
+}
+
+func TestEngine_Secrets_SubstitutedAndMasked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer top-secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Secrets",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Headers:    models.Headers{"Authorization": "Bearer ${api_token}"},
+		},
+		Tests: []models.TestCase{
+			{Name: "auth", Method: "GET", Path: "/", ExpectedStatus: []int{200}},
+		},
+	}
+
+	engine := New(1, nil, true)
+	engine.SetSecrets(map[string]string{"api_token": "top-secret"})
+	summary := engine.Run(config)
+
+	assert.Equal(t, 1, summary.SuccessfulReqs)
+	require.NotEmpty(t, summary.DebugLogs)
+	for _, log := range summary.DebugLogs {
+		for _, value := range log.Headers {
+			assert.NotContains(t, value, "top-secret")
+		}
+	}
+}
+
+func TestEngine_Redact_DebugLogsAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"password": "hunter2", "user": "bob"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Name: "Redact",
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Headers:    models.Headers{"Authorization": "Bearer abc", "X-Session": "${session}"},
+			Variables:  map[string]interface{}{"session": "sess-42"},
+			Redact: &models.RedactConfig{
+				Headers:   []string{"Authorization"},
+				Variables: []string{"session"},
+				JSONPaths: []string{"password"},
+			},
+		},
+		Tests: []models.TestCase{
+			{Name: "redacted", Method: "GET", Path: "/", ExpectedStatus: []int{200}},
+		},
+	}
+
+	engine := New(1, nil, true)
+	summary := engine.Run(config)
+
+	require.NotEmpty(t, summary.DebugLogs)
+	for _, log := range summary.DebugLogs {
+		assert.NotContains(t, log.Body, "hunter2")
+		for key, value := range log.Headers {
+			assert.NotContains(t, value, "sess-42", key)
+			assert.NotContains(t, value, "Bearer abc", key)
+		}
+	}
+	samples := summary.EndpointResults["redacted"].FailureSamples
+	require.NotEmpty(t, samples)
+	for _, sample := range samples {
+		assert.NotContains(t, sample.Error, "hunter2")
+		assert.Contains(t, sample.Error, "bob")
+		assert.NotContains(t, sample.BodySample, "hunter2")
+		assert.Contains(t, sample.BodySample, "bob")
+	}
+}
+
+func TestEngine_Redact_AssertionMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"password": "hunter2pass", "user": {"name": "bob", "ssn": "123-45"}}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Redact:     &models.RedactConfig{JSONPaths: []string{"password", "user.ssn"}},
+		},
+		Tests: []models.TestCase{{
+			Name:           "redacted",
+			Method:         "GET",
+			Path:           "/",
+			ExpectedStatus: []int{200},
+			Assertions: []models.Assertion{
+				{Type: "json_path", Target: "password", Operator: "eq", Value: "x"},
+				{Type: "json_path", Target: "user", Operator: "eq", Value: "x"},
+				{Type: "json_path", Target: "password", Operator: "gt", Value: float64(1)},
+				{Type: "json_path", Target: "user.name", Operator: "eq", Value: "x"},
+			},
+		}},
+	}
+
+	summary := New(1, nil, false).Run(config)
+
+	endpoint := summary.EndpointResults["redacted"]
+	require.Len(t, endpoint.Assertions, 4)
+	for _, outcome := range endpoint.Assertions[:3] {
+		require.NotEmpty(t, outcome.Messages)
+		assert.NotContains(t, outcome.Messages[0], "hunter2pass")
+		assert.NotContains(t, outcome.Messages[0], "123-45")
+	}
+	assert.Equal(t, "assertion failed: password eq x, got ***", endpoint.Assertions[0].Messages[0])
+	assert.Contains(t, endpoint.Assertions[3].Messages[0], "got bob", "fields that are not redacted keep their value")
+	require.NotEmpty(t, endpoint.FailureSamples)
+	for _, message := range endpoint.FailureSamples[0].AssertionErrors {
+		assert.NotContains(t, message, "hunter2pass")
+		assert.NotContains(t, message, "123-45")
+	}
+}
+
+func TestEngine_Redact_VerboseBodySample(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"password":"hunter2","token":"SUPERSECRETTOKEN123"}`))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{
+			BaseURL:    server.URL,
+			Timeout:    5 * time.Second,
+			Iterations: 1,
+			Redact:     &models.RedactConfig{Variables: []string{"token"}, JSONPaths: []string{"password"}},
+		},
+		Tests: []models.TestCase{{
+			Name:           "login",
+			Method:         "GET",
+			Path:           "/",
+			ExpectedStatus: []int{200},
+			Extract:        []models.ExtractionRule{{Name: "token", Source: "body", Path: "token"}},
+		}},
+	}
+
+	summary := New(1, nil, true).Run(config)
+
+	require.Len(t, summary.SlowestRequests, 1)
+	assert.Equal(t, `{"password":"***","token":"***"}`, summary.SlowestRequests[0].BodySample,
+		"values extracted from the same response are masked")
+}
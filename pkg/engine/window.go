@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// ParseReportWindow parses a -report-window value: "start:end" offsets from
+// the start of the run, e.g. "2m:8m". Either side may be left out, so "2m:"
+// drops the first two minutes and ":8m" everything after eight.
+func ParseReportWindow(value string) (models.ReportWindow, error) {
+	startValue, endValue, found := strings.Cut(value, ":")
+	if !found {
+		return models.ReportWindow{}, fmt.Errorf("invalid report window %q: expected start:end, e.g. 2m:8m", value)
+	}
+
+	var window models.ReportWindow
+	var err error
+	if startValue != "" {
+		if window.Start, err = time.ParseDuration(startValue); err != nil {
+			return models.ReportWindow{}, fmt.Errorf("invalid report window start: %w", err)
+		}
+	}
+	if endValue != "" {
+		if window.End, err = time.ParseDuration(endValue); err != nil {
+			return models.ReportWindow{}, fmt.Errorf("invalid report window end: %w", err)
+		}
+	}
+
+	if window.Start < 0 || window.End < 0 {
+		return models.ReportWindow{}, fmt.Errorf("invalid report window %q: offsets must not be negative", value)
+	}
+	if window.End > 0 && window.End <= window.Start {
+		return models.ReportWindow{}, fmt.Errorf("invalid report window %q: end must be after start", value)
+	}
+	if window.Start == 0 && window.End == 0 {
+		return models.ReportWindow{}, fmt.Errorf("invalid report window %q: set a start, an end, or both", value)
+	}
+	return window, nil
+}
+
+// inReportWindow reports whether result counts in the statistics of the
+// run, always true without a report window
+func (e *Engine) inReportWindow(result models.TestResult) bool {
+	return e.reportWindow == nil || e.reportWindow.Contains(result.Timestamp.Sub(e.start))
+}
+
+// windowResults returns the results in the report window and the number of
+// those left out
+func (e *Engine) windowResults(allResults []models.TestResult) ([]models.TestResult, int) {
+	if e.reportWindow == nil {
+		return allResults, 0
+	}
+	var windowed []models.TestResult
+	for _, result := range allResults {
+		if e.inReportWindow(result) {
+			windowed = append(windowed, result)
+		}
+	}
+	return windowed, len(allResults) - len(windowed)
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReportWindow(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    models.ReportWindow
+		wantErr string
+	}{
+		{value: "2m:8m", want: models.ReportWindow{Start: 2 * time.Minute, End: 8 * time.Minute}},
+		{value: "30s:", want: models.ReportWindow{Start: 30 * time.Second}},
+		{value: ":5m", want: models.ReportWindow{End: 5 * time.Minute}},
+		{value: "2m", wantErr: "expected start:end"},
+		{value: ":", wantErr: "set a start, an end, or both"},
+		{value: "8m:2m", wantErr: "end must be after start"},
+		{value: "2m:2m", wantErr: "end must be after start"},
+		{value: "-1m:2m", wantErr: "must not be negative"},
+		{value: "soon:2m", wantErr: "invalid report window start"},
+		{value: "1m:later", wantErr: "invalid report window end"},
+	}
+	for _, tt := range tests {
+		got, err := ParseReportWindow(tt.value)
+		if tt.wantErr != "" {
+			assert.ErrorContains(t, err, tt.wantErr, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+
+	window := models.ReportWindow{Start: time.Minute, End: 2 * time.Minute}
+	assert.False(t, window.Contains(59*time.Second))
+	assert.True(t, window.Contains(time.Minute))
+	assert.False(t, window.Contains(2*time.Minute))
+	assert.True(t, models.ReportWindow{Start: time.Minute}.Contains(time.Hour))
+}
+
+func TestEngine_ReportWindow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	run := func(window models.ReportWindow, duration time.Duration, tests ...models.TestCase) *models.Summary {
+		engine := New(2, nil, false)
+		engine.SetReportWindow(window)
+		return engine.Run(&models.Config{
+			Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Duration: duration},
+			Tests:  tests,
+		})
+	}
+	soak := models.TestCase{Name: "soak", Method: "GET", Path: "/soak", ExpectedStatus: []int{200}}
+
+	summary := run(models.ReportWindow{Start: 100 * time.Millisecond, End: 300 * time.Millisecond}, 400*time.Millisecond, soak)
+	require.NotNil(t, summary.ReportWindow)
+	assert.Greater(t, summary.TotalRequests, 0)
+	assert.Greater(t, summary.ExcludedReqs, 0)
+	assert.Equal(t, summary.TotalRequests, summary.EndpointResults["soak"].TotalRequests)
+	assert.Less(t, summary.TotalTime, 300*time.Millisecond)
+
+	summary = run(models.ReportWindow{End: time.Hour}, 100*time.Millisecond, soak)
+	assert.Greater(t, summary.TotalRequests, 0)
+	assert.Equal(t, 0, summary.ExcludedReqs)
+
+	// Runs with dependencies are summarized from their results at the end
+	summary = run(models.ReportWindow{Start: time.Hour}, 0,
+		models.TestCase{Name: "login", Method: "POST", Path: "/login", Iterations: 2, ExpectedStatus: []int{200}},
+		models.TestCase{Name: "profile", Method: "GET", Path: "/profile", Iterations: 3, ExpectedStatus: []int{200}, DependsOn: []string{"login"}},
+	)
+	assert.Equal(t, 0, summary.TotalRequests)
+	assert.Equal(t, 5, summary.ExcludedReqs)
+	assert.Empty(t, summary.EndpointResults)
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxWorkbook is the subset of xl/workbook.xml needed to locate sheets
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is the subset of xl/_rels/workbook.xml.rels mapping sheet IDs to files
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxSharedStrings holds the shared string table (xl/sharedStrings.xml)
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxRichText is a string item: either plain text or a list of formatted runs
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// xlsxSheet is the subset of a worksheet needed to read cell values
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref       string        `xml:"r,attr"`
+			Type      string        `xml:"t,attr"`
+			Value     string        `xml:"v"`
+			InlineStr *xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX returns the cell values of a worksheet as rows of strings.
+// An empty sheet name selects the first sheet of the workbook.
+func readXLSX(filePath, sheet string) ([][]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx file: %w", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("xlsx file has no sheets")
+	}
+	rid := ""
+	for _, s := range workbook.Sheets {
+		if sheet == "" || s.Name == sheet {
+			rid = s.RID
+			break
+		}
+	}
+	if rid == "" {
+		return nil, fmt.Errorf("sheet '%s' not found in xlsx file", sheet)
+	}
+
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			target = rel.Target
+			break
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("xlsx sheet relationship %s not found", rid)
+	}
+	// Targets are relative to xl/ unless absolute within the package
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var ws xlsxSheet
+	if err := decodeXLSXPart(files, target, &ws); err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	for _, row := range ws.Rows {
+		var record []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = xlsxColumnIndex(cell.Ref)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid shared string reference in cell %s", cell.Ref)
+				}
+				record[col] = shared.Items[index].String()
+			case "inlineStr":
+				if cell.InlineStr != nil {
+					record[col] = cell.InlineStr.String()
+				}
+			case "b":
+				record[col] = strconv.FormatBool(cell.Value == "1")
+			default:
+				record[col] = cell.Value
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// decodeXLSXPart unmarshals an XML part of the xlsx archive
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid xlsx file: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex converts a cell reference like "C7" to a zero-based column index
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
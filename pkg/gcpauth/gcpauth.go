@@ -0,0 +1,47 @@
+// Package gcpauth finds access tokens for the Google Cloud APIs bombardino
+// calls: Cloud Storage for report_upload and BigQuery for results_sink.
+package gcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MetadataURL returns an access token of the service account of a GCP
+// instance or, with Workload Identity, of a GKE pod
+const MetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// Token returns GOOGLE_OAUTH_ACCESS_TOKEN or asks the metadata server at
+// metadataURL (normally MetadataURL)
+func Token(ctx context.Context, client *http.Client, getenv func(string) string, metadataURL string) (string, error) {
+	if token := getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no Google credentials: set GOOGLE_OAUTH_ACCESS_TOKEN or run on GCP with a service account (%v)", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("no Google credentials: metadata server returned status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("no Google credentials: invalid metadata server response")
+	}
+	return token.AccessToken, nil
+}
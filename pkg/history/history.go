@@ -0,0 +1,189 @@
+// Package history keeps the JSON reports of past runs in a local directory
+// and renders their trends for bombardino history.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+)
+
+// DefaultDir is where bombardino history looks for results by default
+const DefaultDir = ".bombardino/history"
+
+// Entry is the stored result of one run
+type Entry struct {
+	Config    string              `json:"config"`
+	Commit    string              `json:"commit,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+	Report    reporter.JSONReport `json:"report"`
+}
+
+// Store keeps one directory per config name with one file per run
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// slug turns a config name into a directory name, e.g. "API Tests" into api-tests
+func slug(name string) string {
+	s := strings.Trim(unsafeChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if s == "" {
+		return "unnamed"
+	}
+	return s
+}
+
+// Save stores an entry and returns the path of its file
+func (s *Store) Save(entry Entry) (string, error) {
+	dir := filepath.Join(s.dir, slug(entry.Config))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	name := entry.Timestamp.UTC().Format("20060102T150405.000Z")
+	if entry.Commit != "" {
+		name += "-" + slug(entry.Commit)
+	}
+	path := filepath.Join(dir, name+".json")
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return path, nil
+}
+
+// Load returns the last limit entries of a config, oldest first; limit <= 0
+// returns all of them
+func (s *Store) Load(config string, limit int) ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, slug(config), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history entry: %w", err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse history entry %s: %w", file, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no history for '%s' in %s", config, s.dir)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// GitCommit returns the short commit of the working directory's git
+// repository, or an empty string outside of one
+func GitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Render writes a table of the entries followed by P95 and error rate
+// sparklines
+func Render(w io.Writer, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "History of %q (%d runs)\n\n", entries[0].Config, len(entries))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tCOMMIT\tREQUESTS\tREQ/S\tP95\tERROR RATE\tRESULT")
+	p95s := make([]float64, len(entries))
+	errorRates := make([]float64, len(entries))
+	for i, entry := range entries {
+		summary := entry.Report.Summary
+		p95, _ := time.ParseDuration(summary.P95ResponseTime)
+		p95s[i] = float64(p95)
+		errorRates[i] = errorRate(summary)
+
+		commit := entry.Commit
+		if commit == "" {
+			commit = "-"
+		}
+		result := "passed"
+		if !entry.Report.Success {
+			result = "failed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%v\t%.1f%%\t%s\n",
+			entry.Timestamp.Local().Format("2006-01-02 15:04"), commit, summary.TotalRequests,
+			summary.RequestsPerSec, p95, errorRates[i], result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	last := len(entries) - 1
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "P95          %s  %v → %v\n", sparkline(p95s), time.Duration(p95s[0]), time.Duration(p95s[last]))
+	fmt.Fprintf(w, "Error rate   %s  %.1f%% → %.1f%%\n", sparkline(errorRates), errorRates[0], errorRates[last])
+	return nil
+}
+
+func errorRate(summary reporter.JSONSummary) float64 {
+	executed := summary.SuccessfulReqs + summary.FailedReqs
+	if executed == 0 {
+		return 0
+	}
+	return float64(summary.FailedReqs) / float64(executed) * 100
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as block characters scaled between their minimum
+// and maximum
+func sparkline(values []float64) string {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(at time.Time, commit, p95 string, successful, failed int) Entry {
+	return Entry{
+		Config:    "API Tests",
+		Commit:    commit,
+		Timestamp: at,
+		Report: reporter.JSONReport{
+			Success: failed == 0,
+			Summary: reporter.JSONSummary{
+				TotalRequests:   successful + failed,
+				SuccessfulReqs:  successful,
+				FailedReqs:      failed,
+				RequestsPerSec:  50,
+				P95ResponseTime: p95,
+			},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+
+	// Saved out of order; Load sorts by timestamp
+	for i, e := range []Entry{
+		entry(start.Add(48*time.Hour), "c3d4e5f", "180ms", 995, 5),
+		entry(start, "a1b2c3d", "120ms", 1000, 0),
+		entry(start.Add(24*time.Hour), "", "125ms", 1000, 0),
+	} {
+		path, err := store.Save(e)
+		require.NoError(t, err, "entry %d", i)
+		assert.Equal(t, filepath.Join(dir, "api-tests"), filepath.Dir(path))
+	}
+	_, err := store.Save(Entry{Config: "Other", Timestamp: start})
+	require.NoError(t, err)
+
+	entries, err := store.Load("API Tests", 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "a1b2c3d", entries[0].Commit)
+	assert.Equal(t, "", entries[1].Commit)
+	assert.Equal(t, "180ms", entries[2].Report.Summary.P95ResponseTime)
+
+	entries, err = store.Load("API Tests", 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "c3d4e5f", entries[1].Commit)
+
+	_, err = store.Load("Missing", 0)
+	assert.ErrorContains(t, err, "no history for 'Missing'")
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "api-tests", slug("API Tests"))
+	assert.Equal(t, "checkout-v2", slug("../Checkout v2!"))
+	assert.Equal(t, "unnamed", slug(""))
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 50, 100}))
+	assert.Equal(t, "▁▁", sparkline([]float64{3, 3}), "flat values stay at the bottom")
+}
+
+func TestRender(t *testing.T) {
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		entry(start, "a1b2c3d", "120ms", 1000, 0),
+		entry(start.Add(24*time.Hour), "", "250ms", 980, 20),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, entries))
+	out := buf.String()
+	assert.Contains(t, out, `History of "API Tests" (2 runs)`)
+	assert.Contains(t, out, "a1b2c3d")
+	assert.Contains(t, out, "failed")
+	assert.Contains(t, out, "P95          ▁█  120ms → 250ms")
+	assert.Contains(t, out, "Error rate   ▁█  0.0% → 2.0%")
+}
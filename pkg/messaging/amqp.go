@@ -0,0 +1,337 @@
+package messaging
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// AMQP frame types
+const (
+	amqpMethodFrame    = 1
+	amqpHeaderFrame    = 2
+	amqpBodyFrame      = 3
+	amqpHeartbeatFrame = 8
+	amqpFrameEnd       = 0xCE
+)
+
+// AMQP methods, as class and method IDs
+type amqpMethod struct {
+	class, method uint16
+}
+
+var (
+	amqpConnectionStart   = amqpMethod{10, 10}
+	amqpConnectionStartOk = amqpMethod{10, 11}
+	amqpConnectionTune    = amqpMethod{10, 30}
+	amqpConnectionTuneOk  = amqpMethod{10, 31}
+	amqpConnectionOpen    = amqpMethod{10, 40}
+	amqpConnectionOpenOk  = amqpMethod{10, 41}
+	amqpConnectionClose   = amqpMethod{10, 50}
+	amqpChannelOpen       = amqpMethod{20, 10}
+	amqpChannelOpenOk     = amqpMethod{20, 11}
+	amqpChannelClose      = amqpMethod{20, 40}
+	amqpChannelCloseOk    = amqpMethod{20, 41}
+	amqpConfirmSelect     = amqpMethod{85, 10}
+	amqpConfirmSelectOk   = amqpMethod{85, 11}
+	amqpBasicPublish      = amqpMethod{60, 40}
+	amqpBasicAck          = amqpMethod{60, 80}
+	amqpBasicNack         = amqpMethod{60, 120}
+)
+
+const (
+	amqpChannel  = 1
+	amqpFrameMax = 131072 // Used when the server sets no limit
+)
+
+// amqpPublisher publishes on one channel in confirm mode, so a publish
+// completes when the broker acknowledges the message
+type amqpPublisher struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	frameMax    uint32
+	deliveryTag uint64
+	closed      error // set once the broker closed the channel or connection
+}
+
+func dialAMQP(ctx context.Context, u *url.URL, dialer *net.Dialer) (*amqpPublisher, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		port := "5672"
+		if u.Scheme == "amqps" {
+			port = "5671"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "amqps" {
+		conn = tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+	}
+
+	p := &amqpPublisher{conn: conn, reader: bufio.NewReader(conn)}
+	setDeadline(ctx, conn)
+	if err := p.handshake(u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// handshake opens the connection, the channel, and confirm mode
+func (p *amqpPublisher) handshake(u *url.URL) error {
+	if _, err := p.conn.Write([]byte("AMQP\x00\x00\x09\x01")); err != nil {
+		return err
+	}
+	if _, err := p.expect(amqpConnectionStart); err != nil {
+		return err
+	}
+
+	user, password := "guest", "guest"
+	if u.User != nil {
+		user = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	var startOk encoder
+	amqpTable(&startOk, map[string]string{"product": "bombardino"})
+	amqpShortString(&startOk, "PLAIN")
+	amqpLongString(&startOk, "\x00"+user+"\x00"+password)
+	amqpShortString(&startOk, "en_US")
+	if err := p.send(0, amqpConnectionStartOk, startOk.buf); err != nil {
+		return err
+	}
+
+	tune, err := p.expect(amqpConnectionTune)
+	if err != nil {
+		return err
+	}
+	d := &decoder{buf: tune}
+	channelMax := d.int16()
+	p.frameMax = uint32(d.int32())
+	if p.frameMax == 0 {
+		p.frameMax = amqpFrameMax
+	}
+	var tuneOk encoder
+	tuneOk.int16(channelMax)
+	tuneOk.int32(int32(p.frameMax))
+	tuneOk.int16(0) // no heartbeats
+	if err := p.send(0, amqpConnectionTuneOk, tuneOk.buf); err != nil {
+		return err
+	}
+
+	vhost := "/"
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		vhost = path
+	}
+	var open encoder
+	amqpShortString(&open, vhost)
+	amqpShortString(&open, "")
+	open.int8(0)
+	if err := p.send(0, amqpConnectionOpen, open.buf); err != nil {
+		return err
+	}
+	if _, err := p.expect(amqpConnectionOpenOk); err != nil {
+		return err
+	}
+
+	var channelOpen encoder
+	amqpShortString(&channelOpen, "")
+	if err := p.send(amqpChannel, amqpChannelOpen, channelOpen.buf); err != nil {
+		return err
+	}
+	if _, err := p.expect(amqpChannelOpenOk); err != nil {
+		return err
+	}
+
+	if err := p.send(amqpChannel, amqpConfirmSelect, []byte{0}); err != nil {
+		return err
+	}
+	_, err = p.expect(amqpConfirmSelectOk)
+	return err
+}
+
+func (p *amqpPublisher) Publish(ctx context.Context, msg Message) error {
+	if p.closed != nil {
+		return p.closed
+	}
+	setDeadline(ctx, p.conn)
+
+	var publish encoder
+	publish.int16(0)
+	amqpShortString(&publish, msg.Topic)
+	amqpShortString(&publish, msg.Key)
+	publish.int8(0) // not mandatory, not immediate
+	frames := amqpFrame(nil, amqpMethodFrame, amqpChannel, amqpMethodPayload(amqpBasicPublish, publish.buf))
+
+	var header encoder
+	header.int16(int16(amqpBasicPublish.class))
+	header.int16(0) // weight
+	header.int64(int64(len(msg.Value)))
+	var flags uint16
+	var properties encoder
+	if msg.ContentType != "" {
+		flags |= 0x8000
+		amqpShortString(&properties, msg.ContentType)
+	}
+	if len(msg.Headers) > 0 {
+		flags |= 0x2000
+		amqpTable(&properties, msg.Headers)
+	}
+	header.int16(int16(flags))
+	header.raw(properties.buf)
+	frames = amqpFrame(frames, amqpHeaderFrame, amqpChannel, header.buf)
+
+	for body, size := msg.Value, int(p.frameMax)-8; len(body) > 0; {
+		n := min(len(body), size)
+		frames = amqpFrame(frames, amqpBodyFrame, amqpChannel, body[:n])
+		body = body[n:]
+	}
+	if _, err := p.conn.Write(frames); err != nil {
+		return err
+	}
+	p.deliveryTag++
+
+	// Wait for the confirm of this message
+	for {
+		method, args, err := p.readMethod()
+		if err != nil {
+			return err
+		}
+		switch method {
+		case amqpBasicAck, amqpBasicNack:
+			d := &decoder{buf: args}
+			tag := uint64(d.int64())
+			multiple := d.int8()&1 != 0
+			if tag != p.deliveryTag && !(multiple && tag > p.deliveryTag) {
+				continue
+			}
+			if method == amqpBasicNack {
+				return &BrokerError{"amqp: message nacked by the broker"}
+			}
+			return nil
+		}
+	}
+}
+
+func (p *amqpPublisher) Close() error {
+	if p.closed == nil {
+		var close encoder
+		close.int16(200)
+		amqpShortString(&close, "")
+		close.int16(0)
+		close.int16(0)
+		p.send(0, amqpConnectionClose, close.buf)
+	}
+	return p.conn.Close()
+}
+
+// expect reads methods until the expected one, returning its arguments
+func (p *amqpPublisher) expect(expected amqpMethod) ([]byte, error) {
+	for {
+		method, args, err := p.readMethod()
+		if err != nil {
+			return nil, err
+		}
+		if method == expected {
+			return args, nil
+		}
+	}
+}
+
+// readMethod reads the next method frame, skipping other frames, and turns
+// a channel or connection close by the broker into an error
+func (p *amqpPublisher) readMethod() (amqpMethod, []byte, error) {
+	for {
+		var header [7]byte
+		if _, err := io.ReadFull(p.reader, header[:]); err != nil {
+			return amqpMethod{}, nil, err
+		}
+		// Frames are at most frame_max, or the default until it is negotiated
+		limit := p.frameMax
+		if limit == 0 {
+			limit = amqpFrameMax
+		}
+		size := binary.BigEndian.Uint32(header[3:])
+		if size > limit-8 {
+			return amqpMethod{}, nil, fmt.Errorf("amqp: frame of %d bytes exceeds frame_max %d, is the server an AMQP broker?", size, limit)
+		}
+		payload := make([]byte, size+1)
+		if _, err := io.ReadFull(p.reader, payload); err != nil {
+			return amqpMethod{}, nil, err
+		}
+		if payload[len(payload)-1] != amqpFrameEnd {
+			return amqpMethod{}, nil, fmt.Errorf("amqp: malformed frame")
+		}
+		if header[0] != amqpMethodFrame || len(payload) < 5 {
+			continue
+		}
+
+		method := amqpMethod{binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])}
+		args := payload[4 : len(payload)-1]
+		if method == amqpChannelClose || method == amqpConnectionClose {
+			d := &decoder{buf: args}
+			code := d.int16()
+			text := amqpReadShortString(d)
+			if method == amqpChannelClose {
+				p.send(amqpChannel, amqpChannelCloseOk, nil)
+			}
+			p.closed = &BrokerError{fmt.Sprintf("amqp: %d %s", code, text)}
+			return method, nil, p.closed
+		}
+		return method, args, nil
+	}
+}
+
+func (p *amqpPublisher) send(channel uint16, method amqpMethod, args []byte) error {
+	_, err := p.conn.Write(amqpFrame(nil, amqpMethodFrame, channel, amqpMethodPayload(method, args)))
+	return err
+}
+
+func amqpMethodPayload(method amqpMethod, args []byte) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, method.class)
+	payload = binary.BigEndian.AppendUint16(payload, method.method)
+	return append(payload, args...)
+}
+
+// amqpFrame appends a frame to buf
+func amqpFrame(buf []byte, frameType byte, channel uint16, payload []byte) []byte {
+	buf = append(buf, frameType)
+	buf = binary.BigEndian.AppendUint16(buf, channel)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	return append(buf, amqpFrameEnd)
+}
+
+func amqpShortString(e *encoder, s string) {
+	e.buf = append(e.buf, byte(len(s)))
+	e.raw([]byte(s))
+}
+
+func amqpLongString(e *encoder, s string) {
+	e.int32(int32(len(s)))
+	e.raw([]byte(s))
+}
+
+func amqpReadShortString(d *decoder) string {
+	return string(d.next(int(uint8(d.int8()))))
+}
+
+// amqpTable encodes a field table of string values
+func amqpTable(e *encoder, fields map[string]string) {
+	var table encoder
+	for key, value := range fields {
+		amqpShortString(&table, key)
+		table.buf = append(table.buf, 'S')
+		amqpLongString(&table, value)
+	}
+	e.int32(int32(len(table.buf)))
+	e.raw(table.buf)
+}
@@ -0,0 +1,389 @@
+package messaging
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kafka API keys and the versions used: the oldest ones Kafka 4 still serves
+const (
+	kafkaProduce         = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadata        = 3
+	kafkaMetadataVersion = 4
+)
+
+const (
+	kafkaClientID    = "bombardino"
+	kafkaAcksAll     = -1
+	kafkaTimeout     = 30 * time.Second // Broker-side wait for the replicas
+	kafkaMaxResponse = 100 << 20        // Largest response accepted, Kafka's default socket.request.max.bytes
+)
+
+// kafkaErrors names the error codes a producer commonly gets
+var kafkaErrors = map[int16]string{
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	17: "INVALID_TOPIC_EXCEPTION",
+	19: "NOT_ENOUGH_REPLICAS",
+	20: "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	87: "INVALID_RECORD",
+}
+
+// kafkaError describes a Kafka error code
+func kafkaError(code int16) string {
+	if name, ok := kafkaErrors[code]; ok {
+		return name
+	}
+	return "error code " + strconv.Itoa(int(code))
+}
+
+// kafkaPublisher produces records with acks=all to the leaders of their
+// partitions. Records with a key go to the partition of Kafka's default
+// partitioner (murmur2 of the key); the others are spread round-robin.
+type kafkaPublisher struct {
+	bootstrap   []string
+	dialer      *net.Dialer
+	correlation int32
+	brokers     map[int32]string    // node ID -> host:port
+	leaders     map[string][]int32  // topic -> leader node ID of each partition
+	conns       map[string]net.Conn // host:port -> connection
+	next        int                 // round-robin partition counter
+}
+
+func dialKafka(ctx context.Context, u *url.URL, dialer *net.Dialer) (*kafkaPublisher, error) {
+	p := &kafkaPublisher{
+		dialer:  dialer,
+		brokers: make(map[int32]string),
+		leaders: make(map[string][]int32),
+		conns:   make(map[string]net.Conn),
+	}
+	for _, host := range strings.Split(u.Host, ",") {
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "9092")
+		}
+		p.bootstrap = append(p.bootstrap, host)
+	}
+	if len(p.bootstrap) == 0 {
+		return nil, fmt.Errorf("kafka URL has no broker")
+	}
+
+	// Connect to a bootstrap broker right away, so unreachable clusters fail
+	// the first request before it publishes anything
+	var err error
+	for _, addr := range p.bootstrap {
+		if _, err = p.conn(ctx, addr); err == nil {
+			return p, nil
+		}
+	}
+	return nil, err
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	leaders, err := p.partitions(ctx, msg.Topic)
+	if err != nil {
+		return err
+	}
+
+	partition := int32(p.next % len(leaders))
+	p.next++
+	if msg.Key != "" {
+		partition = (murmur2([]byte(msg.Key)) & 0x7fffffff) % int32(len(leaders))
+	}
+	addr, ok := p.brokers[leaders[partition]]
+	if !ok {
+		delete(p.leaders, msg.Topic)
+		return &BrokerError{fmt.Sprintf("kafka: no leader for %s-%d", msg.Topic, partition)}
+	}
+
+	var body encoder
+	body.int16(-1) // transactional_id
+	body.int16(kafkaAcksAll)
+	body.int32(int32(kafkaTimeout / time.Millisecond))
+	body.int32(1)
+	kafkaString(&body, msg.Topic)
+	body.int32(1)
+	body.int32(partition)
+	batch := recordBatch(msg, time.Now())
+	body.int32(int32(len(batch)))
+	body.raw(batch)
+
+	resp, err := p.roundTrip(ctx, addr, kafkaProduce, kafkaProduceVersion, body.buf)
+	if err != nil {
+		return err
+	}
+	d := &decoder{buf: resp}
+	var code int16
+	for topics := d.int32(); topics > 0 && d.err == nil; topics-- {
+		kafkaReadString(d)
+		for partitions := d.int32(); partitions > 0 && d.err == nil; partitions-- {
+			d.int32()
+			if c := d.int16(); c != 0 {
+				code = c
+			}
+			d.int64() // base_offset
+			d.int64() // log_append_time
+		}
+	}
+	if d.err != nil {
+		p.drop(addr)
+		return fmt.Errorf("kafka produce: %w", d.err)
+	}
+	if code != 0 {
+		// Leadership may have moved; look the partitions up again next time
+		delete(p.leaders, msg.Topic)
+		return &BrokerError{fmt.Sprintf("kafka produce to %s-%d: %s", msg.Topic, partition, kafkaError(code))}
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) Close() error {
+	for addr := range p.conns {
+		p.drop(addr)
+	}
+	return nil
+}
+
+// partitions returns the partition leaders of a topic, from a metadata
+// request the first time
+func (p *kafkaPublisher) partitions(ctx context.Context, topic string) ([]int32, error) {
+	if leaders, ok := p.leaders[topic]; ok {
+		return leaders, nil
+	}
+
+	var body encoder
+	body.int32(1)
+	kafkaString(&body, topic)
+	body.int8(1) // allow_auto_topic_creation
+
+	var resp []byte
+	var err error
+	for _, addr := range p.bootstrap {
+		if resp, err = p.roundTrip(ctx, addr, kafkaMetadata, kafkaMetadataVersion, body.buf); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{buf: resp}
+	d.int32() // throttle_time_ms
+	for brokers := d.int32(); brokers > 0 && d.err == nil; brokers-- {
+		id := d.int32()
+		host := kafkaReadString(d)
+		port := d.int32()
+		kafkaReadString(d) // rack
+		p.brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	kafkaReadString(d) // cluster_id
+	d.int32()          // controller_id
+
+	var leaders []int32
+	var topicErr int16
+	for topics := d.int32(); topics > 0 && d.err == nil; topics-- {
+		code := d.int16()
+		name := kafkaReadString(d)
+		d.int8() // is_internal
+		for partitions := d.int32(); partitions > 0 && d.err == nil; partitions-- {
+			d.int16() // error_code
+			index := d.int32()
+			leader := d.int32()
+			d.next(4 * int(d.int32())) // replica_nodes
+			d.next(4 * int(d.int32())) // isr_nodes
+			if name != topic {
+				continue
+			}
+			for int(index) >= len(leaders) {
+				leaders = append(leaders, -1)
+			}
+			leaders[index] = leader
+		}
+		if name == topic {
+			topicErr = code
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("kafka metadata: %w", d.err)
+	}
+	if topicErr != 0 {
+		return nil, &BrokerError{fmt.Sprintf("kafka topic %s: %s", topic, kafkaError(topicErr))}
+	}
+	if len(leaders) == 0 {
+		return nil, &BrokerError{fmt.Sprintf("kafka topic %s has no partitions", topic)}
+	}
+	p.leaders[topic] = leaders
+	return leaders, nil
+}
+
+// conn returns the connection to a broker, dialing it the first time
+func (p *kafkaPublisher) conn(ctx context.Context, addr string) (net.Conn, error) {
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := p.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// drop closes the connection to a broker after a failure
+func (p *kafkaPublisher) drop(addr string) {
+	if conn, ok := p.conns[addr]; ok {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+// roundTrip sends a request to a broker and returns the body of its response
+func (p *kafkaPublisher) roundTrip(ctx context.Context, addr string, apiKey, version int16, body []byte) ([]byte, error) {
+	conn, err := p.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	setDeadline(ctx, conn)
+
+	p.correlation++
+	var req encoder
+	req.int32(0) // size, set below
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(p.correlation)
+	kafkaString(&req, kafkaClientID)
+	req.raw(body)
+	binary.BigEndian.PutUint32(req.buf, uint32(len(req.buf)-4))
+
+	if _, err := conn.Write(req.buf); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	// A length read from a server that is not a broker could be anything
+	length := binary.BigEndian.Uint32(size[:])
+	if length > kafkaMaxResponse {
+		p.drop(addr)
+		return nil, fmt.Errorf("kafka: response of %d bytes exceeds the limit of %d bytes, is %s a Kafka broker?", length, kafkaMaxResponse, addr)
+	}
+	resp := make([]byte, length)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		p.drop(addr)
+		return nil, err
+	}
+	if len(resp) < 4 || int32(binary.BigEndian.Uint32(resp)) != p.correlation {
+		p.drop(addr)
+		return nil, fmt.Errorf("kafka: response does not match the request")
+	}
+	return resp[4:], nil
+}
+
+// recordBatch encodes a message as a record batch (magic 2) of one record
+func recordBatch(msg Message, now time.Time) []byte {
+	var record encoder
+	record.int8(0)   // attributes
+	record.varint(0) // timestamp delta
+	record.varint(0) // offset delta
+	if msg.Key == "" {
+		record.varint(-1)
+	} else {
+		record.varint(int64(len(msg.Key)))
+		record.raw([]byte(msg.Key))
+	}
+	record.varint(int64(len(msg.Value)))
+	record.raw(msg.Value)
+	record.varint(int64(len(msg.Headers)))
+	for key, value := range msg.Headers {
+		record.varint(int64(len(key)))
+		record.raw([]byte(key))
+		record.varint(int64(len(value)))
+		record.raw([]byte(value))
+	}
+
+	// The CRC covers everything from the attributes on
+	timestamp := now.UnixMilli()
+	var crcd encoder
+	crcd.int16(0) // attributes
+	crcd.int32(0) // last offset delta
+	crcd.int64(timestamp)
+	crcd.int64(timestamp)
+	crcd.int64(-1) // producer ID
+	crcd.int16(-1) // producer epoch
+	crcd.int32(-1) // base sequence
+	crcd.int32(1)  // records
+	crcd.varint(int64(len(record.buf)))
+	crcd.raw(record.buf)
+
+	var batch encoder
+	batch.int64(0) // base offset
+	batch.int32(int32(4 + 1 + 4 + len(crcd.buf)))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(2)   // magic
+	batch.int32(int32(crc32.Checksum(crcd.buf, crc32.MakeTable(crc32.Castagnoli))))
+	batch.raw(crcd.buf)
+	return batch.buf
+}
+
+// murmur2 is the hash of Kafka's default partitioner
+func murmur2(data []byte) int32 {
+	const m = 0x5bd1e995
+	h := uint32(0x9747b28c) ^ uint32(len(data))
+	tail := len(data) &^ 3
+	for i := 0; i < tail; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> 24
+		k *= m
+		h *= m
+		h ^= k
+	}
+	switch len(data) & 3 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+func kafkaString(e *encoder, s string) {
+	e.int16(int16(len(s)))
+	e.raw([]byte(s))
+}
+
+// kafkaReadString reads a string or a nullable string, null being empty
+func kafkaReadString(d *decoder) string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
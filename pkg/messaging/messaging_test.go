@@ -0,0 +1,485 @@
+package messaging
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKafka is a single-node cluster serving metadata and produce requests
+type fakeKafka struct {
+	listener   net.Listener
+	partitions map[string]int   // topic -> partitions
+	errors     map[string]int16 // topic -> produce error code
+
+	mu      sync.Mutex
+	records []fakeRecord
+}
+
+type fakeRecord struct {
+	topic     string
+	partition int32
+	key       string
+	value     string
+	headers   map[string]string
+}
+
+func newFakeKafka(t *testing.T) *fakeKafka {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	k := &fakeKafka{
+		listener:   listener,
+		partitions: map[string]int{"orders": 3, "audit": 1},
+		errors:     map[string]int16{"audit": 29},
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go k.serve(t, conn)
+		}
+	}()
+	return k
+}
+
+func (k *fakeKafka) url() string {
+	return "kafka://" + k.listener.Addr().String()
+}
+
+func (k *fakeKafka) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		d := &decoder{buf: req}
+		apiKey, version, correlation := d.int16(), d.int16(), d.int32()
+		assert.Equal(t, kafkaClientID, kafkaReadString(d))
+
+		var resp encoder
+		resp.int32(correlation)
+		switch apiKey {
+		case kafkaMetadata:
+			assert.Equal(t, int16(kafkaMetadataVersion), version)
+			k.metadata(d, &resp)
+		case kafkaProduce:
+			assert.Equal(t, int16(kafkaProduceVersion), version)
+			k.produce(t, d, &resp)
+		}
+		if !assert.NoError(t, d.err) {
+			return
+		}
+
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(resp.buf)))
+		conn.Write(append(out, resp.buf...))
+	}
+}
+
+func (k *fakeKafka) metadata(d *decoder, resp *encoder) {
+	var topics []string
+	for n := d.int32(); n > 0; n-- {
+		topics = append(topics, kafkaReadString(d))
+	}
+	d.int8()
+
+	host, port, _ := net.SplitHostPort(k.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	resp.int32(0)
+	resp.int32(1)
+	resp.int32(1)
+	kafkaString(resp, host)
+	resp.int32(int32(portNumber))
+	resp.int16(-1)
+	resp.int16(-1)
+	resp.int32(1)
+	resp.int32(int32(len(topics)))
+	for _, topic := range topics {
+		partitions, ok := k.partitions[topic]
+		if ok {
+			resp.int16(0)
+		} else {
+			resp.int16(3)
+		}
+		kafkaString(resp, topic)
+		resp.int8(0)
+		resp.int32(int32(partitions))
+		for i := 0; i < partitions; i++ {
+			resp.int16(0)
+			resp.int32(int32(i))
+			resp.int32(1)
+			resp.int32(1)
+			resp.int32(1)
+			resp.int32(1)
+			resp.int32(1)
+		}
+	}
+}
+
+func (k *fakeKafka) produce(t *testing.T, d *decoder, resp *encoder) {
+	assert.Equal(t, int16(-1), d.int16()) // transactional_id
+	assert.Equal(t, int16(kafkaAcksAll), d.int16())
+	d.int32()
+
+	resp.int32(d.int32())
+	topic := kafkaReadString(d)
+	kafkaString(resp, topic)
+	resp.int32(d.int32())
+	partition := d.int32()
+	batch := d.next(int(d.int32()))
+
+	b := &decoder{buf: batch}
+	b.int64()
+	assert.Equal(t, int(b.int32()), len(batch)-12)
+	b.int32()
+	assert.Equal(t, int8(2), b.int8())
+	crc := uint32(b.int32())
+	assert.Equal(t, crc32.Checksum(b.buf, crc32.MakeTable(crc32.Castagnoli)), crc)
+	b.next(2 + 4 + 8 + 8 + 8 + 2 + 4)
+	assert.Equal(t, int32(1), b.int32())
+
+	r := &varintReader{buf: b.buf}
+	r.varint() // length
+	r.buf = r.buf[1:]
+	r.varint()
+	r.varint()
+	record := fakeRecord{topic: topic, partition: partition, headers: map[string]string{}}
+	if n := r.varint(); n >= 0 {
+		record.key = string(r.next(n))
+	}
+	record.value = string(r.next(r.varint()))
+	for n := r.varint(); n > 0; n-- {
+		key := string(r.next(r.varint()))
+		record.headers[key] = string(r.next(r.varint()))
+	}
+	k.mu.Lock()
+	k.records = append(k.records, record)
+	k.mu.Unlock()
+
+	resp.int32(partition)
+	resp.int16(k.errors[topic])
+	resp.int64(0)
+	resp.int64(-1)
+	resp.int32(0)
+}
+
+type varintReader struct {
+	buf []byte
+}
+
+func (r *varintReader) varint() int64 {
+	v, n := binary.Varint(r.buf)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *varintReader) next(n int64) []byte {
+	data := r.buf[:n]
+	r.buf = r.buf[n:]
+	return data
+}
+
+func TestKafkaPublish(t *testing.T) {
+	k := newFakeKafka(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := Dial(ctx, k.url(), time.Second)
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Publish(ctx, Message{Topic: "orders", Key: "order-42", Value: []byte(`{"id":42}`), Headers: map[string]string{"source": "bombardino"}}))
+	require.NoError(t, p.Publish(ctx, Message{Topic: "orders", Value: []byte("a")}))
+	require.NoError(t, p.Publish(ctx, Message{Topic: "orders", Value: []byte("b")}))
+
+	require.Len(t, k.records, 3)
+	assert.Equal(t, fakeRecord{
+		topic:     "orders",
+		partition: (murmur2([]byte("order-42")) & 0x7fffffff) % 3,
+		key:       "order-42",
+		value:     `{"id":42}`,
+		headers:   map[string]string{"source": "bombardino"},
+	}, k.records[0])
+	// Records without a key are spread over the partitions
+	assert.NotEqual(t, k.records[1].partition, k.records[2].partition)
+
+	err = p.Publish(ctx, Message{Topic: "audit", Value: []byte("x")})
+	var brokerErr *BrokerError
+	require.ErrorAs(t, err, &brokerErr)
+	assert.Contains(t, err.Error(), "TOPIC_AUTHORIZATION_FAILED")
+
+	err = p.Publish(ctx, Message{Topic: "missing", Value: []byte("x")})
+	require.ErrorAs(t, err, &brokerErr)
+	assert.Contains(t, err.Error(), "kafka topic missing: UNKNOWN_TOPIC_OR_PARTITION")
+}
+
+func TestKafkaDial_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = Dial(context.Background(), "kafka://"+addr, time.Second)
+	assert.Error(t, err)
+}
+
+func TestMurmur2(t *testing.T) {
+	// Values of Kafka's own Utils.murmur2
+	tests := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, murmur2([]byte(input)), input)
+	}
+}
+
+// fakeAMQP is a broker accepting a connection in confirm mode and
+// acknowledging its publishes
+type fakeAMQP struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	user      string
+	vhost     string
+	published []fakeDelivery
+}
+
+type fakeDelivery struct {
+	exchange    string
+	routingKey  string
+	contentType string
+	headers     map[string]string
+	body        string
+}
+
+func newFakeAMQP(t *testing.T) *fakeAMQP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &fakeAMQP{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(t, conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeAMQP) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	protocol := make([]byte, 8)
+	if _, err := io.ReadFull(reader, protocol); err != nil {
+		return
+	}
+	assert.Equal(t, "AMQP\x00\x00\x09\x01", string(protocol))
+
+	send := func(channel uint16, method amqpMethod, args []byte) {
+		conn.Write(amqpFrame(nil, amqpMethodFrame, channel, amqpMethodPayload(method, args)))
+	}
+	read := func() (byte, []byte) {
+		var header [7]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return 0, nil
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+		io.ReadFull(reader, payload)
+		return header[0], payload[:len(payload)-1]
+	}
+	readMethod := func(expected amqpMethod) *decoder {
+		_, payload := read()
+		if len(payload) < 4 {
+			return &decoder{err: io.ErrUnexpectedEOF}
+		}
+		assert.Equal(t, expected, amqpMethod{binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])})
+		return &decoder{buf: payload[4:]}
+	}
+
+	var start encoder
+	start.int8(0)
+	start.int8(9)
+	amqpTable(&start, nil)
+	amqpLongString(&start, "PLAIN AMQPLAIN")
+	amqpLongString(&start, "en_US")
+	send(0, amqpConnectionStart, start.buf)
+
+	d := readMethod(amqpConnectionStartOk)
+	d.next(int(d.int32()))
+	assert.Equal(t, "PLAIN", amqpReadShortString(d))
+	response := string(d.next(int(d.int32())))
+
+	var tune encoder
+	tune.int16(2047)
+	tune.int32(4096)
+	tune.int16(60)
+	send(0, amqpConnectionTune, tune.buf)
+	d = readMethod(amqpConnectionTuneOk)
+	d.int16()
+	assert.Equal(t, int32(4096), d.int32())
+
+	d = readMethod(amqpConnectionOpen)
+	b.mu.Lock()
+	b.user, b.vhost = response, amqpReadShortString(d)
+	b.mu.Unlock()
+	send(0, amqpConnectionOpenOk, []byte{0})
+	readMethod(amqpChannelOpen)
+	send(1, amqpChannelOpenOk, []byte{0, 0, 0, 0})
+	readMethod(amqpConfirmSelect)
+	send(1, amqpConfirmSelectOk, nil)
+
+	var tag uint64
+	for {
+		frameType, payload := read()
+		if frameType == 0 {
+			return
+		}
+		if frameType != amqpMethodFrame || binary.BigEndian.Uint16(payload[2:]) != amqpBasicPublish.method {
+			continue
+		}
+		d := &decoder{buf: payload[4:]}
+		d.int16()
+		delivery := fakeDelivery{exchange: amqpReadShortString(d), routingKey: amqpReadShortString(d), headers: map[string]string{}}
+
+		_, header := read()
+		h := &decoder{buf: header}
+		h.int16()
+		h.int16()
+		size := h.int64()
+		flags := uint16(h.int16())
+		if flags&0x8000 != 0 {
+			delivery.contentType = amqpReadShortString(h)
+		}
+		if flags&0x2000 != 0 {
+			table := &decoder{buf: h.next(int(h.int32()))}
+			for len(table.buf) > 0 {
+				key := amqpReadShortString(table)
+				table.int8()
+				delivery.headers[key] = string(table.next(int(table.int32())))
+			}
+		}
+		for int64(len(delivery.body)) < size {
+			_, body := read()
+			assert.LessOrEqual(t, len(body), 4096-8)
+			delivery.body += string(body)
+		}
+
+		if delivery.exchange == "missing" {
+			var close encoder
+			close.int16(404)
+			amqpShortString(&close, "NOT_FOUND - no exchange 'missing' in vhost '/'")
+			close.int16(60)
+			close.int16(40)
+			send(1, amqpChannelClose, close.buf)
+			continue
+		}
+		b.mu.Lock()
+		b.published = append(b.published, delivery)
+		b.mu.Unlock()
+		tag++
+		var ack encoder
+		ack.int64(int64(tag))
+		ack.int8(0)
+		send(1, amqpBasicAck, ack.buf)
+	}
+}
+
+func TestAMQPPublish(t *testing.T) {
+	b := newFakeAMQP(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := Dial(ctx, "amqp://loader:s3cret@"+b.listener.Addr().String()+"/orders", time.Second)
+	require.NoError(t, err)
+	defer p.Close()
+
+	large := make([]byte, 10000)
+	for i := range large {
+		large[i] = 'x'
+	}
+	require.NoError(t, p.Publish(ctx, Message{Topic: "events", Key: "order.created", Value: []byte(`{"id":42}`), ContentType: "application/json", Headers: map[string]string{"source": "bombardino"}}))
+	require.NoError(t, p.Publish(ctx, Message{Key: "work", Value: large}))
+
+	b.mu.Lock()
+	assert.Equal(t, "\x00loader\x00s3cret", b.user)
+	assert.Equal(t, "orders", b.vhost)
+	require.Len(t, b.published, 2)
+	assert.Equal(t, fakeDelivery{exchange: "events", routingKey: "order.created", contentType: "application/json", headers: map[string]string{"source": "bombardino"}, body: `{"id":42}`}, b.published[0])
+	assert.Equal(t, "work", b.published[1].routingKey)
+	assert.Len(t, b.published[1].body, 10000)
+	b.mu.Unlock()
+
+	err = p.Publish(ctx, Message{Topic: "missing", Key: "x", Value: []byte("x")})
+	var brokerErr *BrokerError
+	require.ErrorAs(t, err, &brokerErr)
+	assert.Equal(t, "amqp: 404 NOT_FOUND - no exchange 'missing' in vhost '/'", err.Error())
+
+	// The broker closed the channel, so the publisher cannot be used again
+	assert.ErrorIs(t, p.Publish(ctx, Message{Key: "work"}), err)
+}
+
+func TestDial_UnsupportedScheme(t *testing.T) {
+	_, err := Dial(context.Background(), "mqtt://localhost:1883", time.Second)
+	assert.EqualError(t, err, "unsupported broker URL scheme 'mqtt' (expected kafka, amqp, or amqps)")
+}
+
+// listenHTTP starts a server answering every connection like an HTTP server
+// would, to dial a broker address that points at an HTTP port
+func listenHTTP(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Read(make([]byte, 512))
+				conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestDial_NotABroker(t *testing.T) {
+	addr := listenHTTP(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := Dial(ctx, "kafka://"+addr, time.Second)
+	require.NoError(t, err)
+	defer p.Close()
+	assert.ErrorContains(t, p.Publish(ctx, Message{Topic: "orders", Value: []byte("x")}), "exceeds the limit")
+
+	_, err = Dial(ctx, "amqp://guest:guest@"+addr+"/", time.Second)
+	assert.ErrorContains(t, err, "exceeds frame_max")
+}
@@ -0,0 +1,289 @@
+// Package mock serves configurable fake endpoints for bombardino mock, so
+// configs can be developed and demoed without a real target.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/variables"
+)
+
+// Spec lists the endpoints served by the mock server. Routes are matched in
+// order and the first match wins.
+type Spec struct {
+	Routes []Route
+}
+
+// Route describes a fake endpoint and the response it returns
+type Route struct {
+	Method  string            // Empty matches any method
+	Path    string            // Segments like {id} match any value, a trailing /* matches the rest
+	Status  int               // Response status (default 200)
+	Headers map[string]string // Response headers, may contain ${...} templates
+	Body    interface{}       // JSON value or string, may contain ${...} templates
+	Latency time.Duration     // Wait before responding
+	Jitter  time.Duration     // Random variation added to or subtracted from latency
+}
+
+type rawSpec struct {
+	Routes []rawRoute `json:"routes"`
+}
+
+type rawRoute struct {
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path"`
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+	Latency string            `json:"latency,omitempty"`
+	Jitter  string            `json:"jitter,omitempty"`
+}
+
+// LoadSpec reads and validates a mock spec file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return ParseSpec(data)
+}
+
+// ParseSpec parses and validates a mock spec
+func ParseSpec(data []byte) (*Spec, error) {
+	var raw rawSpec
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	spec := &Spec{}
+	for i, rawRoute := range raw.Routes {
+		if !strings.HasPrefix(rawRoute.Path, "/") {
+			return nil, fmt.Errorf("route %d: path must start with /", i)
+		}
+		route := Route{
+			Method:  strings.ToUpper(rawRoute.Method),
+			Path:    rawRoute.Path,
+			Status:  rawRoute.Status,
+			Headers: rawRoute.Headers,
+			Body:    rawRoute.Body,
+		}
+		if route.Status == 0 {
+			route.Status = http.StatusOK
+		}
+		if route.Status < 100 || route.Status > 599 {
+			return nil, fmt.Errorf("route %d: invalid status %d", i, route.Status)
+		}
+		if rawRoute.Latency != "" {
+			latency, err := time.ParseDuration(rawRoute.Latency)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: invalid latency: %w", i, err)
+			}
+			route.Latency = latency
+		}
+		if rawRoute.Jitter != "" {
+			jitter, err := time.ParseDuration(rawRoute.Jitter)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: invalid jitter: %w", i, err)
+			}
+			route.Jitter = jitter
+		}
+		spec.Routes = append(spec.Routes, route)
+	}
+	return spec, nil
+}
+
+// Server answers requests from a spec. Without routes it echoes every
+// request back as JSON.
+type Server struct {
+	spec        *Spec
+	substitutor *variables.Substitutor
+}
+
+// NewServer creates a mock server for spec; a nil spec echoes all requests
+func NewServer(spec *Spec) *Server {
+	if spec == nil {
+		spec = &Spec{}
+	}
+	return &Server{
+		spec:        spec,
+		substitutor: variables.NewSubstitutor(variables.NewStore()),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	if len(s.spec.Routes) == 0 {
+		writeJSON(w, http.StatusOK, echo(r, body))
+		return
+	}
+
+	for _, route := range s.spec.Routes {
+		params, ok := route.match(r)
+		if !ok {
+			continue
+		}
+		s.respond(w, r, route, requestScope(r, params, body))
+		return
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"error": fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path),
+	})
+}
+
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, route Route, scope variables.Scope) {
+	if delay := latency(route); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for name, value := range route.Headers {
+		w.Header().Set(name, s.substitutor.SubstituteScoped(value, scope))
+	}
+
+	switch body := route.Body.(type) {
+	case nil:
+		w.WriteHeader(route.Status)
+	case string:
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(route.Status)
+		io.WriteString(w, s.substitutor.SubstituteScoped(body, scope))
+	default:
+		writeJSON(w, route.Status, s.substitutor.SubstituteBodyScoped(body, scope))
+	}
+}
+
+// match reports whether a request matches the route, returning the values
+// of its {name} path segments
+func (route Route) match(r *http.Request) (map[string]string, bool) {
+	if route.Method != "" && route.Method != r.Method {
+		return nil, false
+	}
+
+	pattern := strings.Split(strings.Trim(route.Path, "/"), "/")
+	actual := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	params := make(map[string]string)
+	for i, segment := range pattern {
+		if segment == "*" && i == len(pattern)-1 {
+			return params, true
+		}
+		if i >= len(actual) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = actual[i]
+			continue
+		}
+		if segment != actual[i] {
+			return nil, false
+		}
+	}
+	return params, len(pattern) == len(actual)
+}
+
+// requestScope exposes the request to response templates: ${method},
+// ${path}, ${path.id}, ${query.page}, ${header.user_agent} (lowercase,
+// dashes as underscores), ${body} and ${body.user.name}
+func requestScope(r *http.Request, params map[string]string, body []byte) variables.Scope {
+	scope := variables.Scope{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"body":   string(body),
+	}
+	for name, value := range params {
+		scope["path."+name] = value
+	}
+	for name, values := range r.URL.Query() {
+		scope["query."+name] = values[0]
+	}
+	for name, values := range r.Header {
+		scope["header."+strings.ReplaceAll(strings.ToLower(name), "-", "_")] = strings.Join(values, ", ")
+	}
+
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		flatten(scope, "body", parsed)
+	}
+	return scope
+}
+
+// flatten adds the fields of a JSON value to the scope under dotted names
+func flatten(scope variables.Scope, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			flatten(scope, prefix+"."+key, val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			flatten(scope, prefix+"."+strconv.Itoa(i), val)
+		}
+	default:
+		if prefix != "body" {
+			scope[prefix] = v
+		}
+	}
+}
+
+// latency returns the route's latency plus a uniform random offset within
+// ±jitter, never less than zero
+func latency(route Route) time.Duration {
+	delay := route.Latency
+	if route.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(2*route.Jitter)+1)) - route.Jitter
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// echo describes a request for the echo mode
+func echo(r *http.Request, body []byte) map[string]interface{} {
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	query := make(map[string]string)
+	for name, values := range r.URL.Query() {
+		query[name] = values[0]
+	}
+
+	var parsed interface{} = string(body)
+	var decoded interface{}
+	if json.Unmarshal(body, &decoded) == nil {
+		parsed = decoded
+	}
+
+	return map[string]interface{}{
+		"method":  r.Method,
+		"path":    r.URL.Path,
+		"query":   query,
+		"headers": headers,
+		"body":    parsed,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,153 @@
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `{
+	"routes": [
+		{"method": "POST", "path": "/users", "status": 201, "body": {"id": "${counter(user)}", "name": "${body.name}", "tags": ["${body.tags.0}"]}},
+		{"method": "GET", "path": "/users/{id}", "headers": {"X-Trace": "${header.x_trace:-none}"}, "body": {"id": "${path.id}", "page": "${query.page:-1}"}},
+		{"path": "/files/*", "body": "file ${path | upper}"},
+		{"path": "/slow", "latency": "50ms", "status": 204}
+	]
+}`
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+	require.Len(t, spec.Routes, 4)
+	assert.Equal(t, http.StatusOK, spec.Routes[1].Status)
+	assert.Equal(t, 50*time.Millisecond, spec.Routes[3].Latency)
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"invalid json", `{"routes": [`, "failed to parse spec"},
+		{"relative path", `{"routes": [{"path": "users"}]}`, "route 0: path must start with /"},
+		{"invalid status", `{"routes": [{"path": "/", "status": 42}]}`, "route 0: invalid status 42"},
+		{"invalid latency", `{"routes": [{"path": "/", "latency": "soon"}]}`, "route 0: invalid latency"},
+		{"invalid jitter", `{"routes": [{"path": "/", "jitter": "x"}]}`, "route 0: invalid jitter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpec([]byte(tt.spec))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestServer_Routes(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+	server := httptest.NewServer(NewServer(spec))
+	defer server.Close()
+
+	do := func(method, path, body string, headers map[string]string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	resp, body := do("POST", "/users", `{"name": "Mario", "tags": ["admin"]}`, nil)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.JSONEq(t, `{"id": 1, "name": "Mario", "tags": ["admin"]}`, body)
+	_, body = do("POST", "/users", `{"name": "Luigi", "tags": []}`, nil)
+	assert.Contains(t, body, `"id":2`)
+
+	resp, body = do("GET", "/users/42?page=3", "", map[string]string{"X-Trace": "abc"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "abc", resp.Header.Get("X-Trace"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"id": "42", "page": "3"}`, body)
+
+	resp, _ = do("GET", "/users/42", "", nil)
+	assert.Equal(t, "none", resp.Header.Get("X-Trace"))
+
+	resp, body = do("GET", "/files/a/b.txt", "", nil)
+	assert.Equal(t, "file /FILES/A/B.TXT", body)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	start := time.Now()
+	resp, _ = do("DELETE", "/slow", "", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	resp, body = do("DELETE", "/users/42", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, body, "no route for DELETE /users/42")
+}
+
+func TestServer_Echo(t *testing.T) {
+	server := httptest.NewServer(NewServer(nil))
+	defer server.Close()
+
+	req, err := http.NewRequest("PUT", server.URL+"/items/7?dry_run=true", strings.NewReader(`{"qty": 2}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var echoed map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&echoed))
+	assert.Equal(t, "PUT", echoed["method"])
+	assert.Equal(t, "/items/7", echoed["path"])
+	assert.Equal(t, map[string]interface{}{"dry_run": "true"}, echoed["query"])
+	assert.Equal(t, "Bearer t", echoed["headers"].(map[string]interface{})["Authorization"])
+	assert.Equal(t, map[string]interface{}{"qty": float64(2)}, echoed["body"])
+}
+
+func TestServer_DrivesEngine(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	require.NoError(t, err)
+	server := httptest.NewServer(NewServer(spec))
+	defer server.Close()
+
+	config := &models.Config{
+		Global: models.GlobalConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Iterations: 1},
+		Tests: []models.TestCase{
+			{
+				Name:           "Create",
+				Method:         "POST",
+				Path:           "/users",
+				Body:           map[string]interface{}{"name": "Mario", "tags": []interface{}{"admin"}},
+				ExpectedStatus: []int{201},
+				Extract:        []models.ExtractionRule{{Name: "user_id", Source: "body", Path: "id"}},
+			},
+			{
+				Name:           "Get",
+				Method:         "GET",
+				Path:           "/users/${user_id}",
+				ExpectedStatus: []int{200},
+				DependsOn:      []string{"Create"},
+				Assertions:     []models.Assertion{{Type: "json_path", Target: "id", Operator: "eq", Value: "1"}},
+			},
+		},
+	}
+
+	summary := engine.New(1, nil, false).Run(config)
+	assert.Equal(t, 2, summary.SuccessfulReqs)
+	assert.Equal(t, 0, summary.FailedReqs)
+}
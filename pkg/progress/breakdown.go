@@ -0,0 +1,120 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxTestLines is the number of tests the breakdown shows; the others are
+// summed up in a last line
+const maxTestLines = 10
+
+// testNameWidth is the width of the test name column of the breakdown
+const testNameWidth = 28
+
+// breakdown is the line per test drawn below a progress indicator
+type breakdown struct {
+	show      bool
+	tests     []*testProgress // In the order they first finished a request
+	byName    map[string]*testProgress
+	rateStart time.Time // Start of the window the per-test rates are computed over
+	lines     int       // Lines below the bar on screen
+}
+
+// testProgress counts the requests of a test for the breakdown
+type testProgress struct {
+	name   string
+	phase  int // Latest DAG phase of the test (0 outside DAG execution)
+	count  int
+	errors int
+	base   int     // Count at the start of the rate window
+	rate   float64 // Requests per second over the last rate window
+}
+
+func newBreakdown() breakdown {
+	return breakdown{byName: make(map[string]*testProgress), rateStart: time.Now()}
+}
+
+// add counts a finished request of a test
+func (b *breakdown) add(name string, phase int, failed bool) {
+	test := b.byName[name]
+	if test == nil {
+		test = &testProgress{name: name}
+		b.byName[name] = test
+		b.tests = append(b.tests, test)
+	}
+	test.phase = phase
+	test.count++
+	if failed {
+		test.errors++
+	}
+}
+
+// render draws the breakdown below the bar line just written and moves the
+// cursor back to it, so the next render redraws both in place
+func (b *breakdown) render(out io.Writer) {
+	if !b.show {
+		return
+	}
+	fmt.Fprint(out, "\x1b[K")
+	b.updateRates()
+
+	lines := 0
+	for i, test := range b.tests {
+		if i == maxTestLines {
+			break
+		}
+		phase := ""
+		if test.phase > 0 {
+			phase = fmt.Sprintf("phase %d", test.phase)
+		}
+		fmt.Fprintf(out, "\n  %-*s %-8s %8d req | %8.1f req/s | %d errors\x1b[K",
+			testNameWidth, truncate(test.name, testNameWidth), phase, test.count, test.rate, test.errors)
+		lines++
+	}
+	if hidden := len(b.tests) - maxTestLines; hidden > 0 {
+		fmt.Fprintf(out, "\n  ... and %d more tests\x1b[K", hidden)
+		lines++
+	}
+
+	// Lines left over from a longer breakdown are cleared
+	for i := lines; i < b.lines; i++ {
+		fmt.Fprint(out, "\n\x1b[K")
+	}
+	b.lines = max(lines, b.lines)
+	if b.lines > 0 {
+		fmt.Fprintf(out, "\x1b[%dA\r", b.lines)
+	}
+}
+
+// finish leaves the breakdown on screen, ending the output below it
+func (b *breakdown) finish(out io.Writer) {
+	if b.lines > 0 {
+		fmt.Fprintf(out, "\x1b[%dB", b.lines)
+	}
+	fmt.Fprintln(out)
+}
+
+// updateRates recomputes the per-test rates once a second has passed since
+// the last time, so they reflect the current load rather than the average
+func (b *breakdown) updateRates() {
+	window := time.Since(b.rateStart)
+	if window < time.Second {
+		return
+	}
+	for _, test := range b.tests {
+		test.rate = float64(test.count-test.base) / window.Seconds()
+		test.base = test.count
+	}
+	b.rateStart = time.Now()
+}
+
+// truncate shortens name to width runes, marking the cut with an ellipsis
+func truncate(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,97 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DurationProgress shows the progress of a run that lasts a duration: the
+// bar fills with the time elapsed, and the requests are counted without a
+// total, since how many fit in the duration is not known in advance
+type DurationProgress struct {
+	duration  time.Duration
+	current   int
+	startTime time.Time
+	mu        sync.Mutex
+	width     int
+	lastPrint time.Time
+	out       io.Writer
+	tests     breakdown
+	finished  bool
+}
+
+// NewDuration returns the progress of a run of the given duration
+func NewDuration(duration time.Duration) *DurationProgress {
+	return &DurationProgress{
+		duration:  duration,
+		startTime: time.Now(),
+		width:     50,
+		lastPrint: time.Now(),
+		out:       os.Stdout,
+		tests:     newBreakdown(),
+	}
+}
+
+// ShowTests adds a line per test below the bar, as ProgressBar.ShowTests
+func (p *DurationProgress) ShowTests() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tests.show = true
+}
+
+// IncrementTest counts a finished request of a test, run in a DAG phase
+// (0 outside DAG execution)
+func (p *DurationProgress) IncrementTest(name string, phase int, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tests.add(name, phase, failed)
+	p.current++
+	if time.Since(p.lastPrint) > 100*time.Millisecond {
+		p.render()
+		p.lastPrint = time.Now()
+	}
+}
+
+func (p *DurationProgress) render() {
+	elapsed := time.Since(p.startTime)
+	percentage := 1.0
+	if p.duration > 0 && !p.finished {
+		percentage = min(float64(elapsed)/float64(p.duration), 1)
+	}
+	filled := int(percentage * float64(p.width))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", p.width-filled)
+
+	remaining := max(p.duration-elapsed, 0)
+	if p.finished {
+		remaining = 0
+	}
+
+	var rps float64
+	if elapsed.Seconds() > 0 {
+		rps = float64(p.current) / elapsed.Seconds()
+	}
+
+	fmt.Fprintf(p.out, "\r[%s] %d requests | %.1f req/s | Elapsed: %v | Remaining: %v",
+		bar,
+		p.current,
+		rps,
+		elapsed.Round(time.Second),
+		remaining.Round(time.Second),
+	)
+
+	p.tests.render(p.out)
+}
+
+func (p *DurationProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finished = true
+	p.render()
+	p.tests.finish(p.out)
+}
@@ -2,11 +2,22 @@ package progress
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Indicator shows the progress of a run as its requests finish: a
+// ProgressBar for runs of a number of requests, a DurationProgress for runs
+// of a duration
+type Indicator interface {
+	IncrementTest(name string, phase int, failed bool)
+	ShowTests()
+	Finish()
+}
+
 type ProgressBar struct {
 	total     int
 	current   int
@@ -14,6 +25,8 @@ type ProgressBar struct {
 	mu        sync.Mutex
 	width     int
 	lastPrint time.Time
+	out       io.Writer
+	tests     breakdown
 }
 
 func New(total int) *ProgressBar {
@@ -23,13 +36,38 @@ func New(total int) *ProgressBar {
 		startTime: time.Now(),
 		width:     50,
 		lastPrint: time.Now(),
+		out:       os.Stdout,
+		tests:     newBreakdown(),
 	}
 }
 
+// ShowTests adds a line per test below the bar, with its own counter,
+// current rate, and errors, so runs of several tests or DAG phases can be
+// followed test by test
+func (p *ProgressBar) ShowTests() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tests.show = true
+}
+
 func (p *ProgressBar) Increment() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	p.increment()
+}
+
+// IncrementTest counts a finished request of a test, run in a DAG phase
+// (0 outside DAG execution)
+func (p *ProgressBar) IncrementTest(name string, phase int, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tests.add(name, phase, failed)
+	p.increment()
+}
+
+func (p *ProgressBar) increment() {
 	p.current++
 
 	if time.Since(p.lastPrint) > 100*time.Millisecond || p.current == p.total {
@@ -39,7 +77,11 @@ func (p *ProgressBar) Increment() {
 }
 
 func (p *ProgressBar) render() {
-	percentage := float64(p.current) / float64(p.total)
+	// A run without requests has nothing left to do
+	percentage := 1.0
+	if p.total > 0 {
+		percentage = float64(p.current) / float64(p.total)
+	}
 	filled := int(percentage * float64(p.width))
 
 	// Handle case where current exceeds total (duration-based tests)
@@ -56,7 +98,7 @@ func (p *ProgressBar) render() {
 
 	elapsed := time.Since(p.startTime)
 	var eta time.Duration
-	if p.current > 0 {
+	if p.current > 0 && p.current < p.total {
 		eta = time.Duration(float64(elapsed)*(float64(p.total)/float64(p.current)) - float64(elapsed))
 	}
 
@@ -65,7 +107,7 @@ func (p *ProgressBar) render() {
 		rps = float64(p.current) / elapsed.Seconds()
 	}
 
-	fmt.Printf("\r[%s] %d/%d (%.1f%%) | %.1f req/s | Elapsed: %v | ETA: %v",
+	fmt.Fprintf(p.out, "\r[%s] %d/%d (%.1f%%) | %.1f req/s | Elapsed: %v | ETA: %v",
 		bar,
 		p.current,
 		p.total,
@@ -74,6 +116,8 @@ func (p *ProgressBar) render() {
 		elapsed.Round(time.Second),
 		eta.Round(time.Second),
 	)
+
+	p.tests.render(p.out)
 }
 
 func (p *ProgressBar) Finish() {
@@ -82,5 +126,5 @@ func (p *ProgressBar) Finish() {
 
 	p.current = p.total
 	p.render()
-	fmt.Println()
+	p.tests.finish(p.out)
 }
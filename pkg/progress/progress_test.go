@@ -1,6 +1,9 @@
 package progress
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -198,3 +201,71 @@ func BenchmarkProgressBar_IncrementConcurrent(b *testing.B) {
 		}
 	})
 }
+
+func TestProgressBar_ShowTests(t *testing.T) {
+	var out bytes.Buffer
+	pb := New(4)
+	pb.out = &out
+	pb.ShowTests()
+
+	pb.IncrementTest("Login", 1, false)
+	pb.IncrementTest("List orders with a very long descriptive name", 2, true)
+	pb.IncrementTest("Login", 1, false)
+	pb.IncrementTest("Login", 1, true)
+
+	// The last request renders the bar and a line per test, then moves the
+	// cursor back up to the bar
+	output := out.String()
+	assert.Contains(t, output, "4/4 (100.0%)")
+	assert.Regexp(t, `\n  Login\s+phase 1\s+3 req \|\s+[0-9.]+ req/s \| 1 errors`, output)
+	assert.Contains(t, output, "List orders with a very lon…")
+	assert.True(t, strings.HasSuffix(output, "\x1b[2A\r"))
+	assert.Equal(t, 3, pb.tests.byName["Login"].count)
+
+	out.Reset()
+	pb.Finish()
+	assert.True(t, strings.HasSuffix(out.String(), "\x1b[2B\n"))
+}
+
+func TestProgressBar_ShowTests_ManyTests(t *testing.T) {
+	var out bytes.Buffer
+	pb := New(maxTestLines + 3)
+	pb.out = &out
+	pb.ShowTests()
+
+	for i := 0; i < maxTestLines+3; i++ {
+		pb.IncrementTest(fmt.Sprintf("test %d", i), 0, false)
+	}
+	assert.Contains(t, out.String(), "... and 3 more tests")
+	assert.NotContains(t, out.String(), "phase")
+	assert.Equal(t, maxTestLines+1, pb.tests.lines)
+}
+
+func TestDurationProgress(t *testing.T) {
+	var out bytes.Buffer
+	dp := NewDuration(time.Hour)
+	dp.out = &out
+	dp.startTime = time.Now().Add(-15 * time.Minute)
+	dp.lastPrint = time.Time{}
+
+	// The bar follows the time elapsed, and requests have no total
+	dp.IncrementTest("Login", 0, false)
+	assert.Contains(t, out.String(), "[████████████░░░")
+	assert.Contains(t, out.String(), "] 1 requests |")
+	assert.Contains(t, out.String(), "Elapsed: 15m0s | Remaining: 45m0s")
+
+	out.Reset()
+	dp.Finish()
+	assert.Contains(t, out.String(), "["+strings.Repeat("█", 50)+"]")
+	assert.Contains(t, out.String(), "Remaining: 0s")
+	assert.True(t, strings.HasSuffix(out.String(), "\n"))
+
+	// A run longer than planned does not overflow the bar
+	dp = NewDuration(time.Minute)
+	dp.out = &out
+	dp.startTime = time.Now().Add(-2 * time.Minute)
+	dp.lastPrint = time.Time{}
+	out.Reset()
+	dp.IncrementTest("Login", 0, false)
+	assert.Contains(t, out.String(), "Remaining: 0s")
+}
@@ -0,0 +1,76 @@
+// Package pushgateway pushes the metrics of a run to a Prometheus
+// Pushgateway, so CI runs show up in existing dashboards and alerts without
+// a server for Prometheus to scrape.
+package pushgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultJob is the job the metrics are grouped under without -pushgateway-job
+const DefaultJob = "bombardino"
+
+// contentType is the Prometheus text format, which the OpenMetrics report
+// also is
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Pusher pushes metrics to a Pushgateway
+type Pusher struct {
+	client *http.Client
+}
+
+// New creates a pusher
+func New() *Pusher {
+	return &Pusher{client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Push replaces the metrics of job on the Pushgateway at gatewayURL, e.g.
+// http://pushgateway:9091, so each push holds the results of the latest run
+func (p *Pusher) Push(ctx context.Context, gatewayURL, job string, metrics []byte) error {
+	target, err := GroupURL(gatewayURL, job)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(metrics))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push failed: unexpected status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// GroupURL returns the URL the metrics of job are pushed to. A job containing
+// a slash is base64-encoded, as the Pushgateway requires.
+func GroupURL(gatewayURL, job string) (string, error) {
+	parsed, err := url.Parse(gatewayURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid pushgateway url %q (expected http:// or https://)", gatewayURL)
+	}
+	if job == "" {
+		return "", fmt.Errorf("pushgateway job must not be empty")
+	}
+
+	segment := "job/" + url.PathEscape(job)
+	if strings.Contains(job, "/") {
+		segment = "job@base64/" + base64.RawURLEncoding.EncodeToString([]byte(job))
+	}
+	return strings.TrimSuffix(gatewayURL, "/") + "/metrics/" + segment, nil
+}
@@ -0,0 +1,45 @@
+package pushgateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPush(t *testing.T) {
+	var method, path, gotType, body string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, gotType, body = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type"), string(data)
+		w.WriteHeader(status)
+		w.Write([]byte("bad metrics\n"))
+	}))
+	defer server.Close()
+
+	metrics := []byte("bombardino_run_passed 1\n# EOF\n")
+	require.NoError(t, New().Push(context.Background(), server.URL+"/", "checkout perf", metrics))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/checkout%20perf", path)
+	assert.Equal(t, contentType, gotType)
+	assert.Equal(t, string(metrics), body)
+
+	require.NoError(t, New().Push(context.Background(), server.URL, "team/checkout", metrics))
+	assert.Equal(t, "/metrics/job@base64/dGVhbS9jaGVja291dA", path)
+
+	status = http.StatusBadRequest
+	err := New().Push(context.Background(), server.URL, DefaultJob, metrics)
+	assert.EqualError(t, err, "push failed: unexpected status code: 400: bad metrics")
+}
+
+func TestGroupURL(t *testing.T) {
+	_, err := GroupURL("pushgateway:9091", "bombardino")
+	assert.ErrorContains(t, err, "invalid pushgateway url")
+	_, err = GroupURL("http://pushgateway:9091", "")
+	assert.EqualError(t, err, "pushgateway job must not be empty")
+}
@@ -0,0 +1,256 @@
+// Package record captures traffic through a reverse proxy for bombardino
+// record and turns it into a test configuration.
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exchange is a request seen by the recorder and the status it got
+type Exchange struct {
+	Method  string
+	Path    string // Path and query, relative to the target
+	Headers http.Header
+	Body    []byte
+	Status  int
+}
+
+// Recorder is a reverse proxy to a target that records every exchange
+type Recorder struct {
+	target    *url.URL
+	proxy     *httputil.ReverseProxy
+	mu        sync.Mutex
+	exchanges []Exchange
+	// OnExchange, when set, is called after each recorded exchange
+	OnExchange func(Exchange)
+}
+
+// New creates a recorder proxying to target, e.g. https://api.example.com
+func New(target string) (*Recorder, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("target must be an http:// or https:// URL")
+	}
+	r := &Recorder{target: u}
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(u)
+		},
+	}
+	return r, nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	status := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	r.proxy.ServeHTTP(status, req)
+
+	exchange := Exchange{
+		Method:  req.Method,
+		Path:    req.URL.RequestURI(),
+		Headers: req.Header.Clone(),
+		Body:    body,
+		Status:  status.status,
+	}
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.mu.Unlock()
+	if r.OnExchange != nil {
+		r.OnExchange(exchange)
+	}
+}
+
+// Exchanges returns the exchanges recorded so far, in order
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// statusWriter remembers the status code written by the proxy
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// droppedHeaders are set by the HTTP client, the browser, or the proxy and
+// have no place in a test case. Browser Sec-* headers are dropped as well.
+var droppedHeaders = map[string]bool{
+	"Host":                true,
+	"Content-Length":      true,
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Accept-Encoding":     true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"X-Forwarded-For":     true,
+	"X-Forwarded-Host":    true,
+	"X-Forwarded-Proto":   true,
+	"Forwarded":           true,
+	"Proxy-Authorization": true,
+	"User-Agent":          true,
+}
+
+// sensitiveHeaders are replaced by ${variable} references backed by env
+// secrets, so credentials never end up in the recorded file
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+	"X-Auth-Token":  true,
+	"X-Csrf-Token":  true,
+}
+
+func isSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)] ||
+		strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "api-key")
+}
+
+// variableName returns the variable replacing a sensitive header,
+// e.g. x_api_key for X-Api-Key
+func variableName(header string) string {
+	return strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+// The recorded config is written from these types so fields appear in a
+// readable order with durations as strings
+
+type recordedConfig struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Global      recordedGlobal `json:"global"`
+	Tests       []recordedTest `json:"tests"`
+}
+
+type recordedGlobal struct {
+	BaseURL    string                       `json:"base_url"`
+	Timeout    string                       `json:"timeout"`
+	Iterations int                          `json:"iterations"`
+	Secrets    map[string]map[string]string `json:"secrets,omitempty"`
+}
+
+type recordedTest struct {
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           interface{}       `json:"body,omitempty"`
+	ExpectedStatus []int             `json:"expected_status"`
+}
+
+// Config renders the recorded exchanges as a test configuration, one test
+// per exchange in the order they were seen. Sensitive headers become
+// ${variable} references resolved from environment variables.
+func (r *Recorder) Config(name string) ([]byte, error) {
+	exchanges := r.Exchanges()
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("no requests were recorded")
+	}
+
+	config := recordedConfig{
+		Name:        name,
+		Description: "Recorded by bombardino record. Check it with -t, add assertions, extract values with \"extract\", and chain tests with \"depends_on\"",
+		Global: recordedGlobal{
+			BaseURL:    strings.TrimSuffix(r.target.String(), "/"),
+			Timeout:    "30s",
+			Iterations: 1,
+		},
+	}
+
+	var envVars []string
+	names := make(map[string]int)
+	for _, exchange := range exchanges {
+		test := recordedTest{
+			Name:           exchange.Method + " " + exchange.Path,
+			Method:         exchange.Method,
+			Path:           exchange.Path,
+			ExpectedStatus: []int{exchange.Status},
+		}
+		names[test.Name]++
+		if count := names[test.Name]; count > 1 {
+			test.Name = fmt.Sprintf("%s (%d)", test.Name, count)
+		}
+
+		for header, values := range exchange.Headers {
+			if droppedHeaders[header] || strings.HasPrefix(header, "Sec-") {
+				continue
+			}
+			if header == "Content-Type" && strings.HasPrefix(values[0], "application/json") {
+				continue // Set automatically for JSON bodies
+			}
+			if test.Headers == nil {
+				test.Headers = make(map[string]string)
+			}
+			if isSensitive(header) {
+				variable := variableName(header)
+				test.Headers[header] = "${" + variable + "}"
+				if config.Global.Secrets == nil {
+					config.Global.Secrets = make(map[string]map[string]string)
+				}
+				if _, ok := config.Global.Secrets[variable]; !ok {
+					key := strings.ToUpper(variable)
+					config.Global.Secrets[variable] = map[string]string{"provider": "env", "key": key}
+					envVars = append(envVars, key)
+				}
+				continue
+			}
+			test.Headers[header] = strings.Join(values, ", ")
+		}
+
+		if len(exchange.Body) > 0 {
+			var body interface{}
+			if err := json.Unmarshal(exchange.Body, &body); err != nil {
+				body = string(exchange.Body)
+				test.Description = "The recorded body is not JSON and is sent as a JSON string; adjust it before running"
+			}
+			test.Body = body
+		}
+
+		config.Tests = append(config.Tests, test)
+	}
+
+	if len(envVars) > 0 {
+		sort.Strings(envVars)
+		config.Description += fmt.Sprintf(". Sensitive headers were replaced by variables: export %s before running", strings.Join(envVars, ", "))
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package record
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrearaponi/bombardino/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == "POST" {
+			assert.JSONEq(t, `{"name": "Mario"}`, string(body), "the proxied body is intact")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer target.Close()
+
+	recorder, err := New(target.URL + "/")
+	require.NoError(t, err)
+	var seen []string
+	recorder.OnExchange = func(e Exchange) { seen = append(seen, e.Method+" "+e.Path) }
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	send := func(method, path, body string, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(method, proxy.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := send("GET", "/api/users?page=2", "", map[string]string{"Authorization": "Bearer s3cret", "Accept": "application/json"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	send("POST", "/api/users", `{"name": "Mario"}`, map[string]string{"Content-Type": "application/json", "X-Session-Token": "abc"})
+	send("GET", "/api/users?page=2", "", nil)
+
+	assert.Equal(t, []string{"GET /api/users?page=2", "POST /api/users", "GET /api/users?page=2"}, seen)
+
+	data, err := recorder.Config("Recorded")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cret")
+	assert.NotContains(t, string(data), `"abc"`)
+
+	var recorded recordedConfig
+	require.NoError(t, json.Unmarshal(data, &recorded))
+	assert.Equal(t, target.URL, recorded.Global.BaseURL)
+	assert.Equal(t, map[string]map[string]string{
+		"authorization":   {"provider": "env", "key": "AUTHORIZATION"},
+		"x_session_token": {"provider": "env", "key": "X_SESSION_TOKEN"},
+	}, recorded.Global.Secrets)
+	assert.Contains(t, recorded.Description, "export AUTHORIZATION, X_SESSION_TOKEN")
+
+	require.Len(t, recorded.Tests, 3)
+	get := recorded.Tests[0]
+	assert.Equal(t, "GET /api/users?page=2", get.Name)
+	assert.Equal(t, "/api/users?page=2", get.Path)
+	assert.Equal(t, []int{200}, get.ExpectedStatus)
+	assert.Equal(t, "${authorization}", get.Headers["Authorization"])
+	assert.Equal(t, "application/json", get.Headers["Accept"])
+	assert.NotContains(t, get.Headers, "Accept-Encoding")
+
+	post := recorded.Tests[1]
+	assert.Equal(t, []int{201}, post.ExpectedStatus)
+	assert.Equal(t, map[string]interface{}{"name": "Mario"}, post.Body)
+	assert.Equal(t, map[string]string{"X-Session-Token": "${x_session_token}"}, post.Headers)
+
+	assert.Equal(t, "GET /api/users?page=2 (2)", recorded.Tests[2].Name)
+
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	_, err = config.LoadFromFile(path)
+	assert.NoError(t, err, "the recorded config is valid")
+}
+
+func TestRecorder_Errors(t *testing.T) {
+	_, err := New("api.example.com")
+	assert.Error(t, err)
+
+	recorder, err := New("https://api.example.com")
+	require.NoError(t, err)
+	_, err = recorder.Config("Empty")
+	assert.EqualError(t, err, "no requests were recorded")
+}
@@ -0,0 +1,195 @@
+package redact
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Mask is the replacement for redacted content
+const Mask = "***"
+
+// Redactor masks sensitive headers, values, and JSON fields
+type Redactor struct {
+	headers   map[string]bool
+	variables map[string]bool
+	jsonPaths [][]string
+	values    func() []string
+}
+
+// New creates a redactor for the given config. The values function returns
+// the current plaintext values that must never appear in output (resolved
+// secrets and redacted variables); it is called on every redaction so that
+// values extracted during the run are covered too.
+func New(config models.RedactConfig, values func() []string) *Redactor {
+	r := &Redactor{
+		headers: make(map[string]bool, len(config.Headers)),
+		values:  values,
+	}
+	for _, name := range config.Headers {
+		r.headers[strings.ToLower(name)] = true
+	}
+	for _, name := range config.Variables {
+		if r.variables == nil {
+			r.variables = make(map[string]bool, len(config.Variables))
+		}
+		r.variables[name] = true
+	}
+	for _, path := range config.JSONPaths {
+		if path != "" {
+			r.jsonPaths = append(r.jsonPaths, strings.Split(path, "."))
+		}
+	}
+	return r
+}
+
+// Enabled reports whether the redactor has anything to mask
+func (r *Redactor) Enabled() bool {
+	return r != nil && (len(r.headers) > 0 || len(r.jsonPaths) > 0 || r.values != nil)
+}
+
+// Header returns the value to display for a header
+func (r *Redactor) Header(name, value string) string {
+	if r == nil {
+		return value
+	}
+	if r.headers[strings.ToLower(name)] {
+		return Mask
+	}
+	return r.String(value)
+}
+
+// String replaces every sensitive value occurring in s
+func (r *Redactor) String(s string) string {
+	if r == nil || r.values == nil || s == "" {
+		return s
+	}
+	for _, value := range r.values() {
+		if value != "" {
+			s = strings.ReplaceAll(s, value, Mask)
+		}
+	}
+	return s
+}
+
+// Body masks configured JSON paths in a JSON body and sensitive values anywhere.
+// Non-JSON bodies only get value masking.
+func (r *Redactor) Body(body string) string {
+	if r == nil || body == "" {
+		return body
+	}
+	if len(r.jsonPaths) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(body), &doc); err == nil {
+			changed := false
+			for _, path := range r.jsonPaths {
+				if maskPath(doc, path) {
+					changed = true
+				}
+			}
+			if changed {
+				if out, err := json.Marshal(doc); err == nil {
+					body = string(out)
+				}
+			}
+		}
+	}
+	return r.String(body)
+}
+
+// Variable reports whether the values of a variable are masked
+func (r *Redactor) Variable(name string) bool {
+	return r != nil && r.variables[name]
+}
+
+// Path reports whether a dotted JSON path reads a masked field, or a value
+// holding one (e.g. user for user.ssn)
+func (r *Redactor) Path(path string) bool {
+	if r == nil || path == "" {
+		return false
+	}
+	segments := strings.Split(path, ".")
+	for _, masked := range r.jsonPaths {
+		if overlaps(segments, masked) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether one path is a prefix of the other, a "#" segment
+// matching any other
+func overlaps(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] && a[i] != "#" && b[i] != "#" {
+			return false
+		}
+	}
+	return true
+}
+
+// DebugLog returns a copy of the log entry with sensitive data masked
+func (r *Redactor) DebugLog(log models.DebugLog) models.DebugLog {
+	if !r.Enabled() {
+		return log
+	}
+	log.URL = r.String(log.URL)
+	log.Body = r.Body(log.Body)
+	log.Error = r.String(log.Error)
+	if log.Headers != nil {
+		headers := make(map[string]string, len(log.Headers))
+		for key, value := range log.Headers {
+			headers[key] = r.Header(key, value)
+		}
+		log.Headers = headers
+	}
+	return log
+}
+
+// maskPath replaces the value at path with Mask. A "#" segment matches every
+// array element, numeric segments index into arrays.
+func maskPath(node interface{}, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	segment, rest := path[0], path[1:]
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		child, ok := v[segment]
+		if !ok {
+			return false
+		}
+		if len(rest) == 0 {
+			v[segment] = Mask
+			return true
+		}
+		return maskPath(child, rest)
+
+	case []interface{}:
+		if segment == "#" {
+			changed := false
+			for i := range v {
+				if len(rest) == 0 {
+					v[i] = Mask
+					changed = true
+				} else if maskPath(v[i], rest) {
+					changed = true
+				}
+			}
+			return changed
+		}
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= len(v) {
+			return false
+		}
+		if len(rest) == 0 {
+			v[index] = Mask
+			return true
+		}
+		return maskPath(v[index], rest)
+	}
+	return false
+}
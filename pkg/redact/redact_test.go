@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Header(t *testing.T) {
+	r := New(models.RedactConfig{Headers: []string{"Authorization"}}, nil)
+
+	assert.Equal(t, Mask, r.Header("authorization", "Bearer abc"))
+	assert.Equal(t, "application/json", r.Header("Content-Type", "application/json"))
+}
+
+func TestRedactor_String_Values(t *testing.T) {
+	r := New(models.RedactConfig{}, func() []string { return []string{"s3cret", ""} })
+
+	assert.Equal(t, "token=*** done", r.String("token=s3cret done"))
+	assert.Equal(t, "", r.String(""))
+}
+
+func TestRedactor_Body_JSONPaths(t *testing.T) {
+	r := New(models.RedactConfig{JSONPaths: []string{"password", "user.ssn", "cards.#.number", "missing.path"}}, nil)
+
+	body := r.Body(`{"password":"pw","user":{"name":"bob","ssn":"123"},"cards":[{"number":"4111"},{"number":"5500"}]}`)
+
+	assert.NotContains(t, body, "pw\"")
+	assert.NotContains(t, body, "123")
+	assert.NotContains(t, body, "4111")
+	assert.NotContains(t, body, "5500")
+	assert.Contains(t, body, `"name":"bob"`)
+}
+
+func TestRedactor_Variable(t *testing.T) {
+	r := New(models.RedactConfig{Variables: []string{"token"}}, nil)
+
+	assert.True(t, r.Variable("token"))
+	assert.False(t, r.Variable("user"))
+	assert.False(t, (*Redactor)(nil).Variable("token"))
+}
+
+func TestRedactor_Path(t *testing.T) {
+	r := New(models.RedactConfig{JSONPaths: []string{"password", "user.ssn", "cards.#.number"}}, nil)
+
+	assert.True(t, r.Path("password"))
+	assert.True(t, r.Path("user.ssn"))
+	assert.True(t, r.Path("user"), "holds a masked field")
+	assert.True(t, r.Path("cards.0.number"))
+	assert.True(t, r.Path("cards.#.number"))
+	assert.False(t, r.Path("user.name"))
+	assert.False(t, r.Path("cards.0.brand"))
+	assert.False(t, (*Redactor)(nil).Path("password"))
+}
+
+func TestRedactor_Body_NonJSON(t *testing.T) {
+	r := New(models.RedactConfig{JSONPaths: []string{"password"}}, func() []string { return []string{"pw"} })
+
+	assert.Equal(t, "password=***", r.Body("password=pw"))
+}
+
+func TestRedactor_DebugLog(t *testing.T) {
+	r := New(models.RedactConfig{Headers: []string{"X-Api-Key"}}, func() []string { return []string{"t0k3n-value"} })
+
+	original := models.DebugLog{
+		URL:     "http://example.com/?key=t0k3n-value",
+		Headers: map[string]string{"X-Api-Key": "abc", "Accept": "*/*"},
+		Body:    `{"token":"t0k3n-value"}`,
+	}
+	masked := r.DebugLog(original)
+
+	assert.Equal(t, "http://example.com/?key=***", masked.URL)
+	assert.Equal(t, Mask, masked.Headers["X-Api-Key"])
+	assert.Equal(t, "*/*", masked.Headers["Accept"])
+	assert.Equal(t, `{"token":"***"}`, masked.Body)
+	// The original entry must not be modified
+	assert.Equal(t, "abc", original.Headers["X-Api-Key"])
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var r *Redactor
+
+	assert.False(t, r.Enabled())
+	assert.Equal(t, "value", r.String("value"))
+	assert.Equal(t, "value", r.Header("Authorization", "value"))
+	assert.Equal(t, "body", r.Body("body"))
+}
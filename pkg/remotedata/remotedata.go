@@ -0,0 +1,226 @@
+package remotedata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/awssig"
+)
+
+// Fetcher downloads remote data files (HTTP(S) URLs and S3 URIs) to local
+// files so the engine can read them like any other data file
+type Fetcher struct {
+	client   *http.Client
+	cacheDir string
+	ttl      time.Duration
+	getenv   func(string) string
+	now      func() time.Time
+
+	// s3Endpoint overrides the S3 endpoint, using path-style URLs
+	// (e.g. for MinIO or tests). Defaults to AWS_ENDPOINT_URL_S3.
+	s3Endpoint string
+
+	tempDir string
+	fetched map[string]string
+}
+
+// NewFetcher creates a fetcher. When cacheDir is set, downloaded files are
+// kept there and reused until they are older than ttl (0 = never expire);
+// otherwise they are stored in a temporary directory removed by Close.
+func NewFetcher(cacheDir string, ttl time.Duration) *Fetcher {
+	return &Fetcher{
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		cacheDir: cacheDir,
+		ttl:      ttl,
+		getenv:   os.Getenv,
+		now:      time.Now,
+		fetched:  make(map[string]string),
+	}
+}
+
+// IsRemote reports whether a data file refers to a remote source
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "https://") ||
+		strings.HasPrefix(source, "s3://")
+}
+
+// ResolveConfig fetches every remote data file in the config and replaces
+// its reference with the local path
+func (f *Fetcher) ResolveConfig(config *models.Config) error {
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		if !IsRemote(test.DataFile) {
+			continue
+		}
+		local, err := f.Fetch(test.DataFile)
+		if err != nil {
+			return fmt.Errorf("test %s: data file %s: %w", test.Name, test.DataFile, err)
+		}
+		test.DataFile = local
+	}
+	return nil
+}
+
+// Fetch downloads a remote data file and returns its local path. The same
+// source is only downloaded once per fetcher.
+func (f *Fetcher) Fetch(source string) (string, error) {
+	if local, ok := f.fetched[source]; ok {
+		return local, nil
+	}
+
+	dir, err := f.dir()
+	if err != nil {
+		return "", err
+	}
+	local := filepath.Join(dir, cacheFileName(source))
+
+	if f.cacheDir != "" && f.isFresh(local) {
+		f.fetched[source] = local
+		return local, nil
+	}
+
+	req, err := f.newRequest(source)
+	if err != nil {
+		return "", err
+	}
+	if err := f.download(req, local); err != nil {
+		return "", err
+	}
+
+	f.fetched[source] = local
+	return local, nil
+}
+
+// Close removes downloaded files that are not kept in a cache directory
+func (f *Fetcher) Close() error {
+	if f.tempDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(f.tempDir)
+	f.tempDir = ""
+	return err
+}
+
+// dir returns the directory downloaded files are stored in
+func (f *Fetcher) dir() (string, error) {
+	if f.cacheDir != "" {
+		if err := os.MkdirAll(f.cacheDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		return f.cacheDir, nil
+	}
+	if f.tempDir == "" {
+		dir, err := os.MkdirTemp("", "bombardino-data-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		f.tempDir = dir
+	}
+	return f.tempDir, nil
+}
+
+// isFresh reports whether a cached file exists and has not expired
+func (f *Fetcher) isFresh(local string) bool {
+	info, err := os.Stat(local)
+	if err != nil {
+		return false
+	}
+	return f.ttl <= 0 || f.now().Sub(info.ModTime()) < f.ttl
+}
+
+// newRequest builds the download request for an HTTP(S) URL or S3 URI
+func (f *Fetcher) newRequest(source string) (*http.Request, error) {
+	if !strings.HasPrefix(source, "s3://") {
+		req, err := http.NewRequest(http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		return req, nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid S3 URI (expected s3://bucket/key)")
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+
+	region, err := awssig.RegionFromEnv("", f.getenv)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := awssig.CredentialsFromEnv(f.getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := f.s3Endpoint
+	if endpoint == "" {
+		endpoint = f.getenv("AWS_ENDPOINT_URL_S3")
+	}
+	var target string
+	if endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	awssig.Sign(req, nil, creds, region, "s3", f.now().UTC())
+	return req, nil
+}
+
+// download writes the response body to local, replacing it atomically
+func (f *Fetcher) download(req *http.Request, local string) error {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("download failed: unexpected status code: %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(local), ".download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), local); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// cacheFileName derives a stable local name for a source, keeping the
+// original file name so the data format can still be detected
+func cacheFileName(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	name := source
+	if u, err := url.Parse(source); err == nil {
+		name = u.Path
+	}
+	return hex.EncodeToString(sum[:8]) + "-" + path.Base(name)
+}
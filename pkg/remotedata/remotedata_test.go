@@ -0,0 +1,155 @@
+package remotedata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRemote(t *testing.T) {
+	assert.True(t, IsRemote("https://example.com/users.csv"))
+	assert.True(t, IsRemote("http://example.com/users.csv"))
+	assert.True(t, IsRemote("s3://bucket/users.csv"))
+	assert.False(t, IsRemote("users.csv"))
+	assert.False(t, IsRemote("/data/users.csv"))
+}
+
+func TestFetcher_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/exports/users.csv.gz", r.URL.Path)
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	f := NewFetcher("", 0)
+	defer f.Close()
+
+	local, err := f.Fetch(server.URL + "/exports/users.csv.gz?token=abc")
+	require.NoError(t, err)
+
+	// The original file name is kept so the format can be detected
+	assert.True(t, strings.HasSuffix(local, "-users.csv.gz"))
+	content, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(content))
+
+	// Temporary downloads are removed on close
+	require.NoError(t, f.Close())
+	_, err = os.Stat(local)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFetcher_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := NewFetcher("", 0)
+	defer f.Close()
+
+	_, err := f.Fetch(server.URL + "/users.csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestFetcher_CacheReuseAndExpiry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	source := server.URL + "/users.json"
+
+	local, err := NewFetcher(cacheDir, time.Hour).Fetch(source)
+	require.NoError(t, err)
+	assert.Equal(t, cacheDir, filepath.Dir(local))
+
+	// A new fetcher (next run) reuses the cached file
+	_, err = NewFetcher(cacheDir, time.Hour).Fetch(source)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Once expired the file is downloaded again
+	expired := NewFetcher(cacheDir, time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = expired.Fetch(source)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestFetcher_S3(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/datasets/load/users.csv", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		w.Write([]byte("id\n1\n"))
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "SECRET",
+		"AWS_REGION":            "eu-west-1",
+	}
+	f := NewFetcher("", 0)
+	defer f.Close()
+	f.getenv = func(key string) string { return env[key] }
+	f.s3Endpoint = server.URL
+
+	local, err := f.Fetch("s3://datasets/load/users.csv")
+	require.NoError(t, err)
+	content, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Equal(t, "id\n1\n", string(content))
+}
+
+func TestFetcher_S3_InvalidURI(t *testing.T) {
+	f := NewFetcher("", 0)
+	defer f.Close()
+
+	_, err := f.Fetch("s3://bucket-only")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid S3 URI")
+}
+
+func TestFetcher_ResolveConfig(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("id\n1\n"))
+	}))
+	defer server.Close()
+
+	config := &models.Config{
+		Tests: []models.TestCase{
+			{Name: "a", DataFile: server.URL + "/shared.csv"},
+			{Name: "b", DataFile: server.URL + "/shared.csv"},
+			{Name: "c", DataFile: "local.csv"},
+		},
+	}
+
+	f := NewFetcher("", 0)
+	defer f.Close()
+	require.NoError(t, f.ResolveConfig(config))
+
+	assert.False(t, IsRemote(config.Tests[0].DataFile))
+	assert.Equal(t, config.Tests[0].DataFile, config.Tests[1].DataFile)
+	assert.Equal(t, "local.csv", config.Tests[2].DataFile)
+
+	// Shared sources are downloaded once
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
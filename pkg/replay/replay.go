@@ -0,0 +1,85 @@
+// Package replay reads the requests captured in a debug log, so that they can
+// be sent again at the times they were first sent.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Request is a captured request and when it was sent
+type Request struct {
+	Offset  time.Duration // Time from the first request of the capture
+	Test    string        // Test that sent it
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+	Status  int // Status of its captured response (0: none captured)
+}
+
+// Load reads the requests of the debug log at path
+func Load(path string) ([]Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads the requests of a debug log, ordered by the time they were
+// sent, each with the status of its response when the log has it
+func Read(r io.Reader) ([]Request, error) {
+	var entries []models.DebugLog
+	statuses := make(map[string]int)
+	decoder := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var entry models.DebugLog
+		if err := decoder.Decode(&entry); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse capture entry %d: %w", n, err)
+		}
+		switch entry.Type {
+		case "request":
+			if entry.Method != "" && entry.URL != "" {
+				entries = append(entries, entry)
+			}
+		case "response":
+			if entry.RequestID != "" {
+				statuses[entry.RequestID] = entry.StatusCode
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("capture has no requests")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	first := entries[0].Timestamp
+	requests := make([]Request, len(entries))
+	for i, entry := range entries {
+		requests[i] = Request{
+			Offset:  entry.Timestamp.Sub(first),
+			Test:    entry.TestName,
+			Method:  entry.Method,
+			URL:     entry.URL,
+			Headers: entry.Headers,
+			Body:    entry.Body,
+		}
+		if entry.RequestID != "" {
+			requests[i].Status = statuses[entry.RequestID]
+		}
+	}
+	return requests, nil
+}
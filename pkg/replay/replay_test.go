@@ -0,0 +1,51 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const capture = `{"timestamp":"2024-01-01T12:00:01.5Z","request_id":"b","type":"request","test_name":"create","method":"POST","url":"https://api.example.com/orders","headers":{"Content-Type":"application/json"},"body":"{\"sku\":\"A1\"}"}
+{"timestamp":"2024-01-01T12:00:00Z","request_id":"a","type":"request","test_name":"list","method":"GET","url":"https://api.example.com/orders?page=2"}
+{"timestamp":"2024-01-01T12:00:00.2Z","request_id":"a","type":"response","test_name":"list","status_code":200}
+{"timestamp":"2024-01-01T12:00:01.8Z","request_id":"b","type":"response","test_name":"create","status_code":201}
+{"timestamp":"2024-01-01T12:00:03Z","request_id":"c","type":"request","test_name":"list","method":"GET","url":"https://api.example.com/orders"}
+`
+
+func TestRead(t *testing.T) {
+	requests, err := Read(strings.NewReader(capture))
+	require.NoError(t, err)
+	assert.Equal(t, []Request{
+		{Test: "list", Method: "GET", URL: "https://api.example.com/orders?page=2", Status: 200},
+		{
+			Offset:  1500 * time.Millisecond,
+			Test:    "create",
+			Method:  "POST",
+			URL:     "https://api.example.com/orders",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"sku":"A1"}`,
+			Status:  201,
+		},
+		{Offset: 3 * time.Second, Test: "list", Method: "GET", URL: "https://api.example.com/orders"},
+	}, requests)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Load(filepath.Join(dir, "missing.jsonl"))
+	assert.ErrorContains(t, err, "failed to read capture")
+
+	path := filepath.Join(dir, "capture.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"request"}`+"\n{oops\n"), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "failed to parse capture entry 2")
+
+	_, err = Read(strings.NewReader(`{"type":"response","request_id":"a","status_code":200}`))
+	assert.EqualError(t, err, "capture has no requests")
+}
@@ -0,0 +1,192 @@
+// Package reportdiff compares two JSON reports for bombardino compare, so
+// before/after deploy runs can be checked for regressions without rerunning.
+package reportdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+)
+
+// TotalRow is the name of the row comparing the whole runs
+const TotalRow = "TOTAL"
+
+// Thresholds are the changes beyond which a metric counts as a regression
+type Thresholds struct {
+	ThroughputDrop    float64 // Percent of the previous requests/sec
+	P95Increase       float64 // Percent of the previous P95
+	ErrorRateIncrease float64 // Percentage points
+}
+
+// DefaultThresholds flag a 10% throughput drop, a 10% slower P95, or one
+// more percentage point of errors
+var DefaultThresholds = Thresholds{ThroughputDrop: 10, P95Increase: 10, ErrorRateIncrease: 1}
+
+// Metrics are the compared figures of a run or an endpoint
+type Metrics struct {
+	Requests   int
+	Throughput float64 // Requests per second
+	P95        time.Duration
+	ErrorRate  float64 // Percent of executed requests that failed
+}
+
+// Row compares a run or an endpoint; Before or After is nil when the
+// endpoint only appears in one of the reports
+type Row struct {
+	Name        string
+	Before      *Metrics
+	After       *Metrics
+	Regressions []string
+}
+
+// Regressed reports whether a metric of the row crossed its threshold
+func (r Row) Regressed() bool {
+	return len(r.Regressions) > 0
+}
+
+// Load reads a report written with -output json or -report-file
+func Load(path string) (*reporter.JSONReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report reporter.JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// Compare compares the totals and the endpoints of two reports. The total
+// row comes first, then endpoints by name.
+func Compare(before, after *reporter.JSONReport, thresholds Thresholds) []Row {
+	total := Row{Name: TotalRow, Before: summaryMetrics(before), After: summaryMetrics(after)}
+	total.Regressions = regressions(total.Before, total.After, thresholds)
+	rows := []Row{total}
+
+	names := make(map[string]bool)
+	for name := range before.Endpoints {
+		names[name] = true
+	}
+	for name := range after.Endpoints {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		row := Row{Name: name}
+		if ep, ok := before.Endpoints[name]; ok {
+			row.Before = endpointMetrics(ep, before)
+		}
+		if ep, ok := after.Endpoints[name]; ok {
+			row.After = endpointMetrics(ep, after)
+		}
+		row.Regressions = regressions(row.Before, row.After, thresholds)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func summaryMetrics(report *reporter.JSONReport) *Metrics {
+	p95, _ := time.ParseDuration(report.Summary.P95ResponseTime)
+	return &Metrics{
+		Requests:   report.Summary.TotalRequests,
+		Throughput: report.Summary.RequestsPerSec,
+		P95:        p95,
+		ErrorRate:  errorRate(report.Summary.SuccessfulReqs, report.Summary.FailedReqs),
+	}
+}
+
+// endpointMetrics spreads the endpoint's requests over the whole run, as
+// reports carry no per-endpoint duration
+func endpointMetrics(ep reporter.JSONEndpoint, report *reporter.JSONReport) *Metrics {
+	p95, _ := time.ParseDuration(ep.P95ResponseTime)
+	metrics := &Metrics{
+		Requests:  ep.TotalRequests,
+		P95:       p95,
+		ErrorRate: errorRate(ep.SuccessfulReqs, ep.FailedReqs),
+	}
+	if totalTime, err := time.ParseDuration(report.Summary.TotalTime); err == nil && totalTime > 0 {
+		metrics.Throughput = float64(ep.TotalRequests) / totalTime.Seconds()
+	}
+	return metrics
+}
+
+func errorRate(successful, failed int) float64 {
+	if successful+failed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(successful+failed) * 100
+}
+
+func regressions(before, after *Metrics, thresholds Thresholds) []string {
+	if before == nil || after == nil {
+		return nil
+	}
+	var found []string
+	if change, ok := percentChange(before.Throughput, after.Throughput); ok && -change > thresholds.ThroughputDrop {
+		found = append(found, fmt.Sprintf("throughput %+.1f%%", change))
+	}
+	if change, ok := percentChange(float64(before.P95), float64(after.P95)); ok && change > thresholds.P95Increase {
+		found = append(found, fmt.Sprintf("p95 %+.1f%%", change))
+	}
+	if change := after.ErrorRate - before.ErrorRate; change > thresholds.ErrorRateIncrease {
+		found = append(found, fmt.Sprintf("error rate %+.1fpp", change))
+	}
+	return found
+}
+
+// percentChange returns the change from before to after in percent, or
+// false when there is no baseline to compare with
+func percentChange(before, after float64) (float64, bool) {
+	if before == 0 {
+		return 0, false
+	}
+	return (after - before) / before * 100, true
+}
+
+// Print writes the comparison as a table
+func Print(w io.Writer, rows []Row) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tTHROUGHPUT (req/s)\tP95\tERROR RATE\tRESULT")
+	for _, row := range rows {
+		switch {
+		case row.Before == nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\tnew\n", row.Name,
+				fmt.Sprintf("%.2f", row.After.Throughput), row.After.P95, fmt.Sprintf("%.1f%%", row.After.ErrorRate))
+		case row.After == nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\tremoved\n", row.Name,
+				fmt.Sprintf("%.2f", row.Before.Throughput), row.Before.P95, fmt.Sprintf("%.1f%%", row.Before.ErrorRate))
+		default:
+			result := "ok"
+			if row.Regressed() {
+				result = "REGRESSION: " + strings.Join(row.Regressions, ", ")
+			}
+			fmt.Fprintf(tw, "%s\t%.2f → %.2f%s\t%v → %v%s\t%.1f%% → %.1f%% (%+.1fpp)\t%s\n", row.Name,
+				row.Before.Throughput, row.After.Throughput, formatChange(row.Before.Throughput, row.After.Throughput),
+				row.Before.P95, row.After.P95, formatChange(float64(row.Before.P95), float64(row.After.P95)),
+				row.Before.ErrorRate, row.After.ErrorRate, row.After.ErrorRate-row.Before.ErrorRate,
+				result)
+		}
+	}
+	return tw.Flush()
+}
+
+func formatChange(before, after float64) string {
+	change, ok := percentChange(before, after)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.1f%%)", change)
+}
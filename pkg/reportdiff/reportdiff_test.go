@@ -0,0 +1,100 @@
+package reportdiff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func report(totalTime string, rps float64, p95 string, endpoints map[string]reporter.JSONEndpoint) *reporter.JSONReport {
+	summary := reporter.JSONSummary{TotalTime: totalTime, RequestsPerSec: rps, P95ResponseTime: p95}
+	for _, ep := range endpoints {
+		summary.TotalRequests += ep.TotalRequests
+		summary.SuccessfulReqs += ep.SuccessfulReqs
+		summary.FailedReqs += ep.FailedReqs
+	}
+	return &reporter.JSONReport{Summary: summary, Endpoints: endpoints}
+}
+
+func TestCompare(t *testing.T) {
+	before := report("10s", 30, "100ms", map[string]reporter.JSONEndpoint{
+		"Login":  {TotalRequests: 100, SuccessfulReqs: 100, P95ResponseTime: "80ms"},
+		"Search": {TotalRequests: 200, SuccessfulReqs: 200, P95ResponseTime: "120ms"},
+		"Legacy": {TotalRequests: 10, SuccessfulReqs: 10, P95ResponseTime: "5ms"},
+	})
+	after := report("10s", 29, "105ms", map[string]reporter.JSONEndpoint{
+		"Login":  {TotalRequests: 100, SuccessfulReqs: 100, P95ResponseTime: "85ms"},
+		"Search": {TotalRequests: 150, SuccessfulReqs: 147, FailedReqs: 3, P95ResponseTime: "240ms"},
+		"Orders": {TotalRequests: 50, SuccessfulReqs: 50, P95ResponseTime: "40ms"},
+	})
+
+	rows := Compare(before, after, DefaultThresholds)
+	require.Len(t, rows, 5)
+	names := []string{}
+	for _, row := range rows {
+		names = append(names, row.Name)
+	}
+	assert.Equal(t, []string{TotalRow, "Legacy", "Login", "Orders", "Search"}, names)
+
+	total := rows[0]
+	assert.False(t, total.Regressed(), "the totals stay within the thresholds: %v", total.Regressions)
+	assert.InDelta(t, 0.0, total.Before.ErrorRate, 1e-9)
+	assert.InDelta(t, 1.0, total.After.ErrorRate, 1e-9)
+
+	assert.Nil(t, rows[1].After)
+	assert.False(t, rows[1].Regressed())
+	assert.False(t, rows[2].Regressed())
+	assert.Nil(t, rows[3].Before)
+
+	search := rows[4]
+	assert.Equal(t, 20.0, search.Before.Throughput)
+	assert.Equal(t, 15.0, search.After.Throughput)
+	assert.Equal(t, 240*time.Millisecond, search.After.P95)
+	assert.Equal(t, []string{"throughput -25.0%", "p95 +100.0%", "error rate +2.0pp"}, search.Regressions)
+
+	lenient := Compare(before, after, Thresholds{ThroughputDrop: 50, P95Increase: 150, ErrorRateIncrease: 5})
+	for _, row := range lenient {
+		assert.False(t, row.Regressed(), row.Name)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	before := report("10s", 30, "100ms", map[string]reporter.JSONEndpoint{
+		"Search": {TotalRequests: 200, SuccessfulReqs: 200, P95ResponseTime: "120ms"},
+		"Legacy": {TotalRequests: 10, SuccessfulReqs: 10, P95ResponseTime: "5ms"},
+	})
+	after := report("10s", 30, "100ms", map[string]reporter.JSONEndpoint{
+		"Search": {TotalRequests: 200, SuccessfulReqs: 200, P95ResponseTime: "240ms"},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, Compare(before, after, DefaultThresholds)))
+	output := buf.String()
+	assert.Contains(t, output, "ENDPOINT")
+	assert.Contains(t, output, "120ms → 240ms (+100.0%)")
+	assert.Contains(t, output, "REGRESSION: p95 +100.0%")
+	assert.Contains(t, output, "removed")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"summary": {"total_requests": 5, "p95_response_time": "12ms"}, "endpoints": {}}`), 0644))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, loaded.Summary.TotalRequests)
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "failed to parse report")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read report")
+}
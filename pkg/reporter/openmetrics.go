@@ -0,0 +1,162 @@
+package reporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// invalidLabelChars matches the characters not allowed in label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// metricsWriter writes metric families in the OpenMetrics text format
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+// family starts a metric family. Every family is a gauge: a report holds the
+// final values of one run, which is what a Pushgateway expects of a batch job.
+func (m *metricsWriter) family(name, help string) {
+	fmt.Fprintf(m.w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(m.w, "# HELP %s %s\n", name, help)
+}
+
+// sample writes a sample of the current family; labels are name, value pairs
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.w.WriteString(name)
+	if len(labels) > 0 {
+		m.w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			fmt.Fprintf(m.w, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteByte(' ')
+	m.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.w.WriteByte('\n')
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// labelName turns a -label key into a valid label name, e.g. "build-id"
+// into "build_id". Names starting with an underscore are reserved, so
+// those and names starting with a digit get a label_ prefix.
+func labelName(key string) string {
+	name := invalidLabelChars.ReplaceAllString(key, "_")
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = "label_" + name
+	}
+	return name
+}
+
+// WriteOpenMetricsReport writes the summary as OpenMetrics text, which the
+// Prometheus text format parsers (and so a Pushgateway) accept as well.
+// Durations are in seconds.
+func (r *Reporter) WriteOpenMetricsReport(w io.Writer, summary *models.Summary) error {
+	m := &metricsWriter{w: bufio.NewWriter(w)}
+
+	info := []string{"run_id", summary.RunID}
+	if meta := summary.Metadata; meta != nil {
+		info = append(info, "config", meta.ConfigName, "version", meta.Version)
+		keys := make([]string, 0, len(meta.Labels))
+		for key := range meta.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			info = append(info, labelName(key), meta.Labels[key])
+		}
+	}
+	m.family("bombardino_run_info", "Run the metrics belong to, with its -label labels")
+	m.sample("bombardino_run_info", 1, info...)
+
+	passed := 0.0
+	if summary.Passed() {
+		passed = 1
+	}
+	m.family("bombardino_run_passed", "Whether the run passed (1) or failed (0)")
+	m.sample("bombardino_run_passed", passed)
+
+	if meta := summary.Metadata; meta != nil && !meta.FinishedAt.IsZero() {
+		m.family("bombardino_run_finished_timestamp_seconds", "Time the run finished, in seconds since the epoch")
+		m.sample("bombardino_run_finished_timestamp_seconds", float64(meta.FinishedAt.UnixNano())/float64(time.Second))
+	}
+
+	m.family("bombardino_run_duration_seconds", "Duration of the run")
+	m.sample("bombardino_run_duration_seconds", summary.TotalTime.Seconds())
+
+	m.family("bombardino_requests", "Requests of the run by result")
+	m.sample("bombardino_requests", float64(summary.SuccessfulReqs), "result", "success")
+	m.sample("bombardino_requests", float64(summary.FailedReqs), "result", "failed")
+	m.sample("bombardino_requests", float64(summary.SkippedReqs), "result", "skipped")
+	m.sample("bombardino_requests", float64(summary.ThrottledReqs), "result", "throttled")
+
+	m.family("bombardino_requests_per_second", "Throughput of the run")
+	m.sample("bombardino_requests_per_second", summary.RequestsPerSec)
+
+	m.family("bombardino_response_time_seconds", "Response time percentiles of the run")
+	m.sample("bombardino_response_time_seconds", summary.P50ResponseTime.Seconds(), "percentile", "50")
+	m.sample("bombardino_response_time_seconds", summary.P95ResponseTime.Seconds(), "percentile", "95")
+	m.sample("bombardino_response_time_seconds", summary.P99ResponseTime.Seconds(), "percentile", "99")
+
+	m.family("bombardino_response_time_avg_seconds", "Average response time of the run")
+	m.sample("bombardino_response_time_avg_seconds", summary.AvgResponseTime.Seconds())
+
+	m.family("bombardino_response_time_max_seconds", "Slowest response time of the run")
+	m.sample("bombardino_response_time_max_seconds", summary.MaxResponseTime.Seconds())
+
+	if len(summary.StatusCodes) > 0 {
+		codes := make([]int, 0, len(summary.StatusCodes))
+		for code := range summary.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		m.family("bombardino_responses", "Responses by status code (0: no response)")
+		for _, code := range codes {
+			m.sample("bombardino_responses", float64(summary.StatusCodes[code]), "code", strconv.Itoa(code))
+		}
+	}
+
+	if summary.TotalAssertions > 0 {
+		m.family("bombardino_assertions", "Assertions evaluated by result")
+		m.sample("bombardino_assertions", float64(summary.AssertionsPassed), "result", "passed")
+		m.sample("bombardino_assertions", float64(summary.AssertionsFailed), "result", "failed")
+	}
+
+	if len(summary.EndpointResults) > 0 {
+		names := make([]string, 0, len(summary.EndpointResults))
+		for name := range summary.EndpointResults {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		m.family("bombardino_test_requests", "Requests of each test by result")
+		for _, name := range names {
+			endpoint := summary.EndpointResults[name]
+			m.sample("bombardino_test_requests", float64(endpoint.SuccessfulReqs), "test", name, "result", "success")
+			m.sample("bombardino_test_requests", float64(endpoint.FailedReqs), "test", name, "result", "failed")
+		}
+		m.family("bombardino_test_response_time_seconds", "Response time percentiles of each test")
+		for _, name := range names {
+			endpoint := summary.EndpointResults[name]
+			m.sample("bombardino_test_response_time_seconds", endpoint.P50ResponseTime.Seconds(), "test", name, "percentile", "50")
+			m.sample("bombardino_test_response_time_seconds", endpoint.P95ResponseTime.Seconds(), "test", name, "percentile", "95")
+			m.sample("bombardino_test_response_time_seconds", endpoint.P99ResponseTime.Seconds(), "test", name, "percentile", "99")
+		}
+	}
+
+	m.w.WriteString("# EOF\n")
+	return m.w.Flush()
+}
@@ -0,0 +1,95 @@
+package reporter
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter_WriteOpenMetricsReport(t *testing.T) {
+	summary := &models.Summary{
+		RunID:            "run-1",
+		TotalRequests:    10,
+		SuccessfulReqs:   9,
+		FailedReqs:       1,
+		TotalTime:        2 * time.Second,
+		RequestsPerSec:   5,
+		AvgResponseTime:  40 * time.Millisecond,
+		MaxResponseTime:  250 * time.Millisecond,
+		P50ResponseTime:  30 * time.Millisecond,
+		P95ResponseTime:  120 * time.Millisecond,
+		P99ResponseTime:  250 * time.Millisecond,
+		StatusCodes:      map[int]int{200: 9, 500: 1},
+		TotalAssertions:  10,
+		AssertionsPassed: 9,
+		AssertionsFailed: 1,
+		EndpointResults: map[string]*models.EndpointSummary{
+			`Get "user"`: {SuccessfulReqs: 9, FailedReqs: 1, P50ResponseTime: 30 * time.Millisecond, P95ResponseTime: 120 * time.Millisecond, P99ResponseTime: 250 * time.Millisecond},
+		},
+		Metadata: &models.RunMetadata{
+			ConfigName: "API Tests",
+			Version:    "1.2.0",
+			FinishedAt: time.Unix(1700000000, 500000000),
+			Labels:     map[string]string{"team": "checkout", "build-id": "42"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, New(false).WriteOpenMetricsReport(&buf, summary))
+	output := buf.String()
+
+	for _, line := range []string{
+		`bombardino_run_info{run_id="run-1",config="API Tests",version="1.2.0",build_id="42",team="checkout"} 1`,
+		`bombardino_run_passed 0`,
+		`bombardino_run_finished_timestamp_seconds 1.7000000005e+09`,
+		`bombardino_run_duration_seconds 2`,
+		`bombardino_requests{result="success"} 9`,
+		`bombardino_requests{result="failed"} 1`,
+		`bombardino_requests_per_second 5`,
+		`bombardino_response_time_seconds{percentile="95"} 0.12`,
+		`bombardino_response_time_avg_seconds 0.04`,
+		`bombardino_responses{code="500"} 1`,
+		`bombardino_assertions{result="failed"} 1`,
+		`bombardino_test_requests{test="Get \"user\"",result="success"} 9`,
+		`bombardino_test_response_time_seconds{test="Get \"user\"",percentile="99"} 0.25`,
+	} {
+		assert.Contains(t, output, line+"\n")
+	}
+	assert.Contains(t, output, "# TYPE bombardino_requests gauge\n# HELP bombardino_requests Requests of the run by result\n")
+	assert.True(t, strings.HasSuffix(output, "\n# EOF\n"))
+
+	// Every family is declared once, before its samples
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name = strings.Fields(name)[0]
+			assert.False(t, seen[name], "family %s declared twice", name)
+			seen[name] = true
+		}
+	}
+
+	buf.Reset()
+	require.NoError(t, New(false).WriteOpenMetricsReport(&buf, &models.Summary{}))
+	assert.Contains(t, buf.String(), `bombardino_run_info{run_id=""} 1`)
+	assert.NotContains(t, buf.String(), "bombardino_responses")
+	assert.NotContains(t, buf.String(), "bombardino_run_finished_timestamp_seconds")
+
+	path := t.TempDir() + "/perf.prom"
+	require.NoError(t, New(false).WriteReportFile(ReportFile{Format: "openmetrics", Path: path}, summary))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, output, string(data))
+}
+
+func TestLabelName(t *testing.T) {
+	assert.Equal(t, "team", labelName("team"))
+	assert.Equal(t, "build_id", labelName("build-id"))
+	assert.Equal(t, "label_1st", labelName("1st"))
+	assert.Equal(t, "label__internal", labelName("_internal"))
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/andrearaponi/bombardino/internal/models"
 )
@@ -17,6 +20,8 @@ var htmlTemplate string
 
 type Reporter struct {
 	verbose bool
+	quiet   bool // Text report shows only the summary
+	plain   bool // Text report uses plain text instead of emoji
 }
 
 func New(verbose bool) *Reporter {
@@ -25,79 +30,468 @@ func New(verbose bool) *Reporter {
 	}
 }
 
+// SetQuiet limits the text report to the summary section
+func (r *Reporter) SetQuiet(quiet bool) {
+	r.quiet = quiet
+}
+
+// SetPlain replaces the emoji of the text report with plain text, for logs
+// that do not render them (-no-color, NO_COLOR)
+func (r *Reporter) SetPlain(plain bool) {
+	r.plain = plain
+}
+
+// icon returns emoji, or its plain text replacement in plain mode
+func (r *Reporter) icon(emoji, plain string) string {
+	if r.plain {
+		return plain
+	}
+	return emoji
+}
+
 func (r *Reporter) GenerateReport(summary *models.Summary) {
+	if r.quiet {
+		r.printSummary(summary)
+		return
+	}
 	r.printHeader()
+	if summary.Metadata != nil || summary.RunID != "" || summary.Resumed != nil || summary.Replay != nil {
+		r.printRun(summary)
+	}
 	r.printSummary(summary)
 	r.printStatusCodes(summary)
+	if len(summary.ScenarioResults) > 0 {
+		r.printScenarioResults(summary)
+	}
+	if len(summary.TargetResults) > 0 {
+		r.printTargetResults(summary)
+	}
+	if len(summary.PhaseResults) > 0 {
+		r.printPhaseResults(summary)
+	}
+	if summary.SteadyState != nil {
+		r.printSteadyState(summary)
+	}
+	if len(summary.SLOAlerts) > 0 {
+		r.printSLOAlerts(summary)
+	}
+	if summary.Autoscale != nil {
+		r.printAutoscale(summary)
+	}
+	if summary.Capacity != nil {
+		r.printCapacity(summary)
+	}
 	if len(summary.EndpointResults) > 0 {
 		r.printEndpointResults(summary)
 	}
+	if len(summary.ErrorCategories) > 0 {
+		r.printErrorCategories(summary)
+	}
 	if len(summary.Errors) > 0 {
 		r.printErrors(summary)
 	}
+	if r.verbose && len(summary.SlowestRequests) > 0 {
+		r.printSlowestRequests(summary)
+	}
+	if summary.Generator != nil {
+		r.printGenerator(summary)
+	}
 	r.printFooter()
 }
 
 type JSONReport struct {
-	Summary   JSONSummary             `json:"summary"`
-	Endpoints map[string]JSONEndpoint `json:"endpoints"`
-	DebugLogs []models.DebugLog       `json:"debug_logs,omitempty"`
-	Success   bool                    `json:"success"`
+	RunID       string                  `json:"run_id,omitempty"`
+	Seed        int64                   `json:"seed,omitempty"`
+	Metadata    *JSONMetadata           `json:"metadata,omitempty"`
+	Summary     JSONSummary             `json:"summary"`
+	Endpoints   map[string]JSONEndpoint `json:"endpoints"`
+	Scenarios   map[string]JSONScenario `json:"scenarios,omitempty"`
+	Targets     []JSONTarget            `json:"targets,omitempty"`
+	Phases      []JSONPhase             `json:"phases,omitempty"`
+	SteadyState *JSONSteadyState        `json:"steady_state,omitempty"`
+	SLOAlerts   []JSONSLOAlert          `json:"slo_alerts,omitempty"`
+	Autoscale   *JSONAutoscale          `json:"autoscale,omitempty"`
+	Capacity    *JSONCapacity           `json:"capacity,omitempty"`
+	Generator   *JSONGenerator          `json:"generator,omitempty"`
+	Slowest     []JSONSlowRequest       `json:"slowest_requests,omitempty"`
+	DebugLogs   []models.DebugLog       `json:"debug_logs,omitempty"`
+	Success     bool                    `json:"success"`
+}
+
+// JSONMetadata identifies what produced a run
+type JSONMetadata struct {
+	ConfigName string            `json:"config_name,omitempty"`
+	ConfigFile string            `json:"config_file,omitempty"`
+	ConfigHash string            `json:"config_sha256,omitempty"`
+	Version    string            `json:"version,omitempty"`
+	Commit     string            `json:"commit,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	StartedAt  string            `json:"started_at"`
+	FinishedAt string            `json:"finished_at"`
+	Args       []string          `json:"args,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// JSONSlowRequest is one of the slowest requests of a run (verbose mode)
+type JSONSlowRequest struct {
+	TestName     string      `json:"test"`
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	StatusCode   int         `json:"status_code,omitempty"`
+	ResponseTime string      `json:"response_time"`
+	Timestamp    string      `json:"timestamp"`
+	Timing       *JSONTiming `json:"timing,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	RequestID    string      `json:"request_id,omitempty"`
+}
+
+// JSONTiming breaks down the response time of a request
+type JSONTiming struct {
+	DNS      string `json:"dns"`
+	Connect  string `json:"connect"`
+	TLS      string `json:"tls"`
+	Wait     string `json:"wait"`
+	Transfer string `json:"transfer"`
 }
 
 type JSONSummary struct {
-	TotalRequests     int            `json:"total_requests"`
-	SuccessfulReqs    int            `json:"successful_requests"`
-	FailedReqs        int            `json:"failed_requests"`
-	SuccessRate       float64        `json:"success_rate_percent"`
-	TotalTime         string         `json:"total_time"`
-	AvgResponseTime   string         `json:"avg_response_time"`
-	MinResponseTime   string         `json:"min_response_time"`
-	MaxResponseTime   string         `json:"max_response_time"`
-	P50ResponseTime   string         `json:"p50_response_time"`
-	P95ResponseTime   string         `json:"p95_response_time"`
-	P99ResponseTime   string         `json:"p99_response_time"`
-	RequestsPerSec    float64        `json:"requests_per_sec"`
-	StatusCodes       map[string]int `json:"status_codes"`
-	Errors            map[string]int `json:"errors"`
-	TotalAssertions   int            `json:"total_assertions,omitempty"`
-	AssertionsPassed  int            `json:"assertions_passed,omitempty"`
-	AssertionsFailed  int            `json:"assertions_failed,omitempty"`
-	TotalComparisons  int            `json:"total_comparisons,omitempty"`
-	ComparisonsPassed int            `json:"comparisons_passed,omitempty"`
-	ComparisonsFailed int            `json:"comparisons_failed,omitempty"`
+	TotalRequests      int               `json:"total_requests"`
+	SuccessfulReqs     int               `json:"successful_requests"`
+	FailedReqs         int               `json:"failed_requests"`
+	SuccessRate        float64           `json:"success_rate_percent"`
+	TotalTime          string            `json:"total_time"`
+	AvgResponseTime    string            `json:"avg_response_time"`
+	MinResponseTime    string            `json:"min_response_time"`
+	MaxResponseTime    string            `json:"max_response_time"`
+	P50ResponseTime    string            `json:"p50_response_time"`
+	P95ResponseTime    string            `json:"p95_response_time"`
+	P99ResponseTime    string            `json:"p99_response_time"`
+	RequestsPerSec     float64           `json:"requests_per_sec"`
+	StatusCodes        map[string]int    `json:"status_codes"`
+	Errors             map[string]int    `json:"errors"`
+	ErrorCategories    map[string]int    `json:"error_categories,omitempty"`
+	TotalAssertions    int               `json:"total_assertions,omitempty"`
+	AssertionsPassed   int               `json:"assertions_passed,omitempty"`
+	AssertionsFailed   int               `json:"assertions_failed,omitempty"`
+	TotalComparisons   int               `json:"total_comparisons,omitempty"`
+	ComparisonsPassed  int               `json:"comparisons_passed,omitempty"`
+	ComparisonsFailed  int               `json:"comparisons_failed,omitempty"`
+	ThrottledReqs      int               `json:"throttled_requests,omitempty"`
+	ThrottleRate       float64           `json:"throttle_rate_percent,omitempty"`
+	ThrottleRetries    int               `json:"throttle_retries,omitempty"`
+	MaxDurationReached bool              `json:"max_duration_reached,omitempty"`
+	Interrupted        bool              `json:"interrupted,omitempty"`
+	AbortedBy          string            `json:"aborted_by_slo,omitempty"`
+	ReportWindow       *JSONReportWindow `json:"report_window,omitempty"`
+	Transfer           *JSONTransfer     `json:"transfer,omitempty"`
+	ScheduleLag        *JSONScheduleLag  `json:"schedule_lag,omitempty"`
+	Resumed            *JSONResumed      `json:"resumed,omitempty"`
+	Replay             *JSONReplay       `json:"replay,omitempty"`
+}
+
+// JSONResumed is the progress a run had made when it was resumed from a
+// checkpoint; the rest of the report covers only what ran after
+type JSONResumed struct {
+	Elapsed    string `json:"elapsed"`
+	Iterations int    `json:"iterations"`
+}
+
+func jsonResumed(resumed *models.ResumeSummary) *JSONResumed {
+	if resumed == nil {
+		return nil
+	}
+	return &JSONResumed{Elapsed: resumed.Elapsed.Round(time.Millisecond).String(), Iterations: resumed.Iterations}
+}
+
+// JSONReplay is the capture a replay sent again and at what pace
+type JSONReplay struct {
+	File     string  `json:"file"`
+	Speed    float64 `json:"speed"`
+	Requests int     `json:"requests"`
+	Span     string  `json:"span"`
+}
+
+func jsonReplay(replay *models.ReplaySummary) *JSONReplay {
+	if replay == nil {
+		return nil
+	}
+	return &JSONReplay{File: replay.File, Speed: replay.Speed, Requests: replay.Requests, Span: replay.Span.Round(time.Millisecond).String()}
+}
+
+// JSONReportWindow is the part of the run the statistics cover, with the
+// requests left out of them
+type JSONReportWindow struct {
+	Start            string `json:"start"`
+	End              string `json:"end,omitempty"`
+	ExcludedRequests int    `json:"excluded_requests"`
+}
+
+// JSONScheduleLag reports the requests sent behind their target rate, and
+// the response times corrected for coordinated omission
+type JSONScheduleLag struct {
+	LateRequests int         `json:"late_requests"`
+	AvgLag       string      `json:"avg_lag"`
+	MaxLag       string      `json:"max_lag"`
+	Corrected    JSONLatency `json:"corrected"`
 }
 
 type JSONEndpoint struct {
-	Name              string         `json:"name"`
-	URL               string         `json:"url"`
-	TotalRequests     int            `json:"total_requests"`
-	SuccessfulReqs    int            `json:"successful_requests"`
-	FailedReqs        int            `json:"failed_requests"`
-	SuccessRate       float64        `json:"success_rate_percent"`
-	AvgResponseTime   string         `json:"avg_response_time"`
-	P50ResponseTime   string         `json:"p50_response_time"`
-	P95ResponseTime   string         `json:"p95_response_time"`
-	P99ResponseTime   string         `json:"p99_response_time"`
-	StatusCodes       map[string]int `json:"status_codes"`
-	Errors            []string       `json:"errors"`
-	Success           bool           `json:"success"`
-	TotalAssertions   int            `json:"total_assertions,omitempty"`
-	AssertionsPassed  int            `json:"assertions_passed,omitempty"`
-	AssertionsFailed  int            `json:"assertions_failed,omitempty"`
-	TotalComparisons  int            `json:"total_comparisons,omitempty"`
-	ComparisonsPassed int            `json:"comparisons_passed,omitempty"`
-	ComparisonsFailed int            `json:"comparisons_failed,omitempty"`
+	Name              string                 `json:"name"`
+	URL               string                 `json:"url"`
+	TotalRequests     int                    `json:"total_requests"`
+	SuccessfulReqs    int                    `json:"successful_requests"`
+	FailedReqs        int                    `json:"failed_requests"`
+	SuccessRate       float64                `json:"success_rate_percent"`
+	AvgResponseTime   string                 `json:"avg_response_time"`
+	P50ResponseTime   string                 `json:"p50_response_time"`
+	P95ResponseTime   string                 `json:"p95_response_time"`
+	P99ResponseTime   string                 `json:"p99_response_time"`
+	StatusCodes       map[string]int         `json:"status_codes"`
+	Errors            []string               `json:"errors,omitempty"`
+	ErrorCategories   map[string]int         `json:"error_categories,omitempty"`
+	Success           bool                   `json:"success"`
+	AllowedFailure    float64                `json:"allowed_failure_rate_percent,omitempty"`
+	BudgetConsumed    float64                `json:"failure_budget_consumed_percent,omitempty"`
+	TotalAssertions   int                    `json:"total_assertions,omitempty"`
+	AssertionsPassed  int                    `json:"assertions_passed,omitempty"`
+	AssertionsFailed  int                    `json:"assertions_failed,omitempty"`
+	TotalComparisons  int                    `json:"total_comparisons,omitempty"`
+	ComparisonsPassed int                    `json:"comparisons_passed,omitempty"`
+	ComparisonsFailed int                    `json:"comparisons_failed,omitempty"`
+	CachedReqs        int                    `json:"cached_requests,omitempty"`
+	ThrottledReqs     int                    `json:"throttled_requests,omitempty"`
+	LatencyHistogram  []JSONBucket           `json:"latency_histogram,omitempty"`
+	Transfer          *JSONTransfer          `json:"transfer,omitempty"`
+	StatusClasses     map[string]JSONLatency `json:"status_class_latency,omitempty"`
+	SSE               *JSONSSE               `json:"sse,omitempty"`
+	Rows              *JSONRows              `json:"rows,omitempty"`
+	FailureSamples    []JSONFailureSample    `json:"failure_samples,omitempty"`
+	Assertions        []JSONAssertion        `json:"assertions,omitempty"`
+}
+
+// JSONAssertion reports how often one assertion of an endpoint passed
+type JSONAssertion struct {
+	Type     string      `json:"type"`
+	Target   string      `json:"target,omitempty"`
+	Operator string      `json:"operator,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Passed   int         `json:"passed"`
+	Failed   int         `json:"failed"`
+	Messages []string    `json:"failure_messages,omitempty"`
+	Context  string      `json:"failure_context,omitempty"`
+}
+
+// JSONFailureSample is one of the first failed requests of an endpoint
+type JSONFailureSample struct {
+	StatusCode      int      `json:"status_code,omitempty"`
+	ErrorCategory   string   `json:"error_category"`
+	Error           string   `json:"error,omitempty"`
+	AssertionErrors []string `json:"assertion_errors,omitempty"`
+	Body            string   `json:"body,omitempty"`
+	RequestID       string   `json:"request_id,omitempty"`
+	Timestamp       string   `json:"timestamp"`
+}
+
+// JSONLatency reports the response times of a group of requests
+type JSONLatency struct {
+	Requests        int    `json:"requests"`
+	AvgResponseTime string `json:"avg_response_time"`
+	P50ResponseTime string `json:"p50_response_time"`
+	P95ResponseTime string `json:"p95_response_time"`
+	P99ResponseTime string `json:"p99_response_time"`
+}
+
+// JSONTransfer reports the request and response body bytes of a run or an
+// endpoint. Throughput is averaged over the whole run.
+type JSONTransfer struct {
+	BytesSent        int64            `json:"bytes_sent"`
+	BytesReceived    int64            `json:"bytes_received"`
+	AvgRequestSize   float64          `json:"avg_request_size_bytes"`
+	AvgResponseSize  float64          `json:"avg_response_size_bytes"`
+	SentMBPerSec     float64          `json:"sent_mb_per_sec"`
+	ReceivedMBPerSec float64          `json:"received_mb_per_sec"`
+	Compression      *JSONCompression `json:"compression,omitempty"`
+}
+
+// JSONCompression reports the responses decoded from a content encoding:
+// their size as received and decoded, and the time spent decoding
+type JSONCompression struct {
+	Responses       int     `json:"responses"`
+	BytesCompressed int64   `json:"bytes_compressed"`
+	BytesDecoded    int64   `json:"bytes_decoded"`
+	SavedPercent    float64 `json:"saved_percent"`
+	AvgDecodeTime   string  `json:"avg_decode_time"`
+}
+
+// JSONSSE reports the Server-Sent Events streams of an endpoint
+type JSONSSE struct {
+	Streams       int    `json:"streams"`
+	Events        int    `json:"events"`
+	AvgFirstEvent string `json:"avg_time_to_first_event"`
+	AvgInterval   string `json:"avg_event_interval"`
+	P95Interval   string `json:"p95_event_interval"`
+	MaxInterval   string `json:"max_event_interval"`
+}
+
+// JSONRows reports the rows returned, or affected, by the queries of an
+// endpoint
+type JSONRows struct {
+	Queries int     `json:"queries"`
+	Total   int64   `json:"total"`
+	Avg     float64 `json:"avg"`
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+}
+
+// JSONBucket is a latency histogram bucket, e.g. "50ms-100ms"
+type JSONBucket struct {
+	Range   string  `json:"range"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+type JSONScenario struct {
+	Name            string  `json:"name"`
+	TotalRequests   int     `json:"total_requests"`
+	SuccessfulReqs  int     `json:"successful_requests"`
+	FailedReqs      int     `json:"failed_requests"`
+	SuccessRate     float64 `json:"success_rate_percent"`
+	AvgResponseTime string  `json:"avg_response_time"`
+	TotalTime       string  `json:"total_time"`
+	RequestsPerSec  float64 `json:"requests_per_sec"`
+}
+
+// JSONGenerator reports the health of the load generator during the run
+type JSONGenerator struct {
+	CPUs               int      `json:"cpus"`
+	AvgCPUPercent      float64  `json:"avg_cpu_percent"`
+	MaxCPUPercent      float64  `json:"max_cpu_percent"`
+	MaxHeapBytes       int64    `json:"max_heap_bytes"`
+	MaxMemoryBytes     int64    `json:"max_memory_bytes"`
+	GCCount            int      `json:"gc_count"`
+	GCPauseTotal       string   `json:"gc_pause_total"`
+	MaxGCPause         string   `json:"max_gc_pause"`
+	MaxGoroutines      int      `json:"max_goroutines"`
+	MaxOpenFiles       int      `json:"max_open_files,omitempty"`
+	OpenFilesLimit     int      `json:"open_files_limit,omitempty"`
+	MaxEphemeralPorts  int      `json:"max_ephemeral_ports,omitempty"`
+	EphemeralPortRange int      `json:"ephemeral_port_range,omitempty"`
+	Warnings           []string `json:"warnings,omitempty"`
+}
+
+// JSONSteadyState reports the ramp and the steady state of a run separately
+type JSONSteadyState struct {
+	Reached bool        `json:"reached"`
+	Start   string      `json:"start,omitempty"` // Offset from the start of the run
+	Ramp    JSONPeriod  `json:"ramp"`
+	Steady  *JSONPeriod `json:"steady,omitempty"`
+}
+
+// JSONPeriod reports the executed requests of a period of the run
+type JSONPeriod struct {
+	JSONLatency
+	FailedReqs     int     `json:"failed_requests"`
+	Duration       string  `json:"duration"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+}
+
+type JSONTarget struct {
+	URL             string         `json:"url"`
+	Weight          int            `json:"weight"`
+	TotalRequests   int            `json:"total_requests"`
+	SuccessfulReqs  int            `json:"successful_requests"`
+	FailedReqs      int            `json:"failed_requests"`
+	SuccessRate     float64        `json:"success_rate_percent"`
+	AvgResponseTime string         `json:"avg_response_time"`
+	P95ResponseTime string         `json:"p95_response_time"`
+	StatusCodes     map[string]int `json:"status_codes"`
+}
+
+// JSONSLOAlert is an alert raised by an SLO whose burn rate exceeded its
+// max_burn_rate
+type JSONSLOAlert struct {
+	SLO          string  `json:"slo"`
+	Time         string  `json:"time"`
+	BurnRate     float64 `json:"burn_rate"`
+	PeakBurnRate float64 `json:"peak_burn_rate"`
+	BadRequests  int     `json:"bad_requests"`
+	Requests     int     `json:"requests"`
+	RecoveredAt  string  `json:"recovered_at,omitempty"`
+}
+
+// JSONAutoscale reports the adjustments of the worker pool and the
+// concurrency the autoscale goal needed
+type JSONAutoscale struct {
+	TargetRPS   float64             `json:"target_rps,omitempty"`
+	MaxLatency  string              `json:"max_latency,omitempty"`
+	Workers     int                 `json:"workers"` // Workers of the last interval that met the goal (0: it never was)
+	PeakWorkers int                 `json:"peak_workers"`
+	Steps       []JSONAutoscaleStep `json:"steps"`
+}
+
+type JSONAutoscaleStep struct {
+	Elapsed         string  `json:"elapsed"`
+	Workers         int     `json:"workers"`
+	RequestsPerSec  float64 `json:"requests_per_sec"`
+	P95ResponseTime string  `json:"p95_response_time"`
+	Met             bool    `json:"met"`
+	NextWorkers     int     `json:"next_workers"`
+}
+
+// JSONCapacity reports the stages of a capacity search, the highest rate
+// the target sustained and the lowest one it broke at
+type JSONCapacity struct {
+	MaxRPS       float64             `json:"max_rps"`      // Highest rate that passed (0: none did)
+	BreakingRPS  float64             `json:"breaking_rps"` // Lowest rate that failed (0: none did)
+	MaxErrorRate float64             `json:"max_error_rate"`
+	MaxP95       string              `json:"max_p95,omitempty"`
+	Stages       []JSONCapacityStage `json:"stages"`
+}
+
+type JSONCapacityStage struct {
+	TargetRPS       float64 `json:"target_rps"`
+	RequestsPerSec  float64 `json:"requests_per_sec"`
+	Requests        int     `json:"requests"`
+	ErrorRate       float64 `json:"error_rate"`
+	P95ResponseTime string  `json:"p95_response_time"`
+	Passed          bool    `json:"passed"`
+	Problem         string  `json:"problem,omitempty"`
+}
+
+type JSONPhase struct {
+	Phase           int      `json:"phase"`
+	Scenario        string   `json:"scenario,omitempty"`
+	Tests           []string `json:"tests"`
+	StartTime       string   `json:"start_time,omitempty"`
+	EndTime         string   `json:"end_time,omitempty"`
+	Duration        string   `json:"duration"`
+	TotalRequests   int      `json:"total_requests"`
+	SuccessfulReqs  int      `json:"successful_requests"`
+	FailedReqs      int      `json:"failed_requests"`
+	SkippedReqs     int      `json:"skipped_requests,omitempty"`
+	AvgResponseTime string   `json:"avg_response_time"`
+	RequestsPerSec  float64  `json:"requests_per_sec"`
 }
 
 func (r *Reporter) GenerateJSONReport(summary *models.Summary) error {
+	return r.WriteJSONReport(os.Stdout, summary)
+}
+
+// WriteJSONReport writes the JSON report to w
+func (r *Reporter) WriteJSONReport(w io.Writer, summary *models.Summary) error {
 	jsonReport := r.createJSONReport(summary)
 	output, err := json.MarshalIndent(jsonReport, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	fmt.Println(string(output))
-	return nil
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+// JSONReport returns the report written by WriteJSONReport
+func (r *Reporter) JSONReport(summary *models.Summary) JSONReport {
+	return r.createJSONReport(summary)
 }
 
 func (r *Reporter) createJSONReport(summary *models.Summary) JSONReport {
@@ -122,6 +516,31 @@ func (r *Reporter) createJSONReport(summary *models.Summary) JSONReport {
 			epStatusCodes[fmt.Sprintf("%d", code)] = count
 		}
 
+		var budgetConsumed float64
+		if ep.AllowedFailureRate > 0 {
+			budgetConsumed = ep.BudgetConsumed()
+		}
+
+		var statusClasses map[string]JSONLatency
+		if len(ep.StatusClassLatency) > 0 {
+			statusClasses = make(map[string]JSONLatency, len(ep.StatusClassLatency))
+			for class, latency := range ep.StatusClassLatency {
+				statusClasses[class] = JSONLatency{
+					Requests:        latency.Requests,
+					AvgResponseTime: latency.AvgResponseTime.Round(1000).String(),
+					P50ResponseTime: latency.P50ResponseTime.Round(1000).String(),
+					P95ResponseTime: latency.P95ResponseTime.Round(1000).String(),
+					P99ResponseTime: latency.P99ResponseTime.Round(1000).String(),
+				}
+			}
+		}
+
+		// Raw per-request error messages are only included in verbose mode
+		var epErrors []string
+		if r.verbose {
+			epErrors = ep.Errors
+		}
+
 		endpoints[name] = JSONEndpoint{
 			Name:              ep.Name,
 			URL:               ep.URL,
@@ -134,44 +553,262 @@ func (r *Reporter) createJSONReport(summary *models.Summary) JSONReport {
 			P95ResponseTime:   ep.P95ResponseTime.Round(1000).String(),
 			P99ResponseTime:   ep.P99ResponseTime.Round(1000).String(),
 			StatusCodes:       epStatusCodes,
-			Errors:            ep.Errors,
-			Success:           ep.FailedReqs == 0,
+			Errors:            epErrors,
+			ErrorCategories:   ep.ErrorCategories,
+			Success:           ep.Passed(),
+			AllowedFailure:    ep.AllowedFailureRate,
+			BudgetConsumed:    budgetConsumed,
 			TotalAssertions:   ep.TotalAssertions,
 			AssertionsPassed:  ep.AssertionsPassed,
 			AssertionsFailed:  ep.AssertionsFailed,
 			TotalComparisons:  ep.TotalComparisons,
 			ComparisonsPassed: ep.ComparisonsPassed,
 			ComparisonsFailed: ep.ComparisonsFailed,
+			CachedReqs:        ep.CachedReqs,
+			ThrottledReqs:     ep.ThrottledReqs,
+			LatencyHistogram:  histogramBuckets(ep.LatencyHistogram),
+			Transfer:          jsonTransfer(ep.Transfer, summary.TotalTime),
+			StatusClasses:     statusClasses,
+			SSE:               jsonSSE(ep.SSE),
+			Rows:              jsonRows(ep.Rows),
+			FailureSamples:    failureSamples(ep.FailureSamples),
+			Assertions:        jsonAssertions(ep.Assertions),
 		}
 	}
 
 	jsonReport := JSONReport{
 		Summary: JSONSummary{
-			TotalRequests:     summary.TotalRequests,
-			SuccessfulReqs:    summary.SuccessfulReqs,
-			FailedReqs:        summary.FailedReqs,
-			SuccessRate:       successRate,
-			TotalTime:         summary.TotalTime.Round(1000).String(),
-			AvgResponseTime:   summary.AvgResponseTime.Round(1000).String(),
-			MinResponseTime:   summary.MinResponseTime.Round(1000).String(),
-			MaxResponseTime:   summary.MaxResponseTime.Round(1000).String(),
-			P50ResponseTime:   summary.P50ResponseTime.Round(1000).String(),
-			P95ResponseTime:   summary.P95ResponseTime.Round(1000).String(),
-			P99ResponseTime:   summary.P99ResponseTime.Round(1000).String(),
-			RequestsPerSec:    summary.RequestsPerSec,
-			StatusCodes:       statusCodes,
-			Errors:            summary.Errors,
-			TotalAssertions:   summary.TotalAssertions,
-			AssertionsPassed:  summary.AssertionsPassed,
-			AssertionsFailed:  summary.AssertionsFailed,
-			TotalComparisons:  summary.TotalComparisons,
-			ComparisonsPassed: summary.ComparisonsPassed,
-			ComparisonsFailed: summary.ComparisonsFailed,
+			TotalRequests:      summary.TotalRequests,
+			SuccessfulReqs:     summary.SuccessfulReqs,
+			FailedReqs:         summary.FailedReqs,
+			SuccessRate:        successRate,
+			TotalTime:          summary.TotalTime.Round(1000).String(),
+			AvgResponseTime:    summary.AvgResponseTime.Round(1000).String(),
+			MinResponseTime:    summary.MinResponseTime.Round(1000).String(),
+			MaxResponseTime:    summary.MaxResponseTime.Round(1000).String(),
+			P50ResponseTime:    summary.P50ResponseTime.Round(1000).String(),
+			P95ResponseTime:    summary.P95ResponseTime.Round(1000).String(),
+			P99ResponseTime:    summary.P99ResponseTime.Round(1000).String(),
+			RequestsPerSec:     summary.RequestsPerSec,
+			StatusCodes:        statusCodes,
+			Errors:             summary.Errors,
+			ErrorCategories:    summary.ErrorCategories,
+			TotalAssertions:    summary.TotalAssertions,
+			AssertionsPassed:   summary.AssertionsPassed,
+			AssertionsFailed:   summary.AssertionsFailed,
+			TotalComparisons:   summary.TotalComparisons,
+			ComparisonsPassed:  summary.ComparisonsPassed,
+			ComparisonsFailed:  summary.ComparisonsFailed,
+			ThrottledReqs:      summary.ThrottledReqs,
+			ThrottleRate:       summary.ThrottleRate(),
+			ThrottleRetries:    summary.ThrottleRetries,
+			MaxDurationReached: summary.MaxDurationReached,
+			Interrupted:        summary.Interrupted,
+			AbortedBy:          summary.AbortedBy,
+			ReportWindow:       jsonReportWindow(summary),
+			Transfer:           jsonTransfer(summary.Transfer, summary.TotalTime),
+			ScheduleLag:        jsonScheduleLag(summary.ScheduleLag),
+			Resumed:            jsonResumed(summary.Resumed),
+			Replay:             jsonReplay(summary.Replay),
 		},
 		Endpoints: endpoints,
-		Success:   summary.FailedReqs == 0,
+		Success:   summary.Passed(),
+	}
+
+	if len(summary.ScenarioResults) > 0 {
+		jsonReport.Scenarios = make(map[string]JSONScenario, len(summary.ScenarioResults))
+		for name, sc := range summary.ScenarioResults {
+			var scSuccessRate float64
+			if sc.TotalRequests > 0 {
+				scSuccessRate = float64(sc.SuccessfulReqs) / float64(sc.TotalRequests) * 100
+			}
+			jsonReport.Scenarios[name] = JSONScenario{
+				Name:            sc.Name,
+				TotalRequests:   sc.TotalRequests,
+				SuccessfulReqs:  sc.SuccessfulReqs,
+				FailedReqs:      sc.FailedReqs,
+				SuccessRate:     scSuccessRate,
+				AvgResponseTime: sc.AvgResponseTime.Round(1000).String(),
+				TotalTime:       sc.TotalTime.Round(1000).String(),
+				RequestsPerSec:  sc.RequestsPerSec,
+			}
+		}
 	}
 	
+	for _, target := range summary.TargetResults {
+		var successRate float64
+		if target.TotalRequests > 0 {
+			successRate = float64(target.SuccessfulReqs) / float64(target.TotalRequests) * 100
+		}
+		statusCodes := make(map[string]int, len(target.StatusCodes))
+		for code, count := range target.StatusCodes {
+			statusCodes[fmt.Sprintf("%d", code)] = count
+		}
+		jsonReport.Targets = append(jsonReport.Targets, JSONTarget{
+			URL:             target.URL,
+			Weight:          target.Weight,
+			TotalRequests:   target.TotalRequests,
+			SuccessfulReqs:  target.SuccessfulReqs,
+			FailedReqs:      target.FailedReqs,
+			SuccessRate:     successRate,
+			AvgResponseTime: target.AvgResponseTime.Round(1000).String(),
+			P95ResponseTime: target.P95ResponseTime.Round(1000).String(),
+			StatusCodes:     statusCodes,
+		})
+	}
+
+	for _, phase := range summary.PhaseResults {
+		jsonPhase := JSONPhase{
+			Phase:           phase.Phase,
+			Scenario:        phase.Scenario,
+			Tests:           phase.Tests,
+			Duration:        phase.Duration.Round(1000).String(),
+			TotalRequests:   phase.TotalRequests,
+			SuccessfulReqs:  phase.SuccessfulReqs,
+			FailedReqs:      phase.FailedReqs,
+			SkippedReqs:     phase.SkippedReqs,
+			AvgResponseTime: phase.AvgResponseTime.Round(1000).String(),
+			RequestsPerSec:  phase.RequestsPerSec,
+		}
+		// Phases whose tests were all skipped never started
+		if !phase.StartTime.IsZero() {
+			jsonPhase.StartTime = phase.StartTime.Format(time.RFC3339Nano)
+			jsonPhase.EndTime = phase.EndTime.Format(time.RFC3339Nano)
+		}
+		jsonReport.Phases = append(jsonReport.Phases, jsonPhase)
+	}
+
+	jsonReport.RunID = summary.RunID
+	jsonReport.Seed = summary.Seed
+	if meta := summary.Metadata; meta != nil {
+		jsonReport.Metadata = &JSONMetadata{
+			ConfigName: meta.ConfigName,
+			ConfigFile: meta.ConfigFile,
+			ConfigHash: meta.ConfigHash,
+			Version:    meta.Version,
+			Commit:     meta.Commit,
+			Hostname:   meta.Hostname,
+			StartedAt:  meta.StartedAt.Format(time.RFC3339Nano),
+			FinishedAt: meta.FinishedAt.Format(time.RFC3339Nano),
+			Args:       meta.Args,
+			Labels:     meta.Labels,
+		}
+	}
+
+	if gen := summary.Generator; gen != nil {
+		jsonReport.Generator = &JSONGenerator{
+			CPUs:               gen.CPUs,
+			AvgCPUPercent:      gen.AvgCPUPercent,
+			MaxCPUPercent:      gen.MaxCPUPercent,
+			MaxHeapBytes:       int64(gen.MaxHeapBytes),
+			MaxMemoryBytes:     int64(gen.MaxMemoryBytes),
+			GCCount:            gen.GCCount,
+			GCPauseTotal:       gen.GCPauseTotal.Round(1000).String(),
+			MaxGCPause:         gen.MaxGCPause.Round(1000).String(),
+			MaxGoroutines:      gen.MaxGoroutines,
+			MaxOpenFiles:       gen.MaxOpenFiles,
+			OpenFilesLimit:     gen.OpenFilesLimit,
+			MaxEphemeralPorts:  gen.MaxEphemeralPorts,
+			EphemeralPortRange: gen.EphemeralPortRange,
+			Warnings:           gen.Warnings,
+		}
+	}
+
+	if steady := summary.SteadyState; steady != nil {
+		jsonReport.SteadyState = &JSONSteadyState{Reached: steady.Reached, Ramp: jsonPeriod(steady.Ramp)}
+		if steady.Reached {
+			period := jsonPeriod(steady.Steady)
+			jsonReport.SteadyState.Start = steady.Start.String()
+			jsonReport.SteadyState.Steady = &period
+		}
+	}
+
+	for _, alert := range summary.SLOAlerts {
+		jsonAlert := JSONSLOAlert{
+			SLO:          alert.SLO,
+			Time:         alert.Time.Format(time.RFC3339Nano),
+			BurnRate:     alert.BurnRate,
+			PeakBurnRate: alert.PeakBurnRate,
+			BadRequests:  alert.BadRequests,
+			Requests:     alert.Requests,
+		}
+		if !alert.RecoveredAt.IsZero() {
+			jsonAlert.RecoveredAt = alert.RecoveredAt.Format(time.RFC3339Nano)
+		}
+		jsonReport.SLOAlerts = append(jsonReport.SLOAlerts, jsonAlert)
+	}
+
+	if autoscale := summary.Autoscale; autoscale != nil {
+		jsonReport.Autoscale = &JSONAutoscale{
+			TargetRPS:   autoscale.TargetRPS,
+			Workers:     autoscale.Workers,
+			PeakWorkers: autoscale.PeakWorkers,
+			Steps:       []JSONAutoscaleStep{},
+		}
+		if autoscale.MaxLatency > 0 {
+			jsonReport.Autoscale.MaxLatency = autoscale.MaxLatency.String()
+		}
+		for _, step := range autoscale.Steps {
+			jsonReport.Autoscale.Steps = append(jsonReport.Autoscale.Steps, JSONAutoscaleStep{
+				Elapsed:         step.Elapsed.Round(time.Millisecond).String(),
+				Workers:         step.Workers,
+				RequestsPerSec:  step.RPS,
+				P95ResponseTime: step.P95.Round(1000).String(),
+				Met:             step.Met,
+				NextWorkers:     step.Next,
+			})
+		}
+	}
+
+	if capacity := summary.Capacity; capacity != nil {
+		jsonReport.Capacity = &JSONCapacity{
+			MaxRPS:       capacity.MaxRPS,
+			BreakingRPS:  capacity.BreakingRPS,
+			MaxErrorRate: capacity.MaxErrorRate,
+			Stages:       []JSONCapacityStage{},
+		}
+		if capacity.MaxP95 > 0 {
+			jsonReport.Capacity.MaxP95 = capacity.MaxP95.String()
+		}
+		for _, stage := range capacity.Stages {
+			jsonReport.Capacity.Stages = append(jsonReport.Capacity.Stages, JSONCapacityStage{
+				TargetRPS:       stage.RPS,
+				RequestsPerSec:  stage.AchievedRPS,
+				Requests:        stage.Requests,
+				ErrorRate:       stage.ErrorRate,
+				P95ResponseTime: stage.P95.Round(1000).String(),
+				Passed:          stage.Passed,
+				Problem:         stage.Problem,
+			})
+		}
+	}
+
+	if r.verbose {
+		for _, result := range summary.SlowestRequests {
+			slow := JSONSlowRequest{
+				TestName:     result.TestName,
+				Method:       result.Method,
+				URL:          result.URL,
+				StatusCode:   result.StatusCode,
+				ResponseTime: result.ResponseTime.Round(1000).String(),
+				Timestamp:    result.Timestamp.Format(time.RFC3339Nano),
+				Error:        result.Error,
+				Body:         result.BodySample,
+				RequestID:    result.RequestID,
+			}
+			if timing := result.Timing; timing != nil {
+				slow.Timing = &JSONTiming{
+					DNS:      timing.DNS.Round(1000).String(),
+					Connect:  timing.Connect.Round(1000).String(),
+					TLS:      timing.TLS.Round(1000).String(),
+					Wait:     timing.Wait.Round(1000).String(),
+					Transfer: timing.Transfer.Round(1000).String(),
+				}
+			}
+			jsonReport.Slowest = append(jsonReport.Slowest, slow)
+		}
+	}
+
 	// Include debug logs if verbose mode is enabled and there are logs
 	if r.verbose && len(summary.DebugLogs) > 0 {
 		jsonReport.DebugLogs = summary.DebugLogs
@@ -188,8 +825,60 @@ func (r *Reporter) printHeader() {
 	fmt.Println()
 }
 
+// printRun prints the ID of the run and what produced it
+func (r *Reporter) printRun(summary *models.Summary) {
+	fmt.Println(r.icon("🧾 ", "") + "RUN")
+	fmt.Println(strings.Repeat("─", 80))
+
+	if summary.RunID != "" {
+		fmt.Printf("Run ID:              %s\n", summary.RunID)
+	}
+	if summary.Seed != 0 {
+		fmt.Printf("Seed:                %d\n", summary.Seed)
+	}
+	if summary.Metadata != nil {
+		r.printMetadata(summary.Metadata)
+	}
+	if summary.Resumed != nil {
+		fmt.Printf("Resumed:             after %v and %d iterations; statistics cover the rest of the run\n",
+			summary.Resumed.Elapsed.Round(time.Second), summary.Resumed.Iterations)
+	}
+	if replay := summary.Replay; replay != nil {
+		fmt.Printf("Replayed:            %d requests captured over %v in %s, at %gx speed\n",
+			replay.Requests, replay.Span.Round(time.Millisecond), replay.File, replay.Speed)
+	}
+	fmt.Println()
+}
+
+// printMetadata prints what produced the run
+func (r *Reporter) printMetadata(meta *models.RunMetadata) {
+	config := meta.ConfigFile
+	if meta.ConfigHash != "" {
+		config += fmt.Sprintf(" (sha256 %.12s)", meta.ConfigHash)
+	}
+	if meta.ConfigName != "" {
+		config = meta.ConfigName + " · " + config
+	}
+	fmt.Printf("Config:              %s\n", config)
+	fmt.Printf("Started:             %s\n", meta.StartedAt.Format(time.RFC3339))
+	fmt.Printf("Finished:            %s\n", meta.FinishedAt.Format(time.RFC3339))
+	if meta.Hostname != "" {
+		fmt.Printf("Host:                %s\n", meta.Hostname)
+	}
+	fmt.Printf("Version:             %s\n", meta.Version)
+	if meta.Commit != "" {
+		fmt.Printf("Commit:              %s\n", meta.Commit)
+	}
+	if len(meta.Labels) > 0 {
+		fmt.Printf("Labels:              %s\n", formatLabels(meta.Labels))
+	}
+	if len(meta.Args) > 0 {
+		fmt.Printf("Arguments:           %s\n", strings.Join(meta.Args, " "))
+	}
+}
+
 func (r *Reporter) printSummary(summary *models.Summary) {
-	fmt.Println("📊 SUMMARY")
+	fmt.Println(r.icon("📊 ", "") + "SUMMARY")
 	fmt.Println(strings.Repeat("─", 80))
 
 	successRate := float64(0)
@@ -207,13 +896,38 @@ func (r *Reporter) printSummary(summary *models.Summary) {
 	if summary.SkippedReqs > 0 {
 		fmt.Printf("Skipped:             %d (%.1f%%)\n", summary.SkippedReqs, skippedRate)
 	}
+	if summary.ThrottledReqs > 0 || summary.ThrottleRetries > 0 {
+		fmt.Printf("Throttled:           %d (%.1f%%) after %d retries\n", summary.ThrottledReqs, summary.ThrottleRate(), summary.ThrottleRetries)
+	}
 	fmt.Printf("Requests/sec:        %.2f\n", summary.RequestsPerSec)
 	fmt.Printf("Total Duration:      %v\n", summary.TotalTime.Round(1000))
+	if summary.ReportWindow != nil {
+		fmt.Printf("Report Window:       %s (%d requests excluded)\n", formatReportWindow(*summary.ReportWindow), summary.ExcludedReqs)
+	}
+	if summary.Transfer.Requests > 0 {
+		sent, received := summary.Transfer.Throughput(summary.TotalTime)
+		fmt.Printf("Data Sent:           %s (avg %s/request, %.2f MB/s)\n",
+			formatBytes(float64(summary.Transfer.BytesSent)), formatBytes(summary.Transfer.AvgRequestSize()), sent)
+		fmt.Printf("Data Received:       %s (avg %s/response, %.2f MB/s)\n",
+			formatBytes(float64(summary.Transfer.BytesReceived)), formatBytes(summary.Transfer.AvgResponseSize()), received)
+		if summary.Transfer.Compressed > 0 {
+			fmt.Printf("Compression:         %s\n", formatCompression(summary.Transfer))
+		}
+	}
+	if summary.MaxDurationReached {
+		fmt.Println(r.icon("⚠️  ", "WARNING: ") + "Run stopped early: max duration reached")
+	}
+	if summary.Interrupted {
+		fmt.Println(r.icon("⚠️  ", "WARNING: ") + "Run interrupted: results are partial")
+	}
+	if summary.AbortedBy != "" {
+		fmt.Println(r.icon("⚠️  ", "WARNING: ") + "Run stopped early: SLO " + summary.AbortedBy + " burn rate exceeded")
+	}
 	fmt.Println()
 
 	// Print assertions summary if any assertions were evaluated
 	if summary.TotalAssertions > 0 {
-		fmt.Println("✅ ASSERTIONS")
+		fmt.Println(r.icon("✅ ", "") + "ASSERTIONS")
 		fmt.Println(strings.Repeat("─", 80))
 		assertionRate := float64(summary.AssertionsPassed) / float64(summary.TotalAssertions) * 100
 		fmt.Printf("Total Assertions:    %d\n", summary.TotalAssertions)
@@ -224,7 +938,7 @@ func (r *Reporter) printSummary(summary *models.Summary) {
 
 	// Print comparisons summary if any comparisons were performed
 	if summary.TotalComparisons > 0 {
-		fmt.Println("🔀 COMPARISONS (Tap Compare)")
+		fmt.Println(r.icon("🔀 ", "") + "COMPARISONS (Tap Compare)")
 		fmt.Println(strings.Repeat("─", 80))
 		comparisonRate := float64(summary.ComparisonsPassed) / float64(summary.TotalComparisons) * 100
 		fmt.Printf("Total Comparisons:   %d\n", summary.TotalComparisons)
@@ -241,6 +955,14 @@ func (r *Reporter) printSummary(summary *models.Summary) {
 	fmt.Printf("P50 (median):        %v\n", summary.P50ResponseTime.Round(1000))
 	fmt.Printf("P95:                 %v\n", summary.P95ResponseTime.Round(1000))
 	fmt.Printf("P99:                 %v\n", summary.P99ResponseTime.Round(1000))
+	if lag := summary.ScheduleLag; lag != nil {
+		// Requests held back by a loop behind its target rate hide that wait
+		fmt.Printf("Schedule Lag:        %d late requests | Avg=%v | Max=%v\n",
+			lag.LateRequests, lag.AvgLag.Round(1000), lag.MaxLag.Round(1000))
+		fmt.Printf("Corrected:           Avg=%v | P50=%v | P95=%v | P99=%v\n",
+			lag.Corrected.AvgResponseTime.Round(1000), lag.Corrected.P50ResponseTime.Round(1000),
+			lag.Corrected.P95ResponseTime.Round(1000), lag.Corrected.P99ResponseTime.Round(1000))
+	}
 	fmt.Println()
 }
 
@@ -249,7 +971,7 @@ func (r *Reporter) printStatusCodes(summary *models.Summary) {
 		return
 	}
 
-	fmt.Println("📈 STATUS CODES")
+	fmt.Println(r.icon("📈 ", "") + "STATUS CODES")
 	fmt.Println(strings.Repeat("─", 80))
 
 	type statusCount struct {
@@ -268,14 +990,251 @@ func (r *Reporter) printStatusCodes(summary *models.Summary) {
 
 	for _, sc := range statuses {
 		percentage := float64(sc.count) / float64(summary.TotalRequests) * 100
+		if r.plain {
+			fmt.Printf("%d:              %d (%.1f%%)\n", sc.code, sc.count, percentage)
+			continue
+		}
 		emoji := r.getStatusEmoji(sc.code)
 		fmt.Printf("%s %d:              %d (%.1f%%)\n", emoji, sc.code, sc.count, percentage)
 	}
 	fmt.Println()
 }
 
+func (r *Reporter) printScenarioResults(summary *models.Summary) {
+	fmt.Println(r.icon("🎬 ", "") + "SCENARIOS")
+	fmt.Println(strings.Repeat("─", 80))
+
+	var names []string
+	for name := range summary.ScenarioResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sc := summary.ScenarioResults[name]
+		successRate := float64(0)
+		if sc.TotalRequests > 0 {
+			successRate = float64(sc.SuccessfulReqs) / float64(sc.TotalRequests) * 100
+		}
+		fmt.Printf("• %s\n", sc.Name)
+		fmt.Printf("   Requests: %d (%s %d, %s %d) - %.1f%% success\n", sc.TotalRequests, r.icon("✅", "passed"), sc.SuccessfulReqs, r.icon("❌", "failed"), sc.FailedReqs, successRate)
+		fmt.Printf("   Avg: %v | Requests/sec: %.2f\n", sc.AvgResponseTime.Round(1000), sc.RequestsPerSec)
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printTargetResults(summary *models.Summary) {
+	fmt.Println(r.icon("🎯 ", "") + "TARGETS")
+	fmt.Println(strings.Repeat("─", 80))
+
+	for _, target := range summary.TargetResults {
+		successRate := float64(0)
+		if target.TotalRequests > 0 {
+			successRate = float64(target.SuccessfulReqs) / float64(target.TotalRequests) * 100
+		}
+		fmt.Printf("• %s (weight %d)\n", target.URL, target.Weight)
+		fmt.Printf("   Requests: %d (%s %d, %s %d) - %.1f%% success\n", target.TotalRequests, r.icon("✅", "passed"), target.SuccessfulReqs, r.icon("❌", "failed"), target.FailedReqs, successRate)
+		fmt.Printf("   Avg: %v | P95: %v | Status: %s\n", target.AvgResponseTime.Round(1000), target.P95ResponseTime.Round(1000), formatStatusCodes(target.StatusCodes))
+	}
+	fmt.Println()
+}
+
+// formatReportWindow describes a report window, e.g. "2m0s to 8m0s"
+func formatReportWindow(window models.ReportWindow) string {
+	if window.End == 0 {
+		return fmt.Sprintf("%v to end", window.Start)
+	}
+	return fmt.Sprintf("%v to %v", window.Start, window.End)
+}
+
+// formatStatusCodes lists status codes and their counts, e.g. "200=95, 500=5"
+func formatStatusCodes(codes map[int]int) string {
+	if len(codes) == 0 {
+		return "-"
+	}
+	sorted := make([]int, 0, len(codes))
+	for code := range codes {
+		sorted = append(sorted, code)
+	}
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, code := range sorted {
+		parts[i] = fmt.Sprintf("%d=%d", code, codes[code])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *Reporter) printPhaseResults(summary *models.Summary) {
+	fmt.Println(r.icon("🔗 ", "") + "DAG PHASES")
+	fmt.Println(strings.Repeat("─", 80))
+
+	// Phase windows are shown relative to the start of the first phase
+	var runStart time.Time
+	for _, phase := range summary.PhaseResults {
+		if !phase.StartTime.IsZero() && (runStart.IsZero() || phase.StartTime.Before(runStart)) {
+			runStart = phase.StartTime
+		}
+	}
+
+	for _, phase := range summary.PhaseResults {
+		name := fmt.Sprintf("Phase %d", phase.Phase)
+		if phase.Scenario != "" {
+			name = phase.Scenario + " / " + name
+		}
+		fmt.Printf("• %s: %s\n", name, strings.Join(phase.Tests, ", "))
+
+		if phase.StartTime.IsZero() {
+			fmt.Printf("   Skipped: %d (dependency failed)\n", phase.SkippedReqs)
+			continue
+		}
+		requests := fmt.Sprintf("   Requests: %d (%s %d, %s %d", phase.TotalRequests,
+			r.icon("✅", "passed"), phase.SuccessfulReqs, r.icon("❌", "failed"), phase.FailedReqs)
+		if phase.SkippedReqs > 0 {
+			requests += fmt.Sprintf(", %s %d", r.icon("⏭️", "skipped"), phase.SkippedReqs)
+		}
+		fmt.Println(requests + ")")
+		fmt.Printf("   Window: +%v → +%v | Duration: %v\n",
+			phase.StartTime.Sub(runStart).Round(time.Millisecond),
+			phase.EndTime.Sub(runStart).Round(time.Millisecond),
+			phase.Duration.Round(1000))
+		fmt.Printf("   Avg: %v | Requests/sec: %.2f\n", phase.AvgResponseTime.Round(1000), phase.RequestsPerSec)
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printGenerator(summary *models.Summary) {
+	gen := summary.Generator
+	fmt.Println(r.icon("🖥️  ", "") + "LOAD GENERATOR")
+	fmt.Println(strings.Repeat("─", 80))
+	if gen.AvgCPUPercent > 0 || gen.MaxCPUPercent > 0 {
+		fmt.Printf("CPU:                 Avg=%.1f%% | Max=%.1f%% of %d CPUs\n", gen.AvgCPUPercent, gen.MaxCPUPercent, gen.CPUs)
+	} else {
+		fmt.Printf("CPUs:                %d\n", gen.CPUs)
+	}
+	fmt.Printf("Memory:              Heap=%s | Total=%s | Goroutines=%d\n",
+		formatBytes(float64(gen.MaxHeapBytes)), formatBytes(float64(gen.MaxMemoryBytes)), gen.MaxGoroutines)
+	fmt.Printf("GC:                  %d cycles | Pauses=%v | Max=%v\n",
+		gen.GCCount, gen.GCPauseTotal.Round(1000), gen.MaxGCPause.Round(1000))
+	if gen.OpenFilesLimit > 0 {
+		fmt.Printf("Open Files:          %d of %d\n", gen.MaxOpenFiles, gen.OpenFilesLimit)
+	}
+	if gen.EphemeralPortRange > 0 {
+		fmt.Printf("Ephemeral Ports:     %d of %d\n", gen.MaxEphemeralPorts, gen.EphemeralPortRange)
+	}
+	for _, warning := range gen.Warnings {
+		fmt.Println(r.icon("⚠️  ", "WARNING: ") + warning)
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printSteadyState(summary *models.Summary) {
+	fmt.Println(r.icon("📈 ", "") + "STEADY STATE")
+	fmt.Println(strings.Repeat("─", 80))
+
+	steady := summary.SteadyState
+	if steady.Reached {
+		fmt.Printf("Reached after %v\n", steady.Start)
+	} else {
+		fmt.Println("Not reached: the whole run is reported as ramp")
+	}
+	r.printPeriod("Ramp", steady.Ramp)
+	if steady.Reached {
+		r.printPeriod("Steady", steady.Steady)
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printSLOAlerts(summary *models.Summary) {
+	fmt.Println(r.icon("🔥 ", "") + "SLO ALERTS")
+	fmt.Println(strings.Repeat("─", 80))
+
+	for _, alert := range summary.SLOAlerts {
+		fmt.Printf("• %s at %s\n", alert.SLO, alert.Time.Format("15:04:05"))
+		fmt.Printf("   Burn rate: %.1f (peak %.1f) | Bad requests: %d of %d in the window\n",
+			alert.BurnRate, alert.PeakBurnRate, alert.BadRequests, alert.Requests)
+		if alert.RecoveredAt.IsZero() {
+			fmt.Println("   Still burning at the end of the run")
+		} else {
+			fmt.Printf("   Recovered after %v\n", alert.RecoveredAt.Sub(alert.Time).Round(time.Millisecond))
+		}
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printAutoscale(summary *models.Summary) {
+	fmt.Println(r.icon("⚖️  ", "") + "AUTOSCALE")
+	fmt.Println(strings.Repeat("─", 80))
+
+	autoscale := summary.Autoscale
+	var goal []string
+	if autoscale.TargetRPS > 0 {
+		goal = append(goal, fmt.Sprintf("%.1f req/s", autoscale.TargetRPS))
+	}
+	if autoscale.MaxLatency > 0 {
+		goal = append(goal, fmt.Sprintf("P95 under %v", autoscale.MaxLatency))
+	}
+	fmt.Printf("Goal: %s\n", strings.Join(goal, ", "))
+	if autoscale.Workers > 0 {
+		fmt.Printf("Workers needed: %d (peak %d)\n", autoscale.Workers, autoscale.PeakWorkers)
+	} else {
+		fmt.Printf("Goal never met (peak %d workers)\n", autoscale.PeakWorkers)
+	}
+
+	if r.verbose {
+		for _, step := range autoscale.Steps {
+			met := ""
+			if step.Met {
+				met = " " + r.icon("✅", "(met)")
+			}
+			fmt.Printf("   [%6v] %d workers | %.1f req/s | P95 %v -> %d workers%s\n",
+				step.Elapsed.Round(time.Second), step.Workers, step.RPS, step.P95.Round(1000), step.Next, met)
+		}
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printCapacity(summary *models.Summary) {
+	fmt.Println(r.icon("📈 ", "") + "CAPACITY")
+	fmt.Println(strings.Repeat("─", 80))
+
+	capacity := summary.Capacity
+	thresholds := fmt.Sprintf("error rate up to %.2f%%", capacity.MaxErrorRate)
+	if capacity.MaxP95 > 0 {
+		thresholds += fmt.Sprintf(", P95 up to %v", capacity.MaxP95)
+	}
+	fmt.Printf("Thresholds: %s\n", thresholds)
+	if capacity.MaxRPS > 0 {
+		fmt.Printf("Max sustainable rate: %.1f req/s\n", capacity.MaxRPS)
+	} else {
+		fmt.Println("No rate sustained, not even start_rps")
+	}
+	if capacity.BreakingRPS > 0 {
+		fmt.Printf("Breaking point: %.1f req/s\n", capacity.BreakingRPS)
+	} else {
+		fmt.Println("No breaking point up to max_rps")
+	}
+
+	for _, stage := range capacity.Stages {
+		outcome := r.icon("✅", "passed")
+		if !stage.Passed {
+			outcome = r.icon("❌", "failed") + " " + stage.Problem
+		}
+		fmt.Printf("   %.1f req/s: %.1f req/s reached | %d requests | errors %.2f%% | P95 %v %s\n",
+			stage.RPS, stage.AchievedRPS, stage.Requests, stage.ErrorRate, stage.P95.Round(1000), outcome)
+	}
+	fmt.Println()
+}
+
+func (r *Reporter) printPeriod(name string, period models.PeriodSummary) {
+	fmt.Printf("• %s: %d requests (%s %d) in %v\n", name, period.Requests,
+		r.icon("❌", "failed"), period.FailedReqs, period.Duration.Round(1000))
+	fmt.Printf("   Avg: %v | P50: %v | P95: %v | P99: %v | Requests/sec: %.2f\n",
+		period.AvgResponseTime.Round(1000), period.P50ResponseTime.Round(1000),
+		period.P95ResponseTime.Round(1000), period.P99ResponseTime.Round(1000), period.RequestsPerSec)
+}
+
 func (r *Reporter) printEndpointResults(summary *models.Summary) {
-	fmt.Println("🎯 ENDPOINT RESULTS")
+	fmt.Println(r.icon("🎯 ", "") + "ENDPOINT RESULTS")
 	fmt.Println(strings.Repeat("─", 80))
 
 	type endpointResult struct {
@@ -295,11 +1254,13 @@ func (r *Reporter) printEndpointResults(summary *models.Summary) {
 
 	for _, ep := range endpoints {
 		// Determine status icon
-		status := "✅"
+		status := r.icon("✅", "[PASS]")
 		if ep.endpoint.SkippedReqs > 0 && ep.endpoint.SuccessfulReqs == 0 && ep.endpoint.FailedReqs == 0 {
-			status = "⏭️"
+			status = r.icon("⏭️", "[SKIP]")
+		} else if ep.endpoint.FailedReqs > 0 && ep.endpoint.Passed() {
+			status = r.icon("⚠️", "[WARN]")
 		} else if ep.endpoint.FailedReqs > 0 {
-			status = "❌"
+			status = r.icon("❌", "[FAIL]")
 		}
 
 		fmt.Printf("%s %s\n", status, ep.endpoint.Name)
@@ -320,12 +1281,51 @@ func (r *Reporter) printEndpointResults(summary *models.Summary) {
 				ep.endpoint.P50ResponseTime.Round(1000),
 				ep.endpoint.P95ResponseTime.Round(1000),
 				ep.endpoint.P99ResponseTime.Round(1000))
+			printStatusClassLatency(ep.endpoint.StatusClassLatency)
+			printLatencyHistogram(histogramBuckets(ep.endpoint.LatencyHistogram))
+		}
+
+		if transfer := ep.endpoint.Transfer; transfer.Requests > 0 {
+			_, received := transfer.Throughput(summary.TotalTime)
+			fmt.Printf("   Data: Sent=%s (avg %s) | Received=%s (avg %s) | %.2f MB/s\n",
+				formatBytes(float64(transfer.BytesSent)), formatBytes(transfer.AvgRequestSize()),
+				formatBytes(float64(transfer.BytesReceived)), formatBytes(transfer.AvgResponseSize()), received)
+			if transfer.Compressed > 0 {
+				fmt.Printf("   Compression: %s\n", formatCompression(transfer))
+			}
+		}
+
+		if sse := ep.endpoint.SSE; sse.Streams > 0 {
+			fmt.Printf("   Events: %d in %d streams | First Event: Avg=%v | Interval: Avg=%v | P95=%v | Max=%v\n",
+				sse.Events, sse.Streams, sse.AvgFirstEvent.Round(1000), sse.AvgInterval.Round(1000),
+				sse.P95Interval.Round(1000), sse.MaxInterval.Round(1000))
+		}
+
+		if rows := ep.endpoint.Rows; rows.Queries > 0 {
+			fmt.Printf("   Rows: %d in %d queries | Avg=%.1f | Min=%d | Max=%d\n",
+				rows.Rows, rows.Queries, rows.AvgRows(), rows.MinRows, rows.MaxRows)
+		}
+
+		if ep.endpoint.ThrottledReqs > 0 {
+			fmt.Printf("   Throttled: %d still rate limited after their retries\n", ep.endpoint.ThrottledReqs)
+		}
+
+		if ep.endpoint.CachedReqs > 0 {
+			fmt.Printf("   Cached: %d of %d responses served from cache\n", ep.endpoint.CachedReqs, ep.endpoint.TotalRequests)
+		}
+
+		if ep.endpoint.AllowedFailureRate > 0 {
+			fmt.Printf("   Failure Budget: %.2f%% of %.2f%% allowed (%.0f%% consumed)\n",
+				ep.endpoint.FailureRate(), ep.endpoint.AllowedFailureRate, ep.endpoint.BudgetConsumed())
 		}
 
 		if ep.endpoint.TotalAssertions > 0 {
 			assertionRate := float64(ep.endpoint.AssertionsPassed) / float64(ep.endpoint.TotalAssertions) * 100
 			fmt.Printf("   Assertions: %d total | Passed: %d (%.1f%%) | Failed: %d\n",
 				ep.endpoint.TotalAssertions, ep.endpoint.AssertionsPassed, assertionRate, ep.endpoint.AssertionsFailed)
+			if r.verbose {
+				r.printFailedAssertions(ep.endpoint.Assertions)
+			}
 		}
 
 		if ep.endpoint.TotalComparisons > 0 {
@@ -343,6 +1343,10 @@ func (r *Reporter) printEndpointResults(summary *models.Summary) {
 			fmt.Printf("%s\n", strings.Join(codes, ", "))
 		}
 
+		if len(ep.endpoint.ErrorCategories) > 0 {
+			fmt.Printf("   Error Types: %s\n", formatCounts(ep.endpoint.ErrorCategories))
+		}
+
 		if len(ep.endpoint.Errors) > 0 && r.verbose {
 			fmt.Printf("   Errors: %d unique\n", len(ep.endpoint.Errors))
 		}
@@ -350,8 +1354,142 @@ func (r *Reporter) printEndpointResults(summary *models.Summary) {
 	}
 }
 
+// printFailedAssertions lists the failed assertions of an endpoint with
+// their first message and the section of the body around the failure
+func (r *Reporter) printFailedAssertions(summaries []models.AssertionSummary) {
+	for _, assertion := range jsonAssertions(summaries) {
+		if assertion.Failed == 0 {
+			continue
+		}
+		fmt.Printf("     %s %s (%d failed)\n", r.icon("✗", "-"), formatAssertion(assertion), assertion.Failed)
+		if len(assertion.Messages) > 0 {
+			fmt.Printf("       %s\n", assertion.Messages[0])
+		}
+		for _, line := range strings.Split(assertion.Context, "\n") {
+			if line != "" {
+				fmt.Printf("       │ %s\n", line)
+			}
+		}
+	}
+}
+
+// histogramBuckets labels the buckets of a latency histogram, leaving out
+// the empty buckets before the fastest and after the slowest request
+func histogramBuckets(histogram []models.LatencyBucket) []JSONBucket {
+	first, last := -1, -1
+	total := 0
+	for i, bucket := range histogram {
+		if bucket.Count > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			total += bucket.Count
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+
+	var buckets []JSONBucket
+	for i := first; i <= last; i++ {
+		var label string
+		switch {
+		case i == 0:
+			label = "<" + histogram[i].UpperBound.String()
+		case histogram[i].UpperBound == 0:
+			label = ">=" + histogram[i-1].UpperBound.String()
+		default:
+			label = histogram[i-1].UpperBound.String() + "-" + histogram[i].UpperBound.String()
+		}
+		buckets = append(buckets, JSONBucket{
+			Range:   label,
+			Count:   histogram[i].Count,
+			Percent: float64(histogram[i].Count) / float64(total) * 100,
+		})
+	}
+	return buckets
+}
+
+// printStatusClassLatency prints the response times per status class when
+// an endpoint answered with more than one, e.g. fast 500s and slow 200s
+func printStatusClassLatency(latencies map[string]*models.LatencySummary) {
+	if len(latencies) < 2 {
+		return
+	}
+	classes := make([]string, 0, len(latencies))
+	for class := range latencies {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	fmt.Println("   By Status Class:")
+	for _, class := range classes {
+		latency := latencies[class]
+		fmt.Printf("     %s (%d): Avg=%v | P50=%v | P95=%v | P99=%v\n",
+			class, latency.Requests,
+			latency.AvgResponseTime.Round(1000),
+			latency.P50ResponseTime.Round(1000),
+			latency.P95ResponseTime.Round(1000),
+			latency.P99ResponseTime.Round(1000))
+	}
+}
+
+// printLatencyHistogram prints the buckets as bars scaled to the largest one
+func printLatencyHistogram(buckets []JSONBucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	const barWidth = 30
+	maxCount := 0
+	for _, bucket := range buckets {
+		if bucket.Count > maxCount {
+			maxCount = bucket.Count
+		}
+	}
+
+	fmt.Println("   Latency Distribution:")
+	for _, bucket := range buckets {
+		width := bucket.Count * barWidth / maxCount
+		if width == 0 && bucket.Count > 0 {
+			width = 1
+		}
+		fmt.Printf("     %-13s %-30s %d (%.1f%%)\n",
+			bucket.Range, strings.Repeat("█", width), bucket.Count, bucket.Percent)
+	}
+}
+
+func (r *Reporter) printSlowestRequests(summary *models.Summary) {
+	fmt.Println(r.icon("🐢 ", "") + "SLOWEST REQUESTS")
+	fmt.Println(strings.Repeat("─", 80))
+
+	for i, result := range summary.SlowestRequests {
+		status := "no response"
+		if result.StatusCode > 0 {
+			status = fmt.Sprintf("%d", result.StatusCode)
+		}
+		fmt.Printf("%2d. %v  %s → %s\n", i+1, result.ResponseTime.Round(1000), result.TestName, status)
+		fmt.Printf("    %s %s\n", result.Method, result.URL)
+		if result.RequestID != "" {
+			fmt.Printf("    Request ID: %s\n", result.RequestID)
+		}
+		if timing := result.Timing; timing != nil {
+			fmt.Printf("    DNS=%v | Connect=%v | TLS=%v | Wait=%v | Transfer=%v\n",
+				timing.DNS.Round(1000), timing.Connect.Round(1000), timing.TLS.Round(1000),
+				timing.Wait.Round(1000), timing.Transfer.Round(1000))
+		}
+		if result.Error != "" {
+			fmt.Printf("    Error: %s\n", strings.SplitN(result.Error, "\n", 2)[0])
+		}
+		if result.BodySample != "" {
+			fmt.Printf("    Body: %s\n", result.BodySample)
+		}
+	}
+	fmt.Println()
+}
+
 func (r *Reporter) printErrors(summary *models.Summary) {
-	fmt.Println("❌ ERRORS")
+	fmt.Println(r.icon("❌ ", "") + "ERRORS")
 	fmt.Println(strings.Repeat("─", 80))
 
 	type errorCount struct {
@@ -375,9 +1513,247 @@ func (r *Reporter) printErrors(summary *models.Summary) {
 	fmt.Println()
 }
 
+func (r *Reporter) printErrorCategories(summary *models.Summary) {
+	fmt.Println(r.icon("🏷️  ", "") + "ERROR CATEGORIES")
+	fmt.Println(strings.Repeat("─", 80))
+
+	type categoryCount struct {
+		category string
+		count    int
+	}
+
+	var categories []categoryCount
+	for category, count := range summary.ErrorCategories {
+		categories = append(categories, categoryCount{category, count})
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].count != categories[j].count {
+			return categories[i].count > categories[j].count
+		}
+		return categories[i].category < categories[j].category
+	})
+
+	for _, cc := range categories {
+		percentage := float64(cc.count) / float64(summary.TotalRequests) * 100
+		fmt.Printf("• %-20s %d (%.1f%%)\n", cc.category+":", cc.count, percentage)
+	}
+	fmt.Println()
+}
+
+// formatCounts renders a count map as "key (n), key (n)" sorted by count
+// jsonTransfer converts transfer stats for the JSON report, or returns nil
+// when no request reached the server
+func jsonScheduleLag(lag *models.ScheduleLagSummary) *JSONScheduleLag {
+	if lag == nil {
+		return nil
+	}
+	return &JSONScheduleLag{
+		LateRequests: lag.LateRequests,
+		AvgLag:       lag.AvgLag.Round(1000).String(),
+		MaxLag:       lag.MaxLag.Round(1000).String(),
+		Corrected: JSONLatency{
+			Requests:        lag.Corrected.Requests,
+			AvgResponseTime: lag.Corrected.AvgResponseTime.Round(1000).String(),
+			P50ResponseTime: lag.Corrected.P50ResponseTime.Round(1000).String(),
+			P95ResponseTime: lag.Corrected.P95ResponseTime.Round(1000).String(),
+			P99ResponseTime: lag.Corrected.P99ResponseTime.Round(1000).String(),
+		},
+	}
+}
+
+// jsonReportWindow converts the report window of a summary, or returns nil
+// when the statistics cover the whole run
+func jsonReportWindow(summary *models.Summary) *JSONReportWindow {
+	if summary.ReportWindow == nil {
+		return nil
+	}
+	window := &JSONReportWindow{Start: summary.ReportWindow.Start.String(), ExcludedRequests: summary.ExcludedReqs}
+	if summary.ReportWindow.End > 0 {
+		window.End = summary.ReportWindow.End.String()
+	}
+	return window
+}
+
+func jsonTransfer(transfer models.TransferStats, totalTime time.Duration) *JSONTransfer {
+	if transfer.Requests == 0 {
+		return nil
+	}
+	sent, received := transfer.Throughput(totalTime)
+	return &JSONTransfer{
+		BytesSent:        transfer.BytesSent,
+		BytesReceived:    transfer.BytesReceived,
+		AvgRequestSize:   transfer.AvgRequestSize(),
+		AvgResponseSize:  transfer.AvgResponseSize(),
+		SentMBPerSec:     sent,
+		ReceivedMBPerSec: received,
+		Compression:      jsonCompression(transfer),
+	}
+}
+
+func jsonCompression(transfer models.TransferStats) *JSONCompression {
+	if transfer.Compressed == 0 {
+		return nil
+	}
+	return &JSONCompression{
+		Responses:       transfer.Compressed,
+		BytesCompressed: transfer.BytesCompressed,
+		BytesDecoded:    transfer.BytesDecoded,
+		SavedPercent:    transfer.CompressionSavings(),
+		AvgDecodeTime:   transfer.AvgDecodeTime().Round(1000).String(),
+	}
+}
+
+func jsonSSE(sse models.SSEStats) *JSONSSE {
+	if sse.Streams == 0 {
+		return nil
+	}
+	return &JSONSSE{
+		Streams:       sse.Streams,
+		Events:        sse.Events,
+		AvgFirstEvent: sse.AvgFirstEvent.Round(1000).String(),
+		AvgInterval:   sse.AvgInterval.Round(1000).String(),
+		P95Interval:   sse.P95Interval.Round(1000).String(),
+		MaxInterval:   sse.MaxInterval.Round(1000).String(),
+	}
+}
+
+func jsonPeriod(period models.PeriodSummary) JSONPeriod {
+	return JSONPeriod{
+		JSONLatency: JSONLatency{
+			Requests:        period.Requests,
+			AvgResponseTime: period.AvgResponseTime.Round(1000).String(),
+			P50ResponseTime: period.P50ResponseTime.Round(1000).String(),
+			P95ResponseTime: period.P95ResponseTime.Round(1000).String(),
+			P99ResponseTime: period.P99ResponseTime.Round(1000).String(),
+		},
+		FailedReqs:     period.FailedReqs,
+		Duration:       period.Duration.Round(1000).String(),
+		RequestsPerSec: period.RequestsPerSec,
+	}
+}
+
+func jsonRows(rows models.RowStats) *JSONRows {
+	if rows.Queries == 0 {
+		return nil
+	}
+	return &JSONRows{
+		Queries: rows.Queries,
+		Total:   rows.Rows,
+		Avg:     rows.AvgRows(),
+		Min:     rows.MinRows,
+		Max:     rows.MaxRows,
+	}
+}
+
+// failureSamples converts an endpoint's failure samples
+func failureSamples(results []models.TestResult) []JSONFailureSample {
+	var samples []JSONFailureSample
+	for _, result := range results {
+		samples = append(samples, JSONFailureSample{
+			StatusCode:      result.StatusCode,
+			ErrorCategory:   result.ErrorCategory,
+			Error:           result.Error,
+			AssertionErrors: result.AssertionErrors,
+			Body:            result.BodySample,
+			RequestID:       result.RequestID,
+			Timestamp:       result.Timestamp.Format(time.RFC3339Nano),
+		})
+	}
+	return samples
+}
+
+// jsonAssertions converts the assertion summaries of an endpoint
+func jsonAssertions(summaries []models.AssertionSummary) []JSONAssertion {
+	var assertions []JSONAssertion
+	for _, summary := range summaries {
+		assertions = append(assertions, JSONAssertion{
+			Type:     summary.Assertion.Type,
+			Target:   summary.Assertion.Target,
+			Operator: summary.Assertion.Operator,
+			Expected: summary.Assertion.Value,
+			Passed:   summary.Passed,
+			Failed:   summary.Failed,
+			Messages: summary.Messages,
+			Context:  summary.Context,
+		})
+	}
+	return assertions
+}
+
+// formatAssertion describes an assertion, e.g. json_path status eq ok
+func formatAssertion(assertion JSONAssertion) string {
+	parts := []string{assertion.Type}
+	if assertion.Target != "" {
+		parts = append(parts, assertion.Target)
+	}
+	if assertion.Operator != "" {
+		parts = append(parts, assertion.Operator)
+	}
+	if assertion.Expected != nil {
+		parts = append(parts, fmt.Sprint(assertion.Expected))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatCompression describes the responses decoded from a content encoding
+func formatCompression(transfer models.TransferStats) string {
+	return fmt.Sprintf("%d responses, %s decoded from %s (%.0f%% saved) | Decode Avg=%v",
+		transfer.Compressed, formatBytes(float64(transfer.BytesDecoded)), formatBytes(float64(transfer.BytesCompressed)),
+		transfer.CompressionSavings(), transfer.AvgDecodeTime().Round(1000))
+}
+
+// formatLabels renders labels as key=value pairs sorted by key
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// formatBytes renders a byte count with a decimal unit, e.g. 1.5 KB
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	unit := 0
+	for bytes >= 1000 && unit < len(units)-1 {
+		bytes /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[unit])
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d)", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (r *Reporter) printFooter() {
 	fmt.Println(strings.Repeat("═", 80))
-	fmt.Println("🚀 Test completed successfully!")
+	fmt.Println(r.icon("🚀 ", "") + "Test completed successfully!")
 	fmt.Println()
 }
 
@@ -397,9 +1773,17 @@ func (r *Reporter) getStatusEmoji(statusCode int) string {
 }
 
 func (r *Reporter) GenerateHTMLReport(summary *models.Summary) error {
+	return r.WriteHTMLReport(os.Stdout, summary)
+}
+
+// WriteHTMLReport writes the HTML report to w
+func (r *Reporter) WriteHTMLReport(w io.Writer, summary *models.Summary) error {
 	jsonReport := r.createJSONReport(summary)
 	
 	funcMap := template.FuncMap{
+		"bytes": func(n int64) string {
+			return formatBytes(float64(n))
+		},
 		"percentage": func(part, total int) float64 {
 			if total == 0 {
 				return 0
@@ -427,6 +1811,7 @@ func (r *Reporter) GenerateHTMLReport(summary *models.Summary) error {
 		"gt": func(a, b int) bool {
 			return a > b
 		},
+		"assertion": formatAssertion,
 	}
 	
 	tmpl, err := template.New("report").Funcs(funcMap).Parse(htmlTemplate)
@@ -434,7 +1819,7 @@ func (r *Reporter) GenerateHTMLReport(summary *models.Summary) error {
 		return fmt.Errorf("failed to parse HTML template: %w", err)
 	}
 	
-	err = tmpl.Execute(os.Stdout, jsonReport)
+	err = tmpl.Execute(w, jsonReport)
 	if err != nil {
 		return fmt.Errorf("failed to execute HTML template: %w", err)
 	}
@@ -442,3 +1827,55 @@ func (r *Reporter) GenerateHTMLReport(summary *models.Summary) error {
 	return nil
 }
 
+
+// ReportFile is a report written to a file with -report-file
+type ReportFile struct {
+	Format string // json, html, or openmetrics
+	Path   string
+}
+
+// ParseReportFile parses a -report-file value: "format=path", or a path
+// whose .json, .html, .htm, or .prom extension gives the format
+func ParseReportFile(value string) (ReportFile, error) {
+	format, path, found := strings.Cut(value, "=")
+	if !found {
+		path = value
+		switch strings.ToLower(filepath.Ext(value)) {
+		case ".json":
+			format = "json"
+		case ".html", ".htm":
+			format = "html"
+		case ".prom":
+			format = "openmetrics"
+		default:
+			return ReportFile{}, fmt.Errorf("cannot tell the report format of %q, use json=%s, html=%s, or openmetrics=%s", value, value, value, value)
+		}
+	}
+	if format != "json" && format != "html" && format != "openmetrics" {
+		return ReportFile{}, fmt.Errorf("unsupported report format '%s' (expected json, html, or openmetrics)", format)
+	}
+	if path == "" {
+		return ReportFile{}, fmt.Errorf("missing path for %s report", format)
+	}
+	return ReportFile{Format: format, Path: path}, nil
+}
+
+// WriteReportFile writes a report to its file, replacing any existing one
+func (r *Reporter) WriteReportFile(file ReportFile, summary *models.Summary) error {
+	f, err := os.Create(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	switch file.Format {
+	case "html":
+		err = r.WriteHTMLReport(f, summary)
+	case "openmetrics":
+		err = r.WriteOpenMetricsReport(f, summary)
+	default:
+		err = r.WriteJSONReport(f, summary)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package reporter
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/andrearaponi/bombardino/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReporter_New(t *testing.T) {
@@ -317,3 +319,1054 @@ func captureOutput(f func()) string {
 	io.Copy(&buf, r)
 	return buf.String()
 }
+
+func TestReporter_GenerateReport_ErrorCategories(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:   10,
+		SuccessfulReqs:  5,
+		FailedReqs:      5,
+		StatusCodes:     map[int]int{200: 5, 500: 2},
+		Errors:          map[string]int{"Unexpected status code: 500": 2},
+		ErrorCategories: map[string]int{"timeout": 3, "unexpected_status": 2},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"api": {
+				Name:            "api",
+				TotalRequests:   10,
+				FailedReqs:      5,
+				Errors:          []string{"raw error"},
+				ErrorCategories: map[string]int{"timeout": 3, "unexpected_status": 2},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+
+	assert.Contains(t, output, "ERROR CATEGORIES")
+	assert.Contains(t, output, "timeout:")
+	assert.Contains(t, output, "Error Types: timeout (3), unexpected_status (2)")
+
+	// Raw per-request messages are only part of the JSON report in verbose mode
+	report := New(false).createJSONReport(summary)
+	assert.Nil(t, report.Endpoints["api"].Errors)
+	assert.Equal(t, 3, report.Endpoints["api"].ErrorCategories["timeout"])
+	verboseReport := New(true).createJSONReport(summary)
+	assert.Equal(t, []string{"raw error"}, verboseReport.Endpoints["api"].Errors)
+}
+
+func TestReporter_FailureBudget(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  1000,
+		SuccessfulReqs: 997,
+		FailedReqs:     3,
+		StatusCodes:    map[int]int{200: 997, 502: 3},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"flaky": {
+				Name:               "flaky",
+				TotalRequests:      1000,
+				SuccessfulReqs:     997,
+				FailedReqs:         3,
+				AllowedFailureRate: 0.5,
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "⚠️ flaky")
+	assert.Contains(t, output, "Failure Budget: 0.30% of 0.50% allowed (60% consumed)")
+
+	report := New(false).createJSONReport(summary)
+	assert.True(t, report.Success)
+	assert.True(t, report.Endpoints["flaky"].Success)
+	assert.InDelta(t, 60, report.Endpoints["flaky"].BudgetConsumed, 0.001)
+}
+
+func TestReporter_Scenarios(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  30,
+		SuccessfulReqs: 29,
+		FailedReqs:     1,
+		StatusCodes:    map[int]int{200: 29, 500: 1},
+		ScenarioResults: map[string]*models.ScenarioSummary{
+			"browsers": {Name: "browsers", TotalRequests: 20, SuccessfulReqs: 20, AvgResponseTime: 10 * time.Millisecond, RequestsPerSec: 40},
+			"checkout": {Name: "checkout", TotalRequests: 10, SuccessfulReqs: 9, FailedReqs: 1, AvgResponseTime: 50 * time.Millisecond, RequestsPerSec: 5},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "🎬 SCENARIOS")
+	assert.Contains(t, output, "Requests: 10 (✅ 9, ❌ 1) - 90.0% success")
+	assert.Less(t, strings.Index(output, "• browsers"), strings.Index(output, "• checkout"))
+
+	report := New(false).createJSONReport(summary)
+	require.Len(t, report.Scenarios, 2)
+	assert.Equal(t, 20, report.Scenarios["browsers"].TotalRequests)
+	assert.InDelta(t, 90, report.Scenarios["checkout"].SuccessRate, 0.001)
+	assert.Equal(t, "50ms", report.Scenarios["checkout"].AvgResponseTime)
+}
+
+func TestReporter_Phases(t *testing.T) {
+	start := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	summary := &models.Summary{
+		TotalRequests:  6,
+		SuccessfulReqs: 4,
+		SkippedReqs:    2,
+		StatusCodes:    map[int]int{200: 4},
+		PhaseResults: []*models.PhaseSummary{
+			{Phase: 1, Tests: []string{"Login"}, StartTime: start, EndTime: start.Add(100 * time.Millisecond), Duration: 100 * time.Millisecond,
+				TotalRequests: 2, SuccessfulReqs: 2, AvgResponseTime: 40 * time.Millisecond, RequestsPerSec: 20},
+			{Phase: 2, Tests: []string{"Get Profile", "Get Settings"}, StartTime: start.Add(100 * time.Millisecond), EndTime: start.Add(1100 * time.Millisecond), Duration: time.Second,
+				TotalRequests: 2, SuccessfulReqs: 2, AvgResponseTime: 900 * time.Millisecond, RequestsPerSec: 2},
+			{Phase: 3, Tests: []string{"Save Settings"}, TotalRequests: 2, SkippedReqs: 2},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "🔗 DAG PHASES")
+	assert.Contains(t, output, "• Phase 2: Get Profile, Get Settings")
+	assert.Contains(t, output, "Window: +100ms → +1.1s | Duration: 1s")
+	assert.Contains(t, output, "Skipped: 2 (dependency failed)")
+
+	report := New(false).createJSONReport(summary)
+	require.Len(t, report.Phases, 3)
+	assert.Equal(t, "1s", report.Phases[1].Duration)
+	assert.Equal(t, "2024-01-02T12:00:00.1Z", report.Phases[1].StartTime)
+	assert.Equal(t, 2.0, report.Phases[1].RequestsPerSec)
+	assert.Empty(t, report.Phases[2].StartTime)
+	assert.Equal(t, 2, report.Phases[2].SkippedReqs)
+}
+
+func TestReporter_LatencyHistogram(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  8,
+		SuccessfulReqs: 8,
+		StatusCodes:    map[int]int{200: 8},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"search": {
+				Name:           "search",
+				TotalRequests:  8,
+				SuccessfulReqs: 8,
+				LatencyHistogram: []models.LatencyBucket{
+					{UpperBound: 10 * time.Millisecond},
+					{UpperBound: 25 * time.Millisecond},
+					{UpperBound: 50 * time.Millisecond, Count: 4},
+					{UpperBound: 100 * time.Millisecond},
+					{UpperBound: time.Second, Count: 2},
+					{Count: 2},
+				},
+			},
+		},
+	}
+
+	report := New(false).createJSONReport(summary)
+	histogram := report.Endpoints["search"].LatencyHistogram
+	require.Len(t, histogram, 4)
+	assert.Equal(t, JSONBucket{Range: "25ms-50ms", Count: 4, Percent: 50}, histogram[0])
+	assert.Equal(t, "50ms-100ms", histogram[1].Range)
+	assert.Equal(t, 0, histogram[1].Count)
+	assert.Equal(t, "100ms-1s", histogram[2].Range)
+	assert.Equal(t, JSONBucket{Range: ">=1s", Count: 2, Percent: 25}, histogram[3])
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Latency Distribution:")
+	assert.Contains(t, output, "25ms-50ms     "+strings.Repeat("█", 30)+" 4 (50.0%)")
+	assert.Contains(t, output, "100ms-1s      "+strings.Repeat("█", 15))
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Latency Distribution")
+	assert.Contains(t, html, "&gt;=1s")
+}
+
+func TestHistogramBuckets_Empty(t *testing.T) {
+	assert.Nil(t, histogramBuckets(nil))
+	assert.Nil(t, histogramBuckets([]models.LatencyBucket{{UpperBound: time.Millisecond}, {}}))
+}
+
+func TestReporter_Transfer(t *testing.T) {
+	transfer := models.TransferStats{Requests: 4, BytesSent: 2000, BytesReceived: 6_000_000}
+	summary := &models.Summary{
+		TotalRequests:  4,
+		SuccessfulReqs: 4,
+		TotalTime:      2 * time.Second,
+		StatusCodes:    map[int]int{200: 4},
+		Transfer:       transfer,
+		EndpointResults: map[string]*models.EndpointSummary{
+			"export": {Name: "export", TotalRequests: 4, SuccessfulReqs: 4, Transfer: transfer},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Data Sent:           2.0 KB (avg 500 B/request, 0.00 MB/s)")
+	assert.Contains(t, output, "Data Received:       6.0 MB (avg 1.5 MB/response, 3.00 MB/s)")
+	assert.Contains(t, output, "Data: Sent=2.0 KB (avg 500 B) | Received=6.0 MB (avg 1.5 MB) | 3.00 MB/s")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Summary.Transfer)
+	assert.Equal(t, int64(6_000_000), report.Summary.Transfer.BytesReceived)
+	assert.Equal(t, 1_500_000.0, report.Summary.Transfer.AvgResponseSize)
+	assert.InDelta(t, 3, report.Summary.Transfer.ReceivedMBPerSec, 1e-9)
+	assert.InDelta(t, 0.001, report.Endpoints["export"].Transfer.SentMBPerSec, 1e-9)
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Data Transfer")
+	assert.Contains(t, html, "6.0 MB in, 2.0 KB out")
+
+	assert.Nil(t, New(false).createJSONReport(&models.Summary{}).Summary.Transfer)
+	assert.Nil(t, report.Summary.Transfer.Compression)
+}
+
+func TestReporter_Compression(t *testing.T) {
+	transfer := models.TransferStats{Requests: 4, BytesReceived: 1_000_000, Compressed: 4, BytesCompressed: 1_000_000, BytesDecoded: 4_000_000, DecodeTime: 8 * time.Millisecond}
+	summary := &models.Summary{
+		TotalRequests:  4,
+		SuccessfulReqs: 4,
+		TotalTime:      time.Second,
+		StatusCodes:    map[int]int{200: 4},
+		Transfer:       transfer,
+		EndpointResults: map[string]*models.EndpointSummary{
+			"export": {Name: "export", TotalRequests: 4, SuccessfulReqs: 4, Transfer: transfer},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Compression:         4 responses, 4.0 MB decoded from 1.0 MB (75% saved) | Decode Avg=2ms")
+	assert.Contains(t, output, "   Compression: 4 responses")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Summary.Transfer.Compression)
+	assert.Equal(t, &JSONCompression{Responses: 4, BytesCompressed: 1_000_000, BytesDecoded: 4_000_000, SavedPercent: 75, AvgDecodeTime: "2ms"}, report.Summary.Transfer.Compression)
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "75% saved by compression")
+}
+
+func TestReporter_StatusClassLatency(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  10,
+		SuccessfulReqs: 7,
+		FailedReqs:     3,
+		StatusCodes:    map[int]int{200: 7, 503: 3},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"orders": {
+				Name:           "orders",
+				TotalRequests:  10,
+				SuccessfulReqs: 7,
+				FailedReqs:     3,
+				StatusClassLatency: map[string]*models.LatencySummary{
+					"5xx": {Requests: 3, AvgResponseTime: 2 * time.Millisecond, P50ResponseTime: 2 * time.Millisecond, P95ResponseTime: 3 * time.Millisecond, P99ResponseTime: 3 * time.Millisecond},
+					"2xx": {Requests: 7, AvgResponseTime: 400 * time.Millisecond, P50ResponseTime: 380 * time.Millisecond, P95ResponseTime: 900 * time.Millisecond, P99ResponseTime: time.Second},
+				},
+			},
+			"health": {
+				Name:               "health",
+				TotalRequests:      1,
+				SuccessfulReqs:     1,
+				StatusClassLatency: map[string]*models.LatencySummary{"2xx": {Requests: 1}},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Equal(t, 1, strings.Count(output, "By Status Class:"), "only shown with more than one class")
+	assert.Contains(t, output, "2xx (7): Avg=400ms | P50=380ms | P95=900ms | P99=1s")
+	assert.Less(t, strings.Index(output, "2xx (7)"), strings.Index(output, "5xx (3)"))
+
+	report := New(false).createJSONReport(summary)
+	classes := report.Endpoints["orders"].StatusClasses
+	require.Len(t, classes, 2)
+	assert.Equal(t, JSONLatency{Requests: 3, AvgResponseTime: "2ms", P50ResponseTime: "2ms", P95ResponseTime: "3ms", P99ResponseTime: "3ms"}, classes["5xx"])
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Equal(t, 1, strings.Count(html, "Latency by Status Class"))
+}
+
+func TestReporter_SteadyState(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  100,
+		SuccessfulReqs: 100,
+		StatusCodes:    map[int]int{200: 100},
+		SteadyState: &models.SteadyStateSummary{
+			Reached: true,
+			Start:   20 * time.Second,
+			Ramp: models.PeriodSummary{
+				LatencySummary: models.LatencySummary{Requests: 20, AvgResponseTime: 250 * time.Millisecond, P95ResponseTime: 800 * time.Millisecond},
+				Duration:       20 * time.Second,
+				RequestsPerSec: 1,
+			},
+			Steady: models.PeriodSummary{
+				LatencySummary: models.LatencySummary{Requests: 80, AvgResponseTime: 90 * time.Millisecond, P95ResponseTime: 120 * time.Millisecond},
+				Duration:       40 * time.Second,
+				RequestsPerSec: 2,
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Reached after 20s")
+	assert.Contains(t, output, "Steady: 80 requests")
+	assert.Contains(t, output, "P95: 120ms")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.SteadyState)
+	assert.Equal(t, "20s", report.SteadyState.Start)
+	assert.Equal(t, "800ms", report.SteadyState.Ramp.P95ResponseTime)
+	require.NotNil(t, report.SteadyState.Steady)
+	assert.Equal(t, 2.0, report.SteadyState.Steady.RequestsPerSec)
+
+	summary.SteadyState = &models.SteadyStateSummary{Ramp: summary.SteadyState.Ramp}
+	report = New(false).createJSONReport(summary)
+	assert.Nil(t, report.SteadyState.Steady)
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Steady State (not reached)")
+}
+
+func TestReporter_ScheduleLag(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:   10,
+		SuccessfulReqs:  10,
+		StatusCodes:     map[int]int{200: 10},
+		P95ResponseTime: 20 * time.Millisecond,
+		ScheduleLag: &models.ScheduleLagSummary{
+			LateRequests: 3,
+			AvgLag:       150 * time.Millisecond,
+			MaxLag:       400 * time.Millisecond,
+			Corrected:    models.LatencySummary{Requests: 10, AvgResponseTime: 60 * time.Millisecond, P95ResponseTime: 410 * time.Millisecond},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Schedule Lag:        3 late requests | Avg=150ms | Max=400ms")
+	assert.Contains(t, output, "P95=410ms")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Summary.ScheduleLag)
+	assert.Equal(t, "400ms", report.Summary.ScheduleLag.MaxLag)
+	assert.Equal(t, "410ms", report.Summary.ScheduleLag.Corrected.P95ResponseTime)
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Schedule Lag: 3 late requests")
+
+	summary.ScheduleLag = nil
+	assert.Nil(t, New(false).createJSONReport(summary).Summary.ScheduleLag)
+}
+
+func TestReporter_Generator(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  10,
+		SuccessfulReqs: 10,
+		StatusCodes:    map[int]int{200: 10},
+		Generator: &models.GeneratorSummary{
+			CPUs:               4,
+			AvgCPUPercent:      72.5,
+			MaxCPUPercent:      96.1,
+			MaxHeapBytes:       48_000_000,
+			MaxMemoryBytes:     96_000_000,
+			GCCount:            12,
+			GCPauseTotal:       3 * time.Millisecond,
+			MaxGoroutines:      130,
+			MaxOpenFiles:       140,
+			OpenFilesLimit:     1024,
+			MaxEphemeralPorts:  120,
+			EphemeralPortRange: 28232,
+			Warnings:           []string{"CPU usage peaked at 96% of 4 CPUs"},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "CPU:                 Avg=72.5% | Max=96.1% of 4 CPUs")
+	assert.Contains(t, output, "Heap=48.0 MB")
+	assert.Contains(t, output, "Ephemeral Ports:     120 of 28232")
+	assert.Contains(t, output, "CPU usage peaked at 96% of 4 CPUs")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Generator)
+	assert.Equal(t, int64(48_000_000), report.Generator.MaxHeapBytes)
+	assert.Equal(t, "3ms", report.Generator.GCPauseTotal)
+	assert.Len(t, report.Generator.Warnings, 1)
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Load Generator")
+	assert.Contains(t, html, "CPU usage peaked at 96% of 4 CPUs")
+}
+
+func TestReporter_SlowestRequests(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  2,
+		SuccessfulReqs: 1,
+		FailedReqs:     1,
+		StatusCodes:    map[int]int{200: 1},
+		SlowestRequests: []models.TestResult{
+			{
+				TestName:     "Search",
+				Method:       "GET",
+				URL:          "https://api.example.com/search",
+				StatusCode:   200,
+				ResponseTime: 2 * time.Second,
+				Timestamp:    time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+				Timing:       &models.RequestTiming{DNS: time.Millisecond, Connect: 2 * time.Millisecond, TLS: 10 * time.Millisecond, Wait: 1980 * time.Millisecond, Transfer: 7 * time.Millisecond},
+				BodySample:   `{"results": []}`,
+			},
+			{
+				TestName:     "Export",
+				Method:       "POST",
+				URL:          "https://api.example.com/export",
+				ResponseTime: time.Second,
+				Error:        "context deadline exceeded",
+				RequestID:    "5f0c6a1e-43c2-4d7e-9a50-1b0d2c7e8f11",
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(true).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "🐢 SLOWEST REQUESTS")
+	assert.Contains(t, output, " 1. 2s  Search → 200")
+	assert.Contains(t, output, "DNS=1ms | Connect=2ms | TLS=10ms | Wait=1.98s | Transfer=7ms")
+	assert.Contains(t, output, `Body: {"results": []}`)
+	assert.Contains(t, output, " 2. 1s  Export → no response")
+	assert.Contains(t, output, "Error: context deadline exceeded")
+	assert.Contains(t, output, "Request ID: 5f0c6a1e-43c2-4d7e-9a50-1b0d2c7e8f11")
+
+	quiet := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.NotContains(t, quiet, "SLOWEST REQUESTS")
+
+	report := New(true).createJSONReport(summary)
+	require.Len(t, report.Slowest, 2)
+	assert.Equal(t, "2s", report.Slowest[0].ResponseTime)
+	assert.Equal(t, "1.98s", report.Slowest[0].Timing.Wait)
+	assert.Nil(t, report.Slowest[1].Timing)
+	assert.Equal(t, "5f0c6a1e-43c2-4d7e-9a50-1b0d2c7e8f11", report.Slowest[1].RequestID)
+	assert.Empty(t, New(false).createJSONReport(summary).Slowest)
+}
+
+func TestReporter_FailureSamples(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  3,
+		SuccessfulReqs: 1,
+		FailedReqs:     2,
+		StatusCodes:    map[int]int{200: 1, 503: 1, 0: 1},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"Checkout": {
+				Name:           "Checkout",
+				URL:            "https://api.example.com/checkout",
+				TotalRequests:  3,
+				SuccessfulReqs: 1,
+				FailedReqs:     2,
+				StatusCodes:    map[int]int{200: 1, 503: 1, 0: 1},
+				FailureSamples: []models.TestResult{
+					{
+						StatusCode:    503,
+						ErrorCategory: "unexpected_status",
+						Error:         "Unexpected status code: 503 (expected: [200])",
+						BodySample:    `{"error": "payment provider down"}`,
+						RequestID:     "5f0c6a1e-43c2-4d7e-9a50-1b0d2c7e8f11",
+						Timestamp:     time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+					},
+					{
+						ErrorCategory: "timeout",
+						Error:         "context deadline exceeded",
+						Timestamp:     time.Date(2024, 1, 2, 12, 0, 1, 0, time.UTC),
+					},
+				},
+			},
+			"Health": {Name: "Health", TotalRequests: 1, SuccessfulReqs: 1, StatusCodes: map[int]int{200: 1}},
+		},
+	}
+
+	endpoints := New(false).createJSONReport(summary).Endpoints
+	assert.Empty(t, endpoints["Health"].FailureSamples)
+	samples := endpoints["Checkout"].FailureSamples
+	require.Len(t, samples, 2)
+	assert.Equal(t, JSONFailureSample{
+		StatusCode:    503,
+		ErrorCategory: "unexpected_status",
+		Error:         "Unexpected status code: 503 (expected: [200])",
+		Body:          `{"error": "payment provider down"}`,
+		RequestID:     "5f0c6a1e-43c2-4d7e-9a50-1b0d2c7e8f11",
+		Timestamp:     "2024-01-02T12:00:00Z",
+	}, samples[0])
+	assert.Equal(t, "timeout", samples[1].ErrorCategory)
+	assert.Zero(t, samples[1].StatusCode)
+
+	data, err := json.Marshal(endpoints["Checkout"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"failure_samples":[{"status_code":503,"error_category":"unexpected_status"`)
+}
+
+func TestReporter_AssertionBreakdown(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:    3,
+		SuccessfulReqs:   1,
+		FailedReqs:       2,
+		StatusCodes:      map[int]int{200: 3},
+		TotalAssertions:  6,
+		AssertionsPassed: 4,
+		AssertionsFailed: 2,
+		EndpointResults: map[string]*models.EndpointSummary{
+			"Job": {
+				Name:             "Job",
+				TotalRequests:    3,
+				SuccessfulReqs:   1,
+				FailedReqs:       2,
+				StatusCodes:      map[int]int{200: 3},
+				TotalAssertions:  6,
+				AssertionsPassed: 4,
+				AssertionsFailed: 2,
+				Assertions: []models.AssertionSummary{
+					{Assertion: models.Assertion{Type: "status", Operator: "eq", Value: 200.0}, Passed: 3},
+					{
+						Assertion: models.Assertion{Type: "json_path", Target: "state", Operator: "eq", Value: "done"},
+						Passed:    1,
+						Failed:    2,
+						Messages:  []string{"assertion failed: state eq done, got pending"},
+						Context:   "body = {\n  \"state\": \"pending\"\n}",
+					},
+				},
+			},
+		},
+	}
+
+	assertions := New(false).createJSONReport(summary).Endpoints["Job"].Assertions
+	require.Len(t, assertions, 2)
+	assert.Equal(t, JSONAssertion{Type: "status", Operator: "eq", Expected: 200.0, Passed: 3}, assertions[0])
+	assert.Equal(t, JSONAssertion{
+		Type:     "json_path",
+		Target:   "state",
+		Operator: "eq",
+		Expected: "done",
+		Passed:   1,
+		Failed:   2,
+		Messages: []string{"assertion failed: state eq done, got pending"},
+		Context:  "body = {\n  \"state\": \"pending\"\n}",
+	}, assertions[1])
+	assert.Equal(t, "json_path state eq done", formatAssertion(assertions[1]))
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "status eq 200")
+	assert.Contains(t, html, "json_path state eq done")
+	assert.Contains(t, html, "✓ 1 · ✗ 2")
+	assert.Contains(t, html, "assertion failed: state eq done, got pending")
+	assert.Contains(t, html, `<pre class="assertion-context">body = {
+  &#34;state&#34;: &#34;pending&#34;
+}</pre>`)
+
+	text := captureOutput(func() {
+		New(true).printEndpointResults(summary)
+	})
+	assert.Contains(t, text, "✗ json_path state eq done (2 failed)")
+	assert.Contains(t, text, "│   \"state\": \"pending\"")
+}
+
+func TestReporter_HTMLSelfContained(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  1,
+		SuccessfulReqs: 1,
+		StatusCodes:    map[int]int{200: 1},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"Health": {Name: "Health", TotalRequests: 1, SuccessfulReqs: 1, StatusCodes: map[int]int{200: 1}},
+		},
+	}
+
+	var html bytes.Buffer
+	require.NoError(t, New(false).WriteHTMLReport(&html, summary))
+	report := html.String()
+
+	// No stylesheets, scripts, fonts, or images are fetched
+	for _, external := range []string{"<link", "<script src", "<img", "@import", "url("} {
+		assert.NotContains(t, report, external)
+	}
+	assert.Equal(t, 1, strings.Count(report, "://"), "only the project link in the footer")
+
+	assert.Contains(t, report, `<button class="theme-toggle" onclick="toggleTheme()"`)
+	assert.Contains(t, report, `[data-theme="light"]`)
+	assert.Contains(t, report, "prefers-color-scheme: light")
+	assert.Contains(t, report, "localStorage")
+	assert.Contains(t, report, "@media print")
+}
+
+func TestReporter_Metadata(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  1,
+		SuccessfulReqs: 1,
+		StatusCodes:    map[int]int{200: 1},
+		Metadata: &models.RunMetadata{
+			ConfigName: "Checkout API",
+			ConfigFile: "checkout.json",
+			ConfigHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			Version:    "1.4.0",
+			Commit:     "abc1234",
+			Hostname:   "ci-runner-3",
+			StartedAt:  time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+			FinishedAt: time.Date(2024, 1, 2, 12, 5, 0, 0, time.UTC),
+			Args:       []string{"-config=checkout.json", "-header", "Authorization: ***"},
+			Labels:     map[string]string{"team": "checkout", "env": "staging"},
+		},
+	}
+
+	meta := New(false).createJSONReport(summary).Metadata
+	require.NotNil(t, meta)
+	assert.Equal(t, JSONMetadata{
+		ConfigName: "Checkout API",
+		ConfigFile: "checkout.json",
+		ConfigHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Version:    "1.4.0",
+		Commit:     "abc1234",
+		Hostname:   "ci-runner-3",
+		StartedAt:  "2024-01-02T12:00:00Z",
+		FinishedAt: "2024-01-02T12:05:00Z",
+		Args:       []string{"-config=checkout.json", "-header", "Authorization: ***"},
+		Labels:     map[string]string{"team": "checkout", "env": "staging"},
+	}, *meta)
+	assert.Nil(t, New(false).createJSONReport(&models.Summary{}).Metadata)
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "🧾 RUN")
+	assert.Contains(t, output, "Config:              Checkout API · checkout.json (sha256 9f86d081884c)")
+	assert.Contains(t, output, "Started:             2024-01-02T12:00:00Z")
+	assert.Contains(t, output, "Host:                ci-runner-3")
+	assert.Contains(t, output, "Labels:              env=staging, team=checkout")
+	assert.Contains(t, output, "Arguments:           -config=checkout.json -header Authorization: ***")
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "checkout.json · 9f86d081884c")
+	assert.Contains(t, html, "on ci-runner-3")
+	assert.Contains(t, html, `<span class="run-label">env=staging</span>`)
+	assert.Contains(t, html, "bombardino -config=checkout.json -header Authorization: ***")
+}
+
+func TestReporter_RunID(t *testing.T) {
+	summary := &models.Summary{
+		RunID:          "ci-4242",
+		TotalRequests:  1,
+		SuccessfulReqs: 1,
+		StatusCodes:    map[int]int{200: 1},
+	}
+
+	assert.Equal(t, "ci-4242", New(false).createJSONReport(summary).RunID)
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Run ID:              ci-4242")
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "run ci-4242")
+}
+
+func TestReporter_Targets(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  8,
+		SuccessfulReqs: 2,
+		FailedReqs:     6,
+		StatusCodes:    map[int]int{200: 2, 500: 6},
+		TargetResults: []*models.TargetSummary{
+			{URL: "https://eu.example.com", Weight: 1, TotalRequests: 2, SuccessfulReqs: 2, AvgResponseTime: 20 * time.Millisecond, P95ResponseTime: 30 * time.Millisecond, StatusCodes: map[int]int{200: 2}},
+			{URL: "https://us.example.com", Weight: 3, TotalRequests: 6, FailedReqs: 6, AvgResponseTime: 90 * time.Millisecond, P95ResponseTime: 120 * time.Millisecond, StatusCodes: map[int]int{500: 6}},
+		},
+	}
+
+	targets := New(false).createJSONReport(summary).Targets
+	require.Len(t, targets, 2)
+	assert.Equal(t, JSONTarget{
+		URL:             "https://us.example.com",
+		Weight:          3,
+		TotalRequests:   6,
+		FailedReqs:      6,
+		AvgResponseTime: "90ms",
+		P95ResponseTime: "120ms",
+		StatusCodes:     map[string]int{"500": 6},
+	}, targets[1])
+	assert.Equal(t, 100.0, targets[0].SuccessRate)
+	assert.Nil(t, New(false).createJSONReport(&models.Summary{}).Targets)
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "TARGETS")
+	assert.Contains(t, output, "• https://us.example.com (weight 3)")
+	assert.Contains(t, output, "Avg: 90ms | P95: 120ms | Status: 500=6")
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, `<div class="endpoint-name">https://us.example.com</div>`)
+	assert.Contains(t, html, "weight 3")
+}
+
+func TestParseReportFile(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ReportFile
+		wantErr string
+	}{
+		{value: "json=out/report.json", want: ReportFile{Format: "json", Path: "out/report.json"}},
+		{value: "html=report.txt", want: ReportFile{Format: "html", Path: "report.txt"}},
+		{value: "report.JSON", want: ReportFile{Format: "json", Path: "report.JSON"}},
+		{value: "report.htm", want: ReportFile{Format: "html", Path: "report.htm"}},
+		{value: "perf.prom", want: ReportFile{Format: "openmetrics", Path: "perf.prom"}},
+		{value: "openmetrics=metrics.txt", want: ReportFile{Format: "openmetrics", Path: "metrics.txt"}},
+		{value: "report.txt", wantErr: "cannot tell the report format"},
+		{value: "text=report.txt", wantErr: "unsupported report format 'text'"},
+		{value: "json=", wantErr: "missing path for json report"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseReportFile(tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReporter_WriteReportFile(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  2,
+		SuccessfulReqs: 2,
+		StatusCodes:    map[int]int{200: 2},
+	}
+	dir := t.TempDir()
+	jsonPath := dir + "/report.json"
+	htmlPath := dir + "/report.html"
+
+	output := captureOutput(func() {
+		require.NoError(t, New(false).WriteReportFile(ReportFile{Format: "json", Path: jsonPath}, summary))
+		require.NoError(t, New(false).WriteReportFile(ReportFile{Format: "html", Path: htmlPath}, summary))
+	})
+	assert.Empty(t, output, "nothing is written to stdout")
+
+	data, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"total_requests": 2`)
+
+	data, err = os.ReadFile(htmlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<html")
+
+	err = New(false).WriteReportFile(ReportFile{Format: "json", Path: dir + "/missing/report.json"}, summary)
+	assert.ErrorContains(t, err, "failed to create report file")
+}
+
+func TestReporter_Quiet(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  10,
+		SuccessfulReqs: 9,
+		FailedReqs:     1,
+		StatusCodes:    map[int]int{200: 9, 500: 1},
+		Errors:         map[string]int{"Unexpected status code: 500": 1},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"orders": {Name: "orders", TotalRequests: 10, SuccessfulReqs: 9, FailedReqs: 1},
+		},
+	}
+
+	reporter := New(false)
+	reporter.SetQuiet(true)
+	output := captureOutput(func() {
+		reporter.GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Total Requests:      10")
+	assert.NotContains(t, output, "BOMBARDINO RESULTS")
+	assert.NotContains(t, output, "STATUS CODES")
+	assert.NotContains(t, output, "ENDPOINT RESULTS")
+	assert.NotContains(t, output, "ERRORS")
+}
+
+func TestReporter_Plain(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:      10,
+		SuccessfulReqs:     9,
+		FailedReqs:         1,
+		StatusCodes:        map[int]int{200: 9, 500: 1},
+		Errors:             map[string]int{"Unexpected status code: 500": 1},
+		ErrorCategories:    map[string]int{"unexpected_status": 1},
+		MaxDurationReached: true,
+		ScenarioResults: map[string]*models.ScenarioSummary{
+			"browse": {Name: "browse", TotalRequests: 10, SuccessfulReqs: 9, FailedReqs: 1},
+		},
+		EndpointResults: map[string]*models.EndpointSummary{
+			"orders": {Name: "orders", TotalRequests: 10, SuccessfulReqs: 9, FailedReqs: 1},
+		},
+	}
+
+	reporter := New(false)
+	reporter.SetPlain(true)
+	output := captureOutput(func() {
+		reporter.GenerateReport(summary)
+	})
+	assert.Contains(t, output, "\nSUMMARY\n")
+	assert.Contains(t, output, "WARNING: Run stopped early")
+	assert.Contains(t, output, "\n200:              9 (90.0%)")
+	assert.Contains(t, output, "Requests: 10 (passed 9, failed 1)")
+	assert.Contains(t, output, "[FAIL] orders")
+	for _, emoji := range []string{"📊", "✅", "❌", "⚠️", "📈", "🎬", "🎯", "🏷️", "🚀"} {
+		assert.NotContains(t, output, emoji)
+	}
+}
+
+func TestReporter_Interrupted(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  3,
+		SuccessfulReqs: 3,
+		StatusCodes:    map[int]int{200: 3},
+		Interrupted:    true,
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Run interrupted: results are partial")
+
+	report := New(false).createJSONReport(summary)
+	assert.True(t, report.Summary.Interrupted)
+	assert.False(t, report.Success)
+}
+
+func TestReporter_Autoscale(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  100,
+		SuccessfulReqs: 100,
+		StatusCodes:    map[int]int{200: 100},
+		Autoscale: &models.AutoscaleSummary{
+			TargetRPS:   200,
+			MaxLatency:  100 * time.Millisecond,
+			Workers:     8,
+			PeakWorkers: 10,
+			Steps: []models.AutoscaleStep{
+				{Elapsed: 5 * time.Second, Workers: 4, RPS: 98.5, P95: 40 * time.Millisecond, Next: 8},
+				{Elapsed: 10 * time.Second, Workers: 8, RPS: 199.2, P95: 60 * time.Millisecond, Met: true, Next: 8},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(true).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Goal: 200.0 req/s, P95 under 100ms")
+	assert.Contains(t, output, "Workers needed: 8 (peak 10)")
+	assert.Contains(t, output, "[    5s] 4 workers | 98.5 req/s | P95 40ms -> 8 workers")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Autoscale)
+	assert.Equal(t, 8, report.Autoscale.Workers)
+	assert.Equal(t, "100ms", report.Autoscale.MaxLatency)
+	require.Len(t, report.Autoscale.Steps, 2)
+	assert.Equal(t, JSONAutoscaleStep{Elapsed: "10s", Workers: 8, RequestsPerSec: 199.2, P95ResponseTime: "60ms", Met: true, NextWorkers: 8}, report.Autoscale.Steps[1])
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "8 workers needed")
+
+	summary.Autoscale = &models.AutoscaleSummary{TargetRPS: 5000, PeakWorkers: 1000}
+	output = captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Goal never met (peak 1000 workers)")
+}
+
+func TestReporter_Resumed(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  60,
+		SuccessfulReqs: 60,
+		StatusCodes:    map[int]int{200: 60},
+		RunID:          "run-1",
+		Seed:           42,
+		Resumed:        &models.ResumeSummary{Elapsed: 90 * time.Second, Iterations: 40},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Seed:                42")
+	assert.Contains(t, output, "Resumed:             after 1m30s and 40 iterations; statistics cover the rest of the run")
+
+	report := New(false).createJSONReport(summary)
+	assert.Equal(t, &JSONResumed{Elapsed: "1m30s", Iterations: 40}, report.Summary.Resumed)
+	assert.Equal(t, int64(42), report.Seed)
+}
+
+func TestReporter_Replay(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  1200,
+		SuccessfulReqs: 1200,
+		StatusCodes:    map[int]int{200: 1200},
+		Replay:         &models.ReplaySummary{File: "capture.jsonl", Speed: 2, Requests: 1200, Span: 10 * time.Minute},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Replayed:            1200 requests captured over 10m0s in capture.jsonl, at 2x speed")
+
+	report := New(false).createJSONReport(summary)
+	assert.Equal(t, &JSONReplay{File: "capture.jsonl", Speed: 2, Requests: 1200, Span: "10m0s"}, report.Summary.Replay)
+}
+
+func TestReporter_Capacity(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  300,
+		SuccessfulReqs: 290,
+		FailedReqs:     10,
+		StatusCodes:    map[int]int{200: 290, 503: 10},
+		Capacity: &models.CapacitySummary{
+			MaxErrorRate: 1,
+			MaxP95:       200 * time.Millisecond,
+			MaxRPS:       150,
+			BreakingRPS:  200,
+			Stages: []models.CapacityStage{
+				{RPS: 100, AchievedRPS: 99.8, Requests: 100, P95: 40 * time.Millisecond, Passed: true},
+				{RPS: 200, AchievedRPS: 180.2, Requests: 100, ErrorRate: 10, P95: 90 * time.Millisecond, Problem: "error rate 10.00% over 1.00%"},
+				{RPS: 150, AchievedRPS: 149.9, Requests: 100, P95: 60 * time.Millisecond, Passed: true},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Thresholds: error rate up to 1.00%, P95 up to 200ms")
+	assert.Contains(t, output, "Max sustainable rate: 150.0 req/s")
+	assert.Contains(t, output, "Breaking point: 200.0 req/s")
+	assert.Contains(t, output, "200.0 req/s: 180.2 req/s reached | 100 requests | errors 10.00% | P95 90ms ❌ error rate 10.00% over 1.00%")
+
+	report := New(false).createJSONReport(summary)
+	require.NotNil(t, report.Capacity)
+	assert.Equal(t, 150.0, report.Capacity.MaxRPS)
+	assert.Equal(t, 200.0, report.Capacity.BreakingRPS)
+	assert.Equal(t, "200ms", report.Capacity.MaxP95)
+	require.Len(t, report.Capacity.Stages, 3)
+	assert.Equal(t, JSONCapacityStage{TargetRPS: 150, RequestsPerSec: 149.9, Requests: 100, P95ResponseTime: "60ms", Passed: true}, report.Capacity.Stages[2])
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "150.0 req/s sustained")
+
+	summary.Capacity = &models.CapacitySummary{MaxErrorRate: 1, BreakingRPS: 10}
+	output = captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "No rate sustained, not even start_rps")
+}
+
+func TestReporter_SLOAlerts(t *testing.T) {
+	raised := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	summary := &models.Summary{
+		TotalRequests: 100,
+		FailedReqs:    40,
+		StatusCodes:   map[int]int{500: 40},
+		AbortedBy:     "errors",
+		SLOAlerts: []models.SLOAlert{
+			{SLO: "latency", Time: raised, BurnRate: 2.5, PeakBurnRate: 4, BadRequests: 5, Requests: 20, RecoveredAt: raised.Add(1500 * time.Millisecond)},
+			{SLO: "errors", Time: raised.Add(2 * time.Second), BurnRate: 40, PeakBurnRate: 40, BadRequests: 20, Requests: 50},
+		},
+	}
+
+	report := New(false).createJSONReport(summary)
+	assert.Equal(t, "errors", report.Summary.AbortedBy)
+	assert.False(t, report.Success)
+	require.Len(t, report.SLOAlerts, 2)
+	assert.Equal(t, JSONSLOAlert{
+		SLO:          "latency",
+		Time:         "2024-01-01T12:00:00Z",
+		BurnRate:     2.5,
+		PeakBurnRate: 4,
+		BadRequests:  5,
+		Requests:     20,
+		RecoveredAt:  "2024-01-01T12:00:01.5Z",
+	}, report.SLOAlerts[0])
+	assert.Empty(t, report.SLOAlerts[1].RecoveredAt)
+	assert.Nil(t, New(false).createJSONReport(&models.Summary{}).SLOAlerts)
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Run stopped early: SLO errors burn rate exceeded")
+	assert.Contains(t, output, "SLO ALERTS")
+	assert.Contains(t, output, "Burn rate: 2.5 (peak 4.0) | Bad requests: 5 of 20 in the window")
+	assert.Contains(t, output, "Recovered after 1.5s")
+	assert.Contains(t, output, "Still burning at the end of the run")
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "SLO Alerts (run stopped by errors)")
+	assert.Contains(t, html, `<div class="endpoint-name">latency</div>`)
+}
+
+func TestReporter_ReportWindow(t *testing.T) {
+	summary := &models.Summary{
+		TotalRequests:  120,
+		SuccessfulReqs: 120,
+		StatusCodes:    map[int]int{200: 120},
+		ReportWindow:   &models.ReportWindow{Start: 2 * time.Minute, End: 8 * time.Minute},
+		ExcludedReqs:   40,
+	}
+
+	window := New(false).createJSONReport(summary).Summary.ReportWindow
+	assert.Equal(t, &JSONReportWindow{Start: "2m0s", End: "8m0s", ExcludedRequests: 40}, window)
+	summary.ReportWindow.End = 0
+	assert.Equal(t, &JSONReportWindow{Start: "2m0s", ExcludedRequests: 40}, New(false).createJSONReport(summary).Summary.ReportWindow)
+	assert.Nil(t, New(false).createJSONReport(&models.Summary{}).Summary.ReportWindow)
+
+	output := captureOutput(func() {
+		New(false).GenerateReport(summary)
+	})
+	assert.Contains(t, output, "Report Window:       2m0s to end (40 requests excluded)")
+
+	html := captureOutput(func() {
+		require.NoError(t, New(false).GenerateHTMLReport(summary))
+	})
+	assert.Contains(t, html, "Report window 2m0s to end (40 requests excluded)")
+}
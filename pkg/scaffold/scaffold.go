@@ -0,0 +1,239 @@
+// Package scaffold generates starter configuration files for bombardino init.
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Auth styles offered by the wizard
+const (
+	AuthNone   = "none"
+	AuthBearer = "bearer"
+	AuthAPIKey = "api-key"
+	AuthBasic  = "basic"
+)
+
+// Answers holds the choices made in the init wizard
+type Answers struct {
+	Name           string
+	BaseURL        string
+	Auth           string
+	Method         string
+	Path           string
+	ExpectedStatus int
+}
+
+// DefaultAnswers are used for every question left blank
+func DefaultAnswers() Answers {
+	return Answers{
+		Name:           "My API Tests",
+		BaseURL:        "http://localhost:8080",
+		Auth:           AuthNone,
+		Method:         "GET",
+		Path:           "/health",
+		ExpectedStatus: 200,
+	}
+}
+
+// Prompt asks the wizard questions on out and reads the answers from in.
+// Invalid answers are asked again; blank answers keep the default.
+func Prompt(in io.Reader, out io.Writer) (Answers, error) {
+	answers := DefaultAnswers()
+	reader := bufio.NewReader(in)
+
+	questions := []struct {
+		label    string
+		value    *string
+		validate func(string) error
+	}{
+		{"Test suite name", &answers.Name, nil},
+		{"Base URL", &answers.BaseURL, validateBaseURL},
+		{"Auth style (none, bearer, api-key, basic)", &answers.Auth, validateAuth},
+		{"First endpoint method", &answers.Method, nil},
+		{"First endpoint path", &answers.Path, validatePath},
+	}
+
+	for _, q := range questions {
+		value, err := ask(reader, out, q.label, *q.value, q.validate)
+		if err != nil {
+			return answers, err
+		}
+		*q.value = value
+	}
+	answers.Auth = strings.ToLower(answers.Auth)
+	answers.Method = strings.ToUpper(answers.Method)
+
+	status, err := ask(reader, out, "Expected status code", strconv.Itoa(answers.ExpectedStatus), validateStatus)
+	if err != nil {
+		return answers, err
+	}
+	answers.ExpectedStatus, _ = strconv.Atoi(status)
+
+	return answers, nil
+}
+
+// ask prints a question with its default and reads one line
+func ask(reader *bufio.Reader, out io.Writer, label, def string, validate func(string) error) (string, error) {
+	for {
+		fmt.Fprintf(out, "%s [%s]: ", label, def)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return "", fmt.Errorf("input ended before all questions were answered")
+			}
+			return "", err
+		}
+
+		value := strings.TrimSpace(line)
+		if value == "" {
+			value = def
+		}
+		if validate != nil {
+			if verr := validate(value); verr != nil {
+				fmt.Fprintf(out, "  %v\n", verr)
+				if err == io.EOF {
+					return "", verr
+				}
+				continue
+			}
+		}
+		return value, nil
+	}
+}
+
+func validateBaseURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base URL must start with http:// or https://")
+	}
+	return nil
+}
+
+func validateAuth(value string) error {
+	switch strings.ToLower(value) {
+	case AuthNone, AuthBearer, AuthAPIKey, AuthBasic:
+		return nil
+	}
+	return fmt.Errorf("auth style must be one of none, bearer, api-key, basic")
+}
+
+func validatePath(value string) error {
+	if !strings.HasPrefix(value, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	return nil
+}
+
+func validateStatus(value string) error {
+	status, err := strconv.Atoi(value)
+	if err != nil || status < 100 || status > 599 {
+		return fmt.Errorf("status code must be a number between 100 and 599")
+	}
+	return nil
+}
+
+// The starter config is written from these types so fields appear in a
+// readable order with durations as strings
+
+type starterConfig struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Global      starterGlobal `json:"global"`
+	Tests       []starterTest `json:"tests"`
+}
+
+type starterGlobal struct {
+	BaseURL      string                       `json:"base_url"`
+	Timeout      string                       `json:"timeout"`
+	Iterations   int                          `json:"iterations"`
+	ThinkTimeMin string                       `json:"think_time_min"`
+	ThinkTimeMax string                       `json:"think_time_max"`
+	Headers      map[string]string            `json:"headers"`
+	Secrets      map[string]map[string]string `json:"secrets,omitempty"`
+}
+
+type starterTest struct {
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Method         string             `json:"method"`
+	Path           string             `json:"path"`
+	ExpectedStatus []int              `json:"expected_status"`
+	Assertions     []starterAssertion `json:"assertions"`
+	Tags           []string           `json:"tags"`
+}
+
+type starterAssertion struct {
+	Type     string      `json:"type"`
+	Target   string      `json:"target"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// Generate renders a starter config for the given answers. Notes that would
+// be comments in other formats are written into description fields, since
+// JSON has no comments.
+func Generate(answers Answers) ([]byte, error) {
+	description := "Generated by bombardino init. Check it with -t, then raise -workers and global.iterations for real load"
+
+	headers := map[string]string{"Accept": "application/json"}
+	var secrets map[string]map[string]string
+	switch answers.Auth {
+	case AuthBearer:
+		headers["Authorization"] = "Bearer ${api_token}"
+		secrets = map[string]map[string]string{"api_token": {"provider": "env", "key": "API_TOKEN"}}
+		description += ". Export API_TOKEN before running."
+	case AuthAPIKey:
+		headers["X-API-Key"] = "${api_key}"
+		secrets = map[string]map[string]string{"api_key": {"provider": "env", "key": "API_KEY"}}
+		description += ". Export API_KEY before running."
+	case AuthBasic:
+		headers["Authorization"] = "Basic ${basic_credentials}"
+		secrets = map[string]map[string]string{"basic_credentials": {"provider": "env", "key": "BASIC_CREDENTIALS"}}
+		description += ". Export BASIC_CREDENTIALS as base64(user:password) before running."
+	case AuthNone, "":
+	default:
+		return nil, fmt.Errorf("unknown auth style '%s'", answers.Auth)
+	}
+
+	config := starterConfig{
+		Name:        answers.Name,
+		Description: description,
+		Global: starterGlobal{
+			BaseURL:      answers.BaseURL,
+			Timeout:      "30s",
+			Iterations:   10,
+			ThinkTimeMin: "100ms",
+			ThinkTimeMax: "500ms",
+			Headers:      headers,
+			Secrets:      secrets,
+		},
+		Tests: []starterTest{{
+			Name:           fmt.Sprintf("%s %s", answers.Method, answers.Path),
+			Description:    "First endpoint. Add more tests, extract values with \"extract\", and chain them with \"depends_on\".",
+			Method:         answers.Method,
+			Path:           answers.Path,
+			ExpectedStatus: []int{answers.ExpectedStatus},
+			Assertions: []starterAssertion{
+				{Type: "status", Target: "response", Operator: "eq", Value: answers.ExpectedStatus},
+				{Type: "response_time", Target: "response", Operator: "lt", Value: "1s"},
+			},
+			Tags: []string{"smoke"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
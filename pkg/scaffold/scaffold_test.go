@@ -0,0 +1,85 @@
+package scaffold
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrearaponi/bombardino/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrompt(t *testing.T) {
+	input := strings.Join([]string{
+		"Shop API",
+		"ftp://nope", // rejected and asked again
+		"https://api.shop.test",
+		"API-KEY",
+		"post",
+		"/orders",
+		"",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	answers, err := Prompt(strings.NewReader(input), &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, Answers{
+		Name:           "Shop API",
+		BaseURL:        "https://api.shop.test",
+		Auth:           AuthAPIKey,
+		Method:         "POST",
+		Path:           "/orders",
+		ExpectedStatus: 200,
+	}, answers)
+	assert.Contains(t, out.String(), "base URL must start with http:// or https://")
+}
+
+func TestPrompt_Defaults(t *testing.T) {
+	answers, err := Prompt(strings.NewReader("\n\n\n\n\n\n"), &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAnswers(), answers)
+}
+
+func TestPrompt_InputEnds(t *testing.T) {
+	_, err := Prompt(strings.NewReader("Shop\n"), &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "input ended")
+}
+
+func TestGenerate_LoadsAsValidConfig(t *testing.T) {
+	for _, auth := range []string{AuthNone, AuthBearer, AuthAPIKey, AuthBasic} {
+		t.Run(auth, func(t *testing.T) {
+			answers := DefaultAnswers()
+			answers.Auth = auth
+
+			data, err := Generate(answers)
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "bombardino.json")
+			require.NoError(t, os.WriteFile(path, data, 0644))
+
+			cfg, err := config.LoadFromFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "My API Tests", cfg.Name)
+			require.Len(t, cfg.Tests, 1)
+			assert.Equal(t, "GET /health", cfg.Tests[0].Name)
+			assert.Len(t, cfg.Tests[0].Assertions, 2)
+			if auth == AuthNone {
+				assert.Empty(t, cfg.Global.Secrets)
+			} else {
+				assert.Len(t, cfg.Global.Secrets, 1)
+			}
+		})
+	}
+}
+
+func TestGenerate_UnknownAuth(t *testing.T) {
+	answers := DefaultAnswers()
+	answers.Auth = "oauth"
+	_, err := Generate(answers)
+	require.Error(t, err)
+}
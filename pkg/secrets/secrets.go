@@ -0,0 +1,188 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/awssig"
+	"github.com/tidwall/gjson"
+)
+
+// Resolver resolves named secrets from external providers
+type Resolver struct {
+	client *http.Client
+	getenv func(string) string
+	now    func() time.Time
+
+	// awsEndpoint overrides the Secrets Manager endpoint (used in tests)
+	awsEndpoint string
+}
+
+// NewResolver creates a new secrets resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		client: &http.Client{Timeout: 10 * time.Second},
+		getenv: os.Getenv,
+		now:    time.Now,
+	}
+}
+
+// ResolveAll resolves every secret and returns a map of name to value
+func (r *Resolver) ResolveAll(refs map[string]models.SecretRef) (map[string]string, error) {
+	// Resolve in a stable order so errors are deterministic
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]string, len(refs))
+	for _, name := range names {
+		value, err := r.Resolve(refs[name])
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// Resolve resolves a single secret reference
+func (r *Resolver) Resolve(ref models.SecretRef) (string, error) {
+	switch ref.Provider {
+	case "env":
+		return r.resolveEnv(ref)
+	case "vault":
+		return r.resolveVault(ref)
+	case "aws":
+		return r.resolveAWS(ref)
+	default:
+		return "", fmt.Errorf("unknown provider: %s", ref.Provider)
+	}
+}
+
+// resolveEnv reads the secret from an environment variable
+func (r *Resolver) resolveEnv(ref models.SecretRef) (string, error) {
+	value := r.getenv(ref.Key)
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Key)
+	}
+	return value, nil
+}
+
+// resolveVault reads the secret from HashiCorp Vault (KV v1 or v2)
+func (r *Resolver) resolveVault(ref models.SecretRef) (string, error) {
+	address := ref.Address
+	if address == "" {
+		address = r.getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", fmt.Errorf("vault address not configured (set address or VAULT_ADDR)")
+	}
+
+	token := r.getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set")
+	}
+
+	url := strings.TrimSuffix(address, "/") + "/v1/" + strings.TrimPrefix(ref.Key, "/")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := r.getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	body, err := r.do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+
+	field := ref.Field
+	if field == "" {
+		field = "value"
+	}
+
+	// KV v2 nests the payload under data.data, KV v1 under data
+	for _, path := range []string{"data.data." + field, "data." + field} {
+		if value := gjson.GetBytes(body, path); value.Exists() {
+			return value.String(), nil
+		}
+	}
+	return "", fmt.Errorf("vault: field '%s' not found at %s", field, ref.Key)
+}
+
+// resolveAWS reads the secret from AWS Secrets Manager
+func (r *Resolver) resolveAWS(ref models.SecretRef) (string, error) {
+	region, err := awssig.RegionFromEnv(ref.Region, r.getenv)
+	if err != nil {
+		return "", err
+	}
+
+	creds, err := awssig.CredentialsFromEnv(r.getenv)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := r.awsEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+
+	payload, _ := json.Marshal(map[string]string{"SecretId": ref.Key})
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create aws request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	awssig.Sign(req, payload, creds, region, "secretsmanager", r.now().UTC())
+
+	body, err := r.do(req)
+	if err != nil {
+		return "", fmt.Errorf("aws: %w", err)
+	}
+
+	secretString := gjson.GetBytes(body, "SecretString")
+	if !secretString.Exists() {
+		return "", fmt.Errorf("aws: secret %s has no SecretString", ref.Key)
+	}
+	if ref.Field == "" {
+		return secretString.String(), nil
+	}
+
+	value := gjson.Get(secretString.String(), ref.Field)
+	if !value.Exists() {
+		return "", fmt.Errorf("aws: field '%s' not found in secret %s", ref.Field, ref.Key)
+	}
+	return value.String(), nil
+}
+
+// do executes a request and returns the body, failing on non-2xx status
+func (r *Resolver) do(req *http.Request) ([]byte, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Never echo the body: providers may include sensitive details
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return body, nil
+}
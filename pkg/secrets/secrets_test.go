@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResolver(env map[string]string) *Resolver {
+	r := NewResolver()
+	r.getenv = func(key string) string { return env[key] }
+	r.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return r
+}
+
+func TestResolver_Env(t *testing.T) {
+	r := newTestResolver(map[string]string{"API_TOKEN": "s3cret"})
+
+	value, err := r.Resolve(models.SecretRef{Provider: "env", Key: "API_TOKEN"})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+}
+
+func TestResolver_Env_Missing(t *testing.T) {
+	r := newTestResolver(map[string]string{})
+
+	_, err := r.Resolve(models.SecretRef{Provider: "env", Key: "API_TOKEN"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API_TOKEN")
+}
+
+func TestResolver_Vault_KVv2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/app", r.URL.Path)
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		w.Write([]byte(`{"data": {"data": {"password": "hunter2"}}}`))
+	}))
+	defer server.Close()
+
+	r := newTestResolver(map[string]string{"VAULT_TOKEN": "vault-token"})
+	value, err := r.Resolve(models.SecretRef{
+		Provider: "vault",
+		Key:      "secret/data/app",
+		Field:    "password",
+		Address:  server.URL,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+}
+
+func TestResolver_Vault_KVv1DefaultField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"value": "plain"}}`))
+	}))
+	defer server.Close()
+
+	r := newTestResolver(map[string]string{"VAULT_TOKEN": "t", "VAULT_ADDR": server.URL})
+	value, err := r.Resolve(models.SecretRef{Provider: "vault", Key: "kv/app"})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", value)
+}
+
+func TestResolver_Vault_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors": ["permission denied"]}`))
+	}))
+	defer server.Close()
+
+	r := newTestResolver(map[string]string{"VAULT_TOKEN": "t"})
+	_, err := r.Resolve(models.SecretRef{Provider: "vault", Key: "kv/app", Address: server.URL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestResolver_AWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/secretsmanager/aws4_request"))
+		w.Write([]byte(`{"Name": "prod/db", "SecretString": "{\"username\":\"admin\",\"password\":\"pw\"}"}`))
+	}))
+	defer server.Close()
+
+	r := newTestResolver(map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "SECRET",
+	})
+	r.awsEndpoint = server.URL
+
+	value, err := r.Resolve(models.SecretRef{Provider: "aws", Key: "prod/db", Field: "password", Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "pw", value)
+}
+
+func TestResolver_AWS_MissingCredentials(t *testing.T) {
+	r := newTestResolver(map[string]string{"AWS_REGION": "us-east-1"})
+
+	_, err := r.Resolve(models.SecretRef{Provider: "aws", Key: "prod/db"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AWS_ACCESS_KEY_ID")
+}
+
+func TestResolver_ResolveAll(t *testing.T) {
+	r := newTestResolver(map[string]string{"A": "1", "B": "2"})
+
+	values, err := r.ResolveAll(map[string]models.SecretRef{
+		"first":  {Provider: "env", Key: "A"},
+		"second": {Provider: "env", Key: "B"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"first": "1", "second": "2"}, values)
+}
+
+func TestResolver_ResolveAll_ErrorIncludesName(t *testing.T) {
+	r := newTestResolver(map[string]string{})
+
+	_, err := r.ResolveAll(map[string]models.SecretRef{
+		"api_token": {Provider: "env", Key: "MISSING"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api_token")
+}
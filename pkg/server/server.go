@@ -0,0 +1,407 @@
+// Package server exposes an HTTP API for bombardino serve, so runs can be
+// driven by orchestration systems: upload configs, start and stop runs,
+// follow their live stats, and fetch their reports.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/config"
+	"github.com/andrearaponi/bombardino/pkg/engine"
+	"github.com/andrearaponi/bombardino/pkg/remotedata"
+	"github.com/andrearaponi/bombardino/pkg/replay"
+	"github.com/andrearaponi/bombardino/pkg/reporter"
+	"github.com/andrearaponi/bombardino/pkg/secrets"
+	"github.com/andrearaponi/bombardino/pkg/stream"
+	"github.com/google/uuid"
+)
+
+// Run states
+const (
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusStopped  = "stopped"
+	StatusFailed   = "failed" // The run could not start, e.g. its target never became ready
+)
+
+// maxConfigSize bounds uploaded configs
+const maxConfigSize = 10 << 20
+
+// Server manages uploaded configs and the runs started from them. One run
+// is active at a time, so concurrent runs do not skew each other's results.
+type Server struct {
+	workers int
+	token   string
+	log     *slog.Logger
+	mux     *http.ServeMux
+
+	mu      sync.Mutex
+	configs map[string]*storedConfig
+	runs    map[string]*run
+	active  *run
+	wg      sync.WaitGroup
+}
+
+type storedConfig struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Tests      int       `json:"tests"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	data       []byte
+}
+
+type run struct {
+	ID         string     `json:"id"`
+	ConfigID   string     `json:"config_id"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Passed     *bool      `json:"passed,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	cancel  context.CancelFunc
+	events  *stream.Writer
+	summary *models.Summary
+}
+
+// runStatus is a run as returned by the API, with its live stats
+type runStatus struct {
+	run
+	Stats stream.Stats `json:"stats"`
+}
+
+// startRequest is the body of POST /runs
+type startRequest struct {
+	ConfigID string `json:"config_id"`
+	Workers  int    `json:"workers,omitempty"`
+	Env      string `json:"env,omitempty"`
+}
+
+// New creates a server running with workers concurrent workers by default.
+// When token is set, every request needs an "Authorization: Bearer <token>"
+// header.
+func New(workers int, token string, logger *slog.Logger) *Server {
+	s := &Server{
+		workers: workers,
+		token:   token,
+		log:     logger,
+		mux:     http.NewServeMux(),
+		configs: make(map[string]*storedConfig),
+		runs:    make(map[string]*run),
+	}
+	s.mux.HandleFunc("POST /configs", s.uploadConfig)
+	s.mux.HandleFunc("GET /configs", s.listConfigs)
+	s.mux.HandleFunc("POST /runs", s.startRun)
+	s.mux.HandleFunc("GET /runs", s.listRuns)
+	s.mux.HandleFunc("GET /runs/{id}", s.getRun)
+	s.mux.HandleFunc("POST /runs/{id}/stop", s.stopRun)
+	s.mux.HandleFunc("GET /runs/{id}/report", s.getReport)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		expected := "Bearer " + s.token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Shutdown stops the active run and waits for it to finish
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	if s.active != nil {
+		s.active.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) uploadConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read config: %v", err))
+		return
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stored := &storedConfig{
+		ID:         uuid.NewString(),
+		Name:       cfg.Name,
+		Tests:      len(cfg.Tests),
+		UploadedAt: time.Now(),
+		data:       data,
+	}
+	s.mu.Lock()
+	s.configs[stored.ID] = stored
+	s.mu.Unlock()
+
+	s.log.Info("config uploaded", "id", stored.ID, "name", stored.Name)
+	writeJSON(w, http.StatusCreated, stored)
+}
+
+func (s *Server) listConfigs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	configs := make([]*storedConfig, 0, len(s.configs))
+	for _, stored := range s.configs {
+		configs = append(configs, stored)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].UploadedAt.Before(configs[j].UploadedAt)
+	})
+	writeJSON(w, http.StatusOK, configs)
+}
+
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request) {
+	var req startRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	stored, ok := s.configs[req.ConfigID]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown config '%s'", req.ConfigID))
+		return
+	}
+
+	// Configs are parsed again for every run, as a run changes its config
+	cfg, err := config.Parse(stored.data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := config.ApplyEnvironment(cfg, req.Env); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := config.CheckRequiredVariables(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	workers := req.Workers
+	if workers <= 0 {
+		workers = s.workers
+	}
+
+	var resolved map[string]string
+	if len(cfg.Global.Secrets) > 0 {
+		resolved, err = secrets.NewResolver().ResolveAll(cfg.Global.Secrets)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to resolve secrets: %v", err))
+			return
+		}
+	}
+
+	var replayed []replay.Request
+	if cfg.Global.Replay != nil {
+		if replayed, err = replay.Load(cfg.Global.Replay.File); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to load replay capture: %v", err))
+			return
+		}
+	}
+
+	fetcher := remotedata.NewFetcher("", 0)
+	if err := fetcher.ResolveConfig(cfg); err != nil {
+		fetcher.Close()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to fetch data files: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	if s.active != nil {
+		active := s.active.ID
+		s.mu.Unlock()
+		fetcher.Close()
+		writeError(w, http.StatusConflict, fmt.Sprintf("run %s is still running", active))
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	current := &run{
+		ID:        uuid.NewString(),
+		ConfigID:  stored.ID,
+		Name:      cfg.Name,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+		events:    stream.New(io.Discard, time.Second),
+	}
+	s.runs[current.ID] = current
+	s.active = current
+	s.wg.Add(1)
+	status := s.status(current)
+	s.mu.Unlock()
+
+	go s.execute(ctx, current, cfg, workers, resolved, replayed, fetcher)
+
+	s.log.Info("run started", "id", current.ID, "config", cfg.Name, "workers", workers)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// execute runs a config in the background and records its summary
+func (s *Server) execute(ctx context.Context, current *run, cfg *models.Config, workers int, resolved map[string]string, replayed []replay.Request, fetcher *remotedata.Fetcher) {
+	defer s.wg.Done()
+	defer current.cancel()
+
+	testEngine := engine.New(workers, nil, false)
+	testEngine.SetLogger(s.log)
+	testEngine.SetRunID(current.ID)
+	testEngine.SetContext(ctx)
+	testEngine.SetEventStream(current.events)
+	testEngine.SetSecrets(resolved)
+	if replayed != nil {
+		testEngine.SetReplay(replayed)
+	}
+
+	if err := testEngine.WaitReady(cfg); err != nil {
+		fetcher.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := time.Now()
+		current.FinishedAt = &now
+		current.Status = StatusFailed
+		if ctx.Err() != nil {
+			current.Status = StatusStopped
+		}
+		current.Error = err.Error()
+		s.active = nil
+		s.log.Warn("run not started", "id", current.ID, "error", err)
+		return
+	}
+
+	summary := testEngine.Run(cfg)
+	if err := fetcher.Close(); err != nil {
+		s.log.Warn("failed to remove downloaded data files", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	passed := summary.Passed()
+	current.summary = summary
+	current.FinishedAt = &now
+	current.Passed = &passed
+	current.Status = StatusFinished
+	if summary.Interrupted {
+		current.Status = StatusStopped
+	}
+	s.active = nil
+	s.log.Info("run finished", "id", current.ID, "status", current.Status, "passed", passed)
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	runs := make([]runStatus, 0, len(s.runs))
+	for _, current := range s.runs {
+		runs = append(runs, s.status(current))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.runs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown run '%s'", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status(current))
+}
+
+func (s *Server) stopRun(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.runs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown run '%s'", r.PathValue("id")))
+		return
+	}
+	if current.Status != StatusRunning {
+		writeError(w, http.StatusConflict, fmt.Sprintf("run %s is already %s", current.ID, current.Status))
+		return
+	}
+	current.cancel()
+	s.log.Info("run stop requested", "id", current.ID)
+	writeJSON(w, http.StatusAccepted, s.status(current))
+}
+
+// getReport returns the report of a finished run, as JSON or, with
+// ?format=html, as HTML
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	current, ok := s.runs[r.PathValue("id")]
+	var summary *models.Summary
+	var status string
+	if ok {
+		summary = current.summary
+		status = current.Status
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown run '%s'", r.PathValue("id")))
+		return
+	}
+	if summary == nil && status == StatusRunning {
+		writeError(w, http.StatusConflict, fmt.Sprintf("run %s is still running", current.ID))
+		return
+	}
+	if summary == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("run %s has no report: it did not start", current.ID))
+		return
+	}
+
+	report := reporter.New(false)
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		report.WriteJSONReport(w, summary)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		report.WriteHTMLReport(w, summary)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported report format '%s' (expected json or html)", format))
+	}
+}
+
+// status returns a run with its live stats; the caller holds the lock
+func (s *Server) status(current *run) runStatus {
+	return runStatus{run: *current, Stats: current.events.Stats()}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
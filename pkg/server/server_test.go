@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, token string) (*Server, *httptest.Server) {
+	t.Helper()
+	api := New(2, token, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server := httptest.NewServer(api)
+	t.Cleanup(func() {
+		server.Close()
+		api.Shutdown()
+	})
+	return api, server
+}
+
+func call(t *testing.T, method, url, token string, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func testConfig(baseURL, mode string) string {
+	return fmt.Sprintf(`{
+		"name": "Control API",
+		"global": {"base_url": %q, "timeout": "5s", %s},
+		"tests": [{"name": "ping", "method": "GET", "path": "/ping", "expected_status": [200]}]
+	}`, baseURL, mode)
+}
+
+func waitForStatus(t *testing.T, url string, status string) runStatus {
+	t.Helper()
+	var current runStatus
+	require.Eventually(t, func() bool {
+		call(t, http.MethodGet, url, "", "", &current)
+		return current.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return current
+}
+
+func TestServer_Run(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	_, server := newTestServer(t, "")
+
+	var uploaded storedConfig
+	status := call(t, http.MethodPost, server.URL+"/configs", "", testConfig(target.URL, `"iterations": 5`), &uploaded)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "Control API", uploaded.Name)
+	assert.Equal(t, 1, uploaded.Tests)
+
+	var configs []storedConfig
+	call(t, http.MethodGet, server.URL+"/configs", "", "", &configs)
+	require.Len(t, configs, 1)
+
+	var started runStatus
+	status = call(t, http.MethodPost, server.URL+"/runs", "", fmt.Sprintf(`{"config_id": %q}`, uploaded.ID), &started)
+	require.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, uploaded.ID, started.ConfigID)
+
+	finished := waitForStatus(t, server.URL+"/runs/"+started.ID, StatusFinished)
+	require.NotNil(t, finished.Passed)
+	assert.True(t, *finished.Passed)
+	assert.Equal(t, 5, finished.Stats.TotalRequests)
+	assert.NotNil(t, finished.FinishedAt)
+
+	var report struct {
+		Success bool `json:"success"`
+		Summary struct {
+			TotalRequests int `json:"total_requests"`
+		} `json:"summary"`
+	}
+	status = call(t, http.MethodGet, server.URL+"/runs/"+started.ID+"/report", "", "", &report)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, report.Success)
+	assert.Equal(t, 5, report.Summary.TotalRequests)
+
+	resp, err := http.Get(server.URL + "/runs/" + started.ID + "/report?format=html")
+	require.NoError(t, err)
+	html, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.True(t, bytes.Contains(html, []byte("<html")))
+
+	var runs []runStatus
+	call(t, http.MethodGet, server.URL+"/runs", "", "", &runs)
+	require.Len(t, runs, 1)
+	assert.Equal(t, StatusFinished, runs[0].Status)
+}
+
+func TestServer_Stop(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	_, server := newTestServer(t, "")
+
+	var uploaded storedConfig
+	call(t, http.MethodPost, server.URL+"/configs", "", testConfig(target.URL, `"duration": "1m"`), &uploaded)
+
+	var started runStatus
+	call(t, http.MethodPost, server.URL+"/runs", "", fmt.Sprintf(`{"config_id": %q}`, uploaded.ID), &started)
+
+	// One run at a time, and no report before it ends
+	var errResp map[string]string
+	status := call(t, http.MethodPost, server.URL+"/runs", "", fmt.Sprintf(`{"config_id": %q}`, uploaded.ID), &errResp)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, errResp["error"], "is still running")
+	status = call(t, http.MethodGet, server.URL+"/runs/"+started.ID+"/report", "", "", nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status = call(t, http.MethodPost, server.URL+"/runs/"+started.ID+"/stop", "", "", nil)
+	require.Equal(t, http.StatusAccepted, status)
+	stopped := waitForStatus(t, server.URL+"/runs/"+started.ID, StatusStopped)
+	assert.False(t, *stopped.Passed)
+
+	status = call(t, http.MethodPost, server.URL+"/runs/"+started.ID+"/stop", "", "", &errResp)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, errResp["error"], "is already stopped")
+}
+
+func TestServer_TargetNotReady(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer target.Close()
+	_, server := newTestServer(t, "")
+
+	var uploaded storedConfig
+	call(t, http.MethodPost, server.URL+"/configs", "", testConfig(target.URL,
+		`"iterations": 5, "readiness": {"url": "/health", "timeout": "200ms", "interval": "50ms"}`), &uploaded)
+
+	var started runStatus
+	call(t, http.MethodPost, server.URL+"/runs", "", fmt.Sprintf(`{"config_id": %q}`, uploaded.ID), &started)
+	failed := waitForStatus(t, server.URL+"/runs/"+started.ID, StatusFailed)
+	assert.Contains(t, failed.Error, "/health not ready after 200ms")
+	assert.Zero(t, failed.Stats.TotalRequests)
+
+	var errResp map[string]string
+	status := call(t, http.MethodGet, server.URL+"/runs/"+started.ID+"/report", "", "", &errResp)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, errResp["error"], "did not start")
+}
+
+func TestServer_Errors(t *testing.T) {
+	_, server := newTestServer(t, "")
+
+	var errResp map[string]string
+	status := call(t, http.MethodPost, server.URL+"/configs", "", `{"name": "Broken"`, &errResp)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, errResp["error"], "failed to parse JSON")
+
+	status = call(t, http.MethodPost, server.URL+"/runs", "", `{"config_id": "missing"}`, &errResp)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "unknown config 'missing'", errResp["error"])
+
+	var uploaded storedConfig
+	call(t, http.MethodPost, server.URL+"/configs", "", testConfig("http://localhost", `"replay": {"file": "missing.jsonl"}`), &uploaded)
+	status = call(t, http.MethodPost, server.URL+"/runs", "", fmt.Sprintf(`{"config_id": %q}`, uploaded.ID), &errResp)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, errResp["error"], "failed to load replay capture")
+
+	status = call(t, http.MethodGet, server.URL+"/runs/missing", "", "", &errResp)
+	assert.Equal(t, http.StatusNotFound, status)
+	status = call(t, http.MethodPost, server.URL+"/runs/missing/stop", "", "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_Token(t *testing.T) {
+	_, server := newTestServer(t, "s3cret")
+
+	var errResp map[string]string
+	status := call(t, http.MethodGet, server.URL+"/configs", "", "", &errResp)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "missing or invalid bearer token", errResp["error"])
+	status = call(t, http.MethodGet, server.URL+"/configs", "wrong", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	var configs []storedConfig
+	status = call(t, http.MethodGet, server.URL+"/configs", "s3cret", "", &configs)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, configs)
+}
@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/gcpauth"
+)
+
+// bigQuery inserts rows with the tabledata.insertAll streaming API
+type bigQuery struct {
+	client      *http.Client
+	target      string // insertAll URL of the table
+	getenv      func(string) string
+	metadataURL string
+	inserted    int // Rows sent so far, numbering the insert IDs
+}
+
+func newBigQuery(client *http.Client, cfg models.ResultsSink, getenv func(string) string, metadataURL string) (*bigQuery, error) {
+	parts := strings.Split(cfg.Table, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid BigQuery table %q (expected project.dataset.table)", cfg.Table)
+	}
+	endpoint := cfg.URL
+	if endpoint == "" {
+		endpoint = "https://bigquery.googleapis.com"
+	}
+	target := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(parts[0]), url.PathEscape(parts[1]), url.PathEscape(parts[2]))
+	return &bigQuery{client: client, target: target, getenv: getenv, metadataURL: metadataURL}, nil
+}
+
+type bigQueryRow struct {
+	InsertID string `json:"insertId"`
+	JSON     Row    `json:"json"`
+}
+
+type bigQueryResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+func (b *bigQuery) Insert(ctx context.Context, rows []Row) error {
+	// Insert IDs let BigQuery drop the rows of a batch sent twice
+	request := struct {
+		Rows []bigQueryRow `json:"rows"`
+	}{Rows: make([]bigQueryRow, len(rows))}
+	for i, row := range rows {
+		request.Rows[i] = bigQueryRow{InsertID: row.RunID + "-" + strconv.Itoa(b.inserted+i), JSON: row}
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	token, err := gcpauth.Token(ctx, b.client, b.getenv, b.metadataURL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	body, err := send(b.client, req)
+	if err != nil {
+		return err
+	}
+	b.inserted += len(rows)
+
+	// Rejected rows come back with a 200 status code
+	var resp bigQueryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("insert failed: invalid response: %w", err)
+	}
+	if len(resp.InsertErrors) > 0 {
+		first := resp.InsertErrors[0]
+		message := "unknown error"
+		if len(first.Errors) > 0 {
+			message = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		return fmt.Errorf("insert failed: %d of %d rows rejected, row %d: %s", len(resp.InsertErrors), len(rows), first.Index, message)
+	}
+	return nil
+}
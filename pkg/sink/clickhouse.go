@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// clickHouse inserts rows through the ClickHouse HTTP interface in the
+// JSONEachRow format
+type clickHouse struct {
+	client   *http.Client
+	endpoint string
+	table    string
+	user     string
+	password string
+}
+
+func newClickHouse(client *http.Client, cfg models.ResultsSink, getenv func(string) string) *clickHouse {
+	return &clickHouse{
+		client:   client,
+		endpoint: strings.TrimSuffix(cfg.URL, "/"),
+		table:    cfg.Table,
+		user:     getenv("CLICKHOUSE_USER"),
+		password: getenv("CLICKHOUSE_PASSWORD"),
+	}
+}
+
+func (c *clickHouse) Insert(ctx context.Context, rows []Row) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+	}
+
+	query := url.Values{"query": {"INSERT INTO " + c.table + " FORMAT JSONEachRow"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/?"+query.Encode(), &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	_, err = send(c.client, req)
+	return err
+}
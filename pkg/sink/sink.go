@@ -0,0 +1,103 @@
+// Package sink inserts per-request results into analytics databases
+// (ClickHouse and BigQuery) for the results_sink config block, so the
+// results of thousands of runs can be queried together. Both are reached
+// over their HTTP APIs.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/andrearaponi/bombardino/pkg/gcpauth"
+)
+
+// timestampFormat is accepted by ClickHouse DateTime64 and BigQuery
+// TIMESTAMP columns alike
+const timestampFormat = "2006-01-02 15:04:05.000000"
+
+// Row is the result of a request as stored in a sink table
+type Row struct {
+	RunID          string  `json:"run_id"`
+	Timestamp      string  `json:"timestamp"` // When the request started, UTC
+	Test           string  `json:"test"`
+	Scenario       string  `json:"scenario"`
+	Method         string  `json:"method"`
+	URL            string  `json:"url"`
+	Target         string  `json:"target"`
+	StatusCode     int     `json:"status_code"`
+	ResponseTimeMs float64 `json:"response_time_ms"`
+	Success        bool    `json:"success"`
+	Skipped        bool    `json:"skipped"`
+	Throttled      bool    `json:"throttled"`
+	Error          string  `json:"error"`
+	ErrorCategory  string  `json:"error_category"`
+	RequestSize    int64   `json:"request_size"`
+	ResponseSize   int64   `json:"response_size"`
+}
+
+// NewRow converts the result of a request of the run runID
+func NewRow(runID string, result models.TestResult) Row {
+	return Row{
+		RunID:          runID,
+		Timestamp:      result.Timestamp.UTC().Format(timestampFormat),
+		Test:           result.TestName,
+		Scenario:       result.Scenario,
+		Method:         result.Method,
+		URL:            result.URL,
+		Target:         result.Target,
+		StatusCode:     result.StatusCode,
+		ResponseTimeMs: float64(result.ResponseTime) / float64(time.Millisecond),
+		Success:        result.Success,
+		Skipped:        result.Skipped,
+		Throttled:      result.Throttled,
+		Error:          result.Error,
+		ErrorCategory:  result.ErrorCategory,
+		RequestSize:    result.RequestSize,
+		ResponseSize:   result.ResponseSize,
+	}
+}
+
+// Inserter inserts batches of rows into a table. It is not safe for
+// concurrent use.
+type Inserter interface {
+	Insert(ctx context.Context, rows []Row) error
+}
+
+// New returns the inserter of a results sink. Credentials come from the
+// environment:
+//   - ClickHouse: CLICKHOUSE_USER and CLICKHOUSE_PASSWORD, if the server needs them
+//   - BigQuery: GOOGLE_OAUTH_ACCESS_TOKEN, or the GCP metadata server
+func New(cfg models.ResultsSink) (Inserter, error) {
+	client := &http.Client{Timeout: time.Minute}
+	switch cfg.Type {
+	case models.SinkClickHouse:
+		return newClickHouse(client, cfg, os.Getenv), nil
+	case models.SinkBigQuery:
+		return newBigQuery(client, cfg, os.Getenv, gcpauth.MetadataURL)
+	default:
+		return nil, fmt.Errorf("unsupported sink type '%s' (expected clickhouse or bigquery)", cfg.Type)
+	}
+}
+
+// send sends an insert request, failing on a status code other than 2xx
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("insert failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("insert failed: unexpected status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("insert failed: %w", err)
+	}
+	return body, nil
+}
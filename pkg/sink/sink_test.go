@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a database endpoint that remembers the last request it got
+type recorder struct {
+	server *httptest.Server
+	req    *http.Request
+	body   string
+	status int
+	reply  string
+}
+
+func newRecorder(t *testing.T) *recorder {
+	rec := &recorder{status: http.StatusOK}
+	rec.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.req, rec.body = r, string(body)
+		w.WriteHeader(rec.status)
+		w.Write([]byte(rec.reply))
+	}))
+	t.Cleanup(rec.server.Close)
+	return rec
+}
+
+func testRows() []Row {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 250_000_000, time.FixedZone("CET", 3600))
+	return []Row{
+		NewRow("run-1", models.TestResult{TestName: "Login", Method: "POST", URL: "http://api/login", StatusCode: 200, ResponseTime: 42500 * time.Microsecond, Success: true, Timestamp: started}),
+		NewRow("run-1", models.TestResult{TestName: "Login", Method: "POST", URL: "http://api/login", Error: "timeout", ErrorCategory: "timeout", Timestamp: started}),
+	}
+}
+
+func TestNewRow(t *testing.T) {
+	row := testRows()[0]
+	assert.Equal(t, "2026-03-01 11:00:00.250000", row.Timestamp)
+	assert.Equal(t, 42.5, row.ResponseTimeMs)
+	assert.Equal(t, "Login", row.Test)
+	assert.True(t, row.Success)
+}
+
+func TestClickHouse_Insert(t *testing.T) {
+	rec := newRecorder(t)
+	env := map[string]string{"CLICKHOUSE_USER": "loader", "CLICKHOUSE_PASSWORD": "secret"}
+	c := newClickHouse(http.DefaultClient, models.ResultsSink{URL: rec.server.URL + "/", Table: "perf.results"}, func(key string) string { return env[key] })
+
+	require.NoError(t, c.Insert(context.Background(), testRows()))
+	assert.Equal(t, http.MethodPost, rec.req.Method)
+	assert.Equal(t, "INSERT INTO perf.results FORMAT JSONEachRow", rec.req.URL.Query().Get("query"))
+	assert.Equal(t, "loader", rec.req.Header.Get("X-ClickHouse-User"))
+	assert.Equal(t, "secret", rec.req.Header.Get("X-ClickHouse-Key"))
+
+	lines := strings.Split(strings.TrimSpace(rec.body), "\n")
+	require.Len(t, lines, 2)
+	var row Row
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "timeout", row.Error)
+	assert.Equal(t, "run-1", row.RunID)
+
+	rec.status, rec.reply = http.StatusNotFound, "Code: 60. DB::Exception: Table perf.results does not exist\n"
+	err := c.Insert(context.Background(), testRows())
+	assert.EqualError(t, err, "insert failed: unexpected status code: 404: Code: 60. DB::Exception: Table perf.results does not exist")
+}
+
+func TestBigQuery_Insert(t *testing.T) {
+	rec := newRecorder(t)
+	env := map[string]string{"GOOGLE_OAUTH_ACCESS_TOKEN": "ya29.token"}
+	b, err := newBigQuery(http.DefaultClient, models.ResultsSink{URL: rec.server.URL, Table: "my-project.perf.results"}, func(key string) string { return env[key] }, "")
+	require.NoError(t, err)
+
+	rec.reply = `{"kind": "bigquery#tableDataInsertAllResponse"}`
+	require.NoError(t, b.Insert(context.Background(), testRows()))
+	assert.Equal(t, "/bigquery/v2/projects/my-project/datasets/perf/tables/results/insertAll", rec.req.URL.Path)
+	assert.Equal(t, "Bearer ya29.token", rec.req.Header.Get("Authorization"))
+
+	var request struct {
+		Rows []bigQueryRow `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(rec.body), &request))
+	require.Len(t, request.Rows, 2)
+	assert.Equal(t, "run-1-1", request.Rows[1].InsertID)
+	assert.Equal(t, "Login", request.Rows[1].JSON.Test)
+
+	// Insert IDs keep counting across batches
+	require.NoError(t, b.Insert(context.Background(), testRows()))
+	require.NoError(t, json.Unmarshal([]byte(rec.body), &request))
+	assert.Equal(t, "run-1-2", request.Rows[0].InsertID)
+
+	rec.reply = `{"insertErrors": [{"index": 1, "errors": [{"reason": "invalid", "message": "no such field: scenario"}]}]}`
+	err = b.Insert(context.Background(), testRows())
+	assert.EqualError(t, err, "insert failed: 1 of 2 rows rejected, row 1: invalid: no such field: scenario")
+
+	_, err = newBigQuery(http.DefaultClient, models.ResultsSink{Table: "perf.results"}, nil, "")
+	assert.ErrorContains(t, err, "expected project.dataset.table")
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(models.ResultsSink{Type: "postgres"})
+	assert.EqualError(t, err, "unsupported sink type 'postgres' (expected clickhouse or bigquery)")
+}
@@ -0,0 +1,579 @@
+package sqlclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MySQL capability flags
+const (
+	mysqlLongPassword     = 0x00000001
+	mysqlFoundRows        = 0x00000002
+	mysqlConnectWithDB    = 0x00000008
+	mysqlProtocol41       = 0x00000200
+	mysqlSSL              = 0x00000800
+	mysqlTransactions     = 0x00002000
+	mysqlSecureConnection = 0x00008000
+	mysqlPluginAuth       = 0x00080000
+)
+
+// MySQL column types converted from text
+const (
+	mysqlDecimal    = 0
+	mysqlTiny       = 1
+	mysqlShort      = 2
+	mysqlLong       = 3
+	mysqlFloat      = 4
+	mysqlDouble     = 5
+	mysqlLongLong   = 8
+	mysqlInt24      = 9
+	mysqlYear       = 13
+	mysqlJSON       = 245
+	mysqlNewDecimal = 246
+)
+
+const (
+	mysqlMaxPacket = 1<<24 - 1
+	mysqlCharset   = 45 // utf8mb4_general_ci
+	mysqlComQuery  = 0x03
+	mysqlComQuit   = 0x01
+)
+
+type mysqlConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	seq    byte
+	tls    bool
+}
+
+func openMySQL(ctx context.Context, u *url.URL, dialer *net.Dialer) (*mysqlConn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort(u, "3306"))
+	if err != nil {
+		return nil, err
+	}
+	setDeadline(ctx, conn)
+	c := &mysqlConn{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.handshake(u); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake answers the initial handshake of the server, upgrading to TLS
+// when the tls parameter asks, and authenticates
+func (c *mysqlConn) handshake(u *url.URL) error {
+	packet, err := c.read()
+	if err != nil {
+		return err
+	}
+	if len(packet) > 0 && packet[0] == 0xFF {
+		return mysqlError(packet)
+	}
+	d := &decoder{buf: packet}
+	if d.byte() != 10 {
+		return fmt.Errorf("mysql: unsupported protocol version %d", packet[0])
+	}
+	d.nulString() // server version
+	d.next(4)     // connection id
+	scramble := append([]byte(nil), d.next(8)...)
+	d.next(1)
+	capabilities := uint32(d.uint16())
+	d.next(3) // charset, status
+	capabilities |= uint32(d.uint16()) << 16
+	dataLen := int(d.byte())
+	d.next(10)
+	if capabilities&mysqlSecureConnection != 0 {
+		scramble = append(scramble, d.next(max(13, dataLen-8)-1)...)
+		d.next(1)
+	}
+	plugin := "mysql_native_password"
+	if capabilities&mysqlPluginAuth != 0 {
+		if name := d.nulString(); name != "" {
+			plugin = name
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("mysql: malformed handshake")
+	}
+	if capabilities&mysqlProtocol41 == 0 {
+		return fmt.Errorf("mysql: server does not support protocol 4.1")
+	}
+
+	flags := uint32(mysqlLongPassword | mysqlFoundRows | mysqlProtocol41 | mysqlTransactions | mysqlSecureConnection | mysqlPluginAuth)
+	database := strings.TrimPrefix(u.Path, "/")
+	if database != "" {
+		flags |= mysqlConnectWithDB
+	}
+	header := binary.LittleEndian.AppendUint32(nil, 0)
+	header = binary.LittleEndian.AppendUint32(header, mysqlMaxPacket)
+	header = append(header, mysqlCharset)
+	header = append(header, make([]byte, 23)...)
+
+	switch mode := u.Query().Get("tls"); mode {
+	case "", "false":
+	case "true", "skip-verify":
+		if capabilities&mysqlSSL == 0 {
+			return fmt.Errorf("mysql: server does not support TLS")
+		}
+		flags |= mysqlSSL
+		binary.LittleEndian.PutUint32(header, flags)
+		if err := c.write(header); err != nil {
+			return err
+		}
+		config := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: mode == "skip-verify"}
+		c.conn = tls.Client(c.conn, config)
+		c.reader = bufio.NewReader(c.conn)
+		c.tls = true
+	default:
+		return fmt.Errorf("unsupported tls '%s' (expected true, skip-verify, or false)", mode)
+	}
+
+	user := u.User.Username()
+	password, _ := u.User.Password()
+	auth, err := mysqlAuth(plugin, password, scramble)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(header, flags)
+	response := append(header, user+"\x00"...)
+	response = append(response, byte(len(auth)))
+	response = append(response, auth...)
+	if database != "" {
+		response = append(response, database+"\x00"...)
+	}
+	response = append(response, plugin+"\x00"...)
+	if err := c.write(response); err != nil {
+		return err
+	}
+	return c.authResult(plugin, password, scramble)
+}
+
+// authResult reads the outcome of authentication, answering plugin switches
+// and the extra exchanges of caching_sha2_password
+func (c *mysqlConn) authResult(plugin, password string, scramble []byte) error {
+	for {
+		packet, err := c.read()
+		if err != nil {
+			return err
+		}
+		if len(packet) == 0 {
+			return fmt.Errorf("mysql: malformed authentication packet")
+		}
+		switch packet[0] {
+		case 0x00:
+			return nil
+		case 0xFF:
+			return mysqlError(packet)
+		case 0xFE: // auth switch
+			d := &decoder{buf: packet[1:]}
+			plugin = d.nulString()
+			scramble = []byte(strings.TrimSuffix(string(d.rest()), "\x00"))
+			auth, err := mysqlAuth(plugin, password, scramble)
+			if err != nil {
+				return err
+			}
+			if err := c.write(auth); err != nil {
+				return err
+			}
+		case 0x01: // more data
+			if plugin != "caching_sha2_password" || len(packet) < 2 {
+				return fmt.Errorf("mysql: unexpected authentication data")
+			}
+			switch packet[1] {
+			case 3: // fast auth succeeded, OK follows
+			case 4: // full auth: the password in clear over TLS, else encrypted
+				if c.tls {
+					err = c.write([]byte(password + "\x00"))
+				} else {
+					err = c.write([]byte{2}) // request the public key
+				}
+			default: // the public key
+				var encrypted []byte
+				if encrypted, err = mysqlEncryptPassword(packet[1:], password, scramble); err == nil {
+					err = c.write(encrypted)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("mysql: unexpected authentication packet 0x%02x", packet[0])
+		}
+	}
+}
+
+// mysqlAuth returns the scrambled password for an authentication plugin
+func mysqlAuth(plugin, password string, scramble []byte) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+	switch plugin {
+	case "mysql_native_password":
+		// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+		hash := sha1.Sum([]byte(password))
+		double := sha1.Sum(hash[:])
+		mix := sha1.Sum(append(append([]byte(nil), scramble[:min(len(scramble), 20)]...), double[:]...))
+		for i := range hash {
+			hash[i] ^= mix[i]
+		}
+		return hash[:], nil
+	case "caching_sha2_password":
+		// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+		hash := sha256.Sum256([]byte(password))
+		double := sha256.Sum256(hash[:])
+		mix := sha256.Sum256(append(double[:], scramble...))
+		for i := range hash {
+			hash[i] ^= mix[i]
+		}
+		return hash[:], nil
+	default:
+		return nil, fmt.Errorf("mysql: unsupported authentication plugin '%s'", plugin)
+	}
+}
+
+// mysqlEncryptPassword encrypts the password with the public key of the
+// server, for full caching_sha2_password authentication without TLS
+func mysqlEncryptPassword(keyPEM []byte, password string, scramble []byte) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("mysql: invalid server public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: invalid server public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("mysql: server public key is not RSA")
+	}
+	if len(scramble) == 0 {
+		return nil, fmt.Errorf("mysql: missing authentication scramble")
+	}
+	plain := []byte(password + "\x00")
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, rsaKey, plain, nil)
+}
+
+func (c *mysqlConn) Query(ctx context.Context, query string, params []interface{}) (*Result, error) {
+	setDeadline(ctx, c.conn)
+	query, err := mysqlInterpolate(query, params)
+	if err != nil {
+		return nil, err
+	}
+	c.seq = 0
+	if err := c.write(append([]byte{mysqlComQuery}, query...)); err != nil {
+		return nil, err
+	}
+
+	packet, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(packet) == 0 {
+		return nil, fmt.Errorf("mysql: malformed response")
+	}
+	switch packet[0] {
+	case 0x00:
+		d := &decoder{buf: packet[1:]}
+		return &Result{RowCount: int(d.lenencInt())}, nil
+	case 0xFF:
+		return nil, mysqlError(packet)
+	}
+
+	d := &decoder{buf: packet}
+	count := int(d.lenencInt())
+	result := &Result{Columns: make([]string, 0, count)}
+	types := make([]byte, 0, count)
+	for i := 0; i < count; i++ {
+		packet, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		d := &decoder{buf: packet}
+		for j := 0; j < 4; j++ {
+			d.lenencString() // catalog, schema, table, org_table
+		}
+		name := d.lenencString()
+		d.lenencString() // org_name
+		d.lenencInt()
+		d.next(6) // charset, length
+		columnType := d.byte()
+		if d.err != nil {
+			return nil, fmt.Errorf("mysql: malformed column definition")
+		}
+		result.Columns = append(result.Columns, name)
+		types = append(types, columnType)
+	}
+	if _, err := c.read(); err != nil { // EOF after the columns
+		return nil, err
+	}
+
+	for {
+		packet, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if len(packet) > 0 && packet[0] == 0xFF {
+			return nil, mysqlError(packet)
+		}
+		if len(packet) > 0 && packet[0] == 0xFE && len(packet) < 9 {
+			result.RowCount = len(result.Rows)
+			return result, nil
+		}
+		d := &decoder{buf: packet}
+		row := make([]interface{}, count)
+		for i := range row {
+			if d.peek() == 0xFB {
+				d.byte()
+				continue
+			}
+			row[i] = mysqlValue(d.lenencString(), types[i])
+		}
+		if d.err != nil {
+			return nil, fmt.Errorf("mysql: malformed row")
+		}
+		result.Rows = append(result.Rows, row)
+	}
+}
+
+func (c *mysqlConn) Close() error {
+	c.seq = 0
+	c.write([]byte{mysqlComQuit})
+	return c.conn.Close()
+}
+
+// read reads a packet, tracking its sequence number
+func (c *mysqlConn) read() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return nil, err
+	}
+	size := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, packet); err != nil {
+		return nil, err
+	}
+	c.seq = header[3] + 1
+	return packet, nil
+}
+
+// write writes a packet with the next sequence number
+func (c *mysqlConn) write(packet []byte) error {
+	if len(packet) >= mysqlMaxPacket {
+		return fmt.Errorf("mysql: packet of %d bytes is too large", len(packet))
+	}
+	header := []byte{byte(len(packet)), byte(len(packet) >> 8), byte(len(packet) >> 16), c.seq}
+	c.seq++
+	_, err := c.conn.Write(append(header, packet...))
+	return err
+}
+
+// mysqlError converts an ERR packet to a ServerError
+func mysqlError(packet []byte) error {
+	if len(packet) < 3 {
+		return &ServerError{Message: "malformed error packet"}
+	}
+	code := binary.LittleEndian.Uint16(packet[1:])
+	message := packet[3:]
+	if len(message) >= 6 && message[0] == '#' {
+		message = message[6:]
+	}
+	return &ServerError{Code: strconv.Itoa(int(code)), Message: string(message)}
+}
+
+// mysqlValue types the text of a value from its column type
+func mysqlValue(text string, columnType byte) interface{} {
+	switch columnType {
+	case mysqlTiny, mysqlShort, mysqlLong, mysqlLongLong, mysqlInt24, mysqlYear:
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case mysqlFloat, mysqlDouble, mysqlDecimal, mysqlNewDecimal:
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case mysqlJSON:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+	return text
+}
+
+// mysqlInterpolate replaces the ? placeholders of a query outside quoted
+// strings and identifiers with the escaped parameters, as the text protocol
+// has no bound parameters
+func mysqlInterpolate(query string, params []interface{}) (string, error) {
+	if len(params) == 0 {
+		return query, nil
+	}
+	var b strings.Builder
+	var quote byte
+	next := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote != '`' && i+1 < len(query) {
+				b.WriteByte(ch)
+				i++
+				ch = query[i]
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '?':
+			if next >= len(params) {
+				return "", fmt.Errorf("query has more placeholders than the %d parameters", len(params))
+			}
+			text, ok, err := formatParam(params[next])
+			if err != nil {
+				return "", fmt.Errorf("parameter %d: %w", next+1, err)
+			}
+			switch {
+			case !ok:
+				b.WriteString("NULL")
+			case isString(params[next]):
+				b.WriteString(mysqlQuote(text))
+			default:
+				b.WriteString(text)
+			}
+			next++
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	if next != len(params) {
+		return "", fmt.Errorf("query has %d placeholders for %d parameters", next, len(params))
+	}
+	return b.String(), nil
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// mysqlQuote returns s as a quoted string literal
+func mysqlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\x1a':
+			b.WriteString(`\Z`)
+		case '\\', '\'', '"':
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// decoder reads the little-endian fields of a MySQL packet, recording the
+// first read past its end
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil || n < 0 || n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		d.buf = nil
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) peek() byte {
+	if len(d.buf) == 0 {
+		return 0
+	}
+	return d.buf[0]
+}
+
+func (d *decoder) rest() []byte {
+	b := d.buf
+	d.buf = nil
+	return b
+}
+
+func (d *decoder) nulString() string {
+	end := strings.IndexByte(string(d.buf), 0)
+	if end < 0 {
+		return string(d.rest())
+	}
+	s := string(d.buf[:end])
+	d.buf = d.buf[end+1:]
+	return s
+}
+
+// lenencInt reads a length-encoded integer
+func (d *decoder) lenencInt() uint64 {
+	var size int
+	switch first := d.byte(); first {
+	case 0xFC:
+		size = 2
+	case 0xFD:
+		size = 3
+	case 0xFE:
+		size = 8
+	default:
+		return uint64(first)
+	}
+	var v uint64
+	for i, b := range d.next(size) {
+		v |= uint64(b) << (8 * i)
+	}
+	return v
+}
+
+// lenencString reads a length-encoded string
+func (d *decoder) lenencString() string {
+	return string(d.next(int(d.lenencInt())))
+}
@@ -0,0 +1,460 @@
+package sqlclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PostgreSQL protocol constants
+const (
+	pgProtocolVersion = 3 << 16
+	pgSSLRequest      = 80877103
+	pgMaxMessage      = 64 << 20 // Largest message accepted, far above the rows a load test reads
+
+	pgAuthOK           = 0
+	pgAuthCleartext    = 3
+	pgAuthMD5          = 5
+	pgAuthSASL         = 10
+	pgAuthSASLContinue = 11
+	pgAuthSASLFinal    = 12
+)
+
+// PostgreSQL type OIDs converted from text
+const (
+	pgBool    = 16
+	pgInt8    = 20
+	pgInt2    = 21
+	pgInt4    = 23
+	pgOID     = 26
+	pgJSON    = 114
+	pgFloat4  = 700
+	pgFloat8  = 701
+	pgNumeric = 1700
+	pgJSONB   = 3802
+)
+
+type postgresConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func openPostgres(ctx context.Context, u *url.URL, dialer *net.Dialer) (*postgresConn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort(u, "5432"))
+	if err != nil {
+		return nil, err
+	}
+	setDeadline(ctx, conn)
+
+	if conn, err = pgNegotiateTLS(conn, u); err != nil {
+		return nil, err
+	}
+	c := &postgresConn{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.startup(u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// pgNegotiateTLS upgrades the connection to TLS as sslmode asks: prefer (the
+// default) uses TLS when the server offers it, require without verifying the
+// certificate, verify-full verifying it
+func pgNegotiateTLS(conn net.Conn, u *url.URL) (net.Conn, error) {
+	mode := u.Query().Get("sslmode")
+	switch mode {
+	case "disable":
+		return conn, nil
+	case "", "prefer", "require", "verify-full":
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("unsupported sslmode '%s' (expected disable, prefer, require, or verify-full)", mode)
+	}
+
+	request := binary.BigEndian.AppendUint32(nil, 8)
+	request = binary.BigEndian.AppendUint32(request, pgSSLRequest)
+	var answer [1]byte
+	if _, err := conn.Write(request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := io.ReadFull(conn, answer[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if answer[0] != 'S' {
+		if mode == "require" || mode == "verify-full" {
+			conn.Close()
+			return nil, fmt.Errorf("postgres: server does not support TLS (sslmode=%s)", mode)
+		}
+		return conn, nil
+	}
+	config := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: mode != "verify-full"}
+	return tls.Client(conn, config), nil
+}
+
+// startup authenticates and waits until the server is ready for queries
+func (c *postgresConn) startup(u *url.URL) error {
+	user := u.User.Username()
+	password, _ := u.User.Password()
+	database := strings.TrimPrefix(u.Path, "/")
+	if database == "" {
+		database = user
+	}
+
+	var params strings.Builder
+	for _, kv := range [][2]string{{"user", user}, {"database", database}, {"application_name", "bombardino"}} {
+		params.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+	}
+	params.WriteString("\x00")
+	startup := binary.BigEndian.AppendUint32(nil, uint32(8+params.Len()))
+	startup = binary.BigEndian.AppendUint32(startup, pgProtocolVersion)
+	startup = append(startup, params.String()...)
+	if _, err := c.conn.Write(startup); err != nil {
+		return err
+	}
+
+	var scram *scramClient
+	for {
+		kind, body, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 'R':
+			if len(body) < 4 {
+				return fmt.Errorf("postgres: malformed authentication request")
+			}
+			data := body[4:]
+			switch binary.BigEndian.Uint32(body) {
+			case pgAuthOK:
+			case pgAuthCleartext:
+				err = c.send('p', []byte(password+"\x00"))
+			case pgAuthMD5:
+				inner := md5.Sum([]byte(password + user))
+				outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data...))
+				err = c.send('p', []byte("md5"+hex.EncodeToString(outer[:])+"\x00"))
+			case pgAuthSASL:
+				if !strings.Contains(string(data), "SCRAM-SHA-256\x00") {
+					return fmt.Errorf("postgres: unsupported SASL mechanisms %q", strings.TrimRight(string(data), "\x00"))
+				}
+				scram = newSCRAMClient(password)
+				first := scram.clientFirst()
+				msg := append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(first)))...)
+				err = c.send('p', append(msg, first...))
+			case pgAuthSASLContinue:
+				var final string
+				if scram == nil {
+					return fmt.Errorf("postgres: unexpected SASL message")
+				}
+				if final, err = scram.clientFinal(string(data)); err == nil {
+					err = c.send('p', []byte(final))
+				}
+			case pgAuthSASLFinal:
+				if scram == nil || !scram.verifyServer(string(data)) {
+					return fmt.Errorf("postgres: invalid SCRAM server signature")
+				}
+			default:
+				return fmt.Errorf("postgres: unsupported authentication method %d", binary.BigEndian.Uint32(body))
+			}
+			if err != nil {
+				return err
+			}
+		case 'E':
+			return pgError(body)
+		case 'Z':
+			return nil
+		}
+	}
+}
+
+func (c *postgresConn) Query(ctx context.Context, query string, params []interface{}) (*Result, error) {
+	setDeadline(ctx, c.conn)
+
+	// Parse, bind with text parameters, describe, execute, and sync in one write
+	var msgs []byte
+	parse := append([]byte("\x00"+query+"\x00"), 0, 0)
+	msgs = pgMessage(msgs, 'P', parse)
+
+	bind := []byte("\x00\x00")
+	bind = binary.BigEndian.AppendUint16(bind, 0)
+	bind = binary.BigEndian.AppendUint16(bind, uint16(len(params)))
+	for i, param := range params {
+		text, ok, err := formatParam(param)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+		}
+		if !ok {
+			bind = binary.BigEndian.AppendUint32(bind, 0xFFFFFFFF)
+			continue
+		}
+		bind = binary.BigEndian.AppendUint32(bind, uint32(len(text)))
+		bind = append(bind, text...)
+	}
+	bind = binary.BigEndian.AppendUint16(bind, 0)
+	msgs = pgMessage(msgs, 'B', bind)
+	msgs = pgMessage(msgs, 'D', []byte("P\x00"))
+	msgs = pgMessage(msgs, 'E', []byte("\x00\x00\x00\x00\x00"))
+	msgs = pgMessage(msgs, 'S', nil)
+	if _, err := c.conn.Write(msgs); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var types []uint32
+	var queryErr error
+	for {
+		kind, body, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 'T':
+			result.Columns, types = pgRowDescription(body)
+		case 'D':
+			row, err := pgDataRow(body, types)
+			if err != nil {
+				return nil, err
+			}
+			result.Rows = append(result.Rows, row)
+		case 'C':
+			result.RowCount = len(result.Rows)
+			if len(result.Rows) == 0 {
+				result.RowCount = pgTagRows(strings.TrimRight(string(body), "\x00"))
+			}
+		case 'E':
+			queryErr = pgError(body)
+		case 'Z':
+			if queryErr != nil {
+				return nil, queryErr
+			}
+			return result, nil
+		}
+	}
+}
+
+func (c *postgresConn) Close() error {
+	c.conn.Write(pgMessage(nil, 'X', nil))
+	return c.conn.Close()
+}
+
+// read reads a message, returning its type and body
+func (c *postgresConn) read() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size < 4 {
+		return 0, nil, fmt.Errorf("postgres: malformed message")
+	}
+	if size > pgMaxMessage {
+		return 0, nil, fmt.Errorf("postgres: message of %d bytes exceeds the limit of %d bytes, is the server PostgreSQL?", size, pgMaxMessage)
+	}
+	body := make([]byte, size-4)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+func (c *postgresConn) send(kind byte, body []byte) error {
+	_, err := c.conn.Write(pgMessage(nil, kind, body))
+	return err
+}
+
+// pgMessage appends a message to buf
+func pgMessage(buf []byte, kind byte, body []byte) []byte {
+	buf = append(buf, kind)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(body)+4))
+	return append(buf, body...)
+}
+
+// pgError converts an ErrorResponse to a ServerError
+func pgError(body []byte) error {
+	err := &ServerError{}
+	for _, field := range strings.Split(string(body), "\x00") {
+		if field == "" {
+			continue
+		}
+		switch field[0] {
+		case 'C':
+			err.Code = field[1:]
+		case 'M':
+			err.Message = field[1:]
+		}
+	}
+	return err
+}
+
+// pgRowDescription returns the names and type OIDs of the columns
+func pgRowDescription(body []byte) ([]string, []uint32) {
+	if len(body) < 2 {
+		return nil, nil
+	}
+	count := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	names := make([]string, 0, count)
+	types := make([]uint32, 0, count)
+	for i := 0; i < count; i++ {
+		end := strings.IndexByte(string(body), 0)
+		if end < 0 || len(body) < end+19 {
+			break
+		}
+		names = append(names, string(body[:end]))
+		types = append(types, binary.BigEndian.Uint32(body[end+7:]))
+		body = body[end+19:]
+	}
+	return names, types
+}
+
+// pgDataRow decodes the text values of a row
+func pgDataRow(body []byte, types []uint32) ([]interface{}, error) {
+	if len(body) < 2 {
+		return nil, fmt.Errorf("postgres: malformed row")
+	}
+	count := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	row := make([]interface{}, count)
+	for i := 0; i < count; i++ {
+		if len(body) < 4 {
+			return nil, fmt.Errorf("postgres: malformed row")
+		}
+		size := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if size < 0 {
+			continue
+		}
+		if int(size) > len(body) {
+			return nil, fmt.Errorf("postgres: malformed row")
+		}
+		var oid uint32
+		if i < len(types) {
+			oid = types[i]
+		}
+		row[i] = pgValue(string(body[:size]), oid)
+		body = body[size:]
+	}
+	return row, nil
+}
+
+// pgValue types the text of a value from its column type
+func pgValue(text string, oid uint32) interface{} {
+	switch oid {
+	case pgBool:
+		return text == "t"
+	case pgInt2, pgInt4, pgInt8, pgOID:
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case pgFloat4, pgFloat8, pgNumeric:
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case pgJSON, pgJSONB:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+	return text
+}
+
+// pgTagRows returns the rows affected according to a command tag, e.g.
+// "UPDATE 3" or "INSERT 0 1"
+func pgTagRows(tag string) int {
+	fields := strings.Fields(tag)
+	if len(fields) < 2 {
+		return 0
+	}
+	rows, _ := strconv.Atoi(fields[len(fields)-1])
+	return rows
+}
+
+// scramClient authenticates with SCRAM-SHA-256 (RFC 7677), the default
+// password method of PostgreSQL
+type scramClient struct {
+	password    string
+	nonce       string
+	firstBare   string // client-first-message-bare
+	authMessage string
+	salted      []byte
+}
+
+func newSCRAMClient(password string) *scramClient {
+	nonce := make([]byte, 18)
+	rand.Read(nonce)
+	return &scramClient{password: password, nonce: base64.StdEncoding.EncodeToString(nonce)}
+}
+
+// clientFirst returns the client-first-message; PostgreSQL takes the user
+// from the startup message, so it is empty here
+func (s *scramClient) clientFirst() string {
+	s.firstBare = "n=,r=" + s.nonce
+	return "n,," + s.firstBare
+}
+
+// clientFinal returns the client-final-message answering serverFirst
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			iterations, _ = strconv.Atoi(value)
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, s.nonce) || iterations <= 0 {
+		return "", fmt.Errorf("postgres: invalid SCRAM server message")
+	}
+
+	s.salted, err = pbkdf2.Key(sha256.New, s.password, saltBytes, iterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	clientKey := hmacSHA256(s.salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	s.authMessage = s.firstBare + "," + serverFirst + "," + withoutProof
+	signature := hmacSHA256(storedKey[:], s.authMessage)
+	for i := range clientKey {
+		clientKey[i] ^= signature[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(clientKey), nil
+}
+
+// verifyServer checks the server signature of the server-final-message
+func (s *scramClient) verifyServer(serverFinal string) bool {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(serverFinal, "v="))
+	if err != nil || s.salted == nil {
+		return false
+	}
+	serverKey := hmacSHA256(s.salted, "Server Key")
+	return hmac.Equal(signature, hmacSHA256(serverKey, s.authMessage))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,447 @@
+package sqlclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassword = "s3cret"
+
+// listen starts a fake server calling serve for each connection
+func listen(t *testing.T, serve func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// fakePostgres authenticates with SCRAM-SHA-256 and answers queries on
+// a users table
+type fakePostgres struct {
+	mu     sync.Mutex
+	params [][]interface{}
+}
+
+func (p *fakePostgres) serve(t *testing.T, conn net.Conn) {
+	r := bufio.NewReader(conn)
+	startup := readStartup(r)
+	if binary.BigEndian.Uint32(startup) == pgSSLRequest {
+		conn.Write([]byte("N"))
+		startup = readStartup(r)
+	}
+	assert.Equal(t, uint32(pgProtocolVersion), binary.BigEndian.Uint32(startup))
+	assert.Contains(t, string(startup), "user\x00app\x00database\x00shop\x00")
+
+	send := func(kind byte, body []byte) { conn.Write(pgMessage(nil, kind, body)) }
+	auth := func(code uint32, data string) { send('R', append(binary.BigEndian.AppendUint32(nil, code), data...)) }
+
+	// SCRAM-SHA-256, verifying the proof as the server does
+	auth(pgAuthSASL, "SCRAM-SHA-256\x00\x00")
+	_, msg := readPG(r)
+	clientFirst := string(msg[len("SCRAM-SHA-256\x00")+4:])
+	clientFirstBare := strings.TrimPrefix(clientFirst, "n,,")
+	nonce := strings.TrimPrefix(clientFirstBare, "n=,r=") + "server"
+	salt := []byte("saltsalt")
+	serverFirst := "r=" + nonce + ",s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+	auth(pgAuthSASLContinue, serverFirst)
+
+	_, msg = readPG(r)
+	withoutProof, proof, _ := strings.Cut(string(msg), ",p=")
+	salted, _ := pbkdf2.Key(sha256.New, testPassword, salt, 4096, sha256.Size)
+	storedKey := sha256.Sum256(hmacSHA256(salted, "Client Key"))
+	authMessage := clientFirstBare + "," + serverFirst + "," + withoutProof
+	clientKey, _ := base64.StdEncoding.DecodeString(proof)
+	for i, b := range hmacSHA256(storedKey[:], authMessage) {
+		if i < len(clientKey) {
+			clientKey[i] ^= b
+		}
+	}
+	if recovered := sha256.Sum256(clientKey); recovered != storedKey {
+		send('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed for user \"app\"\x00\x00"))
+		return
+	}
+	auth(pgAuthSASLFinal, "v="+base64.StdEncoding.EncodeToString(hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)))
+	auth(pgAuthOK, "")
+	send('S', []byte("server_version\x0016.0\x00"))
+	send('Z', []byte("I"))
+
+	for {
+		var query string
+		var params []interface{}
+		for {
+			kind, body := readPG(r)
+			if kind == 0 || kind == 'X' {
+				return
+			}
+			switch kind {
+			case 'P':
+				query, _, _ = strings.Cut(string(body[1:]), "\x00")
+			case 'B':
+				d := body[4:]
+				count := int(binary.BigEndian.Uint16(d))
+				d = d[2:]
+				for i := 0; i < count; i++ {
+					size := int32(binary.BigEndian.Uint32(d))
+					d = d[4:]
+					if size < 0 {
+						params = append(params, nil)
+						continue
+					}
+					params = append(params, string(d[:size]))
+					d = d[size:]
+				}
+			}
+			if kind == 'S' {
+				break
+			}
+		}
+		p.mu.Lock()
+		p.params = append(p.params, params)
+		p.mu.Unlock()
+
+		send('1', nil)
+		switch {
+		case strings.HasPrefix(query, "SELECT"):
+			send('2', nil)
+			var desc []byte
+			desc = binary.BigEndian.AppendUint16(desc, 4)
+			for _, col := range []struct {
+				name string
+				oid  uint32
+			}{{"id", pgInt4}, {"name", 25}, {"active", pgBool}, {"meta", pgJSONB}} {
+				desc = append(desc, col.name+"\x00"...)
+				desc = append(desc, make([]byte, 6)...)
+				desc = binary.BigEndian.AppendUint32(desc, col.oid)
+				desc = append(desc, make([]byte, 8)...)
+			}
+			send('T', desc)
+			for _, name := range []string{"alice", "bob"} {
+				var row []byte
+				row = binary.BigEndian.AppendUint16(row, 4)
+				for _, value := range []string{"7", name, "t"} {
+					row = binary.BigEndian.AppendUint32(row, uint32(len(value)))
+					row = append(row, value...)
+				}
+				row = binary.BigEndian.AppendUint32(row, 0xFFFFFFFF)
+				send('D', row)
+			}
+			send('C', []byte("SELECT 2\x00"))
+		case strings.HasPrefix(query, "UPDATE"):
+			send('2', nil)
+			send('n', nil)
+			send('C', []byte("UPDATE 3\x00"))
+		default:
+			send('E', []byte("SERROR\x00C42601\x00Msyntax error at or near \"BOGUS\"\x00\x00"))
+		}
+		send('Z', []byte("I"))
+	}
+}
+
+func readStartup(r io.Reader) []byte {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return make([]byte, 4)
+	}
+	body := make([]byte, binary.BigEndian.Uint32(size[:])-4)
+	io.ReadFull(r, body)
+	return body
+}
+
+func readPG(r io.Reader) (byte, []byte) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	io.ReadFull(r, body)
+	return header[0], body
+}
+
+func TestPostgresQuery(t *testing.T) {
+	server := &fakePostgres{}
+	addr := listen(t, func(conn net.Conn) { server.serve(t, conn) })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := Open(ctx, "postgres://app:"+testPassword+"@"+addr+"/shop", time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	result, err := conn.Query(ctx, "SELECT id, name, active, meta FROM users WHERE id = $1 AND note = $2", []interface{}{float64(7), nil})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "active", "meta"}, result.Columns)
+	assert.Equal(t, 2, result.RowCount)
+	assert.Equal(t, []interface{}{int64(7), "alice", true, nil}, result.Rows[0])
+	assert.JSONEq(t, `[{"id":7,"name":"alice","active":true,"meta":null},{"id":7,"name":"bob","active":true,"meta":null}]`, string(result.JSON()))
+
+	result, err = conn.Query(ctx, "UPDATE users SET active = $1", []interface{}{false})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.RowCount)
+	assert.Empty(t, result.Rows)
+
+	_, err = conn.Query(ctx, "BOGUS", nil)
+	var serverErr *ServerError
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, "42601", serverErr.Code)
+
+	// The connection stays usable after a query error
+	_, err = conn.Query(ctx, "UPDATE users SET active = true", nil)
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []interface{}{"7", nil}, server.params[0])
+	assert.Equal(t, []interface{}{"false"}, server.params[1])
+}
+
+func TestPostgresOpen_WrongPassword(t *testing.T) {
+	server := &fakePostgres{}
+	addr := listen(t, func(conn net.Conn) { server.serve(t, conn) })
+
+	_, err := Open(context.Background(), "postgres://app:wrong@"+addr+"/shop?sslmode=prefer", time.Second)
+	var serverErr *ServerError
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, "28P01", serverErr.Code)
+}
+
+// fakeMySQL authenticates with a plugin and answers queries on a users table
+type fakeMySQL struct {
+	plugin string
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (m *fakeMySQL) serve(t *testing.T, conn net.Conn) {
+	r := bufio.NewReader(conn)
+	write := func(seq byte, packet []byte) {
+		conn.Write(append([]byte{byte(len(packet)), byte(len(packet) >> 8), byte(len(packet) >> 16), seq}, packet...))
+	}
+
+	scramble := []byte("abcdefghijklmnopqrst")
+	caps := uint32(mysqlProtocol41 | mysqlSecureConnection | mysqlPluginAuth | mysqlConnectWithDB | mysqlTransactions)
+	handshake := append([]byte{10}, "8.0.36\x00"...)
+	handshake = binary.LittleEndian.AppendUint32(handshake, 1)
+	handshake = append(handshake, scramble[:8]...)
+	handshake = append(handshake, 0)
+	handshake = binary.LittleEndian.AppendUint16(handshake, uint16(caps))
+	handshake = append(handshake, mysqlCharset, 2, 0)
+	handshake = binary.LittleEndian.AppendUint16(handshake, uint16(caps>>16))
+	handshake = append(handshake, 21)
+	handshake = append(handshake, make([]byte, 10)...)
+	handshake = append(handshake, scramble[8:]...)
+	handshake = append(handshake, 0)
+	handshake = append(handshake, m.plugin+"\x00"...)
+	write(0, handshake)
+
+	_, response := readMySQL(r)
+	d := &decoder{buf: response}
+	flags := binary.LittleEndian.Uint32(d.next(4))
+	d.next(28)
+	assert.Equal(t, "app", d.nulString())
+	authData := d.next(int(d.byte()))
+	if flags&mysqlConnectWithDB != 0 {
+		assert.Equal(t, "shop", d.nulString())
+	}
+	assert.Equal(t, m.plugin, d.nulString())
+
+	// Verify the scramble against the stored hash, as the server does
+	var ok bool
+	seq := byte(2)
+	switch m.plugin {
+	case "mysql_native_password":
+		stage1 := sha1.Sum([]byte(testPassword))
+		stored := sha1.Sum(stage1[:])
+		mix := sha1.Sum(append(append([]byte(nil), scramble...), stored[:]...))
+		candidate := make([]byte, len(authData))
+		for i := range authData {
+			candidate[i] = authData[i] ^ mix[i%len(mix)]
+		}
+		ok = sha1.Sum(candidate) == stored
+	case "caching_sha2_password":
+		stage1 := sha256.Sum256([]byte(testPassword))
+		stored := sha256.Sum256(stage1[:])
+		mix := sha256.Sum256(append(stored[:], scramble...))
+		candidate := make([]byte, len(authData))
+		for i := range authData {
+			candidate[i] = authData[i] ^ mix[i%len(mix)]
+		}
+		ok = sha256.Sum256(candidate) == stored
+		if ok {
+			write(seq, []byte{0x01, 3})
+			seq++
+		}
+	}
+	if !ok {
+		write(seq, append([]byte{0xFF, 0x15, 0x04}, "#28000Access denied for user 'app'"...))
+		return
+	}
+	write(seq, []byte{0, 0, 0, 2, 0, 0, 0})
+
+	for {
+		_, packet := readMySQL(r)
+		if len(packet) == 0 || packet[0] == mysqlComQuit {
+			return
+		}
+		query := string(packet[1:])
+		m.mu.Lock()
+		m.queries = append(m.queries, query)
+		m.mu.Unlock()
+
+		switch {
+		case strings.HasPrefix(query, "SELECT"):
+			seq := byte(1)
+			next := func(packet []byte) {
+				write(seq, packet)
+				seq++
+			}
+			next([]byte{3})
+			for _, col := range []struct {
+				name string
+				typ  byte
+			}{{"id", mysqlLongLong}, {"name", 253}, {"score", mysqlNewDecimal}} {
+				var def []byte
+				for _, s := range []string{"def", "shop", "users", "users", col.name, col.name} {
+					def = append(def, byte(len(s)))
+					def = append(def, s...)
+				}
+				def = append(def, 0x0c, mysqlCharset, 0, 0, 0, 1, 0, col.typ, 0, 0, 0, 0, 0)
+				next(def)
+			}
+			next([]byte{0xFE, 0, 0, 2, 0})
+			next(append([]byte{1, '7', 5}, "alice\xFB"...))
+			next([]byte{0xFE, 0, 0, 2, 0})
+		case strings.HasPrefix(query, "UPDATE"):
+			write(1, []byte{0, 3, 0, 2, 0, 0, 0})
+		default:
+			write(1, append([]byte{0xFF, 0x28, 0x04}, "#42000You have an error in your SQL syntax"...))
+		}
+	}
+}
+
+func readMySQL(r io.Reader) (byte, []byte) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	packet := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	io.ReadFull(r, packet)
+	return header[3], packet
+}
+
+func TestMySQLQuery(t *testing.T) {
+	for _, plugin := range []string{"mysql_native_password", "caching_sha2_password"} {
+		t.Run(plugin, func(t *testing.T) {
+			server := &fakeMySQL{plugin: plugin}
+			addr := listen(t, func(conn net.Conn) { server.serve(t, conn) })
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			conn, err := Open(ctx, "mysql://app:"+testPassword+"@"+addr+"/shop", time.Second)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			result, err := conn.Query(ctx, "SELECT id, name, score FROM users WHERE name = ? AND note <> '?'", []interface{}{"o'brien"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"id", "name", "score"}, result.Columns)
+			assert.Equal(t, 1, result.RowCount)
+			assert.Equal(t, []interface{}{int64(7), "alice", nil}, result.Rows[0])
+
+			result, err = conn.Query(ctx, "UPDATE users SET active = ?", []interface{}{true})
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.RowCount)
+
+			_, err = conn.Query(ctx, "BOGUS", nil)
+			var serverErr *ServerError
+			require.ErrorAs(t, err, &serverErr)
+			assert.Equal(t, "1064", serverErr.Code)
+			assert.Equal(t, "You have an error in your SQL syntax", serverErr.Message)
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			assert.Equal(t, `SELECT id, name, score FROM users WHERE name = 'o\'brien' AND note <> '?'`, server.queries[0])
+			assert.Equal(t, "UPDATE users SET active = true", server.queries[1])
+		})
+	}
+}
+
+func TestMySQLOpen_WrongPassword(t *testing.T) {
+	server := &fakeMySQL{plugin: "mysql_native_password"}
+	addr := listen(t, func(conn net.Conn) { server.serve(t, conn) })
+
+	_, err := Open(context.Background(), "mysql://app:wrong@"+addr+"/shop", time.Second)
+	var serverErr *ServerError
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, "1045", serverErr.Code)
+}
+
+func TestMySQLInterpolate(t *testing.T) {
+	query, err := mysqlInterpolate("SELECT ? , `a?` , \"b\\\"?\" , ?", []interface{}{float64(1.5), "line\nbreak"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1.5 , `a?` , \"b\\\"?\" , 'line\\nbreak'", query)
+
+	_, err = mysqlInterpolate("SELECT ?", []interface{}{"a", "b"})
+	assert.Error(t, err)
+	_, err = mysqlInterpolate("SELECT ?, ?", []interface{}{"a"})
+	assert.Error(t, err)
+	_, err = mysqlInterpolate("SELECT ?", []interface{}{[]string{"a"}})
+	assert.Error(t, err)
+}
+
+func TestOpen_Errors(t *testing.T) {
+	_, err := Open(context.Background(), "sqlite://file.db", time.Second)
+	assert.ErrorContains(t, err, "unsupported DSN scheme 'sqlite'")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	_, err = Open(context.Background(), "postgres://app@"+addr+"/shop", time.Second)
+	var opErr *net.OpError
+	assert.True(t, errors.As(err, &opErr))
+}
+
+func TestPostgresOpen_NotPostgres(t *testing.T) {
+	addr := listen(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 512))
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+	})
+
+	_, err := Open(context.Background(), "postgres://app@"+addr+"/shop", time.Second)
+	assert.ErrorContains(t, err, "exceeds the limit")
+}
+
+func TestPgTagRows(t *testing.T) {
+	assert.Equal(t, 1, pgTagRows("INSERT 0 1"))
+	assert.Equal(t, 12, pgTagRows("DELETE 12"))
+	assert.Equal(t, 0, pgTagRows("CREATE TABLE"))
+	assert.True(t, bytes.Equal([]byte("[]"), (&Result{}).JSON()))
+}
@@ -0,0 +1,344 @@
+// Package stream writes the events of a run as NDJSON, one JSON object per
+// line, so external tools can follow a run while it is in progress.
+package stream
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+)
+
+// Event types, in the order they are emitted
+const (
+	EventRunStarted      = "run_started"
+	EventRequestFinished = "request_finished"
+	EventIntervalSummary = "interval_summary"
+	EventRunFinished     = "run_finished"
+)
+
+// SLO events, emitted between request_finished events when the burn rate of
+// an SLO crosses its max_burn_rate
+const (
+	EventSLOAlert     = "slo_alert"
+	EventSLORecovered = "slo_recovered"
+)
+
+// RunStarted is emitted once before the first request
+type RunStarted struct {
+	Event            string    `json:"event"`
+	Time             time.Time `json:"time"`
+	Name             string    `json:"name"`
+	RunID            string    `json:"run_id,omitempty"`
+	Workers          int       `json:"workers"`
+	ExpectedRequests int       `json:"expected_requests,omitempty"` // An estimate for duration-based runs
+}
+
+// RequestFinished is emitted for every executed or skipped request
+type RequestFinished struct {
+	Event          string    `json:"event"`
+	Time           time.Time `json:"time"`
+	Test           string    `json:"test"`
+	Method         string    `json:"method"`
+	URL            string    `json:"url"`
+	StatusCode     int       `json:"status_code"`
+	ResponseTimeMs float64   `json:"response_time_ms"`
+	Success        bool      `json:"success"`
+	Skipped        bool      `json:"skipped,omitempty"`
+	Throttled      bool      `json:"throttled,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	ErrorCategory  string    `json:"error_category,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+}
+
+// IntervalSummary is emitted every interval with the requests finished
+// since the previous one and the running totals
+type IntervalSummary struct {
+	Event          string    `json:"event"`
+	Time           time.Time `json:"time"`
+	ElapsedSeconds float64   `json:"elapsed_s"`
+	Requests       int       `json:"requests"`
+	Failed         int       `json:"failed"`
+	RequestsPerSec float64   `json:"requests_per_sec"`
+	P95Ms          float64   `json:"p95_ms"`
+	TotalRequests  int       `json:"total_requests"`
+	TotalFailed    int       `json:"total_failed"`
+}
+
+// SLOAlert is emitted when an SLO raises an alert (slo_alert) and when its
+// burn rate falls back under the limit (slo_recovered)
+type SLOAlert struct {
+	Event       string    `json:"event"`
+	Time        time.Time `json:"time"`
+	SLO         string    `json:"slo"`
+	BurnRate    float64   `json:"burn_rate"`
+	BadRequests int       `json:"bad_requests,omitempty"`
+	Requests    int       `json:"requests,omitempty"`
+	Abort       bool      `json:"abort,omitempty"` // The alert stops the run
+}
+
+// RunFinished is emitted once with the final summary
+type RunFinished struct {
+	Event              string    `json:"event"`
+	Time               time.Time `json:"time"`
+	RunID              string    `json:"run_id,omitempty"`
+	TotalRequests      int       `json:"total_requests"`
+	SuccessfulReqs     int       `json:"successful_requests"`
+	FailedReqs         int       `json:"failed_requests"`
+	SkippedReqs        int       `json:"skipped_requests"`
+	ThrottledReqs      int       `json:"throttled_requests,omitempty"`
+	TotalTimeSeconds   float64   `json:"total_time_s"`
+	RequestsPerSec     float64   `json:"requests_per_sec"`
+	AvgResponseTimeMs  float64   `json:"avg_response_time_ms"`
+	P95Ms              float64   `json:"p95_ms"`
+	Passed             bool      `json:"passed"`
+	Interrupted        bool      `json:"interrupted,omitempty"`
+	MaxDurationReached bool      `json:"max_duration_reached,omitempty"`
+	AbortedBy          string    `json:"aborted_by_slo,omitempty"`
+}
+
+// Stats are the running totals of a run, for live status
+type Stats struct {
+	ElapsedSeconds float64          `json:"elapsed_s"`
+	TotalRequests  int              `json:"total_requests"`
+	TotalFailed    int              `json:"total_failed"`
+	RequestsPerSec float64          `json:"requests_per_sec"`
+	LastInterval   *IntervalSummary `json:"last_interval,omitempty"`
+}
+
+// Writer emits the events of one run. It is safe for concurrent use.
+type Writer struct {
+	mu       sync.Mutex
+	encoder  *json.Encoder
+	interval time.Duration
+	err      error
+
+	start         time.Time
+	end           time.Time
+	intervalStart time.Time
+	times         []time.Duration
+	failed        int
+	totalRequests int
+	totalFailed   int
+	lastInterval  *IntervalSummary
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// New creates a writer emitting interval summaries every interval; an
+// interval of 0 disables them
+func New(w io.Writer, interval time.Duration) *Writer {
+	return &Writer{encoder: json.NewEncoder(w), interval: interval}
+}
+
+// RunStarted emits run_started and starts the interval summaries
+func (w *Writer) RunStarted(name, runID string, workers, expectedRequests int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.start = time.Now()
+	w.intervalStart = w.start
+	w.write(RunStarted{
+		Event:            EventRunStarted,
+		Time:             w.start,
+		Name:             name,
+		RunID:            runID,
+		Workers:          workers,
+		ExpectedRequests: expectedRequests,
+	})
+
+	if w.interval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.tick(w.stop, w.done)
+	}
+}
+
+func (w *Writer) tick(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			w.mu.Lock()
+			w.flushInterval(now)
+			w.mu.Unlock()
+		}
+	}
+}
+
+// RequestFinished emits request_finished for a result
+func (w *Writer) RequestFinished(result models.TestResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !result.Skipped {
+		w.times = append(w.times, result.ResponseTime)
+		w.totalRequests++
+		if !result.Success && !result.Throttled {
+			w.failed++
+			w.totalFailed++
+		}
+	}
+	w.write(RequestFinished{
+		Event:          EventRequestFinished,
+		Time:           result.Timestamp,
+		Test:           result.TestName,
+		Method:         result.Method,
+		URL:            result.URL,
+		StatusCode:     result.StatusCode,
+		ResponseTimeMs: milliseconds(result.ResponseTime),
+		Success:        result.Success,
+		Skipped:        result.Skipped,
+		Throttled:      result.Throttled,
+		Error:          result.Error,
+		ErrorCategory:  result.ErrorCategory,
+		RequestID:      result.RequestID,
+	})
+}
+
+// SLOAlert emits slo_alert for an alert just raised; abort tells whether it
+// stops the run
+func (w *Writer) SLOAlert(alert models.SLOAlert, abort bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.write(SLOAlert{
+		Event:       EventSLOAlert,
+		Time:        alert.Time,
+		SLO:         alert.SLO,
+		BurnRate:    alert.BurnRate,
+		BadRequests: alert.BadRequests,
+		Requests:    alert.Requests,
+		Abort:       abort,
+	})
+}
+
+// SLORecovered emits slo_recovered for an alert just cleared, with the peak
+// burn rate it reached
+func (w *Writer) SLORecovered(alert models.SLOAlert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.write(SLOAlert{
+		Event:    EventSLORecovered,
+		Time:     alert.RecoveredAt,
+		SLO:      alert.SLO,
+		BurnRate: alert.PeakBurnRate,
+	})
+}
+
+// RunFinished stops the interval summaries, emits the last partial interval
+// and run_finished, and returns the first error met while writing
+func (w *Writer) RunFinished(summary *models.Summary) error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+		w.stop = nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	w.end = now
+	if w.interval > 0 && len(w.times) > 0 {
+		w.flushInterval(now)
+	}
+	w.write(RunFinished{
+		Event:              EventRunFinished,
+		Time:               now,
+		RunID:              summary.RunID,
+		TotalRequests:      summary.TotalRequests,
+		SuccessfulReqs:     summary.SuccessfulReqs,
+		FailedReqs:         summary.FailedReqs,
+		SkippedReqs:        summary.SkippedReqs,
+		ThrottledReqs:      summary.ThrottledReqs,
+		TotalTimeSeconds:   summary.TotalTime.Seconds(),
+		RequestsPerSec:     summary.RequestsPerSec,
+		AvgResponseTimeMs:  milliseconds(summary.AvgResponseTime),
+		P95Ms:              milliseconds(summary.P95ResponseTime),
+		Passed:             summary.Passed(),
+		Interrupted:        summary.Interrupted,
+		MaxDurationReached: summary.MaxDurationReached,
+		AbortedBy:          summary.AbortedBy,
+	})
+	return w.err
+}
+
+// Stats returns the running totals and the last interval summary; they
+// stop changing once the run finished
+func (w *Writer) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := Stats{
+		TotalRequests: w.totalRequests,
+		TotalFailed:   w.totalFailed,
+		LastInterval:  w.lastInterval,
+	}
+	if !w.start.IsZero() {
+		end := w.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		stats.ElapsedSeconds = end.Sub(w.start).Seconds()
+		if stats.ElapsedSeconds > 0 {
+			stats.RequestsPerSec = float64(w.totalRequests) / stats.ElapsedSeconds
+		}
+	}
+	return stats
+}
+
+// flushInterval emits interval_summary and resets the interval; the caller
+// holds the lock
+func (w *Writer) flushInterval(now time.Time) {
+	var rps float64
+	if elapsed := now.Sub(w.intervalStart).Seconds(); elapsed > 0 {
+		rps = float64(len(w.times)) / elapsed
+	}
+	interval := IntervalSummary{
+		Event:          EventIntervalSummary,
+		Time:           now,
+		ElapsedSeconds: now.Sub(w.start).Seconds(),
+		Requests:       len(w.times),
+		Failed:         w.failed,
+		RequestsPerSec: rps,
+		P95Ms:          milliseconds(p95(w.times)),
+		TotalRequests:  w.totalRequests,
+		TotalFailed:    w.totalFailed,
+	}
+	w.write(interval)
+	w.lastInterval = &interval
+	w.intervalStart = now
+	w.times = w.times[:0]
+	w.failed = 0
+}
+
+// write encodes an event as one line, keeping the first error; the caller
+// holds the lock
+func (w *Writer) write(event interface{}) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.encoder.Encode(event)
+}
+
+// p95 returns the nearest-rank 95th percentile, sorting times in place
+func p95(times []time.Duration) time.Duration {
+	if len(times) == 0 {
+		return 0
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[(len(times)*95+99)/100-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
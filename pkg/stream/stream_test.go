@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/andrearaponi/bombardino/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), "line %q", scanner.Text())
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, time.Hour)
+
+	w.RunStarted("API Tests", "run-1", 4, 3)
+	w.RequestFinished(models.TestResult{TestName: "login", Method: "POST", URL: "http://api/login", StatusCode: 200, ResponseTime: 20 * time.Millisecond, Success: true})
+	w.RequestFinished(models.TestResult{TestName: "search", Method: "GET", URL: "http://api/search", StatusCode: 500, ResponseTime: 80 * time.Millisecond, Error: "unexpected status code: 500", ErrorCategory: "unexpected_status", RequestID: "8f14e45f"})
+	w.RequestFinished(models.TestResult{TestName: "orders", Method: "GET", URL: "http://api/orders", Skipped: true})
+	require.NoError(t, w.RunFinished(&models.Summary{RunID: "run-1", TotalRequests: 3, SuccessfulReqs: 1, FailedReqs: 1, SkippedReqs: 1, P95ResponseTime: 80 * time.Millisecond}))
+
+	events := decode(t, &buf)
+	require.Len(t, events, 6)
+	kinds := []string{}
+	for _, event := range events {
+		kinds = append(kinds, event["event"].(string))
+	}
+	assert.Equal(t, []string{
+		EventRunStarted, EventRequestFinished, EventRequestFinished, EventRequestFinished,
+		EventIntervalSummary, EventRunFinished,
+	}, kinds)
+
+	assert.Equal(t, "API Tests", events[0]["name"])
+	assert.Equal(t, "run-1", events[0]["run_id"])
+	assert.Equal(t, 4.0, events[0]["workers"])
+	assert.Equal(t, "run-1", events[5]["run_id"])
+
+	assert.Equal(t, "search", events[2]["test"])
+	assert.Equal(t, 500.0, events[2]["status_code"])
+	assert.Equal(t, 80.0, events[2]["response_time_ms"])
+	assert.Equal(t, "unexpected_status", events[2]["error_category"])
+	assert.Equal(t, "8f14e45f", events[2]["request_id"])
+	assert.NotContains(t, events[1], "request_id")
+	assert.Equal(t, true, events[3]["skipped"])
+
+	// The last partial interval is flushed on finish; skipped requests are not counted
+	interval := events[4]
+	assert.Equal(t, 2.0, interval["requests"])
+	assert.Equal(t, 1.0, interval["failed"])
+	assert.Equal(t, 80.0, interval["p95_ms"])
+	assert.Equal(t, 2.0, interval["total_requests"])
+
+	finished := events[5]
+	assert.Equal(t, 3.0, finished["total_requests"])
+	assert.Equal(t, 80.0, finished["p95_ms"])
+	assert.Equal(t, false, finished["passed"])
+
+	stats := w.Stats()
+	assert.Equal(t, 2, stats.TotalRequests)
+	assert.Equal(t, 1, stats.TotalFailed)
+	require.NotNil(t, stats.LastInterval)
+	assert.Equal(t, 80.0, stats.LastInterval.P95Ms)
+	assert.Equal(t, stats, w.Stats(), "stats stop changing once the run finished")
+}
+
+func TestWriter_Intervals(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, 20*time.Millisecond)
+
+	w.RunStarted("Intervals", "", 1, 0)
+	for i := 0; i < 3; i++ {
+		w.RequestFinished(models.TestResult{TestName: "ping", ResponseTime: time.Millisecond, Success: true})
+		time.Sleep(30 * time.Millisecond)
+	}
+	require.NoError(t, w.RunFinished(&models.Summary{TotalRequests: 3, SuccessfulReqs: 3}))
+
+	var intervals []map[string]interface{}
+	for _, event := range decode(t, &buf) {
+		if event["event"] == EventIntervalSummary {
+			intervals = append(intervals, event)
+		}
+	}
+	require.GreaterOrEqual(t, len(intervals), 2)
+	last := intervals[len(intervals)-1]
+	assert.Equal(t, 3.0, last["total_requests"])
+}
+
+func TestWriter_NoIntervals(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, 0)
+
+	w.RunStarted("No intervals", "", 1, 1)
+	w.RequestFinished(models.TestResult{TestName: "ping", Success: true})
+	require.NoError(t, w.RunFinished(&models.Summary{TotalRequests: 1, SuccessfulReqs: 1}))
+
+	for _, event := range decode(t, &buf) {
+		assert.NotEqual(t, EventIntervalSummary, event["event"])
+	}
+}
+
+func TestP95(t *testing.T) {
+	times := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		times = append(times, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 95*time.Millisecond, p95(times))
+	assert.Equal(t, time.Duration(0), p95(nil))
+	assert.Equal(t, 7*time.Millisecond, p95([]time.Duration{7 * time.Millisecond}))
+}
+
+func TestWriter_SLOAlerts(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, 0)
+
+	raised := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	alert := models.SLOAlert{SLO: "checkout", Time: raised, BurnRate: 12.5, PeakBurnRate: 20, BadRequests: 5, Requests: 40, RecoveredAt: raised.Add(time.Minute)}
+	w.SLOAlert(alert, true)
+	w.SLORecovered(alert)
+	require.NoError(t, w.RunFinished(&models.Summary{AbortedBy: "checkout"}))
+
+	events := decode(t, &buf)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventSLOAlert, events[0]["event"])
+	assert.Equal(t, "checkout", events[0]["slo"])
+	assert.Equal(t, 12.5, events[0]["burn_rate"])
+	assert.Equal(t, 5.0, events[0]["bad_requests"])
+	assert.Equal(t, 40.0, events[0]["requests"])
+	assert.Equal(t, true, events[0]["abort"])
+
+	assert.Equal(t, EventSLORecovered, events[1]["event"])
+	assert.Equal(t, 20.0, events[1]["burn_rate"])
+	assert.Equal(t, "2024-01-01T12:01:00Z", events[1]["time"])
+	assert.NotContains(t, events[1], "abort")
+
+	assert.Equal(t, "checkout", events[2]["aborted_by_slo"])
+	assert.Equal(t, false, events[2]["passed"])
+}
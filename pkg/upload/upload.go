@@ -0,0 +1,190 @@
+// Package upload puts reports in object storage (Amazon S3, Google Cloud
+// Storage, and Azure Blob Storage) for the report_upload config block, so
+// containerized runs do not need a volume to hand their results over.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andrearaponi/bombardino/pkg/awssig"
+	"github.com/andrearaponi/bombardino/pkg/gcpauth"
+)
+
+// Uploader uploads files to s3://, gs://, and az:// destinations. Credentials
+// come from the environment:
+//   - S3: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION
+//   - GCS: GOOGLE_OAUTH_ACCESS_TOKEN, or the GCP metadata server
+//   - Azure: AZURE_STORAGE_SAS_TOKEN
+type Uploader struct {
+	client *http.Client
+	getenv func(string) string
+	now    func() time.Time
+
+	// Endpoint overrides (e.g. for MinIO, emulators, or tests). The S3 and GCS
+	// ones default to AWS_ENDPOINT_URL_S3 and STORAGE_EMULATOR_HOST.
+	s3Endpoint    string
+	gcsEndpoint   string
+	azureEndpoint string
+	metadataURL   string
+}
+
+// New creates an uploader
+func New() *Uploader {
+	return &Uploader{
+		client:      &http.Client{Timeout: 5 * time.Minute},
+		getenv:      os.Getenv,
+		now:         time.Now,
+		metadataURL: gcpauth.MetadataURL,
+	}
+}
+
+// Destination replaces {timestamp} in a destination with the time a run
+// started, so every run gets its own object
+func Destination(dest string, startedAt time.Time) string {
+	return strings.ReplaceAll(dest, "{timestamp}", startedAt.UTC().Format("20060102T150405Z"))
+}
+
+// Upload stores data at dest, e.g. s3://bucket/reports/report.json
+func (u *Uploader) Upload(ctx context.Context, dest, contentType string, data []byte) error {
+	scheme, rest, _ := strings.Cut(dest, "://")
+	container, object, _ := strings.Cut(rest, "/")
+	if container == "" || object == "" {
+		return fmt.Errorf("invalid destination %q (expected scheme://bucket/object)", dest)
+	}
+
+	var req *http.Request
+	var err error
+	switch scheme {
+	case "s3":
+		req, err = u.s3Request(ctx, container, object, contentType, data)
+	case "gs":
+		req, err = u.gcsRequest(ctx, container, object, contentType, data)
+	case "az":
+		req, err = u.azureRequest(ctx, container, object, contentType, data)
+	default:
+		return fmt.Errorf("unsupported destination %q (expected s3://, gs:// or az://)", dest)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", withoutQuery(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload failed: unexpected status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (u *Uploader) s3Request(ctx context.Context, bucket, key, contentType string, data []byte) (*http.Request, error) {
+	region, err := awssig.RegionFromEnv("", u.getenv)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := awssig.CredentialsFromEnv(u.getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := u.s3Endpoint
+	if endpoint == "" {
+		endpoint = u.getenv("AWS_ENDPOINT_URL_S3")
+	}
+	var target string
+	if endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	awssig.Sign(req, data, creds, region, "s3", u.now().UTC())
+	return req, nil
+}
+
+func (u *Uploader) gcsRequest(ctx context.Context, bucket, object, contentType string, data []byte) (*http.Request, error) {
+	endpoint := u.gcsEndpoint
+	emulator := false
+	if endpoint == "" {
+		if host := u.getenv("STORAGE_EMULATOR_HOST"); host != "" {
+			endpoint, emulator = host, true
+			if !strings.Contains(endpoint, "://") {
+				endpoint = "http://" + endpoint
+			}
+		} else {
+			endpoint = "https://storage.googleapis.com"
+		}
+	}
+
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(bucket), url.QueryEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	// Emulators accept unauthenticated requests
+	if !emulator {
+		token, err := gcpauth.Token(ctx, u.client, u.getenv, u.metadataURL)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+func (u *Uploader) azureRequest(ctx context.Context, account, blobPath, contentType string, data []byte) (*http.Request, error) {
+	sas := strings.TrimPrefix(u.getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sas == "" {
+		return nil, fmt.Errorf("AZURE_STORAGE_SAS_TOKEN must be set")
+	}
+	if !strings.Contains(blobPath, "/") {
+		return nil, fmt.Errorf("invalid Azure destination (expected az://account/container/blob)")
+	}
+
+	var target string
+	if u.azureEndpoint != "" {
+		target = strings.TrimSuffix(u.azureEndpoint, "/") + "/" + account + "/" + blobPath
+	} else {
+		target = fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, blobPath)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target+"?"+sas, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", withoutQuery(err))
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-version", "2021-08-06")
+	return req, nil
+}
+
+// withoutQuery removes the query from the URL of an error, as it can carry
+// a credential such as an Azure SAS token
+func withoutQuery(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	target, _, _ := strings.Cut(urlErr.URL, "?")
+	return &url.Error{Op: urlErr.Op, URL: target, Err: urlErr.Err}
+}
@@ -0,0 +1,150 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a storage endpoint that remembers the last request it got
+type recorder struct {
+	server *httptest.Server
+	req    *http.Request
+	body   string
+	status int
+}
+
+func newRecorder(t *testing.T) *recorder {
+	rec := &recorder{status: http.StatusOK}
+	rec.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.req, rec.body = r, string(body)
+		w.WriteHeader(rec.status)
+	}))
+	t.Cleanup(rec.server.Close)
+	return rec
+}
+
+func newUploader(env map[string]string) *Uploader {
+	u := New()
+	u.getenv = func(key string) string { return env[key] }
+	return u
+}
+
+func TestUpload_S3(t *testing.T) {
+	rec := newRecorder(t)
+	u := newUploader(map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "SECRET",
+		"AWS_REGION":            "eu-west-1",
+	})
+	u.s3Endpoint = rec.server.URL
+
+	require.NoError(t, u.Upload(context.Background(), "s3://perf/nightly/report.json", "application/json", []byte(`{"success":true}`)))
+	assert.Equal(t, http.MethodPut, rec.req.Method)
+	assert.Equal(t, "/perf/nightly/report.json", rec.req.URL.Path)
+	assert.Equal(t, "application/json", rec.req.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rec.req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+	assert.Contains(t, rec.req.Header.Get("Authorization"), "content-type")
+	assert.Equal(t, `{"success":true}`, rec.body)
+
+	u = newUploader(nil)
+	err := u.Upload(context.Background(), "s3://perf/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "aws region not configured")
+}
+
+func TestUpload_GCS(t *testing.T) {
+	rec := newRecorder(t)
+	u := newUploader(map[string]string{"GOOGLE_OAUTH_ACCESS_TOKEN": "ya29.token"})
+	u.gcsEndpoint = rec.server.URL
+
+	require.NoError(t, u.Upload(context.Background(), "gs://perf/nightly/report.html", "text/html; charset=utf-8", []byte("<html>")))
+	assert.Equal(t, http.MethodPost, rec.req.Method)
+	assert.Equal(t, "/upload/storage/v1/b/perf/o", rec.req.URL.Path)
+	assert.Equal(t, "media", rec.req.URL.Query().Get("uploadType"))
+	assert.Equal(t, "nightly/report.html", rec.req.URL.Query().Get("name"))
+	assert.Equal(t, "Bearer ya29.token", rec.req.Header.Get("Authorization"))
+	assert.Equal(t, "<html>", rec.body)
+}
+
+func TestUpload_GCSMetadataToken(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"from-metadata","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer metadata.Close()
+	rec := newRecorder(t)
+	u := newUploader(nil)
+	u.gcsEndpoint = rec.server.URL
+	u.metadataURL = metadata.URL
+
+	require.NoError(t, u.Upload(context.Background(), "gs://perf/report.json", "application/json", []byte("{}")))
+	assert.Equal(t, "Bearer from-metadata", rec.req.Header.Get("Authorization"))
+
+	metadata.Close()
+	err := u.Upload(context.Background(), "gs://perf/report.json", "application/json", []byte("{}"))
+	assert.ErrorContains(t, err, "no Google credentials")
+}
+
+func TestUpload_GCSEmulator(t *testing.T) {
+	rec := newRecorder(t)
+	u := newUploader(map[string]string{"STORAGE_EMULATOR_HOST": strings.TrimPrefix(rec.server.URL, "http://")})
+
+	require.NoError(t, u.Upload(context.Background(), "gs://perf/report.json", "application/json", []byte("{}")))
+	assert.Empty(t, rec.req.Header.Get("Authorization"))
+}
+
+func TestUpload_Azure(t *testing.T) {
+	rec := newRecorder(t)
+	u := newUploader(map[string]string{"AZURE_STORAGE_SAS_TOKEN": "?sv=2021-08-06&sig=abc"})
+	u.azureEndpoint = rec.server.URL
+
+	require.NoError(t, u.Upload(context.Background(), "az://perfacct/reports/nightly/report.json", "application/json", []byte("{}")))
+	assert.Equal(t, http.MethodPut, rec.req.Method)
+	assert.Equal(t, "/perfacct/reports/nightly/report.json", rec.req.URL.Path)
+	assert.Equal(t, "abc", rec.req.URL.Query().Get("sig"))
+	assert.Equal(t, "BlockBlob", rec.req.Header.Get("x-ms-blob-type"))
+
+	u = newUploader(nil)
+	err := u.Upload(context.Background(), "az://perfacct/reports/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "AZURE_STORAGE_SAS_TOKEN must be set")
+}
+
+func TestUpload_Errors(t *testing.T) {
+	rec := newRecorder(t)
+	rec.status = http.StatusForbidden
+	u := newUploader(map[string]string{"AZURE_STORAGE_SAS_TOKEN": "sig=abc"})
+	u.azureEndpoint = rec.server.URL
+
+	err := u.Upload(context.Background(), "az://acct/reports/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "unexpected status code: 403")
+
+	// Failed requests do not show the SAS token of their URL
+	rec.server.Close()
+	err = u.Upload(context.Background(), "az://acct/reports/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "upload failed: Put \""+rec.server.URL+"/acct/reports/report.json\"")
+	assert.NotContains(t, err.Error(), "sig=abc")
+
+	err = u.Upload(context.Background(), "ftp://host/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "unsupported destination")
+	err = u.Upload(context.Background(), "s3://bucket-only", "application/json", nil)
+	assert.ErrorContains(t, err, "invalid destination")
+	err = u.Upload(context.Background(), "az://acct/report.json", "application/json", nil)
+	assert.ErrorContains(t, err, "expected az://account/container/blob")
+}
+
+func TestDestination(t *testing.T) {
+	startedAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	assert.Equal(t, "s3://perf/nightly/20261016T073000Z.json", Destination("s3://perf/nightly/{timestamp}.json", startedAt))
+	assert.Equal(t, "s3://perf/report.json", Destination("s3://perf/report.json", startedAt))
+}
@@ -0,0 +1,33 @@
+package variables
+
+import (
+	"sync"
+)
+
+// Counters provides named, thread-safe, monotonically increasing counters
+type Counters struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+// NewCounters creates a new counter set
+func NewCounters() *Counters {
+	return &Counters{
+		values: make(map[string]int64),
+	}
+}
+
+// Next increments the named counter and returns its new value (starting at 1)
+func (c *Counters) Next(name string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[name]++
+	return c.values[name]
+}
+
+// Value returns the current value of the named counter without incrementing it
+func (c *Counters) Value(name string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[name]
+}
@@ -3,6 +3,8 @@ package variables
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"sync"
 
 	"github.com/andrearaponi/bombardino/internal/models"
 	"github.com/tidwall/gjson"
@@ -10,7 +12,8 @@ import (
 
 // Extractor extracts variables from HTTP responses
 type Extractor struct {
-	store *Store
+	store    *Store
+	patterns sync.Map // regex of header rules -> *regexp.Regexp
 }
 
 // NewExtractor creates a new extractor
@@ -22,6 +25,13 @@ func NewExtractor(store *Store) *Extractor {
 
 // Extract extracts variables from a response based on the given rules
 func (e *Extractor) Extract(rules []models.ExtractionRule, body []byte, headers http.Header, statusCode int) error {
+	return e.ExtractScoped(rules, body, headers, statusCode, nil)
+}
+
+// ExtractScoped extracts variables into scope instead of the shared store,
+// so they are only visible to requests using that scope. A nil scope stores
+// them in the shared store.
+func (e *Extractor) ExtractScoped(rules []models.ExtractionRule, body []byte, headers http.Header, statusCode int, scope Scope) error {
 	for _, rule := range rules {
 		var value interface{}
 		var found bool
@@ -30,7 +40,10 @@ func (e *Extractor) Extract(rules []models.ExtractionRule, body []byte, headers
 		case "body":
 			value, found = e.extractFromBody(body, rule.Path)
 		case "header":
-			value, found = e.extractFromHeader(headers, rule.Path)
+			var err error
+			if value, found, err = e.extractFromHeader(headers, rule); err != nil {
+				return err
+			}
 		case "status":
 			value = statusCode
 			found = true
@@ -38,7 +51,21 @@ func (e *Extractor) Extract(rules []models.ExtractionRule, body []byte, headers
 			return fmt.Errorf("unknown source: %s", rule.Source)
 		}
 
-		if found {
+		if !found {
+			continue
+		}
+		if rule.Transform != "" {
+			steps, err := ParsePipeline(rule.Transform)
+			if err != nil {
+				return err
+			}
+			if value, err = ApplyPipeline(value, steps); err != nil {
+				return fmt.Errorf("transform of %s failed: %w", rule.Name, err)
+			}
+		}
+		if scope != nil {
+			scope[rule.Name] = value
+		} else {
 			e.store.Set(rule.Name, value)
 		}
 	}
@@ -79,16 +106,60 @@ func (e *Extractor) extractFromBody(body []byte, path string) (interface{}, bool
 	}
 }
 
-// extractFromHeader extracts a value from HTTP headers
-func (e *Extractor) extractFromHeader(headers http.Header, headerName string) (interface{}, bool) {
+// extractFromHeader extracts a value from HTTP headers: the value at the
+// rule's index (the first by default) of a possibly repeated header, or with
+// a regex the capture of the first value matching it
+func (e *Extractor) extractFromHeader(headers http.Header, rule models.ExtractionRule) (interface{}, bool, error) {
 	if headers == nil {
-		return nil, false
+		return nil, false, nil
 	}
 
-	value := headers.Get(headerName)
-	if value == "" {
-		return nil, false
+	values := headers.Values(rule.Path)
+	if rule.Index != nil {
+		index := *rule.Index
+		if index < 0 {
+			index += len(values)
+		}
+		if index < 0 || index >= len(values) {
+			return nil, false, nil
+		}
+		values = values[index : index+1]
 	}
 
-	return value, true
+	if rule.Regex == "" {
+		if len(values) == 0 || values[0] == "" {
+			return nil, false, nil
+		}
+		return values[0], true, nil
+	}
+
+	pattern, err := e.pattern(rule.Regex)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, value := range values {
+		match := pattern.FindStringSubmatch(value)
+		if match == nil {
+			continue
+		}
+		// The first capture group, or the whole match without groups
+		if len(match) > 1 {
+			return match[1], true, nil
+		}
+		return match[0], true, nil
+	}
+	return nil, false, nil
+}
+
+// pattern returns a compiled regex of a header rule, compiling each once
+func (e *Extractor) pattern(source string) (*regexp.Regexp, error) {
+	if cached, ok := e.patterns.Load(source); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", source, err)
+	}
+	e.patterns.Store(source, pattern)
+	return pattern, nil
 }
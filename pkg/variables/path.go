@@ -0,0 +1,65 @@
+package variables
+
+import (
+	"net/url"
+	"strings"
+)
+
+// pathPlaceholder is a {name} placeholder in a path template, spanning
+// path[start:end]
+type pathPlaceholder struct {
+	name       string
+	start, end int
+}
+
+// pathPlaceholders finds the {name} placeholders of path, leaving out the
+// braces of ${variable} references
+func pathPlaceholders(path string) []pathPlaceholder {
+	var placeholders []pathPlaceholder
+	for offset := 0; ; {
+		open := strings.IndexByte(path[offset:], '{')
+		if open < 0 {
+			return placeholders
+		}
+		open += offset
+		close := strings.IndexByte(path[open:], '}')
+		if close < 0 {
+			return placeholders
+		}
+		close += open
+		if name := path[open+1 : close]; name != "" && (open == 0 || path[open-1] != '$') {
+			placeholders = append(placeholders, pathPlaceholder{name: name, start: open, end: close + 1})
+		}
+		offset = close + 1
+	}
+}
+
+// PathPlaceholders returns the names of the {name} placeholders in path, in
+// order of appearance
+func PathPlaceholders(path string) []string {
+	var names []string
+	for _, placeholder := range pathPlaceholders(path) {
+		names = append(names, placeholder.name)
+	}
+	return names
+}
+
+// ExpandPath replaces the {name} placeholders of path with the matching
+// params, percent-encoded so values with slashes, spaces, or other reserved
+// characters stay within their path segment. Placeholders without a param
+// are left as is.
+func ExpandPath(path string, params map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, placeholder := range pathPlaceholders(path) {
+		value, ok := params[placeholder.name]
+		if !ok {
+			continue
+		}
+		b.WriteString(path[last:placeholder.start])
+		b.WriteString(url.PathEscape(value))
+		last = placeholder.end
+	}
+	b.WriteString(path[last:])
+	return b.String()
+}
@@ -1,37 +1,65 @@
 package variables
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 )
 
-// varPattern matches ${variable_name} patterns, including dotted names like ${data.username}
-var varPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_.]*)\}`)
+// varPattern matches ${variable_name} patterns, including dotted names like
+// ${data.username}, references with a default value like ${region:-eu}, and
+// transform pipelines like ${token | base64}
+var varPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_.]*)(:-[^}|]*)?\s*(\|[^}]*)?\}`)
+
+// counterPattern matches ${counter(name)} patterns for named atomic counters
+var counterPattern = regexp.MustCompile(`\$\{counter\(([a-zA-Z_][a-zA-Z0-9_.]*)\)\}`)
+
+// Scope holds request-local variables (e.g. __iteration, __vu) that take
+// precedence over the shared store. Counter values are cached in the scope so
+// every reference to the same counter within one request yields the same value.
+type Scope map[string]interface{}
 
 // Substitutor replaces variable references with their values
 type Substitutor struct {
-	store *Store
+	store    *Store
+	counters *Counters
 }
 
 // NewSubstitutor creates a new substitutor
 func NewSubstitutor(store *Store) *Substitutor {
 	return &Substitutor{
-		store: store,
+		store:    store,
+		counters: NewCounters(),
 	}
 }
 
+// Counters returns the named counters used by ${counter(name)}
+func (s *Substitutor) Counters() *Counters {
+	return s.counters
+}
+
 // Substitute replaces all ${variable} patterns in the input string
 func (s *Substitutor) Substitute(input string) string {
+	return s.SubstituteScoped(input, nil)
+}
+
+// SubstituteScoped replaces all ${variable} and ${counter(name)} patterns,
+// resolving request-local scope variables before the shared store
+func (s *Substitutor) SubstituteScoped(input string, scope Scope) string {
+	input = counterPattern.ReplaceAllStringFunc(input, func(match string) string {
+		name := counterPattern.FindStringSubmatch(match)[1]
+		return fmt.Sprintf("%d", s.counterValue(name, scope))
+	})
+
 	return varPattern.ReplaceAllStringFunc(input, func(match string) string {
-		// Extract variable name from ${name}
-		varName := match[2 : len(match)-1]
-
-		if value, ok := s.store.Get(varName); ok {
-			return s.store.GetString(varName)
-		} else {
-			// Keep original if variable not found
-			_ = value // Suppress unused warning
-			return match
+		// Extract variable name, default, and transforms from ${name:-default | transform}
+		groups := varPattern.FindStringSubmatch(match)
+
+		if value, ok := s.resolve(groups, scope); ok {
+			return fmt.Sprintf("%v", value)
 		}
+		// Keep original if variable not found
+		return match
 	})
 }
 
@@ -47,6 +75,12 @@ func (s *Substitutor) SubstituteMap(m map[string]string) map[string]string {
 // SubstituteBody substitutes variables in an arbitrary body structure
 // Supports strings, maps, and arrays recursively
 func (s *Substitutor) SubstituteBody(body interface{}) interface{} {
+	return s.SubstituteBodyScoped(body, nil)
+}
+
+// SubstituteBodyScoped substitutes variables in an arbitrary body structure
+// using request-local scope variables
+func (s *Substitutor) SubstituteBodyScoped(body interface{}, scope Scope) interface{} {
 	if body == nil {
 		return nil
 	}
@@ -55,41 +89,43 @@ func (s *Substitutor) SubstituteBody(body interface{}) interface{} {
 	case string:
 		// Check if the entire string is a single variable reference
 		// If so, return the actual value (preserving type for numbers, bools, etc.)
-		if matches := varPattern.FindStringSubmatch(v); len(matches) == 2 && matches[0] == v {
-			varName := matches[1]
-			if value, ok := s.store.Get(varName); ok {
+		if matches := varPattern.FindStringSubmatch(v); len(matches) == 4 && matches[0] == v {
+			if value, ok := s.resolve(matches, scope); ok {
 				return value
 			}
 			return v // Keep original if not found
 		}
+		if matches := counterPattern.FindStringSubmatch(v); len(matches) == 2 && matches[0] == v {
+			return s.counterValue(matches[1], scope)
+		}
 		// Otherwise do string substitution (for embedded variables)
-		return s.Substitute(v)
+		return s.SubstituteScoped(v, scope)
 
 	case map[string]interface{}:
 		result := make(map[string]interface{}, len(v))
 		for key, val := range v {
-			result[key] = s.SubstituteBody(val)
+			result[key] = s.SubstituteBodyScoped(val, scope)
 		}
 		return result
 
 	case map[string]string:
 		result := make(map[string]interface{}, len(v))
 		for key, val := range v {
-			result[key] = s.Substitute(val)
+			result[key] = s.SubstituteScoped(val, scope)
 		}
 		return result
 
 	case []interface{}:
 		result := make([]interface{}, len(v))
 		for i, val := range v {
-			result[i] = s.SubstituteBody(val)
+			result[i] = s.SubstituteBodyScoped(val, scope)
 		}
 		return result
 
 	case []string:
 		result := make([]interface{}, len(v))
 		for i, val := range v {
-			result[i] = s.Substitute(val)
+			result[i] = s.SubstituteScoped(val, scope)
 		}
 		return result
 
@@ -98,3 +134,96 @@ func (s *Substitutor) SubstituteBody(body interface{}) interface{} {
 		return v
 	}
 }
+
+// Resolve returns the value of a single ${variable} reference, with its
+// default and transforms, reporting false when it has none
+func (s *Substitutor) Resolve(reference string, scope Scope) (interface{}, bool) {
+	matches := varPattern.FindStringSubmatch(reference)
+	if matches == nil || matches[0] != reference {
+		return nil, false
+	}
+	return s.resolve(matches, scope)
+}
+
+// resolve returns the value of a reference matched by varPattern: the
+// variable, or its default when it is not set, run through its transforms.
+// It reports false when there is no value or a transform fails.
+func (s *Substitutor) resolve(groups []string, scope Scope) (interface{}, bool) {
+	name, defaultPart, pipeline := groups[1], groups[2], groups[3]
+
+	value, ok := s.lookup(name, scope)
+	if !ok && defaultPart != "" {
+		value, ok = defaultPart[len(":-"):], true
+		if pipeline != "" {
+			// Whitespace before the pipe separates it from the default
+			value = strings.TrimSpace(value.(string))
+		}
+	}
+	if !ok || pipeline == "" {
+		return value, ok
+	}
+
+	steps, err := ParsePipeline(pipeline)
+	if err != nil {
+		return nil, false
+	}
+	transformed, err := ApplyPipeline(value, steps)
+	if err != nil {
+		return nil, false
+	}
+	return transformed, true
+}
+
+// lookup resolves a variable from the scope first, then from the store
+func (s *Substitutor) lookup(name string, scope Scope) (interface{}, bool) {
+	if value, ok := scope[name]; ok {
+		return value, true
+	}
+	return s.store.Get(name)
+}
+
+// counterValue returns the value of a counter for this scope, incrementing it
+// only on the first reference within the scope
+func (s *Substitutor) counterValue(name string, scope Scope) int64 {
+	key := "counter(" + name + ")"
+	if value, ok := scope[key].(int64); ok {
+		return value
+	}
+	value := s.counters.Next(name)
+	if scope != nil {
+		scope[key] = value
+	}
+	return value
+}
+
+// References returns the names of the ${variable} references in a string or
+// an arbitrary body structure. Counter references and references with a
+// default value are not included since they always resolve.
+func References(value interface{}) []string {
+	var names []string
+	switch v := value.(type) {
+	case string:
+		for _, match := range varPattern.FindAllStringSubmatch(v, -1) {
+			if match[2] == "" {
+				names = append(names, match[1])
+			}
+		}
+	case map[string]interface{}:
+		for _, val := range v {
+			names = append(names, References(val)...)
+		}
+	case map[string]string:
+		for _, val := range v {
+			names = append(names, References(val)...)
+		}
+	case []interface{}:
+		for _, val := range v {
+			names = append(names, References(val)...)
+		}
+	case []string:
+		for _, val := range v {
+			names = append(names, References(val)...)
+		}
+	}
+	return names
+}
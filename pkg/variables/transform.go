@@ -0,0 +1,157 @@
+package variables
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// transformFunc converts a value for use in a request
+type transformFunc func(string) (string, error)
+
+// transforms are the named steps of a transform pipeline, written as
+// ${name | base64 | urlencode} or in the transform field of an extraction rule
+var transforms = map[string]transformFunc{
+	"base64": func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	},
+	"base64url": func(s string) (string, error) {
+		return base64.RawURLEncoding.EncodeToString([]byte(s)), nil
+	},
+	"base64decode": func(s string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			// Also accept unpadded and URL-safe input, e.g. JWT segments
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		}
+		return string(decoded), err
+	},
+	"urlencode": func(s string) (string, error) {
+		return url.QueryEscape(s), nil
+	},
+	"urldecode": url.QueryUnescape,
+	"upper": func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	},
+	"lower": func(s string) (string, error) {
+		return strings.ToLower(s), nil
+	},
+	"trim": func(s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	},
+	"xmlescape": func(s string) (string, error) {
+		var escaped strings.Builder
+		err := xml.EscapeText(&escaped, []byte(s))
+		return escaped.String(), err
+	},
+	"sha256": func(s string) (string, error) {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:]), nil
+	},
+}
+
+// TransformNames returns the names of the available transforms, sorted
+func TransformNames() []string {
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePipeline splits a transform pipeline such as "base64 | urlencode"
+// into its steps and checks that each one exists. Steps starting with a dot
+// select a field from a JSON value, like jq: ".user.id".
+func ParsePipeline(spec string) ([]string, error) {
+	var steps []string
+	for _, step := range strings.Split(spec, "|") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		if _, ok := transforms[step]; !ok && !strings.HasPrefix(step, ".") {
+			return nil, fmt.Errorf("unknown transform '%s' (expected %s, or a .path into JSON)", step, strings.Join(TransformNames(), ", "))
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// ApplyPipeline runs value through the steps of a parsed pipeline. Without
+// steps the value is returned unchanged, otherwise the result is a string.
+func ApplyPipeline(value interface{}, steps []string) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+
+	result := fmt.Sprintf("%v", value)
+	for _, step := range steps {
+		if strings.HasPrefix(step, ".") {
+			selected := gjson.Get(result, strings.TrimPrefix(step, "."))
+			if !selected.Exists() {
+				return nil, fmt.Errorf("%s not found", step)
+			}
+			result = selected.String()
+			continue
+		}
+
+		var err error
+		if result, err = transforms[step](result); err != nil {
+			return nil, fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	return result, nil
+}
+
+// UnknownTransforms returns the transforms used in the ${variable | ...}
+// references of a string or an arbitrary body structure that do not exist
+func UnknownTransforms(value interface{}) []string {
+	var unknown []string
+	for _, spec := range pipelines(value) {
+		for _, step := range strings.Split(spec, "|") {
+			step = strings.TrimSpace(step)
+			if _, err := ParsePipeline(step); err != nil {
+				unknown = append(unknown, step)
+			}
+		}
+	}
+	return unknown
+}
+
+// pipelines returns the transform pipelines of the references in value
+func pipelines(value interface{}) []string {
+	var specs []string
+	switch v := value.(type) {
+	case string:
+		for _, match := range varPattern.FindAllStringSubmatch(v, -1) {
+			if match[3] != "" {
+				specs = append(specs, match[3])
+			}
+		}
+	case map[string]interface{}:
+		for _, val := range v {
+			specs = append(specs, pipelines(val)...)
+		}
+	case map[string]string:
+		for _, val := range v {
+			specs = append(specs, pipelines(val)...)
+		}
+	case []interface{}:
+		for _, val := range v {
+			specs = append(specs, pipelines(val)...)
+		}
+	case []string:
+		for _, val := range v {
+			specs = append(specs, pipelines(val)...)
+		}
+	}
+	return specs
+}
@@ -178,6 +178,40 @@ func TestExtractor_ExtractFromHeader(t *testing.T) {
 	assert.Equal(t, "100", s.GetString("rate_limit"))
 }
 
+func TestExtractor_ExtractFromHeader_RegexAndIndex(t *testing.T) {
+	s := NewStore()
+	e := NewExtractor(s)
+
+	headers := http.Header{
+		"Set-Cookie": []string{"theme=dark; Path=/", "session=abc123; HttpOnly", "lang=it"},
+		"Link":       []string{`</page/2>; rel="next"`},
+	}
+	first, last, outOfRange := 0, -1, 5
+
+	rules := []models.ExtractionRule{
+		{Name: "session", Source: "header", Path: "Set-Cookie", Regex: `session=([^;]+)`},
+		{Name: "first_cookie", Source: "header", Path: "Set-Cookie", Index: &first},
+		{Name: "last_cookie", Source: "header", Path: "Set-Cookie", Index: &last},
+		{Name: "last_name", Source: "header", Path: "Set-Cookie", Index: &last, Regex: `^[a-z]+`},
+		{Name: "next", Source: "header", Path: "Link", Regex: `<([^>]+)>; rel="next"`},
+		{Name: "missing_index", Source: "header", Path: "Set-Cookie", Index: &outOfRange},
+		{Name: "no_match", Source: "header", Path: "Set-Cookie", Regex: `token=(\w+)`},
+	}
+
+	err := e.Extract(rules, nil, headers, 200)
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc123", s.GetString("session"))
+	assert.Equal(t, "theme=dark; Path=/", s.GetString("first_cookie"))
+	assert.Equal(t, "lang=it", s.GetString("last_cookie"))
+	assert.Equal(t, "lang", s.GetString("last_name"))
+	assert.Equal(t, "/page/2", s.GetString("next"))
+	_, found := s.Get("missing_index")
+	assert.False(t, found)
+	_, found = s.Get("no_match")
+	assert.False(t, found)
+}
+
 func TestExtractor_ExtractFromStatus(t *testing.T) {
 	s := NewStore()
 	e := NewExtractor(s)
@@ -250,6 +284,23 @@ func TestExtractor_InvalidSource(t *testing.T) {
 	assert.Contains(t, err.Error(), "unknown source")
 }
 
+func TestExtractor_ExtractScoped(t *testing.T) {
+	s := NewStore()
+	e := NewExtractor(s)
+	scope := Scope{}
+
+	rules := []models.ExtractionRule{
+		{Name: "auth_token", Source: "body", Path: "token"},
+	}
+
+	err := e.ExtractScoped(rules, []byte(`{"token": "loop-token"}`), nil, 200, scope)
+	require.NoError(t, err)
+
+	assert.Equal(t, "loop-token", scope["auth_token"])
+	_, ok := s.Get("auth_token")
+	assert.False(t, ok, "scoped extraction must not leak into the shared store")
+}
+
 // =============================================================================
 // Substitutor Tests
 // =============================================================================
@@ -568,3 +619,182 @@ func TestVariablesIntegration(t *testing.T) {
 	assert.Equal(t, 42, bodyMap["user_id"])
 	assert.Equal(t, "Updated Name", bodyMap["name"])
 }
+
+// =============================================================================
+// Counter and Scope Tests
+// =============================================================================
+
+func TestCounters_Next(t *testing.T) {
+	counters := NewCounters()
+
+	assert.Equal(t, int64(1), counters.Next("order_id"))
+	assert.Equal(t, int64(2), counters.Next("order_id"))
+	assert.Equal(t, int64(1), counters.Next("user_id"))
+	assert.Equal(t, int64(2), counters.Value("order_id"))
+}
+
+func TestSubstitutor_Counter(t *testing.T) {
+	store := NewStore()
+	sub := NewSubstitutor(store)
+
+	assert.Equal(t, "order-1", sub.Substitute("order-${counter(order_id)}"))
+	assert.Equal(t, "order-2", sub.Substitute("order-${counter(order_id)}"))
+
+	// Whole-string references keep the numeric type
+	assert.Equal(t, int64(3), sub.SubstituteBody("${counter(order_id)}"))
+}
+
+func TestSubstitutor_CounterStableWithinScope(t *testing.T) {
+	store := NewStore()
+	sub := NewSubstitutor(store)
+
+	scope := Scope{}
+	url := sub.SubstituteScoped("/orders/${counter(order_id)}", scope)
+	body := sub.SubstituteBodyScoped(map[string]interface{}{"id": "${counter(order_id)}"}, scope)
+
+	assert.Equal(t, "/orders/1", url)
+	assert.Equal(t, map[string]interface{}{"id": int64(1)}, body)
+
+	// A new scope (request) gets the next value
+	assert.Equal(t, "/orders/2", sub.SubstituteScoped("/orders/${counter(order_id)}", Scope{}))
+}
+
+func TestSubstitutor_ScopeTakesPrecedence(t *testing.T) {
+	store := NewStore()
+	store.Set("__vu", "global")
+	store.Set("user", "alice")
+	sub := NewSubstitutor(store)
+
+	scope := Scope{"__iteration": int64(7), "__vu": 3}
+	assert.Equal(t, "alice-7-3", sub.SubstituteScoped("${user}-${__iteration}-${__vu}", scope))
+	assert.Equal(t, 3, sub.SubstituteBodyScoped("${__vu}", scope))
+}
+
+func TestSubstitutor_DefaultValues(t *testing.T) {
+	store := NewStore()
+	store.Set("region", "us")
+	store.Set("limit", 50)
+	sub := NewSubstitutor(store)
+
+	assert.Equal(t, "/us/items?limit=50", sub.Substitute("/${region:-eu}/items?limit=${limit:-10}"))
+	assert.Equal(t, "/eu/v1", sub.Substitute("/${zone:-eu}/${version:-v1}"))
+	assert.Equal(t, "x=", sub.Substitute("x=${empty:-}"))
+	assert.Equal(t, "https://api.example.com/path", sub.Substitute("${host:-https://api.example.com/path}"))
+
+	body := sub.SubstituteBody(map[string]interface{}{
+		"limit": "${limit:-10}",
+		"page":  "${page:-1}",
+		"sort":  "${sort}",
+	})
+	assert.Equal(t, map[string]interface{}{"limit": 50, "page": "1", "sort": "${sort}"}, body)
+}
+
+func TestReferences(t *testing.T) {
+	assert.Equal(t, []string{"base", "id"}, References("${base}/users/${id}?n=${counter(n)}"))
+	assert.ElementsMatch(t, []string{"token", "data.name", "tag"}, References(map[string]interface{}{
+		"auth": "Bearer ${token}",
+		"user": map[string]interface{}{"name": "${data.name}", "age": 30},
+		"tags": []interface{}{"${tag}", "static"},
+	}))
+	assert.Nil(t, References(42))
+	assert.Equal(t, []string{"id"}, References("/${region:-eu}/users/${id}"))
+}
+
+// =============================================================================
+// Transform Tests
+// =============================================================================
+
+func TestSubstitutor_Transforms(t *testing.T) {
+	store := NewStore()
+	store.Set("credentials", "user:pass")
+	store.Set("query", "a b&c")
+	store.Set("profile", `{"user": {"id": 42, "name": "Ada"}}`)
+	sub := NewSubstitutor(store)
+
+	assert.Equal(t, "Basic dXNlcjpwYXNz", sub.Substitute("Basic ${credentials | base64}"))
+	assert.Equal(t, "/search?q=a+b%26c", sub.Substitute("/search?q=${query|urlencode}"))
+	assert.Equal(t, "ADA", sub.Substitute("${profile | .user.name | upper}"))
+	assert.Equal(t, "EU", sub.Substitute("${region:-eu | upper}"))
+	assert.Equal(t, "${credentials | shout}", sub.Substitute("${credentials | shout}"))
+	assert.Equal(t, "${profile | .missing}", sub.Substitute("${profile | .missing}"))
+
+	// A transformed value is always a string
+	body := sub.SubstituteBody(map[string]interface{}{"id": "${profile | .user.id}"})
+	assert.Equal(t, map[string]interface{}{"id": "42"}, body)
+}
+
+func TestParsePipeline(t *testing.T) {
+	steps, err := ParsePipeline(" base64 | .token |urlencode ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base64", ".token", "urlencode"}, steps)
+
+	_, err = ParsePipeline("base64 | rot13")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown transform 'rot13'")
+}
+
+func TestApplyPipeline(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		pipeline string
+		expected interface{}
+	}{
+		{"hello", "", "hello"},
+		{42, "", 42},
+		{42, "base64", "NDI="},
+		{"aGVsbG8=", "base64decode", "hello"},
+		{"eyJzdWIiOiIxMjMifQ", "base64decode | .sub", "123"},
+		{"a/b?c", "base64url", "YS9iP2M"},
+		{"a%2Fb", "urldecode", "a/b"},
+		{"  MiXeD ", "trim | lower", "mixed"},
+		{"abc", "sha256", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`Tom & "Jerry" <co>`, "xmlescape", "Tom &amp; &#34;Jerry&#34; &lt;co&gt;"},
+	}
+
+	for _, tt := range tests {
+		steps, err := ParsePipeline(tt.pipeline)
+		require.NoError(t, err)
+		result, err := ApplyPipeline(tt.value, steps)
+		require.NoError(t, err, tt.pipeline)
+		assert.Equal(t, tt.expected, result, tt.pipeline)
+	}
+
+	_, err := ApplyPipeline("not base64!", []string{"base64decode"})
+	assert.Error(t, err)
+}
+
+func TestExtractor_Transform(t *testing.T) {
+	s := NewStore()
+	e := NewExtractor(s)
+
+	rules := []models.ExtractionRule{
+		{Name: "encoded_token", Source: "body", Path: "token", Transform: "base64"},
+		{Name: "next", Source: "header", Path: "Link", Transform: "urlencode"},
+	}
+	headers := http.Header{"Link": []string{"/items?page=2"}}
+
+	err := e.Extract(rules, []byte(`{"token": "jwt"}`), headers, 200)
+	require.NoError(t, err)
+
+	assert.Equal(t, "and0", s.GetString("encoded_token"))
+	assert.Equal(t, "%2Fitems%3Fpage%3D2", s.GetString("next"))
+
+	err = e.Extract([]models.ExtractionRule{{Name: "decoded", Source: "body", Path: "token", Transform: "base64decode"}}, []byte(`{"token": "%%%"}`), nil, 200)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transform of decoded failed")
+}
+
+func TestPathPlaceholders(t *testing.T) {
+	assert.Equal(t, []string{"org", "id"}, PathPlaceholders("/orgs/{org}/users/{id}?v=${version}"))
+	assert.Nil(t, PathPlaceholders("/users/${id}"))
+	assert.Nil(t, PathPlaceholders("/users/{}"))
+}
+
+func TestExpandPath(t *testing.T) {
+	params := map[string]string{"org": "a/b", "id": "John Doe", "file": "x?y#z"}
+
+	assert.Equal(t, "/orgs/a%2Fb/users/John%20Doe", ExpandPath("/orgs/{org}/users/{id}", params))
+	assert.Equal(t, "/files/x%3Fy%23z/${id}", ExpandPath("/files/{file}/${id}", params))
+	assert.Equal(t, "/users/{name}", ExpandPath("/users/{name}", params))
+	assert.Equal(t, "/{org}", ExpandPath("/{org}", nil))
+}